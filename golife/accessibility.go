@@ -0,0 +1,194 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"log"
+	"strings"
+
+	"github.com/vegacom/mobile/ui"
+)
+
+// In accessibility mode, see cfg.accessible, the buttons of the bars are hit up to buttonSep beyond
+// their rectangles, if not further as with the compact controls, and their icons are drawn
+// accessibleScale times larger. The screen reader, if the platform has one enabled, speaks the
+// labels of the buttons pressed and lists them as controls whatever the mode, see updateControls.
+const accessibleScale = 1.35 // Up to where neighbors nearly touch.
+
+// accessibleBar applies accessibility mode, or its absence, to bar, once its buttons are added.
+func accessibleBar(bar *ui.Bar) {
+	if bar == nil {
+		return
+	}
+	bar.Slop = controls.slop
+	if cfg.accessible && bar.Slop < buttonSep {
+		bar.Slop = buttonSep
+	}
+	if cfg.accessible {
+		bar.SetScale(accessibleScale)
+	} else {
+		bar.SetScale(1)
+	}
+}
+
+// setAccessible turns accessibility mode on or off.
+func setAccessible(on bool) {
+	cfg.accessible = on
+	accessibleBar(buttonBar)
+	accessibleBar(toolBar)
+	log.Printf("accessibility mode %v", on)
+}
+
+// announceEvery is the number of generations between the announcements of the progress of the
+// game, see announceProgress.
+const announceEvery = 500
+
+// announced is the latest multiple of announceEvery the game was stepped to, see announceProgress.
+var announced int
+
+// announcer has the screen reader speak, see speak. It is announce but for tests.
+var announcer = announce
+
+// speakBroken is set once the screen reader could not be reached, which it is then not asked to
+// again.
+var speakBroken bool
+
+// speak has the screen reader speak text. Whether one is enabled is left to the platform, see
+// announce.
+func speak(text string) {
+	if speakBroken {
+		return
+	}
+	if err := announcer(text); err != nil {
+		log.Printf("speaking: %v, spoken labels disabled", err)
+		speakBroken = true
+	}
+}
+
+// buttonLabel returns the label spoken for the button of the given image, as it currently shows:
+// the string of the key "button.<image>", see text, or else the name of the image.
+func buttonLabel(img string) string {
+	img = face(img)
+	key := "button." + img
+	if _, ok := texts[key]; !ok {
+		return strings.Replace(img, "_", " ", -1)
+	}
+	if img == forwardImage {
+		return text(key, forwardGenerations)
+	}
+	return text(key)
+}
+
+// announceState has the screen reader speak that the game goes on or was paused, with its
+// generation and population then.
+func announceState() {
+	if paused {
+		speak(text("announce.paused", univ.life.Generation(), univ.life.Population()))
+	} else {
+		speak(text("announce.running"))
+	}
+}
+
+// announceProgress has the screen reader speak the generation and population of the game as it
+// runs every announceEvery generations. It is called every frame.
+func announceProgress() {
+	if univ == nil {
+		return
+	}
+	g := univ.life.Generation()
+	m := g - g%announceEvery
+	if m == announced {
+		return
+	}
+	// Stepping back, to another game or to the announcements of another mode is not progress.
+	progress := m > announced && !paused
+	announced = m
+	if progress {
+		speak(text("announce.progress", g, univ.life.Population()))
+	}
+}
+
+// A control is an enabled button of the bars as the screen reader lists it, for the user to move
+// through the buttons and activate them, see registerControls.
+type control struct {
+	rect  image.Rectangle // In pixels, from the top left corner of the screen.
+	label string
+	bar   *ui.Bar
+	name  string
+}
+
+// registered are the controls the screen reader was last handed, see updateControls.
+var registered []control
+
+// registrar hands the controls to the screen reader. It is registerControls but for tests.
+var registrar = registerControls
+
+// registerBroken is set once the controls could not be handed to the screen reader, which it then
+// is not again.
+var registerBroken bool
+
+// clicked receives, from the threads of the platform, the indexes in registered of the controls
+// the screen reader activates, see updateControls.
+var clicked = make(chan int, 16)
+
+// updateControls taps the buttons the screen reader activated, then hands it the buttons of the
+// bars whenever they, their layout or their labels changed since, so that the controls it lists
+// follow the bars. It is called every frame.
+func updateControls() {
+	for done := false; !done; {
+		select {
+		case k := <-clicked:
+			if k < 0 || k >= len(registered) {
+				continue
+			}
+			// The button is gone if its bar was rebuilt meanwhile.
+			c := registered[k]
+			if b := c.bar.Button(c.name); b != nil && !b.Disabled() {
+				log.Printf("%s activated by the screen reader", c.name)
+				tapButton(c.bar, c.name)
+			}
+		default:
+			done = true
+		}
+	}
+	if registerBroken {
+		return
+	}
+	var cs []control
+	// In the order the touches find them, see pointer.begin.
+	for _, bar := range []*ui.Bar{views.tour.bar(), views.notice.bar(), toolBar, buttonBar} {
+		if bar == nil {
+			continue
+		}
+		for _, b := range bar.Buttons {
+			if b.Disabled() {
+				continue
+			}
+			r := image.Rect(int(b.Rect.Min.X.Px()), int(b.Rect.Min.Y.Px()), int(b.Rect.Max.X.Px()), int(b.Rect.Max.Y.Px()))
+			cs = append(cs, control{rect: r, label: buttonLabel(b.Name), bar: bar, name: b.Name})
+		}
+	}
+	if sameControls(cs, registered) {
+		return
+	}
+	registered = cs
+	if err := registrar(cs); err != nil {
+		log.Printf("registering the controls: %v, virtual controls disabled", err)
+		registerBroken = true
+	}
+}
+
+// sameControls reports whether a and b list the same buttons, at the same places, with the same
+// labels.
+func sameControls(a, b []control) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if a[k] != b[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,22 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+//go:build android
+// +build android
+
+package main
+
+// The callback of the Accessibility class of the app is exported apart from bridge_android.go, as
+// the files exporting functions to C can only declare them in their preamble.
+
+import "C"
+
+// controlClicked is called by the native method Accessibility.click on the UI thread as the screen
+// reader activates the control k, and leaves it to updateControls, dropping it if too many wait.
+//
+//export controlClicked
+func controlClicked(k C.int) {
+	select {
+	case clicked <- int(k):
+	default:
+	}
+}
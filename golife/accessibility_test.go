@@ -0,0 +1,132 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/mobile/event"
+)
+
+// listen has the announcements of the screen reader appended to spoken, until the returned function
+// is called.
+func listen(spoken *[]string) func() {
+	old := announcer
+	announcer = func(text string) error {
+		*spoken = append(*spoken, text)
+		return nil
+	}
+	return func() { announcer = old }
+}
+
+func TestAnnounce(t *testing.T) {
+	gestureScene(t)
+	var spoken []string
+	defer listen(&spoken)()
+
+	// Spoken out of accessibility mode too, whether a screen reader listens being left to the
+	// platform.
+	setPaused(true)
+	if want := []string{text("announce.paused", 0, 0)}; !equalStrings(spoken, want) {
+		t.Errorf("spoken %q once paused, want %q", spoken, want)
+	}
+	spoken = nil
+	finger(1, event.TouchStart, cellLoc(4, 4))
+	finger(1, event.TouchEnd, cellLoc(4, 4))
+	// The label of the button as paused, then the change.
+	label := buttonLabel(pauseImage)
+	finger(2, event.TouchStart, buttonLoc(t, pauseImage))
+	finger(2, event.TouchEnd, buttonLoc(t, pauseImage))
+	if want := []string{label, text("announce.running")}; !equalStrings(spoken, want) {
+		t.Errorf("spoken %q once resumed, want %q", spoken, want)
+	}
+	spoken = nil
+	setPaused(true)
+	setPaused(true)
+	if want := []string{text("announce.paused", 0, 1)}; !equalStrings(spoken, want) {
+		t.Errorf("spoken %q once paused, want %q", spoken, want)
+	}
+
+	// The progress of the game is told as it runs.
+	setPaused(false)
+	spoken = nil
+	announced = 0
+	for k := 0; k < announceEvery+1; k++ {
+		univ.Step()
+		announceProgress()
+	}
+	if want := []string{text("announce.progress", announceEvery, univ.life.Population())}; !equalStrings(spoken, want) {
+		t.Errorf("spoken %q over %d generations, want %q", spoken, announceEvery+1, want)
+	}
+}
+
+func TestControls(t *testing.T) {
+	gestureScene(t)
+	defer setSelecting(false)
+	var got [][]control
+	old := registrar
+	registrar = func(cs []control) error {
+		got = append(got, cs)
+		return nil
+	}
+	defer func() { registrar, registered = old, nil }()
+	registered = nil
+	setPaused(true)
+
+	// The buttons of the bar are registered with their labels, once until they change.
+	updateControls()
+	updateControls()
+	if len(got) != 1 {
+		t.Fatalf("registered %d times, want 1", len(got))
+	}
+	find := func(cs []control, label string) int {
+		for k, c := range cs {
+			if c.label == label {
+				return k
+			}
+		}
+		return -1
+	}
+	k := find(got[0], buttonLabel(pauseImage))
+	if k < 0 {
+		t.Fatalf("no control labelled %q in %+v", buttonLabel(pauseImage), got[0])
+	}
+	r := buttonBar.Button(pauseImage).Rect
+	if c := got[0][k]; c.rect.Min.X != int(r.Min.X.Px()) || c.rect.Max.Y != int(r.Max.Y.Px()) {
+		t.Errorf("control at %v, button at %v", c.rect, r)
+	}
+
+	// Activating it taps the button, whose new label is registered.
+	clicked <- k
+	updateControls()
+	if paused {
+		t.Errorf("still paused once the control activated")
+	}
+	if len(got) != 2 || find(got[1], buttonLabel(pauseImage)) != k || buttonLabel(pauseImage) == got[0][k].label {
+		t.Errorf("registered %d times, last %+v once resumed", len(got), got[len(got)-1])
+	}
+
+	// The tool bar shown, its enabled buttons are registered too.
+	setSelecting(true)
+	updateToolBar()
+	updateControls()
+	last := got[len(got)-1]
+	for _, b := range toolBar.Buttons {
+		if k := find(last, buttonLabel(b.Name)); (k >= 0) == b.Disabled() {
+			t.Errorf("%s button disabled %v, registered %v", b.Name, b.Disabled(), k >= 0)
+		}
+	}
+}
+
+// equalStrings reports whether a and b hold the same strings, in order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if a[k] != b[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"log"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// analysisInterval is how often the analysis overlay looks for objects again.
+const analysisInterval = 250 * time.Millisecond
+
+// maxFramedObjects is the number of known objects the analysis overlay frames at most, so that
+// large fields do not flood the scene with nodes.
+const maxFramedObjects = 200
+
+// The legend of the analysis overlay has a row per known object and one for the other objects,
+// each analysisRowHeight high with the count in analysisDigits digits.
+const (
+	analysisRowHeight = 2 * debugDigitHeight
+	analysisDigits    = 4
+)
+
+// An analysisOverlay frames the known objects on the grid, see life.KnownObjects, and counts them in
+// a legend at the top-right corner of the grid, along with the other objects.
+type analysisOverlay struct {
+	nodes []*sprite.Node // Of the legend.
+	// counters count the objects of each of life.KnownObjects, then the other objects.
+	counters []*counter
+	frames   [][]*sprite.Node // Of the framed objects, see frameCells, hidden once unused.
+	tex      sprite.Texture   // Previews of the known objects.
+	since    time.Time        // Of the latest analysis.
+}
+
+// toggleAnalysis shows or hides the analysis overlay.
+func toggleAnalysis() {
+	if views.analysis != nil {
+		views.analysis.release()
+		views.analysis = nil
+	} else {
+		views.analysis = newAnalysisOverlay()
+	}
+	buttonBar.Refresh()
+}
+
+// updateAnalysis analyzes the grid again if the analysis overlay shows and it is time to. It is
+// called every frame.
+func updateAnalysis() {
+	if views.analysis != nil && time.Since(views.analysis.since) >= analysisInterval {
+		views.analysis.refresh()
+	}
+}
+
+// newAnalysisOverlay returns an analysis overlay of the current grid, nil if the previews of the
+// objects could not be loaded.
+func newAnalysisOverlay() *analysisOverlay {
+	var ps []*life.Pattern
+	for _, o := range life.KnownObjects {
+		ps = append(ps, o.Pattern)
+	}
+	tex, err := eng.LoadTexture(patternPreviews(ps))
+	if err != nil {
+		log.Printf("analysis: %v", err)
+		return nil
+	}
+	a := &analysisOverlay{tex: tex}
+	const h = analysisRowHeight
+	var (
+		n   = len(ps) + 1
+		row = geom.Pt(h + buttonSep/2)
+		pad = geom.Pt(buttonSep / 2)
+		dh  = geom.Pt(debugDigitHeight)
+		w   = h + buttonSep/2 + geom.Pt(analysisDigits)*dh*hudDigitWidth/hudDigitHeight
+		x0  = geom.Width - buttonSep - w
+		y0  = geom.Pt(buttonBarHeight + buttonSep)
+	)
+	add := func(t sprite.SubTex, x, y, w, h geom.Pt) {
+		n := &sprite.Node{}
+		eng.Register(n)
+		scene.AppendChild(n)
+		eng.SetSubTex(n, t)
+		eng.SetTransform(n, f32.Affine{{float32(w), 0, float32(x)}, {0, float32(h), float32(y)}})
+		a.nodes = append(a.nodes, n)
+	}
+	// On the left if rightToLeft, the previews right of their counts.
+	add(*textures[outOfBoundsImage], mirrorX(x0-pad, w+2*pad, screen()), y0-pad, w+2*pad, geom.Pt(n)*row-buttonSep/2+2*pad)
+	for k := 0; k < n; k++ {
+		y := y0 + geom.Pt(k)*row
+		t := *textures[androidImage]
+		if k < len(ps) {
+			t = sprite.SubTex{tex, image.Rect(k*stampPreviewSize, 0, (k+1)*stampPreviewSize, stampPreviewSize)}
+		}
+		add(t, mirrorX(x0, h, screen()), y, h, h)
+		cx := mirrorX(x0+h+buttonSep/2, w-h-buttonSep/2, screen())
+		a.counters = append(a.counters, newCounter(scene, cx, y+(h-dh)/2, dh, analysisDigits))
+	}
+	a.refresh()
+	return a
+}
+
+// refresh analyzes the grid, framing and counting its objects.
+func (a *analysisOverlay) refresh() {
+	index := make(map[string]int)
+	for k, o := range life.KnownObjects {
+		index[o.Name] = k
+	}
+	counts := make([]int, len(a.counters))
+	framed := 0
+	for _, o := range univ.life.Objects() {
+		k, ok := index[o.Name]
+		if !ok {
+			counts[len(counts)-1]++
+			continue
+		}
+		counts[k]++
+		if framed < maxFramedObjects {
+			if framed == len(a.frames) {
+				a.frames = append(a.frames, nil)
+			}
+			a.frames[framed] = frameCells(a.frames[framed], image.Rect(o.X, o.Y, o.X+o.W, o.Y+o.H))
+			framed++
+		}
+	}
+	for k := framed; k < len(a.frames); k++ {
+		frameCells(a.frames[k], image.Rectangle{})
+	}
+	for k, c := range a.counters {
+		c.set(counts[k])
+	}
+	a.since = time.Now()
+}
+
+// release removes a from the scene, unregisters its nodes and frees its texture, if a is not nil.
+func (a *analysisOverlay) release() {
+	if a == nil {
+		return
+	}
+	for _, c := range a.counters {
+		c.release()
+	}
+	for _, n := range a.nodes {
+		scene.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	for _, f := range a.frames {
+		releaseFrame(f)
+	}
+	a.tex.Unload()
+}
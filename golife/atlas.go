@@ -0,0 +1,115 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"sort"
+)
+
+// atlasManifest is the asset telling where each button image is in the buttonsImage atlas. It is
+// a JSON object giving the number of columns of the grid the atlas is laid out on, whose cells are
+// square, and the rectangle of each image in cells, its max corner excluded, for instance:
+//
+//	{
+//		"columns": 4,
+//		"images": {
+//			"pause": [0, 0, 1, 1],
+//			"play": [1, 0, 2, 1]
+//		}
+//	}
+const atlasManifest = "buttons.json"
+
+// atlasImages are the images of the buttons atlas. Without a usable manifest they are assumed laid
+// out in this order, row by row on a grid of atlasColumns, as in the fallback atlas.
+var atlasImages = []string{
+	pauseImage, playImage, stepImage, backImage,
+	decSpeedImage, incSpeedImage, replayImage, patternImage,
+	ruleImage, edgesImage, boundedImage, reflectImage,
+	agesImage, exportImage, recordImage, stopImage,
+	themeImage, settingsImage, seedImage, symmetryImage,
+	soundImage, muteImage, selectImage, copyImage,
+	cutImage, pasteImage, rotateImage, flipImage,
+	undoImage, redoImage, growImage, stableImage,
+	periodImage, shareImage, versusImage, wonImage,
+	lostImage, searchImage, trailImage, cellBrushImage,
+	blockBrushImage, lineBrushImage, sprayBrushImage, forwardImage,
+	hapticsImage, accessImage, analyzeImage, nudgeLeftImage,
+	nudgeRightImage, nudgeUpImage, nudgeDownImage, fitImage,
+	localeImage, largeImage, ringImage, dotImage,
+	compactImage, showcaseImage, mutationImage, profileImage,
+	followImage, wallImage, liveWallImage,
+}
+
+const atlasColumns = 4
+
+// atlasRects returns the rectangles of the images of the buttons atlas, which occupies r in its
+// texture. If the manifest cannot be used, the default layout is returned.
+func atlasRects(r image.Rectangle) map[string]image.Rectangle {
+	a, err := openAsset(atlasManifest)
+	if err == nil {
+		defer a.Close()
+		var rects map[string]image.Rectangle
+		if rects, err = parseAtlas(a, r); err == nil {
+			return rects
+		}
+	}
+	log.Printf("%s: %v; using the default layout", atlasManifest, err)
+	rects := make(map[string]image.Rectangle)
+	unit := r.Dx() / atlasColumns
+	for k, name := range atlasImages {
+		x, y := r.Min.X+k%atlasColumns*unit, r.Min.Y+k/atlasColumns*unit
+		rects[name] = image.Rect(x, y, x+unit, y+unit)
+	}
+	return rects
+}
+
+// parseAtlas parses the atlas manifest read from r for an atlas occupying bounds and returns the
+// rectangles of its images in px. Every image of atlasImages must be present, and the rectangles
+// must be inside bounds and must not overlap.
+func parseAtlas(r io.Reader, bounds image.Rectangle) (map[string]image.Rectangle, error) {
+	var m struct {
+		Columns int
+		Images  map[string][4]int
+	}
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+	if m.Columns <= 0 || bounds.Dx()%m.Columns != 0 {
+		return nil, fmt.Errorf("%d columns do not divide the %v atlas", m.Columns, bounds)
+	}
+	unit := bounds.Dx() / m.Columns
+
+	var names []string
+	for name := range m.Images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rects := make(map[string]image.Rectangle)
+	for _, name := range names {
+		c := m.Images[name]
+		if c[0] >= c[2] || c[1] >= c[3] {
+			return nil, fmt.Errorf("empty rectangle %v for %q", c, name)
+		}
+		rr := image.Rect(c[0]*unit, c[1]*unit, c[2]*unit, c[3]*unit).Add(bounds.Min)
+		if !rr.In(bounds) {
+			return nil, fmt.Errorf("rectangle %v of %q outside the %v atlas", rr, name, bounds)
+		}
+		for _, other := range names {
+			if o, ok := rects[other]; ok && o.Overlaps(rr) {
+				return nil, fmt.Errorf("rectangles of %q and %q overlap", other, name)
+			}
+		}
+		rects[name] = rr
+	}
+	for _, name := range atlasImages {
+		if _, ok := rects[name]; !ok {
+			return nil, fmt.Errorf("missing image %q", name)
+		}
+	}
+	return rects, nil
+}
@@ -0,0 +1,106 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"os"
+	"strings"
+	"testing"
+)
+
+// atlasRows is the number of rows of the default layout of the atlas, and atlasBounds the bounds of
+// a 48px atlas below a row of other images in its texture.
+var (
+	atlasRows   = (len(atlasImages) + 3) / 4
+	atlasBounds = image.Rect(0, 48, 4*48, 48+atlasRows*48)
+)
+
+// testManifest returns the manifest of the default layout of the atlas, edited by edit.
+func testManifest(t *testing.T, edit func(images map[string][4]int)) string {
+	images := make(map[string][4]int)
+	for k, name := range atlasImages {
+		x, y := k%atlasColumns, k/atlasColumns
+		images[name] = [4]int{x, y, x + 1, y + 1}
+	}
+	edit(images)
+	b, err := json.Marshal(map[string]interface{}{"columns": atlasColumns, "images": images})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestParseAtlas(t *testing.T) {
+	f, err := os.Open("assets/" + atlasManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rects, err := parseAtlas(f, atlasBounds)
+	if err != nil {
+		t.Fatalf("bundled manifest: %v", err)
+	}
+	// The bundled manifest is the default layout.
+	if len(rects) != len(atlasImages) {
+		t.Errorf("%d images in the bundled manifest, want %d", len(rects), len(atlasImages))
+	}
+	for k, name := range atlasImages {
+		x, y := k%atlasColumns*48, 48+k/atlasColumns*48
+		if r := rects[name]; r != image.Rect(x, y, x+48, y+48) {
+			t.Errorf("%q at %v, want %v", name, r, image.Rect(x, y, x+48, y+48))
+		}
+	}
+
+	// Images may span several cells of the grid, and extra ones are kept.
+	m := testManifest(t, func(images map[string][4]int) {
+		images["logo"] = [4]int{0, atlasRows, 4, atlasRows + 2}
+	})
+	rects, err = parseAtlas(strings.NewReader(m), image.Rect(0, 48, 4*48, 48+(atlasRows+2)*48))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := rects["logo"]; r != image.Rect(0, 48+atlasRows*48, 4*48, 48+(atlasRows+2)*48) {
+		t.Errorf("logo at %v", r)
+	}
+}
+
+func TestParseAtlasInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name, manifest string
+		err            string // Part of the error message.
+	}{
+		{"not JSON", "columns: 4", "invalid"},
+		{"no columns", `{"images": {}}`, "columns"},
+		{"negative columns", `{"columns": -4, "images": {}}`, "columns"},
+		{"columns not dividing", `{"columns": 5, "images": {}}`, "columns"},
+		{"overlap", testManifest(t, func(images map[string][4]int) {
+			images[playImage] = [4]int{0, 0, 2, 1}
+		}), "overlap"},
+		{"right of the atlas", testManifest(t, func(images map[string][4]int) {
+			images[playImage] = [4]int{4, 0, 5, 1}
+		}), "outside"},
+		{"below the atlas", testManifest(t, func(images map[string][4]int) {
+			images[playImage] = [4]int{0, atlasRows, 1, atlasRows + 1}
+		}), "outside"},
+		{"above the atlas", testManifest(t, func(images map[string][4]int) {
+			images[playImage] = [4]int{0, -1, 1, 0}
+		}), "outside"},
+		{"empty rectangle", testManifest(t, func(images map[string][4]int) {
+			images[playImage] = [4]int{1, 0, 1, 1}
+		}), "empty"},
+		{"missing image", testManifest(t, func(images map[string][4]int) {
+			delete(images, analyzeImage)
+		}), "missing"},
+	} {
+		_, err := parseAtlas(strings.NewReader(test.manifest), atlasBounds)
+		if err == nil {
+			t.Errorf("%s: parsed", test.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %q, want one about %q", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// bannerTime is how long the banner shows while the game runs. Paused, it shows until dismissed.
+const bannerTime = 4 * time.Second
+
+// bannerImages are the images of the buttons of the banner, from left to right: keeping the game
+// running, replaying it from its seed and seeding a random one. Each dismisses the banner.
+var bannerImages = []string{playImage, replayImage, seedImage}
+
+// A banner reports, over the top of the grid, that the game stabilized: after a block, the
+// generation its cycle started at, after a ring, the period of the cycle, after arrows, how far its
+// cells move each period if they drift, and after a cell, the population. Its buttons are below.
+type banner struct {
+	generation, period, population int
+	drift                          image.Point
+	end                            time.Time
+	nodes                          []*sprite.Node // Nil until shown by draw.
+	counters                       []*counter
+	buttons                        *ui.Bar
+}
+
+// showBanner shows the banner telling the game entered a cycle of the given period at generation,
+// moving by drift each period, with a population of population cells. The banner is only built by
+// the next frame, as it may be told from the arranger of the scene.
+func showBanner(generation, period int, drift image.Point, population int) {
+	views.notice.release()
+	views.notice = &banner{
+		generation: generation,
+		period:     period,
+		drift:      drift,
+		population: population,
+		end:        time.Now().Add(bannerTime),
+	}
+}
+
+// updateBanner builds the banner if told since the last frame, or hides it once shown long enough
+// while the game runs.
+func updateBanner() {
+	b := views.notice
+	switch {
+	case b == nil:
+	case time.Now().After(b.end) && !paused:
+		b.release()
+		views.notice = nil
+	case b.nodes == nil:
+		b.build()
+	}
+}
+
+// build adds the nodes of b to the scene, centered below the button bar, and its buttons below
+// them.
+func (b *banner) build() {
+	type figure struct {
+		img   string
+		value int
+	}
+	figures := []figure{{stableImage, b.generation}, {periodImage, b.period}}
+	switch {
+	case b.drift.X > 0:
+		figures = append(figures, figure{nudgeRightImage, b.drift.X})
+	case b.drift.X < 0:
+		figures = append(figures, figure{nudgeLeftImage, -b.drift.X})
+	}
+	switch {
+	case b.drift.Y > 0:
+		figures = append(figures, figure{nudgeDownImage, b.drift.Y})
+	case b.drift.Y < 0:
+		figures = append(figures, figure{nudgeUpImage, -b.drift.Y})
+	}
+	figures = append(figures, figure{androidImage, b.population})
+
+	// Each figure is an image followed by its digits, 2*pad apart, pad from the edges.
+	h, pad := buttonSize, geom.Pt(2)
+	var w geom.Pt
+	for _, f := range figures {
+		w += h + geom.Pt(len(strconv.Itoa(f.value)))*hudDigitWidth + 2*pad
+	}
+	if bw := geom.Pt(len(bannerImages))*(h+buttonSep) - buttonSep + 2*pad; w < bw {
+		w = bw
+	}
+	x, y := (geom.Width-w)/2, geom.Pt(buttonBarHeight+buttonSep)
+	add := func(img string, x, y, w, h geom.Pt) {
+		n := &sprite.Node{}
+		eng.Register(n)
+		scene.AppendChild(n)
+		eng.SetTransform(n, f32.Affine{{float32(w), 0, float32(x)}, {0, float32(h), float32(y)}})
+		eng.SetSubTex(n, *textures[img])
+		b.nodes = append(b.nodes, n)
+	}
+	add(outOfBoundsImage, x, y, w, 2*h+2*pad)
+	box := geom.Rectangle{Min: geom.Point{X: x, Y: y}, Max: geom.Point{X: x + w, Y: y + 2*h + 2*pad}}
+	fx := x + pad
+	for _, f := range figures {
+		add(f.img, mirrorX(fx, h, box), y, h, h)
+		d := len(strconv.Itoa(f.value))
+		dw := geom.Pt(d) * hudDigitWidth
+		c := newCounter(scene, mirrorX(fx+h, dw, box), y+(h-hudDigitHeight)/2, hudDigitHeight, d)
+		c.set(f.value)
+		b.counters = append(b.counters, c)
+		fx += h + dw + 2*pad
+	}
+
+	// The scene is below the system bar.
+	b.buttons = ui.NewBar(eng, scene, geom.Point{Y: systemBarHeight}, buttonFace)
+	for k, r := range mirrorAll(ui.Row(len(bannerImages), h, buttonSep, systemBarHeight+y+h+pad), screen()) {
+		b.buttons.Add(bannerImages[k], r)
+	}
+	// Only random universes have a seed to replay.
+	b.buttons.Button(replayImage).SetDisabled(soupSeed == 0)
+	accessibleBar(b.buttons)
+}
+
+// bar returns the bar of the buttons of b, nil if b is nil or not yet shown.
+func (b *banner) bar() *ui.Bar {
+	if b == nil {
+		return nil
+	}
+	return b.buttons
+}
+
+// tap acts on a tap on the button of b of the given image, see bannerImages, and dismisses b.
+func (b *banner) tap(img string) {
+	b.release()
+	views.notice = nil
+	switch img {
+	case replayImage:
+		univ.reseed(soupSeed)
+	case seedImage:
+		univ.reseed(rand.Int63n(maxSoupSeed) + 1)
+	}
+	setPaused(false)
+	sounds.click()
+	pulse(tapPulse)
+}
+
+// release removes b, if shown, from the scene and unregisters its nodes.
+func (b *banner) release() {
+	if b == nil {
+		return
+	}
+	for _, n := range b.nodes {
+		scene.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	for _, c := range b.counters {
+		c.release()
+	}
+	b.buttons.Release()
+	b.nodes, b.counters, b.buttons = nil, nil, nil
+}
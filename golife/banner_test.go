@@ -0,0 +1,97 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+// bannerLoc returns the absolute location of the center of the button of the banner of the given
+// image.
+func bannerLoc(t *testing.T, img string) geom.Point {
+	b := views.notice.bar().Button(img)
+	if b == nil {
+		t.Fatalf("no %s button on the banner", img)
+	}
+	return geom.Point{X: (b.Rect.Min.X + b.Rect.Max.X) / 2, Y: (b.Rect.Min.Y + b.Rect.Max.Y) / 2}
+}
+
+func TestBanner(t *testing.T) {
+	gestureScene(t)
+	soupSeed = 0
+	univ.life.Edges = life.Wrap
+	univ.life.SetCells([][2]int{{11, 20}, {12, 21}, {10, 22}, {11, 22}, {12, 22}}, true)
+	univ.edited()
+
+	// A glider stagnates once told moving diagonally by a cell every 4 generations.
+	var (
+		period, gen int
+		drift       image.Point
+	)
+	for k := 0; k < 20 && period == 0; k++ {
+		if univ.Step() {
+			var ok bool
+			if period, drift, ok = univ.history.stagnates(univ.life); ok {
+				gen = univ.life.Generation() - period
+			}
+		}
+	}
+	if period != 4 || drift != image.Pt(1, 1) {
+		t.Fatalf("glider stagnating with period %d, drift %v, want 4 and (1,1)", period, drift)
+	}
+	if _, _, ok := univ.history.stagnates(univ.life); ok {
+		t.Errorf("stagnation told twice")
+	}
+
+	// The banner tells the generation, the period, both axes of the drift and the population, and
+	// stays while paused.
+	setPaused(true)
+	showBanner(gen, period, drift, univ.life.Population())
+	updateBanner()
+	if len(views.notice.counters) != 5 || views.notice.bar() == nil {
+		t.Fatalf("banner of %d figures, buttons %v, want 5 figures and buttons", len(views.notice.counters), views.notice.bar() != nil)
+	}
+	if !views.notice.bar().Button(replayImage).Disabled() {
+		t.Errorf("replay enabled without a seed")
+	}
+	views.notice.end = views.notice.end.Add(-2 * bannerTime)
+	updateBanner()
+	if views.notice == nil {
+		t.Fatalf("banner hidden while paused")
+	}
+
+	// Keeping the game running dismisses the banner and resumes the same game.
+	cells := alive()
+	finger(1, event.TouchStart, bannerLoc(t, playImage))
+	finger(1, event.TouchEnd, bannerLoc(t, playImage))
+	if views.notice != nil || paused || len(alive()) != len(cells) || alive()[0] != cells[0] {
+		t.Errorf("run on: banner %v, paused %v, cells %v, want %v", views.notice != nil, paused, alive(), cells)
+	}
+
+	// Seeding starts a random game, which can then be replayed.
+	setPaused(true)
+	showBanner(gen, period, drift, univ.life.Population())
+	updateBanner()
+	finger(1, event.TouchStart, bannerLoc(t, seedImage))
+	finger(1, event.TouchEnd, bannerLoc(t, seedImage))
+	if views.notice != nil || paused || soupSeed == 0 || univ.life.Generation() != 0 {
+		t.Errorf("reseed: banner %v, paused %v, seed %d, generation %d", views.notice != nil, paused, soupSeed, univ.life.Generation())
+	}
+	seeded := alive()
+	univ.Step()
+	showBanner(1, 1, image.Point{}, univ.life.Population())
+	updateBanner()
+	if len(views.notice.counters) != 3 {
+		t.Errorf("banner of %d figures without drift, want 3", len(views.notice.counters))
+	}
+	finger(1, event.TouchStart, bannerLoc(t, replayImage))
+	finger(1, event.TouchEnd, bannerLoc(t, replayImage))
+	if got := alive(); views.notice != nil || len(got) != len(seeded) || univ.life.Generation() != 0 {
+		t.Errorf("replay: banner %v, %d cells at generation %d, want %d at 0", views.notice != nil, len(got), univ.life.Generation(), len(seeded))
+	}
+}
@@ -0,0 +1,396 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+//go:build android
+// +build android
+
+package main
+
+/*
+#include <jni.h>
+#include <stdlib.h>
+#include <string.h>
+
+// Set by the app package as the NativeActivity is created.
+extern JavaVM* current_vm;
+extern jobject current_ctx;
+
+// attach returns the JNI environment of the calling thread, attaching it to the VM if needed, in
+// which case it sets *attached for detach. It returns NULL on failure.
+static JNIEnv* attach(int* attached) {
+	JNIEnv* env;
+	*attached = 0;
+	if ((*current_vm)->GetEnv(current_vm, (void**)&env, JNI_VERSION_1_6) == JNI_OK) {
+		return env;
+	}
+	if ((*current_vm)->AttachCurrentThread(current_vm, &env, NULL) != JNI_OK) {
+		return NULL;
+	}
+	*attached = 1;
+	return env;
+}
+
+// detach clears any pending exception, reporting it by returning -1, and detaches the calling
+// thread from the VM if attach attached it. It returns ret otherwise.
+static int detach(JNIEnv* env, int attached, int ret) {
+	if ((*env)->ExceptionCheck(env)) {
+		(*env)->ExceptionClear(env);
+		ret = -1;
+	}
+	if (attached) {
+		(*current_vm)->DetachCurrentThread(current_vm);
+	}
+	return ret;
+}
+
+// systemService returns a local reference to the system service of the given name, NULL if none.
+static jobject systemService(JNIEnv* env, const char* name) {
+	jclass ctx = (*env)->GetObjectClass(env, current_ctx);
+	jmethodID get = (*env)->GetMethodID(env, ctx, "getSystemService", "(Ljava/lang/String;)Ljava/lang/Object;");
+	jstring s = (*env)->NewStringUTF(env, name);
+	jobject service = (*env)->CallObjectMethod(env, current_ctx, get, s);
+	(*env)->DeleteLocalRef(env, s);
+	(*env)->DeleteLocalRef(env, ctx);
+	if ((*env)->ExceptionCheck(env)) {
+		return NULL;
+	}
+	return service;
+}
+
+// vibrate asks the Vibrator system service to vibrate for ms milliseconds, and returns 0 unless
+// it failed. The VIBRATE permission is required.
+static int vibrate(jlong ms) {
+	int attached;
+	JNIEnv* env = attach(&attached);
+	if (env == NULL) {
+		return -1;
+	}
+	int ret = -1;
+	jobject vibrator = systemService(env, "vibrator");
+	if (vibrator != NULL) {
+		jclass cls = (*env)->GetObjectClass(env, vibrator);
+		jmethodID vib = (*env)->GetMethodID(env, cls, "vibrate", "(J)V");
+		if (vib != NULL) {
+			(*env)->CallVoidMethod(env, vibrator, vib, ms);
+			ret = 0;
+		}
+		(*env)->DeleteLocalRef(env, cls);
+		(*env)->DeleteLocalRef(env, vibrator);
+	}
+	return detach(env, attached, ret);
+}
+
+// announce has the screen reader, TalkBack, speak text, if enabled, and returns 0 unless it failed.
+// The NativeActivity has no views for TalkBack to read, hence an announcement event.
+static int announce(const char* text) {
+	int attached;
+	JNIEnv* env = attach(&attached);
+	if (env == NULL) {
+		return -1;
+	}
+	int ret = -1;
+	jobject manager = systemService(env, "accessibility");
+	if (manager != NULL) {
+		jclass cls = (*env)->GetObjectClass(env, manager);
+		jmethodID enabled = (*env)->GetMethodID(env, cls, "isEnabled", "()Z");
+		jmethodID send = enabled == NULL ? NULL : (*env)->GetMethodID(env, cls, "sendAccessibilityEvent",
+			"(Landroid/view/accessibility/AccessibilityEvent;)V");
+		jboolean on = enabled != NULL && send != NULL && (*env)->CallBooleanMethod(env, manager, enabled);
+		if (!on && !(*env)->ExceptionCheck(env)) {
+			// No screen reader to speak.
+			ret = 0;
+		}
+		jclass ev = on ? (*env)->FindClass(env, "android/view/accessibility/AccessibilityEvent") : NULL;
+		if (ev != NULL) {
+			jmethodID obtain = (*env)->GetStaticMethodID(env, ev, "obtain", "(I)Landroid/view/accessibility/AccessibilityEvent;");
+			jmethodID getText = obtain != NULL ? (*env)->GetMethodID(env, ev, "getText", "()Ljava/util/List;") : NULL;
+			// AccessibilityEvent.TYPE_ANNOUNCEMENT.
+			jobject e = getText != NULL ? (*env)->CallStaticObjectMethod(env, ev, obtain, 0x4000) : NULL;
+			jobject list = e != NULL ? (*env)->CallObjectMethod(env, e, getText) : NULL;
+			if (list != NULL) {
+				jclass lc = (*env)->GetObjectClass(env, list);
+				jmethodID add = (*env)->GetMethodID(env, lc, "add", "(Ljava/lang/Object;)Z");
+				jstring s = add != NULL ? (*env)->NewStringUTF(env, text) : NULL;
+				if (s != NULL) {
+					(*env)->CallBooleanMethod(env, list, add, s);
+					if (!(*env)->ExceptionCheck(env)) {
+						(*env)->CallVoidMethod(env, manager, send, e);
+						ret = 0;
+					}
+					(*env)->DeleteLocalRef(env, s);
+				}
+				(*env)->DeleteLocalRef(env, lc);
+				(*env)->DeleteLocalRef(env, list);
+			}
+			if (e != NULL) {
+				(*env)->DeleteLocalRef(env, e);
+			}
+			(*env)->DeleteLocalRef(env, ev);
+		}
+		(*env)->DeleteLocalRef(env, cls);
+		(*env)->DeleteLocalRef(env, manager);
+	}
+	return detach(env, attached, ret);
+}
+
+// controlClicked is exported by accessibility_android.go.
+extern void controlClicked(int k);
+
+// click implements the native method Accessibility.click.
+static void JNICALL click(JNIEnv* env, jclass cls, jint k) {
+	controlClicked(k);
+}
+
+// accessibility is a global reference to the Accessibility class of the app, see accessibilityClass.
+static jclass accessibility = NULL;
+
+// accessibilityClass returns the Accessibility class of the app, its native method registered, NULL
+// on failure. The classes of the app are loaded by the class loader of the activity, as FindClass
+// only finds the classes of the system on the threads attached from native code.
+static jclass accessibilityClass(JNIEnv* env) {
+	if (accessibility != NULL) {
+		return accessibility;
+	}
+	jclass ctx = (*env)->GetObjectClass(env, current_ctx);
+	jmethodID getLoader = (*env)->GetMethodID(env, ctx, "getClassLoader", "()Ljava/lang/ClassLoader;");
+	jobject loader = getLoader != NULL ? (*env)->CallObjectMethod(env, current_ctx, getLoader) : NULL;
+	if (loader != NULL) {
+		jclass lc = (*env)->GetObjectClass(env, loader);
+		jmethodID load = (*env)->GetMethodID(env, lc, "loadClass", "(Ljava/lang/String;)Ljava/lang/Class;");
+		jstring name = load != NULL ? (*env)->NewStringUTF(env, "com.example.golife.Accessibility") : NULL;
+		jclass cls = name != NULL ? (jclass)(*env)->CallObjectMethod(env, loader, load, name) : NULL;
+		if (cls != NULL) {
+			JNINativeMethod m = {"click", "(I)V", (void*)click};
+			if ((*env)->RegisterNatives(env, cls, &m, 1) == 0) {
+				accessibility = (jclass)(*env)->NewGlobalRef(env, cls);
+			}
+			(*env)->DeleteLocalRef(env, cls);
+		}
+		if (name != NULL) {
+			(*env)->DeleteLocalRef(env, name);
+		}
+		(*env)->DeleteLocalRef(env, lc);
+		(*env)->DeleteLocalRef(env, loader);
+	}
+	(*env)->DeleteLocalRef(env, ctx);
+	return accessibility;
+}
+
+// registerControls hands the n controls to the Accessibility class of the app, their rectangles as
+// left, top, right and bottom in rects and their labels one per line in labels, and returns 0
+// unless it failed.
+static int registerControls(jint* rects, int n, const char* labels) {
+	int attached;
+	JNIEnv* env = attach(&attached);
+	if (env == NULL) {
+		return -1;
+	}
+	int ret = -1;
+	jclass cls = accessibilityClass(env);
+	jmethodID set = cls != NULL ? (*env)->GetStaticMethodID(env, cls, "setControls",
+		"(Landroid/app/Activity;[ILjava/lang/String;)V") : NULL;
+	jintArray r = set != NULL ? (*env)->NewIntArray(env, 4 * n) : NULL;
+	if (r != NULL) {
+		(*env)->SetIntArrayRegion(env, r, 0, 4 * n, rects);
+		jstring s = (*env)->NewStringUTF(env, labels);
+		if (s != NULL) {
+			(*env)->CallStaticVoidMethod(env, cls, set, current_ctx, r, s);
+			ret = 0;
+			(*env)->DeleteLocalRef(env, s);
+		}
+		(*env)->DeleteLocalRef(env, r);
+	}
+	return detach(env, attached, ret);
+}
+
+// locale writes to buf, n bytes long, the name of the default locale of the VM, for instance
+// "es_MX", and returns 0 unless it failed.
+static int locale(char* buf, int n) {
+	int attached;
+	JNIEnv* env = attach(&attached);
+	if (env == NULL) {
+		return -1;
+	}
+	int ret = -1;
+	jclass cls = (*env)->FindClass(env, "java/util/Locale");
+	if (cls != NULL) {
+		jmethodID getDefault = (*env)->GetStaticMethodID(env, cls, "getDefault", "()Ljava/util/Locale;");
+		jmethodID toString = (*env)->GetMethodID(env, cls, "toString", "()Ljava/lang/String;");
+		if (getDefault != NULL && toString != NULL) {
+			jobject l = (*env)->CallStaticObjectMethod(env, cls, getDefault);
+			jstring s = (jstring)(*env)->CallObjectMethod(env, l, toString);
+			if (s != NULL) {
+				const char* c = (*env)->GetStringUTFChars(env, s, NULL);
+				if (c != NULL) {
+					strncpy(buf, c, n - 1);
+					buf[n - 1] = 0;
+					(*env)->ReleaseStringUTFChars(env, s, c);
+					ret = 0;
+				}
+				(*env)->DeleteLocalRef(env, s);
+			}
+			(*env)->DeleteLocalRef(env, l);
+		}
+		(*env)->DeleteLocalRef(env, cls);
+	}
+	return detach(env, attached, ret);
+}
+
+// filesDir writes to buf, n bytes long, the absolute path of the directory of the private files of
+// the app, and returns 0 unless it failed.
+static int filesDir(char* buf, int n) {
+	int attached;
+	JNIEnv* env = attach(&attached);
+	if (env == NULL) {
+		return -1;
+	}
+	int ret = -1;
+	jclass ctx = (*env)->GetObjectClass(env, current_ctx);
+	jmethodID getFilesDir = (*env)->GetMethodID(env, ctx, "getFilesDir", "()Ljava/io/File;");
+	jobject dir = getFilesDir != NULL ? (*env)->CallObjectMethod(env, current_ctx, getFilesDir) : NULL;
+	if (dir != NULL) {
+		jclass file = (*env)->GetObjectClass(env, dir);
+		jmethodID getPath = (*env)->GetMethodID(env, file, "getAbsolutePath", "()Ljava/lang/String;");
+		jstring s = getPath != NULL ? (jstring)(*env)->CallObjectMethod(env, dir, getPath) : NULL;
+		if (s != NULL) {
+			const char* c = (*env)->GetStringUTFChars(env, s, NULL);
+			if (c != NULL && strlen(c) < (size_t)n) {
+				strcpy(buf, c);
+				ret = 0;
+			}
+			if (c != NULL) {
+				(*env)->ReleaseStringUTFChars(env, s, c);
+			}
+			(*env)->DeleteLocalRef(env, s);
+		}
+		(*env)->DeleteLocalRef(env, file);
+		(*env)->DeleteLocalRef(env, dir);
+	}
+	(*env)->DeleteLocalRef(env, ctx);
+	return detach(env, attached, ret);
+}
+
+// intentData returns a copy of the data URI of the intent the activity was started with, to be
+// freed, NULL if none or on failure.
+static char* intentData() {
+	int attached;
+	JNIEnv* env = attach(&attached);
+	if (env == NULL) {
+		return NULL;
+	}
+	char* ret = NULL;
+	jclass ctx = (*env)->GetObjectClass(env, current_ctx);
+	jmethodID getIntent = (*env)->GetMethodID(env, ctx, "getIntent", "()Landroid/content/Intent;");
+	jobject intent = getIntent != NULL ? (*env)->CallObjectMethod(env, current_ctx, getIntent) : NULL;
+	if (intent != NULL) {
+		jclass cls = (*env)->GetObjectClass(env, intent);
+		jmethodID getData = (*env)->GetMethodID(env, cls, "getDataString", "()Ljava/lang/String;");
+		jstring s = getData != NULL ? (jstring)(*env)->CallObjectMethod(env, intent, getData) : NULL;
+		if (s != NULL) {
+			const char* c = (*env)->GetStringUTFChars(env, s, NULL);
+			if (c != NULL) {
+				ret = strdup(c);
+				(*env)->ReleaseStringUTFChars(env, s, c);
+			}
+			(*env)->DeleteLocalRef(env, s);
+		}
+		(*env)->DeleteLocalRef(env, cls);
+		(*env)->DeleteLocalRef(env, intent);
+	}
+	(*env)->DeleteLocalRef(env, ctx);
+	if (detach(env, attached, 0) != 0) {
+		free(ret);
+		ret = NULL;
+	}
+	return ret;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// The platform features x/mobile has no API for are reached through JNI.
+
+// vibrate vibrates the device for d.
+func vibrate(d time.Duration) error {
+	if C.vibrate(C.jlong(d/time.Millisecond)) != 0 {
+		return errors.New("no vibrator, or the VIBRATE permission is missing")
+	}
+	return nil
+}
+
+// announce has the screen reader speak text, if one is enabled.
+func announce(text string) error {
+	s := C.CString(text)
+	defer C.free(unsafe.Pointer(s))
+	if C.announce(s) != 0 {
+		return errors.New("no accessibility manager")
+	}
+	return nil
+}
+
+// registerControls hands the controls to the screen reader, which lists them as virtual views of
+// the window of the activity, see Accessibility.java.
+func registerControls(cs []control) error {
+	rects := make([]C.jint, 4*len(cs)+1) // One more, so that there is a first one.
+	labels := make([]string, len(cs))
+	for k, c := range cs {
+		rects[4*k], rects[4*k+1] = C.jint(c.rect.Min.X), C.jint(c.rect.Min.Y)
+		rects[4*k+2], rects[4*k+3] = C.jint(c.rect.Max.X), C.jint(c.rect.Max.Y)
+		labels[k] = strings.Replace(c.label, "\n", " ", -1)
+	}
+	s := C.CString(strings.Join(labels, "\n"))
+	defer C.free(unsafe.Pointer(s))
+	if C.registerControls(&rects[0], C.int(len(cs)), s) != 0 {
+		return errors.New("no Accessibility class")
+	}
+	return nil
+}
+
+// systemLocale returns the locale the user picked in the system settings, for instance "es_MX".
+func systemLocale() (string, error) {
+	var buf [32]C.char
+	if C.locale(&buf[0], C.int(len(buf))) != 0 {
+		return "", errors.New("no default locale")
+	}
+	return C.GoString(&buf[0]), nil
+}
+
+// appDir returns the directory of the private files of the app, kept until the app is uninstalled,
+// unlike the cache directory os.TempDir is on Android. It is the temporary directory if unknown.
+func appDir() string {
+	appDirOnce.Do(func() {
+		var buf [512]C.char
+		if C.filesDir(&buf[0], C.int(len(buf))) != 0 {
+			log.Printf("no directory of the private files, using %s", os.TempDir())
+			appDirPath = os.TempDir()
+			return
+		}
+		appDirPath = C.GoString(&buf[0])
+	})
+	return appDirPath
+}
+
+// The directory of the private files, asked once.
+var (
+	appDirOnce sync.Once
+	appDirPath string
+)
+
+// launchLink returns the link the app was opened with through the golife:// intent filter of the
+// manifest, empty if none.
+func launchLink() string {
+	s := C.intentData()
+	if s == nil {
+		return ""
+	}
+	defer C.free(unsafe.Pointer(s))
+	return C.GoString(s)
+}
@@ -0,0 +1,58 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+//go:build !android
+// +build !android
+
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+// The desktop has none of the platform features reached through JNI on Android.
+
+// vibrate does nothing, as the desktop has no vibrator.
+func vibrate(d time.Duration) error {
+	return nil
+}
+
+// announce does nothing, as the app does not reach the screen readers of the desktop.
+func announce(text string) error {
+	return nil
+}
+
+// registerControls does nothing, as the app does not reach the screen readers of the desktop.
+func registerControls(cs []control) error {
+	return nil
+}
+
+// systemLocale returns the locale of the environment, as POSIX systems tell it, for instance
+// "es_MX.UTF-8".
+func systemLocale() (string, error) {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if l := os.Getenv(v); l != "" {
+			return l, nil
+		}
+	}
+	return "", errors.New("no locale in the environment")
+}
+
+// appDir returns the directory of the private files of the app: the temporary directory, as the
+// desktop builds are for development.
+func appDir() string {
+	return os.TempDir()
+}
+
+// launchLink returns the first argument of the command line that is a golife:// link, as the
+// desktops open links with them, empty if none.
+func launchLink() string {
+	for _, a := range os.Args[1:] {
+		if strings.HasPrefix(a, "golife://") {
+			return a
+		}
+	}
+	return ""
+}
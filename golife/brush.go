@@ -0,0 +1,156 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"math/rand"
+)
+
+// A brush tells which cells a stroke paints, alive or dead, around the cells it goes through.
+type brush int
+
+const (
+	cellBrush  brush = iota // The cells gone through.
+	blockBrush              // The 3x3 blocks centered on them.
+	// lineBrush paints the straight line from the cell the stroke started on to the one under the
+	// finger, which follows the finger until lifted.
+	lineBrush
+	sprayBrush // Random cells around them, see sprayRadius.
+)
+
+// brushImages are the images of the brushes, shown by the brush button.
+var brushImages = []string{
+	cellBrush:  cellBrushImage,
+	blockBrush: blockBrushImage,
+	lineBrush:  lineBrushImage,
+	sprayBrush: sprayBrushImage,
+}
+
+// The spray brush paints the cells closer than sprayRadius cells to the ones gone through, each
+// alive with a probability falling as the finger speeds up, as an airbrush: maxSprayDensity while
+// the finger lingers, down to minSprayDensity once it moves at sprayFastSpeed Pt/s or more.
+const (
+	sprayRadius     = 3
+	maxSprayDensity = 0.3
+	minSprayDensity = 0.05
+	sprayFastSpeed  = 1000
+)
+
+// sprayDensity returns the probability of the spray brush painting a cell for a finger moving at
+// speed Pt/s.
+func sprayDensity(speed float64) float64 {
+	if speed >= sprayFastSpeed {
+		return minSprayDensity
+	}
+	return maxSprayDensity - (maxSprayDensity-minSprayDensity)*speed/sprayFastSpeed
+}
+
+// currentBrush is the brush of the strokes, picked by the brush button of the tool bar while
+// paused. During a match, strokes seed single cells whatever the brush.
+var currentBrush brush
+
+// nextBrush makes the brush following the current one the current one.
+func nextBrush() {
+	currentBrush = (currentBrush + 1) % brush(len(brushImages))
+	toolBar.Refresh()
+	log.Printf("brush %s", brushImages[currentBrush])
+}
+
+// cells returns the cells b paints for the cell (i, j) gone through, some possibly outside the
+// field. The spray brush draws them from rnd, each with probability density. The line brush paints
+// single cells, the line being drawn by drawLine.
+func (b brush) cells(i, j int, rnd *rand.Rand, density float64) [][2]int {
+	var cells [][2]int
+	switch b {
+	case blockBrush:
+		for dj := -1; dj <= 1; dj++ {
+			for di := -1; di <= 1; di++ {
+				cells = append(cells, [2]int{i + di, j + dj})
+			}
+		}
+	case sprayBrush:
+		const r = sprayRadius
+		for dj := -r + 1; dj < r; dj++ {
+			for di := -r + 1; di < r; di++ {
+				if di*di+dj*dj < r*r && rnd.Float64() < density {
+					cells = append(cells, [2]int{i + di, j + dj})
+				}
+			}
+		}
+	default:
+		cells = [][2]int{{i, j}}
+	}
+	return cells
+}
+
+// paintCells sets alive the dead ones of cells, clipped to the field, as painted by s, or dead the
+// alive ones if s erases, and repaints them. Pinned cells are left as they are, unless s paints
+// walls, see paintWalls.
+func (u *universe) paintCells(s *stroke, cells [][2]int) {
+	if s.wall {
+		u.paintWalls(s, cells)
+		return
+	}
+	var changed [][2]int
+	for _, c := range cells {
+		if c[0] >= 0 && c[0] < u.cols && c[1] >= 0 && c[1] < u.rows && u.life.Alive(c[0], c[1]) == s.erase &&
+			!u.life.Pinned(c[0], c[1]) {
+			changed = append(changed, c)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	if s.edited {
+		u.life.SetCells(changed, !s.erase)
+	} else {
+		// A single edit, the cells painted next going on with it.
+		u.transact(s.label(), func() { u.life.SetCells(changed, !s.erase) })
+		s.edited = true
+	}
+	u.edited()
+	for _, c := range changed {
+		u.show(c[0], c[1], !s.erase)
+	}
+	s.drawn = append(s.drawn, changed...)
+}
+
+// label returns the label of the edit of s, see universe.transact.
+func (s *stroke) label() string {
+	switch {
+	case s.wall && s.erase:
+		return text("edit.unwall")
+	case s.wall:
+		return text("edit.wall")
+	case s.erase:
+		return text("edit.erase")
+	}
+	return text("edit.paint")
+}
+
+// drawLine paints for s, whose brush is the line brush, the line from the cell it started on to
+// (i, j), in place of the line painted so far.
+func (u *universe) drawLine(s *stroke, i, j int) {
+	if i == s.i && j == s.j {
+		return
+	}
+	u.revertStroke(s)
+	var cells [][2]int
+	line(s.i0, s.j0, i, j, func(i, j int) { cells = append(cells, [2]int{i, j}) })
+	u.paintCells(s, cells)
+}
+
+// revertStroke kills the cells painted by s, or revives those it erased, and repaints them. The
+// edit is left to the caller.
+func (u *universe) revertStroke(s *stroke) {
+	if s.wall {
+		u.revertWalls(s)
+		return
+	}
+	u.life.SetCells(s.drawn, s.erase)
+	for _, c := range s.drawn {
+		u.show(c[0], c[1], s.erase)
+	}
+	s.drawn = nil
+}
@@ -0,0 +1,68 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/mobile/event"
+)
+
+func TestSprayBrush(t *testing.T) {
+	// The cells sprayed only depend on the seed of the universe.
+	spray := func(seed int64, density float64) [][2]int {
+		rnd := rand.New(rand.NewSource(seed))
+		var cells [][2]int
+		for k := 0; k < 1000; k++ {
+			cells = append(cells, sprayBrush.cells(10, 10, rnd, density)...)
+		}
+		return cells
+	}
+	if a, b := spray(1, 0.2), spray(1, 0.2); fmt.Sprint(a) != fmt.Sprint(b) {
+		t.Errorf("same seed sprayed different cells")
+	}
+	for _, c := range spray(2, 1) {
+		if di, dj := c[0]-10, c[1]-10; di*di+dj*dj >= sprayRadius*sprayRadius {
+			t.Fatalf("sprayed %v, out of the radius", c)
+		}
+	}
+
+	// The faster the finger, the fewer the cells.
+	last := 2.0
+	for _, speed := range []float64{0, 100, 500, sprayFastSpeed, 10 * sprayFastSpeed} {
+		d := sprayDensity(speed)
+		if d > last || d < minSprayDensity || d > maxSprayDensity {
+			t.Errorf("density %v at %v Pt/s, after %v", d, speed, last)
+		}
+		last = d
+	}
+	slow, fast := len(spray(3, sprayDensity(0))), len(spray(3, sprayDensity(sprayFastSpeed)))
+	if fast*2 > slow {
+		t.Errorf("%d cells sprayed by a fast finger, %d by a lingering one", fast, slow)
+	}
+}
+
+func TestSpraySeed(t *testing.T) {
+	gestureScene(t)
+	defer func(b brush) { currentBrush = b }(currentBrush)
+	currentBrush = sprayBrush
+	setPaused(true)
+	// Sprayed on the same soup, the same strokes paint the same cells.
+	spray := func(seed int64) string {
+		univ.reseed(seed)
+		univ.life.SetCells(alive(), false)
+		for _, c := range [][2]int{{4, 4}, {12, 4}, {4, 12}} {
+			finger(1, event.TouchStart, cellLoc(c[0], c[1]))
+			finger(1, event.TouchEnd, cellLoc(c[0], c[1]))
+		}
+		return fmt.Sprint(alive())
+	}
+	if a, b := spray(7), spray(7); a != b || a == "[]" {
+		t.Errorf("sprayed %s, then %s on the same soup", a, b)
+	}
+	if spray(7) == spray(8) {
+		t.Errorf("same cells sprayed on another soup")
+	}
+}
@@ -0,0 +1,173 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"log"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// A comparison plays the game under two rules side by side, in lockstep from the same cells, to
+// compare them. A long press on the rule button starts one from the middle of the current game,
+// under the rule of the universe in the first half of the screen and the next rule of the rule
+// button in the second, which the rule button then cycles. The halves split the universe across
+// its longer side. A tap on a half, or next to it, ends the comparison, the universe going on
+// with the game of that half.
+//
+// Meanwhile, as during a match, the universe shows the games, the cells of either in a color of
+// its own and the cells alive in one game only in a third, unless cfg.compareDiff is off.
+type comparison struct {
+	start   *life.Life // The cells both games started from.
+	games   [2]*life.Life
+	stacked bool // Whether the halves are one above the other.
+	// due is the number of generations due since the last ones were shown, fractions included.
+	due float64
+}
+
+// compare is the current comparison, nil if none.
+var compare *comparison
+
+// startComparison starts comparing the rule of the universe with the next one.
+func startComparison() {
+	u := univ
+	c := &comparison{stacked: u.rows > u.cols}
+	w, h := u.cols/2, u.rows
+	if c.stacked {
+		w, h = u.cols, u.rows/2
+	}
+	c.start = u.life.Clone()
+	if cw, ch := c.start.Bounds(); cw != w || ch != h {
+		c.start.Resize(w, h, (w-cw)/2, (h-ch)/2)
+	}
+	c.restart(u.life.Rule, nextRule(u.life.Rule))
+	setSelecting(false)
+	u.life.Colors = 3
+	u.edits.clear()
+	spark.clear()
+	compare = c
+	c.mirror()
+	buttonBar.Refresh()
+}
+
+// restart has c compare the rules a and b again from the start.
+func (c *comparison) restart(a, b life.Rule) {
+	for k, r := range []life.Rule{a, b} {
+		l := c.start.Clone()
+		l.Rule = r
+		c.games[k] = l
+	}
+	c.due = 0
+	log.Printf("comparing %v with %v", a, b)
+}
+
+// cycleRule has c compare the rule of the first game with the next rule of the rule button after
+// the one of the second, skipping the first, again from the start.
+func (c *comparison) cycleRule() {
+	a, b := c.games[0].Rule, nextRule(c.games[1].Rule)
+	if b == a {
+		b = nextRule(b)
+	}
+	c.restart(a, b)
+	c.mirror()
+}
+
+// half returns the cells of the universe showing the game k, centered on its half.
+func (c *comparison) half(k int) image.Rectangle {
+	u := univ
+	w, h := c.games[k].Bounds()
+	x0, y0 := k*u.cols/2+(u.cols/2-w)/2, (u.rows-h)/2
+	if c.stacked {
+		x0, y0 = (u.cols-w)/2, k*u.rows/2+(u.rows/2-h)/2
+	}
+	return image.Rect(x0, y0, x0+w, y0+h)
+}
+
+// arrange steps the games at the current speed and shows them. It is called by the arranger of the
+// scene instead of stepping the universe, unless paused.
+func (c *comparison) arrange(elapsed float64) {
+	c.due += elapsed * speed / 60
+	if c.due > maxCatchUp {
+		c.due = maxCatchUp
+	}
+	if c.due < 1 {
+		return
+	}
+	for ; c.due >= 1; c.due-- {
+		for _, l := range c.games {
+			l.Step()
+		}
+	}
+	c.mirror()
+}
+
+// mirror copies the games to their halves of the universe, clipped if they do not fit, and paints
+// it.
+func (c *comparison) mirror() {
+	u := univ
+	halves := [2]image.Rectangle{c.half(0), c.half(1)}
+	for j := 0; j < u.rows; j++ {
+		for i := 0; i < u.cols; i++ {
+			alive, color := false, 0
+			for k, r := range halves {
+				if p := image.Pt(i, j); p.In(r) {
+					q := p.Sub(r.Min)
+					alive, color = c.games[k].Alive(q.X, q.Y), k
+					if alive && cfg.compareDiff && !c.games[1-k].Alive(q.X, q.Y) {
+						color = 2
+					}
+				}
+			}
+			u.life.Set(i, j, alive)
+			if alive {
+				u.life.SetColor(i, j, color)
+			}
+		}
+	}
+	u.paint()
+}
+
+// nearest returns the game whose half is nearest to the cell (i, j), the halves of a universe of an
+// odd number of cells across its split leaving a row or column out.
+func (c *comparison) nearest(i, j int) int {
+	k, best := 0, -1
+	for h := range c.games {
+		r := c.half(h)
+		dx, dy := 0, 0
+		switch {
+		case i < r.Min.X:
+			dx = r.Min.X - i
+		case i >= r.Max.X:
+			dx = i - r.Max.X + 1
+		}
+		switch {
+		case j < r.Min.Y:
+			dy = r.Min.Y - j
+		case j >= r.Max.Y:
+			dy = j - r.Max.Y + 1
+		}
+		if d := dx + dy; best < 0 || d < best {
+			k, best = h, d
+		}
+	}
+	return k
+}
+
+// pick ends c, the universe going on with the game of the half nearest to the cell (i, j).
+func (c *comparison) pick(i, j int) {
+	k := c.nearest(i, j)
+	compare = nil
+	u, l := univ, c.games[k]
+	if w, h := l.Bounds(); w != u.cols || h != u.rows {
+		l.Resize(u.cols, u.rows, (u.cols-w)/2, (u.rows-h)/2)
+	}
+	session, touchLog = nil, nil
+	u.adopt(l)
+	u.history.clear()
+	u.timeline.clear()
+	spark.clear()
+	u.due = 0
+	buttonBar.Refresh()
+	log.Printf("comparison: going on with %v", l.Rule)
+}
@@ -0,0 +1,103 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+// compareScene returns a universe with a rule button, under Life without death, whose next rule
+// is Seeds, with a lone cell in the middle, which only the former keeps.
+func compareScene(t *testing.T) {
+	gestureScene(t)
+	cfg.buttons = append(cfg.buttons, ruleImage)
+	layout()
+	setPaused(true)
+	var err error
+	if univ.life.Rule, err = life.ParseRule(rules[2]); err != nil {
+		t.Fatal(err)
+	}
+	univ.life.Set(univ.cols/2, univ.rows/2, true)
+}
+
+func TestComparison(t *testing.T) {
+	compareScene(t)
+
+	// A long press on the rule button starts comparing it with the next one.
+	finger(1, event.TouchStart, buttonLoc(t, ruleImage))
+	pointers[1].start = time.Now().Add(-longPress)
+	holdPointers()
+	finger(1, event.TouchEnd, buttonLoc(t, ruleImage))
+	c := compare
+	if c == nil {
+		t.Fatalf("no comparison once the rule button was held")
+	}
+	if got := c.games[1].Rule.String(); got != rules[3] || c.games[0].Rule.String() != rules[2] {
+		t.Errorf("comparing %v with %v, want %s with %s", c.games[0].Rule, got, rules[2], rules[3])
+	}
+	if !c.stacked || c.games[0].Population() != 1 || c.games[1].Population() != 1 || len(alive()) != 2 {
+		t.Errorf("comparison started with %d and %d cells, %d shown", c.games[0].Population(), c.games[1].Population(),
+			len(alive()))
+	}
+
+	// The games step in lockstep, the cell alive in one game only tinted.
+	c.arrange(1.5 * 60 / speed)
+	if g0, g1 := c.games[0].Generation(), c.games[1].Generation(); g0 != 1 || g1 != 1 {
+		t.Errorf("comparison stepped to generations %d and %d, want 1", g0, g1)
+	}
+	cells := alive()
+	if len(cells) != 1 || univ.life.Color(cells[0][0], cells[0][1]) != 2 {
+		t.Errorf("comparison showing %v", cells)
+	}
+	cfg.compareDiff = false
+	c.mirror()
+	if univ.life.Color(cells[0][0], cells[0][1]) != 0 {
+		t.Errorf("cell alive in the first game only tinted %d without the diff", univ.life.Color(cells[0][0], cells[0][1]))
+	}
+
+	// The rule button cycles the second rule, from the start.
+	finger(2, event.TouchStart, buttonLoc(t, ruleImage))
+	finger(2, event.TouchEnd, buttonLoc(t, ruleImage))
+	if got := c.games[1].Rule.String(); got != rules[4] || c.games[1].Generation() != 0 {
+		t.Errorf("rule button cycled to %s at generation %d, want %s from the start", got, c.games[1].Generation(), rules[4])
+	}
+
+	// A tap on the first half goes on with its game.
+	r := c.half(0)
+	finger(3, event.TouchStart, cellLoc(r.Min.X, r.Min.Y))
+	finger(3, event.TouchEnd, cellLoc(r.Min.X, r.Min.Y))
+	if compare != nil {
+		t.Fatalf("comparison going on once a half was tapped")
+	}
+	if got := univ.life.Rule.String(); got != rules[2] || len(alive()) != 1 || univ.life.Colors != cfg.colors {
+		t.Errorf("universe going on under %s with %d cells of %d colors", got, len(alive()), univ.life.Colors)
+	}
+}
+
+func TestComparisonGap(t *testing.T) {
+	compareScene(t)
+	// The halves of a universe of an odd number of rows leave the last one out.
+	if univ.rows%2 == 0 {
+		geom.Height += cellSize
+		layout()
+	}
+	startComparison()
+	c := compare
+	if r := c.half(1); !c.stacked || r.Max.Y != univ.rows-1 {
+		t.Fatalf("second half %v of a universe of %d rows", r, univ.rows)
+	}
+	// A tap on it picks the nearest half.
+	finger(1, event.TouchStart, cellLoc(0, univ.rows-1))
+	finger(1, event.TouchEnd, cellLoc(0, univ.rows-1))
+	if compare != nil {
+		t.Fatalf("comparison going on once the row between the halves was tapped")
+	}
+	if got := univ.life.Rule.String(); got != rules[3] {
+		t.Errorf("universe going on under %s, want the rule of the nearest half %s", got, rules[3])
+	}
+}
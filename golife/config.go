@@ -0,0 +1,714 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/golife/netplay"
+	"golang.org/x/mobile/geom"
+)
+
+// configAsset is the optional manifest that customized builds ship to override the built-in
+// defaults. It is a JSON object with any of the keys in configFields, for instance:
+//
+//	{
+//		"cellSize": 12,
+//		"speed": 20,
+//		"density": 0.4,
+//		"buttons": ["pause", "replay"],
+//		"edges": "bounded",
+//		"rule": "B36/S23",
+//		"seamEcho": true
+//	}
+const configAsset = "config.json"
+
+// A config holds the startup defaults of the app.
+type config struct {
+	cellSize geom.Pt       // Side of a cell, in Pt.
+	speed    float64       // Initial speed, see speed.
+	density  float64       // Probability of a cell being alive in a random universe.
+	buttons  []string      // Images of the buttons in the button bar, from left to right.
+	edges    life.EdgeMode // Edge mode of the universe.
+	rule     life.Rule     // Rule of the universe.
+	seamEcho bool          // Whether to show the cells across the seams of the torus.
+	maxCells int           // Budget of cells in the universe, each costing a sprite node.
+	history  int           // Number of past generations kept to step back.
+	// exportScale is the side in px of a cell in the exported snapshots.
+	exportScale int
+	seedMode    life.SeedMode // How random universes lay out their cells.
+	recordEvery int           // Number of generations per frame of the recordings.
+	theme       int           // Index in themes of the initial theme.
+	seed        int64         // Seed of the random universes, 0 for a random one each time.
+	sound       bool          // Whether to play sounds, see mixer.
+	ticks       bool          // Whether steps tick, if sounds are played.
+	// parallelCells is the size of the smallest field stepped concurrently, see
+	// life.Life.ParallelCells.
+	parallelCells int
+	// frameRender draws the cells as a single texture rather than a node each, see frame. The
+	// budget of cells is then ignored.
+	frameRender bool
+	colors      int  // Number of colors of the cells, see variantColors.
+	sparkline   bool // Whether to graph the population of the latest generations, see sparkline.
+	autoPause   bool // Whether to pause once the game stagnates, see history.
+	pauseMenus  bool // Whether to pause while a panel is open, see holdPanel.
+	follow      bool // Whether the camera follows the action, see follower.
+	clearPins   bool // Whether clearing the field unpins its cells too, see universe.place.
+	cellSprite  int  // Sprite of the alive cells, see cellSpriteNames.
+	compareDiff bool // Whether the cells alive in one game of a comparison only are tinted, see comparison.
+	// drift is the number of cells the field of the torus scrolls by per generation, along x then y,
+	// zero for none, see universe.drift.
+	drift [2]float64
+	// link is the pattern placed alone on launch and replay, in place of the saved game and random
+	// universes, nil if none, and run the game started paused instead, see shareRun. The manifest
+	// gives either as a golife:// link, see share, replaced by the link the app is opened with.
+	link *life.Pattern
+	run  *life.Run
+	// versus tells how to play matches against another device, see versusMatch.
+	versus versusConfig
+	// searchGenerations is the number of generations each soup of a search is played for, see
+	// soupSearch, and searchBy the criterion the soups found are ranked by, one of
+	// life.SoupOrders.
+	searchGenerations int
+	searchBy          string
+	trail             int // Number of generations the cells that just died take to fade out, see trail.
+	// resample tells whether picking a cell size from the settings scales the game to the new
+	// grid, rather than cropping or padding it, see universe.resample.
+	resample bool
+	haptics  bool // Whether button taps and stamps vibrate the device, see pulse.
+	// accessible turns accessibility mode on, with larger buttons whose labels are spoken, see
+	// accessibleBar.
+	accessible bool
+	// large turns the large controls on, larger and in high contrast, see scaleControls.
+	large bool
+	// compact turns the compact controls on, smaller, unless the large controls are on, see
+	// compactControls.
+	compact bool
+	// locale is the locale of the strings told the user, see loadStrings, empty for the one of the
+	// system.
+	locale string
+	// packURL is the http or https URL of the pattern pack the pattern picker downloads, see
+	// startPack, empty for none.
+	packURL string
+	// tutorialDone tells whether the first-run tutorial was completed or skipped, see
+	// startTutorial.
+	tutorialDone bool
+	// showcase starts the showcase on launch, for kiosks, see startShowcase.
+	showcase bool
+	// console opens the debug console on launch, see openConsole.
+	console bool
+	// mutation turns the mutation of the game on: mutationCells random cells flip every
+	// mutationEvery generations, see life.Mutation.
+	mutation                     bool
+	mutationEvery, mutationCells int
+	// profileSections are the sections of the profiles imported that are taken, see
+	// profile.merge, and keepSettings the settings kept as they are.
+	profileSections, keepSettings []string
+	// startup is the source of the universe on launch, see startupSource, and startupName the
+	// pattern or export it names, if any.
+	startup, startupName string
+	// screensaver reseeds the game cfg.screensaverDelay seconds after it stagnates, in place of
+	// pausing it, see scheduleReseed.
+	screensaver      bool
+	screensaverDelay int
+	// limiter tells when to limit the speed of the game on slow devices, see speedLimiter.
+	limiter limiterConfig
+}
+
+// A versusConfig tells how to play matches against another device. The manifest gives it as an
+// object with any of the keys host, join, generations and budget.
+type versusConfig struct {
+	host        string // Address to listen on for a guest, if not joining a host.
+	join        string // Address of the host to join, empty to host matches.
+	generations int    // Length of the hosted matches.
+	budget      int    // Number of cells each player seeds in the hosted matches.
+}
+
+// edgeModeNames are the names of the edge modes in the config manifest.
+var edgeModeNames = []string{life.Wrap: "wrap", life.Bounded: "bounded", life.Reflect: "reflect", life.Grow: "grow"}
+
+// variantColors are the numbers of colors of the variants of Life in the config manifest, see
+// life.Life.Colors.
+var variantColors = map[string]int{"life": 1, "immigration": 2, "quadlife": 4}
+
+// defaultConfig returns the built-in defaults.
+func defaultConfig() config {
+	return config{
+		cellSize: 8,
+		speed:    initialSpeed,
+		density:  0.25,
+		buttons: []string{pauseImage, backImage, stepImage, forwardImage, decSpeedImage, incSpeedImage,
+			replayImage, patternImage, selectImage, agesImage, exportImage, recordImage, soundImage, settingsImage},
+		maxCells:          40000,
+		history:           64,
+		exportScale:       4,
+		recordEvery:       1,
+		rule:              life.Conway,
+		sound:             true,
+		haptics:           true,
+		sparkline:         true,
+		autoPause:         true,
+		pauseMenus:        true,
+		compareDiff:       true,
+		versus:            versusConfig{host: fmt.Sprintf(":%d", netplay.Port), generations: 500, budget: 40},
+		searchGenerations: 1000,
+		searchBy:          "lifespan",
+		mutationEvery:     50,
+		mutationCells:     8,
+		profileSections:   []string{settingsSection, patternsSection},
+		startup:           autosaveStartup,
+		screensaverDelay:  5,
+		limiter:           limiterConfig{below: 0.7, headroom: 20, window: 2, sustain: 2},
+	}
+}
+
+// configFields maps each manifest key to the function that validates its value and stores it in c.
+var configFields = map[string]func(c *config, raw json.RawMessage) error{
+	"cellSize": func(c *config, raw json.RawMessage) error {
+		var v float32
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 2 || v > 64 {
+			return fmt.Errorf("%v out of range [2, 64]", v)
+		}
+		c.cellSize = geom.Pt(v)
+		return nil
+	},
+	"speed": func(c *config, raw json.RawMessage) error {
+		var v float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < minSpeed || v > maxSpeed {
+			return fmt.Errorf("%v out of range [%v, %v]", v, minSpeed, maxSpeed)
+		}
+		c.speed = v
+		return nil
+	},
+	// renderEvery is the former speed setting, a number of frames per generation at 60 fps.
+	"renderEvery": func(c *config, raw json.RawMessage) error {
+		var v uint32
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 1 || v > 60 {
+			return fmt.Errorf("%d out of range [1, 60]", v)
+		}
+		c.speed = 60 / float64(v)
+		return nil
+	},
+	"density": func(c *config, raw json.RawMessage) error {
+		var v float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 0 || v > 1 {
+			return fmt.Errorf("%v out of range [0, 1]", v)
+		}
+		c.density = v
+		return nil
+	},
+	"buttons": func(c *config, raw json.RawMessage) error {
+		var v []string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		seen := make(map[string]bool)
+		for _, img := range v {
+			switch img {
+			case pauseImage, backImage, stepImage, decSpeedImage, incSpeedImage, replayImage, patternImage, ruleImage,
+				edgesImage, agesImage, exportImage, recordImage, themeImage, settingsImage, soundImage,
+				selectImage, shareImage, versusImage, searchImage, forwardImage, analyzeImage, fitImage,
+				showcaseImage, profileImage:
+			default:
+				return fmt.Errorf("unknown button %q", img)
+			}
+			if seen[img] {
+				return fmt.Errorf("duplicated button %q", img)
+			}
+			seen[img] = true
+		}
+		c.buttons = v
+		return nil
+	},
+	"edges": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		for mode, name := range edgeModeNames {
+			if name == v {
+				c.edges = life.EdgeMode(mode)
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown edge mode %q", v)
+	},
+	"rule": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		r, err := life.ParseRule(v)
+		if err != nil {
+			return err
+		}
+		c.rule = r
+		return nil
+	},
+	"seamEcho": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.seamEcho)
+	},
+	"sound": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.sound)
+	},
+	"ticks": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.ticks)
+	},
+	"autoPause": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.autoPause)
+	},
+	"screensaver": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.screensaver)
+	},
+	"screensaverDelay": func(c *config, raw json.RawMessage) error {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 1 || v > 600 {
+			return fmt.Errorf("%d out of range [1, 600]", v)
+		}
+		c.screensaverDelay = v
+		return nil
+	},
+	"sparkline": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.sparkline)
+	},
+	"pauseMenus": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.pauseMenus)
+	},
+	"follow": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.follow)
+	},
+	"clearPins": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.clearPins)
+	},
+	"compareDiff": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.compareDiff)
+	},
+	"cellSprite": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		for k, name := range cellSpriteNames {
+			if name == v {
+				c.cellSprite = k
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown cell sprite %q", v)
+	},
+	"drift": func(c *config, raw json.RawMessage) error {
+		var v [2]float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		for _, d := range v {
+			if d < -1 || d > 1 {
+				return fmt.Errorf("%v out of range [-1, 1]", d)
+			}
+		}
+		c.drift = v
+		return nil
+	},
+	"accessible": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.accessible)
+	},
+	"large": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.large)
+	},
+	"compact": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.compact)
+	},
+	"showcase": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.showcase)
+	},
+	"console": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.console)
+	},
+	"mutation": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.mutation)
+	},
+	"tutorialDone": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.tutorialDone)
+	},
+	"locale": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v != "" && !isLocale(v) {
+			return fmt.Errorf("invalid locale %q", v)
+		}
+		c.locale = v
+		return nil
+	},
+	"packURL": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if u, err := url.Parse(v); v != "" && (err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "") {
+			return fmt.Errorf("invalid pack URL %q", v)
+		}
+		c.packURL = v
+		return nil
+	},
+	"haptics": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.haptics)
+	},
+	"resample": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.resample)
+	},
+	"link": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		p, r, err := parseLink(v)
+		if err != nil {
+			return err
+		}
+		c.link, c.run = p, r
+		return nil
+	},
+	"versus": func(c *config, raw json.RawMessage) error {
+		var v struct {
+			Host, Join          string
+			Generations, Budget int
+		}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v.Host != "" {
+			c.versus.host = v.Host
+		}
+		c.versus.join = v.Join
+		if v.Generations != 0 {
+			if v.Generations < 1 || v.Generations > 100000 {
+				return fmt.Errorf("generations %d out of range [1, 100000]", v.Generations)
+			}
+			c.versus.generations = v.Generations
+		}
+		if v.Budget != 0 {
+			if v.Budget < 1 || v.Budget > 10000 {
+				return fmt.Errorf("budget %d out of range [1, 10000]", v.Budget)
+			}
+			c.versus.budget = v.Budget
+		}
+		return nil
+	},
+	"speedLimit": func(c *config, raw json.RawMessage) error {
+		var v struct {
+			Off                       bool
+			Below                     float64
+			Headroom, Window, Sustain int
+		}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		l := c.limiter
+		l.off = v.Off
+		if v.Below != 0 {
+			if v.Below < 0 || v.Below >= 1 {
+				return fmt.Errorf("below %v out of range (0, 1)", v.Below)
+			}
+			l.below = v.Below
+		}
+		if v.Headroom != 0 {
+			if v.Headroom < 1 || v.Headroom > 1000 {
+				return fmt.Errorf("headroom %d out of range [1, 1000]", v.Headroom)
+			}
+			l.headroom = v.Headroom
+		}
+		if v.Window != 0 {
+			if v.Window < 1 || v.Window > 60 {
+				return fmt.Errorf("window %d out of range [1, 60]", v.Window)
+			}
+			l.window = v.Window
+		}
+		if v.Sustain != 0 {
+			if v.Sustain < 1 || v.Sustain > 100 {
+				return fmt.Errorf("sustain %d out of range [1, 100]", v.Sustain)
+			}
+			l.sustain = v.Sustain
+		}
+		c.limiter = l
+		return nil
+	},
+	"trail": func(c *config, raw json.RawMessage) error {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 0 || v > maxTrail {
+			return fmt.Errorf("%d out of range [0, %d]", v, maxTrail)
+		}
+		c.trail = v
+		return nil
+	},
+	"startup": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		switch v {
+		case randomStartup, autosaveStartup, patternStartup, exportStartup, dailyStartup:
+		default:
+			return fmt.Errorf("unknown startup %q", v)
+		}
+		c.startup = v
+		return nil
+	},
+	"startupName": func(c *config, raw json.RawMessage) error {
+		return json.Unmarshal(raw, &c.startupName)
+	},
+	"profileSections": func(c *config, raw json.RawMessage) error {
+		var v []string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		for _, s := range v {
+			if s != settingsSection && s != patternsSection {
+				return fmt.Errorf("unknown section %q", s)
+			}
+		}
+		c.profileSections = v
+		return nil
+	},
+	"keepSettings": func(c *config, raw json.RawMessage) error {
+		var v []string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		for _, k := range v {
+			if _, ok := settingsValues()[k]; !ok {
+				return fmt.Errorf("unknown setting %q", k)
+			}
+		}
+		c.keepSettings = v
+		return nil
+	},
+	"mutationEvery": func(c *config, raw json.RawMessage) error {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 1 || v > 100000 {
+			return fmt.Errorf("%d out of range [1, 100000]", v)
+		}
+		c.mutationEvery = v
+		return nil
+	},
+	"mutationCells": func(c *config, raw json.RawMessage) error {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 1 || v > 1000 {
+			return fmt.Errorf("%d out of range [1, 1000]", v)
+		}
+		c.mutationCells = v
+		return nil
+	},
+	"searchGenerations": func(c *config, raw json.RawMessage) error {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 10 || v > 100000 {
+			return fmt.Errorf("%d out of range [10, 100000]", v)
+		}
+		c.searchGenerations = v
+		return nil
+	},
+	"searchBy": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if _, ok := life.SoupOrders[v]; !ok {
+			return fmt.Errorf("unknown criterion %q", v)
+		}
+		c.searchBy = v
+		return nil
+	},
+	"variant": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		n, ok := variantColors[v]
+		if !ok {
+			return fmt.Errorf("unknown variant %q", v)
+		}
+		c.colors = n
+		return nil
+	},
+	"render": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		switch v {
+		case "nodes", "texture":
+		default:
+			return fmt.Errorf("unknown render mode %q", v)
+		}
+		c.frameRender = v == "texture"
+		return nil
+	},
+	"maxCells": func(c *config, raw json.RawMessage) error {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 100 || v > 1000000 {
+			return fmt.Errorf("%d out of range [100, 1000000]", v)
+		}
+		c.maxCells = v
+		return nil
+	},
+	"parallelCells": func(c *config, raw json.RawMessage) error {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 0 || v > 1<<24 {
+			return fmt.Errorf("%d out of range [0, %d]", v, 1<<24)
+		}
+		c.parallelCells = v
+		return nil
+	},
+	"history": func(c *config, raw json.RawMessage) error {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 0 || v > 1024 {
+			return fmt.Errorf("%d out of range [0, 1024]", v)
+		}
+		c.history = v
+		return nil
+	},
+	"seedMode": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		m, err := life.ParseSeedMode(v)
+		if err != nil {
+			return err
+		}
+		c.seedMode = m
+		return nil
+	},
+	"exportScale": func(c *config, raw json.RawMessage) error {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 1 || v > 32 {
+			return fmt.Errorf("%d out of range [1, 32]", v)
+		}
+		c.exportScale = v
+		return nil
+	},
+	"theme": func(c *config, raw json.RawMessage) error {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		k, err := parseTheme(v)
+		if err != nil {
+			return err
+		}
+		c.theme = k
+		return nil
+	},
+	"seed": func(c *config, raw json.RawMessage) error {
+		var v int64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 0 || v > maxSoupSeed {
+			return fmt.Errorf("%d out of range [0, %d]", v, maxSoupSeed)
+		}
+		c.seed = v
+		return nil
+	},
+	"recordEvery": func(c *config, raw json.RawMessage) error {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v < 1 || v > 60 {
+			return fmt.Errorf("%d out of range [1, 60]", v)
+		}
+		c.recordEvery = v
+		return nil
+	},
+}
+
+// readConfig returns c overridden by the fields of the JSON manifest read from r, named name in
+// messages. Unknown keys and invalid values are logged and ignored so a bad manifest cannot prevent
+// the app from starting; only a manifest that is not a JSON object is an error.
+func readConfig(c config, name string, r io.Reader) (config, error) {
+	var m map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return c, fmt.Errorf("%s: %v", name, err)
+	}
+	for k, raw := range m {
+		set, ok := configFields[k]
+		if !ok {
+			log.Printf("%s: ignoring unknown key %q", name, k)
+			continue
+		}
+		if err := set(&c, raw); err != nil {
+			log.Printf("%s: ignoring %q: %v", name, k, err)
+		}
+	}
+	return c, nil
+}
+
+// loadConfig returns the startup configuration: the built-in defaults overridden by the manifest
+// asset if the build ships one, then by the settings chosen in the settings panel.
+func loadConfig() config {
+	c := defaultConfig()
+	if a, err := openAsset(configAsset); err == nil {
+		if c, err = readConfig(c, configAsset, a); err != nil {
+			log.Print(err)
+		}
+		a.Close()
+	} else if !os.IsNotExist(err) {
+		log.Printf("%s: %v", configAsset, err)
+	}
+	if f, err := os.Open(settingsPath()); err == nil {
+		if c, err = readConfig(c, settingsPath(), f); err != nil {
+			log.Print(err)
+		}
+		f.Close()
+	} else if !os.IsNotExist(err) {
+		log.Print(err)
+	}
+	return c
+}
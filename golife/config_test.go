@@ -0,0 +1,183 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// sameConfig reports whether the configs c and d are the same.
+func sameConfig(c, d config) bool {
+	return fmt.Sprintf("%+v", c) == fmt.Sprintf("%+v", d)
+}
+
+func TestReadConfig(t *testing.T) {
+	highLife, _ := life.ParseRule("B36/S23")
+	for _, test := range []struct {
+		name, manifest string
+		edit           func(c *config) // Edits the defaults into the wanted config.
+	}{
+		{"empty", "{}", func(c *config) {}},
+		{"locale", `{"locale": "es_MX"}`, func(c *config) { c.locale = "es_MX" }},
+		{"large", `{"large": true}`, func(c *config) { c.large = true }},
+		{"compact", `{"compact": true}`, func(c *config) { c.compact = true }},
+		{"tutorialDone", `{"tutorialDone": true}`, func(c *config) { c.tutorialDone = true }},
+		{"screensaver", `{"screensaver": true, "screensaverDelay": 30}`, func(c *config) {
+			c.screensaver, c.screensaverDelay = true, 30
+		}},
+		{"speedLimit", `{"speedLimit": {"below": 0.5, "sustain": 3}}`, func(c *config) {
+			c.limiter.below, c.limiter.sustain = 0.5, 3
+		}},
+		{"invalid speedLimit", `{"speedLimit": {"window": 5, "below": 1.5}}`, func(c *config) {}},
+		{"drift", `{"drift": [0.25, -0.5]}`, func(c *config) { c.drift = [2]float64{0.25, -0.5} }},
+		{"invalid drift", `{"drift": [2, 0]}`, func(c *config) {}},
+		{"startup", `{"startup": "pattern", "startupName": "glider.rle"}`, func(c *config) {
+			c.startup, c.startupName = patternStartup, "glider.rle"
+		}},
+		{"mutation", `{"mutation": true, "mutationEvery": 10, "mutationCells": 3}`, func(c *config) {
+			c.mutation, c.mutationEvery, c.mutationCells = true, 10, 3
+		}},
+		{"searchBy", `{"searchBy": "methuselah"}`, func(c *config) { c.searchBy = "methuselah" }},
+		{"invalid searchBy", `{"searchBy": "beauty"}`, func(c *config) {}},
+		{"showcase", `{"showcase": true, "buttons": ["showcase"]}`, func(c *config) {
+			c.showcase, c.buttons = true, []string{showcaseImage}
+		}},
+		{
+			name:     "partial",
+			manifest: `{"cellSize": 12, "rule": "B36/S23", "edges": "bounded", "buttons": ["pause", "replay"]}`,
+			edit: func(c *config) {
+				c.cellSize = 12
+				c.rule = highLife
+				c.edges = life.Bounded
+				c.buttons = []string{pauseImage, replayImage}
+			},
+		},
+		{
+			name:     "former speed",
+			manifest: `{"renderEvery": 6, "seamEcho": true}`,
+			edit: func(c *config) {
+				c.speed = 10
+				c.seamEcho = true
+			},
+		},
+		{
+			name:     "unknown keys",
+			manifest: `{"density": 0.5, "celSize": 12, "colour": "red"}`,
+			edit:     func(c *config) { c.density = 0.5 },
+		},
+		{
+			name:     "invalid values",
+			manifest: `{"density": 1.5, "cellSize": "big", "rule": "B9/S23", "maxCells": 50, "speed": 20, "locale": "español"}`,
+			edit:     func(c *config) { c.speed = 20 },
+		},
+		{
+			name:     "invalid buttons",
+			manifest: `{"buttons": ["pause", "pause"], "variant": "quadlife", "versus": {"budget": -1}}`,
+			edit:     func(c *config) { c.colors = 4 },
+		},
+		{
+			name:     "versus",
+			manifest: `{"versus": {"join": "10.0.0.2:7070", "generations": 200}, "render": "texture"}`,
+			edit: func(c *config) {
+				c.versus.join = "10.0.0.2:7070"
+				c.versus.generations = 200
+				c.frameRender = true
+			},
+		},
+	} {
+		got, err := readConfig(defaultConfig(), test.name, strings.NewReader(test.manifest))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		want := defaultConfig()
+		test.edit(&want)
+		if !sameConfig(got, want) {
+			t.Errorf("%s: read\n%+v\nwant\n%+v", test.name, got, want)
+		}
+	}
+}
+
+func TestReadConfigInvalid(t *testing.T) {
+	for _, manifest := range []string{"", "[1, 2]", `{"speed": 20`, "cellSize: 12"} {
+		c, err := readConfig(defaultConfig(), "test", strings.NewReader(manifest))
+		if err == nil {
+			t.Errorf("%q read as a manifest", manifest)
+		}
+		if !sameConfig(c, defaultConfig()) {
+			t.Errorf("%q changed the defaults to %+v", manifest, c)
+		}
+	}
+}
+
+// inTempDir runs f in a temporary working directory, which also holds the settings file.
+func inTempDir(t testing.TB, f func(dir string)) {
+	dir, err := ioutil.TempDir("", "golife")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	tmp := os.Getenv("TMPDIR")
+	os.Setenv("TMPDIR", dir)
+	defer os.Setenv("TMPDIR", tmp)
+	f(dir)
+}
+
+func TestLoadConfig(t *testing.T) {
+	inTempDir(t, func(dir string) {
+		// Without the manifest nor settings, the defaults are used.
+		if c := loadConfig(); !sameConfig(c, defaultConfig()) {
+			t.Errorf("config without a manifest %+v, want the defaults", c)
+		}
+
+		if err := os.Mkdir("assets", 0755); err != nil {
+			t.Fatal(err)
+		}
+		manifest := `{"density": 0.4, "speed": 20, "edges": "reflect"}`
+		if err := ioutil.WriteFile(filepath.Join("assets", configAsset), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+		want := defaultConfig()
+		want.density, want.speed, want.edges = 0.4, 20, life.Reflect
+		if c := loadConfig(); !sameConfig(c, want) {
+			t.Errorf("config %+v, want %+v", c, want)
+		}
+
+		// The settings take precedence over the manifest.
+		if settingsPath() != filepath.Join(dir, "golife.settings.json") {
+			t.Fatalf("settings at %s, out of %s", settingsPath(), dir)
+		}
+		if err := ioutil.WriteFile(settingsPath(), []byte(`{"density": 0.1}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		want.density = 0.1
+		if c := loadConfig(); !sameConfig(c, want) {
+			t.Errorf("config with settings %+v, want %+v", c, want)
+		}
+
+		// A corrupt manifest is ignored.
+		if err := ioutil.WriteFile(filepath.Join("assets", configAsset), []byte("{"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		want = defaultConfig()
+		want.density = 0.1
+		if c := loadConfig(); !sameConfig(c, want) {
+			t.Errorf("config with a corrupt manifest %+v, want %+v", c, want)
+		}
+	})
+}
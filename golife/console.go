@@ -0,0 +1,356 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	imagedraw "image/draw"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+const (
+	// consoleLines is the number of lines of the log the debug console keeps.
+	consoleLines = 200
+	// consoleScale is the size in px of a font pixel of the text of the console, and consoleRow the
+	// height in px of its rows.
+	consoleScale = 2
+	consoleRow   = (glyphHeight + 3) * consoleScale
+	// consoleFile is the file of the app directory the console dumps its text to.
+	consoleFile = "golife.console.txt"
+)
+
+// The names of the buttons of the console.
+const (
+	consoleDump    = "dump"
+	consoleFixture = "fixture"
+	consoleClose   = "close"
+)
+
+// consoleButtons are the buttons of the console, in order.
+var consoleButtons = []string{consoleDump, consoleFixture, consoleClose}
+
+// A logRing keeps the last lines written to the log, for the debug console, and forwards them to
+// the output of the log as set up by the platform, where logcat picks it up on Android.
+type logRing struct {
+	mu      sync.Mutex
+	out     io.Writer // Nil to keep the lines only.
+	lines   []string  // Up to consoleLines, the oldest at next once full.
+	next    int
+	partial string // The end of the last write, short of a newline.
+	total   int    // Lines written so far, for the console to tell new ones.
+}
+
+// logs keeps the lines of the log of every package, once main sets it as the output of the log.
+var logs = &logRing{out: os.Stderr}
+
+// Write implements io.Writer.
+func (r *logRing) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.partial + string(p)
+	for {
+		k := strings.IndexByte(s, '\n')
+		if k < 0 {
+			break
+		}
+		if len(r.lines) < consoleLines {
+			r.lines = append(r.lines, s[:k])
+		} else {
+			r.lines[r.next] = s[:k]
+			r.next = (r.next + 1) % consoleLines
+		}
+		r.total++
+		s = s[k+1:]
+	}
+	r.partial = s
+	if r.out == nil {
+		return len(p), nil
+	}
+	return r.out.Write(p)
+}
+
+// snapshot returns the lines kept by r, from the oldest, and the number of lines written so far.
+func (r *logRing) snapshot() ([]string, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(append([]string(nil), r.lines[r.next:]...), r.lines[:r.next]...), r.total
+}
+
+// frameTime is how long the last frame took to draw, see draw.
+var frameTime time.Duration
+
+// animations returns the number of animations running: the camera fitting the cells or following
+// the action, and the ripples.
+func animations() int {
+	n := 0
+	if views.fit != nil {
+		n++
+	}
+	if follow.on {
+		n++
+	}
+	for _, r := range views.ripples {
+		if !r.done {
+			n++
+		}
+	}
+	return n
+}
+
+// consoleFigures returns the lines of the figures the console shows above the log.
+func consoleFigures() []string {
+	r := usage()
+	algorithm := "none"
+	if univ != nil {
+		algorithm = univ.life.Algorithm()
+	}
+	return []string{
+		fmt.Sprintf("nodes %d, %d attached", r.nodes, r.attached),
+		fmt.Sprintf("animations %d", animations()),
+		fmt.Sprintf("goroutines %d", runtime.NumGoroutine()),
+		fmt.Sprintf("frame %d us", frameTime/time.Microsecond),
+		fmt.Sprintf("life %s", algorithm),
+	}
+}
+
+// wrapLines returns lines cut into rows of up to cols characters, empty lines taking a row.
+func wrapLines(lines []string, cols int) []string {
+	var rows []string
+	for _, l := range lines {
+		rs := []rune(l)
+		for len(rs) > cols {
+			rows = append(rows, string(rs[:cols]))
+			rs = rs[cols:]
+		}
+		rows = append(rows, string(rs))
+	}
+	return rows
+}
+
+// A consoleView shows the latest lines of the log under the figures of consoleFigures, for
+// developers without access to logcat, and dumps them to consoleFile. The log follows the latest
+// lines unless dragged down to older ones. As the stamp menu, it is drawn over the scene from a
+// scene graph of its own and, while open, receives every touch.
+type consoleView struct {
+	root  *sprite.Node
+	bar   *ui.Bar
+	faces map[string]sprite.SubTex
+	// img is drawn anew and uploaded to tex as the text changes: the labels of the buttons in the
+	// first pickerPx rows, then the text, rows by cols characters.
+	img        *image.RGBA
+	tex        sprite.Texture
+	rows, cols int
+	// back is the number of rows the log is scrolled back from its latest line. A drag of the
+	// finger of the touch sequence drag, from dragY as the log was back dragBack rows, scrolls it.
+	back     int
+	dragging bool
+	drag     event.TouchSequenceID
+	dragY    geom.Pt
+	dragBack int
+	// since is when the figures were last drawn, and logged the number of lines logged then.
+	since  time.Time
+	logged int
+}
+
+// openConsole opens the debug console over the whole screen.
+func openConsole() {
+	var (
+		pad   = geom.Pt(buttonSep)
+		line  = buttonSize
+		top   = systemBarHeight + pad
+		textY = top + line + pad
+		textW = geom.Width - 2*pad
+		w     = int(textW.Px())
+		h     = int((geom.Height - pad - textY).Px())
+	)
+	if w <= 0 || h < consoleRow {
+		log.Printf("debug console: no room on a %vx%v screen", geom.Width, geom.Height)
+		return
+	}
+	c := &consoleView{
+		root:  &sprite.Node{},
+		img:   image.NewRGBA(image.Rect(0, 0, w, pickerPx+h)),
+		rows:  h / consoleRow,
+		cols:  w / (glyphAdvance * consoleScale),
+		faces: make(map[string]sprite.SubTex),
+	}
+	tex, err := eng.LoadTexture(c.img)
+	if err != nil {
+		log.Printf("debug console: %v", err)
+		return
+	}
+	forgetPointers()
+	c.tex = tex
+	eng.Register(c.root)
+	eng.SetTransform(c.root, f32.Affine{{1, 0, 0}, {0, 1, 0}})
+	add := func(sub sprite.SubTex, x, y, w, h geom.Pt) {
+		n := &sprite.Node{}
+		eng.Register(n)
+		c.root.AppendChild(n)
+		eng.SetSubTex(n, sub)
+		eng.SetTransform(n, f32.Affine{{float32(w), 0, float32(x)}, {0, float32(h), float32(y)}})
+	}
+	add(*textures[outOfBoundsImage], 0, 0, geom.Width, geom.Height)
+	px := func(n int) geom.Pt { return geom.Pt(float32(n) / geom.PixelsPerPt) }
+	add(sprite.SubTex{tex, image.Rect(0, pickerPx, w, pickerPx+h)}, pad, textY, px(w), px(h))
+
+	// The labels of the buttons, in lines of the bitmap font of pickerPx, as the picker.
+	rect := geom.Rectangle{Min: geom.Point{X: pad, Y: top}, Max: geom.Point{X: pad + textW, Y: top + line}}
+	c.bar = ui.NewBar(eng, c.root, geom.Point{}, func(b *ui.Button) sprite.SubTex { return c.faces[b.Name] })
+	x, lx := pad, 0
+	for _, name := range consoleButtons {
+		lw := textWidth(text("console."+name), pickerScale)
+		c.faces[name] = sprite.SubTex{tex, image.Rect(lx, 0, lx+lw, pickerPx)}
+		bw := geom.Pt(lw) * line / pickerPx
+		c.bar.Add(name, mirror(geom.Rectangle{Min: geom.Point{X: x, Y: top}, Max: geom.Point{X: x + bw, Y: top + line}}, rect))
+		x, lx = x+bw+2*pad, lx+lw+glyphAdvance*pickerScale
+	}
+	c.render()
+	views.console = c
+	holdPanel(consoleName)
+}
+
+// render draws the text of c and uploads it: the labels of the buttons, the figures, then the rows
+// of the log that fit, as scrolled back.
+func (c *consoleView) render() {
+	imagedraw.Draw(c.img, c.img.Bounds(), image.Black, image.Point{}, imagedraw.Src)
+	dy := (pickerPx - glyphHeight*pickerScale) / 2
+	lx := 0
+	for _, name := range consoleButtons {
+		s := text("console." + name)
+		drawText(c.img, s, lx, dy, pickerScale, color.White)
+		lx += textWidth(s, pickerScale) + glyphAdvance*pickerScale
+	}
+
+	lines, logged := logs.snapshot()
+	figures := wrapLines(consoleFigures(), c.cols)
+	rows := wrapLines(lines, c.cols)
+	// The log is a row below the figures.
+	shown := c.rows - len(figures) - 1
+	if shown < 0 {
+		shown = 0
+	}
+	if c.back > len(rows)-shown {
+		c.back = len(rows) - shown
+	}
+	if c.back < 0 {
+		c.back = 0
+	}
+	end := len(rows) - c.back
+	start := end - shown
+	if start < 0 {
+		start = 0
+	}
+	green := color.RGBA{0x66, 0xdd, 0x66, 0xff}
+	y := pickerPx + consoleScale
+	for _, s := range figures {
+		drawText(c.img, s, 0, y, consoleScale, green)
+		y += consoleRow
+	}
+	y += consoleRow
+	for _, s := range rows[start:end] {
+		drawText(c.img, s, 0, y, consoleScale, color.White)
+		y += consoleRow
+	}
+	c.tex.Upload(c.img.Bounds(), c.img)
+	c.since, c.logged = time.Now(), logged
+}
+
+// updateConsole draws the console again, if open, once lines were logged or the figures are due to
+// be refreshed, see debugInterval. It is called every frame.
+func updateConsole() {
+	c := views.console
+	if c == nil {
+		return
+	}
+	if _, logged := logs.snapshot(); logged != c.logged || time.Since(c.since) >= debugInterval {
+		c.render()
+	}
+}
+
+// touch handles t while c is open. The buttons dump the text of c, export the touches logged as a
+// fixture or close c, and dragging a finger down scrolls the log back to older lines.
+func (c *consoleView) touch(t event.Touch) {
+	switch t.Type {
+	case event.TouchStart:
+		if b := c.bar.Find(t.Loc); b != nil {
+			sounds.click()
+			switch b.Name {
+			case consoleDump:
+				c.dump()
+			case consoleFixture:
+				c.fixture()
+			case consoleClose:
+				c.close()
+			}
+			return
+		}
+		c.dragging, c.drag, c.dragY, c.dragBack = true, t.ID, t.Loc.Y, c.back
+	case event.TouchMove:
+		if !c.dragging || t.ID != c.drag {
+			return
+		}
+		if back := c.dragBack + int((t.Loc.Y-c.dragY).Px())/consoleRow; back != c.back {
+			c.back = back
+			c.render()
+		}
+	case event.TouchEnd:
+		if t.ID == c.drag {
+			c.dragging = false
+		}
+	}
+}
+
+// dump writes the figures and the lines of the log kept to consoleFile.
+func (c *consoleView) dump() {
+	name := filepath.Join(appDir(), consoleFile)
+	lines, _ := logs.snapshot()
+	err := writeFile(name, func(w io.Writer) error {
+		_, err := io.WriteString(w, strings.Join(append(append(consoleFigures(), ""), lines...), "\n")+"\n")
+		return err
+	})
+	if err != nil {
+		log.Printf("dumping the debug console: %v", err)
+		return
+	}
+	// Toasts show under the console, which shows the log.
+	log.Printf("debug console dumped to %s", name)
+}
+
+// fixture exports the touches logged to fixtureFile, see exportFixture.
+func (c *consoleView) fixture() {
+	name := filepath.Join(appDir(), fixtureFile)
+	if err := exportFixture(name); err != nil {
+		log.Printf("exporting the fixture: %v", err)
+		return
+	}
+	log.Printf("fixture of %d touches exported to %s", len(touchLog.Touches), name)
+}
+
+// close closes c, unregisters its nodes and frees its texture.
+func (c *consoleView) close() {
+	c.bar.Release()
+	for n := c.root.FirstChild; n != nil; n = c.root.FirstChild {
+		c.root.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	eng.Unregister(c.root)
+	c.tex.Unload()
+	views.console = nil
+	releasePanel(consoleName)
+}
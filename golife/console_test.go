@@ -0,0 +1,134 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+func TestLogRing(t *testing.T) {
+	var out bytes.Buffer
+	r := &logRing{out: &out}
+	fmt.Fprintf(r, "first\nsec")
+	fmt.Fprintf(r, "ond\n")
+	if lines, total := r.snapshot(); strings.Join(lines, "|") != "first|second" || total != 2 {
+		t.Errorf("lines %q, %d in all, want first and second", lines, total)
+	}
+	// Only the latest lines are kept, from the oldest.
+	for k := 0; k < consoleLines+10; k++ {
+		fmt.Fprintf(r, "line %d\n", k)
+	}
+	lines, total := r.snapshot()
+	if len(lines) != consoleLines || lines[0] != "line 10" || lines[len(lines)-1] != fmt.Sprintf("line %d", consoleLines+9) {
+		t.Errorf("%d lines kept, from %q to %q", len(lines), lines[0], lines[len(lines)-1])
+	}
+	if total != consoleLines+12 {
+		t.Errorf("%d lines in all, want %d", total, consoleLines+12)
+	}
+	// Everything is forwarded.
+	if !strings.HasPrefix(out.String(), "first\nsecond\nline 0\n") {
+		t.Errorf("forwarded %q", out.String()[:20])
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	rows := wrapLines([]string{"abcdefgh", "", "abc"}, 3)
+	if got := strings.Join(rows, "|"); got != "abc|def|gh||abc" {
+		t.Errorf("wrapped to %q", got)
+	}
+}
+
+func TestConsole(t *testing.T) {
+	gestureScene(t)
+	defer func(l *logRing) { logs = l }(logs)
+	logs = &logRing{}
+	for k := 0; k < consoleLines; k++ {
+		fmt.Fprintf(logs, "line %d\n", k)
+	}
+	debugging = true
+	updateDebug()
+	defer func() {
+		debugging = false
+		updateDebug()
+	}()
+
+	// A tap on the overlay opens the console, following the log.
+	o := views.overlay.rect
+	loc := geom.Point{X: (o.Min.X + o.Max.X) / 2, Y: (o.Min.Y + o.Max.Y) / 2}
+	finger(1, event.TouchStart, loc)
+	finger(1, event.TouchEnd, loc)
+	c := views.console
+	if c == nil {
+		t.Fatalf("console closed once the debug overlay was tapped")
+	}
+	if c.back != 0 || c.logged != consoleLines {
+		t.Errorf("console back %d rows with %d lines logged, want following the %d lines", c.back, c.logged, consoleLines)
+	}
+
+	// Dragging down scrolls back, stroke after stroke, up to the oldest line.
+	start := geom.Point{X: geom.Width / 2, Y: geom.Height / 2}
+	finger(2, event.TouchStart, start)
+	finger(2, event.TouchMove, geom.Point{X: start.X, Y: start.Y + geom.Pt(float32(3*consoleRow)/geom.PixelsPerPt)})
+	if c.back != 3 {
+		t.Errorf("console back %d rows once dragged 3 rows down", c.back)
+	}
+	finger(2, event.TouchMove, geom.Point{X: start.X, Y: geom.Height})
+	finger(2, event.TouchEnd, geom.Point{X: start.X, Y: geom.Height})
+	for back := 0; back != c.back; {
+		back = c.back
+		finger(2, event.TouchStart, start)
+		finger(2, event.TouchMove, geom.Point{X: start.X, Y: geom.Height})
+		finger(2, event.TouchEnd, geom.Point{X: start.X, Y: geom.Height})
+	}
+	rows := len(wrapLines(consoleFigures(), c.cols)) + 1
+	if want := consoleLines - (c.rows - rows); c.back != want {
+		t.Errorf("console back %d rows once dragged down over and over, want %d", c.back, want)
+	}
+
+	inTempDir(t, func(dir string) {
+		finger(3, event.TouchStart, buttonCenter(t, c, consoleDump))
+		b, err := ioutil.ReadFile(filepath.Join(dir, consoleFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := string(b); !strings.Contains(s, "goroutines") || !strings.Contains(s, "line 0\n") {
+			t.Errorf("console dumped %q", s)
+		}
+	})
+	finger(4, event.TouchStart, buttonCenter(t, c, consoleClose))
+	if views.console != nil {
+		t.Errorf("console open once closed")
+	}
+}
+
+// buttonCenter returns the absolute location of the center of the button of the given name of c.
+func buttonCenter(t *testing.T, c *consoleView, name string) geom.Point {
+	b := c.bar.Button(name)
+	if b == nil {
+		t.Fatalf("no %s button", name)
+	}
+	return geom.Point{X: (b.Rect.Min.X + b.Rect.Max.X) / 2, Y: (b.Rect.Min.Y + b.Rect.Max.Y) / 2}
+}
+
+func TestConsoleLeaks(t *testing.T) {
+	trackedScene(t)
+	base := usage()
+	for k := 0; k < 20; k++ {
+		openConsole()
+		if views.console == nil {
+			t.Fatalf("console %d not open", k)
+		}
+		views.console.close()
+	}
+	if r := usage(); r.nodes != base.nodes || r.attached != base.attached || r.textures != base.textures {
+		t.Errorf("console opened and closed 20 times leaves %+v, was %+v", r, base)
+	}
+}
@@ -0,0 +1,77 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+
+	"golang.org/x/mobile/geom"
+)
+
+// A controlLayout holds the sizes of the controls, in Pt. The layout in use is one of normalControls,
+// largeControls and compactControls, see scaleControls, which buttonSize and the others follow.
+type controlLayout struct {
+	button, sep geom.Pt // Side of the buttons and space between them, see buttonSize and buttonSep.
+	// Sizes of the digits of the hud, see hudDigitHeight, hudDigitWidth and hudColorDigitHeight.
+	digitHeight, digitWidth, colorDigitHeight geom.Pt
+	slop                                      geom.Pt // How far beyond their rectangles buttons are hit.
+}
+
+// Sizes of the controls at their normal scale, in Pt.
+const (
+	normalButtonSize     = 14
+	normalButtonSep      = 6
+	normalDigitHeight    = 8
+	normalDigitWidth     = 6
+	normalColorDigitSize = 5
+)
+
+// Sizes of the compact controls, in Pt, see compactControls.
+const (
+	compactButtonSize = 10
+	compactButtonSep  = 3
+)
+
+var (
+	normalControls = controlLayout{normalButtonSize, normalButtonSep, normalDigitHeight, normalDigitWidth,
+		normalColorDigitSize, 0}
+	largeControls = controlLayout{normalButtonSize * largeScale, normalButtonSep * largeScale,
+		normalDigitHeight * largeScale, normalDigitWidth * largeScale, normalColorDigitSize * largeScale,
+		normalButtonSep * largeScale}
+	// With the compact controls, see cfg.compact, the button bar leaves more of small screens to the
+	// grid: the buttons are smaller and closer, but hit as far as the normal ones and their
+	// separation reach.
+	compactControls = controlLayout{compactButtonSize, compactButtonSep, 6, 4.5, 4,
+		(normalButtonSize + normalButtonSep - compactButtonSize) / 2}
+)
+
+// controls is the layout of the controls in use.
+var controls = normalControls
+
+// scaleControls sets the sizes of the controls as told by cfg.large, or else cfg.compact.
+func scaleControls() {
+	switch {
+	case cfg.large:
+		controls = largeControls
+	case cfg.compact:
+		controls = compactControls
+	default:
+		controls = normalControls
+	}
+	c := controls
+	buttonSize, buttonSep = c.button, c.sep
+	settingsSlot = 2 * buttonSize
+	hudDigitHeight, hudDigitWidth, hudColorDigitHeight = c.digitHeight, c.digitWidth, c.colorDigitHeight
+}
+
+// setCompact turns the compact controls on or off, and the large ones off. The scene is laid out
+// again at the new sizes by the next frame.
+func setCompact(on bool) {
+	cfg.compact = on
+	if on && cfg.large {
+		setLarge(false)
+	}
+	scaleControls()
+	laidOut = geom.Point{}
+	log.Printf("compact controls %v", on)
+}
@@ -0,0 +1,71 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/geom"
+)
+
+func TestCompactControls(t *testing.T) {
+	defer func() { cfg.compact, cfg.large = false, false; scaleControls() }()
+	for _, test := range []struct {
+		name string
+		w, h geom.Pt
+		rows int // Rows of the compact bar.
+	}{
+		{"portrait", 320, 480, 1},
+		{"landscape", 480, 320, 1},
+		// The 15 slots of the default buttons, in two rows, three at the normal size.
+		{"narrow", 160, 480, 2},
+	} {
+		testScene(t, test.w, test.h)
+		univ.adopt(life.New(univ.cols, univ.rows))
+		forgetPointers()
+		layout()
+		height, h := buttonBarHeight, univ.h
+
+		setCompact(true)
+		layout()
+		if buttonSize != compactButtonSize || buttonBar.Slop <= buttonSep {
+			t.Errorf("%s: buttons of %v hit %v away", test.name, buttonSize, buttonBar.Slop)
+		}
+		if want := geom.Pt(test.rows)*(buttonSize+buttonSep) - buttonSep + 1; buttonBarHeight != want {
+			t.Errorf("%s: compact bar %v high, want %v", test.name, buttonBarHeight, want)
+		}
+		// The universe takes up what the bar leaves.
+		if buttonBarHeight >= height || univ.h != geom.Height-systemBarHeight-buttonBarHeight || univ.h <= h {
+			t.Errorf("%s: universe %v high under a bar %v high, %v under %v", test.name, univ.h, buttonBarHeight,
+				h, height)
+		}
+		checkButtonBar(t, test.name)
+
+		// A finger by a button still hits it, as far as a normal button and its separation reach.
+		if reach := (buttonSize + 2*buttonBar.Slop); reach < normalButtonSize+normalButtonSep {
+			t.Errorf("%s: buttons hit %v across", test.name, reach)
+		}
+		b := buttonBar.Buttons[0]
+		loc := geom.Point{X: b.Rect.Min.X + buttonSize/2, Y: b.Rect.Min.Y - buttonBar.Slop + 0.5}
+		if got := buttonBar.Find(loc); got != b {
+			t.Errorf("%s: finger above %s at %v hits %v", test.name, b.Name, b.Rect, got)
+		}
+
+		// The large controls win over the compact ones.
+		setLarge(true)
+		layout()
+		if cfg.compact || buttonSize != normalButtonSize*largeScale {
+			t.Errorf("%s: compact %v with the large controls, buttons of %v", test.name, cfg.compact, buttonSize)
+		}
+		setCompact(true)
+		if cfg.large || buttonSize != compactButtonSize {
+			t.Errorf("%s: large %v with the compact controls, buttons of %v", test.name, cfg.large, buttonSize)
+		}
+		setCompact(false)
+		layout()
+		if buttonBarHeight != height || univ.h != h {
+			t.Errorf("%s: bar %v high once back to normal, was %v", test.name, buttonBarHeight, height)
+		}
+	}
+}
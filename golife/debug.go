@@ -0,0 +1,146 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"time"
+
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// debugInterval is how often the debug overlay refreshes its figures, averaged over the interval.
+const debugInterval = time.Second
+
+// debugRows are the images labelling the figures of the debug overlay, from top to bottom: frames
+// per second, generations per second, microseconds per step, listener included, sprite nodes in the
+// trees rendered and registered out of them, and the KB of the textures, of the history and of the
+// game. See usage.
+var debugRows = []string{recordImage, incSpeedImage, stepImage, androidImage, cutImage, themeImage, backImage,
+	ruleImage}
+
+// Units are in Pt.
+const (
+	debugDigits      = 6
+	debugDigitHeight = 6
+)
+
+// A debugOverlay shows figures about the performance of the app, at the top-left corner of the
+// grid, for developers to check optimizations on devices. A third finger on the grid during a
+// pinch shows or hides it, and a tap on it opens the debug console, see openConsole.
+type debugOverlay struct {
+	nodes    []*sprite.Node // The background and labels.
+	counters []*counter     // One per row of debugRows.
+	rect     geom.Rectangle // Of the background, using absolute location.
+	// Counts since the figures were last refreshed.
+	since         time.Time
+	frames, steps int
+	stepTime      time.Duration
+}
+
+// debugging is set while the debug overlay, views.overlay, should show.
+var debugging bool
+
+// updateDebug builds or releases the debug overlay as debugging tells, and counts a frame. It is
+// called every rendered frame.
+func updateDebug() {
+	switch {
+	case debugging && views.overlay == nil:
+		views.overlay = newDebugOverlay()
+	case !debugging && views.overlay != nil:
+		views.overlay.release()
+		views.overlay = nil
+	}
+	if o := views.overlay; o != nil {
+		o.frames++
+		if d := time.Since(o.since); d >= debugInterval {
+			o.refresh(d)
+		}
+	}
+}
+
+// newDebugOverlay returns a debug overlay, with its figures shown from the next refresh.
+func newDebugOverlay() *debugOverlay {
+	o := &debugOverlay{since: time.Now()}
+	const h = debugDigitHeight
+	var (
+		row = geom.Pt(h + buttonSep/2)
+		x0  = geom.Pt(buttonSep)
+		y0  = geom.Pt(buttonBarHeight + buttonSep)
+		w   = h + buttonSep/2 + geom.Pt(debugDigits)*h*hudDigitWidth/hudDigitHeight
+		pad = geom.Pt(buttonSep / 2)
+	)
+	add := func(img string, x, y, w, h geom.Pt) {
+		n := &sprite.Node{}
+		eng.Register(n)
+		scene.AppendChild(n)
+		eng.SetSubTex(n, *textures[img])
+		eng.SetTransform(n, f32.Affine{{float32(w), 0, float32(x)}, {0, float32(h), float32(y)}})
+		o.nodes = append(o.nodes, n)
+	}
+	bh := geom.Pt(len(debugRows))*row - buttonSep/2 + 2*pad
+	add(outOfBoundsImage, x0-pad, y0-pad, w+2*pad, bh)
+	// The scene is below the system bar.
+	o.rect = geom.Rectangle{
+		Min: geom.Point{X: x0 - pad, Y: systemBarHeight + y0 - pad},
+		Max: geom.Point{X: x0 + w + pad, Y: systemBarHeight + y0 - pad + bh},
+	}
+	for k, img := range debugRows {
+		y := y0 + geom.Pt(k)*row
+		add(img, x0, y, h, h)
+		o.counters = append(o.counters, newCounter(scene, x0+h+buttonSep/2, y, h, debugDigits))
+	}
+	return o
+}
+
+// release removes o from the scene and unregisters its nodes.
+func (o *debugOverlay) release() {
+	for _, c := range o.counters {
+		c.release()
+	}
+	for _, n := range o.nodes {
+		scene.RemoveChild(n)
+		eng.Unregister(n)
+	}
+}
+
+// refresh shows the figures counted over the last d, and starts counting again.
+func (o *debugOverlay) refresh(d time.Duration) {
+	perStep := 0
+	if o.steps > 0 {
+		perStep = int(o.stepTime / time.Duration(o.steps) / time.Microsecond)
+	}
+	r := usage()
+	for k, v := range []int{
+		int(float64(o.frames)/d.Seconds() + 0.5),
+		int(float64(o.steps)/d.Seconds() + 0.5),
+		perStep,
+		r.attached,
+		r.nodes - r.attached,
+		r.textureBytes / 1024,
+		r.historyBytes / 1024,
+		r.lifeBytes / 1024,
+	} {
+		o.counters[k].set(v)
+	}
+	o.since, o.frames, o.steps, o.stepTime = time.Now(), 0, 0, 0
+}
+
+// stepped counts a step of the universe that took d, if o is not nil.
+func (o *debugOverlay) stepped(d time.Duration) {
+	if o == nil {
+		return
+	}
+	o.steps++
+	o.stepTime += d
+}
+
+// countNodes returns the number of nodes of the tree rooted at n.
+func countNodes(n *sprite.Node) int {
+	c := 1
+	for k := n.FirstChild; k != nil; k = k.NextSibling {
+		c += countNodes(k)
+	}
+	return c
+}
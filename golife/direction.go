@@ -0,0 +1,42 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/geom"
+)
+
+// rtlLanguages are the languages written right to left.
+var rtlLanguages = map[string]bool{"ar": true, "fa": true, "he": true, "iw": true, "ur": true, "yi": true}
+
+// rightToLeft tells whether the locale in use, see loadStrings, is written right to left. The bars,
+// panels and menus are then mirrored across their vertical axis, from the order of their buttons to
+// the side of their counters. Numbers still read left to right.
+var rightToLeft bool
+
+// screen returns the rectangle of the screen.
+func screen() geom.Rectangle {
+	return geom.Rectangle{Max: geom.Point{X: geom.Width, Y: geom.Height}}
+}
+
+// mirror returns r mirrored across the vertical axis of bounds if rightToLeft, else r.
+func mirror(r, bounds geom.Rectangle) geom.Rectangle {
+	if !rightToLeft {
+		return r
+	}
+	return ui.Mirror(r, bounds)
+}
+
+// mirrorAll mirrors each of rs in place, see mirror, and returns rs.
+func mirrorAll(rs []geom.Rectangle, bounds geom.Rectangle) []geom.Rectangle {
+	for k, r := range rs {
+		rs[k] = mirror(r, bounds)
+	}
+	return rs
+}
+
+// mirrorX returns the left of the span of width w from x across bounds, see mirror.
+func mirrorX(x, w geom.Pt, bounds geom.Rectangle) geom.Pt {
+	return mirror(geom.Rectangle{Min: geom.Point{X: x}, Max: geom.Point{X: x + w}}, bounds).Min.X
+}
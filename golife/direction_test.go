@@ -0,0 +1,93 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/geom"
+)
+
+func TestVisualOrder(t *testing.T) {
+	for s, want := range map[string]string{
+		"":                 "",
+		"get pack":         "get pack",
+		"שלום":             "םולש",
+		"שלום עולם":        "םלוע םולש",
+		"שלום 42":          "42 םולש",
+		"fit שלום עולם ok": "fit םלוע םולש ok",
+		"42%":              "42%",
+	} {
+		if got := visualOrder(s); got != want {
+			t.Errorf("%q drawn as %q, want %q", s, got, want)
+		}
+	}
+}
+
+// layoutRects lays out the scene in the direction of rightToLeft and returns the rectangles of the
+// buttons of the button bar, the tool bar, the settings panel and the picker, opened and closed in
+// turn, by name.
+func layoutRects() []map[string]geom.Rectangle {
+	rects := func(b *ui.Bar) map[string]geom.Rectangle {
+		m := make(map[string]geom.Rectangle)
+		for _, b := range b.Buttons {
+			m[b.Name] = b.Rect
+		}
+		return m
+	}
+	layout()
+	setPaused(true)
+	updateToolBar()
+	bars := []map[string]geom.Rectangle{rects(buttonBar), rects(toolBar), {}}
+	openSettings()
+	for k, row := range views.panel.rows {
+		bars[2][fmt.Sprintf("%d-", k)], bars[2][fmt.Sprintf("%d+", k)] = row.dec, row.inc
+	}
+	views.panel.close()
+	openPicker("", false)
+	bars = append(bars, rects(views.picker.bar))
+	views.picker.close()
+	return bars
+}
+
+func TestRightToLeft(t *testing.T) {
+	gestureScene(t)
+	defer func() { rightToLeft = false; layout() }()
+	ltr := layoutRects()
+	rightToLeft = true
+	rtl := layoutRects()
+
+	// Every button is mirrored across the screen, the settings panel and the picker being centered
+	// on it.
+	for k, what := range []string{"button bar", "tool bar", "settings panel", "picker"} {
+		if len(ltr[k]) == 0 || len(rtl[k]) != len(ltr[k]) {
+			t.Errorf("%s: %d buttons left to right, %d right to left", what, len(ltr[k]), len(rtl[k]))
+		}
+		for name, r := range ltr[k] {
+			want := ui.Mirror(r, screen())
+			if got := rtl[k][name]; !near(got.Min, want.Min) || !near(got.Max, want.Max) {
+				t.Errorf("%s: %s at %v right to left, want %v", what, name, got, want)
+			}
+		}
+	}
+	// The first button of the bar is the rightmost one, and the population shows left of the bar.
+	if b := buttonBar.Buttons; b[0].Rect.Min.X < b[len(b)-1].Rect.Min.X {
+		t.Errorf("first button at %v, last one at %v", b[0].Rect, b[len(b)-1].Rect)
+	}
+	if status.x > geom.Width/2 {
+		t.Errorf("population at %v", status.x)
+	}
+}
+
+func TestRightToLeftLocale(t *testing.T) {
+	defer func(c config) { cfg = c; loadStrings() }(cfg)
+	for locale, want := range map[string]bool{"he_IL": true, "ar": true, "fa_IR": true, "es_MX": false, "en": false} {
+		cfg.locale = locale
+		loadStrings()
+		if rightToLeft != want {
+			t.Errorf("%s: right to left %v", locale, rightToLeft)
+		}
+	}
+}
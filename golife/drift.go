@@ -0,0 +1,67 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+)
+
+// The drift scrolls the field of the torus across the screen area forever, as an ambient display,
+// by cfg.drift cells per generation: the field shows moved by a fraction of a cell, its slide, and
+// once the slide reaches a whole cell the game is shifted by a cell instead, see life.Life.Shift.
+// Along each seam, the cells of the opposite edge show in the gap left, as the echoes do. The field
+// drifts as the generations are due, composing with the speed, and stops while paused or edited.
+
+// drifts reports whether the fields drift, as told by cfg.drift.
+func drifts() bool {
+	return cfg.drift[0] != 0 || cfg.drift[1] != 0
+}
+
+// drifting reports whether the field of u drifts, only the torus doing so.
+func (u *universe) drifting() bool {
+	return drifts() && u.life.Edges == life.Wrap
+}
+
+// editing reports whether the user is editing the field, which stops it drifting: painting, framing
+// or pinching it, selecting cells or with a menu or panel open.
+func editing() bool {
+	if selecting || views.menu != nil || views.panel != nil {
+		return true
+	}
+	for _, p := range pointers {
+		if p.role != ignored && p.role != pressing {
+			return true
+		}
+	}
+	return false
+}
+
+// drift scrolls the field of u for gens generations, fractions included, unless edited.
+func (u *universe) drift(gens float64) {
+	if !u.drifting() || editing() {
+		return
+	}
+	d := geom.Point{
+		X: u.slide.X + geom.Pt(cfg.drift[0]*gens)*cellSize,
+		Y: u.slide.Y + geom.Pt(cfg.drift[1]*gens)*cellSize,
+	}
+	di, dj := math.Floor(float64(d.X/cellSize)), math.Floor(float64(d.Y/cellSize))
+	u.slide = geom.Point{X: d.X - geom.Pt(di)*cellSize, Y: d.Y - geom.Pt(dj)*cellSize}
+	if di != 0 || dj != 0 {
+		u.life.Shift(int(di), int(dj))
+		u.paint()
+	}
+	u.placeSheet()
+}
+
+// placeSheet moves the nodes of the cells of u by its slide.
+func (u *universe) placeSheet() {
+	eng.SetTransform(u.sheet, f32.Affine{
+		{1, 0, float32(u.slide.X)},
+		{0, 1, float32(u.slide.Y)},
+	})
+}
@@ -0,0 +1,72 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/geom"
+)
+
+func TestDrift(t *testing.T) {
+	e := testScene(t, 320, 480)
+	cfg.drift = [2]float64{0.25, 0.125}
+	univ = univ.resize(univ.h, univ.w)
+	univ.adopt(life.New(univ.cols, univ.rows))
+	forgetPointers()
+	block := [][2]int{{3, 3}, {4, 3}, {3, 4}, {4, 4}}
+	univ.life.SetCells(block, true)
+	univ.life.SetCells([][2]int{{univ.cols - 1, 6}}, true)
+	univ.paint()
+	slid := func(x, y geom.Pt) {
+		m := e.transforms[univ.sheet]
+		if m[0][2] != float32(x*cellSize) || m[1][2] != float32(y*cellSize) {
+			t.Errorf("cells moved by %v,%v, want %v,%v cells", m[0][2], m[1][2], x, y)
+		}
+	}
+
+	// Short of a whole cell, the cells slide, the game as it is.
+	univ.drift(2)
+	slid(0.5, 0.25)
+	if got, want := fmt.Sprint(alive()), fmt.Sprint(append(block, [2]int{univ.cols - 1, 6})); got != want {
+		t.Errorf("cells %v alive once slid", got)
+	}
+	// The cell of the right edge shows across the seam, on the left, as it is rather than echoed.
+	k := 6*univ.cols + univ.cols - 1
+	if len(univ.echoes[k]) == 0 {
+		t.Fatalf("no echo of the cell across the seam")
+	}
+	for _, n := range univ.echoes[k] {
+		if e.subTex[n] != e.subTex[univ.cells[k]] {
+			t.Errorf("echo shows %v, the cell %v", e.subTex[n], e.subTex[univ.cells[k]])
+		}
+	}
+	// And is touched there.
+	loc := cellLoc(0, 6)
+	loc.X -= cellSize / 4
+	if i, j, ok := univ.cellAt(loc); !ok || i != univ.cols-1 || j != 6 {
+		t.Errorf("cell (%d, %d) %v touched across the seam, want (%d, 6)", i, j, ok, univ.cols-1)
+	}
+
+	// A whole cell on, the game is shifted instead, around the torus.
+	univ.drift(2)
+	slid(0, 0.5)
+	want := fmt.Sprint([][2]int{{4, 3}, {5, 3}, {4, 4}, {5, 4}, {0, 6}})
+	if got := fmt.Sprint(alive()); got != want {
+		t.Errorf("cells %v alive once drifted a cell, want %v", got, want)
+	}
+
+	// The field stands still while edited, and out of the torus.
+	selecting = true
+	univ.drift(2)
+	selecting = false
+	slid(0, 0.5)
+	univ.setEdges(life.Bounded)
+	univ.drift(2)
+	slid(0, 0.5)
+	if got := fmt.Sprint(alive()); got != want {
+		t.Errorf("cells %v alive once drifted while edited or bounded, want %v", got, want)
+	}
+}
@@ -0,0 +1,168 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"time"
+
+	"golang.org/x/mobile/geom"
+)
+
+// A stroke is a touch gesture painting cells alive, as told by its brush, or while paused dead if it
+// started on an alive cell, so that a tap toggles the cell under it. While walling, it pins the
+// cells instead, or unpins them if it started on a pinned cell.
+type stroke struct {
+	brush  brush
+	i0, j0 int // Cell the stroke started on.
+	i, j   int // Last cell reached.
+	erase  bool
+	wall   bool // Whether the stroke pins cells, see walling.
+	// painted holds the indexes of the cells gone through so far, and drawn the cells the stroke
+	// set alive, or dead if erase, or pinned, or unpinned if erase, along with whether they were
+	// alive before in was.
+	painted map[int]bool
+	drawn   [][2]int
+	was     []bool
+	// For long presses: when and where, using absolute location, the stroke started, whether it
+	// started on a dead cell and whether the finger left holdRadius since.
+	start       time.Time
+	loc         geom.Point
+	born, moved bool
+	// speed is the speed of the finger in Pt/s between its last two locations, the last one being
+	// lastLoc at lastTime, for the spray brush.
+	speed    float64
+	lastLoc  geom.Point
+	lastTime time.Time
+	// edited is set once the stroke painted a cell, and the cells before it remembered to undo it.
+	edited bool
+}
+
+// cellAt returns the column and row of the cell under point, which uses absolute location.
+func (u *universe) cellAt(point geom.Point) (i, j int, ok bool) {
+	// Out of the screen area, cells zoomed in are hidden.
+	if x, y := point.X-0.1, point.Y-systemBarHeight-buttonBarHeight; x < 0 || y < 0 || x >= u.w || y >= u.h {
+		return 0, 0, false
+	}
+	// Undo the camera and the cell offsets set in newCell.
+	p := u.toField(point)
+	x, y := p.X-u.margin, p.Y-u.margin
+	if x < 0 || y < 0 {
+		return 0, 0, false
+	}
+	// And the drift, the cells of the opposite edge showing across the seams, see universe.slide.
+	if x -= u.slide.X; x < 0 {
+		x += geom.Pt(u.cols) * cellSize
+	}
+	if y -= u.slide.Y; y < 0 {
+		y += geom.Pt(u.rows) * cellSize
+	}
+	i, j = int(x/cellSize), int(y/cellSize)
+	if i >= u.cols || j >= u.rows {
+		return 0, 0, false
+	}
+	return i, j, true
+}
+
+// newStroke starts at loc a stroke on the cell (i, j) and paints it.
+func (u *universe) newStroke(i, j int, loc geom.Point) *stroke {
+	s := &stroke{
+		brush:   currentBrush,
+		i0:      i,
+		j0:      j,
+		i:       i,
+		j:       j,
+		painted: make(map[int]bool),
+		start:   time.Now(),
+		loc:     loc,
+		born:    !u.life.Alive(i, j),
+	}
+	s.lastLoc, s.lastTime = loc, s.start
+	s.erase = paused && !s.born
+	if s.wall = walling && paused && versus == nil; s.wall {
+		s.erase = u.life.Pinned(i, j)
+	}
+	if versus != nil {
+		// Seeds single cells.
+		s.brush = cellBrush
+	}
+	u.paintCell(s, i, j)
+	return s
+}
+
+// moveStroke continues s after the finger moved to loc, painting the cells on the way.
+func (u *universe) moveStroke(s *stroke, loc geom.Point) {
+	if math.Hypot(float64(loc.X-s.loc.X), float64(loc.Y-s.loc.Y)) > holdRadius {
+		s.moved = true
+	}
+	now := time.Now()
+	if dt := now.Sub(s.lastTime).Seconds(); dt > 0 {
+		s.speed = math.Hypot(float64(loc.X-s.lastLoc.X), float64(loc.Y-s.lastLoc.Y)) / dt
+	}
+	s.lastLoc, s.lastTime = loc, now
+	if i, j, ok := u.cellAt(loc); ok {
+		u.strokeTo(s, i, j)
+	}
+}
+
+// strokeTo paints the cells on the line from the last cell reached by s to (i, j), so a fast
+// swipe does not leave gaps, or with the line brush the line from the first cell to (i, j).
+func (u *universe) strokeTo(s *stroke, i, j int) {
+	if s.brush == lineBrush {
+		u.drawLine(s, i, j)
+	} else {
+		line(s.i, s.j, i, j, func(i, j int) { u.paintCell(s, i, j) })
+	}
+	s.i, s.j = i, j
+}
+
+// paintCell paints with the brush of s the cells of (i, j), and repaints them, unless s already
+// went through it.
+func (u *universe) paintCell(s *stroke, i, j int) {
+	k := j*u.cols + i
+	if s.painted[k] {
+		return
+	}
+	s.painted[k] = true
+	if versus != nil {
+		// Seeds the match instead.
+		versus.seed(i, j)
+		return
+	}
+	u.paintCells(s, s.brush.cells(i, j, u.spray, sprayDensity(s.speed)))
+}
+
+// line calls f for every cell on the line from (i0, j0) to (i1, j1), both included, using
+// Bresenham's algorithm.
+func line(i0, j0, i1, j1 int, f func(i, j int)) {
+	di, si := abs(i1-i0), 1
+	if i1 < i0 {
+		si = -1
+	}
+	dj, sj := -abs(j1-j0), 1
+	if j1 < j0 {
+		sj = -1
+	}
+	for err := di + dj; ; {
+		f(i0, j0)
+		if i0 == i1 && j0 == j1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dj {
+			err += dj
+			i0 += si
+		}
+		if e2 <= di {
+			err += di
+			j0 += sj
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
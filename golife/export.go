@@ -0,0 +1,112 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Colors of the exported snapshots.
+var (
+	snapshotDeadColor  = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	snapshotAliveColor = color.RGBA{0xa4, 0xc6, 0x39, 0xff} // The android green.
+)
+
+// Snapshot renders the field of u, each cell as a scale*scale block of pixels, alive cells tinted by
+// age if ages are shown. It only reads the game state, not the sprites.
+func (u *universe) Snapshot(scale int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, u.cols*scale, u.rows*scale))
+	for j := 0; j < u.rows; j++ {
+		for i := 0; i < u.cols; i++ {
+			c := u.snapshotColor(i, j)
+			for y := j * scale; y < (j+1)*scale; y++ {
+				for x := i * scale; x < (i+1)*scale; x++ {
+					img.Set(x, y, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// snapshotColor returns the color of the cell (i, j) in the snapshots.
+func (u *universe) snapshotColor(i, j int) color.Color {
+	if u.life.Pinned(i, j) {
+		return brickTints[brickTint(u.life.Alive(i, j))].c
+	}
+	if !u.life.Alive(i, j) {
+		if s := u.life.State(i, j); s > 1 {
+			return decayTints[decayLevel(s, u.life.Rule)].c
+		}
+		return snapshotDeadColor
+	}
+	var c color.Color = snapshotAliveColor
+	if u.life.Colors > 1 {
+		if k := u.life.Color(i, j); k > 0 {
+			c = colorTints[k-1].c
+		}
+		return c
+	}
+	for _, t := range ageTints {
+		if ageColors && u.life.Age(i, j) >= t.minAge {
+			c = t.c
+		}
+	}
+	return c
+}
+
+// exportDir returns the directory snapshots are written to: the shared pictures directory if the
+// device has one, otherwise the temporary directory.
+func exportDir() string {
+	if ext := os.Getenv("EXTERNAL_STORAGE"); ext != "" {
+		dir := filepath.Join(ext, "Pictures")
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			return dir
+		}
+	}
+	return os.TempDir()
+}
+
+// export writes a snapshot of u as a PNG file, its alive cells as an RLE file to share it with
+// other Life software, and the journal of the session as a .golife file to replay it, see session.
+// They are named after the current time. It returns the path of the files without their extension.
+func (u *universe) export() (string, error) {
+	name := filepath.Join(exportDir(), time.Now().Format("golife-20060102-150405"))
+	err := writeFile(name+".png", func(w io.Writer) error {
+		return png.Encode(w, u.Snapshot(cfg.exportScale))
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := writeFile(name+".rle", u.life.Pattern().WriteRLE); err != nil {
+		return "", err
+	}
+	if err := exportSession(u, name+".golife"); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// writeFile creates the file name with the content written by write. On error, the file is removed.
+func writeFile(name string, write func(io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(name)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(name)
+		return err
+	}
+	return nil
+}
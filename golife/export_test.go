@@ -0,0 +1,123 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// testGlider returns a universe of 6x5 cells with a glider in its top-left corner.
+func testGlider() *universe {
+	l := life.New(6, 5)
+	for _, c := range [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}} {
+		l.Set(c[0], c[1], true)
+	}
+	return &universe{cols: 6, rows: 5, life: l}
+}
+
+// sameColor reports whether the colors c and d are the same.
+func sameColor(c, d color.Color) bool {
+	r0, g0, b0, a0 := c.RGBA()
+	r1, g1, b1, a1 := d.RGBA()
+	return r0 == r1 && g0 == g1 && b0 == b1 && a0 == a1
+}
+
+func TestSnapshot(t *testing.T) {
+	defer func(a bool) { ageColors = a }(ageColors)
+	ageColors = false
+	u := testGlider()
+	img := u.Snapshot(3)
+	if b := img.Bounds(); b != image.Rect(0, 0, 18, 15) {
+		t.Fatalf("snapshot bounds %v, want 18x15 px", b)
+	}
+	for y := 0; y < 15; y++ {
+		for x := 0; x < 18; x++ {
+			want := color.Color(snapshotDeadColor)
+			if u.life.Alive(x/3, y/3) {
+				want = snapshotAliveColor
+			}
+			if c := img.At(x, y); !sameColor(c, want) {
+				t.Fatalf("pixel %d,%d of cell %d,%d %v, want %v", x, y, x/3, y/3, c, want)
+			}
+		}
+	}
+}
+
+func TestSnapshotTints(t *testing.T) {
+	defer func(a bool) { ageColors = a }(ageColors)
+	ageColors = true
+	// A block survives unchanged, so its cells age.
+	u := &universe{cols: 4, rows: 4, life: life.New(4, 4)}
+	u.life.SetCells([][2]int{{1, 1}, {2, 1}, {1, 2}, {2, 2}}, true)
+	if c := u.Snapshot(1).At(1, 1); !sameColor(c, snapshotAliveColor) {
+		t.Errorf("newborn cell is %v, want %v", c, snapshotAliveColor)
+	}
+	// The ramp goes from green to red.
+	if young, ancient := ageTints[0].c, ageTints[len(ageTints)-1].c; young.G <= young.R || ancient.R <= ancient.G {
+		t.Errorf("age tints from %v to %v, want green to red", young, ancient)
+	}
+	for _, tint := range ageTints {
+		for u.life.Age(1, 1) < tint.minAge {
+			u.life.Step()
+		}
+		if c := u.Snapshot(1).At(1, 1); !sameColor(c, tint.c) {
+			t.Errorf("cell %d generations old is %v, want %v", u.life.Age(1, 1), c, tint.c)
+		}
+	}
+	ageColors = false
+	if c := u.Snapshot(1).At(1, 1); !sameColor(c, snapshotAliveColor) {
+		t.Errorf("old cell is %v without ages, want %v", c, snapshotAliveColor)
+	}
+
+	// Colors are shown rather than ages.
+	u = testGlider()
+	u.life.Colors = 2
+	u.life.SetColor(1, 0, 1)
+	if c := u.Snapshot(1).At(1, 0); !sameColor(c, colorTints[0].c) {
+		t.Errorf("cell of color 1 is %v, want %v", c, colorTints[0].c)
+	}
+}
+
+func TestExport(t *testing.T) {
+	inTempDir(t, func(dir string) {
+		os.Setenv("EXTERNAL_STORAGE", "")
+		u := testGlider()
+		name, err := u.export()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Dir(name) != dir {
+			t.Errorf("exported to %s, out of %s", name, dir)
+		}
+		f, err := os.Open(name + ".png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		img, err := png.Decode(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != 6*cfg.exportScale || b.Dy() != 5*cfg.exportScale {
+			t.Errorf("exported snapshot of %v", b)
+		}
+
+		// Failures to write leave no file behind.
+		os.Chmod(dir, 0500)
+		defer os.Chmod(dir, 0700)
+		if _, err := u.export(); err == nil && os.Getuid() != 0 {
+			t.Errorf("exported to a read-only directory")
+		}
+		if files, _ := ioutil.ReadDir(dir); len(files) > 3 {
+			t.Errorf("%d files left by the failed export", len(files))
+		}
+	})
+}
@@ -0,0 +1,556 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+)
+
+// Colors of the fallback images.
+var (
+	fallbackColor      = color.RGBA{0x88, 0x88, 0x88, 0xff}
+	fallbackGlyphColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// fallbackImage returns a procedurally generated replacement for the image asset of the given
+// name: a solid square with a simple glyph for buttons, a plain square for cells and plain blocks
+// for digits. As with the assets, the top-left corner is left transparent since it is reused as
+// emptyImage.
+func fallbackImage(name string) image.Image {
+	if name == buttonsImage {
+		// The button images laid out as told by atlasImages.
+		const size = 72
+		rows := (len(atlasImages) + atlasColumns - 1) / atlasColumns
+		atlas := image.NewNRGBA(image.Rect(0, 0, atlasColumns*size, rows*size))
+		for k, img := range atlasImages {
+			src, x0, y0 := fallbackImage(img), k%atlasColumns*size, k/atlasColumns*size
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					atlas.Set(x0+x, y0+y, src.At(x, y))
+				}
+			}
+		}
+		return atlas
+	}
+	if name == digitsImage {
+		// A strip of 10 glyphs, each a plain block.
+		img := image.NewNRGBA(image.Rect(0, 0, 10*12, 16))
+		for x := 0; x < 10*12; x++ {
+			for y := 2; y < 14 && x%12 >= 2 && x%12 < 10; y++ {
+				img.Set(x, y, fallbackColor)
+			}
+		}
+		return img
+	}
+	const size = 72
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	fill := func(r image.Rectangle, c color.Color) {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				img.Set(x, y, c)
+			}
+		}
+	}
+	fill(image.Rect(4, 4, size-4, size-4), fallbackColor)
+
+	const mid = size / 2
+	switch name {
+	case pauseImage:
+		fill(image.Rect(22, 18, 32, 54), fallbackGlyphColor)
+		fill(image.Rect(40, 18, 50, 54), fallbackGlyphColor)
+	case playImage:
+		for y := 18; y < 54; y++ {
+			d := y - 18
+			if d > 18 {
+				d = 36 - d
+			}
+			fill(image.Rect(24, y, 24+2*d, y+1), fallbackGlyphColor)
+		}
+	case stepImage:
+		for y := 18; y < 54; y++ {
+			d := y - 18
+			if d > 18 {
+				d = 36 - d
+			}
+			fill(image.Rect(20, y, 20+3*d/2, y+1), fallbackGlyphColor)
+		}
+		fill(image.Rect(46, 18, 52, 54), fallbackGlyphColor)
+	case backImage:
+		for y := 18; y < 54; y++ {
+			d := y - 18
+			if d > 18 {
+				d = 36 - d
+			}
+			fill(image.Rect(52-3*d/2, y, 52, y+1), fallbackGlyphColor)
+		}
+		fill(image.Rect(20, 18, 26, 54), fallbackGlyphColor)
+	case decSpeedImage:
+		fill(image.Rect(18, mid-4, 54, mid+4), fallbackGlyphColor)
+	case incSpeedImage:
+		fill(image.Rect(18, mid-4, 54, mid+4), fallbackGlyphColor)
+		fill(image.Rect(mid-4, 18, mid+4, 54), fallbackGlyphColor)
+	case edgesImage:
+		fill(image.Rect(18, 18, 30, 22), fallbackGlyphColor)
+		fill(image.Rect(42, 18, 54, 22), fallbackGlyphColor)
+		fill(image.Rect(18, 50, 30, 54), fallbackGlyphColor)
+		fill(image.Rect(42, 50, 54, 54), fallbackGlyphColor)
+	case boundedImage:
+		fill(image.Rect(18, 18, 54, 22), fallbackGlyphColor)
+		fill(image.Rect(18, 50, 54, 54), fallbackGlyphColor)
+	case stableImage:
+		// A block.
+		fill(image.Rect(18, 18, 34, 34), fallbackGlyphColor)
+		fill(image.Rect(38, 18, 54, 34), fallbackGlyphColor)
+		fill(image.Rect(18, 38, 34, 54), fallbackGlyphColor)
+		fill(image.Rect(38, 38, 54, 54), fallbackGlyphColor)
+	case periodImage:
+		// A ring.
+		for y := 16; y < 56; y++ {
+			for x := 16; x < 56; x++ {
+				if d := (x-mid)*(x-mid) + (y-mid)*(y-mid); d >= 14*14 && d < 20*20 {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case growImage:
+		// A square with bars heading out of each side.
+		fill(image.Rect(26, 26, 46, 30), fallbackGlyphColor)
+		fill(image.Rect(26, 42, 46, 46), fallbackGlyphColor)
+		fill(image.Rect(26, 26, 30, 46), fallbackGlyphColor)
+		fill(image.Rect(42, 26, 46, 46), fallbackGlyphColor)
+		fill(image.Rect(mid-2, 10, mid+2, 20), fallbackGlyphColor)
+		fill(image.Rect(mid-2, 52, mid+2, 62), fallbackGlyphColor)
+		fill(image.Rect(10, mid-2, 20, mid+2), fallbackGlyphColor)
+		fill(image.Rect(52, mid-2, 62, mid+2), fallbackGlyphColor)
+	case reflectImage:
+		for y := 18; y < 54; y++ {
+			d := y - 18
+			if d > 18 {
+				d = 36 - d
+			}
+			fill(image.Rect(16, y, 16+d, y+1), fallbackGlyphColor)
+			fill(image.Rect(56-d, y, 56, y+1), fallbackGlyphColor)
+		}
+		fill(image.Rect(34, 14, 38, 58), fallbackGlyphColor)
+	case ruleImage:
+		for y := 18; y < 54; y += 14 {
+			for x := 18; x < 54; x += 14 {
+				fill(image.Rect(x, y, x+8, y+8), fallbackGlyphColor)
+			}
+		}
+	case patternImage:
+		for _, c := range [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}} {
+			x, y := 18+13*c[0], 18+13*c[1]
+			fill(image.Rect(x, y, x+10, y+10), fallbackGlyphColor)
+		}
+	case agesImage:
+		fill(image.Rect(18, 42, 26, 54), fallbackGlyphColor)
+		fill(image.Rect(32, 30, 40, 54), fallbackGlyphColor)
+		fill(image.Rect(46, 18, 54, 54), fallbackGlyphColor)
+	case exportImage:
+		fill(image.Rect(mid-4, 18, mid+4, 42), fallbackGlyphColor)
+		for y := 36; y < 48; y++ {
+			d := 48 - y
+			fill(image.Rect(mid-d, y, mid+d, y+1), fallbackGlyphColor)
+		}
+		fill(image.Rect(18, 50, 54, 54), fallbackGlyphColor)
+	case replayImage:
+		fill(image.Rect(18, 18, 54, 54), fallbackGlyphColor)
+		fill(image.Rect(26, 26, 46, 46), fallbackColor)
+	case recordImage:
+		for y := 18; y < 54; y++ {
+			for x := 18; x < 54; x++ {
+				if (x-mid)*(x-mid)+(y-mid)*(y-mid) < 18*18 {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case stopImage:
+		fill(image.Rect(22, 22, 50, 50), fallbackGlyphColor)
+	case soundImage, muteImage:
+		// A speaker, with a wave or crossed out.
+		fill(image.Rect(16, 28, 26, 44), fallbackGlyphColor)
+		for x := 26; x < 36; x++ {
+			fill(image.Rect(x, 28-(x-26), x+1, 44+(x-26)), fallbackGlyphColor)
+		}
+		if name == soundImage {
+			fill(image.Rect(42, 26, 46, 46), fallbackGlyphColor)
+			fill(image.Rect(50, 20, 54, 52), fallbackGlyphColor)
+			break
+		}
+		for d := -8; d <= 8; d++ {
+			fill(image.Rect(48+d-1, mid+d-1, 48+d+2, mid+d+2), fallbackGlyphColor)
+			fill(image.Rect(48+d-1, mid-d-1, 48+d+2, mid-d+2), fallbackGlyphColor)
+		}
+	case settingsImage:
+		// A gear.
+		for y := 14; y < 58; y++ {
+			for x := 14; x < 58; x++ {
+				d := (x-mid)*(x-mid) + (y-mid)*(y-mid)
+				tooth := x >= mid-4 && x < mid+4 || y >= mid-4 && y < mid+4
+				if d >= 8*8 && (d < 16*16 || tooth && d < 22*22) {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case seedImage:
+		// A die showing five.
+		fill(image.Rect(18, 18, 54, 54), fallbackGlyphColor)
+		for _, c := range [][2]int{{26, 26}, {46, 26}, {mid, mid}, {26, 46}, {46, 46}} {
+			fill(image.Rect(c[0]-3, c[1]-3, c[0]+3, c[1]+3), fallbackColor)
+		}
+	case selectImage:
+		// A dashed rectangle.
+		for x := 18; x < 54; x += 8 {
+			fill(image.Rect(x, 20, x+4, 24), fallbackGlyphColor)
+			fill(image.Rect(x, 48, x+4, 52), fallbackGlyphColor)
+		}
+		for y := 20; y < 52; y += 8 {
+			fill(image.Rect(18, y, 22, y+4), fallbackGlyphColor)
+			fill(image.Rect(50, y, 54, y+4), fallbackGlyphColor)
+		}
+	case copyImage:
+		fill(image.Rect(16, 16, 44, 44), fallbackGlyphColor)
+		fill(image.Rect(20, 20, 40, 40), fallbackColor)
+		fill(image.Rect(28, 28, 56, 56), fallbackGlyphColor)
+	case cutImage:
+		// Crossed blades.
+		for d := 0; d < 36; d++ {
+			fill(image.Rect(18+d, 18+d, 21+d, 21+d), fallbackGlyphColor)
+			fill(image.Rect(51-d, 18+d, 54-d, 21+d), fallbackGlyphColor)
+		}
+	case pasteImage:
+		fill(image.Rect(18, 20, 54, 58), fallbackGlyphColor)
+		fill(image.Rect(22, 26, 50, 54), fallbackColor)
+		fill(image.Rect(28, 14, 44, 24), fallbackGlyphColor)
+	case rotateImage:
+		// A ring open at the top left, and the head of its arrow.
+		for y := 16; y < 56; y++ {
+			for x := 16; x < 56; x++ {
+				d := (x-mid)*(x-mid) + (y-mid)*(y-mid)
+				if d >= 14*14 && d < 20*20 && (x >= mid || y >= mid) {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+		for d := 0; d < 8; d++ {
+			fill(image.Rect(mid-8+d, 10+d, mid-7+d, 30-d), fallbackGlyphColor)
+		}
+	case flipImage:
+		// A triangle mirrored across an axis, as its outline.
+		for y := 18; y < 54; y++ {
+			w := (y - 18) / 2
+			fill(image.Rect(mid-4-w, y, mid-4, y+1), fallbackGlyphColor)
+			fill(image.Rect(mid+4+w-2, y, mid+4+w, y+1), fallbackGlyphColor)
+		}
+		fill(image.Rect(mid+4, 52, mid+22, 54), fallbackGlyphColor)
+		fill(image.Rect(mid+4, 18, mid+6, 54), fallbackGlyphColor)
+		fill(image.Rect(mid-1, 14, mid+1, 58), fallbackGlyphColor)
+	case undoImage, redoImage:
+		// A hooked arrow heading back to the left, mirrored to head right.
+		set := func(x, y int) {
+			if name == redoImage {
+				x = size - 1 - x
+			}
+			img.Set(x, y, fallbackGlyphColor)
+		}
+		for y := 8; y < 58; y++ {
+			for x := 8; x < 58; x++ {
+				d := (x-40)*(x-40) + (y-40)*(y-40)
+				hook := x >= 40 && d >= 12*12 && d < 18*18
+				bars := x >= 22 && x < 40 && (y < 28 || y >= 52)
+				head := x < 24 && y >= 25-(x-8) && y < 26+(x-8)
+				if hook || bars || head {
+					set(x, y)
+				}
+			}
+		}
+	case shareImage:
+		// Three nodes linked by two edges.
+		for _, c := range [][2]int{{50, 18}, {22, mid}, {50, 54}} {
+			fill(image.Rect(c[0]-8, c[1]-8, c[0]+8, c[1]+8), fallbackGlyphColor)
+		}
+		for x := 22; x < 50; x++ {
+			d := (x - 22) * 18 / 28
+			fill(image.Rect(x, mid-d-2, x+1, mid-d+2), fallbackGlyphColor)
+			fill(image.Rect(x, mid+d-2, x+1, mid+d+2), fallbackGlyphColor)
+		}
+	case searchImage:
+		// A magnifier: a ring with a handle to the bottom right.
+		for y := 8; y < 64; y++ {
+			for x := 8; x < 64; x++ {
+				d := (x-30)*(x-30) + (y-30)*(y-30)
+				ring := d >= 13*13 && d < 20*20
+				handle := x >= 42 && y >= 42 && x-y < 5 && y-x < 5
+				if ring || handle {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case analyzeImage:
+		// Two cells, each framed.
+		for _, o := range []int{8, 38} {
+			fill(image.Rect(o, o, o+26, o+26), fallbackGlyphColor)
+			fill(image.Rect(o+3, o+3, o+23, o+23), fallbackColor)
+			fill(image.Rect(o+8, o+8, o+18, o+18), fallbackGlyphColor)
+		}
+	case accessImage:
+		// A person with open arms.
+		for y := 9; y < 24; y++ {
+			for x := 29; x < 44; x++ {
+				if (x-36)*(x-36)+(y-16)*(y-16) < 7*7 {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+		fill(image.Rect(12, 26, 60, 32), fallbackGlyphColor)
+		fill(image.Rect(31, 26, 41, 46), fallbackGlyphColor)
+		for y := 44; y < 62; y++ {
+			d := (y - 44) / 2
+			fill(image.Rect(31-d, y, 37-d, y+1), fallbackGlyphColor)
+			fill(image.Rect(35+d, y, 41+d, y+1), fallbackGlyphColor)
+		}
+	case hapticsImage:
+		// A phone shaking, between two pairs of strokes.
+		fill(image.Rect(26, 12, 46, 60), fallbackGlyphColor)
+		fill(image.Rect(30, 16, 42, 56), fallbackColor)
+		fill(image.Rect(32, 50, 40, 54), fallbackGlyphColor)
+		for _, x := range []int{6, 14, 54, 62} {
+			h := 12
+			if x == 14 || x == 54 {
+				h = 24
+			}
+			fill(image.Rect(x, 36-h/2, x+4, 36+h/2), fallbackGlyphColor)
+		}
+	case forwardImage:
+		// Two arrowheads heading right.
+		for x := 10; x < 36; x++ {
+			w := (x - 10) * 18 / 26
+			fill(image.Rect(x, 18+w, x+1, 54-w), fallbackGlyphColor)
+			fill(image.Rect(x+26, 18+w, x+27, 54-w), fallbackGlyphColor)
+		}
+	case versusImage:
+		// Two arrowheads facing each other.
+		for x := 8; x < 32; x++ {
+			w := (x - 8) * 16 / 24
+			fill(image.Rect(x, 20+w, x+1, 52-w), fallbackGlyphColor)
+			fill(image.Rect(size-1-x, 20+w, size-x, 52-w), fallbackGlyphColor)
+		}
+	case wonImage:
+		// A crown.
+		fill(image.Rect(14, 46, 58, 56), fallbackGlyphColor)
+		for x := 14; x < 58; x++ {
+			// Three spikes, 22 px wide and 28 px high.
+			d := (x - 14) % 22
+			if d > 11 {
+				d = 22 - d
+			}
+			fill(image.Rect(x, 18+d*28/11/2, x+1, 46), fallbackGlyphColor)
+		}
+	case lostImage:
+		// A tombstone, with a cross.
+		for y := 14; y < 60; y++ {
+			for x := 20; x < 52; x++ {
+				d := (x-mid)*(x-mid) + (y-30)*(y-30)
+				cross := x >= 34 && x < 38 && y >= 20 && y < 48 || x >= 27 && x < 45 && y >= 27 && y < 31
+				if (y >= 30 || d < 16*16) && !cross {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case cellBrushImage:
+		fill(image.Rect(26, 26, 46, 46), fallbackGlyphColor)
+	case blockBrushImage:
+		// A block of 3x3 cells.
+		for y := 15; y < 55; y += 15 {
+			for x := 15; x < 55; x += 15 {
+				fill(image.Rect(x, y, x+12, y+12), fallbackGlyphColor)
+			}
+		}
+	case lineBrushImage:
+		// A line between two end cells.
+		fill(image.Rect(10, 50, 22, 62), fallbackGlyphColor)
+		fill(image.Rect(50, 10, 62, 22), fallbackGlyphColor)
+		for x := 16; x < 56; x++ {
+			fill(image.Rect(x, 70-x-4, x+1, 70-x+4), fallbackGlyphColor)
+		}
+	case sprayBrushImage:
+		// Scattered cells.
+		for _, c := range [][2]int{{20, 16}, {40, 12}, {54, 26}, {30, 30}, {14, 40}, {46, 42}, {26, 54}, {52, 56}} {
+			fill(image.Rect(c[0]-4, c[1]-4, c[0]+4, c[1]+4), fallbackGlyphColor)
+		}
+	case trailImage:
+		// A cell moving up and right, leaving a trail of smaller and smaller cells.
+		for _, r := range []image.Rectangle{
+			image.Rect(42, 12, 60, 30), image.Rect(29, 31, 41, 43), image.Rect(20, 46, 28, 54), image.Rect(13, 57, 17, 61),
+		} {
+			fill(r, fallbackGlyphColor)
+		}
+	case symmetryImage:
+		// Two triangles mirrored across an axis.
+		for y := 18; y < 54; y++ {
+			w := (y - 18) / 2
+			fill(image.Rect(mid-4-w, y, mid-4, y+1), fallbackGlyphColor)
+			fill(image.Rect(mid+4, y, mid+4+w, y+1), fallbackGlyphColor)
+		}
+		fill(image.Rect(mid-1, 14, mid+1, 58), fallbackGlyphColor)
+	case nudgeLeftImage, nudgeRightImage, nudgeUpImage, nudgeDownImage:
+		// An arrowhead heading the way the cells move, drawn heading right and turned.
+		for v := 22; v < 50; v++ {
+			for u := 0; u < size; u++ {
+				if d := u - mid; d*d*28*28 >= (50-v)*(50-v)*18*18 {
+					continue
+				}
+				x, y := v, u
+				switch name {
+				case nudgeLeftImage:
+					x = size - 1 - v
+				case nudgeDownImage:
+					x, y = u, v
+				case nudgeUpImage:
+					x, y = u, size-1-v
+				}
+				img.Set(x, y, fallbackGlyphColor)
+			}
+		}
+	case fitImage:
+		// The corners of a frame, each an L 14 px long.
+		for _, c := range [][2]int{{14, 14}, {58, 14}, {14, 58}, {58, 58}} {
+			x, y := c[0], c[1]
+			if x > mid {
+				x -= 14
+			}
+			if y > mid {
+				y -= 14
+			}
+			ex, ey := c[0], c[1]
+			if ex > mid {
+				ex -= 6
+			}
+			if ey > mid {
+				ey -= 6
+			}
+			fill(image.Rect(x, ey, x+14, ey+6), fallbackGlyphColor)
+			fill(image.Rect(ex, y, ex+6, y+14), fallbackGlyphColor)
+		}
+	case ringImage, dotImage:
+		// A ring, or a disc.
+		inner := 28
+		if name == dotImage {
+			inner = 0
+		}
+		for y := 0; y < 72; y++ {
+			for x := 0; x < 72; x++ {
+				dx, dy := x-mid, y-mid
+				if d := dx*dx + dy*dy; d < 34*34 && d >= inner*inner {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case showcaseImage:
+		// A play triangle in a frame, as a screen playing.
+		for y := 14; y < 58; y++ {
+			for x := 10; x < 62; x++ {
+				frame := x < 14 || x >= 58 || y < 18 || y >= 54
+				dy := y - mid
+				play := x >= 28 && x < 48 && 4*dy*dy < (48-x)*(48-x)
+				if frame || play {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case mutationImage:
+		// Scattered cells, one of them framed, as flipped cells highlighted.
+		for y := 10; y < 62; y++ {
+			for x := 10; x < 62; x++ {
+				cell := x >= 42 && x < 54 && y >= 16 && y < 28 || x >= 16 && x < 28 && y >= 46 && y < 56
+				frame := x >= 18 && x < 40 && y >= 14 && y < 36 && (x < 22 || x >= 36 || y < 18 || y >= 32)
+				inner := x >= 25 && x < 33 && y >= 21 && y < 29
+				if cell || frame || inner {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case profileImage:
+		// A head over shoulders, as the settings of a user.
+		for y := 10; y < 62; y++ {
+			for x := 10; x < 62; x++ {
+				dx, hy, sy := x-mid, y-26, y-62
+				head := dx*dx+hy*hy < 11*11
+				shoulders := y < 58 && dx*dx+sy*sy < 22*22
+				if head || shoulders {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case compactImage:
+		// Four small squares packed together, as controls closer.
+		for y := 22; y < 50; y++ {
+			for x := 22; x < 50; x++ {
+				if (x < 34 || x >= 38) && (y < 34 || y >= 38) {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case largeImage:
+		// A small square and a large one, as controls growing.
+		for y := 14; y < 58; y++ {
+			for x := 14; x < 58; x++ {
+				small := x < 30 && y >= 42 && (x < 18 || x >= 26 || y < 46 || y >= 54)
+				large := x >= 34 && y < 38 && (x < 38 || x >= 54 || y < 18 || y >= 34)
+				if small || large {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case localeImage:
+		// A globe: a circle crossed by the equator and a meridian.
+		for y := 14; y < 58; y++ {
+			for x := 14; x < 58; x++ {
+				dx, dy := x-mid, y-mid
+				d := dx*dx + dy*dy
+				m := dx*dx*22*22 + dy*dy*9*9
+				if d < 22*22 && (d >= 18*18 || dy >= -2 && dy < 2 || m >= 7*7*22*22 && m < 9*9*22*22) {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case followImage:
+		// A compass: a ring around a needle pointing up.
+		for y := 10; y < 62; y++ {
+			for x := 10; x < 62; x++ {
+				dx, dy := x-mid, y-mid
+				d := dx*dx + dy*dy
+				ring := d < 26*26 && d >= 22*22
+				needle := dy > -18 && dy < 18 && 3*abs(dx) < 18-abs(dy)
+				if ring || needle {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	case wallImage, liveWallImage:
+		// A wall of three courses of bricks, their joints staggered, hollow for the dead walls.
+		for course := 0; course < 3; course++ {
+			y := 16 + course*14
+			for x := 12 - course%2*10; x < 60; x += 20 {
+				r := image.Rect(x, y, x+17, y+11).Intersect(image.Rect(12, 16, 60, 58))
+				fill(r, fallbackGlyphColor)
+				if name == wallImage {
+					fill(r.Inset(3), fallbackColor)
+				}
+			}
+		}
+	case themeImage:
+		// A half filled circle.
+		for y := 18; y < 54; y++ {
+			for x := 18; x < 54; x++ {
+				d := (x-mid)*(x-mid) + (y-mid)*(y-mid)
+				if d < 18*18 && (x < mid || d >= 14*14) {
+					img.Set(x, y, fallbackGlyphColor)
+				}
+			}
+		}
+	}
+	return img
+}
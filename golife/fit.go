@@ -0,0 +1,119 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"time"
+
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite/clock"
+)
+
+const (
+	// fitPad is the number of cells left around the alive ones once the view fits them.
+	fitPad = 2
+	// fitTime is how long the view takes to fit the cells, in ticks of the clock, 300ms.
+	fitTime = 18
+	// A pinch whose fingers are lifted within twoFingerTap without moving further than holdRadius
+	// is a two finger tap, and two of them doubleTapTime apart fit the view to the cells.
+	twoFingerTap  = 250 * time.Millisecond
+	doubleTapTime = 400 * time.Millisecond
+)
+
+// A view is a camera of the universe, see universe.look: its zoom and the location on the field
+// shown at the center of the screen area.
+type view struct {
+	zoom   float32
+	anchor geom.Point
+}
+
+// A fitting moves the camera of the universe from a view to another, easing in and out over
+// fitTime.
+type fitting struct {
+	from, to view
+	start    clock.Time // -1 until the first frame.
+}
+
+// lastTwoFingerTap is when the last two finger tap not part of a double tap ended.
+var lastTwoFingerTap time.Time
+
+// center returns the absolute location of the center of the screen area of u.
+func (u *universe) center() geom.Point {
+	return geom.Point{X: 0.1 + u.w/2, Y: systemBarHeight + buttonBarHeight + u.h/2}
+}
+
+// liveBounds returns the smallest rectangle of the field of u holding all its alive cells, empty
+// if none.
+func (u *universe) liveBounds() image.Rectangle {
+	var r image.Rectangle
+	for j := 0; j < u.rows; j++ {
+		for i := 0; i < u.cols; i++ {
+			if u.life.Alive(i, j) {
+				r = r.Union(image.Rect(i, j, i+1, j+1))
+			}
+		}
+	}
+	return r
+}
+
+// fitView returns the view of u showing all its alive cells, fitPad cells around them included as
+// long as they are on the field, as large as the screen area and the bounds of the cell size let
+// them be. Without alive cells, it shows the whole field.
+func (u *universe) fitView() view {
+	field := image.Rect(0, 0, u.cols, u.rows)
+	r := u.liveBounds()
+	if r.Empty() {
+		r = field
+	} else {
+		r = r.Inset(-fitPad).Intersect(field)
+	}
+	w, h := geom.Pt(r.Dx())*cellSize, geom.Pt(r.Dy())*cellSize
+	zoom := float32(u.w / w)
+	if z := float32(u.h / h); z < zoom {
+		zoom = z
+	}
+	return view{zoom: zoom, anchor: geom.Point{
+		X: u.margin + geom.Pt(r.Min.X+r.Max.X)/2*cellSize,
+		Y: u.margin + geom.Pt(r.Min.Y+r.Max.Y)/2*cellSize,
+	}}
+}
+
+// startFit starts moving the camera of the universe to fit its alive cells, see fitView.
+func startFit() {
+	views.fit = &fitting{
+		from:  view{zoom: univ.zoom, anchor: univ.toField(univ.center())},
+		to:    univ.fitView(),
+		start: -1,
+	}
+}
+
+// updateFit moves the camera of the universe along the current fitting, if any, for the frame of
+// the given time.
+func updateFit(t clock.Time) {
+	f := views.fit
+	if f == nil {
+		return
+	}
+	if f.start < 0 {
+		f.start = t
+	}
+	x := clock.EaseInOut(f.start, f.start+fitTime, t)
+	univ.look(f.from.zoom+(f.to.zoom-f.from.zoom)*x, geom.Point{
+		X: f.from.anchor.X + (f.to.anchor.X-f.from.anchor.X)*geom.Pt(x),
+		Y: f.from.anchor.Y + (f.to.anchor.Y-f.from.anchor.Y)*geom.Pt(x),
+	}, univ.center())
+	if x >= 1 {
+		views.fit = nil
+	}
+}
+
+// twoFingerTapped tells a two finger tap ended, which fits the view if it follows another one.
+func twoFingerTapped() {
+	if time.Since(lastTwoFingerTap) < doubleTapTime {
+		lastTwoFingerTap = time.Time{}
+		startFit()
+		return
+	}
+	lastTwoFingerTap = time.Now()
+}
@@ -0,0 +1,124 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+// near reports whether the locations p and q are less than a Pt apart.
+func near(p, q geom.Point) bool {
+	return math.Hypot(float64(p.X-q.X), float64(p.Y-q.Y)) < 1
+}
+
+func TestFitView(t *testing.T) {
+	gestureScene(t)
+	setPaused(true)
+	// Without cells, the whole field.
+	v := univ.fitView()
+	fw, fh := geom.Pt(univ.cols)*cellSize, geom.Pt(univ.rows)*cellSize
+	if v.zoom != float32(math.Min(float64(univ.w/fw), float64(univ.h/fh))) || !near(v.anchor, geom.Point{X: fw / 2, Y: fh / 2}) {
+		t.Errorf("empty field fit as %+v", v)
+	}
+
+	// Off-center cells, padded, fill the screen area along one axis and show at its center.
+	univ.life.SetCells([][2]int{{10, 20}, {29, 20}, {10, 29}, {29, 29}}, true)
+	v = univ.fitView()
+	if want := float32(math.Min(float64(univ.w/(24*cellSize)), float64(univ.h/(14*cellSize)))); v.zoom != want {
+		t.Errorf("zoom %v, want %v to show 24x14 cells", v.zoom, want)
+	}
+	if want := (geom.Point{X: 20 * cellSize, Y: 25 * cellSize}); !near(v.anchor, want) {
+		t.Errorf("anchor %v, want %v", v.anchor, want)
+	}
+	univ.look(v.zoom, v.anchor, univ.center())
+	a, b := cellLoc(10, 20), cellLoc(29, 29)
+	if mid := (geom.Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}); !near(mid, univ.center()) {
+		t.Errorf("cells centered at %v, want %v", mid, univ.center())
+	}
+	for _, c := range [][2]int{{8, 18}, {31, 31}} {
+		if i, j, ok := univ.cellAt(cellLoc(c[0], c[1])); !ok || i != c[0] || j != c[1] {
+			t.Errorf("padding cell %v out of the screen", c)
+		}
+	}
+
+	// Cells along the edges are not padded beyond them, and the view stops at the edges.
+	for _, c := range [][2]int{{0, 0}, {univ.cols - 5, univ.rows - 4}} {
+		univ.life.Clear(0, 0, univ.cols, univ.rows)
+		univ.life.SetCells([][2]int{{c[0], c[1]}, {c[0] + 4, c[1] + 3}}, true)
+		v = univ.fitView()
+		r := univ.liveBounds().Inset(-fitPad)
+		if c[0] == 0 {
+			r.Min.X, r.Min.Y = 0, 0
+		} else {
+			r.Max.X, r.Max.Y = univ.cols, univ.rows
+		}
+		if want := (geom.Point{X: geom.Pt(r.Min.X+r.Max.X) / 2 * cellSize, Y: geom.Pt(r.Min.Y+r.Max.Y) / 2 * cellSize}); !near(v.anchor, want) {
+			t.Errorf("cells at %v: anchor %v, want %v", c, v.anchor, want)
+		}
+		univ.look(v.zoom, v.anchor, univ.center())
+		if univ.zoom != float32(maxCellSize/cellSize) {
+			t.Errorf("cells at %v: zoom %v, want the largest", c, univ.zoom)
+		}
+		corner := geom.Point{X: 1, Y: systemBarHeight + buttonBarHeight + 1}
+		if c[0] != 0 {
+			corner = geom.Point{X: geom.Width - 1, Y: geom.Height - 1}
+		}
+		if i, j, _ := univ.cellAt(corner); i != c[0] && i != c[0]+4 || j != c[1] && j != c[1]+3 {
+			t.Errorf("cells at %v: cell %d,%d in the corner of the screen", c, i, j)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	gestureScene(t)
+	setPaused(true)
+	univ.life.SetCells([][2]int{{10, 20}, {29, 29}}, true)
+	want := univ.fitView()
+
+	// The camera eases from the current view to the one fitting the cells.
+	startFit()
+	updateFit(100)
+	if univ.zoom != 1 {
+		t.Errorf("zoom %v once started, want 1", univ.zoom)
+	}
+	updateFit(100 + fitTime/2)
+	if univ.zoom <= 1 || univ.zoom >= want.zoom {
+		t.Errorf("zoom %v halfway, want between 1 and %v", univ.zoom, want.zoom)
+	}
+	updateFit(100 + fitTime)
+	if univ.zoom != want.zoom || !near(univ.toField(univ.center()), want.anchor) || views.fit != nil {
+		t.Errorf("view %v at %v once over, want %+v", univ.zoom, univ.toField(univ.center()), want)
+	}
+
+	// A touch stops it short.
+	univ.look(1, geom.Point{}, univ.center())
+	startFit()
+	updateFit(200)
+	updateFit(200 + fitTime/2)
+	z := univ.zoom
+	finger(1, event.TouchStart, buttonLoc(t, pauseImage))
+	finger(1, event.TouchEnd, buttonLoc(t, pauseImage))
+	updateFit(200 + fitTime)
+	if views.fit != nil || univ.zoom != z {
+		t.Errorf("fitting went on after a touch, zoom %v, want %v", univ.zoom, z)
+	}
+
+	// Two quick two finger taps fit the view, not a single one.
+	univ.look(1, geom.Point{}, univ.center())
+	for k := 0; k < 2; k++ {
+		if views.fit != nil {
+			t.Errorf("fitting after %d two finger taps", k)
+		}
+		finger(1, event.TouchStart, cellLoc(5, 20))
+		finger(2, event.TouchStart, cellLoc(15, 20))
+		finger(1, event.TouchEnd, cellLoc(5, 20))
+		finger(2, event.TouchEnd, cellLoc(15, 20))
+	}
+	if views.fit == nil {
+		t.Errorf("two finger double tap not fitting")
+	}
+}
@@ -0,0 +1,167 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+const (
+	// fixtureVersion is the version of the fixtures written by exportFixture. It must change
+	// whenever their format does, the fixtures of former versions recorded again.
+	fixtureVersion = 1
+	// maxFixtureTouches bounds the touches logged, past which the touch log starts over from the
+	// current game.
+	maxFixtureTouches = 10000
+	// fixtureFile is the file of the app directory the debug console exports the touch log to.
+	fixtureFile = "golife.fixture.json"
+)
+
+// A fixture is a log of the touches of the screen from a game, which the replay regression
+// tests play back through touch, stepping the game between the touches to the generations they
+// happened at, see TestFixtures. The debug console exports the touch log as a fixture, so
+// that a bug found on a device turns into a test once the file is copied to golife/testdata.
+//
+// It is a JSON object of the screen, the settings as saved by saveSettings, the camera, the game
+// as encoded by life.Life.MarshalBinary, the touches and the game as of the export. Times are
+// relative to the first touch. The spray and long presses depend on randomness and timers the
+// touches do not replay, so the logs of them may not end in the same game.
+type fixture struct {
+	Version  int                        `json:"version"`
+	Width    geom.Pt                    `json:"width"` // Of the screen.
+	Height   geom.Pt                    `json:"height"`
+	Settings map[string]json.RawMessage `json:"settings"`
+	// Zoom is the zoom of the camera, and Anchor the location of the field, see universe.toField,
+	// showing in the middle of the screen area of the universe.
+	Zoom    float32        `json:"zoom"`
+	Anchor  geom.Point     `json:"anchor"`
+	Paused  bool           `json:"paused"`
+	Start   []byte         `json:"start"`
+	Touches []fixtureTouch `json:"touches"`
+	End     []byte         `json:"end"`
+}
+
+// A fixtureTouch is a touch of a fixture.
+type fixtureTouch struct {
+	At         int64   `json:"at"`         // Milliseconds since the first touch.
+	Generation int     `json:"generation"` // Of the game as the touch happened.
+	ID         int64   `json:"id"`
+	Type       string  `json:"type"` // One of start, move and end.
+	X          geom.Pt `json:"x"`
+	Y          geom.Pt `json:"y"`
+}
+
+// touchTypes are the types of touches by their names in the fixtures.
+var touchTypes = map[string]event.TouchType{
+	"start": event.TouchStart,
+	"move":  event.TouchMove,
+	"end":   event.TouchEnd,
+}
+
+// touchLog records the touches of the screen since the game was last replaced as a whole, see
+// session, nil until the first touch. touchLogStart is when that touch happened.
+var (
+	touchLog      *fixture
+	touchLogStart time.Time
+)
+
+// middle returns the absolute location of the middle of the screen area of u.
+func (u *universe) middle() geom.Point {
+	return geom.Point{X: 0.1 + u.w/2, Y: systemBarHeight + buttonBarHeight + u.h/2}
+}
+
+// logTouch adds t to the touch log, starting one from the current game if there is none or the
+// log is full.
+func logTouch(t event.Touch) {
+	if univ == nil {
+		return
+	}
+	if touchLog == nil || len(touchLog.Touches) >= maxFixtureTouches {
+		if touchLog != nil {
+			log.Printf("touch log of %d touches full, starting over", len(touchLog.Touches))
+		}
+		settings, err := settingsJSON()
+		if err != nil {
+			log.Printf("logging touches: %v", err)
+			return
+		}
+		start, _ := univ.life.MarshalBinary()
+		touchLog = &fixture{
+			Version:  fixtureVersion,
+			Width:    geom.Width,
+			Height:   geom.Height,
+			Settings: settings,
+			Zoom:     univ.zoom,
+			Anchor:   univ.toField(univ.middle()),
+			Paused:   paused,
+			Start:    start,
+		}
+		touchLogStart = time.Now()
+	}
+	name := "end"
+	for n, typ := range touchTypes {
+		if typ == t.Type {
+			name = n
+		}
+	}
+	touchLog.Touches = append(touchLog.Touches, fixtureTouch{
+		At:         int64(time.Since(touchLogStart) / time.Millisecond),
+		Generation: univ.life.Generation(),
+		ID:         int64(t.ID),
+		Type:       name,
+		X:          t.Loc.X,
+		Y:          t.Loc.Y,
+	})
+}
+
+// unlogTouch removes the latest touch logged, if any.
+func unlogTouch() {
+	if l := touchLog; l != nil && len(l.Touches) > 0 {
+		l.Touches = l.Touches[:len(l.Touches)-1]
+	}
+}
+
+// exportFixture writes the touch log, ending with the current game, to the file name.
+func exportFixture(name string) error {
+	if touchLog == nil {
+		return errors.New("no touches recorded")
+	}
+	f := *touchLog
+	f.End, _ = univ.life.MarshalBinary()
+	b, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFile(name, func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	})
+}
+
+// decodeFixture returns the fixture b. It checks its format and version, not its games.
+func decodeFixture(b []byte) (*fixture, error) {
+	var f fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	switch {
+	case f.Version < 1:
+		return nil, errors.New("not a fixture")
+	case f.Version != fixtureVersion:
+		return nil, fmt.Errorf("fixture of version %d, want %d: record it again", f.Version, fixtureVersion)
+	}
+	for _, t := range f.Touches {
+		if _, ok := touchTypes[t.Type]; !ok {
+			return nil, fmt.Errorf("touch of unknown type %q", t.Type)
+		}
+	}
+	return &f, nil
+}
@@ -0,0 +1,183 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+// replayFixture plays the fixture of the given name back on a new scene, and reports an error
+// unless it ends in the game it was exported with.
+func replayFixture(t *testing.T, name string, f *fixture) {
+	testScene(t, f.Width, f.Height)
+	c := cfg
+	var keys []string
+	for k := range f.Settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		set, ok := configFields[k]
+		if !ok {
+			t.Fatalf("%s: unknown setting %q", name, k)
+		}
+		if err := set(&c, f.Settings[k]); err != nil {
+			t.Fatalf("%s: setting %q: %v", name, k, err)
+		}
+	}
+	old := cfg
+	cfg = c
+	applySettings(old)
+
+	start, end := life.New(1, 1), life.New(1, 1)
+	for _, g := range []struct {
+		l *life.Life
+		b []byte
+	}{{start, f.Start}, {end, f.End}} {
+		if err := g.l.UnmarshalBinary(g.b); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	if w, h := start.Bounds(); w != univ.cols || h != univ.rows {
+		t.Fatalf("%s: game of %dx%d cells on a universe of %dx%d", name, w, h, univ.cols, univ.rows)
+	}
+	univ.adopt(start)
+	univ.look(f.Zoom, f.Anchor, univ.middle())
+	forgetPointers()
+	setPaused(f.Paused)
+	stepTo := func(g int) {
+		for univ.life.Generation() < g {
+			univ.Step()
+		}
+		if n := univ.life.Generation(); n != g {
+			t.Fatalf("%s: at generation %d, want %d", name, n, g)
+		}
+	}
+	for _, tc := range f.Touches {
+		stepTo(tc.Generation)
+		touch(event.Touch{ID: event.TouchSequenceID(tc.ID), Type: touchTypes[tc.Type], Loc: geom.Point{X: tc.X, Y: tc.Y}})
+	}
+	stepTo(end.Generation())
+	if b, _ := univ.life.MarshalBinary(); !bytes.Equal(b, f.End) {
+		t.Errorf("%s: replayed to %d cells, want %d", name, univ.life.Population(), end.Population())
+	}
+}
+
+// TestFixtures replays the fixtures exported by the debug console to testdata.
+func TestFixtures(t *testing.T) {
+	names, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := decodeFixture(b)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		replayFixture(t, name, f)
+	}
+}
+
+func TestFixtureExport(t *testing.T) {
+	gestureScene(t)
+	setPaused(true)
+	// A tap and a stroke paint cells, the step button steps between them, and the game steps on
+	// apart from the touches.
+	finger(1, event.TouchStart, cellLoc(4, 4))
+	finger(1, event.TouchEnd, cellLoc(4, 4))
+	finger(2, event.TouchStart, buttonLoc(t, stepImage))
+	finger(2, event.TouchEnd, buttonLoc(t, stepImage))
+	finger(3, event.TouchStart, cellLoc(2, 6))
+	finger(3, event.TouchMove, cellLoc(6, 6))
+	finger(3, event.TouchEnd, cellLoc(6, 6))
+	univ.Step()
+	want := alive()
+	if len(want) == 0 {
+		t.Fatalf("no cells painted")
+	}
+	var b []byte
+	inTempDir(t, func(dir string) {
+		name := filepath.Join(dir, fixtureFile)
+		if err := exportFixture(name); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		if b, err = ioutil.ReadFile(name); err != nil {
+			t.Fatal(err)
+		}
+	})
+	f, err := decodeFixture(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Touches) != 7 || f.Touches[0].At != 0 || f.Touches[2].Generation != 0 || f.Touches[4].Generation != 1 {
+		t.Errorf("touches exported %+v", f.Touches)
+	}
+	replayFixture(t, fixtureFile, f)
+	if got := alive(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("replayed to %v, want %v", got, want)
+	}
+}
+
+func TestFixtureVersion(t *testing.T) {
+	for _, c := range []struct {
+		fixture string
+		ok      bool
+	}{
+		{fmt.Sprintf(`{"version": %d, "touches": [{"type": "start"}, {"type": "end"}]}`, fixtureVersion), true},
+		{fmt.Sprintf(`{"version": %d}`, fixtureVersion+1), false},
+		{`{"touches": []}`, false},
+		{fmt.Sprintf(`{"version": %d, "touches": [{"type": "hover"}]}`, fixtureVersion), false},
+		{`[]`, false},
+	} {
+		if _, err := decodeFixture([]byte(c.fixture)); (err == nil) != c.ok {
+			t.Errorf("fixture %s decoded with error %v", c.fixture, err)
+		}
+	}
+}
+
+func TestFixtureConsole(t *testing.T) {
+	gestureScene(t)
+	debugging = true
+	updateDebug()
+	defer func() {
+		debugging = false
+		updateDebug()
+	}()
+	// A tap on a cell away from the debug overlay, which covers the top left corner.
+	finger(1, event.TouchStart, cellLoc(20, 20))
+	finger(1, event.TouchEnd, cellLoc(20, 20))
+	// The touches opening and using the console are not logged.
+	o := views.overlay.rect
+	loc := geom.Point{X: (o.Min.X + o.Max.X) / 2, Y: (o.Min.Y + o.Max.Y) / 2}
+	finger(2, event.TouchStart, loc)
+	finger(2, event.TouchEnd, loc)
+	if views.console == nil {
+		t.Fatalf("console closed once the debug overlay was tapped")
+	}
+	inTempDir(t, func(dir string) {
+		finger(3, event.TouchStart, buttonCenter(t, views.console, consoleFixture))
+		b, err := ioutil.ReadFile(filepath.Join(dir, fixtureFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f, err := decodeFixture(b); err != nil || len(f.Touches) != 2 {
+			t.Errorf("console exported %s, error %v", b, err)
+		}
+	})
+	views.console.close()
+}
@@ -0,0 +1,156 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"math"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite/clock"
+)
+
+// followWindow is the number of latest generations whose changed cells the follow camera heads for.
+const followWindow = 8
+
+// followStiffness is the stiffness of the spring pulling the follow camera toward the changes, per
+// second squared. The spring is critically damped: it settles without overshooting.
+const followStiffness = 20.0
+
+// followEdge is the fraction of the view, from either edge, that the changes must reach in Grow
+// mode for the view to move over the field, see follower.shift.
+const followEdge = 0.2
+
+// A follower is the camera of the universe following the action, see cfg.follow: a damped spring
+// pulls the location of the field shown at the center of its screen area toward the centroid of the
+// cells born or dead in the latest generations. Panning by hand disengages it.
+type follower struct {
+	on bool
+	// sx and sy sum the locations on the field, see universe.toField, of the cells changed in
+	// each of the latest generations, by generation modulo followWindow, and n counts them.
+	sx, sy [followWindow]geom.Pt
+	n      [followWindow]int
+	slot   int // Of the generation being stepped.
+	// vel is the velocity of the camera, in Pt per second on the field, and last the time of the
+	// last frame it moved on, -1 if none.
+	vel  geom.Point
+	last clock.Time
+}
+
+// follow is the follow camera of the universe.
+var follow = follower{last: -1}
+
+// start engages f, heading for the cells that change from now on.
+func (f *follower) start() {
+	*f = follower{on: true, last: -1}
+}
+
+// disengage turns f off once the user moved the camera, and says so.
+func (f *follower) disengage() {
+	if !f.on {
+		return
+	}
+	f.on = false
+	log.Printf("follow camera off")
+	showToast(text("follow.off"))
+}
+
+// stepping tells f a generation of u is being stepped, whose changes replace the oldest ones.
+func (f *follower) stepping() {
+	f.slot = (f.slot + 1) % followWindow
+	f.sx[f.slot], f.sy[f.slot], f.n[f.slot] = 0, 0, 0
+}
+
+// changed tells f the cell (i, j) of u was born or died.
+func (f *follower) changed(u *universe, i, j int) {
+	if !f.on || u != univ {
+		return
+	}
+	f.sx[f.slot] += u.margin + (geom.Pt(i)+0.5)*cellSize
+	f.sy[f.slot] += u.margin + (geom.Pt(j)+0.5)*cellSize
+	f.n[f.slot]++
+}
+
+// target returns the centroid of the cells changed in the latest generations, on the field, and
+// false if none changed.
+func (f *follower) target() (geom.Point, bool) {
+	var sx, sy geom.Pt
+	n := 0
+	for k := range f.n {
+		sx, sy, n = sx+f.sx[k], sy+f.sy[k], n+f.n[k]
+	}
+	if n == 0 {
+		return geom.Point{}, false
+	}
+	return geom.Point{X: sx / geom.Pt(n), Y: sy / geom.Pt(n)}, true
+}
+
+// updateFollow moves the camera of the universe toward the action for the frame of the given time,
+// if the follow camera is on and no fitting moves it already.
+func updateFollow(t clock.Time) {
+	f := &follow
+	if !f.on || views.fit != nil {
+		f.last = -1
+		return
+	}
+	u := univ
+	target, ok := f.target()
+	if !ok || f.last < 0 {
+		f.last = t
+		return
+	}
+	dt := float64(t-f.last) / 60
+	f.last = t
+	if dt > 0.1 {
+		// Long frames would make the spring unstable.
+		dt = 0.1
+	}
+	if u.life.Edges == life.Grow {
+		target = f.shift(u, target)
+	}
+	focus := u.center()
+	at := u.toField(focus)
+	k, c := followStiffness, 2*math.Sqrt(followStiffness)
+	vx := float64(f.vel.X) + (k*float64(target.X-at.X)-c*float64(f.vel.X))*dt
+	vy := float64(f.vel.Y) + (k*float64(target.Y-at.Y)-c*float64(f.vel.Y))*dt
+	u.look(u.zoom, geom.Point{X: at.X + geom.Pt(vx*dt), Y: at.Y + geom.Pt(vy*dt)}, focus)
+	// The camera stops at the edges of the field, and so does the spring.
+	now := u.toField(focus)
+	f.vel = geom.Point{X: (now.X - at.X) / geom.Pt(dt), Y: (now.Y - at.Y) / geom.Pt(dt)}
+}
+
+// shift moves the view of the field of u over the field, which is unbounded in Grow mode, once the
+// target of f on the view reached either edge of it, so that the target is back at its center. The
+// camera and the changes move along, so that the cells show where they did. It returns the target
+// on the view moved.
+func (f *follower) shift(u *universe, target geom.Point) geom.Point {
+	i := int((target.X - u.margin) / cellSize)
+	j := int((target.Y - u.margin) / cellSize)
+	ei, ej := int(float64(u.cols)*followEdge), int(float64(u.rows)*followEdge)
+	var di, dj int
+	if i < ei || i >= u.cols-ei {
+		di = i - u.cols/2
+	}
+	if j < ej || j >= u.rows-ej {
+		dj = j - u.rows/2
+	}
+	if di == 0 && dj == 0 {
+		return target
+	}
+	// The cell at (di, dj) moves to (0, 0).
+	at := u.toField(u.center())
+	u.life.Resize(u.cols, u.rows, -di, -dj)
+	dx, dy := geom.Pt(di)*cellSize, geom.Pt(dj)*cellSize
+	for k := range f.n {
+		f.sx[k] -= geom.Pt(f.n[k]) * dx
+		f.sy[k] -= geom.Pt(f.n[k]) * dy
+	}
+	u.look(u.zoom, geom.Point{X: at.X - dx, Y: at.Y - dy}, u.center())
+	// The edits were of the cells where they were.
+	u.edits.clear()
+	u.edited()
+	u.repaint()
+	log.Printf("view moved by %d,%d cells", di, dj)
+	return geom.Point{X: target.X - dx, Y: target.Y - dy}
+}
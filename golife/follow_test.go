@@ -0,0 +1,100 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite/clock"
+)
+
+// followFor steps univ n generations, moving the follow camera for a frame after each from t on,
+// and returns the time of the next frame.
+func followFor(n int, t clock.Time) clock.Time {
+	for k := 0; k < n; k++ {
+		univ.Step()
+		updateFollow(t)
+		t += 4
+	}
+	return t
+}
+
+// gliderCenter returns the center of the alive cells of univ, on the field.
+func gliderCenter() geom.Point {
+	r := univ.liveBounds()
+	return geom.Point{
+		X: univ.margin + geom.Pt(r.Min.X+r.Max.X)/2*cellSize,
+		Y: univ.margin + geom.Pt(r.Min.Y+r.Max.Y)/2*cellSize,
+	}
+}
+
+func TestFollow(t *testing.T) {
+	gestureScene(t)
+	setPaused(true)
+	univ.adopt(life.New(univ.cols, univ.rows))
+	univ.life.Stamp(glider, 2, 2)
+	univ.look(3, geom.Point{}, univ.center())
+	follow.start()
+
+	// The camera heads for the glider as it travels, without overshooting it.
+	start := univ.toField(univ.center())
+	t0 := followFor(60, 100)
+	at, g := univ.toField(univ.center()), gliderCenter()
+	if d := distance(at, g); d > 6*cellSize || at.X < start.X || at.Y < start.Y {
+		t.Errorf("camera at %v from %v, %v from the glider at %v", at, start, d, g)
+	}
+	if at.X > g.X+cellSize || at.Y > g.Y+cellSize {
+		t.Errorf("camera at %v beyond the glider at %v", at, g)
+	}
+
+	// Panning by hand disengages it.
+	a, b := cellLoc(10, 10), cellLoc(16, 16)
+	finger(1, event.TouchStart, a)
+	finger(2, event.TouchStart, b)
+	finger(1, event.TouchMove, geom.Point{X: a.X + 4*cellSize, Y: a.Y})
+	finger(2, event.TouchMove, geom.Point{X: b.X + 4*cellSize, Y: b.Y})
+	finger(1, event.TouchEnd, a)
+	finger(2, event.TouchEnd, b)
+	if follow.on || views.popup == nil || views.popup.text != text("follow.off") {
+		t.Fatalf("follow camera on %v after a pan, toast %+v", follow.on, views.popup)
+	}
+	at = univ.toField(univ.center())
+	followFor(20, t0)
+	if now := univ.toField(univ.center()); now != at {
+		t.Errorf("camera moved from %v to %v once disengaged", at, now)
+	}
+}
+
+func TestFollowGrow(t *testing.T) {
+	gestureScene(t)
+	setPaused(true)
+	univ.adopt(life.New(univ.cols, univ.rows))
+	univ.setEdges(life.Grow)
+	univ.life.Stamp(glider, univ.cols/2, univ.rows/2)
+	follow.start()
+
+	// The view of the unbounded field moves along with the glider, which never leaves it.
+	followFor(4*univ.cols, 100)
+	if n := len(alive()); n != 5 {
+		t.Errorf("%d cells in view once the glider traveled beyond the first view", n)
+	}
+	r := univ.liveBounds()
+	if r.Min.X < univ.cols/10 || r.Max.X > univ.cols-univ.cols/10 {
+		t.Errorf("glider at %v in a view of %dx%d", r, univ.cols, univ.rows)
+	}
+}
+
+// distance returns the distance between a and b.
+func distance(a, b geom.Point) geom.Pt {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	return dx + dy
+}
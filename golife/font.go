@@ -0,0 +1,165 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"unicode"
+)
+
+// Glyphs of the bitmap font, glyphWidth by glyphHeight font pixels, glyphAdvance apart.
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphAdvance = glyphWidth + 1
+)
+
+// glyphs are the characters of the bitmap font: lower case letters, shown as capitals, the accented
+// ones of the Spanish string table shorter under their accent, digits, a dash, a percent sign, a
+// backspace arrow and an underscore, the punctuation of the log lines of the debug console, and the
+// question and exclamation marks, by rows of font pixels from the top, the leftmost pixel of a row
+// in bit 4. Other characters, as spaces, are blank.
+var glyphs = map[rune][glyphHeight]uint8{
+	'a': {0x0e, 0x11, 0x11, 0x1f, 0x11, 0x11, 0x11},
+	'b': {0x1e, 0x11, 0x11, 0x1e, 0x11, 0x11, 0x1e},
+	'c': {0x0e, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0e},
+	'd': {0x1c, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1c},
+	'e': {0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x1f},
+	'f': {0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x10},
+	'g': {0x0e, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0f},
+	'h': {0x11, 0x11, 0x11, 0x1f, 0x11, 0x11, 0x11},
+	'i': {0x0e, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0e},
+	'j': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0c},
+	'k': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'l': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1f},
+	'm': {0x11, 0x1b, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'n': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'o': {0x0e, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e},
+	'p': {0x1e, 0x11, 0x11, 0x1e, 0x10, 0x10, 0x10},
+	'q': {0x0e, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0d},
+	'r': {0x1e, 0x11, 0x11, 0x1e, 0x14, 0x12, 0x11},
+	's': {0x0f, 0x10, 0x10, 0x0e, 0x01, 0x01, 0x1e},
+	't': {0x1f, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'u': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e},
+	'v': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x04},
+	'w': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0a},
+	'x': {0x11, 0x11, 0x0a, 0x04, 0x0a, 0x11, 0x11},
+	'y': {0x11, 0x11, 0x11, 0x0a, 0x04, 0x04, 0x04},
+	'z': {0x1f, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1f},
+	'á': {0x02, 0x04, 0x0e, 0x11, 0x1f, 0x11, 0x11},
+	'é': {0x02, 0x04, 0x1f, 0x10, 0x1e, 0x10, 0x1f},
+	'í': {0x02, 0x04, 0x0e, 0x04, 0x04, 0x04, 0x0e},
+	'ó': {0x02, 0x04, 0x0e, 0x11, 0x11, 0x11, 0x0e},
+	'ú': {0x02, 0x04, 0x11, 0x11, 0x11, 0x11, 0x0e},
+	'ü': {0x0a, 0x00, 0x11, 0x11, 0x11, 0x11, 0x0e},
+	'ñ': {0x0d, 0x16, 0x11, 0x19, 0x15, 0x13, 0x11},
+	'0': {0x0e, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0e},
+	'1': {0x04, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x0e},
+	'2': {0x0e, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1f},
+	'3': {0x1f, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0e},
+	'4': {0x02, 0x06, 0x0a, 0x12, 0x1f, 0x02, 0x02},
+	'5': {0x1f, 0x10, 0x1e, 0x01, 0x01, 0x11, 0x0e},
+	'6': {0x06, 0x08, 0x10, 0x1e, 0x11, 0x11, 0x0e},
+	'7': {0x1f, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0e, 0x11, 0x11, 0x0e, 0x11, 0x11, 0x0e},
+	'9': {0x0e, 0x11, 0x11, 0x0f, 0x01, 0x02, 0x0c},
+	'-': {0x00, 0x00, 0x00, 0x1f, 0x00, 0x00, 0x00},
+	'<': {0x02, 0x04, 0x08, 0x10, 0x08, 0x04, 0x02},
+	'%': {0x19, 0x1a, 0x02, 0x04, 0x08, 0x0b, 0x13},
+	'_': {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1f},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x0c},
+	',': {0x00, 0x00, 0x00, 0x00, 0x0c, 0x04, 0x08},
+	':': {0x00, 0x0c, 0x0c, 0x00, 0x0c, 0x0c, 0x00},
+	'/': {0x01, 0x01, 0x02, 0x04, 0x08, 0x10, 0x10},
+	'(': {0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02},
+	')': {0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08},
+	'=': {0x00, 0x00, 0x1f, 0x00, 0x1f, 0x00, 0x00},
+	'?': {0x0e, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+	'¿': {0x04, 0x00, 0x04, 0x08, 0x10, 0x11, 0x0e},
+	'!': {0x04, 0x04, 0x04, 0x04, 0x04, 0x00, 0x04},
+	'¡': {0x04, 0x00, 0x04, 0x04, 0x04, 0x04, 0x04},
+}
+
+// accents maps the accented letters of the string tables to their letter.
+var accents = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n")
+
+// foldText returns s in lower case, its accented letters as their letter, so that it matches the
+// queries typed on the keyboard of the picker, which has none.
+func foldText(s string) string {
+	return accents.Replace(strings.ToLower(s))
+}
+
+// rtl reports whether r is a letter written right to left. Digits, even Arabic ones, are written
+// left to right.
+func rtl(r rune) bool {
+	return !unicode.IsDigit(r) && unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana)
+}
+
+// visualOrder returns s in the order its characters are drawn from left to right: the direction of
+// its first letter or digit, runs of the other direction reversed within. This falls short of the
+// bidirectional algorithm, but does for labels.
+func visualOrder(s string) string {
+	rs := []rune(s)
+	reverse := func(rs []rune) {
+		for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+			rs[i], rs[j] = rs[j], rs[i]
+		}
+	}
+	strong := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	base := false
+	for _, r := range rs {
+		if strong(r) {
+			base = rtl(r)
+			break
+		}
+	}
+	// A run is reversed from its first letter or digit to its last, the characters between included.
+	for i := 0; i < len(rs); i++ {
+		if !strong(rs[i]) || rtl(rs[i]) == base {
+			continue
+		}
+		end := i + 1
+		for j := end; j < len(rs); j++ {
+			if strong(rs[j]) {
+				if rtl(rs[j]) == base {
+					break
+				}
+				end = j + 1
+			}
+		}
+		reverse(rs[i:end])
+		i = end - 1
+	}
+	if base {
+		reverse(rs)
+	}
+	return string(rs)
+}
+
+// textWidth returns the width in px of the text s drawn by drawText with font pixels of scale px.
+func textWidth(s string, scale int) int {
+	return len([]rune(s)) * glyphAdvance * scale
+}
+
+// drawText draws the text s with the bitmap font in c on img, its top left corner at (x, y) and
+// font pixels of scale px, in lower case, in visual order.
+func drawText(img *image.RGBA, s string, x, y, scale int, c color.Color) {
+	for _, r := range visualOrder(strings.ToLower(s)) {
+		g := glyphs[r]
+		for j, row := range g {
+			for i := 0; i < glyphWidth; i++ {
+				if row&(1<<uint(glyphWidth-1-i)) == 0 {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						img.Set(x+i*scale+dx, y+j*scale+dy, c)
+					}
+				}
+			}
+		}
+		x += glyphAdvance * scale
+	}
+}
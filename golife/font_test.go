@@ -0,0 +1,40 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestGlyphs(t *testing.T) {
+	// Every character of the string tables has a glyph, none drawn blank in its place.
+	for _, locale := range languages {
+		table, err := readStrings(locale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, s := range table {
+			for _, r := range strings.ToLower(s) {
+				if _, ok := glyphs[r]; !ok && !unicode.IsSpace(r) {
+					t.Errorf("%s: %s: no glyph for %q", locale, key, r)
+				}
+			}
+		}
+	}
+
+	// Accented letters are drawn as such, not as their letter.
+	drawn := func(s string) string {
+		img := image.NewRGBA(image.Rect(0, 0, textWidth(s, 1), glyphHeight))
+		drawText(img, s, 0, 0, 1, color.White)
+		return string(img.Pix)
+	}
+	for _, c := range [][2]string{{"año", "ano"}, {"¿Qué?", "?Que?"}, {"¡Sí!", "!Si!"}} {
+		if drawn(c[0]) == drawn(c[1]) {
+			t.Errorf("%q drawn as %q", c[0], c[1])
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// forwardGenerations is the number of generations the fast-forward button jumps, and
+// longForwardGenerations the number a long press on it does.
+const (
+	forwardGenerations     = 100
+	longForwardGenerations = 10000
+)
+
+// forwardChunk is the number of generations the fast-forward steps between checks that it was not
+// stopped, when the game is stepped by HashLife, see life.NewStepper. Otherwise it checks every
+// generation.
+const forwardChunk = 1 << 10
+
+// The spinner shown over the grid while fast-forwarding is the replay image, spinnerSize wide,
+// turning once every spinnerTurn.
+const (
+	spinnerSize = 2 * normalButtonSize
+	spinnerTurn = time.Second
+)
+
+// A fastForward steps a copy of the game some generations in the background, without showing them,
+// so that the universe can jump to the last one. Meanwhile the game stands still and only the
+// fast-forward, speed and sound buttons act, see tap. The long runs of large fields are stepped by
+// HashLife, see life.NewStepper.
+type fastForward struct {
+	stop chan struct{}
+	// done receives the copy once stepped, unless stopped.
+	done  chan *life.Life
+	start time.Time
+}
+
+// forward is the fast-forward in progress, nil if none.
+var forward *fastForward
+
+// startForward starts fast-forwarding the universe the given number of generations.
+func startForward(generations int) {
+	l := univ.life.Clone()
+	f := &fastForward{stop: make(chan struct{}), done: make(chan *life.Life, 1), start: time.Now()}
+	s := life.NewStepper(l, generations)
+	chunk := 1
+	if s != life.Stepper(l) {
+		chunk = forwardChunk
+		log.Printf("fast-forwarding %d generations by HashLife", generations)
+	}
+	go func() {
+		for g := 0; g < generations; g += chunk {
+			select {
+			case <-f.stop:
+				return
+			default:
+			}
+			if chunk > generations-g {
+				chunk = generations - g
+			}
+			s.Advance(chunk)
+		}
+		f.done <- l
+	}()
+	forward = f
+	buttonBar.Refresh()
+}
+
+// stopForward stops the fast-forward, leaving the universe as it was.
+func stopForward() {
+	close(forward.stop)
+	forward.release()
+	forward = nil
+	buttonBar.Refresh()
+	log.Printf("fast-forward stopped")
+}
+
+// updateForward turns the spinner, and jumps the universe to the stepped copy once done. It is
+// called every frame.
+func updateForward() {
+	f := forward
+	if f == nil {
+		return
+	}
+	select {
+	case l := <-f.done:
+		f.release()
+		forward = nil
+		buttonBar.Refresh()
+		univ.jump(l)
+		log.Printf("fast-forwarded to generation %d in %v", l.Generation(), time.Since(f.start))
+	default:
+		f.spin()
+	}
+}
+
+// spin shows the spinner at the center of the grid, turned as for the time since f started.
+func (f *fastForward) spin() {
+	if views.spinner == nil {
+		views.spinner = &sprite.Node{}
+		eng.Register(views.spinner)
+		scene.AppendChild(views.spinner)
+		eng.SetSubTex(views.spinner, *textures[replayImage])
+	}
+	t := time.Since(f.start) % spinnerTurn
+	a := 2 * math.Pi * t.Seconds() / spinnerTurn.Seconds()
+	// The unit square of the node, turned by a about its center.
+	s := float64(spinnerSize)
+	cos, sin := s*math.Cos(a), s*math.Sin(a)
+	cx := float64(geom.Width) / 2
+	cy := float64(buttonBarHeight) + float64(geom.Height-systemBarHeight-buttonBarHeight)/2
+	eng.SetTransform(views.spinner, f32.Affine{
+		{float32(cos), float32(-sin), float32(cx - (cos-sin)/2)},
+		{float32(sin), float32(cos), float32(cy - (sin+cos)/2)},
+	})
+}
+
+// release removes the spinner of f from the scene, if shown.
+func (f *fastForward) release() {
+	if views.spinner != nil {
+		scene.RemoveChild(views.spinner)
+		eng.Unregister(views.spinner)
+		views.spinner = nil
+	}
+}
+
+// jump makes l, a copy of the game of u stepped on, the game of u. The game it replaces goes into
+// the timeline, so that Back returns to it.
+func (u *universe) jump(l *life.Life) {
+	tl := &u.timeline
+	if snap, err := u.life.MarshalBinary(); err == nil {
+		tl.record(snap)
+	}
+	tl.future = nil
+	// The screen may have changed size meanwhile, see layout.
+	if w, h := l.Bounds(); w != u.cols || h != u.rows {
+		l.Resize(u.cols, u.rows, (u.cols-w)/2, (u.rows-h)/2)
+	}
+	u.due = 0
+	u.trail.clear()
+	u.adopt(l)
+	spark.record(l.Population())
+}
@@ -0,0 +1,53 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/event"
+)
+
+func TestLongForward(t *testing.T) {
+	// A screen large enough for HashLife.
+	testScene(t, 640, 960)
+	forgetPointers()
+	l := life.New(univ.cols, univ.rows)
+	l.Edges = life.Bounded
+	l.SeedWith(0.3, life.Random, 1)
+	univ.adopt(l)
+	setPaused(true)
+	want := l.Clone()
+	want.Advance(longForwardGenerations)
+	if _, dense := life.NewStepper(univ.life.Clone(), longForwardGenerations).(*life.Life); dense {
+		t.Fatalf("a field of %dx%d cells not stepped by HashLife", univ.cols, univ.rows)
+	}
+
+	// A long press on the button jumps longForwardGenerations generations, as stepped one by one.
+	finger(1, event.TouchStart, buttonLoc(t, forwardImage))
+	pointers[1].start = time.Now().Add(-longPress)
+	holdPointers()
+	finger(1, event.TouchEnd, buttonLoc(t, forwardImage))
+	if forward == nil || views.popup == nil || views.popup.text != text("button.forward", longForwardGenerations) {
+		t.Fatalf("fast-forward %v, toast %+v once the button held", forward, views.popup)
+	}
+	for deadline := time.Now().Add(time.Minute); forward != nil && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+		updateForward()
+	}
+	if forward != nil {
+		t.Fatalf("still fast-forwarding")
+	}
+	if g := univ.life.Generation(); g != longForwardGenerations {
+		t.Errorf("fast-forwarded to generation %d, want %d", g, longForwardGenerations)
+	}
+	for j := 0; j < univ.rows; j++ {
+		for i := 0; i < univ.cols; i++ {
+			if univ.life.Alive(i, j) != want.Alive(i, j) {
+				t.Fatalf("cell (%d, %d) alive %v, want %v", i, j, univ.life.Alive(i, j), want.Alive(i, j))
+			}
+		}
+	}
+}
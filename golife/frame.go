@@ -0,0 +1,155 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"math"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+const (
+	// maxFrameScale is the largest side in px of a cell in a frame. Larger cells are magnified by
+	// the texture, which fewer pixels keep cheap to upload.
+	maxFrameScale = 4
+	// maxFrameSize bounds the width and height in px of the texture of a frame, which devices do
+	// not all support large.
+	maxFrameSize = 2048
+)
+
+// A frame draws the cells of a universe, and their seam echoes, as the pixels of a single texture
+// shown by one node, rather than a node per cell. That is the texture render mode: the image is
+// uploaded once per rendered frame if cells changed, instead of a sub-texture set per changed cell.
+// Dead cells are transparent and alive ones solid squares in the colors of the snapshots.
+type frame struct {
+	node  *sprite.Node
+	tex   sprite.Texture // Nil until the first flush.
+	img   *image.RGBA
+	scale int  // Side in px of a cell in img.
+	edge  int  // Cells left around the field for the echo strips, 0 or 1.
+	dirty bool // Whether img changed since it was last uploaded.
+}
+
+// newFrame returns a frame of dead cells covering the field of u and its margin.
+func (u *universe) newFrame() *frame {
+	f := &frame{node: &sprite.Node{}}
+	if u.margin > 0 || drifts() {
+		f.edge = 1
+	}
+	w, h := u.cols+2*f.edge, u.rows+2*f.edge
+	f.scale = int(math.Max(1, math.Min(maxFrameScale, float64(cellSize.Px()))))
+	for f.scale > 1 && (w*f.scale > maxFrameSize || h*f.scale > maxFrameSize) {
+		f.scale--
+	}
+	f.img = image.NewRGBA(image.Rect(0, 0, w*f.scale, h*f.scale))
+	eng.Register(f.node)
+	u.sheet.AppendChild(f.node)
+	// The margin already is a cell wide, if any, else the echoes are drawn out of the field.
+	o := float32(u.margin - geom.Pt(f.edge)*cellSize)
+	eng.SetTransform(f.node, f32.Affine{
+		{float32(geom.Pt(w) * cellSize), 0, o},
+		{0, float32(geom.Pt(h) * cellSize), o},
+	})
+	return f
+}
+
+// set paints the cell (i, j) of the field c, which can be one cell outside it for the echoes.
+func (f *frame) set(i, j int, c color.RGBA) {
+	x0, y0 := (i+f.edge)*f.scale, (j+f.edge)*f.scale
+	n := f.scale
+	if n >= 4 {
+		// A gap between cells, as the cell image has.
+		n--
+	}
+	for y := y0; y < y0+n; y++ {
+		for x := x0; x < x0+n; x++ {
+			f.img.SetRGBA(x, y, c)
+		}
+	}
+	f.dirty = true
+}
+
+// flush uploads the image of f if it changed. Textures can only be loaded on the GL thread, hence
+// it is called from draw.
+func (f *frame) flush() {
+	if f == nil || !f.dirty {
+		return
+	}
+	f.dirty = false
+	if f.tex != nil {
+		f.tex.Upload(f.img.Bounds(), f.img)
+		return
+	}
+	tex, err := eng.LoadTexture(f.img)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f.tex = tex
+	eng.SetSubTex(f.node, sprite.SubTex{tex, f.img.Bounds()})
+}
+
+// release removes f from the scene and frees its texture.
+func (f *frame) release() {
+	f.node.Parent.RemoveChild(f.node)
+	eng.Unregister(f.node)
+	if f.tex != nil {
+		f.tex.Unload()
+	}
+}
+
+// showFrame paints the cell (i, j) of u in its frame, along with its echoes in Wrap mode.
+func (u *universe) showFrame(i, j int, alive bool) {
+	c, echo := color.RGBA{}, color.RGBA{}
+	if u.life.Pinned(i, j) {
+		// Pinned cells have no echoes.
+		c = color.RGBAModel.Convert(u.snapshotColor(i, j)).(color.RGBA)
+	} else if alive {
+		c = color.RGBAModel.Convert(u.snapshotColor(i, j)).(color.RGBA)
+		switch {
+		case u.drifting():
+			echo = c
+		case u.life.Edges == life.Wrap:
+			// A third of the opacity, premultiplied.
+			echo = color.RGBA{c.R / 3, c.G / 3, c.B / 3, c.A / 3}
+		}
+	} else if u.life.State(i, j) > 1 {
+		// Dying cells have no echoes.
+		c = color.RGBAModel.Convert(u.snapshotColor(i, j)).(color.RGBA)
+	} else if l := u.trail.level(j*u.cols + i); l > 0 {
+		// As the images of the fading cells, premultiplied.
+		a := color.RGBAModel.Convert(snapshotAliveColor).(color.RGBA)
+		f := func(v uint8) uint8 { return uint8(int(v) * l / (trailLevels + 1)) }
+		c = color.RGBA{f(a.R), f(a.G), f(a.B), f(a.A)}
+	}
+	u.frame.set(i, j, c)
+	if u.frame.edge == 0 {
+		return
+	}
+	// The echoes are across the seams, on the ring of cells around the field.
+	is, js := []int{i}, []int{j}
+	if i == 0 {
+		is = append(is, u.cols)
+	}
+	if i == u.cols-1 {
+		is = append(is, -1)
+	}
+	if j == 0 {
+		js = append(js, u.rows)
+	}
+	if j == u.rows-1 {
+		js = append(js, -1)
+	}
+	for _, ej := range js {
+		for _, ei := range is {
+			if ei != i || ej != j {
+				u.frame.set(ei, ej, echo)
+			}
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"time"
+
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+// A role is what a finger does, decided when it touches the screen.
+type role int
+
+const (
+	ignored  role = iota // Nothing, for instance a finger out of the grid and buttons.
+	painting             // Painting cells, see stroke.
+	pressing             // Pressing a button.
+	pinching             // Zooming and panning the grid with another finger, see pinch.
+	framing              // Selecting a rectangle of cells, see selection.
+)
+
+// A pointer is a finger touching the screen.
+type pointer struct {
+	role role
+	loc  geom.Point // Last location, absolute.
+	// For painting, the stroke, nil once the finger joined a pinch.
+	stroke *stroke
+	// For pressing, the bar and image of the button, empty once the finger slid off it, when it
+	// was pressed and whether it was held for longPress, which replaces its action, and when a
+	// held nudge arrow last repeated, see holdNudge.
+	bar      *ui.Bar
+	button   string
+	start    time.Time
+	heldLong bool
+	repeated time.Time
+	// For framing, the cell the finger started on, a corner of the selection.
+	anchor image.Point
+}
+
+var (
+	// pointers holds the fingers touching the screen by touch sequence.
+	pointers = make(map[event.TouchSequenceID]*pointer)
+	// zooming is the current pinch, if any. It lasts until both its fingers are lifted.
+	zooming *pinch
+)
+
+// route dispatches t to the role of its finger. Cells and buttons are only reached by the fingers
+// that started on them, so that fingers do not disturb each other.
+func route(t event.Touch) {
+	p := pointers[t.ID]
+	if t.Type == event.TouchStart || p == nil {
+		// A finger that started before the scene was built, or was forgotten, is ignored.
+		p = &pointer{loc: t.Loc}
+		pointers[t.ID] = p
+		if t.Type == event.TouchStart {
+			p.begin(t.ID, t.Loc)
+		}
+	}
+	p.loc = t.Loc
+
+	switch p.role {
+	case painting:
+		if p.stroke == nil {
+			break
+		}
+		if t.Type == event.TouchEnd {
+			if i, j, ok := univ.cellAt(t.Loc); ok {
+				univ.strokeTo(p.stroke, i, j)
+			}
+			if p.stroke.edited {
+				tutor(cellCommand)
+			}
+			if !p.stroke.moved {
+				startRipple(univ.toField(t.Loc))
+			}
+		} else {
+			univ.moveStroke(p.stroke, t.Loc)
+		}
+	case pressing:
+		if p.button == "" {
+			break
+		}
+		// The button is gone if its bar was rebuilt meanwhile.
+		if b := p.bar.Button(p.button); b == nil || !b.Contains(t.Loc) {
+			p.release()
+		} else if t.Type == event.TouchEnd {
+			img := p.button
+			p.release()
+			if !p.heldLong {
+				tapButton(p.bar, img)
+			}
+		}
+	case framing:
+		if i, j, ok := univ.cellAt(t.Loc); ok {
+			r := image.Rectangle{Min: p.anchor, Max: image.Pt(i, j)}.Canon()
+			r.Max = r.Max.Add(image.Pt(1, 1))
+			selectCells(r)
+		}
+	case pinching:
+		if t.Type == event.TouchMove && zooming != nil && zooming.active() {
+			zooming.update()
+		}
+	}
+
+	if t.Type == event.TouchEnd {
+		delete(pointers, t.ID)
+		if zooming != nil && pointers[zooming.ids[0]] == nil && pointers[zooming.ids[1]] == nil {
+			zooming.end()
+			zooming = nil
+		}
+	}
+}
+
+// begin gives a role to p, the finger of the touch sequence id that just touched the screen at loc.
+// A finger touching the grid while another one is painting turns both into a pinch, the painting
+// undone. Any finger stops the view fitting the cells where it is.
+func (p *pointer) begin(id event.TouchSequenceID, loc geom.Point) {
+	views.fit = nil
+	// The tutorial, the banner and the tool bar are over the grid.
+	for _, bar := range []*ui.Bar{views.tour.bar(), views.notice.bar(), toolBar, buttonBar} {
+		if b := bar.Find(loc); b != nil {
+			p.role, p.bar, p.button, p.start = pressing, bar, b.Name, time.Now()
+			b.SetPressed(true)
+			speak(buttonLabel(b.Name))
+			return
+		}
+	}
+	if o := views.overlay; o != nil && ui.Contains(o.rect, loc) {
+		// The console takes over the touches, from this one, left out of the fixtures it exports.
+		unlogTouch()
+		openConsole()
+		return
+	}
+	i, j, onGrid := univ.cellAt(loc)
+	if onGrid && zooming == nil {
+		for qid, q := range pointers {
+			if q != p && q.role == painting {
+				// The cells the first finger painted were only meant to start the pinch.
+				if q.stroke != nil && q.stroke.edited {
+					univ.revertStroke(q.stroke)
+					univ.dropEdit()
+				}
+				q.role, q.stroke, p.role = pinching, nil, pinching
+				zooming = newPinch(qid, id)
+				return
+			}
+		}
+	}
+	switch {
+	case zooming != nil && onGrid:
+		// A third finger on the grid during a pinch, which shows or hides the debug overlay.
+		debugging = !debugging
+		logUsage()
+	case onGrid && forward != nil:
+		// The game stands still while fast-forwarding, and edits would be lost.
+	case onGrid && compare != nil:
+		// The game of the half tapped, or the nearest, goes on.
+		compare.pick(i, j)
+	case onGrid && selecting:
+		p.role, p.anchor = framing, image.Pt(i, j)
+		selectCells(image.Rect(i, j, i+1, j+1))
+	case onGrid:
+		p.role, p.stroke = painting, univ.newStroke(i, j, loc)
+	}
+}
+
+// release shows the button pressed by p released, unless gone with its bar, and forgets about it.
+func (p *pointer) release() {
+	if b := p.bar.Button(p.button); b != nil {
+		b.SetPressed(false)
+	}
+	p.button = ""
+}
+
+// forgetPointers drops every finger, for instance once the scene they touch is rebuilt.
+func forgetPointers() {
+	pointers = make(map[event.TouchSequenceID]*pointer)
+	zooming = nil
+}
+
+// holdPointers acts on the fingers held still long enough, as long presses do not wait for an
+// event. It is called every frame.
+func holdPointers() {
+	for _, p := range pointers {
+		switch {
+		case p.role == painting && p.stroke != nil && p.stroke.isLongPress() && versus == nil:
+			// The menu takes over the touches.
+			univ.openStampMenu(p.stroke)
+			return
+		case p.role == pressing && p.bar == buttonBar && p.button == replayImage && !p.heldLong && time.Since(p.start) >= longPress:
+			p.heldLong = true
+			cycleSeedMode()
+		case p.role == pressing && p.bar == buttonBar && p.button == patternImage && time.Since(p.start) >= longPress:
+			// The picker takes over the touches.
+			p.release()
+			openPicker("", false)
+			return
+		case p.role == pressing && p.button == shareImage && !p.heldLong && time.Since(p.start) >= longPress:
+			p.heldLong = true
+			shareRun()
+		case p.role == pressing && p.button == profileImage && !p.heldLong && time.Since(p.start) >= longPress:
+			p.heldLong = true
+			importProfile()
+		case p.role == pressing && p.bar == toolBar && p.button == wallImage && !p.heldLong && time.Since(p.start) >= longPress:
+			p.heldLong = true
+			switchWallPolarity()
+		case p.role == pressing && p.bar == buttonBar && p.button == forwardImage && !p.heldLong && time.Since(p.start) >= longPress:
+			p.heldLong = true
+			if compare == nil && versus == nil && forward == nil {
+				showToast(text("button.forward", longForwardGenerations))
+				startForward(longForwardGenerations)
+			}
+		case p.role == pressing && p.bar == buttonBar && p.button == ruleImage && !p.heldLong && time.Since(p.start) >= longPress:
+			p.heldLong = true
+			if compare == nil && versus == nil && forward == nil {
+				startComparison()
+			}
+		case p.role == pressing:
+			p.holdNudge()
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+// gestureScene builds the scene with an empty universe for touching it, running.
+func gestureScene(t *testing.T) {
+	testScene(t, 320, 480)
+	univ.adopt(life.New(univ.cols, univ.rows))
+	forgetPointers()
+	setPaused(false)
+}
+
+// cellLoc returns the absolute location of the center of the cell (i, j) as the camera shows it,
+// see universe.cellAt.
+func cellLoc(i, j int) geom.Point {
+	z := geom.Pt(univ.zoom)
+	return geom.Point{
+		X: 0.1 + univ.pan.X + z*(univ.margin+(geom.Pt(i)+0.5)*cellSize),
+		Y: systemBarHeight + buttonBarHeight + univ.pan.Y + z*(univ.margin+(geom.Pt(j)+0.5)*cellSize),
+	}
+}
+
+// buttonLoc returns the absolute location of the center of the button of the given image, on the
+// button bar or else the tool bar.
+func buttonLoc(t *testing.T, img string) geom.Point {
+	b := buttonBar.Button(img)
+	if b == nil && toolBar != nil {
+		b = toolBar.Button(img)
+	}
+	if b == nil {
+		t.Fatalf("no %s button", img)
+	}
+	return geom.Point{X: (b.Rect.Min.X + b.Rect.Max.X) / 2, Y: (b.Rect.Min.Y + b.Rect.Max.Y) / 2}
+}
+
+// finger sends the touch of the sequence id of type typ at loc.
+func finger(id event.TouchSequenceID, typ event.TouchType, loc geom.Point) {
+	touch(event.Touch{ID: id, Type: typ, Loc: loc})
+}
+
+// alive returns the alive cells of the universe.
+func alive() [][2]int {
+	var cells [][2]int
+	for j := 0; j < univ.rows; j++ {
+		for i := 0; i < univ.cols; i++ {
+			if univ.life.Alive(i, j) {
+				cells = append(cells, [2]int{i, j})
+			}
+		}
+	}
+	return cells
+}
+
+func TestRouteTapDuringDrag(t *testing.T) {
+	gestureScene(t)
+	finger(1, event.TouchStart, cellLoc(2, 5))
+	finger(1, event.TouchMove, cellLoc(4, 5))
+	// Another finger taps the pause button while the first one paints.
+	finger(2, event.TouchStart, buttonLoc(t, pauseImage))
+	if pointers[2].role != pressing || !buttonBar.Button(pauseImage).Pressed() {
+		t.Errorf("finger on the pause button not pressing it")
+	}
+	finger(1, event.TouchMove, cellLoc(6, 5))
+	finger(2, event.TouchEnd, buttonLoc(t, pauseImage))
+	if !paused {
+		t.Errorf("tap on the pause button during a stroke did not pause")
+	}
+	finger(1, event.TouchMove, cellLoc(8, 5))
+	// The painting finger lifted on the pause button does not tap it.
+	finger(1, event.TouchEnd, buttonLoc(t, pauseImage))
+	if !paused {
+		t.Errorf("end of the stroke on the pause button tapped it")
+	}
+	if got := len(alive()); got != 7 {
+		t.Errorf("stroke over cells 2 to 8 painted %d cells: %v", got, alive())
+	}
+	if len(pointers) != 0 {
+		t.Errorf("%d fingers left once lifted", len(pointers))
+	}
+}
+
+func TestRouteTwoDrags(t *testing.T) {
+	gestureScene(t)
+	finger(1, event.TouchStart, cellLoc(2, 5))
+	// A second finger on the grid turns the stroke into a pinch, which undoes the cell painted by the
+	// first one and paints nothing.
+	finger(2, event.TouchStart, cellLoc(12, 15))
+	if pointers[1].role != pinching || pointers[2].role != pinching || zooming == nil {
+		t.Fatalf("two fingers on the grid not pinching")
+	}
+	painted := alive()
+	if len(painted) != 0 {
+		t.Errorf("%d cells painted by the start of the pinch", len(painted))
+	}
+	// Moving both fingers, interleaved, pans but neither paints.
+	for k := 1; k <= 3; k++ {
+		finger(1, event.TouchMove, cellLoc(2+k, 5))
+		finger(2, event.TouchMove, cellLoc(12+k, 15))
+	}
+	if got := len(alive()); got != len(painted) {
+		t.Errorf("%d cells alive after the pinch moved, want %d", got, len(painted))
+	}
+	finger(2, event.TouchEnd, cellLoc(15, 15))
+	if zooming == nil {
+		t.Errorf("pinch over with a finger still down")
+	}
+	// The remaining finger still pinches, so it does not paint either.
+	finger(1, event.TouchMove, cellLoc(5, 9))
+	finger(1, event.TouchEnd, cellLoc(5, 9))
+	if zooming != nil || len(pointers) != 0 {
+		t.Errorf("pinch left once both fingers are lifted")
+	}
+	if got := len(alive()); got != len(painted) {
+		t.Errorf("%d cells alive after the pinch, want %d", got, len(painted))
+	}
+}
+
+func TestRouteCancelled(t *testing.T) {
+	gestureScene(t)
+	// A finger sliding off the button it pressed cancels the tap.
+	finger(1, event.TouchStart, buttonLoc(t, pauseImage))
+	finger(1, event.TouchMove, cellLoc(10, 10))
+	if buttonBar.Button(pauseImage).Pressed() {
+		t.Errorf("pause button still pressed once the finger slid off")
+	}
+	finger(1, event.TouchMove, buttonLoc(t, pauseImage))
+	finger(1, event.TouchEnd, buttonLoc(t, pauseImage))
+	if paused {
+		t.Errorf("cancelled tap on the pause button paused")
+	}
+	if len(alive()) != 0 {
+		t.Errorf("finger from the button bar painted cells")
+	}
+
+	// A stroke is forgotten with its finger when the scene is laid out again.
+	finger(2, event.TouchStart, cellLoc(2, 2))
+	forgetPointers()
+	finger(2, event.TouchMove, cellLoc(6, 2))
+	finger(2, event.TouchEnd, cellLoc(6, 2))
+	if got := alive(); len(got) != 1 {
+		t.Errorf("forgotten stroke went on painting: %v", got)
+	}
+
+	// A finger that started on the grid does not tap the buttons it reaches.
+	finger(3, event.TouchStart, cellLoc(2, 8))
+	finger(3, event.TouchMove, buttonLoc(t, pauseImage))
+	finger(3, event.TouchEnd, buttonLoc(t, pauseImage))
+	if paused || buttonBar.Button(pauseImage).Pressed() {
+		t.Errorf("stroke ending on the pause button tapped it")
+	}
+}
+
+func TestRouteToggle(t *testing.T) {
+	gestureScene(t)
+	setPaused(true)
+	// While paused, a tap toggles the cell under it.
+	for _, want := range []bool{true, false, true} {
+		finger(1, event.TouchStart, cellLoc(3, 4))
+		finger(1, event.TouchEnd, cellLoc(3, 4))
+		if univ.life.Alive(3, 4) != want {
+			t.Errorf("tap left the cell 3,4 alive %v, want %v", !want, want)
+		}
+	}
+	// A stroke starting on an alive cell erases the cells it goes through, and only those.
+	univ.life.SetCells([][2]int{{4, 4}, {5, 4}, {7, 4}, {5, 6}}, true)
+	finger(1, event.TouchStart, cellLoc(3, 4))
+	finger(1, event.TouchMove, cellLoc(8, 4))
+	finger(1, event.TouchEnd, cellLoc(8, 4))
+	if got := alive(); len(got) != 1 || got[0] != [2]int{5, 6} {
+		t.Errorf("erasing stroke left %v alive, want 5,6", got)
+	}
+	// So does the line brush, drawing the line again as the finger moves.
+	defer func(b brush) { currentBrush = b }(currentBrush)
+	currentBrush = lineBrush
+	univ.life.SetCells([][2]int{{2, 8}, {3, 8}, {4, 8}, {3, 9}, {4, 10}}, true)
+	finger(1, event.TouchStart, cellLoc(2, 8))
+	finger(1, event.TouchMove, cellLoc(4, 10))
+	finger(1, event.TouchMove, cellLoc(4, 8))
+	finger(1, event.TouchEnd, cellLoc(4, 8))
+	if got := alive(); len(got) != 3 || univ.life.Alive(3, 8) || !univ.life.Alive(3, 9) || !univ.life.Alive(4, 10) {
+		t.Errorf("erased line left %v alive, want 5,6 3,9 4,10", got)
+	}
+
+	// Running, strokes only paint cells alive.
+	currentBrush = cellBrush
+	setPaused(false)
+	finger(1, event.TouchStart, cellLoc(5, 6))
+	finger(1, event.TouchMove, cellLoc(5, 8))
+	finger(1, event.TouchEnd, cellLoc(5, 8))
+	if !univ.life.Alive(5, 6) || !univ.life.Alive(5, 7) || !univ.life.Alive(5, 8) {
+		t.Errorf("stroke while running did not paint its cells alive")
+	}
+}
@@ -0,0 +1,29 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Lengths of the haptic pulses of a button tap and of a stamp of the stamp menu.
+const (
+	tapPulse   = 15 * time.Millisecond
+	stampPulse = 30 * time.Millisecond
+)
+
+// hapticsBroken is set once the device failed to vibrate, which it is then not asked to again.
+var hapticsBroken bool
+
+// pulse vibrates the device for d, if cfg.haptics. The vibration is left to the platform, see
+// vibrate.
+func pulse(d time.Duration) {
+	if !cfg.haptics || hapticsBroken {
+		return
+	}
+	if err := vibrate(d); err != nil {
+		log.Printf("vibrating: %v, haptics disabled", err)
+		hapticsBroken = true
+	}
+}
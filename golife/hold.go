@@ -0,0 +1,78 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import "log"
+
+// The panels, such as the settings panel or the pattern picker, take over the touches while open,
+// so that the grid cannot be painted behind them, and pause the simulation with cfg.pauseMenus or
+// when busy, see heavyPanels. Either way, holding kicks in as a panel opens, see holdPanel, and is
+// undone as it closes, see releasePanel, nested panels being undone in turn.
+
+// The names of the panels, as told holdPanel and releasePanel.
+const (
+	settingsPanelName = "settings"
+	stampMenuName     = "stamps"
+	shareViewName     = "share"
+	galleryName       = "gallery"
+	pickerName        = "picker"
+	consoleName       = "console"
+)
+
+// heavyPanels are the panels pausing the simulation whatever cfg.pauseMenus: the pattern picker,
+// drawing the previews of the patterns.
+var heavyPanels = map[string]bool{pickerName: true}
+
+// A panelHold is an open panel, as held by holdPanel.
+type panelHold struct {
+	name   string
+	paused bool // Whether the simulation was paused as the panel opened.
+	pauses bool // Whether the panel paused it.
+}
+
+// holds are the open panels, innermost last.
+var holds []panelHold
+
+// pausesPanel reports whether the panel of the given name pauses the simulation while open.
+func pausesPanel(name string) bool {
+	return cfg.pauseMenus || heavyPanels[name]
+}
+
+// holdPanel tells that the panel of the given name opens, pausing the simulation if it should.
+func holdPanel(name string) {
+	h := panelHold{name: name, paused: paused, pauses: pausesPanel(name)}
+	holds = append(holds, h)
+	if h.pauses && !paused {
+		setPaused(true)
+	}
+}
+
+// releasePanel tells that the innermost open panel of the given name closes. If it paused the
+// simulation, the simulation is paused or not as before it opened, unless a panel opened over it is
+// still open, which then restores that state as it closes.
+func releasePanel(name string) {
+	for k := len(holds) - 1; k >= 0; k-- {
+		h := holds[k]
+		if h.name != name {
+			continue
+		}
+		holds = append(holds[:k], holds[k+1:]...)
+		switch {
+		case !h.pauses:
+		case k < len(holds):
+			// The panel opened over saw the simulation paused, and is left to restore it.
+			holds[k].paused, holds[k].pauses = h.paused, true
+		case paused != h.paused:
+			setPaused(h.paused)
+		}
+		return
+	}
+	log.Printf("closing the %s panel, not open", name)
+}
+
+// dropPanels releases the open panels, innermost first, as they are gone with the GL context.
+func dropPanels() {
+	for len(holds) > 0 {
+		releasePanel(holds[len(holds)-1].name)
+	}
+}
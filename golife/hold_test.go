@@ -0,0 +1,88 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/mobile/event"
+)
+
+func TestPauseMenus(t *testing.T) {
+	gestureScene(t)
+
+	// By default, a panel pauses the game until closed.
+	openSettings()
+	if !paused {
+		t.Errorf("game running behind the settings panel")
+	}
+	views.panel.close()
+	if paused {
+		t.Errorf("game still paused once the settings panel closed")
+	}
+	setPaused(true)
+	openSettings()
+	views.panel.close()
+	if !paused {
+		t.Errorf("paused game resumed by the settings panel")
+	}
+
+	// Otherwise the game runs on behind, the grid taking no touches, but the picker still pauses.
+	cfg.pauseMenus = false
+	setPaused(false)
+	openSettings()
+	if paused {
+		t.Errorf("game paused behind the settings panel")
+	}
+	finger(1, event.TouchStart, cellLoc(3, 4))
+	finger(1, event.TouchEnd, cellLoc(3, 4))
+	if views.panel != nil || len(alive()) != 0 {
+		t.Errorf("touch out of the settings panel painted %d cells", len(alive()))
+	}
+	openPicker("", false)
+	if !paused {
+		t.Errorf("game running behind the picker")
+	}
+	views.picker.close()
+	if paused || len(holds) != 0 {
+		t.Errorf("game paused %v once the picker closed, %d panels held", paused, len(holds))
+	}
+}
+
+func TestNestedPanels(t *testing.T) {
+	gestureScene(t)
+	cfg.pauseMenus = false
+	for _, test := range []struct {
+		name   string
+		closes []string // Order the panels close in.
+	}{
+		{"innermost first", []string{pickerName, settingsPanelName}},
+		{"outermost first", []string{settingsPanelName, pickerName}},
+	} {
+		setPaused(false)
+		holdPanel(settingsPanelName)
+		holdPanel(pickerName)
+		if !paused {
+			t.Errorf("%s: game running behind the picker", test.name)
+		}
+		releasePanel(test.closes[0])
+		// Back to the settings panel, the game runs on, while the picker keeps it paused.
+		if want := test.closes[0] == settingsPanelName; paused != want {
+			t.Errorf("%s: game paused %v with the %s panel left", test.name, paused, test.closes[1])
+		}
+		releasePanel(test.closes[1])
+		if paused || len(holds) != 0 {
+			t.Errorf("%s: game paused %v once closed, %d panels held", test.name, paused, len(holds))
+		}
+	}
+
+	// Panels gone with the GL context leave the game as it was before.
+	cfg.pauseMenus = true
+	setPaused(false)
+	holdPanel(settingsPanelName)
+	holdPanel(pickerName)
+	dropPanels()
+	if paused || len(holds) != 0 {
+		t.Errorf("game paused %v once the panels dropped, %d panels held", paused, len(holds))
+	}
+}
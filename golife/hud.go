@@ -0,0 +1,199 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// Units are in Pt.
+const (
+	hudDigits       = 7 // Maximum number of digits of a counter.
+	hudColorColumns = 2
+)
+
+// The sizes of the digits of the hud, in Pt, as told by controls, see scaleControls.
+var (
+	hudDigitHeight geom.Pt = 8
+	hudDigitWidth  geom.Pt = 6
+	// Height of the digits of the population of each color, in rows of hudColorColumns.
+	hudColorDigitHeight geom.Pt = 5
+)
+
+// A hud shows the generation count left of the button bar and the population right of it, or in
+// games of several colors the population of each color, after a cell of the color. Both sides swap
+// if rightToLeft.
+type hud struct {
+	generation, population *counter
+	colors                 []*counter
+	swatches               []*sprite.Node
+	// The room of the population, its left at x, n digits wide.
+	x geom.Pt
+	n int
+}
+
+// A counter shows a number with digit sprites.
+type counter struct {
+	digits []*sprite.Node // From the most to the least significant digit.
+	value  int            // Value shown, -1 if none yet.
+}
+
+// newHUD lays out the counters in the room left on both sides of the button bar.
+func newHUD(buttonBar *ui.Bar) *hud {
+	room := geom.Width / 2
+	for _, b := range buttonBar.Buttons {
+		if r := b.Rect.Min.X; r < room {
+			room = r
+		}
+	}
+	n := int((room - 2*buttonSep) / hudDigitWidth)
+	if n > hudDigits {
+		n = hudDigits
+	}
+	w := geom.Pt(n) * hudDigitWidth
+	return &hud{
+		generation: newCounter(scene, mirrorX(buttonSep, w, screen()), (buttonSize-hudDigitHeight)/2, hudDigitHeight, n),
+		x:          mirrorX(geom.Width-buttonSep-w, w, screen()),
+		n:          n,
+	}
+}
+
+// update shows the generation count and population of l.
+func (h *hud) update(l *life.Life) {
+	h.generation.set(l.Generation())
+	colors := l.Colors
+	if colors < 2 {
+		colors = 0
+	}
+	if h.population == nil && colors == 0 || len(h.colors) != colors {
+		h.layout(colors)
+	}
+	if h.population != nil {
+		h.population.set(l.Population())
+	}
+	for c, counter := range h.colors {
+		counter.set(l.ColorPopulation(c))
+	}
+}
+
+// layout shows in the room of the population a counter for each of the given number of colors,
+// or a single one if 0.
+func (h *hud) layout(colors int) {
+	h.releasePopulation()
+	if colors == 0 {
+		h.population = newCounter(scene, h.x, (buttonSize-hudDigitHeight)/2, hudDigitHeight, h.n)
+		return
+	}
+	d := hudColorDigitHeight
+	rows := (colors + hudColorColumns - 1) / hudColorColumns
+	y0 := (buttonSize - geom.Pt(rows)*(d+1) + 1) / 2
+	w := geom.Pt(h.n) * hudDigitWidth / hudColorColumns
+	digits := int((w - d - 2) / (d * hudDigitWidth / hudDigitHeight))
+	for c := 0; c < colors; c++ {
+		x, y := h.x+geom.Pt(c%hudColorColumns)*w, y0+geom.Pt(c/hudColorColumns)*(d+1)
+		s := &sprite.Node{}
+		eng.Register(s)
+		scene.AppendChild(s)
+		eng.SetTransform(s, f32.Affine{{float32(d), 0, float32(x)}, {0, float32(d), float32(y)}})
+		img := androidImage
+		if c > 0 {
+			img = colorTints[c-1].name
+		}
+		eng.SetSubTex(s, *textures[img])
+		h.swatches = append(h.swatches, s)
+		h.colors = append(h.colors, newCounter(scene, x+d+1, y, d, digits))
+	}
+}
+
+// refresh shows the counters again, for instance after the digit textures changed.
+func (h *hud) refresh() {
+	for _, c := range append([]*counter{h.generation, h.population}, h.colors...) {
+		if c == nil {
+			continue
+		}
+		v := c.value
+		c.value = -1
+		c.set(v)
+	}
+}
+
+// release removes the counters from the scene and unregisters their nodes.
+func (h *hud) release() {
+	h.generation.release()
+	h.releasePopulation()
+}
+
+// releasePopulation removes the counters of the population from the scene, and the cells of the
+// colors, and unregisters their nodes.
+func (h *hud) releasePopulation() {
+	if h.population != nil {
+		h.population.release()
+		h.population = nil
+	}
+	for _, c := range h.colors {
+		c.release()
+	}
+	for _, s := range h.swatches {
+		scene.RemoveChild(s)
+		eng.Unregister(s)
+	}
+	h.colors, h.swatches = nil, nil
+}
+
+// newCounter returns a counter of n digits h high, child of parent, whose leftmost digit has its
+// top-left corner at (x, y).
+func newCounter(parent *sprite.Node, x, y, h geom.Pt, n int) *counter {
+	c := &counter{value: -1}
+	w := h * hudDigitWidth / hudDigitHeight
+	for k := 0; k < n; k++ {
+		d := &sprite.Node{}
+		eng.Register(d)
+		parent.AppendChild(d)
+		eng.SetTransform(d, f32.Affine{
+			{float32(w), 0, float32(x + geom.Pt(k)*w)},
+			{0, float32(h), float32(y)},
+		})
+		c.digits = append(c.digits, d)
+	}
+	return c
+}
+
+// release removes the digits of c from their parent and unregisters their nodes.
+func (c *counter) release() {
+	for _, d := range c.digits {
+		d.Parent.RemoveChild(d)
+		eng.Unregister(d)
+	}
+}
+
+// set shows v, right aligned. Values with too many digits show as all nines.
+func (c *counter) set(v int) {
+	if v == c.value || v < 0 {
+		return
+	}
+	c.value = v
+	for k := len(c.digits) - 1; k >= 0; k-- {
+		switch {
+		case v == 0 && k < len(c.digits)-1:
+			eng.SetSubTex(c.digits[k], *textures[emptyImage])
+		case c.overflows():
+			eng.SetSubTex(c.digits[k], *textures[digitImage(9)])
+		default:
+			eng.SetSubTex(c.digits[k], *textures[digitImage(v%10)])
+		}
+		v /= 10
+	}
+}
+
+// overflows reports whether the value shown has more digits than c.
+func (c *counter) overflows() bool {
+	limit := 1
+	for range c.digits {
+		limit *= 10
+	}
+	return c.value >= limit
+}
@@ -0,0 +1,50 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+
+	"golang.org/x/mobile/geom"
+)
+
+// With the large controls, see cfg.large, the buttons, the panels and the digits of the hud are
+// largeScale times larger, see largeControls, the button bar wrapping in rows if it no longer fits
+// the screen, the buttons are hit up to buttonSep beyond their rectangles, and the icons and the
+// digits are drawn in high contrast over the theme, see contrasted.
+const largeScale = 1.5
+
+// setLarge turns the large controls on or off. The textures are drawn again, and the scene is laid
+// out again at the new sizes by the next frame.
+func setLarge(on bool) {
+	cfg.large = on
+	if on {
+		cfg.compact = false
+	}
+	scaleControls()
+	setTheme(currentTheme)
+	laidOut = geom.Point{}
+	log.Printf("large controls %v", on)
+}
+
+// contrasted returns img, an image of icons, in high contrast over the background of the current
+// theme: every pixel opaque in black or white, whichever stands out most, or transparent if it was
+// mostly so.
+func contrasted(img image.Image) image.Image {
+	fg := color.NRGBA{0, 0, 0, 0xff}
+	if bg := themes[currentTheme].background; 299*int(bg.R)+587*int(bg.G)+114*int(bg.B) < 128*1000 {
+		fg = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	}
+	b := img.Bounds()
+	out := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a >= 0x8000 {
+				out.SetNRGBA(x, y, fg)
+			}
+		}
+	}
+	return out
+}
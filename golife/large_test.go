@@ -0,0 +1,149 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/geom"
+)
+
+// overlap reports whether the rectangles r and s share more than their edges.
+func overlap(r, s geom.Rectangle) bool {
+	return r.Min.X < s.Max.X && s.Min.X < r.Max.X && r.Min.Y < s.Max.Y && s.Min.Y < r.Max.Y
+}
+
+// inside reports whether r lies in bounds.
+func inside(r, bounds geom.Rectangle) bool {
+	return r.Min.X >= bounds.Min.X && r.Min.Y >= bounds.Min.Y &&
+		r.Max.X <= bounds.Max.X && r.Max.Y <= bounds.Max.Y
+}
+
+// checkBar reports the buttons of bar out of bounds or overlapping one another.
+func checkBar(t *testing.T, what string, bar *ui.Bar, bounds geom.Rectangle) {
+	if bar == nil || len(bar.Buttons) == 0 {
+		t.Errorf("%s: no buttons", what)
+		return
+	}
+	for k, b := range bar.Buttons {
+		if !inside(b.Rect, bounds) {
+			t.Errorf("%s: %s at %v, out of %v", what, b.Name, b.Rect, bounds)
+		}
+		for _, c := range bar.Buttons[k+1:] {
+			if overlap(b.Rect, c.Rect) {
+				t.Errorf("%s: %s at %v overlaps %s at %v", what, b.Name, b.Rect, c.Name, c.Rect)
+			}
+		}
+	}
+}
+
+// checkButtonBar reports the buttons of the button bar out of the screen, overlapping one another,
+// below the bar or over the hud.
+func checkButtonBar(t *testing.T, what string) {
+	checkBar(t, what+" button bar", buttonBar, screen())
+	top := geom.Rectangle{Max: geom.Point{X: geom.Width, Y: systemBarHeight + buttonSize}}
+	for _, b := range buttonBar.Buttons {
+		if b.Rect.Max.Y > systemBarHeight+buttonBarHeight {
+			t.Errorf("%s: %s at %v, below the bar", what, b.Name, b.Rect)
+		}
+		if !overlap(b.Rect, top) {
+			continue
+		}
+		if w := geom.Pt(status.n) * hudDigitWidth; b.Rect.Min.X < buttonSep+w || b.Rect.Max.X > status.x {
+			t.Errorf("%s: %s at %v overlaps the hud, %v digits wide", what, b.Name, b.Rect, w)
+		}
+	}
+	if status.n < 1 {
+		t.Errorf("%s: hud of %d digits", what, status.n)
+	}
+}
+
+func TestLargeControls(t *testing.T) {
+	// A small screen, which the button bar no longer fits in one row at the larger scale.
+	testScene(t, 240, 360)
+	univ.adopt(life.New(univ.cols, univ.rows))
+	forgetPointers()
+	defer func() { cfg.large = false; scaleControls() }()
+	rows := univ.rows
+	setLarge(true)
+	layout()
+	if buttonSize != normalButtonSize*largeScale || hudDigitHeight != normalDigitHeight*largeScale {
+		t.Errorf("buttons of %v, digits of %v", buttonSize, hudDigitHeight)
+	}
+
+	// The bar wraps in rows clear of the hud and of the universe below.
+	if buttonBarHeight < 2*buttonSize {
+		t.Errorf("button bar %v high, not wrapped", buttonBarHeight)
+	}
+	checkButtonBar(t, "large")
+	if univ.rows >= rows {
+		t.Errorf("universe of %d rows under the wrapped bar, %d under one row", univ.rows, rows)
+	}
+	setPaused(true)
+	updateToolBar()
+	checkBar(t, "tool bar", toolBar, screen())
+
+	// The panels and menus fit the screen, their buttons apart.
+	openSettings()
+	if !inside(views.panel.rect, screen()) {
+		t.Errorf("settings panel at %v", views.panel.rect)
+	}
+	for k, row := range views.panel.rows {
+		if !inside(row.dec, views.panel.rect) || !inside(row.inc, views.panel.rect) || overlap(row.dec, row.inc) {
+			t.Errorf("setting %d: minus at %v, plus at %v", k, row.dec, row.inc)
+		}
+		if k > 0 && overlap(row.dec, views.panel.rows[k-1].dec) {
+			t.Errorf("setting %d overlaps the previous one", k)
+		}
+	}
+	// Turning the large controls off from the panel keeps it open at the normal size.
+	for k := range settings {
+		if settings[k].icon() == largeImage {
+			settings[k].change(1)
+		}
+	}
+	layout()
+	if cfg.large || views.panel == nil || buttonSize != normalButtonSize {
+		t.Fatalf("large controls %v, settings panel open %v", cfg.large, views.panel != nil)
+	}
+	views.panel.close()
+	setLarge(true)
+	layout()
+
+	openPicker("", true)
+	if !inside(views.picker.rect, screen()) {
+		t.Errorf("picker at %v", views.picker.rect)
+	}
+	checkBar(t, "picker", views.picker.bar, views.picker.rect)
+	views.picker.close()
+
+	univ.openStampMenu(&stroke{i: 2, j: 2, loc: cellLoc(2, 2), painted: make(map[int]bool)})
+	if views.menu == nil || !inside(views.menu.rect, screen()) {
+		t.Fatalf("stamp menu not fitting the screen")
+	}
+	checkBar(t, "stamp menu", views.menu.bar, views.menu.rect)
+	views.menu.close()
+}
+
+func TestContrasted(t *testing.T) {
+	defer func(k int) { currentTheme = k }(currentTheme)
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{0x80, 0x80, 0x80, 0xc0})
+	img.SetNRGBA(1, 0, color.NRGBA{0xff, 0x00, 0x00, 0x40})
+	for k, want := range []color.NRGBA{{0, 0, 0, 0xff}, {0xff, 0xff, 0xff, 0xff}} {
+		currentTheme = k
+		c := contrasted(img)
+		if got := color.NRGBAModel.Convert(c.At(0, 0)); got != want {
+			t.Errorf("theme %s: icon drawn in %v, want %v", themes[k].name, got, want)
+		}
+		for x := 1; x < 3; x++ {
+			if _, _, _, a := c.At(x, 0).RGBA(); a != 0 {
+				t.Errorf("theme %s: faint pixel %d drawn", themes[k].name, x)
+			}
+		}
+	}
+}
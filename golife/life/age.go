@@ -0,0 +1,49 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+// An AgeListener is a StepListener also notified of the alive cells that survive to some ages, so
+// that the cells need not be scanned after every Step to find them. The Listener of a game is told
+// if it implements AgeListener.
+type AgeListener interface {
+	StepListener
+	// AgeMarks returns the ages to be told of, in increasing order. It is asked at every Step.
+	AgeMarks() []int
+	// OnAge is called for an alive cell that survives to one of the AgeMarks, its age. Only the
+	// cells in view are told, see Grow.
+	OnAge(x, y, age int)
+}
+
+// ageMarks returns the Listener of l if it is an AgeListener with marks, along with them, else nil.
+func (l *Life) ageMarks() (AgeListener, []int) {
+	al, ok := l.Listener.(AgeListener)
+	if !ok {
+		return nil, nil
+	}
+	marks := al.AgeMarks()
+	if len(marks) == 0 {
+		return nil, nil
+	}
+	return al, marks
+}
+
+// tellAge tells al, if not nil, of the age of the alive cell at index k of the field if it is one
+// of marks.
+func (l *Life) tellAge(k int, al AgeListener, marks []int) {
+	if al == nil {
+		return
+	}
+	age := int(l.age[k])
+	if age > marks[len(marks)-1] {
+		return
+	}
+	for _, m := range marks {
+		if m == age {
+			x, y := k%l.w-l.vx, k/l.w-l.vy
+			if x >= 0 && x < l.vw && y >= 0 && y < l.vh {
+				al.OnAge(x, y, age)
+			}
+			return
+		}
+	}
+}
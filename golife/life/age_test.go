@@ -0,0 +1,57 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// ageLog records the cells told to an AgeListener reaching the ages of marks.
+type ageLog struct {
+	marks []int
+	told  []string
+}
+
+func (a *ageLog) OnBirth(x, y int) {}
+func (a *ageLog) OnDeath(x, y int) {}
+func (a *ageLog) AgeMarks() []int  { return a.marks }
+func (a *ageLog) OnAge(x, y, age int) {
+	a.told = append(a.told, fmt.Sprintf("%d,%d:%d", x, y, age))
+}
+
+// TestAgeMarks steps a soup with both algorithms, which must tell the cells reaching the ages of the
+// marks, as found by scanning the field.
+func TestAgeMarks(t *testing.T) {
+	for _, l := range []*Life{New(40, 30), NewSparse(40, 30)} {
+		l.SeedWith(0.05, Random, 3)
+		log := &ageLog{marks: []int{1, 5, 20}}
+		l.Listener = log
+		for gen := 1; gen <= 60; gen++ {
+			log.told = nil
+			l.Step()
+			var want []string
+			for y := 0; y < 30; y++ {
+				for x := 0; x < 40; x++ {
+					if a := l.Age(x, y); l.Alive(x, y) && (a == 1 || a == 5 || a == 20) {
+						want = append(want, fmt.Sprintf("%d,%d:%d", x, y, a))
+					}
+				}
+			}
+			sort.Strings(log.told)
+			sort.Strings(want)
+			if got := fmt.Sprint(log.told); got != fmt.Sprint(want) {
+				t.Fatalf("%T generation %d: told %s, want %s", l.sparse, gen, got, want)
+			}
+		}
+	}
+	// Without marks, nothing is told.
+	l := newGame("OO", "OO")
+	log := &ageLog{}
+	l.Listener = log
+	l.Step()
+	if len(log.told) != 0 {
+		t.Errorf("told %v without marks", log.told)
+	}
+}
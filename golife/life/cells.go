@@ -0,0 +1,135 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseCells parses a pattern in the plaintext format of LifeWiki, the .cells files. Lines starting
+// with ! are comments. Every other line is a row of the pattern, . being a dead cell and O an alive
+// one. Rows may be shorter than the pattern, dead cells at their end being left out.
+func ParseCells(r io.Reader) (*Pattern, error) {
+	sc := bufio.NewScanner(r)
+	p := &Pattern{}
+	line := 0
+	for sc.Scan() {
+		line++
+		s := strings.TrimRight(sc.Text(), " \t\r")
+		if strings.HasPrefix(s, "!") {
+			continue
+		}
+		if len(s) > maxPatternSize || p.H == maxPatternSize {
+			return nil, fmt.Errorf("life: invalid plaintext pattern: line %d: pattern too large", line)
+		}
+		for x, c := range s {
+			switch c {
+			case '.':
+			case 'O', '*':
+				p.Cells = append(p.Cells, [2]int{x, p.H})
+			default:
+				return nil, fmt.Errorf("life: invalid plaintext pattern: line %d: unexpected %q", line, c)
+			}
+		}
+		if len(s) > p.W {
+			p.W = len(s)
+		}
+		p.H++
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	// Trailing empty rows are not part of the pattern.
+	if len(p.Cells) == 0 {
+		p.W, p.H = 0, 0
+	} else {
+		p.H = p.Cells[len(p.Cells)-1][1] + 1
+	}
+	return p, nil
+}
+
+// life106Header is the first line of a pattern in the Life 1.06 format.
+const life106Header = "#Life 1.06"
+
+// ParseLife106 parses a pattern in the Life 1.06 format, which starts with the line "#Life 1.06"
+// followed by the coordinates "<x> <y>" of the alive cells, one per line. The coordinates may be
+// negative: the pattern is the smallest rectangle holding every cell.
+func ParseLife106(r io.Reader) (*Pattern, error) {
+	sc := bufio.NewScanner(r)
+	if !sc.Scan() || strings.TrimSpace(sc.Text()) != life106Header {
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("life: invalid Life 1.06 pattern: missing %q header", life106Header)
+	}
+
+	var cells [][2]int
+	line := 1
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		f := strings.Fields(s)
+		if len(f) != 2 {
+			return nil, fmt.Errorf("life: invalid Life 1.06 pattern: line %d: malformed coordinates %q", line, s)
+		}
+		var c [2]int
+		for k := range c {
+			d, err := strconv.Atoi(f[k])
+			if err != nil || d < -maxPatternSize || d > maxPatternSize {
+				return nil, fmt.Errorf("life: invalid Life 1.06 pattern: line %d: invalid coordinate %q", line, f[k])
+			}
+			c[k] = d
+		}
+		cells = append(cells, c)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return newPattern(cells)
+}
+
+// newPattern returns the pattern of the given alive cells, which may be listed in any order and more
+// than once, moved so that the pattern is the smallest rectangle holding them.
+func newPattern(cells [][2]int) (*Pattern, error) {
+	p := &Pattern{}
+	if len(cells) == 0 {
+		return p, nil
+	}
+	sort.Sort(rowMajor(cells))
+	x0, y0, x1, y1 := cells[0][0], cells[0][1], cells[0][0], cells[len(cells)-1][1]
+	for _, c := range cells {
+		if c[0] < x0 {
+			x0 = c[0]
+		}
+		if c[0] > x1 {
+			x1 = c[0]
+		}
+	}
+	p.W, p.H = x1-x0+1, y1-y0+1
+	if p.W > maxPatternSize || p.H > maxPatternSize {
+		return nil, fmt.Errorf("life: pattern of %dx%d cells too large", p.W, p.H)
+	}
+	for k, c := range cells {
+		if k == 0 || c != cells[k-1] {
+			p.Cells = append(p.Cells, [2]int{c[0] - x0, c[1] - y0})
+		}
+	}
+	return p, nil
+}
+
+// rowMajor sorts cells in row-major order.
+type rowMajor [][2]int
+
+func (c rowMajor) Len() int      { return len(c) }
+func (c rowMajor) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c rowMajor) Less(i, j int) bool {
+	return c[i][1] < c[j][1] || c[i][1] == c[j][1] && c[i][0] < c[j][0]
+}
@@ -0,0 +1,110 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCells(t *testing.T) {
+	for _, test := range []struct {
+		name, text string
+		want       *Pattern
+	}{
+		{
+			name: "diehard",
+			text: "!Name: Diehard\n!Author: Achim Flammenkamp\n......O.\nOO......\n.O...OOO\n",
+			want: &Pattern{W: 8, H: 3, Cells: [][2]int{{6, 0}, {0, 1}, {1, 1}, {1, 2}, {5, 2}, {6, 2}, {7, 2}}},
+		},
+		{
+			name: "short rows",
+			text: "O\n\n..O\n",
+			want: &Pattern{W: 3, H: 3, Cells: [][2]int{{0, 0}, {2, 2}}},
+		},
+		{
+			name: "stars and CRLF",
+			text: ".*\r\n*.\r\n",
+			want: &Pattern{W: 2, H: 2, Cells: [][2]int{{1, 0}, {0, 1}}},
+		},
+		{
+			name: "trailing empty rows",
+			text: "OO\n..\n..\n",
+			want: &Pattern{W: 2, H: 1, Cells: [][2]int{{0, 0}, {1, 0}}},
+		},
+		{
+			name: "empty",
+			text: "!Nothing\n",
+			want: &Pattern{},
+		},
+	} {
+		p, err := ParseCells(strings.NewReader(test.text))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !samePattern(p, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, p, test.want)
+		}
+	}
+}
+
+func TestParseCellsInvalid(t *testing.T) {
+	for _, test := range []struct{ name, text string }{
+		{"unexpected character", "..O\n.X.\n"},
+		{"RLE", "x = 3, y = 1\n3o!\n"},
+		{"row too long", strings.Repeat(".", maxPatternSize+1) + "O\n"},
+	} {
+		if p, err := ParseCells(strings.NewReader(test.text)); err == nil {
+			t.Errorf("%s: parsed as %+v", test.name, p)
+		}
+	}
+}
+
+func TestParseLife106(t *testing.T) {
+	for _, test := range []struct {
+		name, text string
+		want       *Pattern
+	}{
+		{
+			name: "glider",
+			text: "#Life 1.06\n0 -1\n1 0\n-1 1\n0 1\n1 1\n",
+			want: &Pattern{W: 3, H: 3, Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}},
+		},
+		{
+			name: "comments, blanks and duplicates",
+			text: "#Life 1.06\n#D A domino\n\n  5 7 \n6 7\n5 7\n",
+			want: &Pattern{W: 2, H: 1, Cells: [][2]int{{0, 0}, {1, 0}}},
+		},
+		{
+			name: "empty",
+			text: "#Life 1.06\n",
+			want: &Pattern{},
+		},
+	} {
+		p, err := ParseLife106(strings.NewReader(test.text))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !samePattern(p, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, p, test.want)
+		}
+	}
+}
+
+func TestParseLife106Invalid(t *testing.T) {
+	for _, test := range []struct{ name, text string }{
+		{"no header", "0 0\n1 0\n"},
+		{"Life 1.05", "#Life 1.05\n.O\n"},
+		{"one coordinate", "#Life 1.06\n0\n"},
+		{"three coordinates", "#Life 1.06\n0 1 2\n"},
+		{"not a number", "#Life 1.06\n0 x\n"},
+		{"coordinate too large", "#Life 1.06\n0 99999999\n"},
+		{"pattern too large", "#Life 1.06\n-40000 0\n40000 0\n"},
+	} {
+		if p, err := ParseLife106(strings.NewReader(test.text)); err == nil {
+			t.Errorf("%s: parsed as %+v", test.name, p)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+// MaxColors is the largest number of colors of the cells of a game, see Life.Colors.
+const MaxColors = 4
+
+// Color returns the color of the specified cell, which must be inside the field, in view, and alive.
+// Cells of games of a single color are of color 0.
+func (l *Life) Color(x, y int) int {
+	return int(l.color[l.key(x, y)])
+}
+
+// SetColor sets the color of the specified cell, which must be inside the field, in view, and alive,
+// to c in [0, MaxColors), unless pinned, see Pin.
+func (l *Life) SetColor(x, y, c int) {
+	k := l.key(x, y)
+	if l.pinned[k] {
+		return
+	}
+	l.touch(k)
+	l.pops[l.color[k]]--
+	l.color[k] = uint8(c)
+	l.pops[c]++
+}
+
+// ColorPopulation returns the number of alive cells of color c in [0, MaxColors).
+func (l *Life) ColorPopulation(c int) int {
+	return l.pops[c]
+}
+
+// countColors counts the alive cells of each color again, for instance once the fields changed as
+// a whole.
+func (l *Life) countColors() {
+	l.pops = [MaxColors]int{}
+	for k, alive := range l.a.s {
+		if alive {
+			l.pops[l.color[k]]++
+		}
+	}
+}
+
+// birthColor returns the color of the cell at (x, y) of the field once born from its alive
+// neighbors: the color most of them have or, if several colors tie, the first color of the game
+// none of them has, else the first of the tied colors.
+func (l *Life) birthColor(x, y int) uint8 {
+	if l.Colors < 2 {
+		return 0
+	}
+	var n [MaxColors]int
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if k, ok := l.a.index(x+dx, y+dy, l.Edges); ok && (dx != 0 || dy != 0) && l.a.s[k] {
+				n[l.color[k]]++
+			}
+		}
+	}
+	best, tied := 0, 0
+	for c := range n {
+		if n[c] > n[best] {
+			best = c
+		}
+	}
+	for c := range n {
+		if n[c] == n[best] {
+			tied++
+		}
+	}
+	if tied == 1 {
+		return uint8(best)
+	}
+	for c := 0; c < l.Colors && c < MaxColors; c++ {
+		if n[c] == 0 {
+			return uint8(c)
+		}
+	}
+	return uint8(best)
+}
@@ -0,0 +1,46 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import "testing"
+
+func TestImmigration(t *testing.T) {
+	// Newborn cells take the color most of their parents have.
+	l := newGame(
+		".....",
+		".OOO.",
+		".....",
+	)
+	l.Colors = 2
+	l.SetColor(1, 1, 1)
+	l.SetColor(2, 1, 1)
+	l.Step()
+	for _, c := range [][2]int{{2, 0}, {2, 1}, {2, 2}} {
+		if !l.Alive(c[0], c[1]) || l.Color(c[0], c[1]) != 1 {
+			t.Errorf("cell %d,%d not alive of color 1", c[0], c[1])
+		}
+	}
+	if l.ColorPopulation(0) != 0 || l.ColorPopulation(1) != 3 {
+		t.Errorf("populations of the colors %d and %d, want 0 and 3", l.ColorPopulation(0), l.ColorPopulation(1))
+	}
+}
+
+func TestQuadLife(t *testing.T) {
+	// Three parents of different colors give birth to a cell of the fourth.
+	l := newGame(
+		".....",
+		".OOO.",
+		".....",
+	)
+	l.Colors = 4
+	l.SetColor(1, 1, 1)
+	l.SetColor(2, 1, 2)
+	l.SetColor(3, 1, 3)
+	l.Step()
+	if !l.Alive(2, 0) || l.Color(2, 0) != 0 || l.Color(2, 2) != 0 {
+		t.Errorf("newborn cells of colors %d and %d, want 0", l.Color(2, 0), l.Color(2, 2))
+	}
+	if l.Color(2, 1) != 2 {
+		t.Errorf("surviving cell changed to color %d", l.Color(2, 1))
+	}
+}
@@ -0,0 +1,157 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+// MaxPeriod is the longest period of the cycles told by Period.
+const MaxPeriod = 64
+
+// A cycleHistory remembers the latest generations of a game stepped since its cells last changed
+// otherwise, to tell when it enters a cycle. Generations are told apart by hash, and by rule and
+// edge mode, which may have changed between steps. To tell when they moved, see Drift, their
+// populations are remembered too, and the sums of the coordinates of their alive cells on the
+// field, which Step keeps up to date from its changes once summed from scratch.
+type cycleHistory struct {
+	gens [MaxPeriod + 1]struct {
+		hash       uint64
+		rule       Rule
+		edges      EdgeMode
+		population int
+		sumX, sumY int
+		summed     bool // Whether sumX and sumY are known.
+	}
+	n int // Number of generations remembered, the latest at index (n-1)%len(gens).
+	// sumX and sumY are the sums of the current generation, unless stale.
+	sumX, sumY int
+	stale      bool
+}
+
+// remember records the generation of l just stepped to.
+func (l *Life) remember() {
+	h := &l.cycles
+	g := &h.gens[h.n%len(h.gens)]
+	g.hash, g.rule, g.edges = l.hash, l.Rule, l.Edges
+	g.population, g.sumX, g.sumY, g.summed = l.population, h.sumX, h.sumY, !h.stale
+	h.n++
+}
+
+// forget forgets the generations remembered but the current one, once cells changed outside of
+// Step. The sums of the coordinates are left stale until the next Step, as cells may change one
+// at a time.
+func (l *Life) forget() {
+	l.cycles.n = 0
+	l.cycles.stale = true
+	l.remember()
+}
+
+// moved updates the sums of the coordinates of the alive cells after the cell at (x, y) of the
+// field was born or died during a Step.
+func (h *cycleHistory) moved(x, y int, born bool) {
+	if born {
+		h.sumX, h.sumY = h.sumX+x, h.sumY+y
+	} else {
+		h.sumX, h.sumY = h.sumX-x, h.sumY-y
+	}
+}
+
+// stepped remembers the generation of l just stepped to, summing the coordinates of its alive
+// cells from scratch if they were stale.
+func (l *Life) stepped() {
+	h := &l.cycles
+	if h.stale {
+		h.sumX, h.sumY = 0, 0
+		for k, alive := range l.a.s {
+			if alive {
+				h.moved(k%l.w, k/l.w, true)
+			}
+		}
+		h.stale = false
+	}
+	l.remember()
+}
+
+// Period returns the period of the cycle the game entered, 1 for a still life or a field of dead
+// cells, or 0 if none. A cycle is told once the current generation repeats one of the MaxPeriod
+// previous ones, stepped since the cells last changed outside of Step.
+func (l *Life) Period() int {
+	h := &l.cycles
+	if h.n < 2 {
+		return 0
+	}
+	last := h.gens[(h.n-1)%len(h.gens)]
+	for p := 1; p < h.n && p <= MaxPeriod; p++ {
+		if g := h.gens[(h.n-1-p)%len(h.gens)]; g.hash == last.hash && g.rule == last.rule && g.edges == last.edges {
+			return p
+		}
+	}
+	return 0
+}
+
+// Drift returns the period of the cycle the game entered moving across the field, as spaceships
+// do, and how far it moves: the current generation is the one period generations before moved by
+// (dx, dy), wrapping around in Wrap mode. It is 0 if none, or if the game is in a cycle staying in
+// place, see Period. The moves looked for are at most a cell per generation along each axis, the
+// speed of light. Only the offsets by which the alive cells of the earlier generation, as many,
+// could have moved as a whole are tried, as told by the sums of their coordinates.
+func (l *Life) Drift() (period, dx, dy int) {
+	h := &l.cycles
+	if h.n < 2 || l.population == 0 || l.Period() != 0 {
+		return 0, 0, 0
+	}
+	last := h.gens[(h.n-1)%len(h.gens)]
+	if !last.summed {
+		return 0, 0, 0
+	}
+	for p := 1; p < h.n && p <= MaxPeriod; p++ {
+		g := h.gens[(h.n-1-p)%len(h.gens)]
+		if !g.summed || g.population != last.population || g.rule != last.rule || g.edges != last.edges {
+			continue
+		}
+		for _, dx := range l.driftOffsets(p, last.sumX-g.sumX, l.w) {
+			for _, dy := range l.driftOffsets(p, last.sumY-g.sumY, l.h) {
+				if mh, ok := l.movedHash(-dx, -dy); ok && mh == g.hash && (dx != 0 || dy != 0) {
+					return p, dx, dy
+				}
+			}
+		}
+	}
+	return 0, 0, 0
+}
+
+// driftOffsets returns the offsets, at most p long, by which the alive cells of l may have moved
+// along an axis of the field size cells long in p generations, as the sum of their coordinates
+// along it changed by delta: as many times the offset as cells, give or take the size for each
+// cell that wrapped around in Wrap mode.
+func (l *Life) driftOffsets(p, delta, size int) []int {
+	var ds []int
+	for d := -p; d <= p; d++ {
+		if r := delta - d*l.population; r == 0 || l.Edges == Wrap && r%size == 0 {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+// movedHash returns the hash l would have with every cell moved by (dx, dy) on the field, wrapping
+// around in Wrap mode, or false if a cell would leave the field.
+func (l *Life) movedHash(dx, dy int) (uint64, bool) {
+	var h uint64
+	for k, alive := range l.a.s {
+		d := l.decay[k]
+		if !alive && d == 0 {
+			continue
+		}
+		x, y := k%l.w+dx, k/l.w+dy
+		if x < 0 || x >= l.w || y < 0 || y >= l.h {
+			if l.Edges != Wrap {
+				return 0, false
+			}
+			x, y = (x%l.w+l.w)%l.w, (y%l.h+l.h)%l.h
+		}
+		if alive {
+			h ^= cellKey(y*l.w + x)
+		} else {
+			h ^= stateKey(y*l.w+x, int(d))
+		}
+	}
+	return h, true
+}
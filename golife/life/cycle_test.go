@@ -0,0 +1,121 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import "testing"
+
+func TestPeriod(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		rows   []string
+		steps  int
+		period int
+	}{
+		{"empty", []string{"...", "...", "..."}, 2, 1},
+		{"block", []string{"....", ".OO.", ".OO.", "...."}, 2, 1},
+		{"blinker", []string{".....", ".....", ".OOO.", ".....", "....."}, 3, 2},
+		{"blinker too early", []string{".....", ".....", ".OOO.", ".....", "....."}, 1, 0},
+		{"toad", []string{
+			"......",
+			"......",
+			"..OOO.",
+			".OOO..",
+			"......",
+			"......",
+		}, 4, 2},
+		{"glider", []string{
+			".O......",
+			"..O.....",
+			"OOO.....",
+			"........",
+			"........",
+			"........",
+			"........",
+			"........",
+		}, 8, 0},
+	} {
+		l := newGame(test.rows...)
+		for k := 0; k < test.steps; k++ {
+			l.Step()
+		}
+		if p := l.Period(); p != test.period {
+			t.Errorf("%s: period %d after %d steps, want %d", test.name, p, test.steps, test.period)
+		}
+	}
+}
+
+func TestPeriodGliderOnTorus(t *testing.T) {
+	// A glider crosses an 8*8 torus back to where it started in 32 generations.
+	l := New(8, 8)
+	l.Stamp(glider, 0, 0)
+	for k := 0; k < 31; k++ {
+		l.Step()
+		if p := l.Period(); p != 0 {
+			t.Fatalf("period %d at generation %d", p, l.Generation())
+		}
+	}
+	l.Step()
+	if p := l.Period(); p != 32 {
+		t.Errorf("period %d, want 32", p)
+	}
+}
+
+func TestPeriodForgetsEdits(t *testing.T) {
+	l := newGame(".....", ".....", ".OOO.", ".....", ".....")
+	for k := 0; k < 4; k++ {
+		l.Step()
+	}
+	l.Set(0, 0, true)
+	if p := l.Period(); p != 0 {
+		t.Errorf("period %d once edited", p)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	lwss := &Pattern{W: 5, H: 4, Cells: [][2]int{{1, 0}, {4, 0}, {0, 1}, {0, 2}, {4, 2}, {0, 3}, {1, 3}, {2, 3}, {3, 3}}}
+	for _, test := range []struct {
+		name           string
+		w, h           int
+		edges          EdgeMode
+		p              *Pattern
+		x, y, steps    int
+		period, dx, dy int
+	}{
+		{"glider", 20, 20, Bounded, glider, 2, 2, 8, 4, 1, 1},
+		{"glider too early", 20, 20, Bounded, glider, 2, 2, 3, 0, 0, 0},
+		// Across the seams of a torus, and back where it started, where Period tells the cycle.
+		{"glider wrapping", 8, 8, Wrap, glider, 5, 5, 12, 4, 1, 1},
+		{"glider back", 8, 8, Wrap, glider, 0, 0, 32, 0, 0, 0},
+		{"lightweight spaceship", 30, 10, Bounded, lwss, 20, 3, 8, 4, -2, 0},
+		{"blinker", 5, 5, Bounded, &Pattern{W: 3, H: 1, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}}}, 1, 2, 8, 0, 0, 0},
+	} {
+		l := New(test.w, test.h)
+		l.Edges = test.edges
+		l.Stamp(test.p, test.x, test.y)
+		for k := 0; k < test.steps; k++ {
+			l.Step()
+		}
+		if p, dx, dy := l.Drift(); p != test.period || dx != test.dx || dy != test.dy {
+			t.Errorf("%s: drift of %d,%d over %d generations, want %d,%d over %d", test.name, dx, dy, p, test.dx, test.dy, test.period)
+		}
+	}
+
+	// Edits make the earlier generations unknown, even to NewSparse games, and the one edited too
+	// as the coordinates of its cells are only summed by the next step.
+	l := NewSparse(20, 20)
+	l.Stamp(glider, 2, 2)
+	for k := 0; k < 8; k++ {
+		l.Step()
+	}
+	l.Set(19, 19, true)
+	l.Set(19, 19, false)
+	for k := 0; k < 5; k++ {
+		if p, _, _ := l.Drift(); p != 0 {
+			t.Errorf("drift over %d generations %d steps after an edit", p, k)
+		}
+		l.Step()
+	}
+	if p, dx, dy := l.Drift(); p != 4 || dx != 1 || dy != 1 {
+		t.Errorf("sparse glider drifts by %d,%d over %d generations, want 1,1 over 4", dx, dy, p)
+	}
+}
@@ -0,0 +1,71 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+// A DecayListener is a StepListener also notified of the dying cells that change state, see
+// Rule.States. The Listener of a game is told if it implements DecayListener.
+type DecayListener interface {
+	StepListener
+	// OnDecay is called for a dying cell that reaches its next state, dead after the last one.
+	// Only the cells in view are told, see Grow.
+	OnDecay(x, y int)
+}
+
+// State returns the state of the specified cell, which must be inside the field, in view: 0 if dead,
+// 1 if alive, else the dying state from 2 it decays through under the Generations rules, see
+// Rule.States.
+func (l *Life) State(x, y int) int {
+	return l.cellState(l.key(x, y))
+}
+
+// cellState is State for the cell at index k of the field.
+func (l *Life) cellState(k int) int {
+	// A cell dying during a Step decays before the fields swap, see Listener.
+	if d := l.decay[k]; d != 0 {
+		return int(d)
+	}
+	if l.a.s[k] {
+		return 1
+	}
+	return 0
+}
+
+// Dying returns the number of dying cells, see Rule.States.
+func (l *Life) Dying() int {
+	return l.dying
+}
+
+// stateKey returns the key for Hash of the cell at index k of a field in the dying state s, mixed
+// from the key of the cell alive so that dying cells do not cancel alive ones.
+func stateKey(k, s int) uint64 {
+	return mix(cellKey(k) + uint64(s)*0x9e3779b97f4a7c15)
+}
+
+// setDecay sets the dying state of the dead cell at index k of the field to s, 0 for a dead one.
+func (l *Life) setDecay(k, s int) {
+	if d := int(l.decay[k]); d != 0 {
+		l.hash ^= stateKey(k, d)
+		l.dying--
+	}
+	l.decay[k] = uint8(s)
+	if s != 0 {
+		l.hash ^= stateKey(k, s)
+		l.dying++
+	}
+}
+
+// decayStep moves the dying cell at index k of the field to its next state, dead after the last
+// one of the rule, and tells the Listener.
+func (l *Life) decayStep(k int) {
+	s := int(l.decay[k]) + 1
+	if s >= l.Rule.states() {
+		s = 0
+	}
+	l.setDecay(k, s)
+	if d, ok := l.Listener.(DecayListener); ok {
+		x, y := k%l.w-l.vx, k/l.w-l.vy
+		if x >= 0 && x < l.vw && y >= 0 && y < l.vh {
+			d.OnDecay(x, y)
+		}
+	}
+}
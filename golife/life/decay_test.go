@@ -0,0 +1,60 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// decayLog records the births, deaths and decays told to a DecayListener.
+type decayLog []string
+
+func (d *decayLog) OnBirth(x, y int) { *d = append(*d, "birth") }
+func (d *decayLog) OnDeath(x, y int) { *d = append(*d, "death") }
+func (d *decayLog) OnDecay(x, y int) { *d = append(*d, "decay") }
+
+func TestDecay(t *testing.T) {
+	// Under B/S/C4, an alive cell without neighbors decays through states 2 and 3 before it is dead.
+	l := newGame("...", ".O.", "...")
+	l.Rule, _ = ParseRule("B/S/C4")
+	var log decayLog
+	l.Listener = &log
+	for _, want := range []int{2, 3, 0, 0} {
+		l.Step()
+		if s := l.State(1, 1); s != want {
+			t.Errorf("generation %d: state %d, want %d", l.Generation(), s, want)
+		}
+	}
+	if l.Dying() != 0 || l.Population() != 0 {
+		t.Errorf("%d dying and %d alive cells left", l.Dying(), l.Population())
+	}
+	if got := fmt.Sprint(log); got != "[death decay decay]" {
+		t.Errorf("told %s", got)
+	}
+}
+
+func TestBriansBrain(t *testing.T) {
+	// No cell is born on a dying one, nor do dying cells count as neighbors.
+	l := newGame(
+		"......",
+		"..OO..",
+		"......",
+		"......",
+	)
+	l.Rule, _ = ParseRule("B2/S/C3")
+	l.Step()
+	want := []string{
+		"..OO..",
+		"......",
+		"..OO..",
+		"......",
+	}
+	if got := picture(l); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("stepped as %v, want %v", got, want)
+	}
+	if l.State(2, 1) != 2 || l.State(3, 1) != 2 || l.Dying() != 2 {
+		t.Errorf("dying cells in states %d and %d, %d dying", l.State(2, 1), l.State(3, 1), l.Dying())
+	}
+}
@@ -0,0 +1,246 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// encodingVersion is the first byte of the binary encoding of a game. It must change whenever the
+// encoding does. Version 1 had no view, see Grow, version 2 no colors, see Life.Colors, version 3
+// no dying cells, see Rule.States, version 4 no ages, see Life.Age, and version 5 no pinned cells,
+// see Life.Pin.
+const encodingVersion = 6
+
+// maxEncodedCells bounds the size of a decoded field, so that a corrupted encoding does not exhaust
+// memory.
+const maxEncodedCells = 1 << 24
+
+var errTruncated = errors.New("life: invalid encoding: truncated")
+
+// MarshalBinary encodes the field, generation count, rule, edge mode, view and number of colors of l.
+// After a version byte and the other values as varints, the number of states of the rule last, the
+// cells are stored in row-major order as the lengths of the alternating runs of dead and alive
+// cells, starting with a possibly empty run of dead cells, followed in games of several colors by the
+// color of each alive cell, then by the age of each alive cell, then by the number of dying cells
+// and, for each, the number of cells since the previous one and its state, then likewise by the
+// number of pinned cells and, for each, the number of cells since the previous one.
+func (l *Life) MarshalBinary() ([]byte, error) {
+	b := []byte{encodingVersion}
+	put := func(v int) {
+		var buf [binary.MaxVarintLen64]byte
+		b = append(b, buf[:binary.PutUvarint(buf[:], uint64(v))]...)
+	}
+	for _, v := range []int{l.w, l.h, l.generation, int(l.Rule.Birth), int(l.Rule.Survival), int(l.Edges),
+		l.vx, l.vy, l.vw, l.vh, l.Colors, l.Rule.States} {
+		put(v)
+	}
+	run, alive := 0, false
+	for _, s := range l.a.s {
+		if s != alive {
+			put(run)
+			run, alive = 0, s
+		}
+		run++
+	}
+	put(run)
+	if l.Colors > 1 {
+		for k, s := range l.a.s {
+			if s {
+				put(int(l.color[k]))
+			}
+		}
+	}
+	for k, s := range l.a.s {
+		if s {
+			put(int(l.age[k]))
+		}
+	}
+	put(l.dying)
+	last := -1
+	for k, d := range l.decay {
+		if d != 0 {
+			put(k - last - 1)
+			put(int(d))
+			last = k
+		}
+	}
+	put(l.pins)
+	last = -1
+	for k, p := range l.pinned {
+		if p {
+			put(k - last - 1)
+			last = k
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary replaces the state of l by the one encoded by MarshalBinary in data, or by an
+// earlier version, whose cells are decoded newborn. The Listener is kept. On error l is left
+// unchanged.
+func (l *Life) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errTruncated
+	}
+	if data[0] == 0 || data[0] > encodingVersion {
+		return fmt.Errorf("life: invalid encoding: unknown version %d", data[0])
+	}
+	r := bytes.NewReader(data[1:])
+	get := func(limit uint64) (int, error) {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return 0, errTruncated
+		}
+		if v > limit {
+			return 0, fmt.Errorf("life: invalid encoding: value %d out of range [0, %d]", v, limit)
+		}
+		return int(v), nil
+	}
+	maxs := []uint64{maxEncodedCells, maxEncodedCells, 1<<63 - 1, 1<<9 - 1, 1<<9 - 1, uint64(Grow)}
+	if data[0] >= 2 {
+		maxs = append(maxs, maxEncodedCells, maxEncodedCells, maxEncodedCells, maxEncodedCells)
+	}
+	if data[0] >= 3 {
+		maxs = append(maxs, MaxColors)
+	}
+	if data[0] >= 4 {
+		maxs = append(maxs, MaxStates)
+	}
+	var hdr [12]int
+	for k, limit := range maxs {
+		v, err := get(limit)
+		if err != nil {
+			return err
+		}
+		hdr[k] = v
+	}
+	w, h := hdr[0], hdr[1]
+	if w == 0 || h == 0 || w*h > maxEncodedCells {
+		return fmt.Errorf("life: invalid encoding: bad field size %dx%d", w, h)
+	}
+	// Version 1 views the whole field.
+	vx, vy, vw, vh := hdr[6], hdr[7], hdr[8], hdr[9]
+	if data[0] == 1 {
+		vw, vh = w, h
+	}
+	if vw == 0 || vh == 0 || vx+vw > w || vy+vh > h {
+		return fmt.Errorf("life: invalid encoding: bad view %dx%d at %d,%d", vw, vh, vx, vy)
+	}
+
+	a, population, hash := newField(w, h), 0, uint64(0)
+	for k, alive := 0, false; k < w*h; alive = !alive {
+		run, err := get(uint64(w*h - k))
+		if err != nil {
+			return err
+		}
+		if run == 0 && k > 0 {
+			return errors.New("life: invalid encoding: empty run")
+		}
+		for end := k + run; k < end; k++ {
+			a.s[k] = alive
+			if alive {
+				hash ^= cellKey(k)
+			}
+		}
+		if alive {
+			population += run
+		}
+	}
+	col := make([]uint8, w*h)
+	if hdr[10] > 1 {
+		for k, alive := range a.s {
+			if !alive {
+				continue
+			}
+			c, err := get(uint64(hdr[10] - 1))
+			if err != nil {
+				return err
+			}
+			col[k] = uint8(c)
+		}
+	}
+	age := make([]uint16, w*h)
+	if data[0] >= 5 {
+		for k, alive := range a.s {
+			if !alive {
+				continue
+			}
+			v, err := get(1<<16 - 1)
+			if err != nil {
+				return err
+			}
+			age[k] = uint16(v)
+		}
+	}
+	decay, dying := make([]uint8, w*h), 0
+	if data[0] >= 4 {
+		states := Rule{States: hdr[11]}.states()
+		n, err := get(uint64(w*h - population))
+		if err != nil {
+			return err
+		}
+		for k := -1; dying < n; dying++ {
+			// The previous dying cell may be the last of the field, leaving no room for another.
+			if k >= w*h-1 {
+				return errors.New("life: invalid encoding: dying cells beyond the field")
+			}
+			gap, err := get(uint64(w*h - k - 2))
+			if err != nil {
+				return err
+			}
+			k += gap + 1
+			s, err := get(MaxStates - 1)
+			if err != nil {
+				return err
+			}
+			if s < 2 || s >= states || a.s[k] {
+				return fmt.Errorf("life: invalid encoding: bad dying cell %d in state %d", k, s)
+			}
+			decay[k] = uint8(s)
+			hash ^= stateKey(k, s)
+		}
+	}
+	pinned, pins := make([]bool, w*h), 0
+	if data[0] >= 6 {
+		n, err := get(uint64(w * h))
+		if err != nil {
+			return err
+		}
+		for k := -1; pins < n; pins++ {
+			if k >= w*h-1 {
+				return errors.New("life: invalid encoding: pinned cells beyond the field")
+			}
+			gap, err := get(uint64(w*h - k - 2))
+			if err != nil {
+				return err
+			}
+			k += gap + 1
+			if decay[k] != 0 || a.s[k] && col[k] != 0 {
+				return fmt.Errorf("life: invalid encoding: bad pinned cell %d", k)
+			}
+			pinned[k] = true
+		}
+	}
+	if r.Len() != 0 {
+		return errors.New("life: invalid encoding: trailing data")
+	}
+
+	l.a, l.b, l.age, l.color = a, newField(w, h), age, col
+	l.decay, l.dying = decay, dying
+	l.pinned, l.pins = pinned, pins
+	l.w, l.h = w, h
+	l.vx, l.vy, l.vw, l.vh = vx, vy, vw, vh
+	l.population, l.generation, l.hash = population, hdr[2], hash
+	l.Rule = Rule{Birth: uint16(hdr[3]), Survival: uint16(hdr[4]), States: hdr[11]}
+	l.Edges = EdgeMode(hdr[5])
+	l.Colors = hdr[10]
+	l.countColors()
+	l.reshapes++
+	l.invalidate()
+	l.forget()
+	return nil
+}
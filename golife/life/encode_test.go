@@ -0,0 +1,253 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// encodingGames returns games exercising every part of the encoding.
+func encodingGames() map[string]*Life {
+	games := map[string]*Life{
+		"empty": New(7, 5),
+		"1x1":   New(1, 1),
+	}
+
+	full := New(6, 4)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 6; x++ {
+			full.Set(x, y, true)
+		}
+	}
+	games["full"] = full
+
+	soup := New(40, 30)
+	soup.SeedWith(0.3, Random, 1)
+	soup.Step()
+	games["soup"] = soup
+
+	colors := New(20, 20)
+	colors.Colors = 4
+	colors.Edges = Bounded
+	colors.SeedWith(0.4, Random, 2)
+	colors.Step()
+	games["colors"] = colors
+
+	brain := New(24, 24)
+	brain.Rule = Rule{Birth: 1 << 2, States: 3}
+	brain.SeedWith(0.3, Mirror, 3)
+	for k := 0; k < 3; k++ {
+		brain.Step()
+	}
+	games["dying"] = brain
+
+	grown := New(8, 8)
+	grown.Edges = Grow
+	grown.Stamp(glider, 5, 5)
+	for k := 0; k < 8; k++ {
+		grown.Step()
+	}
+	games["grown"] = grown
+
+	// A block of old cells among newborn ones, one of them of the largest age.
+	old := New(10, 6)
+	old.Stamp(&Pattern{W: 2, H: 2, Cells: [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}}}, 1, 1)
+	for k := 0; k < 300; k++ {
+		old.Step()
+	}
+	old.age[old.key(1, 1)] = 1<<16 - 1
+	old.Stamp(glider, 6, 2)
+	games["old"] = old
+
+	// Walls and sources among the cells of a soup, a wall in the last cell.
+	pinned := New(16, 12)
+	pinned.SeedWith(0.3, Random, 4)
+	pinned.Pin(0, 0, true)
+	pinned.Pin(5, 5, false)
+	pinned.Pin(15, 11, false)
+	pinned.Step()
+	games["pinned"] = pinned
+	return games
+}
+
+// glider is a glider moving down and right.
+var glider = &Pattern{W: 3, H: 3, Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}}
+
+// sameGame reports the first difference between the games a and b, "" if none.
+func sameGame(a, b *Life) string {
+	aw, ah := a.Bounds()
+	bw, bh := b.Bounds()
+	switch {
+	case aw != bw || ah != bh:
+		return "bounds"
+	case a.w != b.w || a.h != b.h || a.vx != b.vx || a.vy != b.vy:
+		return "field"
+	case a.Generation() != b.Generation():
+		return "generation"
+	case a.Rule != b.Rule || a.Edges != b.Edges || a.Colors != b.Colors:
+		return "rule, edges or colors"
+	case a.Population() != b.Population() || a.Dying() != b.Dying():
+		return "population"
+	case a.Hash() != b.Hash():
+		return "hash"
+	}
+	for k := range a.a.s {
+		if a.cellState(k) != b.cellState(k) {
+			return "cells"
+		}
+		if a.a.s[k] && a.color[k] != b.color[k] {
+			return "colors of the cells"
+		}
+		if a.a.s[k] && a.age[k] != b.age[k] {
+			return "ages of the cells"
+		}
+		if a.pinned[k] != b.pinned[k] {
+			return "pinned cells"
+		}
+	}
+	for c := 0; c < MaxColors; c++ {
+		if a.ColorPopulation(c) != b.ColorPopulation(c) {
+			return "populations of the colors"
+		}
+	}
+	return ""
+}
+
+func TestMarshalBinaryRoundTrip(t *testing.T) {
+	for name, l := range encodingGames() {
+		b, err := l.MarshalBinary()
+		if err != nil {
+			t.Errorf("%s: MarshalBinary: %v", name, err)
+			continue
+		}
+		d := New(3, 3)
+		if err := d.UnmarshalBinary(b); err != nil {
+			t.Errorf("%s: UnmarshalBinary: %v", name, err)
+			continue
+		}
+		// The decoded game must also play on as the original.
+		for gen := 0; gen < 5; gen++ {
+			if diff := sameGame(l, d); diff != "" {
+				t.Errorf("%s: decoded game differs by its %s after %d steps", name, diff, gen)
+				break
+			}
+			l.Step()
+			d.Step()
+		}
+	}
+}
+
+func TestUnmarshalBinaryTruncated(t *testing.T) {
+	for name, l := range encodingGames() {
+		b, _ := l.MarshalBinary()
+		for n := 0; n < len(b); n++ {
+			if err := New(1, 1).UnmarshalBinary(b[:n]); err == nil {
+				t.Errorf("%s: decoded the first %d of %d bytes", name, n, len(b))
+			}
+		}
+		if err := New(1, 1).UnmarshalBinary(append(b, 0)); err == nil {
+			t.Errorf("%s: decoded trailing data", name)
+		}
+	}
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{"no data", nil},
+		{"version 0", []byte{0, 1, 1, 0, 8, 12, 0}},
+		{"future version", []byte{encodingVersion + 1}},
+		{"empty field", []byte{4, 0, 2, 0, 8, 12, 0, 0, 0, 0, 2, 0, 0, 0, 0}},
+		{"view outside", []byte{4, 2, 2, 0, 8, 12, 0, 1, 0, 2, 2, 0, 0, 4, 0}},
+		{"empty run", []byte{4, 2, 1, 0, 8, 12, 0, 0, 0, 2, 1, 0, 0, 1, 0, 1, 0}},
+		{"dying cell alive", []byte{4, 2, 1, 0, 8, 12, 0, 0, 0, 2, 1, 0, 3, 1, 1, 1, 1, 2}},
+		{"dying state out of the rule", []byte{4, 2, 1, 0, 8, 12, 0, 0, 0, 2, 1, 0, 3, 2, 1, 1, 3}},
+		{"dying cells beyond the field", []byte{4, 2, 1, 0, 8, 12, 0, 0, 0, 2, 1, 0, 3, 2, 2, 1, 2, 5, 2}},
+		{"color out of the game", []byte{4, 2, 1, 0, 8, 12, 0, 0, 0, 2, 1, 2, 0, 1, 1, 2, 0}},
+		{"age out of range", []byte{5, 2, 1, 0, 8, 12, 0, 0, 0, 2, 1, 1, 0, 1, 1, 0xff, 0xff, 0x04, 0}},
+	} {
+		if err := New(1, 1).UnmarshalBinary(test.data); err == nil {
+			t.Errorf("%s: decoded %v", test.name, test.data)
+		}
+	}
+}
+
+func TestUnmarshalBinaryVersion4(t *testing.T) {
+	// Version 4 has no ages: a 2x1 field with its right cell alive, and no dying cell.
+	l := New(1, 1)
+	if err := l.UnmarshalBinary([]byte{4, 2, 1, 7, 8, 12, 0, 0, 0, 2, 1, 1, 0, 1, 1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if w, h := l.Bounds(); w != 2 || h != 1 || l.Alive(0, 0) || !l.Alive(1, 0) || l.Generation() != 7 {
+		t.Fatalf("decoded %v", picture(l))
+	}
+	if l.Age(1, 0) != 0 {
+		t.Errorf("cell of version 4 decoded %d generations old, want newborn", l.Age(1, 0))
+	}
+	b, _ := l.MarshalBinary()
+	if want := []byte{6, 2, 1, 7, 8, 12, 0, 0, 0, 2, 1, 1, 0, 1, 1, 0, 0, 0}; string(b) != string(want) {
+		t.Errorf("encoded again as %v, want %v", b, want)
+	}
+}
+
+// TestUnmarshalBinaryFuzz decodes random corruptions of valid encodings, which must either fail or
+// give a consistent game, never panic.
+func TestUnmarshalBinaryFuzz(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var encodings [][]byte
+	for _, l := range encodingGames() {
+		b, _ := l.MarshalBinary()
+		encodings = append(encodings, b)
+	}
+	n := 20000
+	if testing.Short() {
+		n = 2000
+	}
+	for k := 0; k < n; k++ {
+		b := append([]byte(nil), encodings[r.Intn(len(encodings))]...)
+		for m := 1 + r.Intn(4); m > 0; m-- {
+			switch i := r.Intn(len(b)); r.Intn(3) {
+			case 0:
+				b[i] = byte(r.Intn(256))
+			case 1:
+				b[i] ^= 1 << uint(r.Intn(8))
+			default:
+				b = append(b[:i], b[i+1:]...)
+			}
+			if len(b) == 0 {
+				break
+			}
+		}
+		fuzzDecode(t, b)
+	}
+}
+
+// fuzzDecode decodes b, which fails the test if it panics or gives an inconsistent game.
+func fuzzDecode(t *testing.T, b []byte) {
+	defer func() {
+		if err := recover(); err != nil {
+			t.Fatalf("decoding %v: %v", b, err)
+		}
+	}()
+	l := New(1, 1)
+	if l.UnmarshalBinary(b) != nil {
+		return
+	}
+	population, dying := 0, 0
+	for k := range l.a.s {
+		switch s := l.cellState(k); {
+		case s == 1:
+			population++
+		case s > 1:
+			dying++
+		}
+	}
+	if population != l.Population() || dying != l.Dying() {
+		t.Fatalf("decoding %v: counted %d alive and %d dying cells, told %d and %d", b, population, dying,
+			l.Population(), l.Dying())
+	}
+	l.Step()
+}
@@ -0,0 +1,292 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import "math/bits"
+
+// A Stepper steps a game many generations at once, as many Steps would, without telling its
+// Listener.
+type Stepper interface {
+	// Advance steps the game n generations.
+	Advance(n int)
+}
+
+// Advance implements Stepper, calling Step n times.
+func (l *Life) Advance(n int) {
+	for ; n > 0; n-- {
+		l.Step()
+	}
+}
+
+// hashLifeCells and hashLifeGenerations are the size of the smallest field and the smallest run
+// NewStepper steps with the HashLife algorithm. Below, building the quadtree and memoizing the
+// regions cost more than they save.
+const (
+	hashLifeCells       = 1 << 12
+	hashLifeGenerations = 1 << 12
+)
+
+// maxHashLifeNodes is the number of nodes above which a hashLife forgets the nodes no longer in
+// the game, and with them the generations memoized.
+const maxHashLifeNodes = 1 << 21
+
+// NewStepper returns a Stepper of l for a run of the given number of generations: by the HashLife
+// algorithm for long runs of large fields of Bounded edges without a Listener, an active Mutation
+// nor pinned cells, under a rule of two states and of one color, else l itself. HashLife memoizes the
+// regions of the field seen before and the generations they step to, jumping ahead the more
+// generations at once the more regions repeat, as in the oscillators and the still lifes most
+// games settle into, or the empty areas. Stepped by HashLife, the cells lose their ages and the
+// Period of the game is found anew.
+func NewStepper(l *Life, generations int) Stepper {
+	if generations < hashLifeGenerations || l.vw*l.vh < hashLifeCells || l.Edges != Bounded ||
+		l.Listener != nil || l.Mutation.Active() || l.pins > 0 || l.Rule.states() > 2 || l.Colors > 1 {
+		return l
+	}
+	return newHashLife(l)
+}
+
+// A hashLife steps a Life by the HashLife algorithm: the field is a quadtree of canonical nodes,
+// equal regions sharing the same node, each memoizing the center of its region some generations
+// later. The cells beyond the field are walls, which stay dead and are never born, so that the
+// field steps as in the Bounded edge mode. See NewStepper.
+type hashLife struct {
+	l     *Life
+	nodes map[[4]*hlNode]*hlNode
+	// dead, alive and wall are the nodes of a single cell, and walls the nodes of every level
+	// made only of walls, from level 0.
+	dead, alive, wall *hlNode
+	walls             []*hlNode
+	root              *hlNode
+	// x0, y0 is the location in the region of the root of the top-left cell of the field, which
+	// stays within the center half of the region.
+	x0, y0 int
+}
+
+// An hlNode is a square region of 2^level cells, made of four nodes of the level below, or a
+// single cell at level 0.
+type hlNode struct {
+	nw, ne, sw, se *hlNode
+	level, pop     int // pop is the number of alive cells.
+	// next holds at j the center of the region, 2^(level-1) cells wide, 2^j generations later,
+	// nil until computed.
+	next []*hlNode
+}
+
+// newHashLife returns a hashLife of l, which must be of Bounded edges.
+func newHashLife(l *Life) *hashLife {
+	l.crop()
+	h := &hashLife{
+		l:     l,
+		nodes: make(map[[4]*hlNode]*hlNode),
+		dead:  &hlNode{},
+		alive: &hlNode{pop: 1},
+		wall:  &hlNode{},
+	}
+	h.walls = []*hlNode{h.wall}
+	// The field within the center half of the region of the root, walls around.
+	level := 3
+	for 1<<uint(level-1) < l.w || 1<<uint(level-1) < l.h {
+		level++
+	}
+	h.x0, h.y0 = 1<<uint(level-2), 1<<uint(level-2)
+	h.root = h.build(level, 0, 0)
+	return h
+}
+
+// join returns the canonical node of the four given ones.
+func (h *hashLife) join(nw, ne, sw, se *hlNode) *hlNode {
+	k := [4]*hlNode{nw, ne, sw, se}
+	if n, ok := h.nodes[k]; ok {
+		return n
+	}
+	n := &hlNode{nw: nw, ne: ne, sw: sw, se: se, level: nw.level + 1, pop: nw.pop + ne.pop + sw.pop + se.pop}
+	h.nodes[k] = n
+	return n
+}
+
+// wallsOf returns the node of the given level made only of walls.
+func (h *hashLife) wallsOf(level int) *hlNode {
+	for len(h.walls) <= level {
+		w := h.walls[len(h.walls)-1]
+		h.walls = append(h.walls, h.join(w, w, w, w))
+	}
+	return h.walls[level]
+}
+
+// build returns the node of the given level whose region has its top-left cell at (x, y) of the
+// region of the root, from the cells of the field.
+func (h *hashLife) build(level, x, y int) *hlNode {
+	l, siz := h.l, 1<<uint(level)
+	fx, fy := x-h.x0, y-h.y0
+	if fx >= l.w || fy >= l.h || fx+siz <= 0 || fy+siz <= 0 {
+		return h.wallsOf(level)
+	}
+	if level == 0 {
+		if l.a.s[fy*l.w+fx] {
+			return h.alive
+		}
+		return h.dead
+	}
+	half := siz / 2
+	return h.join(h.build(level-1, x, y), h.build(level-1, x+half, y),
+		h.build(level-1, x, y+half), h.build(level-1, x+half, y+half))
+}
+
+// center returns the node of the center of the region of n, of the level below.
+func (h *hashLife) center(n *hlNode) *hlNode {
+	return h.join(n.nw.se, n.ne.sw, n.sw.ne, n.se.nw)
+}
+
+// step returns the center of the region of n, of level 2 at least, 2^j generations later, j being
+// at most level-2.
+func (h *hashLife) step(n *hlNode, j int) *hlNode {
+	if n.next == nil {
+		n.next = make([]*hlNode, n.level-1)
+	}
+	if r := n.next[j]; r != nil {
+		return r
+	}
+	var r *hlNode
+	switch {
+	case n.pop == 0 && h.l.Rule.Birth&1 == 0:
+		// Nothing is born without alive neighbors.
+		r = h.center(n)
+	case n.level == 2:
+		r = h.stepLeaves(n)
+	default:
+		// The nine regions of the level below overlapping the region of n, by row.
+		m := [9]*hlNode{
+			n.nw, h.join(n.nw.ne, n.ne.nw, n.nw.se, n.ne.sw), n.ne,
+			h.join(n.nw.sw, n.nw.se, n.sw.nw, n.sw.ne), h.center(n), h.join(n.ne.sw, n.ne.se, n.se.nw, n.se.ne),
+			n.sw, h.join(n.sw.ne, n.se.nw, n.sw.se, n.se.sw), n.se,
+		}
+		// Their centers, half of the generations later if stepping as far as the level allows, the
+		// regions they make then stepping the rest.
+		full, sub := j == n.level-2, j
+		if full {
+			sub = j - 1
+		}
+		var c [9]*hlNode
+		for k, q := range m {
+			if full {
+				c[k] = h.step(q, sub)
+			} else {
+				c[k] = h.center(q)
+			}
+		}
+		r = h.join(
+			h.step(h.join(c[0], c[1], c[3], c[4]), sub), h.step(h.join(c[1], c[2], c[4], c[5]), sub),
+			h.step(h.join(c[3], c[4], c[6], c[7]), sub), h.step(h.join(c[4], c[5], c[7], c[8]), sub))
+	}
+	n.next[j] = r
+	return r
+}
+
+// stepLeaves returns the center of the region of n, of level 2, a generation later.
+func (h *hashLife) stepLeaves(n *hlNode) *hlNode {
+	var cells [4][4]*hlNode
+	for k, q := range [4]*hlNode{n.nw, n.ne, n.sw, n.se} {
+		x, y := k%2*2, k/2*2
+		cells[y][x], cells[y][x+1], cells[y+1][x], cells[y+1][x+1] = q.nw, q.ne, q.sw, q.se
+	}
+	var next [4]*hlNode
+	for k := range next {
+		x, y := 1+k%2, 1+k/2
+		if cells[y][x] == h.wall {
+			next[k] = h.wall
+			continue
+		}
+		alive := 0
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if (dx != 0 || dy != 0) && cells[y+dy][x+dx] == h.alive {
+					alive++
+				}
+			}
+		}
+		next[k] = h.dead
+		if h.l.Rule.next(cells[y][x] == h.alive, alive) {
+			next[k] = h.alive
+		}
+	}
+	return h.join(next[0], next[1], next[2], next[3])
+}
+
+// expand returns the node of the level above whose center is n, walls around.
+func (h *hashLife) expand(n *hlNode) *hlNode {
+	w := h.wallsOf(n.level - 1)
+	return h.join(h.join(w, w, w, n.nw), h.join(w, w, n.ne, w), h.join(w, n.sw, w, w), h.join(n.se, w, w, w))
+}
+
+// Advance implements Stepper, jumping by the largest powers of two of the generations first, and
+// leaves the cells of the field of the game as stepped.
+func (h *hashLife) Advance(n int) {
+	for n > 0 {
+		j := bits.Len(uint(n)) - 1
+		for h.root.level < j+2 {
+			// The field stays within the center half of the region, a level up.
+			shift := 1 << uint(h.root.level-1)
+			h.root = h.expand(h.root)
+			h.x0, h.y0 = h.x0+shift, h.y0+shift
+		}
+		h.root = h.expand(h.step(h.root, j))
+		h.l.generation += 1 << uint(j)
+		n -= 1 << uint(j)
+		if len(h.nodes) > maxHashLifeNodes {
+			h.collect()
+		}
+	}
+	h.store()
+}
+
+// collect forgets the nodes not in the game anymore, and the generations memoized.
+func (h *hashLife) collect() {
+	h.nodes = make(map[[4]*hlNode]*hlNode)
+	h.walls = h.walls[:1]
+	copies := make(map[*hlNode]*hlNode)
+	var cp func(n *hlNode) *hlNode
+	cp = func(n *hlNode) *hlNode {
+		if n.level == 0 {
+			return n
+		}
+		if c, ok := copies[n]; ok {
+			return c
+		}
+		c := h.join(cp(n.nw), cp(n.ne), cp(n.sw), cp(n.se))
+		copies[n] = c
+		return c
+	}
+	h.root = cp(h.root)
+}
+
+// store sets the cells of the field of the game as in the root, of age 0 and color 0.
+func (h *hashLife) store() {
+	l := h.l
+	for k := range l.a.s {
+		l.a.s[k], l.age[k], l.color[k] = false, 0, 0
+	}
+	l.population, l.hash = 0, 0
+	var put func(n *hlNode, x, y int)
+	put = func(n *hlNode, x, y int) {
+		if n.pop == 0 {
+			return
+		}
+		if n.level == 0 {
+			k := (y-h.y0)*l.w + x - h.x0
+			l.a.s[k] = true
+			l.population++
+			l.hash ^= cellKey(k)
+			return
+		}
+		half := 1 << uint(n.level-1)
+		put(n.nw, x, y)
+		put(n.ne, x+half, y)
+		put(n.sw, x, y+half)
+		put(n.se, x+half, y+half)
+	}
+	put(h.root, 0, 0)
+	l.countColors()
+	l.reshapes++
+	l.invalidate()
+	l.forget()
+}
@@ -0,0 +1,122 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashLife(t *testing.T) {
+	for _, rule := range []string{"B3/S23", "B36/S23", "B3678/S34678", "B0123478/S01234678"} {
+		r, err := ParseRule(rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, size := range [][2]int{{37, 23}, {64, 64}, {100, 7}} {
+			stepped := New(size[0], size[1])
+			stepped.Edges, stepped.Rule = Bounded, r
+			stepped.SeedWith(0.35, Random, int64(size[0]))
+			h := newHashLife(stepped.Clone())
+			// Jumps of every size, repeated so that the memoized generations are used.
+			for _, n := range []int{1, 2, 3, 5, 64, 100, 1, 333} {
+				stepped.Advance(n)
+				h.Advance(n)
+				for k := range stepped.age {
+					stepped.age[k] = 0
+				}
+				if diff := sameGame(stepped, h.l); diff != "" {
+					t.Fatalf("rule %s, %dx%d cells: HashLife differs by its %s at generation %d",
+						rule, size[0], size[1], diff, stepped.Generation())
+				}
+			}
+		}
+	}
+}
+
+func TestHashLifeEdges(t *testing.T) {
+	// A glider breaks on the edge of a bounded field, leaving a block, as in TestEdgeModes.
+	l := newGame(
+		"........",
+		"......O.",
+		".......O",
+		".....OOO",
+		"........",
+		"........",
+	)
+	newHashLife(l).Advance(8)
+	want := []string{
+		"........",
+		"........",
+		"........",
+		"......OO",
+		"......OO",
+		"........",
+	}
+	if got := picture(l); strings.Join(got, "\n") != strings.Join(want, "\n") || l.Generation() != 8 {
+		t.Errorf("generation %d:\n%s\nwant\n%s", l.Generation(), strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	// The period is found anew as the game goes on from there.
+	if l.Period() != 0 {
+		t.Errorf("period %d once jumped", l.Period())
+	}
+	l.Step()
+	if l.Population() != 4 || l.Period() != 1 {
+		t.Errorf("population %d, period %d once stepped on", l.Population(), l.Period())
+	}
+}
+
+func TestNewStepper(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		l           *Life
+		generations int
+		hashLife    bool
+	}{
+		{"long run", New(128, 128), 10000, true},
+		{"short run", New(128, 128), 100, false},
+		{"small", New(16, 16), 10000, false},
+		{"wrapping", New(128, 128), 10000, false},
+		{"listened", New(128, 128), 10000, false},
+		{"colored", New(128, 128), 10000, false},
+		{"decaying", New(128, 128), 10000, false},
+		{"pinned", New(128, 128), 10000, false},
+	} {
+		l := test.l
+		l.Edges = Bounded
+		switch test.name {
+		case "wrapping":
+			l.Edges = Wrap
+		case "listened":
+			l.Listener = &eventLog{}
+		case "colored":
+			l.Colors = 2
+		case "decaying":
+			l.Rule.States = 3
+		case "pinned":
+			l.Pin(64, 64, false)
+		}
+		if _, ok := NewStepper(l, test.generations).(*hashLife); ok != test.hashLife {
+			t.Errorf("%s stepped by HashLife %v", test.name, ok)
+		}
+	}
+}
+
+// benchmarkAdvance benchmarks the Stepper returned by stepper stepping 8192 generations of a
+// bounded random field of 128*128 cells, which settles meanwhile.
+func benchmarkAdvance(b *testing.B, stepper func(l *Life) Stepper) {
+	l := New(128, 128)
+	l.Edges = Bounded
+	for k := 0; k < b.N; k++ {
+		l.SeedWith(0.3, Random, 1)
+		stepper(l).Advance(8192)
+	}
+}
+
+func BenchmarkAdvanceStep(b *testing.B) {
+	benchmarkAdvance(b, func(l *Life) Stepper { return l })
+}
+
+func BenchmarkAdvanceHashLife(b *testing.B) {
+	benchmarkAdvance(b, func(l *Life) Stepper { return newHashLife(l) })
+}
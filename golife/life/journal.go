@@ -0,0 +1,363 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// journalVersion is the first byte of the binary encoding of a journal. It must change whenever the
+// encoding does.
+const journalVersion = 1
+
+// A Journal records a session: the game it started from and every change made to it between the
+// steps, so that the whole session can be replayed, see Replay. Step is deterministic, so only the
+// changes need recording, along with the pace the session was played at.
+//
+// The game tells the journal which of its cells were changed between the steps, whose states are
+// recorded along with the rule, edge mode and number of colors if any of them changed. A change of
+// the field as a whole, as by Resize, Shift, Seed, Pin or UnmarshalBinary, or the session going on
+// with another game, records the whole game.
+type Journal struct {
+	start  []byte // The game the session started from, as encoded by MarshalBinary.
+	events []journalEvent
+	steps  int     // Number of steps recorded.
+	speed  float64 // Latest pace recorded.
+	// game is the game of the session as of the latest step or change recorded, nil once decoded,
+	// reshapes its count of changes as a whole then, and the rest its settings then.
+	game     *Life
+	reshapes int
+	rule     Rule
+	edges    EdgeMode
+	colors   int
+}
+
+// A journalEvent is a change recorded by a Journal: a change of pace if speed is positive, else
+// the whole game if keyframe is not nil, else the cells changed and the rule, edge mode and number
+// of colors after the change.
+type journalEvent struct {
+	step     int // Number of steps recorded before the change.
+	speed    float64
+	keyframe []byte
+	cells    []cellChange
+	rule     Rule
+	edges    EdgeMode
+	colors   int
+}
+
+// A cellChange is the state of a cell of the field after a change, see State, and its color if
+// alive.
+type cellChange struct {
+	k            int // Index of the cell in the field.
+	state, color uint8
+}
+
+// NewJournal returns a journal recording the session starting from l, at speed generations per
+// second.
+func NewJournal(l *Life, speed float64) *Journal {
+	start, _ := l.MarshalBinary()
+	j := &Journal{start: start}
+	j.noted(l)
+	j.SetSpeed(speed)
+	return j
+}
+
+// Steps returns the number of steps recorded.
+func (j *Journal) Steps() int {
+	return j.steps
+}
+
+// Changes returns the number of changes recorded, changes of pace included.
+func (j *Journal) Changes() int {
+	return len(j.events)
+}
+
+// SetSpeed records a change of the pace of the session to speed generations per second, which
+// replays may follow, unless it is the pace already. It does not change the games.
+func (j *Journal) SetSpeed(speed float64) {
+	if speed != j.speed {
+		j.events = append(j.events, journalEvent{step: j.steps, speed: speed})
+		j.speed = speed
+	}
+}
+
+// Note records the changes made to l since the latest step or change recorded. l must be the game
+// of the session, and j must not have been decoded.
+func (j *Journal) Note(l *Life) {
+	e := journalEvent{step: j.steps, rule: l.Rule, edges: l.Edges, colors: l.Colors}
+	if l != j.game || l.reshapes != j.reshapes {
+		e.keyframe, _ = l.MarshalBinary()
+	} else {
+		// Each cell once, in order.
+		sort.Ints(l.touched)
+		for n, k := range l.touched {
+			if n > 0 && k == l.touched[n-1] {
+				continue
+			}
+			e.cells = append(e.cells, cellChange{k: k, state: uint8(l.cellState(k)), color: l.color[k]})
+		}
+		if e.cells == nil && l.Rule == j.rule && l.Edges == j.edges && l.Colors == j.colors {
+			return
+		}
+	}
+	j.events = append(j.events, e)
+	j.noted(l)
+}
+
+// noted remembers l as the game of the session as of now.
+func (j *Journal) noted(l *Life) {
+	if j.game != nil && j.game != l {
+		j.game.journaled, j.game.touched = false, nil
+	}
+	j.game, j.reshapes = l, l.reshapes
+	j.rule, j.edges, j.colors = l.Rule, l.Edges, l.Colors
+	l.journaled, l.touched = true, l.touched[:0]
+}
+
+// touch tells the journal of l, if any, that the cell at index k of the field is changing. Past as
+// many changes as the field has cells, the field is deemed changed as a whole, which bounds the
+// memory of a game no longer noted.
+func (l *Life) touch(k int) {
+	if !l.journaled {
+		return
+	}
+	if len(l.touched) >= len(l.a.s) {
+		l.reshapes++
+		l.touched = l.touched[:0]
+	}
+	l.touched = append(l.touched, k)
+}
+
+// Step records the changes made to l as Note does, then steps l and records the step.
+func (j *Journal) Step(l *Life) {
+	j.Note(l)
+	l.Step()
+	j.steps++
+	// The field growing in Grow mode is replayed by Step.
+	j.reshapes = l.reshapes
+}
+
+// A Replay plays a journal back, from the game it started from.
+type Replay struct {
+	// Life is the game replayed, which has the changes recorded up to the current step applied.
+	Life *Life
+	// Speed is the pace of the session as of the current step, in generations per second, 0 if
+	// the journal recorded none.
+	Speed float64
+	j     *Journal
+	step  int // Number of steps replayed.
+	next  int // Index in the events of the journal of the next change to apply.
+}
+
+// Replay returns a replay of j, with the changes recorded before the first step applied.
+func (j *Journal) Replay() (*Replay, error) {
+	l := New(1, 1)
+	if err := l.UnmarshalBinary(j.start); err != nil {
+		return nil, err
+	}
+	r := &Replay{Life: l, j: j}
+	r.apply()
+	return r, nil
+}
+
+// Done reports whether every step of the journal was replayed.
+func (r *Replay) Done() bool {
+	return r.step >= r.j.steps
+}
+
+// Step steps the game and applies the changes recorded before the next step, or after the last
+// one, unless Done.
+func (r *Replay) Step() {
+	if r.Done() {
+		return
+	}
+	r.Life.Step()
+	r.step++
+	r.apply()
+}
+
+// apply applies the changes recorded before the next step, or after the last one.
+func (r *Replay) apply() {
+	l := r.Life
+	for ; r.next < len(r.j.events) && r.j.events[r.next].step <= r.step; r.next++ {
+		e := &r.j.events[r.next]
+		switch {
+		case e.speed > 0:
+			r.Speed = e.speed
+		case e.keyframe != nil:
+			// Checked by UnmarshalBinary, or encoded by Note.
+			l.UnmarshalBinary(e.keyframe)
+		default:
+			for _, c := range e.cells {
+				if c.k >= len(l.a.s) {
+					continue
+				}
+				l.set(c.k, c.state == 1)
+				if c.state > 1 {
+					l.setDecay(c.k, int(c.state))
+				} else if c.state == 1 {
+					l.pops[l.color[c.k]]--
+					l.color[c.k] = c.color
+					l.pops[c.color]++
+				}
+			}
+			l.Rule, l.Edges, l.Colors = e.rule, e.edges, e.colors
+			l.invalidate()
+			l.forget()
+		}
+	}
+}
+
+// MarshalBinary encodes j, for instance to export the session. After a version byte, the encoded
+// game the session started from, the number of steps and the number of changes, each change is
+// stored with the number of steps since the previous one and its kind: 0 for a change of pace,
+// stored as the bits of the float64, 1 for a whole game, stored encoded, and 2 for changed cells,
+// stored after the rule, edge mode and number of colors, as their number and, for each, the number
+// of cells since the previous one, its state and its color. Values are varints, and lengths precede
+// encoded games.
+func (j *Journal) MarshalBinary() ([]byte, error) {
+	b := []byte{journalVersion}
+	put := func(v uint64) {
+		var buf [binary.MaxVarintLen64]byte
+		b = append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+	}
+	put(uint64(len(j.start)))
+	b = append(b, j.start...)
+	put(uint64(j.steps))
+	put(uint64(len(j.events)))
+	step := 0
+	for _, e := range j.events {
+		put(uint64(e.step - step))
+		step = e.step
+		switch {
+		case e.speed > 0:
+			put(0)
+			put(math.Float64bits(e.speed))
+		case e.keyframe != nil:
+			put(1)
+			put(uint64(len(e.keyframe)))
+			b = append(b, e.keyframe...)
+		default:
+			put(2)
+			for _, v := range []int{int(e.rule.Birth), int(e.rule.Survival), e.rule.States, int(e.edges), e.colors,
+				len(e.cells)} {
+				put(uint64(v))
+			}
+			last := -1
+			for _, c := range e.cells {
+				put(uint64(c.k - last - 1))
+				put(uint64(c.state))
+				put(uint64(c.color))
+				last = c.k
+			}
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary replaces j by the journal encoded by MarshalBinary in data. The decoded journal
+// can be replayed, not recorded on. On error j is left unchanged.
+func (j *Journal) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errTruncated
+	}
+	if data[0] != journalVersion {
+		return fmt.Errorf("life: invalid journal: unknown version %d", data[0])
+	}
+	r := bytes.NewReader(data[1:])
+	get := func(limit uint64) (int, error) {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return 0, errTruncated
+		}
+		if v > limit {
+			return 0, fmt.Errorf("life: invalid journal: value %d out of range [0, %d]", v, limit)
+		}
+		return int(v), nil
+	}
+	// game reads an encoded game, checked by decoding it.
+	game := func() ([]byte, error) {
+		n, err := get(uint64(r.Len()))
+		if err != nil {
+			return nil, err
+		}
+		g := make([]byte, n)
+		r.Read(g)
+		if err := New(1, 1).UnmarshalBinary(g); err != nil {
+			return nil, err
+		}
+		return g, nil
+	}
+	start, err := game()
+	if err != nil {
+		return err
+	}
+	steps, err := get(1<<63 - 1)
+	if err != nil {
+		return err
+	}
+	// Each change takes at least two bytes.
+	n, err := get(uint64(r.Len() / 2))
+	if err != nil {
+		return err
+	}
+	events := make([]journalEvent, n)
+	step := 0
+	for k := range events {
+		e := &events[k]
+		gap, err := get(uint64(steps - step))
+		if err != nil {
+			return err
+		}
+		step += gap
+		e.step = step
+		kind, err := get(2)
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case 0:
+			bits, err := binary.ReadUvarint(r)
+			if err != nil {
+				return errTruncated
+			}
+			if e.speed = math.Float64frombits(bits); !(e.speed > 0) || math.IsInf(e.speed, 0) {
+				return fmt.Errorf("life: invalid journal: bad speed %v", e.speed)
+			}
+		case 1:
+			if e.keyframe, err = game(); err != nil {
+				return err
+			}
+		case 2:
+			var hdr [6]int
+			for k, limit := range []uint64{1<<9 - 1, 1<<9 - 1, MaxStates, uint64(Grow), MaxColors, uint64(r.Len() / 3)} {
+				if hdr[k], err = get(limit); err != nil {
+					return err
+				}
+			}
+			e.rule = Rule{Birth: uint16(hdr[0]), Survival: uint16(hdr[1]), States: hdr[2]}
+			e.edges, e.colors = EdgeMode(hdr[3]), hdr[4]
+			e.cells = make([]cellChange, hdr[5])
+			for c, last := 0, -1; c < len(e.cells); c++ {
+				var v [3]int
+				for k, limit := range []uint64{maxEncodedCells, MaxStates - 1, MaxColors - 1} {
+					if v[k], err = get(limit); err != nil {
+						return err
+					}
+				}
+				last += v[0] + 1
+				e.cells[c] = cellChange{k: last, state: uint8(v[1]), color: uint8(v[2])}
+			}
+		}
+	}
+	if r.Len() != 0 {
+		return errors.New("life: invalid journal: trailing data")
+	}
+	*j = Journal{start: start, events: events, steps: steps}
+	return nil
+}
@@ -0,0 +1,126 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import "testing"
+
+func TestJournal(t *testing.T) {
+	l := New(30, 20)
+	l.SeedWith(0.3, Random, 1)
+	l.Colors = 2
+	j := NewJournal(l, 10)
+	highLife, _ := ParseRule("B36/S23")
+	edits := map[int]func(){
+		3:  func() { l.Set(4, 4, !l.Alive(4, 4)) },
+		5:  func() { l.SetCells([][2]int{{1, 1}, {2, 1}, {3, 1}}, true); l.SetColor(2, 1, 1) },
+		8:  func() { l.Rule = highLife },
+		9:  func() { l.Stamp(glider, 10, 10); l.Set(11, 10, false); l.Set(11, 10, true) },
+		12: func() { l.Shift(3, -2) },
+		13: func() { l.Resize(40, 25, 5, 2) },
+		15: func() { l.Edges = Grow; l.Stamp(glider, 0, 0) },
+		20: func() { l.Pin(2, 2, true); l.Pin(3, 3, false) },
+		25: func() { l.Unpin(2, 2); l.Set(3, 3, true) },
+		30: func() { l.Clear(0, 0, 10, 10); j.SetSpeed(20) },
+		32: func() { l.SeedWith(0.2, Mirror, 2) },
+	}
+	var games []*Life
+	for step := 0; step < 40; step++ {
+		if edit, ok := edits[step]; ok {
+			edit()
+		}
+		games = append(games, l.Clone())
+		j.Step(l)
+	}
+	l.Set(0, 0, true)
+	j.Note(l)
+	games = append(games, l.Clone())
+
+	b, err := j.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Journal
+	if err := decoded.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	for name, j := range map[string]*Journal{"recorded": j, "decoded": &decoded} {
+		r, err := j.Replay()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		// After each step, the replay has the edits made before the next one applied.
+		for step := 0; ; step++ {
+			if d := sameGame(r.Life, games[step]); d != "" {
+				t.Fatalf("%s: replay of step %d differs in %s", name, step, d)
+			}
+			if r.Done() {
+				if step != 40 {
+					t.Errorf("%s: replay done after %d steps", name, step)
+				}
+				break
+			}
+			r.Step()
+		}
+		if r.Speed != 20 {
+			t.Errorf("%s: replay at %v generations per second, want 20", name, r.Speed)
+		}
+	}
+}
+
+func TestJournalEdits(t *testing.T) {
+	l := New(50, 50)
+	l.SeedWith(0.3, Random, 1)
+	j := NewJournal(l, 10)
+	// Steps alone record nothing, even in Grow mode as the field grows.
+	l.Edges = Grow
+	l.Stamp(glider, 45, 45)
+	j.Step(l)
+	n := j.Changes()
+	for k := 0; k < 50; k++ {
+		j.Step(l)
+	}
+	if w, _ := l.Bounds(); j.Changes() != n || l.w == w {
+		t.Errorf("%d changes recorded by steps alone, on a field of %d cells grown to %d", j.Changes()-n, w, l.w)
+	}
+
+	// Edits record the cells they changed, each once, not the whole game.
+	l.SetCells([][2]int{{1, 1}, {2, 1}, {1, 1}}, !l.Alive(1, 1))
+	l.Set(2, 1, !l.Alive(2, 1))
+	l.Set(3, 3, !l.Alive(3, 3))
+	j.Step(l)
+	if e := j.events[len(j.events)-1]; e.keyframe != nil || len(e.cells) != 3 {
+		t.Errorf("edit of 3 cells recorded %d cells, as a whole game: %v", len(e.cells), e.keyframe != nil)
+	}
+	if len(l.touched) != 0 {
+		t.Errorf("%d cells left to note after a step", len(l.touched))
+	}
+
+	// Changes as a whole, or another game, record the whole game.
+	l.Shift(1, 0)
+	j.Step(l)
+	other := l.Clone()
+	other.Set(0, 0, true)
+	j.Step(other)
+	for k, e := range j.events[len(j.events)-2:] {
+		if e.keyframe == nil {
+			t.Errorf("change %d as a whole recorded as %d cells", k, len(e.cells))
+		}
+	}
+
+	// The game no longer journaled is not told its changes, nor is a game edited without being
+	// noted told more than it has cells.
+	l.Set(0, 0, !l.Alive(0, 0))
+	if l.journaled || len(l.touched) != 0 {
+		t.Errorf("former game of the session still journaled")
+	}
+	for k := 0; k < 3*len(other.a.s); k++ {
+		other.Set(0, 0, !other.Alive(0, 0))
+	}
+	if len(other.touched) > len(other.a.s) {
+		t.Errorf("%d changes kept for a field of %d cells", len(other.touched), len(other.a.s))
+	}
+	j.Note(other)
+	if e := j.events[len(j.events)-1]; e.keyframe == nil {
+		t.Errorf("more changes than cells recorded as %d cells", len(e.cells))
+	}
+}
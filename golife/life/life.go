@@ -0,0 +1,789 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+// Modified copy of http://golang.org/doc/play/life.go
+
+// Package life implements Conway's Game of Life, and other Life-like cellular automata, on a finite
+// field, or on one growing as needed.
+package life
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// field represents a two-dimensional field of cells.
+type field struct {
+	s    []bool
+	w, h int
+}
+
+// newField returns an empty field of the specified width and height.
+func newField(w, h int) *field {
+	return &field{
+		s: make([]bool, w*h),
+		w: w,
+		h: h,
+	}
+}
+
+// set sets the state of the specified cell to the given value.
+func (f *field) set(x, y int, b bool) {
+	f.s[y*f.w+x] = b
+}
+
+// alive reports whether the specified cell is alive, see index for the cells outside the field.
+func (f *field) alive(x, y int, e EdgeMode) bool {
+	k, ok := f.index(x, y, e)
+	return ok && f.s[k]
+}
+
+// index returns the index in s of the specified cell and whether it is in the field.
+// If the x or y coordinates are outside the field boundaries they are wrapped
+// toroidally in Wrap mode, for instance an x value of -1 is treated as width-1, and
+// reflected by the edge in Reflect mode, an x value of -1 being treated as 0.
+// Otherwise cells outside the field are dead, as in Bounded and Grow modes.
+func (f *field) index(x, y int, e EdgeMode) (int, bool) {
+	switch e {
+	case Wrap:
+		x = (x%f.w + f.w) % f.w
+		y = (y%f.h + f.h) % f.h
+	case Reflect:
+		x, y = reflect(x, f.w), reflect(y, f.h)
+	}
+	if x < 0 || x >= f.w || y < 0 || y >= f.h {
+		return 0, false
+	}
+	return y*f.w + x, true
+}
+
+// reflect returns the coordinate c reflected into [0, n) by the nearest edge. Coordinates more than
+// n beyond an edge stay out.
+func reflect(c, n int) int {
+	switch {
+	case c < 0:
+		return -1 - c
+	case c >= n:
+		return 2*n - 1 - c
+	}
+	return c
+}
+
+// next returns the state of the specified cell at the next time step.
+func (f *field) next(x, y int, e EdgeMode, r Rule) bool {
+	// Count the adjacent cells that are alive.
+	alive := 0
+	if x > 0 && y > 0 && x < f.w-1 && y < f.h-1 {
+		// Away from the edges, neighbors can be indexed directly whatever the edge mode.
+		k := y*f.w + x
+		for _, d := range [...]int{-f.w - 1, -f.w, -f.w + 1, -1, 1, f.w - 1, f.w, f.w + 1} {
+			if f.s[k+d] {
+				alive++
+			}
+		}
+	} else {
+		for i := -1; i <= 1; i++ {
+			for j := -1; j <= 1; j++ {
+				if (j != 0 || i != 0) && f.alive(x+i, y+j, e) {
+					alive++
+				}
+			}
+		}
+	}
+	return r.next(f.s[y*f.w+x], alive)
+}
+
+// An EdgeMode tells how cells on the edges of the field see beyond them.
+type EdgeMode int
+
+const (
+	// Wrap makes the field a torus: cells on an edge are neighbors of the cells on the
+	// opposite edge.
+	Wrap EdgeMode = iota
+	// Bounded surrounds the field by dead cells.
+	Bounded
+	// Reflect surrounds the field by the reflection of its edges: cells beyond an edge are in the
+	// state of the cells on it.
+	Reflect
+	// Grow makes the field unbounded: it grows beyond the edges alive cells reach, up to
+	// maxGrowCells cells, and the coordinates of the cells are relative to a view of it the size
+	// of the field as created, which Shift moves. The bounded edge mode applies beyond the limit.
+	Grow
+)
+
+// growMargin is the number of cells the field grows by beyond an edge reached by alive cells, and
+// maxGrowCells the size of the field above which it no longer grows.
+const (
+	growMargin   = 16
+	maxGrowCells = 1 << 22
+)
+
+// A StepListener is notified of the cells that change state during a Step.
+type StepListener interface {
+	// OnBirth is called for a dead cell that becomes alive. Only the cells in view are told, see
+	// Grow.
+	OnBirth(x, y int)
+	// OnDeath is called for an alive cell that dies.
+	OnDeath(x, y int)
+}
+
+// Life stores the state of a round of Conway's Game of Life, or of another Life-like cellular
+// automaton.
+type Life struct {
+	a, b *field
+	w, h int
+	// The view of the field the coordinates of cells are relative to, its top-left corner at
+	// (vx, vy). It is the whole field unless the field grew, see Grow.
+	vx, vy, vw, vh int
+	// Number of alive cells and of steps since the last Seed.
+	population, generation int
+	// hash is the XOR of the keys of the alive and dying cells, changed counts the cells changed by
+	// the last Step.
+	hash    uint64
+	changed int
+	// age holds, for every alive cell, the number of steps it survived, and color its color, see
+	// Colors. pops counts the alive cells of each color.
+	age   []uint16
+	color []uint8
+	pops  [MaxColors]int
+	// decay holds the state of every dying cell, see Rule.States, and 0 for the other cells. dying
+	// counts the dying cells.
+	decay []uint8
+	dying int
+	// pinned holds whether every cell is pinned, see Pin, and pins counts the pinned cells.
+	pinned []bool
+	pins   int
+	// sparse is the state of the sparse Step algorithm, nil if not used. See NewSparse.
+	sparse *sparse
+	// cycles remembers the latest generations, see Period.
+	cycles cycleHistory
+	// reshapes counts the changes of the field as a whole outside of Step, as by Resize or Seed.
+	// Once journaled, touched holds the indexes of the cells changed one at a time since the
+	// journal last noted them. See Journal.
+	reshapes  int
+	journaled bool
+	touched   []int
+	// mutations counts the cells flipped by the Mutation since the last Seed.
+	mutations int
+	// Rule is the rule applied by Step. It can be changed at any time.
+	Rule Rule
+	// Edges is how cells on the edges of the field see beyond them. It can be changed at any time.
+	Edges EdgeMode
+	// Colors is the number of colors of the alive cells, up to MaxColors, 0 being 1 as in plain
+	// Life. A newborn cell takes the color most of its parents have: 2 colors play the Immigration
+	// variant, and 4 colors QuadLife, where three parents of different colors give birth to a cell
+	// of the fourth color. It can be changed at any time, cells keeping their colors.
+	Colors int
+	// ParallelCells is the size of the smallest field whose next state Step computes in concurrent
+	// bands of rows, one per processor usable by goroutines, 0 for DefaultParallelCells.
+	ParallelCells int
+	// Listener, if not nil, is notified of the births and deaths of every Step, in row-major order
+	// and before the step completes, of the decay of the dying cells if it is a DecayListener, of
+	// the cells reaching some ages if it is an AgeListener, and of the cells flipped by the
+	// Mutation if it is a MutationListener.
+	Listener StepListener
+	// Mutation flips random cells at the end of the steps it tells. It can be changed at any time.
+	Mutation Mutation
+}
+
+// New returns a new Life game state of the specified width and height, with every cell dead and
+// the Conway rule.
+func New(w, h int) *Life {
+	return &Life{
+		a:      newField(w, h),
+		b:      newField(w, h),
+		age:    make([]uint16, w*h),
+		color:  make([]uint8, w*h),
+		decay:  make([]uint8, w*h),
+		pinned: make([]bool, w*h),
+		w:      w,
+		h:      h,
+		vw:     w,
+		vh:     h,
+		Rule:   Conway,
+	}
+}
+
+// Clone returns a copy of l that can be stepped independently, for instance by another goroutine.
+// The copy has no Listener.
+func (l *Life) Clone() *Life {
+	c := *l
+	c.a = &field{s: append([]bool(nil), l.a.s...), w: l.a.w, h: l.a.h}
+	c.b = newField(l.b.w, l.b.h)
+	c.age = append([]uint16(nil), l.age...)
+	c.color = append([]uint8(nil), l.color...)
+	c.decay = append([]uint8(nil), l.decay...)
+	c.pinned = append([]bool(nil), l.pinned...)
+	if l.sparse != nil {
+		c.sparse = &sparse{stale: true}
+	}
+	c.Listener = nil
+	c.journaled, c.touched = false, nil
+	return &c
+}
+
+// Bounds returns the width and height of the field, of its view in Grow mode.
+func (l *Life) Bounds() (w, h int) {
+	return l.vw, l.vh
+}
+
+// key returns the index in the fields of the cell at (x, y) of the view.
+func (l *Life) key(x, y int) int {
+	return (y+l.vy)*l.w + x + l.vx
+}
+
+// Alive reports whether the specified cell is alive. Coordinates outside the field are wrapped
+// toroidally in Wrap mode, are dead cells in Bounded mode and are reflected by the edges in
+// Reflect mode. In Grow mode, cells out of the view may be alive.
+func (l *Life) Alive(x, y int) bool {
+	return l.a.alive(x+l.vx, y+l.vy, l.Edges)
+}
+
+// Set sets the state of the specified cell, which must be inside the field, in view, unless pinned,
+// see Pin. Cells set alive are of color 0, and dying cells set dead no longer decay.
+func (l *Life) Set(x, y int, alive bool) {
+	if l.set(l.key(x, y), alive) {
+		l.invalidate()
+		l.forget()
+	}
+}
+
+// set is Set for the cell at index k of the field, and reports whether it changed, leaving the
+// caller to tell the sparse algorithm and the cycle detection.
+func (l *Life) set(k int, alive bool) bool {
+	if l.pinned[k] || l.a.s[k] == alive && l.decay[k] == 0 {
+		return false
+	}
+	l.setDecay(k, 0)
+	l.touch(k)
+	if l.a.s[k] != alive {
+		l.a.s[k] = alive
+		l.age[k] = 0
+		l.hash ^= cellKey(k)
+		if alive {
+			l.population++
+			l.color[k] = 0
+			l.pops[0]++
+		} else {
+			l.population--
+			l.pops[l.color[k]]--
+		}
+	}
+	return true
+}
+
+// Resize changes the size of the field to w*h, moving the cell at (x, y) to (x+dx, y+dy). Cells
+// moved outside the field are lost and the new ones are dead. The generation count is kept. In
+// Grow mode the view is resized and moved instead, and no cell is lost: the field grows to cover
+// the view.
+func (l *Life) Resize(w, h, dx, dy int) {
+	// The new view, in the coordinates of the current field.
+	vx, vy := l.vx-dx, l.vy-dy
+	if l.Edges != Grow {
+		l.reshape(vx, vy, w, h)
+		l.vx, l.vy, l.vw, l.vh = 0, 0, w, h
+		return
+	}
+	x0, y0, x1, y1 := 0, 0, l.w, l.h
+	if vx < x0 {
+		x0 = vx
+	}
+	if vy < y0 {
+		y0 = vy
+	}
+	if vx+w > x1 {
+		x1 = vx + w
+	}
+	if vy+h > y1 {
+		y1 = vy + h
+	}
+	l.reshape(x0, y0, x1-x0, y1-y0)
+	l.vx, l.vy, l.vw, l.vh = vx-x0, vy-y0, w, h
+}
+
+// Resample changes the size of the field to w*h, scaling its view to cover the new field: each new
+// cell takes the state, age, color and pin of the one under its center. The generation count is kept. In
+// Grow mode the cells outside the view are lost.
+func (l *Life) Resample(w, h int) {
+	a, age, col := newField(w, h), make([]uint16, w*h), make([]uint8, w*h)
+	decay, pinned := l.decay, l.pinned
+	l.decay, l.pinned = make([]uint8, w*h), make([]bool, w*h)
+	l.population, l.hash, l.dying = 0, 0, 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			k, nk := l.key((2*x+1)*l.vw/(2*w), (2*y+1)*l.vh/(2*h)), y*w+x
+			if d := decay[k]; d != 0 {
+				l.setDecay(nk, int(d))
+			}
+			l.pinned[nk] = pinned[k]
+			if !l.a.s[k] {
+				continue
+			}
+			a.set(x, y, true)
+			age[nk] = l.age[k]
+			col[nk] = l.color[k]
+			l.population++
+			l.hash ^= cellKey(nk)
+		}
+	}
+	l.a, l.b, l.age, l.color = a, newField(w, h), age, col
+	l.w, l.h = w, h
+	l.vx, l.vy, l.vw, l.vh = 0, 0, w, h
+	l.countColors()
+	l.countPins()
+	l.reshapes++
+	l.invalidate()
+	l.forget()
+}
+
+// reshape replaces the fields by their w*h rectangle whose top-left corner is at (x0, y0), dead
+// where beyond them. The view is left for the caller to adjust.
+func (l *Life) reshape(x0, y0, w, h int) {
+	a, age, col := newField(w, h), make([]uint16, w*h), make([]uint8, w*h)
+	decay, pinned := l.decay, l.pinned
+	l.decay, l.pinned = make([]uint8, w*h), make([]bool, w*h)
+	l.population, l.hash, l.dying = 0, 0, 0
+	for y := 0; y < l.h; y++ {
+		for x := 0; x < l.w; x++ {
+			nx, ny := x-x0, y-y0
+			if nx < 0 || nx >= w || ny < 0 || ny >= h {
+				continue
+			}
+			if d := decay[y*l.w+x]; d != 0 {
+				l.setDecay(ny*w+nx, int(d))
+			}
+			l.pinned[ny*w+nx] = pinned[y*l.w+x]
+			if !l.a.s[y*l.w+x] {
+				continue
+			}
+			a.set(nx, ny, true)
+			age[ny*w+nx] = l.age[y*l.w+x]
+			col[ny*w+nx] = l.color[y*l.w+x]
+			l.population++
+			l.hash ^= cellKey(ny*w + nx)
+		}
+	}
+	l.a, l.b, l.age, l.color = a, newField(w, h), age, col
+	l.w, l.h = w, h
+	l.countColors()
+	l.countPins()
+	l.reshapes++
+	l.invalidate()
+	l.forget()
+}
+
+// Shift moves every cell by (dx, dy), with their ages and pins. The generation count is kept. In Wrap mode
+// the cells moved beyond an edge reappear on the opposite one, in Bounded and Reflect modes they
+// are lost. In Grow mode nothing wraps: the view moves over the field instead, which grows to
+// cover it.
+func (l *Life) Shift(dx, dy int) {
+	if l.Edges == Grow {
+		l.Resize(l.vw, l.vh, dx, dy)
+		return
+	}
+	l.crop()
+	a, age, col := newField(l.w, l.h), make([]uint16, l.w*l.h), make([]uint8, l.w*l.h)
+	decay, pinned := l.decay, l.pinned
+	l.decay, l.pinned = make([]uint8, l.w*l.h), make([]bool, l.w*l.h)
+	l.population, l.hash, l.dying = 0, 0, 0
+	for k, alive := range l.a.s {
+		x, y := k%l.w+dx, k/l.w+dy
+		if x < 0 || x >= l.w || y < 0 || y >= l.h {
+			if l.Edges != Wrap {
+				continue
+			}
+			x, y = (x%l.w+l.w)%l.w, (y%l.h+l.h)%l.h
+		}
+		if decay[k] != 0 {
+			l.setDecay(y*l.w+x, int(decay[k]))
+		}
+		l.pinned[y*l.w+x] = pinned[k]
+		if !alive {
+			continue
+		}
+		a.set(x, y, true)
+		age[y*l.w+x] = l.age[k]
+		col[y*l.w+x] = l.color[k]
+		l.population++
+		l.hash ^= cellKey(y*l.w + x)
+	}
+	l.a, l.age, l.color = a, age, col
+	l.countColors()
+	l.countPins()
+	l.reshapes++
+	l.invalidate()
+	l.forget()
+}
+
+// crop shrinks the field to its view, losing the cells out of it.
+func (l *Life) crop() {
+	if l.vx == 0 && l.vy == 0 && l.vw == l.w && l.vh == l.h {
+		return
+	}
+	l.reshape(l.vx, l.vy, l.vw, l.vh)
+	l.vx, l.vy = 0, 0
+}
+
+// grow enlarges the field by growMargin cells beyond each of its edges alive cells reached, so that
+// no cell can be born beyond them, unless the field would be larger than maxGrowCells.
+func (l *Life) grow() {
+	var top, bottom, left, right bool
+	for x := 0; x < l.w; x++ {
+		top = top || l.a.s[x]
+		bottom = bottom || l.a.s[(l.h-1)*l.w+x]
+	}
+	for y := 0; y < l.h; y++ {
+		left = left || l.a.s[y*l.w]
+		right = right || l.a.s[y*l.w+l.w-1]
+	}
+	x0, y0, x1, y1 := 0, 0, l.w, l.h
+	if left {
+		x0 -= growMargin
+	}
+	if top {
+		y0 -= growMargin
+	}
+	if right {
+		x1 += growMargin
+	}
+	if bottom {
+		y1 += growMargin
+	}
+	if n := (x1 - x0) * (y1 - y0); n == l.w*l.h || n > maxGrowCells {
+		return
+	}
+	l.reshape(x0, y0, x1-x0, y1-y0)
+	l.vx, l.vy = l.vx-x0, l.vy-y0
+}
+
+// Population returns the number of alive cells.
+func (l *Life) Population() int {
+	return l.population
+}
+
+// Generation returns the number of steps since the game was created or last seeded.
+func (l *Life) Generation() int {
+	return l.generation
+}
+
+// Bytes returns an estimate of the memory held by l, in bytes: its fields and the state of the
+// cells, sparse algorithm and journal included.
+func (l *Life) Bytes() int {
+	word := int(unsafe.Sizeof(0))
+	n := int(unsafe.Sizeof(*l)) + cap(l.a.s) + cap(l.b.s) + 2*cap(l.age) + cap(l.color) + cap(l.decay) +
+		cap(l.pinned) + word*cap(l.touched)
+	if sp := l.sparse; sp != nil {
+		n += int(unsafe.Sizeof(*sp)) + word*cap(sp.live) + cap(sp.count) + word*cap(sp.touched)
+	}
+	return n
+}
+
+// Age returns the number of steps the specified cell, which must be inside the field, in view, and
+// alive, survived since its birth. Ages saturate at 65535.
+func (l *Life) Age(x, y int) int {
+	return int(l.age[l.key(x, y)])
+}
+
+// Hash returns a hash of the state of every cell, which is maintained as cells change rather than
+// computed on demand. Fields of the same size in the same state have the same hash.
+func (l *Life) Hash() uint64 {
+	return l.hash
+}
+
+// Changed returns the number of cells changed by the last Step.
+func (l *Life) Changed() int {
+	return l.changed
+}
+
+// cellKey returns the key of the cell at index k of a field for Hash, a pseudo-random value
+// (splitmix64).
+func cellKey(k int) uint64 {
+	return mix(uint64(k)*0x9e3779b97f4a7c15 + 0x9e3779b97f4a7c15)
+}
+
+// mix returns a pseudo-random value from z, the finalizer of splitmix64.
+func mix(z uint64) uint64 {
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+// A SeedMode tells how Seed lays out the random cells.
+type SeedMode int
+
+const (
+	// Random makes every cell random.
+	Random SeedMode = iota
+	// Mirror makes a random top-left quarter of the field, mirrored horizontally and vertically.
+	Mirror
+	// Circle makes the cells random in a disk centered on the field and dead around it.
+	Circle
+	// Horizontal makes a random left half of the field, mirrored horizontally.
+	Horizontal
+	// Vertical makes a random top half of the field, mirrored vertically.
+	Vertical
+	// Rotational makes the field look the same once turned by a quarter around its center. Cells
+	// whose quarter turn falls off the field, or between cells, only get the half turn symmetry.
+	Rotational
+)
+
+var seedModeNames = []string{"random", "mirror", "circle", "horizontal", "vertical", "rotational"}
+
+// String returns the lower case name of m.
+func (m SeedMode) String() string {
+	if m < 0 || int(m) >= len(seedModeNames) {
+		return fmt.Sprintf("SeedMode(%d)", int(m))
+	}
+	return seedModeNames[m]
+}
+
+// ParseSeedMode returns the SeedMode of the given name, as returned by String.
+func ParseSeedMode(s string) (SeedMode, error) {
+	for m, name := range seedModeNames {
+		if s == name {
+			return SeedMode(m), nil
+		}
+	}
+	return 0, fmt.Errorf("life: unknown seed mode %q", s)
+}
+
+// Seed replaces the state of every cell but the pinned ones by a random one laid out as told by
+// mode, each random cell being alive with probability density, clamped to [0, 1], and of a random
+// color, and resets the generation count. A field that grew shrinks back to its view first.
+func (l *Life) Seed(density float64, mode SeedMode) {
+	l.seed(density, mode, rand.Float64)
+}
+
+// SeedWith is like Seed, with cells drawn from a source seeded with seed: a field of the same size
+// seeded with the same arguments always gets the same cells.
+func (l *Life) SeedWith(density float64, mode SeedMode, seed int64) {
+	l.seed(density, mode, rand.New(rand.NewSource(seed)).Float64)
+}
+
+// seed is Seed drawing the random cells with random, which returns numbers in [0, 1).
+func (l *Life) seed(density float64, mode SeedMode, random func() float64) {
+	l.crop()
+	density = math.Max(0, math.Min(density, 1))
+	cx, cy := float64(l.w-1)/2, float64(l.h-1)/2
+	r := 0.4 * math.Min(float64(l.w), float64(l.h))
+	// The pinned cells are kept in b meanwhile, so that the symmetric cells of the layout copy the
+	// random ones.
+	copy(l.b.s, l.a.s)
+	l.population, l.hash, l.dying = 0, 0, 0
+	for y := 0; y < l.h; y++ {
+		for x := 0; x < l.w; x++ {
+			k := y*l.w + x
+			l.color[k], l.decay[k] = 0, 0
+			switch m := l.orbit(x, y, mode); {
+			case m < k:
+				// Copy the first cell of the symmetric ones, already set.
+				l.a.s[k], l.color[k] = l.a.s[m], l.color[m]
+			case mode == Circle && math.Hypot(float64(x)-cx, float64(y)-cy) > r:
+				l.a.s[k] = false
+			default:
+				l.a.s[k] = random() < density
+				if l.a.s[k] && l.Colors > 1 {
+					l.color[k] = uint8(random() * float64(l.Colors))
+				}
+			}
+			l.age[k] = 0
+			if l.a.s[k] {
+				l.population++
+				l.hash ^= cellKey(k)
+			}
+		}
+	}
+	for k, p := range l.pinned {
+		if !p {
+			continue
+		}
+		if l.a.s[k] != l.b.s[k] {
+			l.a.s[k] = l.b.s[k]
+			l.hash ^= cellKey(k)
+			if l.a.s[k] {
+				l.population++
+			} else {
+				l.population--
+			}
+		}
+		l.color[k] = 0
+	}
+	l.generation, l.mutations = 0, 0
+	l.countColors()
+	l.reshapes++
+	l.invalidate()
+	l.forget()
+}
+
+// orbit returns the smallest index of the cells the symmetry of mode maps the cell (x, y) to, itself
+// included.
+func (l *Life) orbit(x, y int, mode SeedMode) int {
+	// Coordinates doubled and relative to the center of the field, so that it is at 0, 0.
+	cx, cy := 2*x-(l.w-1), 2*y-(l.h-1)
+	var images [][2]int
+	switch mode {
+	case Mirror:
+		images = [][2]int{{-cx, cy}, {cx, -cy}, {-cx, -cy}}
+	case Horizontal:
+		images = [][2]int{{-cx, cy}}
+	case Vertical:
+		images = [][2]int{{cx, -cy}}
+	case Rotational:
+		images = [][2]int{{-cy, cx}, {-cx, -cy}, {cy, -cx}}
+	}
+	m := y*l.w + x
+	for _, c := range images {
+		// Back to the coordinates of a cell, if it is one.
+		x, y := c[0]+l.w-1, c[1]+l.h-1
+		if x%2 != 0 || y%2 != 0 || x < 0 || x >= 2*l.w || y < 0 || y >= 2*l.h {
+			continue
+		}
+		if k := y/2*l.w + x/2; k < m {
+			m = k
+		}
+	}
+	return m
+}
+
+// DefaultParallelCells is the default of Life.ParallelCells. Below, the goroutines cost more than
+// they save on most devices.
+const DefaultParallelCells = 1 << 14
+
+// Step advances the game by one instant, recomputing and updating all cells. A field that grew is
+// cropped to its view once the edge mode is no longer Grow.
+func (l *Life) Step() {
+	if l.Edges == Grow {
+		l.grow()
+	} else {
+		l.crop()
+	}
+	if l.stepsSparse() {
+		l.stepSparse()
+		l.mutate()
+		return
+	}
+	// Update the state of the next field (b) from the current field (a), in horizontal bands of
+	// rows computed concurrently for large fields.
+	if n := l.bands(); n > 1 {
+		var wg sync.WaitGroup
+		for k := 0; k < n; k++ {
+			wg.Add(1)
+			go func(y0, y1 int) {
+				defer wg.Done()
+				l.stepRows(y0, y1)
+			}(k*l.h/n, (k+1)*l.h/n)
+		}
+		wg.Wait()
+	} else {
+		l.stepRows(0, l.h)
+	}
+
+	// Then account for the changes in row-major order.
+	l.changed = 0
+	dies := l.Rule.states() > 2
+	al, marks := l.ageMarks()
+	for y := 0; y < l.h; y++ {
+		for x := 0; x < l.w; x++ {
+			k := y*l.w + x
+			alive, next := l.a.s[k], l.b.s[k]
+			if next != alive {
+				l.changed++
+				l.hash ^= cellKey(k)
+				l.cycles.moved(x, y, next)
+			}
+			switch {
+			case l.decay[k] != 0:
+				// Dying cells are never born, see stepRows.
+				l.changed++
+				l.decayStep(k)
+			case next && alive:
+				if l.age[k] < 1<<16-1 {
+					l.age[k]++
+				}
+				l.tellAge(k, al, marks)
+			case next && !alive:
+				l.age[k] = 0
+				l.color[k] = l.birthColor(x, y)
+				l.pops[l.color[k]]++
+				l.population++
+				l.notify(x, y, true)
+			case !next && alive:
+				l.population--
+				l.pops[l.color[k]]--
+				if dies {
+					l.setDecay(k, 2)
+				}
+				l.notify(x, y, false)
+			}
+		}
+	}
+	// Swap fields a and b.
+	l.a, l.b = l.b, l.a
+	l.generation++
+	l.invalidate()
+	l.stepped()
+	l.mutate()
+}
+
+// stepsSparse reports whether the next Step uses the sparse algorithm, see NewSparse.
+func (l *Life) stepsSparse() bool {
+	return l.sparse != nil && l.Rule.Birth&1 == 0 && l.Rule.states() == 2 && l.dying == 0 &&
+		l.population*sparseRatio <= l.w*l.h
+}
+
+// bands returns the number of bands of rows the next Step computes concurrently, unless sparse: one
+// per processor usable by goroutines for fields of ParallelCells cells or more, else 1.
+func (l *Life) bands() int {
+	minCells := l.ParallelCells
+	if minCells == 0 {
+		minCells = DefaultParallelCells
+	}
+	if n := runtime.GOMAXPROCS(0); n > 1 && l.w*l.h >= minCells {
+		return n
+	}
+	return 1
+}
+
+// Algorithm returns the name of the algorithm the next Step uses, for diagnostics: "sparse", "bands"
+// for concurrent bands of rows, or "dense".
+func (l *Life) Algorithm() string {
+	switch {
+	case l.stepsSparse():
+		return "sparse"
+	case l.bands() > 1:
+		return "bands"
+	}
+	return "dense"
+}
+
+// notify tells the Listener, if any, of the birth or death of the cell at (x, y) of the field, if
+// in view.
+func (l *Life) notify(x, y int, born bool) {
+	x, y = x-l.vx, y-l.vy
+	if l.Listener == nil || x < 0 || x >= l.vw || y < 0 || y >= l.vh {
+		return
+	}
+	if born {
+		l.Listener.OnBirth(x, y)
+	} else {
+		l.Listener.OnDeath(x, y)
+	}
+}
+
+// stepRows computes the next state of the rows y0 to y1 (excluded) of the field into b. Dying cells
+// stay dead, and pinned cells as they are.
+func (l *Life) stepRows(y0, y1 int) {
+	e, r := l.Edges, l.Rule
+	for y := y0; y < y1; y++ {
+		for x := 0; x < l.w; x++ {
+			k := y*l.w + x
+			if l.pinned[k] {
+				l.b.s[k] = l.a.s[k]
+				continue
+			}
+			l.b.set(x, y, l.decay[k] == 0 && l.a.next(x, y, e, r))
+		}
+	}
+}
@@ -0,0 +1,667 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// newGame returns a game of the given rows, . being a dead cell and O an alive one, in Bounded
+// mode.
+func newGame(rows ...string) *Life {
+	l := New(len(rows[0]), len(rows))
+	l.Edges = Bounded
+	for y, r := range rows {
+		for x, c := range r {
+			if c == 'O' {
+				l.Set(x, y, true)
+			}
+		}
+	}
+	return l
+}
+
+// picture returns the rows of l, as given to newGame.
+func picture(l *Life) []string {
+	w, h := l.Bounds()
+	rows := make([]string, h)
+	for y := range rows {
+		b := make([]byte, w)
+		for x := range b {
+			b[x] = '.'
+			if l.Alive(x, y) {
+				b[x] = 'O'
+			}
+		}
+		rows[y] = string(b)
+	}
+	return rows
+}
+
+func TestStep(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		steps         int
+		before, after []string
+	}{
+		{
+			name:   "empty",
+			steps:  1,
+			before: []string{"...", "...", "..."},
+			after:  []string{"...", "...", "..."},
+		},
+		{
+			name:   "lonely cell",
+			steps:  1,
+			before: []string{"...", ".O.", "..."},
+			after:  []string{"...", "...", "..."},
+		},
+		{
+			name:   "block",
+			steps:  5,
+			before: []string{"....", ".OO.", ".OO.", "...."},
+			after:  []string{"....", ".OO.", ".OO.", "...."},
+		},
+		{
+			name:   "blinker",
+			steps:  1,
+			before: []string{".....", ".....", ".OOO.", ".....", "....."},
+			after:  []string{".....", "..O..", "..O..", "..O..", "....."},
+		},
+		{
+			name:   "blinker back",
+			steps:  2,
+			before: []string{".....", ".....", ".OOO.", ".....", "....."},
+			after:  []string{".....", ".....", ".OOO.", ".....", "....."},
+		},
+		{
+			name:  "glider",
+			steps: 4,
+			before: []string{
+				".O....",
+				"..O...",
+				"OOO...",
+				"......",
+				"......",
+				"......",
+			},
+			after: []string{
+				"......",
+				"..O...",
+				"...O..",
+				".OOO..",
+				"......",
+				"......",
+			},
+		},
+		{
+			name:   "blinker on the edge",
+			steps:  1,
+			before: []string{"O..", "O..", "O.."},
+			after:  []string{"...", "OO.", "..."},
+		},
+		{
+			name:   "block in the corner",
+			steps:  3,
+			before: []string{"OO.", "OO.", "..."},
+			after:  []string{"OO.", "OO.", "..."},
+		},
+		{
+			name:  "glider into the corner",
+			steps: 8,
+			before: []string{
+				".O..",
+				"..O.",
+				"OOO.",
+				"....",
+			},
+			after: []string{
+				"....",
+				"....",
+				"..OO",
+				"..OO",
+			},
+		},
+	} {
+		l := newGame(test.before...)
+		for k := 0; k < test.steps; k++ {
+			l.Step()
+		}
+		if got := picture(l); strings.Join(got, "\n") != strings.Join(test.after, "\n") {
+			t.Errorf("%s: after %d steps got\n%s\nwant\n%s", test.name, test.steps, strings.Join(got, "\n"),
+				strings.Join(test.after, "\n"))
+		}
+		if l.Generation() != test.steps {
+			t.Errorf("%s: generation %d after %d steps", test.name, l.Generation(), test.steps)
+		}
+	}
+}
+
+func TestSetAlive(t *testing.T) {
+	l := New(5, 3)
+	if w, h := l.Bounds(); w != 5 || h != 3 {
+		t.Fatalf("Bounds() = %d, %d, want 5, 3", w, h)
+	}
+	l.Set(4, 2, true)
+	l.Set(0, 1, true)
+	l.Set(0, 1, true)
+	if !l.Alive(4, 2) || !l.Alive(0, 1) || l.Alive(1, 1) {
+		t.Errorf("cells not set")
+	}
+	if l.Population() != 2 {
+		t.Errorf("population %d, want 2", l.Population())
+	}
+	l.Set(4, 2, false)
+	if l.Alive(4, 2) || l.Population() != 1 {
+		t.Errorf("cell not cleared, population %d", l.Population())
+	}
+}
+
+func TestSeedDensity(t *testing.T) {
+	l := New(100, 100)
+	l.Seed(0.25, Random)
+	if p := l.Population(); p < 2000 || p > 3000 {
+		t.Errorf("population %d of 10000 cells seeded at density 0.25", p)
+	}
+	if l.Generation() != 0 {
+		t.Errorf("generation %d once seeded", l.Generation())
+	}
+}
+
+// benchmarkStep steps a soup of a w*h field.
+func benchmarkStep(b *testing.B, w, h int, e EdgeMode) {
+	l := New(w, h)
+	l.Edges = e
+	l.SeedWith(0.3, Random, 1)
+	// Stepped serially unless told otherwise.
+	l.ParallelCells = w*h + 1
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		l.Step()
+	}
+}
+
+func BenchmarkStep256(b *testing.B) {
+	benchmarkStep(b, 256, 256, Bounded)
+}
+
+// ageCounter is an AgeListener counting the cells reaching the ages of marks, as the app tinting
+// the cells by age bracket is told of them.
+type ageCounter struct {
+	marks []int
+	told  int
+}
+
+func (a *ageCounter) OnBirth(x, y int)    {}
+func (a *ageCounter) OnDeath(x, y int)    {}
+func (a *ageCounter) AgeMarks() []int     { return a.marks }
+func (a *ageCounter) OnAge(x, y, age int) { a.told++ }
+
+// benchmarkStepAges steps the soup of BenchmarkStep256 told to an ageCounter of the given marks,
+// none for the ages not shown.
+func benchmarkStepAges(b *testing.B, marks []int) {
+	l := New(256, 256)
+	l.Edges = Bounded
+	l.SeedWith(0.3, Random, 1)
+	l.ParallelCells = 256*256 + 1
+	l.Listener = &ageCounter{marks: marks}
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		l.Step()
+	}
+}
+
+// Against BenchmarkStep256, the cost of the ages of the cells, with the cells reaching the brackets
+// told or not.
+func BenchmarkStepAgesOff(b *testing.B) { benchmarkStepAges(b, nil) }
+func BenchmarkStepAgesOn(b *testing.B)  { benchmarkStepAges(b, []int{1, 5, 20, 100}) }
+
+func TestEdgeModes(t *testing.T) {
+	// A glider about to cross the right edge of a field.
+	before := []string{
+		"........",
+		"......O.",
+		".......O",
+		".....OOO",
+		"........",
+		"........",
+	}
+	for _, test := range []struct {
+		edges EdgeMode
+		steps int
+		after []string
+	}{
+		// Wrapping, the glider reappears on the left.
+		{Wrap, 4, []string{
+			"........",
+			"........",
+			".......O",
+			"O.......",
+			"O.....OO",
+			"........",
+		}},
+		// Bounded, it breaks on the edge, leaving a block.
+		{Bounded, 8, []string{
+			"........",
+			"........",
+			"........",
+			"......OO",
+			"......OO",
+			"........",
+		}},
+	} {
+		l := newGame(before...)
+		l.Edges = test.edges
+		for k := 0; k < test.steps; k++ {
+			l.Step()
+		}
+		if got := picture(l); strings.Join(got, "\n") != strings.Join(test.after, "\n") {
+			t.Errorf("edge mode %d: got\n%s\nwant\n%s", test.edges, strings.Join(got, "\n"),
+				strings.Join(test.after, "\n"))
+		}
+	}
+}
+
+func TestWrapCorners(t *testing.T) {
+	// The four corners of a torus are neighbors of one another: they make a block.
+	l := New(6, 5)
+	for _, c := range [][2]int{{0, 0}, {5, 0}, {0, 4}, {5, 4}} {
+		l.Set(c[0], c[1], true)
+	}
+	l.Step()
+	if l.Population() != 4 || !l.Alive(0, 0) || !l.Alive(5, 0) || !l.Alive(0, 4) || !l.Alive(5, 4) {
+		t.Errorf("corners of the torus not a block:\n%s", strings.Join(picture(l), "\n"))
+	}
+	if !l.Alive(-1, -1) || !l.Alive(6, 5) {
+		t.Errorf("cells beyond the corners not wrapped")
+	}
+}
+
+func BenchmarkStepWrap(b *testing.B) {
+	benchmarkStep(b, 256, 256, Wrap)
+}
+
+func BenchmarkStepBounded(b *testing.B) {
+	benchmarkStep(b, 256, 256, Bounded)
+}
+
+func TestParallelStep(t *testing.T) {
+	// Bands need several processors, whatever the machine running the test.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	for seed := int64(1); seed <= 5; seed++ {
+		for _, e := range []EdgeMode{Wrap, Bounded, Reflect} {
+			serial, parallel := New(97, 61), New(97, 61)
+			serial.ParallelCells = 97*61 + 1
+			parallel.ParallelCells = 1
+			for _, l := range []*Life{serial, parallel} {
+				l.Edges = e
+				l.SeedWith(0.4, Random, seed)
+			}
+			for gen := 1; gen <= 30; gen++ {
+				serial.Step()
+				parallel.Step()
+				if diff := sameGame(serial, parallel); diff != "" {
+					t.Fatalf("seed %d, edge mode %d: parallel step differs by its %s at generation %d", seed, e, diff, gen)
+				}
+			}
+		}
+	}
+}
+
+// benchmarkParallelStep is benchmarkStep with the field stepped in concurrent bands.
+func benchmarkParallelStep(b *testing.B, n int) {
+	l := New(n, n)
+	l.SeedWith(0.3, Random, 1)
+	l.ParallelCells = 1
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		l.Step()
+	}
+}
+
+func BenchmarkStepSerial64(b *testing.B)     { benchmarkStep(b, 64, 64, Wrap) }
+func BenchmarkStepParallel64(b *testing.B)   { benchmarkParallelStep(b, 64) }
+func BenchmarkStepSerial256(b *testing.B)    { benchmarkStep(b, 256, 256, Wrap) }
+func BenchmarkStepParallel256(b *testing.B)  { benchmarkParallelStep(b, 256) }
+func BenchmarkStepSerial1024(b *testing.B)   { benchmarkStep(b, 1024, 1024, Wrap) }
+func BenchmarkStepParallel1024(b *testing.B) { benchmarkParallelStep(b, 1024) }
+
+func TestSeedSymmetry(t *testing.T) {
+	for _, size := range [][2]int{{20, 20}, {21, 15}, {16, 9}} {
+		w, h := size[0], size[1]
+		for _, mode := range []SeedMode{Mirror, Horizontal, Vertical, Rotational} {
+			l := New(w, h)
+			l.SeedWith(0.5, mode, 1)
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					var images [][2]int
+					switch mode {
+					case Mirror:
+						images = [][2]int{{w - 1 - x, y}, {x, h - 1 - y}}
+					case Horizontal:
+						images = [][2]int{{w - 1 - x, y}}
+					case Vertical:
+						images = [][2]int{{x, h - 1 - y}}
+					case Rotational:
+						images = [][2]int{{w - 1 - x, h - 1 - y}}
+						// The quarter turn, where it maps cells to cells.
+						if qx, qy := (w-h)/2+(h-1-y), (h-w)/2+x; (w-h)%2 == 0 && qx >= 0 && qx < w && qy >= 0 && qy < h {
+							images = append(images, [2]int{qx, qy})
+						}
+					}
+					for _, c := range images {
+						if l.Alive(x, y) != l.Alive(c[0], c[1]) {
+							t.Fatalf("%dx%d %v seed: cell %d,%d differs from %d,%d", w, h, mode, x, y, c[0], c[1])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSeedCircle(t *testing.T) {
+	l := New(30, 20)
+	l.SeedWith(1, Circle, 1)
+	for _, c := range [][2]int{{0, 0}, {29, 0}, {0, 19}, {29, 19}, {0, 10}, {15, 0}} {
+		if l.Alive(c[0], c[1]) {
+			t.Errorf("cell %d,%d out of the circle alive", c[0], c[1])
+		}
+	}
+	if !l.Alive(15, 10) {
+		t.Errorf("center of the circle dead")
+	}
+}
+
+func TestSeedDensityBounds(t *testing.T) {
+	for _, test := range []struct {
+		density    float64
+		population int
+	}{
+		{0, 0},
+		{-0.5, 0},
+		{1, 12 * 8},
+		{3, 12 * 8},
+	} {
+		for _, mode := range []SeedMode{Random, Mirror, Horizontal, Vertical, Rotational} {
+			l := New(12, 8)
+			l.SeedWith(test.density, mode, 1)
+			if l.Population() != test.population {
+				t.Errorf("%v seed at density %v: population %d, want %d", mode, test.density, l.Population(),
+					test.population)
+			}
+		}
+	}
+}
+
+func TestSeedWith(t *testing.T) {
+	a, b := New(40, 30), New(40, 30)
+	a.SeedWith(0.3, Random, 42)
+	b.SeedWith(0.3, Random, 42)
+	if diff := sameGame(a, b); diff != "" {
+		t.Errorf("same seeds give games differing by their %s", diff)
+	}
+	b.SeedWith(0.3, Random, 43)
+	if a.Hash() == b.Hash() {
+		t.Errorf("different seeds give the same game")
+	}
+}
+
+func TestParseSeedMode(t *testing.T) {
+	for m := Random; m <= Rotational; m++ {
+		if got, err := ParseSeedMode(m.String()); err != nil || got != m {
+			t.Errorf("ParseSeedMode(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseSeedMode("spiral"); err == nil {
+		t.Errorf("parsed an unknown seed mode")
+	}
+}
+
+func TestGliderTranslation(t *testing.T) {
+	// On a torus, a glider is the same glider moved by a cell down and right every 4 generations,
+	// forever.
+	l := New(10, 8)
+	l.Stamp(glider, 0, 0)
+	for gen := 4; gen <= 80; gen += 4 {
+		for k := 0; k < 4; k++ {
+			l.Step()
+		}
+		if l.Population() != 5 {
+			t.Fatalf("population %d at generation %d", l.Population(), gen)
+		}
+		want := New(10, 8)
+		want.Stamp(glider, 0, 0)
+		want.Shift(gen/4, gen/4)
+		if strings.Join(picture(l), "\n") != strings.Join(picture(want), "\n") {
+			t.Fatalf("generation %d:\n%s\nwant\n%s", gen, strings.Join(picture(l), "\n"),
+				strings.Join(picture(want), "\n"))
+		}
+	}
+}
+
+func TestPopulation(t *testing.T) {
+	l := newGame(
+		".....",
+		".OOO.",
+		".....",
+	)
+	for gen := 0; gen < 4; gen++ {
+		n := 0
+		for _, r := range picture(l) {
+			n += strings.Count(r, "O")
+		}
+		if l.Population() != n {
+			t.Errorf("generation %d: population %d, counted %d", gen, l.Population(), n)
+		}
+		l.Step()
+	}
+}
+
+func TestBytes(t *testing.T) {
+	small, large := New(10, 10), New(100, 100)
+	if small.Bytes() <= 10*10 || large.Bytes()-small.Bytes() < 5*(100*100-10*10) {
+		t.Errorf("%d bytes for 10x10 cells, %d for 100x100", small.Bytes(), large.Bytes())
+	}
+	// The sparse algorithm holds the alive cells on top.
+	l := NewSparse(100, 100)
+	l.SeedWith(0.01, Random, 1)
+	l.Step()
+	if l.Bytes() <= large.Bytes() {
+		t.Errorf("%d bytes sparse, %d dense", l.Bytes(), large.Bytes())
+	}
+}
+
+func TestClone(t *testing.T) {
+	l := New(20, 20)
+	l.SeedWith(0.4, Random, 1)
+	c := l.Clone()
+	if diff := sameGame(l, c); diff != "" {
+		t.Fatalf("clone differs by its %s", diff)
+	}
+	c.Step()
+	c.Set(0, 0, !c.Alive(0, 0))
+	if l.Generation() != 0 || c.Generation() != 1 {
+		t.Errorf("stepping the clone stepped the game")
+	}
+	l.Step()
+	c = l.Clone()
+	l.Step()
+	c.Step()
+	if diff := sameGame(l, c); diff != "" {
+		t.Errorf("clone stepped differs by its %s", diff)
+	}
+}
+
+func TestResize(t *testing.T) {
+	l := newGame(
+		"O...",
+		".OO.",
+		"...O",
+	)
+	// Moved by one cell right, padded by a dead column and cropped by a dead row.
+	l.Resize(6, 2, 1, 0)
+	want := []string{".O....", "..OO.."}
+	if got := picture(l); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("resized as %v, want %v", got, want)
+	}
+	if l.Population() != 3 {
+		t.Errorf("population %d once resized, want 3", l.Population())
+	}
+}
+
+func TestShift(t *testing.T) {
+	l := newGame(
+		"O...",
+		".O..",
+		"...O",
+	)
+	l.Edges = Wrap
+	l.Shift(1, -1)
+	want := []string{"..O.", "O...", ".O.."}
+	if got := picture(l); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("shifted as %v, want %v", got, want)
+	}
+
+	// Unless wrapping, the cells shifted beyond the edges are lost.
+	for _, edges := range []EdgeMode{Bounded, Reflect} {
+		l = newGame(
+			"O...",
+			".O..",
+			"...O",
+		)
+		l.Edges = edges
+		l.Shift(1, -1)
+		want := []string{"..O.", "....", "...."}
+		if got := picture(l); strings.Join(got, "|") != strings.Join(want, "|") || l.Population() != 1 {
+			t.Errorf("edge mode %d: shifted as %v with %d cells, want %v", edges, got, l.Population(), want)
+		}
+	}
+}
+
+func TestReflect(t *testing.T) {
+	// Beyond the edges of a reflected field, cells see the cells on the edges: a domino along an
+	// edge is half of a block, and still, where Bounded mode kills it.
+	before := []string{
+		"..OO..",
+		"......",
+		"......",
+	}
+	for _, test := range []struct {
+		edges EdgeMode
+		after []string
+	}{
+		{Reflect, before},
+		{Bounded, []string{"......", "......", "......"}},
+	} {
+		l := newGame(before...)
+		l.Edges = test.edges
+		l.Step()
+		if got := picture(l); strings.Join(got, "|") != strings.Join(test.after, "|") {
+			t.Errorf("edge mode %d: stepped as %v, want %v", test.edges, got, test.after)
+		}
+	}
+	l := newGame(before...)
+	l.Edges = Reflect
+	if !l.Alive(2, -1) || !l.Alive(3, -1) || l.Alive(1, -1) || !l.Alive(2, 0) {
+		t.Errorf("cells beyond the edges not reflected")
+	}
+}
+
+func TestGrow(t *testing.T) {
+	l := New(6, 6)
+	l.Edges = Grow
+	l.Stamp(glider, 2, 2)
+	for k := 0; k < 40; k++ {
+		l.Step()
+	}
+	// The glider left the view, still alive beyond it.
+	if w, h := l.Bounds(); w != 6 || h != 6 {
+		t.Errorf("view of %dx%d cells, want 6x6", w, h)
+	}
+	if l.Population() != 5 {
+		t.Errorf("population %d, want the 5 cells of the glider", l.Population())
+	}
+	if p := l.Pattern(); p.W != 3 || p.H != 3 || len(p.Cells) != 5 {
+		t.Errorf("pattern of the grown field %+v, want a glider", p)
+	}
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 6; x++ {
+			if l.Alive(x, y) {
+				t.Fatalf("cell %d,%d alive in view", x, y)
+			}
+		}
+	}
+}
+
+func TestGrowLimit(t *testing.T) {
+	// Growing by growMargin cells beyond any edge would exceed maxGrowCells, so the field keeps its
+	// size and the glider dies as under Bounded.
+	const n = 2048
+	if n*(n+growMargin) <= maxGrowCells {
+		t.Fatalf("a field of %d cells grown is within maxGrowCells", n*n)
+	}
+	l, bounded := NewSparse(n, n), NewSparse(n, n)
+	l.Edges, bounded.Edges = Grow, Bounded
+	l.Stamp(glider, n-4, n-4)
+	bounded.Stamp(glider, n-4, n-4)
+	for k := 0; k < 12; k++ {
+		l.Step()
+		bounded.Step()
+	}
+	if l.w != n || l.h != n {
+		t.Errorf("field grown to %dx%d cells, over maxGrowCells", l.w, l.h)
+	}
+	if l.Population() != bounded.Population() || l.Hash() != bounded.Hash() {
+		t.Errorf("glider against the limit evolved apart from the bounded one")
+	}
+}
+
+// eventLog records the events told to a StepListener.
+type eventLog []string
+
+func (e *eventLog) OnBirth(x, y int) { *e = append(*e, fmt.Sprintf("birth %d %d", x, y)) }
+func (e *eventLog) OnDeath(x, y int) { *e = append(*e, fmt.Sprintf("death %d %d", x, y)) }
+
+func TestListenerBlinker(t *testing.T) {
+	l := newGame(".....", ".....", ".OOO.", ".....", ".....")
+	var log eventLog
+	l.Listener = &log
+	l.Step()
+	want := "[birth 2 1 death 1 2 death 3 2 birth 2 3]"
+	if got := fmt.Sprint(log); got != want {
+		t.Errorf("told %s, want %s", got, want)
+	}
+}
+
+// TestListenerGlider checks the events of a glider crossing a torus against the differences between
+// its generations, with the dense and sparse algorithms.
+func TestListenerGlider(t *testing.T) {
+	for _, l := range []*Life{New(9, 7), NewSparse(9, 7)} {
+		l.Stamp(glider, 5, 3)
+		var log eventLog
+		l.Listener = &log
+		for gen := 1; gen <= 12; gen++ {
+			before := picture(l)
+			log = log[:0]
+			l.Step()
+			var want eventLog
+			for y, r := range picture(l) {
+				for x := range r {
+					switch {
+					case r[x] == 'O' && before[y][x] == '.':
+						want.OnBirth(x, y)
+					case r[x] == '.' && before[y][x] == 'O':
+						want.OnDeath(x, y)
+					}
+				}
+			}
+			if fmt.Sprint(log) != fmt.Sprint(want) {
+				t.Fatalf("generation %d: told %v, want %v", gen, log, want)
+			}
+		}
+	}
+}
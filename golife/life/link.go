@@ -0,0 +1,79 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// LinkPrefix starts the links to patterns made by Link.
+const LinkPrefix = "golife://rle/"
+
+// maxLinkRLE bounds the size of the RLE, or other data, decompressed from a link, so that a forged
+// link does not exhaust memory.
+const maxLinkRLE = 1 << 20
+
+// Link returns p as a golife:// link, a compact text to share it: its RLE compressed with DEFLATE,
+// in unpadded URL-safe base64 after LinkPrefix.
+func (p *Pattern) Link() (string, error) {
+	var b bytes.Buffer
+	if err := p.WriteRLE(&b); err != nil {
+		return "", err
+	}
+	return encodeLink(LinkPrefix, b.Bytes())
+}
+
+// ParseLink parses a link made by Link. Surrounding spaces are ignored.
+func ParseLink(s string) (*Pattern, error) {
+	rle, err := decodeLink(LinkPrefix, s)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRLE(bytes.NewReader(rle))
+}
+
+// encodeLink returns the link of the given prefix to data, compressed with DEFLATE, in unpadded
+// URL-safe base64.
+func encodeLink(prefix string, data []byte) (string, error) {
+	var b bytes.Buffer
+	w, err := flate.NewWriter(&b, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(b.Bytes()), nil
+}
+
+// decodeLink returns the data of s, a link of the given prefix made by encodeLink, at most
+// maxLinkRLE bytes of it. Surrounding spaces are ignored.
+func decodeLink(prefix, s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, prefix) {
+		return nil, fmt.Errorf("life: invalid link: missing %s prefix", prefix)
+	}
+	z, err := base64.RawURLEncoding.DecodeString(s[len(prefix):])
+	if err != nil {
+		return nil, fmt.Errorf("life: invalid link: %v", err)
+	}
+	r := flate.NewReader(bytes.NewReader(z))
+	defer r.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxLinkRLE+1))
+	if err != nil {
+		return nil, fmt.Errorf("life: invalid link: %v", err)
+	}
+	if len(data) > maxLinkRLE {
+		return nil, fmt.Errorf("life: invalid link: over %d bytes", maxLinkRLE)
+	}
+	return data, nil
+}
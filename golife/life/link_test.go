@@ -0,0 +1,53 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestLinkRoundTrip(t *testing.T) {
+	gun, _ := ParseRLE(strings.NewReader(gliderGun))
+	s, err := gun.Link()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := ParseLink(" " + s + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !samePattern(p, gun) {
+		t.Errorf("link parsed as %+v, want %+v", p, gun)
+	}
+}
+
+// link returns the link of the given RLE, which need not be valid.
+func link(t *testing.T, rle string) string {
+	var b bytes.Buffer
+	w, _ := flate.NewWriter(&b, flate.BestCompression)
+	w.Write([]byte(rle))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return LinkPrefix + base64.RawURLEncoding.EncodeToString(b.Bytes())
+}
+
+func TestParseLinkInvalid(t *testing.T) {
+	for _, test := range []struct{ name, link string }{
+		{"no prefix", "rle/eJwLycgsVgCiRIWS1OISAC3mBZw"},
+		{"not base64", LinkPrefix + "a*b"},
+		{"not DEFLATE", LinkPrefix + "AAAA"},
+		{"invalid RLE", link(t, "x = 3, y = 1\n4o!")},
+		// A few bytes decompressing to more than the limit, which is not read whole.
+		{"RLE too large", link(t, "x = 1, y = 1\n"+strings.Repeat("$", maxLinkRLE)+"o!")},
+		{"pattern too large", link(t, "x = 100000, y = 1\no!")},
+	} {
+		if p, err := ParseLink(test.link); err == nil {
+			t.Errorf("%s: parsed as %+v", test.name, p)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import "math/rand"
+
+// A Mutation flips random cells of the view as the game steps, keeping a game that settled
+// churning: every Every generations, Cells cells drawn from a source seeded with Seed and the
+// generation, so that a game mutates the same way whenever it reaches the same generation. None if
+// Every or Cells is 0. A cell drawn twice flips back, and a pinned one stays as it is, see Pin.
+type Mutation struct {
+	Every, Cells int
+	Seed         int64
+}
+
+// Active reports whether m flips cells.
+func (m Mutation) Active() bool {
+	return m.Every > 0 && m.Cells > 0
+}
+
+// A MutationListener is a StepListener also notified of the cells flipped by the Mutation, which
+// are not told as births and deaths. The Listener of a game is told if it implements
+// MutationListener.
+type MutationListener interface {
+	StepListener
+	// OnMutation is called for a cell flipped, once the step completed, alive as it is now.
+	OnMutation(x, y int, alive bool)
+}
+
+// Mutations returns the number of cells flipped by the Mutation since the last Seed. Changed does
+// not count them.
+func (l *Life) Mutations() int {
+	return l.mutations
+}
+
+// mutate flips the cells of the Mutation, if the generation reached is one of them.
+func (l *Life) mutate() {
+	m := l.Mutation
+	if !m.Active() || l.generation%m.Every != 0 {
+		return
+	}
+	r := rand.New(rand.NewSource(int64(mix(uint64(m.Seed) ^ mix(uint64(l.generation))))))
+	ml, _ := l.Listener.(MutationListener)
+	for n := 0; n < m.Cells; n++ {
+		x, y := r.Intn(l.vw), r.Intn(l.vh)
+		k := l.key(x, y)
+		if l.pinned[k] {
+			continue
+		}
+		alive := !l.a.s[k]
+		l.set(k, alive)
+		l.mutations++
+		if ml != nil {
+			ml.OnMutation(x, y, alive)
+		}
+	}
+	l.invalidate()
+	l.forget()
+}
@@ -0,0 +1,78 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"fmt"
+	"testing"
+)
+
+// mutationLog records the events told to a MutationListener.
+type mutationLog struct{ eventLog }
+
+func (m *mutationLog) OnMutation(x, y int, alive bool) {
+	m.eventLog = append(m.eventLog, fmt.Sprintf("mutation %d %d %v", x, y, alive))
+}
+
+func TestMutation(t *testing.T) {
+	m := Mutation{Every: 3, Cells: 4, Seed: 5}
+	l := New(20, 20)
+	l.Mutation = m
+	var log mutationLog
+	l.Listener = &log
+	for k := 0; k < 3; k++ {
+		l.Step()
+	}
+	// The flips of an empty field are not told as births, nor do they count as changes.
+	if len(log.eventLog) != 4 || l.Changed() != 0 || l.Mutations() != 4 {
+		t.Errorf("%d mutations and %d changes, told %v", l.Mutations(), l.Changed(), log.eventLog)
+	}
+	for _, e := range log.eventLog {
+		var x, y int
+		var alive bool
+		if _, err := fmt.Sscanf(e, "mutation %d %d %v", &x, &y, &alive); err != nil || !alive {
+			t.Errorf("told %s", e)
+		}
+	}
+	l.Step()
+	l.Step()
+	if l.Mutations() != 4 {
+		t.Errorf("generation %d: %d mutations, want 4", l.Generation(), l.Mutations())
+	}
+
+	// The same game mutates the same way, whichever algorithm steps it, and apart under another seed.
+	a, b, c := New(20, 20), NewSparse(20, 20), New(20, 20)
+	a.Mutation, b.Mutation, c.Mutation = m, m, Mutation{Every: 3, Cells: 4, Seed: 6}
+	for _, g := range []*Life{a, b, c} {
+		g.Stamp(glider, 3, 4)
+	}
+	for k := 0; k < 30; k++ {
+		a.Step()
+		b.Step()
+		c.Step()
+		if d := sameGame(a, b); d != "" {
+			t.Fatalf("generation %d: sparse game differs in %s", a.Generation(), d)
+		}
+	}
+	if sameGame(a, c) == "" {
+		t.Errorf("mutations of different seeds alike")
+	}
+
+	// Seeding forgets them.
+	a.SeedWith(0.3, Random, 1)
+	if a.Mutations() != 0 {
+		t.Errorf("%d mutations once seeded", a.Mutations())
+	}
+}
+
+func TestMutationStagnation(t *testing.T) {
+	// A still life with mutations keeps changing.
+	l := newGame("....", ".OO.", ".OO.", "....")
+	l.Mutation = Mutation{Every: 1, Cells: 1, Seed: 2}
+	for k := 0; k < 10; k++ {
+		l.Step()
+		if p := l.Period(); p != 0 {
+			t.Fatalf("generation %d: period %d", l.Generation(), p)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// An Object is a group of alive cells of the view, each a neighbor of another, as found by Objects.
+type Object struct {
+	// Name is the name of the known object the cells are, see KnownObjects, empty if none.
+	Name       string
+	X, Y, W, H int // The smallest rectangle of the view holding the cells.
+	Population int
+}
+
+// A KnownObject is one of the common objects of Conway's Game of Life that Objects recognizes, in
+// any phase, orientation or mirror image.
+type KnownObject struct {
+	Name    string
+	Pattern *Pattern // A phase.
+	Period  int      // Number of phases the object goes through, 1 for still lifes.
+}
+
+// KnownObjects are the objects told by Objects: the still lifes, oscillators and spaceships that
+// random soups leave the most.
+var KnownObjects = []KnownObject{
+	{"block", &Pattern{W: 2, H: 2, Cells: [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}}}, 1},
+	{"beehive", &Pattern{W: 4, H: 3, Cells: [][2]int{{1, 0}, {2, 0}, {0, 1}, {3, 1}, {1, 2}, {2, 2}}}, 1},
+	{"loaf", &Pattern{W: 4, H: 4, Cells: [][2]int{{1, 0}, {2, 0}, {0, 1}, {3, 1}, {1, 2}, {3, 2}, {2, 3}}}, 1},
+	{"boat", &Pattern{W: 3, H: 3, Cells: [][2]int{{0, 0}, {1, 0}, {0, 1}, {2, 1}, {1, 2}}}, 1},
+	{"tub", &Pattern{W: 3, H: 3, Cells: [][2]int{{1, 0}, {0, 1}, {2, 1}, {1, 2}}}, 1},
+	{"ship", &Pattern{W: 3, H: 3, Cells: [][2]int{{0, 0}, {1, 0}, {0, 1}, {2, 1}, {1, 2}, {2, 2}}}, 1},
+	{"pond", &Pattern{W: 4, H: 4, Cells: [][2]int{{1, 0}, {2, 0}, {0, 1}, {3, 1}, {0, 2}, {3, 2}, {1, 3}, {2, 3}}}, 1},
+	{"blinker", &Pattern{W: 3, H: 1, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}}}, 2},
+	{"glider", &Pattern{W: 3, H: 3, Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}}, 4},
+}
+
+// maxObjectCells is the population of the largest known object, above which objects are not
+// looked up.
+const maxObjectCells = 8
+
+// knownShapes maps the canonical shapes of every phase of the known objects, see canonicalShape,
+// to their names.
+var knownShapes = make(map[string]string)
+
+func init() {
+	for _, o := range KnownObjects {
+		// Stepped in a field leaving room for a glider to move a cell.
+		l := New(o.Pattern.W+4, o.Pattern.H+4)
+		l.Edges = Bounded
+		l.Stamp(o.Pattern, 2, 2)
+		for p := 0; p < o.Period; p++ {
+			var cells [][2]int
+			for y := 0; y < l.h; y++ {
+				for x := 0; x < l.w; x++ {
+					if l.a.s[y*l.w+x] {
+						cells = append(cells, [2]int{x, y})
+					}
+				}
+			}
+			knownShapes[canonicalShape(cells)] = o.Name
+			l.Step()
+		}
+	}
+}
+
+// Objects returns the groups of alive cells of the view, each cell a neighbor of another of its
+// group, in the row-major order of their first cells. Groups that are known objects are named, in
+// games of the Conway rule only. Cells do not neighbor across the edges of the view, and dying
+// cells are not part of any object, see Rule.States.
+func (l *Life) Objects() []Object {
+	var objects []Object
+	seen := make([]bool, l.vw*l.vh)
+	var stack, cells [][2]int
+	for y := 0; y < l.vh; y++ {
+		for x := 0; x < l.vw; x++ {
+			if seen[y*l.vw+x] || !l.a.s[l.key(x, y)] {
+				continue
+			}
+			// Flood the group from (x, y).
+			seen[y*l.vw+x] = true
+			stack, cells = append(stack[:0], [2]int{x, y}), cells[:0]
+			o := Object{X: x, Y: y, W: 1, H: 1}
+			x1, y1 := x, y
+			for len(stack) > 0 {
+				c := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				cells = append(cells, c)
+				if c[0] < o.X {
+					o.X = c[0]
+				}
+				if c[0] > x1 {
+					x1 = c[0]
+				}
+				if c[1] > y1 {
+					y1 = c[1]
+				}
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						nx, ny := c[0]+dx, c[1]+dy
+						if nx < 0 || nx >= l.vw || ny < 0 || ny >= l.vh || seen[ny*l.vw+nx] || !l.a.s[l.key(nx, ny)] {
+							continue
+						}
+						seen[ny*l.vw+nx] = true
+						stack = append(stack, [2]int{nx, ny})
+					}
+				}
+			}
+			o.W, o.H, o.Population = x1-o.X+1, y1-o.Y+1, len(cells)
+			if len(cells) <= maxObjectCells && l.Rule == Conway {
+				o.Name = knownShapes[canonicalShape(cells)]
+			}
+			objects = append(objects, o)
+		}
+	}
+	return objects
+}
+
+// canonicalShape returns the same string for cells, given as {x, y} pairs, and for any of their
+// rotations, reflections and translations: the smallest of the encodings of the eight
+// orientations of the cells moved to the origin.
+func canonicalShape(cells [][2]int) string {
+	best := ""
+	for t := 0; t < 8; t++ {
+		moved := make([][2]int, len(cells))
+		for k, c := range cells {
+			x, y := c[0], c[1]
+			if t&4 != 0 {
+				x, y = y, x
+			}
+			if t&2 != 0 {
+				x = -x
+			}
+			if t&1 != 0 {
+				y = -y
+			}
+			moved[k] = [2]int{x, y}
+		}
+		minX, minY := moved[0][0], moved[0][1]
+		for _, c := range moved {
+			if c[0] < minX {
+				minX = c[0]
+			}
+			if c[1] < minY {
+				minY = c[1]
+			}
+		}
+		for k := range moved {
+			moved[k][0] -= minX
+			moved[k][1] -= minY
+		}
+		sort.Sort(rowMajor(moved))
+		parts := make([]string, len(moved))
+		for k, c := range moved {
+			parts[k] = fmt.Sprintf("%d,%d", c[0], c[1])
+		}
+		if s := strings.Join(parts, ";"); best == "" || s < best {
+			best = s
+		}
+	}
+	return best
+}
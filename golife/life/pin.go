@@ -0,0 +1,107 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+// A PinnedCell is a cell of the view pinned alive or dead, see Pin.
+type PinnedCell struct {
+	X, Y  int
+	Alive bool
+}
+
+// Pin sets the state of the specified cell, which must be inside the field, in view, and pins it
+// there: Step, Set, Seed, Clear, Stamp and the Mutation leave it alive or dead, of color 0, until
+// Unpin. A cell pinned dead is a wall, which the cells around see as dead and nothing is born in,
+// and a cell pinned alive a source they always see alive. Pinning and unpinning change the field as
+// a whole for the Journal.
+func (l *Life) Pin(x, y int, alive bool) {
+	l.pin(l.key(x, y), alive)
+	l.pinsChanged()
+}
+
+// pin is Pin for the cell at index k of the field, leaving the caller to tell of the change, see
+// pinsChanged.
+func (l *Life) pin(k int, alive bool) {
+	if l.pinned[k] {
+		l.pinned[k] = false
+		l.pins--
+	}
+	l.set(k, alive)
+	if alive && l.color[k] != 0 {
+		l.pops[l.color[k]]--
+		l.color[k] = 0
+		l.pops[0]++
+	}
+	l.pinned[k] = true
+	l.pins++
+}
+
+// Unpin unpins the specified cell, which must be inside the field, in view, leaving it in its
+// state. Unpinned cells are left as they are.
+func (l *Life) Unpin(x, y int) {
+	if k := l.key(x, y); l.pinned[k] {
+		l.pinned[k] = false
+		l.pins--
+		l.pinsChanged()
+	}
+}
+
+// Pinned reports whether the specified cell, which must be inside the field, in view, is pinned.
+func (l *Life) Pinned(x, y int) bool {
+	return l.pinned[l.key(x, y)]
+}
+
+// PinnedCells returns the pinned cells of the view, in row-major order.
+func (l *Life) PinnedCells() []PinnedCell {
+	if l.pins == 0 {
+		return nil
+	}
+	var cells []PinnedCell
+	for y := 0; y < l.vh; y++ {
+		for x := 0; x < l.vw; x++ {
+			if k := l.key(x, y); l.pinned[k] {
+				cells = append(cells, PinnedCell{x, y, l.a.s[k]})
+			}
+		}
+	}
+	return cells
+}
+
+// SetPins unpins every cell of the view, then pins the given ones as Pin does, at once. Cells
+// falling outside the view are clipped.
+func (l *Life) SetPins(cells []PinnedCell) {
+	if l.pins == 0 && len(cells) == 0 {
+		return
+	}
+	for y := 0; y < l.vh; y++ {
+		for x := 0; x < l.vw; x++ {
+			if k := l.key(x, y); l.pinned[k] {
+				l.pinned[k] = false
+				l.pins--
+			}
+		}
+	}
+	for _, c := range cells {
+		if c.X >= 0 && c.X < l.vw && c.Y >= 0 && c.Y < l.vh {
+			l.pin(l.key(c.X, c.Y), c.Alive)
+		}
+	}
+	l.pinsChanged()
+}
+
+// pinsChanged tells the journal, the sparse algorithm and the cycle detection that cells were
+// pinned or unpinned.
+func (l *Life) pinsChanged() {
+	l.reshapes++
+	l.invalidate()
+	l.forget()
+}
+
+// countPins counts the pinned cells again, for instance once the fields changed as a whole.
+func (l *Life) countPins() {
+	l.pins = 0
+	for _, p := range l.pinned {
+		if p {
+			l.pins++
+		}
+	}
+}
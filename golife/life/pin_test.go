@@ -0,0 +1,152 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPin(t *testing.T) {
+	// A wall keeps the corner of an L from being born, and a source alone survives.
+	l := newGame(
+		"......",
+		".OO...",
+		".O....",
+		"......",
+		"....O.",
+	)
+	l.Pin(2, 2, false)
+	l.Pin(4, 4, true)
+	l.Step()
+	want := []string{
+		"......",
+		".OO...",
+		".O....",
+		"......",
+		"....O.",
+	}
+	if got := picture(l); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("stepped to\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !l.Pinned(2, 2) || !l.Pinned(4, 4) || l.Pinned(1, 1) || l.Age(4, 4) != 1 {
+		t.Errorf("pinned %v %v %v, source %d generations old", l.Pinned(2, 2), l.Pinned(4, 4), l.Pinned(1, 1), l.Age(4, 4))
+	}
+
+	// Edits of single cells leave them as they are.
+	l.Set(2, 2, true)
+	l.Set(4, 4, false)
+	l.SetCells([][2]int{{2, 2}}, true)
+	l.Stamp(&Pattern{W: 1, H: 1, Cells: [][2]int{{0, 0}}}, 2, 2)
+	l.Clear(3, 3, 2, 2)
+	if l.Alive(2, 2) || !l.Alive(4, 4) {
+		t.Errorf("pinned cells edited: wall %v, source %v", l.Alive(2, 2), l.Alive(4, 4))
+	}
+	// Unpinned, they are cells as the others.
+	l.Unpin(2, 2)
+	l.Set(2, 2, true)
+	if !l.Alive(2, 2) || l.Pinned(2, 2) {
+		t.Errorf("unpinned cell alive %v, pinned %v once set", l.Alive(2, 2), l.Pinned(2, 2))
+	}
+	if got := fmt.Sprint(l.PinnedCells()); got != "[{4 4 true}]" {
+		t.Errorf("pinned cells %s", got)
+	}
+	l.SetPins([]PinnedCell{{0, 0, true}, {5, 0, false}, {9, 9, true}})
+	if got := fmt.Sprint(l.PinnedCells()); got != "[{0 0 true} {5 0 false}]" || !l.Alive(0, 0) || l.Population() != 6 {
+		t.Errorf("pinned cells %s once set, population %d", got, l.Population())
+	}
+}
+
+func TestPinSeed(t *testing.T) {
+	// Seeding keeps the pinned cells, and lays out the others as without them.
+	for _, mode := range []SeedMode{Random, Mirror, Rotational} {
+		plain, pinned := New(21, 21), New(21, 21)
+		for k := 0; k < 21; k++ {
+			pinned.Pin(k, 3, k%2 == 0)
+		}
+		plain.SeedWith(0.5, mode, 7)
+		pinned.SeedWith(0.5, mode, 7)
+		population := plain.Population()
+		for y := 0; y < 21; y++ {
+			for x := 0; x < 21; x++ {
+				switch {
+				case y == 3 && pinned.Alive(x, y) != (x%2 == 0):
+					t.Fatalf("%v: pinned cell %d,%d alive %v once seeded", mode, x, y, pinned.Alive(x, y))
+				case y == 3 && plain.Alive(x, y):
+					population--
+				case y != 3 && pinned.Alive(x, y) != plain.Alive(x, y):
+					t.Fatalf("%v: cell %d,%d seeded alive %v, %v without pinned cells", mode, x, y, pinned.Alive(x, y), plain.Alive(x, y))
+				}
+			}
+		}
+		if pinned.Population() != population+11 {
+			t.Errorf("%v: population %d, want %d", mode, pinned.Population(), population+11)
+		}
+	}
+}
+
+func TestPinMutation(t *testing.T) {
+	l := New(4, 4)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			l.Pin(x, y, x == y)
+		}
+	}
+	l.Mutation = Mutation{Every: 1, Cells: 5}
+	l.Step()
+	if l.Mutations() != 0 || l.Population() != 4 {
+		t.Errorf("%d pinned cells mutated, population %d", l.Mutations(), l.Population())
+	}
+}
+
+func TestPinAlgorithms(t *testing.T) {
+	// The dense, sparse and concurrent algorithms step the pinned cells alike.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	dense, sparse, bands := New(64, 48), NewSparse(64, 48), New(64, 48)
+	bands.ParallelCells = 1
+	for _, l := range []*Life{dense, sparse, bands} {
+		l.SeedWith(0.05, Random, 3)
+		for k := 0; k < 48; k++ {
+			l.Pin(20, k, false)
+			l.Pin(k, k/2, k%3 == 0)
+		}
+	}
+	for gen := 1; gen <= 60; gen++ {
+		for _, l := range []*Life{dense, sparse, bands} {
+			l.Step()
+		}
+		if diff := sameGame(dense, sparse); diff != "" {
+			t.Fatalf("sparse step differs by its %s at generation %d", diff, gen)
+		}
+		if diff := sameGame(dense, bands); diff != "" {
+			t.Fatalf("parallel step differs by its %s at generation %d", diff, gen)
+		}
+	}
+	if sparse.Algorithm() != "sparse" {
+		t.Errorf("sparse field stepped by the %s algorithm", sparse.Algorithm())
+	}
+}
+
+func TestPinReshape(t *testing.T) {
+	l := New(10, 10)
+	l.Edges = Bounded
+	l.Pin(2, 3, true)
+	l.Pin(9, 9, false)
+	l.Shift(1, 1)
+	if got := fmt.Sprint(l.PinnedCells()); got != "[{3 4 true}]" {
+		t.Errorf("pinned cells %s once shifted", got)
+	}
+	l.Resize(12, 12, 2, 1)
+	if got := fmt.Sprint(l.PinnedCells()); got != "[{5 5 true}]" {
+		t.Errorf("pinned cells %s once resized", got)
+	}
+	l.Resample(6, 6)
+	if got := fmt.Sprint(l.PinnedCells()); got != "[{2 2 true}]" {
+		t.Errorf("pinned cells %s once resampled", got)
+	}
+	if c := l.Clone(); !c.Pinned(2, 2) || c.pins != 1 {
+		t.Errorf("clone pinned %v", c.PinnedCells())
+	}
+}
@@ -0,0 +1,67 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import "sort"
+
+// Region returns the w*h rectangle of the field whose top-left corner is at (x, y) as a pattern of
+// the rule of l, keeping its dead cells around the alive ones. Cells outside the field, or its view,
+// are dead.
+func (l *Life) Region(x, y, w, h int) *Pattern {
+	p := &Pattern{W: w, H: h, Rule: l.Rule}
+	for cy := y; cy < y+h; cy++ {
+		for cx := x; cx < x+w; cx++ {
+			if cx >= 0 && cx < l.vw && cy >= 0 && cy < l.vh && l.Alive(cx, cy) {
+				p.Cells = append(p.Cells, [2]int{cx - x, cy - y})
+			}
+		}
+	}
+	return p
+}
+
+// Clear kills the cells of the w*h rectangle of the field whose top-left corner is at (x, y). Cells
+// falling outside the field, or its view, are clipped.
+func (l *Life) Clear(x, y, w, h int) {
+	for cy := y; cy < y+h; cy++ {
+		for cx := x; cx < x+w; cx++ {
+			if cx >= 0 && cx < l.vw && cy >= 0 && cy < l.vh {
+				l.Set(cx, cy, false)
+			}
+		}
+	}
+}
+
+// SetCells sets the state of each of cells, given as {x, y} pairs, as Set does, at once. Cells
+// falling outside the field, or its view, are clipped.
+func (l *Life) SetCells(cells [][2]int, alive bool) {
+	changed := false
+	for _, c := range cells {
+		if c[0] >= 0 && c[0] < l.vw && c[1] >= 0 && c[1] < l.vh && l.set(l.key(c[0], c[1]), alive) {
+			changed = true
+		}
+	}
+	if changed {
+		l.invalidate()
+		l.forget()
+	}
+}
+
+// Rotate returns p turned by a quarter clockwise.
+func (p *Pattern) Rotate() *Pattern {
+	q := &Pattern{W: p.H, H: p.W, Rule: p.Rule}
+	for _, c := range p.Cells {
+		q.Cells = append(q.Cells, [2]int{p.H - 1 - c[1], c[0]})
+	}
+	sort.Sort(rowMajor(q.Cells))
+	return q
+}
+
+// Flip returns p mirrored horizontally.
+func (p *Pattern) Flip() *Pattern {
+	q := &Pattern{W: p.W, H: p.H, Rule: p.Rule}
+	for _, c := range p.Cells {
+		q.Cells = append(q.Cells, [2]int{p.W - 1 - c[0], c[1]})
+	}
+	sort.Sort(rowMajor(q.Cells))
+	return q
+}
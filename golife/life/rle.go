@@ -0,0 +1,245 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A Pattern is a rectangle of cells, for instance a well-known Life object.
+type Pattern struct {
+	W, H int
+	// Cells holds the alive cells, as {x, y} pairs in row-major order.
+	Cells [][2]int
+	// Rule is the rule the pattern is meant for, the zero Rule if it does not tell.
+	Rule Rule
+}
+
+// maxPatternSize bounds the width and height of a parsed pattern, so that a corrupted header does
+// not exhaust memory.
+const maxPatternSize = 1 << 16
+
+// ParseRLE parses a pattern in the run length encoded format used by most Life software. Lines
+// starting with # are comments. The header line "x = <width>, y = <height>" may be followed by
+// ", rule = <rule>" in B/S notation. The cells follow, b being a dead cell, o an alive one and $ the
+// end of a row, each optionally preceded by a repeat count, up to the terminating !.
+func ParseRLE(r io.Reader) (*Pattern, error) {
+	sc := bufio.NewScanner(r)
+	line, header := 0, ""
+	for header == "" && sc.Scan() {
+		line++
+		if s := strings.TrimSpace(sc.Text()); s != "" && !strings.HasPrefix(s, "#") {
+			header = s
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if header == "" {
+		return nil, fmt.Errorf("life: invalid RLE: missing header")
+	}
+	p, err := parseRLEHeader(header)
+	if err != nil {
+		return nil, fmt.Errorf("life: invalid RLE: line %d: %v", line, err)
+	}
+
+	// n is the pending run count, counted reports whether there is one.
+	x, y, n, counted := 0, 0, 0, false
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(s, "#") {
+			continue
+		}
+		for _, c := range s {
+			switch {
+			case c >= '0' && c <= '9':
+				n, counted = 10*n+int(c-'0'), true
+				if n > maxPatternSize {
+					return nil, fmt.Errorf("life: invalid RLE: line %d: run count too large", line)
+				}
+				continue
+			case c == ' ' || c == '\t':
+				if counted {
+					return nil, fmt.Errorf("life: invalid RLE: line %d: run count not followed by a tag", line)
+				}
+				continue
+			case c == '!':
+				if counted {
+					return nil, fmt.Errorf("life: invalid RLE: line %d: run count not followed by a tag", line)
+				}
+				return p, nil
+			}
+			run := 1
+			if counted {
+				if n == 0 {
+					return nil, fmt.Errorf("life: invalid RLE: line %d: zero run count", line)
+				}
+				run = n
+			}
+			n, counted = 0, false
+			switch c {
+			case 'b':
+				x += run
+			case 'o':
+				if x+run > p.W || y >= p.H {
+					return nil, fmt.Errorf("life: invalid RLE: line %d: cells outside the %dx%d pattern", line, p.W, p.H)
+				}
+				for k := 0; k < run; k++ {
+					p.Cells = append(p.Cells, [2]int{x, y})
+					x++
+				}
+			case '$':
+				x, y = 0, y+run
+			default:
+				return nil, fmt.Errorf("life: invalid RLE: line %d: unexpected %q", line, c)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("life: invalid RLE: truncated input, missing !")
+}
+
+// parseRLEHeader parses the header line of an RLE pattern.
+func parseRLEHeader(s string) (*Pattern, error) {
+	p := &Pattern{W: -1, H: -1}
+	for _, f := range strings.Split(s, ",") {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("malformed header %q", s)
+		}
+		k, v := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch k {
+		case "x", "y":
+			d, err := strconv.Atoi(v)
+			if err != nil || d < 0 || d > maxPatternSize {
+				return nil, fmt.Errorf("invalid %s %q", k, v)
+			}
+			if k == "x" {
+				p.W = d
+			} else {
+				p.H = d
+			}
+		case "rule":
+			r, err := ParseRule(v)
+			if err != nil {
+				return nil, err
+			}
+			p.Rule = r
+		default:
+			return nil, fmt.Errorf("unknown header key %q", k)
+		}
+	}
+	if p.W < 0 || p.H < 0 {
+		return nil, fmt.Errorf("header %q lacks x or y", s)
+	}
+	return p, nil
+}
+
+// rleLineLength is the length RLE lines are wrapped at, as required by the format.
+const rleLineLength = 70
+
+// WriteRLE writes p in the run length encoded format read by ParseRLE. Dead cells at the end of
+// rows are left out, as are the rule if p does not tell.
+func (p *Pattern) WriteRLE(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if p.Rule == (Rule{}) {
+		fmt.Fprintf(bw, "x = %d, y = %d\n", p.W, p.H)
+	} else {
+		fmt.Fprintf(bw, "x = %d, y = %d, rule = %v\n", p.W, p.H, p.Rule)
+	}
+
+	// Tokens, a run count followed by a tag, are written whole on lines of at most rleLineLength.
+	col := 0
+	emit := func(run int, tag byte) {
+		tok := string(tag)
+		if run > 1 {
+			tok = strconv.Itoa(run) + tok
+		}
+		if col+len(tok) > rleLineLength {
+			bw.WriteByte('\n')
+			col = 0
+		}
+		bw.WriteString(tok)
+		col += len(tok)
+	}
+	// x, y is the position following the last token, alive the length of the pending run of alive
+	// cells.
+	x, y, alive := 0, 0, 0
+	for _, c := range p.Cells {
+		if alive > 0 && (c[1] != y || c[0] != x+alive) {
+			emit(alive, 'o')
+			x, alive = x+alive, 0
+		}
+		if alive == 0 {
+			if c[1] > y {
+				emit(c[1]-y, '$')
+				x, y = 0, c[1]
+			}
+			if c[0] > x {
+				emit(c[0]-x, 'b')
+				x = c[0]
+			}
+		}
+		alive++
+	}
+	if alive > 0 {
+		emit(alive, 'o')
+	}
+	emit(1, '!')
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+// Pattern returns the smallest rectangle of the field holding every alive cell, in view or not, as a
+// pattern of the rule of l. A field without alive cells gives an empty pattern.
+func (l *Life) Pattern() *Pattern {
+	x0, y0, x1, y1 := l.w, l.h, -1, -1
+	for y := 0; y < l.h; y++ {
+		for x := 0; x < l.w; x++ {
+			if !l.a.s[y*l.w+x] {
+				continue
+			}
+			if x < x0 {
+				x0 = x
+			}
+			if x > x1 {
+				x1 = x
+			}
+			if y < y0 {
+				y0 = y
+			}
+			y1 = y
+		}
+	}
+	p := &Pattern{Rule: l.Rule}
+	if x1 < 0 {
+		return p
+	}
+	p.W, p.H = x1-x0+1, y1-y0+1
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			if l.a.s[y*l.w+x] {
+				p.Cells = append(p.Cells, [2]int{x - x0, y - y0})
+			}
+		}
+	}
+	return p
+}
+
+// Stamp sets alive the cells of p, its top-left corner at (x, y). Cells falling outside the field,
+// or its view, are clipped.
+func (l *Life) Stamp(p *Pattern, x, y int) {
+	for _, c := range p.Cells {
+		cx, cy := x+c[0], y+c[1]
+		if cx >= 0 && cx < l.vw && cy >= 0 && cy < l.vh {
+			l.Set(cx, cy, true)
+		}
+	}
+}
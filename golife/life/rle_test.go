@@ -0,0 +1,140 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// gliderGun is the Gosper glider gun, as bundled with the app.
+const gliderGun = `#N Gosper glider gun
+#O Bill Gosper
+x = 36, y = 9, rule = B3/S23
+24bo$22bobo$12b2o6b2o12b2o$11bo3bo4b2o12b2o$2o8bo5bo3b2o$2o8bo3bob2o4b
+obo$10bo5bo7bo$11bo3bo$12b2o!
+`
+
+// samePattern reports whether the patterns p and q are the same.
+func samePattern(p, q *Pattern) bool {
+	return fmt.Sprint(*p) == fmt.Sprint(*q)
+}
+
+func TestParseRLE(t *testing.T) {
+	for _, test := range []struct {
+		name, rle string
+		want      *Pattern
+	}{
+		{
+			name: "glider",
+			rle:  "#C A glider\nx = 3, y = 3\nbo$2bo$3o!\n",
+			want: &Pattern{W: 3, H: 3, Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}},
+		},
+		{
+			name: "rule and spaces",
+			rle:  "x=2,y=2,rule=b36/s23\n2o $\n b o!",
+			want: &Pattern{W: 2, H: 2, Cells: [][2]int{{0, 0}, {1, 0}, {1, 1}}, Rule: Rule{Birth: 1<<3 | 1<<6, Survival: 1<<2 | 1<<3}},
+		},
+		{
+			name: "empty rows",
+			rle:  "x = 1, y = 4\no3$o!",
+			want: &Pattern{W: 1, H: 4, Cells: [][2]int{{0, 0}, {0, 3}}},
+		},
+		{
+			name: "empty",
+			rle:  "x = 0, y = 0\n!",
+			want: &Pattern{},
+		},
+	} {
+		p, err := ParseRLE(strings.NewReader(test.rle))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !samePattern(p, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, p, test.want)
+		}
+	}
+}
+
+func TestParseRLEInvalid(t *testing.T) {
+	for _, test := range []struct{ name, rle string }{
+		{"no header", ""},
+		{"comments only", "#N Nothing\n"},
+		{"malformed header", "x 3, y 3\n3o!"},
+		{"missing y", "x = 3\n3o!"},
+		{"negative width", "x = -3, y = 1\n3o!"},
+		{"width too large", "x = 70000, y = 1\n3o!"},
+		{"height too large", "x = 3, y = 70000\n3o!"},
+		{"unknown key", "x = 3, y = 1, z = 2\n3o!"},
+		{"invalid rule", "x = 3, y = 1, rule = B9/S23\n3o!"},
+		{"truncated", "x = 3, y = 3\nbo$2bo$3o"},
+		{"zero run count", "x = 3, y = 1\n0o3o!"},
+		{"run count too large", "x = 3, y = 1\n99999999o!"},
+		{"run count before a space", "x = 3, y = 1\n3 o!"},
+		{"run count before the end", "x = 3, y = 1\n3o2!"},
+		{"cells beyond the width", "x = 3, y = 1\n4o!"},
+		{"cells beyond the height", "x = 3, y = 1\n$o!"},
+		{"unknown tag", "x = 3, y = 1\n3x!"},
+	} {
+		if p, err := ParseRLE(strings.NewReader(test.rle)); err == nil {
+			t.Errorf("%s: parsed as %+v", test.name, p)
+		}
+	}
+}
+
+func TestRLERoundTrip(t *testing.T) {
+	gun, err := ParseRLE(strings.NewReader(gliderGun))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gun.W != 36 || gun.H != 9 || len(gun.Cells) != 36 || gun.Rule != Conway {
+		t.Fatalf("glider gun of %dx%d cells, %d alive, rule %v", gun.W, gun.H, len(gun.Cells), gun.Rule)
+	}
+	var b bytes.Buffer
+	if err := gun.WriteRLE(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if len(line) > rleLineLength {
+			t.Errorf("line %q over %d characters", line, rleLineLength)
+		}
+	}
+	again, err := ParseRLE(&b)
+	if err != nil {
+		t.Fatalf("parsing the written gun: %v", err)
+	}
+	if !samePattern(again, gun) {
+		t.Errorf("written gun parsed as %+v, want %+v", again, gun)
+	}
+}
+
+func TestGliderGun(t *testing.T) {
+	gun, _ := ParseRLE(strings.NewReader(gliderGun))
+	l := New(60, 40)
+	l.Edges = Bounded
+	l.Stamp(gun, 1, 1)
+	// The gun has a period of 30 generations, each firing a glider of 5 cells.
+	for k := 0; k < 60; k++ {
+		l.Step()
+	}
+	if l.Population() != len(gun.Cells)+2*5 {
+		t.Errorf("population %d after firing two gliders, want %d", l.Population(), len(gun.Cells)+2*5)
+	}
+}
+
+func TestStampPattern(t *testing.T) {
+	p := &Pattern{W: 3, H: 1, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}}}
+	l := New(4, 2)
+	// Clipped by the right edge.
+	l.Stamp(p, 2, 1)
+	if got := strings.Join(picture(l), "|"); got != "....|..OO" {
+		t.Errorf("stamped as %s", got)
+	}
+	l.Set(0, 0, true)
+	if got := l.Pattern(); got.W != 4 || got.H != 2 || len(got.Cells) != 3 {
+		t.Errorf("Pattern() = %+v", got)
+	}
+}
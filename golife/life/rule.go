@@ -0,0 +1,109 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Rule tells the fate of a cell from its number of alive neighbors. Bit n of Birth is set if a
+// dead cell with n alive neighbors becomes alive, bit n of Survival if an alive cell with n alive
+// neighbors stays alive.
+//
+// Rules of the Generations family, such as Brian's Brain, have more than two States: an alive cell
+// that does not survive decays through the dying states, one per step, before it is dead. Dying
+// cells do not count as alive neighbors, and no cell is born on them.
+type Rule struct {
+	Birth, Survival uint16
+	// States is the number of states of the cells, dead, alive and dying ones, up to MaxStates. 0
+	// is 2 as in Life-like rules, which have no dying states.
+	States int
+}
+
+// MaxStates is the largest number of states of the cells of a rule, see Rule.States.
+const MaxStates = 256
+
+// Conway is the rule of Conway's Game of Life, B3/S23.
+var Conway = Rule{Birth: 1 << 3, Survival: 1<<2 | 1<<3}
+
+// ParseRule parses a rule in B/S notation, for instance "B36/S23" for HighLife, or in B/S/C
+// notation for the Generations family, for instance "B2/S/C3" for Brian's Brain, C telling the
+// number of states. The letters are case insensitive and the parts may come in either order, but B
+// and S are required. C2 is the same as no C part.
+func ParseRule(s string) (Rule, error) {
+	var r Rule
+	parts := strings.Split(strings.ToUpper(s), "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return Rule{}, fmt.Errorf("life: invalid rule %q: want B<digits>/S<digits>[/C<states>]", s)
+	}
+	var seen [3]bool
+	for _, p := range parts {
+		var mask *uint16
+		switch {
+		case strings.HasPrefix(p, "B") && !seen[0]:
+			mask, seen[0] = &r.Birth, true
+		case strings.HasPrefix(p, "S") && !seen[1]:
+			mask, seen[1] = &r.Survival, true
+		case strings.HasPrefix(p, "C") && !seen[2]:
+			seen[2] = true
+			n, err := strconv.Atoi(p[1:])
+			if err != nil || n < 2 || n > MaxStates {
+				return Rule{}, fmt.Errorf("life: invalid rule %q: states %q not in 2-%d", s, p[1:], MaxStates)
+			}
+			if n > 2 {
+				r.States = n
+			}
+			continue
+		default:
+			return Rule{}, fmt.Errorf("life: invalid rule %q: want B<digits>/S<digits>[/C<states>]", s)
+		}
+		for _, c := range p[1:] {
+			if c < '0' || c > '8' {
+				return Rule{}, fmt.Errorf("life: invalid rule %q: neighbor count %q not in 0-8", s, c)
+			}
+			*mask |= 1 << uint(c-'0')
+		}
+	}
+	if !seen[0] || !seen[1] {
+		return Rule{}, fmt.Errorf("life: invalid rule %q: want B<digits>/S<digits>[/C<states>]", s)
+	}
+	return r, nil
+}
+
+// String returns r in B/S notation, or in B/S/C notation if it has dying states.
+func (r Rule) String() string {
+	b := []byte{'B'}
+	for n := uint(0); n <= 8; n++ {
+		if r.Birth&(1<<n) != 0 {
+			b = append(b, byte('0'+n))
+		}
+	}
+	b = append(b, '/', 'S')
+	for n := uint(0); n <= 8; n++ {
+		if r.Survival&(1<<n) != 0 {
+			b = append(b, byte('0'+n))
+		}
+	}
+	if r.States > 2 {
+		b = append(b, "/C"+strconv.Itoa(r.States)...)
+	}
+	return string(b)
+}
+
+// states returns the number of states of the cells, at least 2.
+func (r Rule) states() int {
+	if r.States < 2 {
+		return 2
+	}
+	return r.States
+}
+
+// next returns the state of a cell with the given number of alive neighbors at the next time step.
+func (r Rule) next(alive bool, neighbors int) bool {
+	if alive {
+		return r.Survival&(1<<uint(neighbors)) != 0
+	}
+	return r.Birth&(1<<uint(neighbors)) != 0
+}
@@ -0,0 +1,126 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	for _, test := range []struct {
+		s    string
+		want Rule
+		str  string // String of the rule, s if empty.
+	}{
+		{s: "B3/S23", want: Conway},
+		{s: "B36/S23", want: Rule{Birth: 1<<3 | 1<<6, Survival: 1<<2 | 1<<3}},
+		{s: "B3/S012345678", want: Rule{Birth: 1 << 3, Survival: 1<<9 - 1}},
+		{s: "b3/s23", want: Conway, str: "B3/S23"},
+		{s: "S23/B3", want: Conway, str: "B3/S23"},
+		{s: "B/S", want: Rule{}},
+		{s: "B2/S/C3", want: Rule{Birth: 1 << 2, States: 3}},
+		{s: "B3/S23/C2", want: Conway, str: "B3/S23"},
+		{s: "C4/B34/S345", want: Rule{Birth: 1<<3 | 1<<4, Survival: 1<<3 | 1<<4 | 1<<5, States: 4}, str: "B34/S345/C4"},
+	} {
+		r, err := ParseRule(test.s)
+		if err != nil {
+			t.Errorf("ParseRule(%q): %v", test.s, err)
+			continue
+		}
+		if r != test.want {
+			t.Errorf("ParseRule(%q) = %+v, want %+v", test.s, r, test.want)
+		}
+		str := test.str
+		if str == "" {
+			str = test.s
+		}
+		if r.String() != str {
+			t.Errorf("ParseRule(%q).String() = %q, want %q", test.s, r.String(), str)
+		}
+	}
+}
+
+func TestParseRuleInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"B3S23",
+		"B9/S23",
+		"B3/S29",
+		"B3/B23",
+		"B3/X23",
+		"23/3",
+		"B3/S23/",
+		"B3/S23/C1",
+		"B3/S23/C257",
+		"B3/S23/C3/C4",
+		"B3/S23/Cx",
+		"B3",
+	} {
+		if r, err := ParseRule(s); err == nil {
+			t.Errorf("ParseRule(%q) = %v, want an error", s, r)
+		}
+	}
+}
+
+func TestDefaultRule(t *testing.T) {
+	if r := New(3, 3).Rule; r != Conway || r.String() != "B3/S23" {
+		t.Errorf("default rule %v, want B3/S23", r)
+	}
+}
+
+func TestHighLife(t *testing.T) {
+	// The replicator of HighLife, which copies itself thanks to the births of six neighbors.
+	seed := []string{
+		"................",
+		"................",
+		"................",
+		"................",
+		"................",
+		"........OOO.....",
+		".......O..O.....",
+		"......O...O.....",
+		"......O..O......",
+		"......OOO.......",
+		"................",
+		"................",
+		"................",
+		"................",
+		"................",
+		"................",
+	}
+	highLife, _ := ParseRule("B36/S23")
+	conway, other := newGame(seed...), newGame(seed...)
+	other.Rule = highLife
+	for k := 0; k < 12; k++ {
+		conway.Step()
+		other.Step()
+	}
+	if strings.Join(picture(conway), "\n") == strings.Join(picture(other), "\n") {
+		t.Fatalf("the replicator evolves the same under HighLife and Life")
+	}
+	// After 12 generations, the replicator of HighLife has made two copies of itself, diagonally
+	// apart.
+	want := []string{
+		"................",
+		"................",
+		"................",
+		"......OOO.......",
+		".....O..O.......",
+		"....O...O.......",
+		"....O..O........",
+		"....OOO...OOO...",
+		".........O..O...",
+		"........O...O...",
+		"........O..O....",
+		"........OOO.....",
+		"................",
+		"................",
+		"................",
+		"................",
+	}
+	if got := picture(other); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("replicator after 12 generations under HighLife:\n%s\nwant\n%s", strings.Join(got, "\n"),
+			strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,206 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// RunLinkPrefix starts the links to runs made by Run.Link.
+const RunLinkPrefix = "golife://run/"
+
+// runVersion is the first value of the encoding of a run in a link. It must change whenever the
+// encoding does, ParseRunLink going on decoding the former ones so that old links keep working.
+const runVersion = 2
+
+// A Run is a game that can be replayed exactly from generation 0: a field of the given size seeded
+// at random, see SeedWith, then edited by hand, stepped under a rule and edge mode, and mutated as
+// told by its Mutation. Stepping is deterministic, so nothing else is needed to reproduce its
+// evolution.
+type Run struct {
+	W, H   int
+	Rule   Rule
+	Edges  EdgeMode
+	Colors int
+	// The random cells are drawn as told by SeedWith with these arguments, none if Seed is 0.
+	Density float64
+	Mode    SeedMode
+	Seed    int64
+	// Edits holds the cells toggled by hand once seeded, as {x, y} pairs in row-major order.
+	Edits    [][2]int
+	Mutation Mutation
+}
+
+// NewRun returns the run of l, a game at generation 0 whose random cells, if seed is not 0, were
+// drawn as told by SeedWith with the given arguments: how it differs from them are its edits.
+func NewRun(l *Life, density float64, mode SeedMode, seed int64) *Run {
+	w, h := l.Bounds()
+	r := &Run{
+		W: w, H: h, Rule: l.Rule, Edges: l.Edges, Colors: l.Colors,
+		Density: density, Mode: mode, Seed: seed, Mutation: l.Mutation,
+	}
+	seeded := r.seeded()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if l.Alive(x, y) != seeded.Alive(x, y) {
+				r.Edits = append(r.Edits, [2]int{x, y})
+			}
+		}
+	}
+	return r
+}
+
+// seeded returns the game of r at generation 0 before its edits.
+func (r *Run) seeded() *Life {
+	l := New(r.W, r.H)
+	l.Rule, l.Edges, l.Colors, l.Mutation = r.Rule, r.Edges, r.Colors, r.Mutation
+	if r.Seed != 0 {
+		l.SeedWith(r.Density, r.Mode, r.Seed)
+	}
+	return l
+}
+
+// Life returns the game of r at generation 0, edits included.
+func (r *Run) Life() *Life {
+	l := r.seeded()
+	for _, c := range r.Edits {
+		l.Set(c[0], c[1], !l.Alive(c[0], c[1]))
+	}
+	return l
+}
+
+// Link returns r as a golife:// link, a compact text to share it, see Pattern.Link. After the
+// version, the size, the rule, the edge mode, the number of colors and, since version 2, the
+// mutation, the density is stored as the bits of the float64, followed by the seed mode, the seed
+// and the number of edits, then each edit as the number of cells since the previous one. Values are
+// varints, compressed with DEFLATE and in unpadded URL-safe base64 after RunLinkPrefix.
+func (r *Run) Link() (string, error) {
+	var b []byte
+	put := func(v uint64) {
+		var buf [binary.MaxVarintLen64]byte
+		b = append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+	}
+	m := r.Mutation
+	if r.W < 1 || r.H < 1 || r.Seed < 0 || m.Every < 0 || m.Cells < 0 || m.Seed < 0 {
+		return "", fmt.Errorf("life: invalid run: %dx%d cells, seed %d, mutation %+v", r.W, r.H, r.Seed, m)
+	}
+	for _, v := range []int{runVersion, r.W, r.H, int(r.Rule.Birth), int(r.Rule.Survival), r.Rule.States,
+		int(r.Edges), r.Colors, m.Every, m.Cells} {
+		put(uint64(v))
+	}
+	put(uint64(m.Seed))
+	put(math.Float64bits(r.Density))
+	put(uint64(r.Mode))
+	put(uint64(r.Seed))
+	put(uint64(len(r.Edits)))
+	last := -1
+	for _, c := range r.Edits {
+		k := c[1]*r.W + c[0]
+		if c[0] < 0 || c[0] >= r.W || k >= r.W*r.H || k <= last {
+			return "", fmt.Errorf("life: invalid run: edit %v out of the field or of order", c)
+		}
+		put(uint64(k - last - 1))
+		last = k
+	}
+	return encodeLink(RunLinkPrefix, b)
+}
+
+// ParseRunLink parses a link made by Run.Link, of any version so far. Surrounding spaces are
+// ignored.
+func ParseRunLink(s string) (*Run, error) {
+	b, err := decodeLink(RunLinkPrefix, s)
+	if err != nil {
+		return nil, err
+	}
+	data := bytes.NewReader(b)
+	get := func(limit uint64) (int, error) {
+		v, err := binary.ReadUvarint(data)
+		if err != nil {
+			return 0, errors.New("life: invalid link: truncated run")
+		}
+		if v > limit {
+			return 0, fmt.Errorf("life: invalid link: value %d out of range [0, %d]", v, limit)
+		}
+		return int(v), nil
+	}
+	version, err := get(math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 || version > runVersion {
+		return nil, fmt.Errorf("life: invalid link: unknown run version %d", version)
+	}
+	var hdr [7]int
+	for k, limit := range []uint64{maxPatternSize, maxPatternSize, 1<<9 - 1, 1<<9 - 1, MaxStates, uint64(Grow), MaxColors} {
+		if hdr[k], err = get(limit); err != nil {
+			return nil, err
+		}
+	}
+	r := &Run{
+		W:      hdr[0],
+		H:      hdr[1],
+		Rule:   Rule{Birth: uint16(hdr[2]), Survival: uint16(hdr[3]), States: hdr[4]},
+		Edges:  EdgeMode(hdr[5]),
+		Colors: hdr[6],
+	}
+	if r.W < 1 || r.H < 1 || r.W*r.H > maxEncodedCells {
+		return nil, fmt.Errorf("life: invalid link: run of %dx%d cells", r.W, r.H)
+	}
+	if version >= 2 {
+		m := &r.Mutation
+		if m.Every, err = get(math.MaxInt32); err != nil {
+			return nil, err
+		}
+		if m.Cells, err = get(maxEncodedCells); err != nil {
+			return nil, err
+		}
+		seed, err := get(1<<63 - 1)
+		if err != nil {
+			return nil, err
+		}
+		m.Seed = int64(seed)
+	}
+	bits, err := binary.ReadUvarint(data)
+	if err != nil {
+		return nil, errors.New("life: invalid link: truncated run")
+	}
+	if r.Density = math.Float64frombits(bits); !(r.Density >= 0 && r.Density <= 1) {
+		return nil, fmt.Errorf("life: invalid link: density %v out of range [0, 1]", r.Density)
+	}
+	mode, err := get(uint64(Rotational))
+	if err != nil {
+		return nil, err
+	}
+	r.Mode = SeedMode(mode)
+	seed, err := get(1<<63 - 1)
+	if err != nil {
+		return nil, err
+	}
+	r.Seed = int64(seed)
+	// Each edit takes at least a byte.
+	n, err := get(uint64(data.Len()))
+	if err != nil {
+		return nil, err
+	}
+	r.Edits = make([][2]int, n)
+	for k, last := 0, -1; k < n; k++ {
+		left := r.W*r.H - last - 2
+		if left < 0 {
+			return nil, errors.New("life: invalid link: edit out of the field")
+		}
+		d, err := get(uint64(left))
+		if err != nil {
+			return nil, err
+		}
+		last += d + 1
+		r.Edits[k] = [2]int{last % r.W, last / r.W}
+	}
+	if data.Len() != 0 {
+		return nil, errors.New("life: invalid link: trailing data")
+	}
+	return r, nil
+}
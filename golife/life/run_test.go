@@ -0,0 +1,98 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"strings"
+	"testing"
+)
+
+// edited returns a game of 30x20 cells seeded with the given seed, 0 for none, then edited.
+func edited(seed int64) *Life {
+	l := New(30, 20)
+	l.Edges, l.Colors = Reflect, 2
+	l.Rule, _ = ParseRule("B36/S23")
+	if seed != 0 {
+		l.SeedWith(0.35, Mirror, seed)
+	}
+	l.Stamp(glider, 3, 4)
+	l.Set(29, 19, !l.Alive(29, 19))
+	l.Set(0, 0, !l.Alive(0, 0))
+	return l
+}
+
+func TestRunRoundTrip(t *testing.T) {
+	for _, seed := range []int64{0, 7, 1 << 40} {
+		l := edited(seed)
+		l.Mutation = Mutation{Every: 4, Cells: 3, Seed: seed + 1}
+		s, err := NewRun(l, 0.35, Mirror, seed).Link()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(s, RunLinkPrefix) {
+			t.Errorf("seed %d: link %q", seed, s)
+		}
+		r, err := ParseRunLink(" " + s + "\n")
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if r.Seed != seed || r.Density != 0.35 || r.Mode != Mirror || r.Colors != 2 || r.Edges != Reflect ||
+			r.Mutation != l.Mutation {
+			t.Errorf("seed %d: parsed as %+v", seed, r)
+		}
+		// The run evolves as the game it was made of.
+		g := r.Life()
+		for step := 0; step < 30; step++ {
+			if d := sameGame(g, l); d != "" {
+				t.Fatalf("seed %d: run differs at step %d in %s", seed, step, d)
+			}
+			g.Step()
+			l.Step()
+		}
+	}
+	// Only the edits made once seeded are stored.
+	if r := NewRun(edited(7), 0.35, Mirror, 7); len(r.Edits) > 7 {
+		t.Errorf("%d edits, want at most 7", len(r.Edits))
+	}
+}
+
+// runV1 is a link made by the first version of Run.Link, of the run of edited(7).
+const runV1 = "golife://run/YpQT8eBhYGJ6dmbm5mdnZr62Z2RnZai2YpjHDBgA"
+
+func TestRunLinkVersions(t *testing.T) {
+	r, err := ParseRunLink(runV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := sameGame(r.Life(), edited(7)); d != "" {
+		t.Errorf("old link parsed as a game differing in %s", d)
+	}
+}
+
+func TestParseRunLinkInvalid(t *testing.T) {
+	header := "\x01\x1e\x14\x48\x0c\x00\x01\x02"
+	for _, test := range []struct{ name, link string }{
+		{"pattern link", link(t, "x = 3, y = 1\n3o!")},
+		{"no prefix", strings.TrimPrefix(runV1, RunLinkPrefix)},
+		{"not base64", RunLinkPrefix + "a*b"},
+		{"unknown version", runLink(t, "\x03"+header[1:])},
+		{"empty field", runLink(t, "\x01\x00\x14"+header[3:])},
+		{"truncated", runLink(t, header)},
+		{"bad density", runLink(t, header+"\x80\x80\x80\x80\x80\x80\x80\xf8\x7f\x00\x00\x00")},
+		{"edit out of the field", runLink(t, header+"\x00\x00\x00\x01\xd8\x04")},
+		{"trailing data", runLink(t, header+"\x00\x00\x00\x00\x00")},
+	} {
+		if r, err := ParseRunLink(test.link); err == nil {
+			t.Errorf("%s: parsed as %+v", test.name, r)
+		}
+	}
+}
+
+// runLink returns the run link of the given encoding, which need not be valid.
+func runLink(t *testing.T, data string) string {
+	s, err := encodeLink(RunLinkPrefix, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
@@ -0,0 +1,158 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// soupStopEvery is the number of generations between the checks of a request to stop a search.
+const soupStopEvery = 64
+
+// A Soup is the outcome of a random field played by a SoupSearch.
+type Soup struct {
+	Seed int64
+	// Lifespan is the generation the soup stabilized at, or the number of generations played if it
+	// did not, and Period the period of the soup once stable, 0 if it did not stabilize.
+	Lifespan, Period int
+	Initial, Peak    int // Population of the random field, and the largest.
+	Escaped          int // Alive cells out of the view of the field once over, see Grow.
+}
+
+// Methuselah returns the generations s lived per cell of its random field, which is high for the
+// small soups living long.
+func (s Soup) Methuselah() float64 {
+	if s.Initial == 0 {
+		return 0
+	}
+	return float64(s.Lifespan) / float64(s.Initial)
+}
+
+// SoupOrders are the orders of soups by the criteria searches rank them by, the best first: the
+// longest to stabilize, the highest peak population, the longest period once stable, the
+// longest-lived for their initial population, as methuselahs, and the most cells escaped.
+var SoupOrders = map[string]func(a, b Soup) bool{
+	"lifespan":   func(a, b Soup) bool { return a.Lifespan > b.Lifespan },
+	"peak":       func(a, b Soup) bool { return a.Peak > b.Peak },
+	"period":     func(a, b Soup) bool { return a.Period > b.Period },
+	"methuselah": func(a, b Soup) bool { return a.Methuselah() > b.Methuselah() },
+	"escaped":    func(a, b Soup) bool { return a.Escaped > b.Escaped },
+}
+
+// SortSoups sorts s by less, one of SoupOrders, then by seed.
+func SortSoups(s []Soup, less func(a, b Soup) bool) {
+	sort.Sort(soups{s, less})
+}
+
+// soups sorts soups by less, then by seed.
+type soups struct {
+	s    []Soup
+	less func(a, b Soup) bool
+}
+
+func (s soups) Len() int      { return len(s.s) }
+func (s soups) Swap(i, j int) { s.s[i], s.s[j] = s.s[j], s.s[i] }
+func (s soups) Less(i, j int) bool {
+	a, b := s.s[i], s.s[j]
+	if s.less(a, b) || s.less(b, a) {
+		return s.less(a, b)
+	}
+	return a.Seed < b.Seed
+}
+
+// A SoupSearch plays random fields, numbered by their seed, without showing them, to find the
+// interesting ones. The fields are seeded by SeedWith, so that the soup of a seed can be played
+// again on a field of the same size and settings.
+type SoupSearch struct {
+	// Field returns a field of dead cells to seed, a new one every call.
+	Field       func() *Life
+	Density     float64
+	Mode        SeedMode
+	Generations int // Generations each soup is played for at most.
+	// Workers is the number of soups played concurrently, 0 for one per processor usable by
+	// goroutines.
+	Workers int
+	// Stop, if not nil, stops the search once closed.
+	Stop <-chan struct{}
+}
+
+// Run plays the soups of the seeds from first, n of them, and returns the outcomes of those played
+// until done or stopped, in no order.
+func (s *SoupSearch) Run(first int64, n int) []Soup {
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	seeds := make(chan int64)
+	found := make(chan Soup)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seed := range seeds {
+				if o, ok := s.Play(seed); ok {
+					found <- o
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(seeds)
+		for seed := first; seed < first+int64(n); seed++ {
+			select {
+			case seeds <- seed:
+			case <-s.Stop:
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+	var all []Soup
+	for o := range found {
+		all = append(all, o)
+	}
+	return all
+}
+
+// Play plays the soup of the given seed and returns its outcome, unless the search was stopped
+// meanwhile.
+func (s *SoupSearch) Play(seed int64) (Soup, bool) {
+	l := s.Field()
+	l.SeedWith(s.Density, s.Mode, seed)
+	o := Soup{Seed: seed, Lifespan: s.Generations, Initial: l.Population(), Peak: l.Population()}
+	for g := 1; g <= s.Generations; g++ {
+		if g%soupStopEvery == 0 {
+			select {
+			case <-s.Stop:
+				return o, false
+			default:
+			}
+		}
+		l.Step()
+		if n := l.Population(); n > o.Peak {
+			o.Peak = n
+		}
+		if q := l.Period(); q > 0 {
+			o.Lifespan, o.Period = l.Generation()-q, q
+			break
+		}
+	}
+	if l.Edges == Grow {
+		o.Escaped = l.Population()
+		w, h := l.Bounds()
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				if l.Alive(x, y) {
+					o.Escaped--
+				}
+			}
+		}
+	}
+	return o, true
+}
@@ -0,0 +1,77 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSoupSearch(t *testing.T) {
+	stop := make(chan struct{})
+	s := &SoupSearch{
+		Field:       func() *Life { return NewSparse(16, 16) },
+		Density:     0.3,
+		Generations: 200,
+		Stop:        stop,
+	}
+	// The same soups whatever the number of workers.
+	s.Workers = 1
+	one := s.Run(1, 20)
+	s.Workers = 4
+	many := s.Run(1, 20)
+	for _, found := range [][]Soup{one, many} {
+		SortSoups(found, SoupOrders["lifespan"])
+	}
+	if len(one) != 20 || fmt.Sprint(one) != fmt.Sprint(many) {
+		t.Fatalf("soups searched by 1 worker %v, by 4 %v", one, many)
+	}
+	for k, o := range one {
+		if k > 0 && o.Lifespan > one[k-1].Lifespan {
+			t.Errorf("soup %d of lifespan %d ranked after one of %d", k, o.Lifespan, one[k-1].Lifespan)
+		}
+		// The soups that did not stabilize played every generation.
+		if (o.Period == 0) != (o.Lifespan == s.Generations) || o.Peak < o.Initial || o.Escaped != 0 {
+			t.Errorf("soup %+v", o)
+		}
+	}
+
+	// Stopped, only the soups stable before the first check are played.
+	close(stop)
+	for _, o := range s.Run(1, 20) {
+		if o.Period == 0 || o.Lifespan+o.Period >= soupStopEvery {
+			t.Errorf("soup %+v played once stopped", o)
+		}
+	}
+}
+
+func TestSoupEscaped(t *testing.T) {
+	// Some soups of a field growing beyond its view send cells out of it, gliders for instance.
+	s := &SoupSearch{
+		Field: func() *Life {
+			l := NewSparse(16, 16)
+			l.Edges = Grow
+			return l
+		},
+		Density:     0.3,
+		Generations: 300,
+	}
+	escaped := 0
+	for _, o := range s.Run(1, 20) {
+		escaped += o.Escaped
+	}
+	if escaped == 0 {
+		t.Errorf("no cells escaped from 20 soups of a growing field")
+	}
+}
+
+func TestSortSoups(t *testing.T) {
+	s := []Soup{{Seed: 3, Peak: 5}, {Seed: 1, Peak: 2}, {Seed: 2, Peak: 5}}
+	SortSoups(s, SoupOrders["peak"])
+	if s[0].Seed != 2 || s[1].Seed != 3 || s[2].Seed != 1 {
+		t.Errorf("soups sorted by peak %v", s)
+	}
+	if (Soup{Lifespan: 10, Initial: 4}).Methuselah() != 2.5 || (Soup{}).Methuselah() != 0 {
+		t.Errorf("methuselah scores wrong")
+	}
+}
@@ -0,0 +1,147 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import "sort"
+
+// sparse holds the state of the sparse Step algorithm, which only visits the alive cells and their
+// neighbors.
+type sparse struct {
+	live    []int   // Indexes of the alive cells, sorted.
+	stale   bool    // Whether live must be rebuilt from the field.
+	count   []uint8 // Number of alive neighbors of each cell, only valid for the touched ones.
+	touched []int   // Indexes of the cells with a non-zero count.
+}
+
+// sparseRatio is the ratio of cells to alive cells above which the sparse algorithm is used, below
+// which visiting every cell is faster.
+const sparseRatio = 16
+
+// NewSparse returns a new Life game state like New, whose Step is faster for mostly dead fields:
+// its time depends on the number of alive cells rather than on the size of the field. Fields with
+// more than one alive cell out of sparseRatio, and rules under which dead cells without alive
+// neighbors are born (B0), are stepped as by New.
+func NewSparse(w, h int) *Life {
+	l := New(w, h)
+	l.sparse = &sparse{stale: true}
+	return l
+}
+
+// invalidate tells the sparse algorithm, if used, that cells changed outside of Step.
+func (l *Life) invalidate() {
+	if l.sparse != nil {
+		l.sparse.stale = true
+	}
+}
+
+// stepSparse is Step for games created by NewSparse.
+func (l *Life) stepSparse() {
+	sp := l.sparse
+	if sp.stale {
+		sp.live = sp.live[:0]
+		for k, alive := range l.a.s {
+			if alive {
+				sp.live = append(sp.live, k)
+			}
+		}
+		if len(sp.count) != l.w*l.h {
+			sp.count = make([]uint8, l.w*l.h)
+		}
+		sp.stale = false
+	}
+
+	// Count the alive neighbors of the cells around the alive ones.
+	// Each alive cell counts for the cells that see it as a neighbor, found by reversing the offsets.
+	// In Reflect mode, this also finds the cells of an edge that see themselves beyond it, see
+	// field.index.
+	for _, k := range sp.live {
+		x, y := k%l.w, k/l.w
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx == 0 && dy == 0 {
+					continue
+				}
+				n, ok := l.a.index(x-dx, y-dy, l.Edges)
+				if !ok {
+					continue
+				}
+				if sp.count[n] == 0 {
+					sp.touched = append(sp.touched, n)
+				}
+				sp.count[n]++
+			}
+		}
+	}
+
+	// The only cells that may change are the alive ones and their neighbors, unless pinned. Changes
+	// are applied in row-major order, as by the dense algorithm.
+	var changes []int
+	for _, k := range sp.live {
+		if !l.pinned[k] && !l.Rule.next(true, int(sp.count[k])) {
+			changes = append(changes, k)
+		}
+	}
+	for _, k := range sp.touched {
+		if !l.a.s[k] && !l.pinned[k] && l.Rule.next(false, int(sp.count[k])) {
+			// The colors of the parents, before any cell changes.
+			l.color[k] = l.birthColor(k%l.w, k/l.w)
+			changes = append(changes, k)
+		}
+	}
+	sort.Ints(changes)
+	for _, k := range sp.touched {
+		sp.count[k] = 0
+	}
+	sp.touched = sp.touched[:0]
+
+	for _, k := range sp.live {
+		if l.age[k] < 1<<16-1 {
+			l.age[k]++
+		}
+	}
+	for _, k := range changes {
+		l.hash ^= cellKey(k)
+		l.a.s[k] = !l.a.s[k]
+		l.cycles.moved(k%l.w, k/l.w, l.a.s[k])
+		if l.a.s[k] {
+			l.age[k] = 0
+			l.population++
+			l.pops[l.color[k]]++
+		} else {
+			l.population--
+			l.pops[l.color[k]]--
+		}
+		l.notify(k%l.w, k/l.w, l.a.s[k])
+	}
+	// The cells that survived, still alive, are told of their ages once the deaths are.
+	if al, marks := l.ageMarks(); al != nil {
+		for _, k := range sp.live {
+			if l.a.s[k] {
+				l.tellAge(k, al, marks)
+			}
+		}
+	}
+	l.changed = len(changes)
+	l.generation++
+	l.stepped()
+
+	// Merge the survivors and the births into the sorted list of alive cells.
+	live := make([]int, 0, l.population)
+	for i, j := 0, 0; i < len(sp.live) || j < len(changes); {
+		switch {
+		case j == len(changes) || i < len(sp.live) && sp.live[i] < changes[j]:
+			live = append(live, sp.live[i])
+			i++
+		case i == len(sp.live) || changes[j] < sp.live[i]:
+			if l.a.s[changes[j]] {
+				live = append(live, changes[j])
+			}
+			j++
+		default:
+			// A death.
+			i++
+			j++
+		}
+	}
+	sp.live = live
+}
@@ -0,0 +1,117 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package life
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+// TestSparseStep steps random soups with the sparse and dense algorithms, which must agree on every
+// generation, including edits between steps.
+func TestSparseStep(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	highLife, _ := ParseRule("B36/S23")
+	for trial := 0; trial < 40; trial++ {
+		w, h := 5+r.Intn(60), 5+r.Intn(60)
+		dense, sparse := New(w, h), NewSparse(w, h)
+		density := []float64{0.01, 0.03, 0.06, 0.3}[r.Intn(4)]
+		seed := r.Int63()
+		for _, l := range []*Life{dense, sparse} {
+			l.Edges = EdgeMode(r.Intn(3))
+			if trial%4 == 0 {
+				l.Rule = highLife
+			}
+			if trial%5 == 0 {
+				l.Colors = 2
+			}
+		}
+		sparse.Edges = dense.Edges
+		dense.SeedWith(density, Random, seed)
+		sparse.SeedWith(density, Random, seed)
+		for gen := 1; gen <= 50; gen++ {
+			if gen%17 == 0 {
+				x, y := r.Intn(w), r.Intn(h)
+				dense.Set(x, y, true)
+				sparse.Set(x, y, true)
+			}
+			dense.Step()
+			sparse.Step()
+			if diff := sameGame(dense, sparse); diff != "" {
+				t.Fatalf("trial %d, %dx%d at density %v: sparse step differs by its %s at generation %d",
+					trial, w, h, density, diff, gen)
+			}
+			if dense.Changed() != sparse.Changed() {
+				t.Fatalf("trial %d: %d cells changed by the dense step, %d by the sparse one", trial,
+					dense.Changed(), sparse.Changed())
+			}
+		}
+	}
+}
+
+func TestAlgorithm(t *testing.T) {
+	l := NewSparse(64, 64)
+	l.ParallelCells = 64*64 + 1
+	l.Stamp(glider, 10, 10)
+	if a := l.Algorithm(); a != "sparse" {
+		t.Errorf("algorithm %q for a glider, want sparse", a)
+	}
+	// Busy fields, and B0 rules, are stepped densely, in bands if large enough.
+	l.SeedWith(0.5, Random, 1)
+	if a := l.Algorithm(); a != "dense" {
+		t.Errorf("algorithm %q for a soup, want dense", a)
+	}
+	l.ParallelCells = 1
+	want := "bands"
+	if runtime.GOMAXPROCS(0) == 1 {
+		want = "dense"
+	}
+	if a := l.Algorithm(); a != want {
+		t.Errorf("algorithm %q for a soup in bands, want %s", a, want)
+	}
+	if a := New(64, 64).Algorithm(); a != "dense" {
+		t.Errorf("algorithm %q without the sparse algorithm, want dense", a)
+	}
+}
+
+// benchmarkGliders steps a 1000*1000 torus with a glider every 20*20 cells, 1.25% of alive cells
+// which keep moving without ever colliding, with the sparse algorithm or not.
+func benchmarkGliders(b *testing.B, sparse bool) {
+	l := New(1000, 1000)
+	if sparse {
+		l = NewSparse(1000, 1000)
+	}
+	l.ParallelCells = 1000*1000 + 1
+	for y := 0; y < 1000; y += 20 {
+		for x := 0; x < 1000; x += 20 {
+			l.Stamp(glider, x, y)
+		}
+	}
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		l.Step()
+	}
+}
+
+// benchmarkSoup steps busy soups of a 64*64 torus, seeded again every 16 steps so that they do not
+// die out, with the sparse algorithm or not.
+func benchmarkSoup(b *testing.B, sparse bool) {
+	l := New(64, 64)
+	if sparse {
+		l = NewSparse(64, 64)
+	}
+	for k := 0; k < b.N; k++ {
+		if k%16 == 0 {
+			b.StopTimer()
+			l.SeedWith(0.4, Random, int64(k))
+			b.StartTimer()
+		}
+		l.Step()
+	}
+}
+
+func BenchmarkSparseGliders(b *testing.B) { benchmarkGliders(b, true) }
+func BenchmarkDenseGliders(b *testing.B)  { benchmarkGliders(b, false) }
+func BenchmarkSparseSoup(b *testing.B)    { benchmarkSoup(b, true) }
+func BenchmarkDenseSoup(b *testing.B)     { benchmarkSoup(b, false) }
@@ -0,0 +1,120 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+
+	"golang.org/x/mobile/sprite/clock"
+)
+
+// limitRaise is the factor the limited speed is raised by every time the frames show headroom,
+// until the target speed is back.
+const limitRaise = 1.5
+
+// A limiterConfig tells when to limit the speed of the game, see speedLimiter. The manifest gives
+// it as an object with any of the keys off, below, headroom, window and sustain.
+type limiterConfig struct {
+	off bool // Whether the speed is never limited.
+	// below is the ratio of the speed aimed at under which the speed measured is too slow.
+	below float64
+	// headroom is the mean frame time in ms the frames of a window hitting the speed aimed at take
+	// at most for the speed to be raised.
+	headroom int
+	window   int // Length of the windows of the measures, in seconds.
+	// sustain is the number of windows in a row too slow to limit the speed, or with headroom to
+	// raise it.
+	sustain int
+}
+
+// A speedLimiter lowers the speed the game runs at while the device cannot step it as fast as the
+// user asked for, so that the frames do not take longer and longer, and raises it back once the
+// device can. It only knows of the timings of the frames it is fed, see observe, so that it can
+// be tested without a device.
+//
+// It measures the speed over windows of frames: once the speed measured stays under the speed aimed
+// at for some windows in a row, it limits the speed to the one measured. The speed limited, the
+// frames take less time; once they show headroom for some windows in a row, it raises the limit
+// step by step up to the target speed. The windows needed in a row and the gap between the speed
+// too slow and the one hit keep it from going back and forth every window.
+type speedLimiter struct {
+	limiterConfig
+	// limit is the speed the game is limited to, 0 if it is not.
+	limit float64
+	// ticks, frames and generations are the length of the current window so far, with the
+	// frames and the generations it took.
+	ticks               clock.Time
+	frames, generations int
+	// slow and roomy are the numbers of the last windows in a row too slow and with headroom.
+	slow, roomy int
+}
+
+// limiter limits the speed of the game, see speed.
+var limiter speedLimiter
+
+// speed returns the speed the game runs at for the target speed, in generations per second.
+func (l *speedLimiter) speed(target float64) float64 {
+	if l.limited(target) {
+		return l.limit
+	}
+	return target
+}
+
+// limited tells whether the speed of the game is limited under target.
+func (l *speedLimiter) limited(target float64) bool {
+	return l.limit != 0 && l.limit < target
+}
+
+// catchUp returns the most generations a frame steps: only one while the speed is limited, as the
+// late frames are late because of them.
+func (l *speedLimiter) catchUp(target float64) float64 {
+	if l.limited(target) {
+		return 1
+	}
+	return maxCatchUp
+}
+
+// observe tells l a frame of the running game took elapsed ticks and stepped the given generations,
+// while aiming at target. It returns whether the speed of the game changed.
+func (l *speedLimiter) observe(elapsed clock.Time, generations int, target float64) bool {
+	if l.off {
+		return false
+	}
+	l.ticks += elapsed
+	l.frames++
+	l.generations += generations
+	if l.ticks < clock.Time(l.window*60) {
+		return false
+	}
+	// The clock ticks 60 times per second. The generation owed at the end of the window counts, so
+	// that slow speeds of a few generations per window are not found too slow.
+	aim := l.speed(target)
+	measured := float64(l.generations+1) * 60 / float64(l.ticks)
+	frameTime := float64(l.ticks) * 1000 / 60 / float64(l.frames)
+	l.ticks, l.frames, l.generations = 0, 0, 0
+	switch {
+	case measured < l.below*aim:
+		l.slow, l.roomy = l.slow+1, 0
+	case frameTime <= float64(l.headroom):
+		l.slow, l.roomy = 0, l.roomy+1
+	default:
+		l.slow, l.roomy = 0, 0
+	}
+	switch {
+	case l.slow >= l.sustain:
+		l.slow, l.limit = 0, measured
+		if l.limit < minSpeed {
+			l.limit = minSpeed
+		}
+	case l.roomy >= l.sustain && l.limited(target):
+		l.roomy = 0
+		if l.limit *= limitRaise; l.limit >= target {
+			l.limit = 0
+		}
+	}
+	if now := l.speed(target); now != aim {
+		log.Printf("speed %.1f of %v measured, running at %.1f", measured, target, now)
+		return true
+	}
+	return false
+}
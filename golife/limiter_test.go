@@ -0,0 +1,63 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/mobile/sprite/clock"
+)
+
+// runLimited runs l for the given ticks at target on a device whose frames take a tick, and cost
+// more ticks each generation they step, as the arranger does. It returns the number of times the
+// speed changed.
+func runLimited(l *speedLimiter, target float64, cost, ticks clock.Time) int {
+	changes, due, elapsed := 0, 0.0, clock.Time(1)
+	for t := clock.Time(0); t < ticks; t += elapsed {
+		due += float64(elapsed) * l.speed(target) / 60
+		if c := l.catchUp(target); due > c {
+			due = c
+		}
+		stepped := 0
+		for ; due >= 1; due-- {
+			stepped++
+		}
+		elapsed = 1 + cost*clock.Time(stepped)
+		if l.observe(elapsed, stepped, target) {
+			changes++
+		}
+	}
+	return changes
+}
+
+func TestSpeedLimiter(t *testing.T) {
+	l := &speedLimiter{limiterConfig: defaultConfig().limiter}
+	// A fast device runs at any speed.
+	if runLimited(l, maxSpeed, 0, 60*60) != 0 || l.limited(maxSpeed) {
+		t.Fatalf("fast device limited to %v", l.limit)
+	}
+	// Slow frames limit the speed, once for long.
+	if n := runLimited(l, maxSpeed, 2, 60*60); n != 1 || !l.limited(maxSpeed) || l.catchUp(maxSpeed) != 1 {
+		t.Fatalf("slow device limited to %v after %d changes", l.limit, n)
+	}
+	if l.speed(maxSpeed) < minSpeed || l.speed(maxSpeed) > 0.7*maxSpeed {
+		t.Errorf("slow device limited to %v", l.speed(maxSpeed))
+	}
+	// A speed under the limit is not limited.
+	if l.limited(minSpeed) || l.speed(minSpeed) != minSpeed {
+		t.Errorf("speed %v limited to %v", minSpeed, l.speed(minSpeed))
+	}
+	// The target is back once the frames show headroom.
+	if runLimited(l, maxSpeed, 0, 60*60); l.limited(maxSpeed) || l.catchUp(maxSpeed) != maxCatchUp {
+		t.Errorf("speed still limited to %v with headroom", l.limit)
+	}
+
+	// Slow speeds of a generation every few frames are not too slow.
+	if runLimited(l, minSpeed, 0, 60*60); l.limited(minSpeed) {
+		t.Errorf("speed %v limited to %v", minSpeed, l.limit)
+	}
+	l = &speedLimiter{limiterConfig: limiterConfig{off: true}}
+	if runLimited(l, maxSpeed, 2, 60*60); l.limited(maxSpeed) {
+		t.Errorf("speed limited while off")
+	}
+}
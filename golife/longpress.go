@@ -0,0 +1,29 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import "time"
+
+const (
+	// longPress is how long a finger must stay still on a dead cell to open the stamp menu there.
+	longPress = 500 * time.Millisecond
+	// holdRadius is how far, in Pt, a finger may drift during a long press.
+	holdRadius = 6
+	// flashTime is how long the cell under a long press is highlighted.
+	flashTime = 250 * time.Millisecond
+)
+
+// isLongPress reports whether s is a long press: a stroke that started on a dead cell and stayed
+// still for longPress, not painting walls.
+func (s *stroke) isLongPress() bool {
+	return s.born && !s.wall && !s.moved && time.Since(s.start) >= longPress
+}
+
+// unflash removes the highlight of the last long press, if any.
+func unflash() {
+	if views.flash != nil {
+		grid.RemoveChild(views.flash)
+		eng.Unregister(views.flash)
+		views.flash = nil
+	}
+}
@@ -8,13 +8,21 @@ http://en.wikipedia.org/wiki/Conway%27s_Game_of_Life
 package main
 
 import (
+	"fmt"
 	"image"
+	"image/color"
 	"log"
+	"math"
 	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
 	"time"
 
 	_ "image/png"
 
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/ui"
 	"golang.org/x/mobile/app"
 	"golang.org/x/mobile/event"
 	"golang.org/x/mobile/f32"
@@ -26,201 +34,1108 @@ import (
 )
 
 // Units are in Pt.
-const (
-	systemBarHeight = 12
-	buttonSize      = 14
-	buttonSep       = 6
-	buttonBarHeight = 15
+const systemBarHeight = 12
+
+// The sizes of the controls, in Pt, as told by controls, see scaleControls.
+var (
+	buttonSize geom.Pt = 14
+	buttonSep  geom.Pt = 6
+	// buttonBarHeight is the height of the button bar, of several rows of buttons if they do not
+	// fit in one, see barLayout.
+	buttonBarHeight geom.Pt = 15
 )
 
+// Speeds are in generations per second.
 const (
-	maxUint32          = 1<<32 - 1
-	initialRenderEvery = 5
+	initialSpeed = 10
+	minSpeed     = 1
+	maxSpeed     = 60
+	// maxCatchUp is the most generations rendered by a frame, so that a late frame does not jump
+	// ahead.
+	maxCatchUp = 4
 )
 
 var (
 	cellSize  geom.Pt = 8
 	start             = time.Now()
 	lastClock         = clock.Time(-1)
-	// renderEvery is used to decrease the frequency the next generation is rendered. A value of 3
-	// means to render once every three render calls.
-	renderEvery uint32 = initialRenderEvery
-
-	eng       = glsprite.Engine()
-	scene     *sprite.Node
-	textures  map[string]*sprite.SubTex
-	buttonBar buttonMap
+	// speed is the number of generations rendered per second.
+	speed float64 = initialSpeed
+	// paused stops the generations from advancing, speed is kept to resume at the same speed.
+	paused bool
+	// lastArrange is the time of the last arrangement of the scene.
+	lastArrange = clock.Time(-1)
+	// ageColors tints alive cells by age, otherwise they all look the same.
+	ageColors = true
+	// seedMode is how replay lays out the random cells, cycled by a long press on the replay
+	// button or set in the settings panel.
+	seedMode life.SeedMode
+	// soupSeed is the seed the random cells of the universe were drawn with, 0 if it is not a random
+	// universe or the seed is unknown.
+	soupSeed int64
+	// soupRun tells how the random cells of the universe were drawn, as a run without edits, nil if
+	// it is not a random universe. runStart is the game as it last stepped from generation 0, nil
+	// if it did not since replaced. Both are kept to share the run of the game, see shareRun.
+	soupRun  *life.Run
+	runStart *life.Life
+
+	// cfg holds the startup defaults, possibly overridden by the config manifest.
+	cfg = defaultConfig()
+
+	// mu serializes the draw and touch callbacks, which may run on different threads.
+	mu sync.Mutex
+
+	eng   sprite.Engine = track(glsprite.Engine())
+	scene *sprite.Node
+	// grid holds the nodes of the universe under the camera of its view, first in the scene so that
+	// everything else shows over it, and mask hides the cells zoomed in under the button bar. See
+	// universe.look.
+	grid, mask *sprite.Node
+	textures   map[string]*sprite.SubTex
+	buttonBar  *ui.Bar // The buttons are named by their image.
+	univ       *universe
+	status     *hud
+	views      sceneViews
+
+	// laidOut is the screen size the scene was laid out for.
+	laidOut geom.Point
+
+	// stopped is set while the app is in the background, since stoppedAt. lost is set once it comes
+	// back, until the scene is built again.
+	stopped   bool
+	stoppedAt time.Time
+	lost      bool
+
+	// decoded receives the images decoded in the background by loadTextures.
+	decoded = make(chan decodedImage)
+	drawn   bool // Whether the first frame has been rendered.
 )
 
+// sceneViews are the views built over the game on the engine of the scene: the panels open, the
+// overlays, highlights and animations showing, and the nodes of the modes of the game in progress,
+// which go on without them. They go with the scene as a whole, see dropViews, and are built again
+// by the next frames as needed.
+type sceneViews struct {
+	panel   *settingsPanel // The open settings panel, nil if closed.
+	menu    *stampMenu     // The open stamp menu, nil if closed.
+	shared  *shareView     // The open share view, nil if closed.
+	gallery *soupGallery   // The open gallery, nil if closed.
+	picker  *patternPicker // The open pattern picker, nil if closed.
+	console *consoleView   // The open debug console, nil if closed.
+
+	notice   *banner          // The banner showing, nil if none.
+	popup    *toast           // The toast showing, nil if none.
+	analysis *analysisOverlay // The analysis overlay, nil if not shown.
+	// overlay is the debug overlay, nil until built by the next frame or while hidden, see
+	// debugging.
+	overlay *debugOverlay
+	// flash highlights the cell under the last long press until flashEnd, and the stamp menu is
+	// closed.
+	flash    *sprite.Node
+	flashEnd time.Time
+	// mutants highlight the cells flipped by the latest mutations until mutantsEnd.
+	mutants    []*sprite.Node
+	mutantsEnd time.Time
+	// ripples are the ripples showing and those done, from the oldest started.
+	ripples []*ripple
+	// fit is the current fitting of the camera, if any. It stops short at the next touch.
+	fit *fitting
+
+	// matchHalf frames the half of the field the player seeds during a match, nil until shown.
+	matchHalf []*sprite.Node
+	// spinner turns over the grid while fast-forwarding, nil until the first frame.
+	spinner *sprite.Node
+	// tour shows the step of the tutorial in progress, nil until built by the next frame.
+	tour *tutorialOverlay
+	// showcase shows the title and the cover of the showcase playing.
+	showcase showcaseNodes
+}
+
+// dropViews drops the views of the scene, gone with its engine, the panels released first.
+func dropViews() {
+	dropPanels()
+	views = sceneViews{}
+}
+
 // A universe contains what images to display for each cell state.
 type universe struct {
+	h, w  geom.Pt // Size of the screen area it covers.
 	rows  int
 	cols  int
-	cells []*sprite.Node
-	life  *Life
-}
-
-// A button is a clickable image that triggers an action.
-type button struct {
-	rect *geom.Rectangle // Uses absolute location.
+	cells []*sprite.Node // Nil in the texture render mode.
+	frame *frame         // Draws the cells in the texture render mode, nil otherwise.
+	// sheet is the parent of the nodes of the cells, their echoes and the frame, moved by slide, the
+	// fraction of a cell the field shows moved by while drifting, see drift.
+	sheet *sprite.Node
+	slide geom.Point
+	life  *life.Life
+	// due is the number of generations due since the last one was rendered, fractions included.
+	due float64
+	// margin is the space left around the field for the seam echo strips, if enabled.
+	margin geom.Pt
+	// zoom and pan are the camera of the view of the field, which pinches move without changing
+	// the game: the scale of the cells, 1 showing them cellSize wide, and the location of the
+	// top-left corner of the field relative to the top-left corner of the screen area. See look.
+	zoom float32
+	pan  geom.Point
+	// echoes shows, just outside each edge of the field, the cells of the opposite edge. Nodes are
+	// keyed by the index of the field cell they echo.
+	echoes map[int][]*sprite.Node
+	// border holds the lines framing the field extent, shade the nodes shading the screen area
+	// outside it.
+	border, shade []*sprite.Node
+	// history detects when the game stagnates, to tell it and pause it.
+	history history
+	// timeline holds past generations, to step back.
+	timeline timeline
+	// edits holds the latest edits by the user, to undo them.
+	edits editStack
+	// trail fades out the cells that just died.
+	trail trail
+	// spray draws the cells painted by the spray brush, seeded again with the seed of every random
+	// universe so that the same strokes spray the same cells on the same soup.
+	spray *rand.Rand
 }
 
-// A buttonMap contains the buttons in the button bar.
-type buttonMap map[string]*button
-
 func main() {
 	rand.Seed(time.Now().UnixNano())
+	// The debug console shows the latest lines of the log.
+	log.SetOutput(logs)
+	// Large fields are stepped on every processor.
+	runtime.GOMAXPROCS(runtime.NumCPU())
 	app.Run(app.Callbacks{
-		Draw:  draw,
-		Touch: touch,
+		Start:  startApp,
+		Stop:   stopApp,
+		Draw:   draw,
+		Touch:  touch,
+		Config: configure,
 	})
 }
 
-func (b button) contains(point geom.Point) bool {
-	return b.rect.Min.X <= point.X && point.X <= b.rect.Max.X &&
-		b.rect.Min.Y <= point.Y && point.Y <= b.rect.Max.Y
+// newButtonBar creates a button bar with the buttons of the given images, laid out by barRects.
+func newButtonBar(imgs ...string) *ui.Bar {
+	slots := buttonSlots(imgs)
+	// The scene is below the system bar.
+	bar := ui.NewBar(eng, scene, geom.Point{Y: systemBarHeight}, buttonFace)
+	speedIndicator = nil
+	rs, _ := barLayout(len(slots))
+	for k, r := range rs {
+		if slots[k] == speedIndicatorSlot {
+			speedIndicator = newSpeedIndicator(r.Min.X)
+			continue
+		}
+		bar.Add(slots[k], r)
+	}
+	accessibleBar(bar)
+	return bar
 }
 
-// newButtonMap creates a button bar. The buttons are centered on the top of the screen.
-func newButtonMap(imgs ...string) buttonMap {
-	var (
-		number     = geom.Pt(len(imgs))
-		leftMargin = (geom.Width - number*buttonSize - (number-1)*buttonSep) / 2
-		buttonBar  = make(buttonMap)
-	)
+// buttonSlots returns the slots of the button bar showing the buttons of imgs: their images, the
+// speed indicator taking the place of a button between the speed buttons, if they are next to each
+// other.
+func buttonSlots(imgs []string) []string {
+	var slots []string
 	for k, img := range imgs {
-		n := &sprite.Node{}
-		eng.Register(n)
-		scene.AppendChild(n)
-		x := leftMargin + (buttonSize+buttonSep)*geom.Pt(k)
-		rect := &geom.Rectangle{
-			Min: geom.Point{X: x, Y: systemBarHeight},
-			Max: geom.Point{X: x + buttonSize, Y: systemBarHeight + buttonSize},
+		slots = append(slots, img)
+		if img == decSpeedImage && k+1 < len(imgs) && imgs[k+1] == incSpeedImage {
+			slots = append(slots, speedIndicatorSlot)
 		}
-		buttonBar[img] = &button{rect: rect}
-		eng.SetTransform(n, f32.Affine{
-			{buttonSize, 0, float32(x)},
-			{0, buttonSize, 0},
-		})
-		eng.SetSubTex(n, *textures[img])
 	}
-	return buttonBar
+	return slots
 }
 
-// find returns the name of the button that contains point if any.
-func (buttonBar buttonMap) find(point geom.Point) string {
-	for img, b := range buttonBar {
-		if b.contains(point) {
-			return img
+// barLayout returns the rectangles of n slots of the button bar, centered on the top of the screen
+// and wrapped in as few rows as fit it, and the height of the bar, see buttonBarHeight.
+func barLayout(n int) (rs []geom.Rectangle, height geom.Pt) {
+	cols := int((geom.Width - 2*buttonSep + buttonSep) / (buttonSize + buttonSep))
+	if cols < 1 {
+		cols = 1
+	}
+	rs = ui.Wrap(n, cols, buttonSize, buttonSep, systemBarHeight)
+	height = buttonSize + 1
+	if len(rs) > 0 {
+		height = rs[len(rs)-1].Max.Y - systemBarHeight + 1
+	}
+	return mirrorAll(rs, screen()), height
+}
+
+// rules are the rules the rule button cycles through, in B/S notation, or B/S/C for the rules of
+// several dying states.
+var rules = []string{
+	"B3/S23",        // Conway's Life.
+	"B36/S23",       // HighLife.
+	"B3/S012345678", // Life without death.
+	"B2/S",          // Seeds.
+	"B3678/S34678",  // Day & Night.
+	"B2/S/C3",       // Brian's Brain.
+	"B2/S345/C4",    // Star Wars.
+}
+
+// nextRule returns the rule following r in rules, or the first one if r is not in the list.
+func nextRule(r life.Rule) life.Rule {
+	next := rules[0]
+	for k, name := range rules {
+		if name == r.String() && k+1 < len(rules) {
+			next = rules[k+1]
 		}
 	}
-	return ""
+	r, err := life.ParseRule(next)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return r
+}
+
+// buttonFace returns the sub-texture shown by the button b, dimmed if disabled.
+func buttonFace(b *ui.Button) sprite.SubTex {
+	img := face(b.Name)
+	if b.Disabled() {
+		img = disabledImage(img)
+	}
+	return *textures[img]
+}
+
+// disabledImage returns the name of the dimmed sub-texture of the button image img, see
+// loadTextures.
+func disabledImage(img string) string {
+	return img + "_disabled"
+}
+
+// face returns the image currently shown by the button of the given image, which for toggle
+// buttons depends on the state of the app.
+func face(img string) string {
+	switch {
+	case img == versusImage:
+		return versus.face()
+	case img == searchImage && search != nil:
+		return stopImage
+	case img == forwardImage && forward != nil:
+		return stopImage
+	case img == analyzeImage && views.analysis != nil:
+		return stopImage
+	case img == brushImage:
+		return brushImages[currentBrush]
+	case img == wallImage && walling:
+		return stopImage
+	case img == wallImage:
+		return wallImages[wallAlive]
+	case img == pauseImage && paused:
+		return playImage
+	case img == edgesImage && univ != nil && univ.life.Edges == life.Bounded:
+		return boundedImage
+	case img == edgesImage && univ != nil && univ.life.Edges == life.Reflect:
+		return reflectImage
+	case img == edgesImage && univ != nil && univ.life.Edges == life.Grow:
+		return growImage
+	case img == recordImage && recording != nil:
+		return stopImage
+	case img == soundImage && muted:
+		return muteImage
+	}
+	return img
 }
 
+// setPaused pauses or resumes the simulation, flipping the pause button accordingly, and has the
+// screen reader tell so, see announceState.
+func setPaused(p bool) {
+	changed := p != paused
+	paused = p
+	buttonBar.Refresh()
+	if changed && univ != nil {
+		announceState()
+	}
+}
+
+// cycleSeedMode switches to the next seed mode, used by the next reset.
+func cycleSeedMode() {
+	seedMode = (seedMode + 1) % (life.Rotational + 1)
+	log.Printf("seed mode %v", seedMode)
+}
+
+// resize returns a universe covering the h*w screen area with the same game as u, replacing u. The
+// field stays centered, cropped or padded with dead cells.
+func (u *universe) resize(h, w geom.Pt) *universe {
+	l, cols, rows := u.life, u.cols, u.rows
+	u.release()
+
+	nu := newUniverse(h, w)
+	l.Resize(nu.cols, nu.rows, (nu.cols-cols)/2, (nu.rows-rows)/2)
+	nu.adopt(l)
+	return nu
+}
+
+// newUniverse returns a universe of dead cells covering the h*w screen area. Its cells are not
+// painted until it is reset or adopts a game.
 func newUniverse(h, w geom.Pt) *universe {
+	// Every cell is a sprite node, so a small cell size on a large screen could make enough of them
+	// to stall or exhaust the device. Grow the cells until they fit the budget.
+	if n := float64(h / cellSize * w / cellSize); !cfg.frameRender && n > float64(cfg.maxCells) {
+		siz := geom.Pt(math.Ceil(float64(cellSize) * math.Sqrt(n/float64(cfg.maxCells))))
+		log.Printf("%.0f cells exceed the budget of %d; growing cells from %v to %v",
+			n, cfg.maxCells, cellSize, siz)
+		cellSize = siz
+	}
+	var margin geom.Pt
+	if cfg.seamEcho {
+		margin = cellSize
+	}
 	var (
-		rows = int(h / cellSize)
-		cols = int(w / cellSize)
+		rows = int((h - 2*margin) / cellSize)
+		cols = int((w - 2*margin) / cellSize)
 		u    = &universe{
-			rows: rows,
-			cols: cols,
-			life: NewLife(cols, rows),
+			h:      h,
+			w:      w,
+			rows:   rows,
+			cols:   cols,
+			life:   life.NewSparse(cols, rows),
+			margin: margin,
+			spray:  rand.New(rand.NewSource(rand.Int63())),
 		}
-		siz = float32(cellSize)
 	)
+	// Unzoomed, the field in the top-left corner of the screen area.
+	u.look(1, geom.Point{}, geom.Point{X: 0.1, Y: systemBarHeight + buttonBarHeight})
+	u.sheet = &sprite.Node{}
+	eng.Register(u.sheet)
+	grid.AppendChild(u.sheet)
+	u.placeSheet()
+	u.life.Edges = cfg.edges
+	u.life.Rule = cfg.rule
+	u.life.Colors = cfg.colors
+	u.life.ParallelCells = cfg.parallelCells
+	u.life.Listener = u
+	if cfg.frameRender {
+		u.frame = u.newFrame()
+		u.newBorder(h, w)
+		return u
+	}
 	for j := 0; j < u.rows; j++ {
 		for i := 0; i < u.cols; i++ {
-			n := &sprite.Node{}
-			eng.Register(n)
-			scene.AppendChild(n)
-			eng.SetTransform(n, f32.Affine{
-				{siz, 0, float32(i) * siz},
-				{0, siz, buttonBarHeight + float32(j)*siz},
-			})
-			u.cells = append(u.cells, n)
+			u.cells = append(u.cells, u.newCell(i, j))
+		}
+	}
+	// The cells straddling the seams while drifting are drawn twice, as echoes.
+	if cfg.seamEcho || drifts() {
+		u.echoes = make(map[int][]*sprite.Node)
+		// Walk the ring of cells around the field. Corner cells of the field are thus echoed
+		// three times: in two strips and diagonally.
+		for j := -1; j <= u.rows; j++ {
+			for i := -1; i <= u.cols; i++ {
+				if i >= 0 && i < u.cols && j >= 0 && j < u.rows {
+					continue
+				}
+				// Show the neighbor across the seam.
+				k := (j+u.rows)%u.rows*u.cols + (i+u.cols)%u.cols
+				u.echoes[k] = append(u.echoes[k], u.newCell(i, j))
+			}
 		}
 	}
+	u.newBorder(h, w)
 	return u
 }
 
-func (u *universe) Step() {
-	u.life.Step()
-	var i, j int
-	var img string
-	for k, cell := range u.cells {
-		j = k / u.cols
-		i = k % u.cols
-		img = emptyImage
-		if u.life.A.Alive(i, j) {
-			img = androidImage
+// newCell returns a new node drawn at the location of the cell at (i, j).
+func (u *universe) newCell(i, j int) *sprite.Node {
+	var (
+		siz = float32(cellSize)
+		m   = float32(u.margin)
+		n   = &sprite.Node{}
+	)
+	eng.Register(n)
+	u.sheet.AppendChild(n)
+	eng.SetTransform(n, f32.Affine{
+		{siz, 0, m + float32(i)*siz},
+		{0, siz, m + float32(j)*siz},
+	})
+	return n
+}
+
+// newBorder frames the field extent with a 1px line and shades the rest of the h*w area, so it is
+// clear where the universe ends. Sides where the field reaches the edge of the area get no border.
+func (u *universe) newBorder(h, w geom.Pt) {
+	var (
+		m  = float32(u.margin)
+		fw = float32(geom.Pt(u.cols) * cellSize)
+		fh = float32(geom.Pt(u.rows) * cellSize)
+		px = 1 / geom.PixelsPerPt
+	)
+	add := func(nodes *[]*sprite.Node, img string, x, y, width, height float32) {
+		n := &sprite.Node{}
+		eng.Register(n)
+		grid.AppendChild(n)
+		eng.SetTransform(n, f32.Affine{
+			{width, 0, x},
+			{0, height, y},
+		})
+		eng.SetSubTex(n, *textures[img])
+		*nodes = append(*nodes, n)
+	}
+	// Shade what is right of and below the field and its echo strips.
+	if right := float32(w) - fw - 2*m; right >= px {
+		add(&u.shade, outOfBoundsImage, fw+2*m, 0, right, float32(h))
+	}
+	if bottom := float32(h) - fh - 2*m; bottom >= px {
+		add(&u.shade, outOfBoundsImage, 0, fh+2*m, fw+2*m, bottom)
+	}
+	img := u.borderImage()
+	if m >= px {
+		add(&u.border, img, m-px, m-px, fw+2*px, px)
+		add(&u.border, img, m-px, m, px, fh)
+	}
+	if float32(w)-fw-m >= px {
+		add(&u.border, img, m+fw, m, px, fh)
+	}
+	if float32(h)-fh-m >= px {
+		add(&u.border, img, m-px, m+fh, fw+2*px, px)
+	}
+}
+
+// borderImage returns the image of the border lines, which tells the edge mode.
+func (u *universe) borderImage() string {
+	switch u.life.Edges {
+	case life.Wrap:
+		return wrapBorderImage
+	case life.Reflect:
+		return reflectBorderImage
+	case life.Grow:
+		return growBorderImage
+	}
+	return borderImage
+}
+
+// setEdges changes the edge mode of the universe, keeping its cells.
+func (u *universe) setEdges(mode life.EdgeMode) {
+	u.life.Edges = mode
+	u.edited()
+	u.repaint()
+}
+
+// repaint sets the image of every cell node and of the border, which tells the edge mode.
+func (u *universe) repaint() {
+	for _, n := range u.border {
+		eng.SetSubTex(n, *textures[u.borderImage()])
+	}
+	// Echoes only show in Wrap mode.
+	u.paint()
+}
+
+// step computes the next generation of the universe.
+func (u *universe) step() {
+	u.fadeTrails()
+	follow.stepping()
+	t := time.Now()
+	stepSession(u)
+	views.overlay.stepped(time.Since(t))
+}
+
+// maxSoupSeed is the largest seed of the random universes, which are numbered from 1 so they can be
+// told and reproduced.
+const maxSoupSeed = 999
+
+// reset replaces the universe with a fresh random one of the same size, drawn with the configured
+// seed if any, or a random one. If the config has a link, its pattern is placed or its run started
+// instead.
+func (u *universe) reset() {
+	if err := (linkSource{}).seed(u); err != nil {
+		randomSource{}.seed(u)
+	}
+}
+
+// startRun replaces the universe with the game of r at generation 0, centered and cropped or padded
+// with dead cells if r is of another size, and pauses it so that it can be watched from the start.
+func (u *universe) startRun(r *life.Run) {
+	l := r.Life()
+	if r.W != u.cols || r.H != u.rows {
+		l.Resize(u.cols, u.rows, (u.cols-r.W)/2, (u.rows-r.H)/2)
+	}
+	soupSeed, soupRun, runStart = r.Seed, nil, nil
+	if r.Seed != 0 {
+		soupRun = &life.Run{Density: r.Density, Mode: r.Mode, Seed: r.Seed}
+		u.spray.Seed(r.Seed)
+	}
+	session, touchLog = nil, nil
+	u.adopt(l)
+	// The run mutates as it did, the mutation turning on if it was.
+	if m := r.Mutation; m.Active() {
+		cfg.mutation, cfg.mutationEvery, cfg.mutationCells = true, m.Every, m.Cells
+		u.life.Mutation = m
+	}
+	u.history.clear()
+	u.timeline.clear()
+	u.edits.clear()
+	spark.clear()
+	u.due = 0
+	setPaused(true)
+}
+
+// reseed replaces the universe with the random one of the given seed, the pinned cells kept. The
+// sprite nodes are reused and repainted right away.
+func (u *universe) reseed(s int64) {
+	soupSeed = s
+	soupRun, runStart = &life.Run{Density: cfg.density, Mode: seedMode, Seed: s}, nil
+	log.Printf("seed %d", s)
+	u.life.SeedWith(cfg.density, seedMode, s)
+	u.life.Mutation = mutation()
+	u.spray.Seed(s)
+	session, touchLog = nil, nil
+	u.history.clear()
+	u.timeline.clear()
+	u.edits.clear()
+	spark.clear()
+	u.due = 0
+	u.paint()
+}
+
+// paint sets the image of every cell node from the current state of life, without trails.
+func (u *universe) paint() {
+	u.trail.clear()
+	for k := 0; k < u.cols*u.rows; k++ {
+		u.show(k%u.cols, k/u.cols, u.life.Alive(k%u.cols, k/u.cols))
+	}
+}
+
+// show sets the image of the nodes of the cell (i, j), including its echoes.
+func (u *universe) show(i, j int, alive bool) {
+	if u.frame != nil {
+		u.showFrame(i, j, alive)
+		return
+	}
+	k := j*u.cols + i
+	img, echoImg := emptyImage, emptyImage
+	if u.life.Pinned(i, j) {
+		// Pinned cells have no echoes.
+		img = brickTints[brickTint(u.life.Alive(i, j))].name
+	} else if alive {
+		img = u.cellImage(i, j)
+		switch {
+		case u.drifting():
+			echoImg = img
+		case u.life.Edges == life.Wrap:
+			echoImg = echoImage
 		}
-		// TODO: compare current with prev value. If equal, no-op.
-		eng.SetSubTex(cell, *textures[img])
+	} else if s := u.life.State(i, j); s > 1 {
+		img = decayTints[decayLevel(s, u.life.Rule)].name
+	} else if l := u.trail.level(k); l > 0 {
+		img = trailLevelImage(l)
+	}
+	eng.SetSubTex(u.cells[k], *textures[img])
+	for _, n := range u.echoes[k] {
+		eng.SetSubTex(n, *textures[echoImg])
 	}
 }
 
+// OnBirth implements life.StepListener.
+func (u *universe) OnBirth(i, j int) {
+	follow.changed(u, i, j)
+	u.show(i, j, true)
+}
+
+// OnDeath implements life.StepListener.
+func (u *universe) OnDeath(i, j int) {
+	follow.changed(u, i, j)
+	u.startTrail(i, j)
+	u.show(i, j, false)
+}
+
+// OnDecay implements life.DecayListener.
+func (u *universe) OnDecay(i, j int) { u.show(i, j, false) }
+
+// AgeMarks implements life.AgeListener: births and deaths are shown by OnBirth and OnDeath, and
+// survivors only need a new image when they reach another age bracket, if ages are shown.
+func (u *universe) AgeMarks() []int {
+	if !ageColors || u.life.Colors > 1 {
+		return nil
+	}
+	return ageMarks
+}
+
+// OnAge implements life.AgeListener.
+func (u *universe) OnAge(i, j, age int) { u.show(i, j, true) }
+
 func draw() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Nothing shows while the app is in the background.
+	if stopped {
+		return
+	}
 	if scene == nil {
 		loadScene()
 	}
+	if lost {
+		// The GL context may have been lost with the textures. Start over with a new engine
+		// showing the same game.
+		lost = false
+		forgetPointers()
+		forgetSelection()
+		eng = track(glsprite.Engine())
+		buildScene(univ.life)
+	}
+	holdPointers()
+	speedIndicator.update()
+	updateToolBar()
+	updateBanner()
+	updateToast()
+	updateTutorial()
+	updateVersus()
+	updateSearch()
+	updateForward()
+	updateAnalysis()
+	updateMutants()
+	// The cell of the stamp menu stays highlighted while it is open.
+	if views.flash != nil && views.menu == nil && time.Now().After(views.flashEnd) {
+		unflash()
+	}
+	// Some devices only report their final screen size after the first frames.
+	if laidOut != (geom.Point{X: geom.Width, Y: geom.Height}) {
+		layout()
+	}
 
 	now := clock.Time(time.Since(start) * 60 / time.Second)
 	if now == lastClock {
 		return
 	}
 	lastClock = now
+	began := time.Now()
 
-	gl.ClearColor(1, 1, 1, 1)
+	updateFit(now)
+	updateFollow(now)
+	updatePulse(now)
+	updateRipples(now)
+	updateShowcase(now)
+	updateDebug()
+	updateConsole()
+	announceProgress()
+	updateControls()
+	updatePack()
+	uploadDecoded()
+	univ.frame.flush()
+	spark.flush()
+	bg := themes[currentTheme].background
+	gl.ClearColor(float32(bg.R)/0xff, float32(bg.G)/0xff, float32(bg.B)/0xff, 1)
 	gl.Clear(gl.COLOR_BUFFER_BIT)
-	eng.Render(scene, now)
+	for _, n := range renderedRoots() {
+		eng.Render(n, now)
+	}
+	frameTime = time.Since(began)
+	if !drawn {
+		drawn = true
+		log.Printf("first frame rendered %v after start", time.Since(start))
+	}
 }
 
-func touch(t event.Touch) {
-	if t.Type != event.TouchEnd {
-		// Naive implementation of button event handling: it only matters when/where the user stops
-		// touching the screen.
+// stopApp freezes the game as the app goes to the background, and saves it as the app may be killed
+// any time after.
+func stopApp() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	stopped, stoppedAt = true, time.Now()
+	if univ == nil {
 		return
 	}
+	if err := univ.save(); err != nil {
+		log.Printf("saving the game: %v", err)
+	}
+	recording.stop()
+}
 
-	switch img := buttonBar.find(t.Loc); img {
-	case incSpeedImage:
-		if renderEvery > 1 {
-			renderEvery--
+// startApp resumes the game as the app comes back to the foreground, where it was left. The clock
+// skips the time spent in the background, so that no generation is due for it.
+func startApp() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !stopped {
+		return
+	}
+	stopped = false
+	start = start.Add(time.Since(stoppedAt))
+	lost = scene != nil
+}
+
+// configure lays out the scene again when the screen size changes, for instance on rotation.
+func configure(new, old event.Config) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if scene != nil && (new.Width != old.Width || new.Height != old.Height) {
+		layout()
+	}
+}
+
+// layout rebuilds the universe, keeping its game, and the button bar for the current screen size.
+func layout() {
+	laidOut = geom.Point{X: geom.Width, Y: geom.Height}
+	_, buttonBarHeight = barLayout(len(buttonSlots(cfg.buttons)))
+	placeMask()
+	forgetPointers()
+	views.fit = nil
+	// The settings panel and the debug console stay open, laid out again, as the settings may lay
+	// the scene out again.
+	reopen, reopenConsole := views.panel != nil, views.console != nil
+	if views.panel != nil {
+		views.panel.close()
+	}
+	if views.console != nil {
+		views.console.close()
+	}
+	if views.menu != nil {
+		views.menu.close()
+	}
+	if views.shared != nil {
+		views.shared.close()
+	}
+	if views.gallery != nil {
+		views.gallery.close()
+	}
+	if views.picker != nil {
+		views.picker.close()
+	}
+	setSelecting(false)
+	// Rebuilt by the next frame for the new screen size.
+	toolBar.Release()
+	toolBar = nil
+	views.notice.release()
+	views.popup.release()
+	views.tour.release()
+	views.showcase.hide()
+	unmarkMutants()
+	if views.overlay != nil {
+		views.overlay.release()
+		views.overlay = nil
+	}
+	univ = univ.resize(geom.Height-systemBarHeight-buttonBarHeight, geom.Width)
+	buttonBar.Release()
+	speedIndicator.release()
+	buttonBar = newButtonBar(cfg.buttons...)
+	status.release()
+	status = newHUD(buttonBar)
+	spark.place()
+	// Rebuilt for the new screen size.
+	if views.analysis != nil {
+		views.analysis.release()
+		views.analysis = newAnalysisOverlay()
+	}
+	if reopen {
+		openSettings()
+	}
+	if reopenConsole {
+		openConsole()
+	}
+}
+
+func touch(t event.Touch) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The touches of the game are logged for the fixtures of the replay tests.
+	if demo == nil && views.console == nil {
+		logTouch(t)
+	}
+	switch {
+	case demo != nil:
+		// Any touch stops the showcase.
+		if t.Type == event.TouchStart {
+			stopShowcase()
+		}
+	case views.console != nil:
+		views.console.touch(t)
+	case views.panel != nil:
+		views.panel.touch(t)
+	case views.menu != nil:
+		views.menu.touch(t)
+	case views.shared != nil:
+		views.shared.touch(t)
+	case views.gallery != nil:
+		views.gallery.touch(t)
+	case views.picker != nil:
+		views.picker.touch(t)
+	case univ != nil:
+		route(t)
+	}
+}
+
+// tapButton acts on a tap on the button of the given image of bar.
+func tapButton(bar *ui.Bar, img string) {
+	switch bar {
+	case views.notice.bar():
+		views.notice.tap(img)
+	case views.tour.bar():
+		tour.skipped = true
+	default:
+		tap(img)
+	}
+}
+
+// tap acts on a tap on the button of the given image. During a match, only the versus, speed and
+// sound buttons act, and while fast-forwarding the fast-forward ones instead of the versus one.
+// During a comparison, the pause, speed and sound buttons act, and the rule one cycles the rule
+// compared.
+func tap(img string) {
+	if versus != nil {
+		switch img {
+		case versusImage, incSpeedImage, decSpeedImage, soundImage:
+		default:
+			return
+		}
+	}
+	if forward != nil {
+		switch img {
+		case forwardImage, incSpeedImage, decSpeedImage, soundImage:
+		default:
+			return
+		}
+	}
+	if compare != nil {
+		switch img {
+		case ruleImage:
+			compare.cycleRule()
+			return
+		case pauseImage, incSpeedImage, decSpeedImage, soundImage:
+		default:
+			return
 		}
+	}
+	tutor(face(img))
+	switch img {
+	case incSpeedImage:
+		changeSpeed(+1)
+		speak(text("speed", speed))
 	case decSpeedImage:
-		renderEvery++
+		changeSpeed(-1)
+		speak(text("speed", speed))
 	case pauseImage:
-		if renderEvery == maxUint32 {
-			// TODO(vegacom): add a 'play' button and flip it with 'pause'.
-			renderEvery = initialRenderEvery
+		setPaused(!paused)
+	case stepImage:
+		// Advance exactly one generation, pausing first if needed.
+		setPaused(true)
+		univ.Step()
+	case forwardImage:
+		if forward == nil {
+			startForward(forwardGenerations)
 		} else {
-			renderEvery = maxUint32
+			stopForward()
 		}
+	case backImage:
+		setPaused(true)
+		univ.Back()
 	case replayImage:
-		// TODO: implement replay.
+		// Start over as on launch, at the configured speed.
+		univ.reset()
+		speed = speedLevels[speedLevel(cfg.speed)]
+		speedIndicator.show()
+		setPaused(false)
+	case patternImage:
+		univ.placeNext()
+	case exportImage:
+		if name, err := univ.export(); err != nil {
+			log.Printf("exporting the grid: %v", err)
+		} else {
+			log.Printf("exported the grid to %s.png, .rle and .golife", name)
+		}
+	case settingsImage:
+		openSettings()
+	case themeImage:
+		setTheme((currentTheme + 1) % len(themes))
+	case recordImage:
+		if recording == nil {
+			startRecording()
+		} else {
+			recording.stop()
+		}
+	case agesImage:
+		ageColors = !ageColors
+		univ.paint()
+	case ruleImage:
+		univ.life.Rule = nextRule(univ.life.Rule)
+		univ.edited()
+		log.Printf("rule %v", univ.life.Rule)
+	case edgesImage:
+		// Cycle through Wrap, Bounded, Reflect and Grow.
+		univ.setEdges((univ.life.Edges + 1) % (life.Grow + 1))
+		buttonBar.Refresh()
+	case selectImage:
+		setSelecting(!selecting)
+	case copyImage:
+		copySelection()
+	case cutImage:
+		cutSelection()
+	case pasteImage:
+		pasteClipboard()
+	case rotateImage:
+		transformSelection(text("edit.rotate"), (*life.Pattern).Rotate)
+	case flipImage:
+		transformSelection(text("edit.flip"), (*life.Pattern).Flip)
+	case undoImage:
+		univ.undo()
+	case redoImage:
+		univ.redo()
+	case shareImage:
+		share()
+	case versusImage:
+		tapVersus()
+	case brushImage:
+		nextBrush()
+	case wallImage:
+		toggleWalls()
+	case nudgeLeftImage, nudgeRightImage, nudgeUpImage, nudgeDownImage:
+		univ.nudge(nudges[img], false)
+	case analyzeImage:
+		toggleAnalysis()
+	case fitImage:
+		startFit()
+	case showcaseImage:
+		startShowcase()
+	case profileImage:
+		exportProfile()
+	case searchImage:
+		if search == nil {
+			startSearch()
+		} else {
+			stopSearch()
+		}
+	case soundImage:
+		muted = !muted
+		buttonBar.Refresh()
+		if err := saveSettings(); err != nil {
+			log.Printf("saving the settings: %v", err)
+		}
 	}
+	sounds.click()
+	pulse(tapPulse)
 }
 
 func loadScene() {
+	cfg = loadConfig()
+	loadStrings()
+	cellSize = cfg.cellSize
+	speed = speedLevels[speedLevel(cfg.speed)]
+	limiter = speedLimiter{limiterConfig: cfg.limiter}
+	follow = follower{on: cfg.follow, last: -1}
+	seedMode = cfg.seedMode
+	currentTheme = cfg.theme
+	muted = !cfg.sound
+	if sounds == nil {
+		var err error
+		if sounds, err = newMixer(); err != nil {
+			log.Printf("opening the audio device: %v, sounds disabled", err)
+		}
+	}
+	buildScene(nil)
+	if cfg.showcase {
+		startShowcase()
+	} else {
+		startTutorial()
+	}
+	if cfg.console {
+		openConsole()
+	}
+}
+
+// placeMask lays the mask over the button bar and the system bar, for the screen size.
+func placeMask() {
+	eng.SetTransform(mask, f32.Affine{
+		{float32(geom.Width + 0.1), 0, -0.1},
+		{0, float32(systemBarHeight + buttonBarHeight), -systemBarHeight},
+	})
+}
+
+// buildScene creates the textures and the nodes of the scene showing l, or the saved game if l is
+// nil, or else a random one.
+func buildScene(l *life.Life) {
+	// The views of the former scene, if any, went with its engine.
+	dropViews()
+	scaleControls()
 	textures = loadTextures()
+	setPack(readPack(packDir()))
 	scene = &sprite.Node{}
 	eng.Register(scene)
 	eng.SetTransform(scene, f32.Affine{
 		{1, 0, 0.1},
 		{0, 1, systemBarHeight},
 	})
-	buttonBar = newButtonMap(pauseImage, decSpeedImage, incSpeedImage, replayImage)
+	grid, mask = &sprite.Node{}, &sprite.Node{}
+	for _, n := range []*sprite.Node{grid, mask} {
+		eng.Register(n)
+		scene.AppendChild(n)
+	}
+	eng.SetSubTex(mask, *textures[backgroundImage])
+	_, buttonBarHeight = barLayout(len(buttonSlots(cfg.buttons)))
+	placeMask()
+	// The universe goes first as the faces of the buttons depend on it.
+	univ = newUniverse(geom.Height-systemBarHeight-buttonBarHeight, geom.Width)
+	if l != nil {
+		if w, h := l.Bounds(); w != univ.cols || h != univ.rows {
+			l.Resize(univ.cols, univ.rows, (univ.cols-w)/2, (univ.rows-h)/2)
+		}
+		univ.adopt(l)
+	} else {
+		univ.startFrom(startupSource())
+	}
+	buttonBar = newButtonBar(cfg.buttons...)
+	status = newHUD(buttonBar)
+	if cfg.sparkline {
+		if spark == nil {
+			spark = &sparkline{}
+		}
+		spark.attach()
+	}
+	laidOut = geom.Point{X: geom.Width, Y: geom.Height}
 
-	u := newUniverse(geom.Height-systemBarHeight-buttonBarHeight, geom.Width)
-	count := uint32(1)
 	scene.Arranger = arrangerFunc(func(eng sprite.Engine, n *sprite.Node, t clock.Time) {
-		if count%renderEvery == 0 {
-			u.Step()
+		u := univ
+		status.update(u.life)
+		elapsed := t - lastArrange
+		if lastArrange < 0 {
+			elapsed = 0
+		}
+		lastArrange = t
+		if versus != nil {
+			// The match steps on its own, paused or not.
+			versus.arrange(float64(elapsed))
+			return
+		}
+		if compare != nil {
+			// The games compared step on their own, in lockstep.
+			if !paused {
+				compare.arrange(float64(elapsed))
+			}
+			return
+		}
+		if paused || forward != nil {
+			return
+		}
+		// The clock ticks 60 times per second.
+		u.due += float64(elapsed) * limiter.speed(speed) / 60
+		if c := limiter.catchUp(speed); u.due > c {
+			u.due = c
 		}
-		if count == renderEvery {
-			count = 0
+		u.drift(float64(elapsed) * limiter.speed(speed) / 60)
+		stepped := 0
+		for ; u.due >= 1 && !paused; u.due-- {
+			stepped++
+			if !u.Step() {
+				continue
+			}
+			// The showcase runs the patterns on to the end of their entries, and mutations revive
+			// the games that stagnate.
+			if p, d, ok := u.history.stagnates(u.life); ok && demo == nil && !u.life.Mutation.Active() {
+				g := u.life.Generation() - p
+				log.Printf("stabilized at generation %d, period %d, drift %v", g, p, d)
+				showBanner(g, p, d, u.life.Population())
+				switch {
+				case cfg.screensaver:
+					scheduleReseed(t)
+				case cfg.autoPause:
+					setPaused(true)
+				}
+			}
 		}
-		count++
+		if limiter.observe(elapsed, stepped, speed) {
+			speedIndicator.show()
+		}
+		u.screensave(t)
 	})
 }
 
@@ -229,38 +1144,504 @@ const (
 	emptyImage    = "empty"
 	androidImage  = "android"
 	pauseImage    = "pause"
+	playImage     = "play"
 	decSpeedImage = "speed_decrease"
 	incSpeedImage = "speed_increase"
 	replayImage   = "replay"
+	edgesImage    = "edges"
+	boundedImage  = "bounded"
+	reflectImage  = "reflect"
+	growImage     = "grow"
+	stableImage   = "stable"
+	periodImage   = "period"
+	ruleImage     = "rule"
+	stepImage     = "step"
+	backImage     = "back"
+	patternImage  = "pattern"
+	agesImage     = "ages"
+	exportImage   = "export"
+	recordImage   = "record"
+	themeImage    = "theme"
+	settingsImage = "settings"
+	seedImage     = "seed"
+	symmetryImage = "symmetry"
+	soundImage    = "sound"
+	muteImage     = "mute"
+	selectImage   = "select"
+	copyImage     = "copy"
+	cutImage      = "cut"
+	pasteImage    = "paste"
+	rotateImage   = "rotate"
+	flipImage     = "flip"
+	undoImage     = "undo"
+	redoImage     = "redo"
+	shareImage    = "share"
+	versusImage   = "versus"
+	wonImage      = "won"
+	lostImage     = "lost"
+	searchImage   = "search"
+	forwardImage  = "forward"
+	trailImage    = "trail"
+	hapticsImage  = "haptics"
+	accessImage   = "accessibility"
+	analyzeImage  = "analyze"
+	fitImage      = "fit"
+	localeImage   = "locale"
+	largeImage    = "large"
+	compactImage  = "compact"
+	showcaseImage = "showcase"
+	mutationImage = "mutation"
+	profileImage  = "profile"
+	followImage   = "follow"
+	ringImage     = "ring" // Around the control of a step of the tutorial.
+	dotImage      = "dot"  // One per step of the tutorial.
+	stopImage     = "stop"
+	digitsImage   = "digits"
+	buttonsImage  = "buttons" // Atlas of the button images.
+
+	// The brushes, see brushImages. The brush button shows the current one.
+	brushImage      = "brush"
+	cellBrushImage  = "cell_brush"
+	blockBrushImage = "block_brush"
+	lineBrushImage  = "line_brush"
+	sprayBrushImage = "spray_brush"
+
+	// The walls, see wallImages. The wall button shows their polarity.
+	wallImage     = "wall"
+	liveWallImage = "live_wall"
+
+	// The arrows of the tool bar nudging every cell, see nudgeImages.
+	nudgeLeftImage  = "nudge_left"
+	nudgeRightImage = "nudge_right"
+	nudgeUpImage    = "nudge_up"
+	nudgeDownImage  = "nudge_down"
+
+	// Generated, not loaded from assets.
+	echoImage          = "echo"
+	youngImage         = "young"
+	adultImage         = "adult"
+	oldImage           = "old"
+	ancientImage       = "ancient"
+	redImage           = "red"
+	blueImage          = "blue"
+	yellowImage        = "yellow"
+	dyingImage         = "dying"
+	fadingImage        = "fading"
+	ashImage           = "ash"
+	outOfBoundsImage   = "out_of_bounds"
+	borderImage        = "border"
+	wrapBorderImage    = "wrap_border"
+	reflectBorderImage = "reflect_border"
+	growBorderImage    = "grow_border"
+	backgroundImage    = "background"
+	brickImage         = "brick"
+	liveBrickImage     = "live_brick"
 )
 
+// ageTints are the images of alive cells by age bracket, generated by tinting androidImage with the
+// color of the bracket, ramping from green for the young cells to red for the ancient ones. Newborn
+// cells, and all of them when ages are not shown, use androidImage.
+var ageTints = []struct {
+	name   string
+	minAge int // Steps survived, see life.Life.Age.
+	c      color.NRGBA
+}{
+	{youngImage, 1, color.NRGBA{0x55, 0xbb, 0x33, 0xff}},     // 2 to 5 generations.
+	{adultImage, 5, color.NRGBA{0xbb, 0xbb, 0x22, 0xff}},     // 6 to 20 generations.
+	{oldImage, 20, color.NRGBA{0xee, 0x77, 0x22, 0xff}},      // 21 to 100 generations.
+	{ancientImage, 100, color.NRGBA{0xcc, 0x22, 0x22, 0xff}}, // More than 100 generations.
+}
+
+// colorTints are the images of alive cells of the colors from 1 of games of several colors, see
+// life.Life.Colors, generated by tinting androidImage with the color. Cells of color 0 use
+// androidImage. Colors are shown rather than ages.
+var colorTints = []struct {
+	name string
+	c    color.NRGBA
+}{
+	{redImage, color.NRGBA{0xdd, 0x44, 0x33, 0xff}},
+	{blueImage, color.NRGBA{0x33, 0x66, 0xdd, 0xff}},
+	{yellowImage, color.NRGBA{0xee, 0xaa, 0x22, 0xff}},
+}
+
+// decayTints are the images of the dying cells under rules of several dying states, see
+// life.Rule.States, generated by tinting androidImage with the color of the state as the cells fade
+// out. Rules of more dying states than images share each image between consecutive states.
+var decayTints = []struct {
+	name string
+	c    color.NRGBA
+}{
+	{dyingImage, color.NRGBA{0xee, 0x77, 0x22, 0xff}},
+	{fadingImage, color.NRGBA{0x99, 0x44, 0x22, 0xff}},
+	{ashImage, color.NRGBA{0x55, 0x33, 0x33, 0xff}},
+}
+
+// brickTints are the images of the cells pinned dead then alive, see life.Life.Pin, generated as
+// bricks of the color by bricks.
+var brickTints = []struct {
+	name string
+	c    color.NRGBA
+}{
+	{brickImage, color.NRGBA{0xaa, 0x55, 0x44, 0xff}},
+	{liveBrickImage, color.NRGBA{0x88, 0xcc, 0x44, 0xff}},
+}
+
+// brickTint returns the index in brickTints of the image of a cell pinned alive or dead.
+func brickTint(alive bool) int {
+	if alive {
+		return 1
+	}
+	return 0
+}
+
+// decayLevel returns the index in decayTints of the image of a cell in the dying state s of r.
+func decayLevel(s int, r life.Rule) int {
+	// Cells may be in a state the rule does not have once it changed, until they decay.
+	n := r.States - 2
+	if s-2 >= n {
+		return len(decayTints) - 1
+	}
+	return (s - 2) * len(decayTints) / n
+}
+
+// cellImage returns the image of the alive cell (i, j) of u, which tells its color or age, in the
+// current frame of its pulse if the cells pulse, see cfg.cellSprite.
+func (u *universe) cellImage(i, j int) string {
+	img := androidImage
+	switch {
+	case u.life.Colors > 1 && u.life.Color(i, j) > 0:
+		img = colorTints[u.life.Color(i, j)-1].name
+	case u.life.Colors <= 1 && ageColors:
+		img = ageImage(u.life.Age(i, j))
+	}
+	if cfg.cellSprite == pulseSprite {
+		return pulseFrameImage(img, pulseFrame)
+	}
+	return img
+}
+
+// ageImage returns the image of an alive cell of the given age.
+func ageImage(age int) string {
+	img := androidImage
+	for _, t := range ageTints {
+		if age >= t.minAge {
+			img = t.name
+		}
+	}
+	return img
+}
+
+// ageMarks are the ages at which the cells enter another age bracket, see universe.AgeMarks.
+var ageMarks = func() []int {
+	var marks []int
+	for _, t := range ageTints {
+		marks = append(marks, t.minAge)
+	}
+	return marks
+}()
+
+// An imageAsset describes an image shipped in several pixel sizes, the variant n px high being
+// stored as <name>_<n>.png.
+type imageAsset struct {
+	name  string
+	sizes []int   // Heights in px of the available variants.
+	pt    geom.Pt // Displayed height in Pt, the cell size if 0.
+	eager bool    // Whether it is needed by the first frame.
+}
+
+var imageAssets = []imageAsset{
+	{name: androidImage, sizes: []int{48, 72, 144}, eager: true},
+	// The sizes of the atlas are those of its images, see atlasManifest.
+	{name: buttonsImage, sizes: []int{48, 72, 144}, pt: buttonSize, eager: true},
+	{name: digitsImage, sizes: []int{16, 24, 48}, pt: hudDigitHeight},
+}
+
+// variants returns the file names of the variants of a in order of preference for the current
+// pixel density: the smallest one at least as high as the displayed height, then the next larger
+// ones, then the smaller ones from largest to smallest.
+func (a imageAsset) variants() []string {
+	siz := a.pt
+	if siz == 0 {
+		siz = cellSize
+	}
+	want := int(siz.Px() + 0.5)
+
+	sizes := append([]int(nil), a.sizes...)
+	sort.Ints(sizes)
+	k := sort.SearchInts(sizes, want)
+	var names []string
+	for _, n := range sizes[k:] {
+		names = append(names, fmt.Sprintf("%s_%d.png", a.name, n))
+	}
+	for i := k - 1; i >= 0; i-- {
+		names = append(names, fmt.Sprintf("%s_%d.png", a.name, sizes[i]))
+	}
+	return names
+}
+
 func loadTextures() map[string]*sprite.SubTex {
 	m := make(map[string]*sprite.SubTex)
-	for _, name := range []string{androidImage, pauseImage, replayImage, incSpeedImage, decSpeedImage} {
-		tex, err := newTexture(name)
+	// Only the images needed by the first frame are loaded now, the rest are decoded in the
+	// background and replace their placeholders as they arrive (see uploadDecoded).
+	var pending []imageAsset
+	for _, a := range imageAssets {
+		if !a.eager {
+			pending = append(pending, a)
+			continue
+		}
+		img := decodeImage(a)
+		tex, err := eng.LoadTexture(themed(a.name, img))
 		if err != nil {
 			log.Fatal(err)
 		}
 		// Units are in px.
-		m[name] = &sprite.SubTex{tex, image.Rect(0, 0, 72, 72)}
+		setTexture(m, a.name, tex, img.Bounds())
+		if a.name == buttonsImage {
+			// Disabled buttons are dimmed.
+			if tex, err = eng.LoadTexture(dimmed(themed(a.name, img))); err != nil {
+				log.Fatal(err)
+			}
+			m[disabledImage(a.name)] = &sprite.SubTex{tex, img.Bounds()}
+			for name, r := range atlasRects(img.Bounds()) {
+				m[disabledImage(name)] = &sprite.SubTex{tex, r}
+			}
+		}
+		if a.name == androidImage {
+			if tex, err = eng.LoadTexture(dimmed(img)); err != nil {
+				log.Fatal(err)
+			}
+			m[echoImage] = &sprite.SubTex{tex, img.Bounds()}
+			for name, pulsed := range pulsedImages(img) {
+				if tex, err = eng.LoadTexture(pulseStrip(pulsed)); err != nil {
+					log.Fatal(err)
+				}
+				for k, r := range pulseRects(img.Bounds()) {
+					m[pulseFrameImage(name, k)] = &sprite.SubTex{tex, r}
+				}
+			}
+			for _, t := range ageTints {
+				if tex, err = eng.LoadTexture(tinted(img, t.c)); err != nil {
+					log.Fatal(err)
+				}
+				m[t.name] = &sprite.SubTex{tex, img.Bounds()}
+			}
+			for _, t := range colorTints {
+				if tex, err = eng.LoadTexture(tinted(img, t.c)); err != nil {
+					log.Fatal(err)
+				}
+				m[t.name] = &sprite.SubTex{tex, img.Bounds()}
+			}
+			for _, t := range decayTints {
+				if tex, err = eng.LoadTexture(tinted(img, t.c)); err != nil {
+					log.Fatal(err)
+				}
+				m[t.name] = &sprite.SubTex{tex, img.Bounds()}
+			}
+			for _, t := range brickTints {
+				if tex, err = eng.LoadTexture(bricks(img.Bounds(), t.c)); err != nil {
+					log.Fatal(err)
+				}
+				m[t.name] = &sprite.SubTex{tex, img.Bounds()}
+			}
+			for l := 1; l <= trailLevels; l++ {
+				if tex, err = eng.LoadTexture(faded(img, float64(l)/(trailLevels+1))); err != nil {
+					log.Fatal(err)
+				}
+				m[trailLevelImage(l)] = &sprite.SubTex{tex, img.Bounds()}
+			}
+		}
 	}
 	// Reuse the android image left-top corner (1 px square).
 	m[emptyImage] = &sprite.SubTex{m[androidImage].T, image.Rect(1, 1, 2, 2)}
+
+	names, img := swatches()
+	tex, err := eng.LoadTexture(img)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for k, name := range names {
+		m[name] = &sprite.SubTex{tex, image.Rect(4*k+1, 1, 4*k+3, 3)}
+	}
+	loadRipples(m)
+
+	for _, a := range pending {
+		placeholder := *m[outOfBoundsImage]
+		m[a.name] = &placeholder
+	}
+	for d := 0; d < 10; d++ {
+		placeholder := *m[emptyImage]
+		m[digitImage(d)] = &placeholder
+	}
+	// The variants and the assets they are read from are resolved here, as the pixel density and
+	// the cell size may change meanwhile.
+	variants, open := make([][]string, len(pending)), openAsset
+	for k, a := range pending {
+		variants[k] = a.variants()
+	}
+	go func() {
+		for k, a := range pending {
+			decoded <- decodedImage{a.name, decodeVariants(open, a.name, variants[k])}
+		}
+	}()
 	return m
 }
 
-func newTexture(name string) (sprite.Texture, error) {
-	a, err := app.Open(name + ".png")
+// tinted returns a copy of img in shades of c, keeping its transparency.
+func tinted(img image.Image, c color.NRGBA) image.Image {
+	b := img.Bounds()
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			p := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			// The brightest channel gives the shade.
+			v := p.R
+			if p.G > v {
+				v = p.G
+			}
+			if p.B > v {
+				v = p.B
+			}
+			shade := func(c uint8) uint8 { return uint8(uint16(c) * uint16(v) / 0xff) }
+			dst.SetNRGBA(x, y, color.NRGBA{shade(c.R), shade(c.G), shade(c.B), p.A})
+		}
+	}
+	return dst
+}
+
+// bricks returns an image of bounds b of bricks of color c laid in four courses, their joints
+// staggered, with the mortar in a darker shade. As the cell images, it leaves a gap along its top
+// and left edges, the top-left corner included.
+func bricks(b image.Rectangle, c color.NRGBA) image.Image {
+	dst := image.NewNRGBA(b)
+	w, h := b.Dx(), b.Dy()
+	gap := (w + 15) / 16
+	mortar := color.NRGBA{c.R / 2, c.G / 2, c.B / 2, c.A}
+	for y := gap; y < h; y++ {
+		course := y * 4 / h
+		// A bed of mortar on top of every course, and a joint every half width, shifted by a
+		// quarter on every other course.
+		bed, shift := y-course*h/4 < gap, course%2*w/4
+		for x := gap; x < w; x++ {
+			p := c
+			if bed || (x+shift)%(w/2) < gap {
+				p = mortar
+			}
+			dst.SetNRGBA(b.Min.X+x, b.Min.Y+y, p)
+		}
+	}
+	return dst
+}
+
+// dimmed returns a copy of img with a third of its opacity.
+func dimmed(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			c.A /= 3
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	return dst
+}
+
+// faded returns a copy of img with the fraction a of its opacity.
+func faded(img image.Image, a float64) image.Image {
+	b := img.Bounds()
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			c.A = uint8(float64(c.A)*a + 0.5)
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	return dst
+}
+
+// setTexture stores in m the sub-textures of the image of the given name, occupying r in tex.
+// The digits strip is also sliced into one sub-texture per digit, and the buttons atlas into one
+// per button image.
+func setTexture(m map[string]*sprite.SubTex, name string, tex sprite.Texture, r image.Rectangle) {
+	m[name] = &sprite.SubTex{tex, r}
+	if name == buttonsImage {
+		for img, rr := range atlasRects(r) {
+			m[img] = &sprite.SubTex{tex, rr}
+		}
+	}
+	if name == digitsImage {
+		w := r.Dx() / 10
+		for d := 0; d < 10; d++ {
+			m[digitImage(d)] = &sprite.SubTex{tex, image.Rect(r.Min.X+d*w, r.Min.Y, r.Min.X+(d+1)*w, r.Max.Y)}
+		}
+	}
+}
+
+// digitImage returns the name of the sub-texture of digit d, see setTexture.
+func digitImage(d int) string {
+	return fmt.Sprintf("%s%d", digitsImage, d)
+}
+
+// A decodedImage is an image asset ready to be uploaded as a texture.
+type decodedImage struct {
+	name string
+	img  image.Image
+}
+
+// uploadDecoded uploads the images decoded in the background so far and shows them in place of
+// their placeholders. Textures can only be loaded on the GL thread, hence it is called from draw.
+func uploadDecoded() {
+	for {
+		select {
+		case d := <-decoded:
+			tex, err := eng.LoadTexture(themed(d.name, d.img))
+			if err != nil {
+				log.Fatal(err)
+			}
+			setTexture(textures, d.name, tex, d.img.Bounds())
+			buttonBar.Refresh()
+			status.refresh()
+			speedIndicator.show()
+		default:
+			return
+		}
+	}
+}
+
+// decodeImage returns the best variant of a that can be read. If none can, a generated image is
+// returned rather than stopping the app.
+func decodeImage(a imageAsset) image.Image {
+	return decodeVariants(openAsset, a.name, a.variants())
+}
+
+// decodeVariants returns the first of the variants of the image of the given name that open can
+// read, or else a generated image. It only reads the assets, so that it can run in the background.
+func decodeVariants(open func(string) (app.ReadSeekCloser, error), name string,
+	variants []string) image.Image {
+	for _, v := range variants {
+		img, err := readImage(open, v)
+		if err == nil {
+			return img
+		}
+		log.Printf("%s: %v", v, err)
+	}
+	log.Printf("%s: no usable variant; using a fallback image", name)
+	return fallbackImage(name)
+}
+
+// openAsset opens the asset of the given name. Tests replace it to run without the assets.
+var openAsset = app.Open
+
+func readImage(open func(string) (app.ReadSeekCloser, error), name string) (image.Image, error) {
+	a, err := open(name)
 	if err != nil {
 		return nil, err
 	}
 	defer a.Close()
 
 	img, _, err := image.Decode(a)
-	if err != nil {
-		return nil, err
-	}
-	return eng.LoadTexture(img)
+	return img, err
 }
 
 type arrangerFunc func(e sprite.Engine, n *sprite.Node, t clock.Time)
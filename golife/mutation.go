@@ -0,0 +1,61 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+const (
+	// mutantTime is how long the cells flipped by a mutation are highlighted.
+	mutantTime = 500 * time.Millisecond
+	// maxMutants is the number of cells highlighted at most, the flips beyond only showing.
+	maxMutants = 64
+)
+
+// mutation returns the mutation of the game as told by cfg.mutation, drawn from the seed of the
+// random universe so that a run mutates the same way once replayed, see life.Run.
+func mutation() life.Mutation {
+	if !cfg.mutation {
+		return life.Mutation{}
+	}
+	return life.Mutation{Every: cfg.mutationEvery, Cells: cfg.mutationCells, Seed: soupSeed}
+}
+
+// setMutation turns the mutation of the game on or off.
+func setMutation(on bool) {
+	cfg.mutation = on
+	univ.life.Mutation = mutation()
+	log.Printf("mutation of %d cells every %d generations %v", cfg.mutationCells, cfg.mutationEvery, on)
+}
+
+// OnMutation implements life.MutationListener: the cell shows its new state, highlighted.
+func (u *universe) OnMutation(i, j int, alive bool) {
+	u.show(i, j, alive)
+	if len(views.mutants) >= maxMutants {
+		return
+	}
+	n := u.newCell(i, j)
+	eng.SetSubTex(n, *textures[wrapBorderImage])
+	views.mutants = append(views.mutants, n)
+	views.mutantsEnd = time.Now().Add(mutantTime)
+}
+
+// updateMutants removes the highlights of the flipped cells once due. It is called every frame.
+func updateMutants() {
+	if len(views.mutants) > 0 && time.Now().After(views.mutantsEnd) {
+		unmarkMutants()
+	}
+}
+
+// unmarkMutants removes the highlights of the flipped cells, if any, and unregisters their nodes.
+func unmarkMutants() {
+	for _, n := range views.mutants {
+		n.Parent.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	views.mutants = nil
+}
@@ -0,0 +1,53 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+func TestMutation(t *testing.T) {
+	gestureScene(t)
+	nodes := countNodes(scene)
+	cfg.mutationEvery, cfg.mutationCells = 2, 3
+	setMutation(true)
+	univ.Step()
+	if len(views.mutants) != 0 || len(alive()) != 0 {
+		t.Fatalf("%d cells highlighted, %d alive before the first mutation", len(views.mutants), len(alive()))
+	}
+	univ.Step()
+	if n := len(alive()); len(views.mutants) != 3 || n == 0 || n > 3 || univ.life.Mutations() != 3 {
+		t.Fatalf("%d cells highlighted, %d alive, %d mutations", len(views.mutants), n, univ.life.Mutations())
+	}
+	// The flipped cells show.
+	fake := eng.(*fakeEngine)
+	for _, c := range alive() {
+		if x := fake.subTex[univ.cells[c[1]*univ.cols+c[0]]]; x == *textures[emptyImage] {
+			t.Errorf("cell %v alive shows empty", c)
+		}
+	}
+	views.mutantsEnd = time.Now().Add(-time.Second)
+	updateMutants()
+	if n := countNodes(scene); len(views.mutants) != 0 || n != nodes {
+		t.Errorf("%d cells highlighted, %d nodes in the scene, %d before", len(views.mutants), n, nodes)
+	}
+
+	// A replay mutates the same way, and a run carries the mutation.
+	univ.reseed(7)
+	m := univ.life.Mutation
+	if !m.Active() || m.Seed != 7 {
+		t.Fatalf("mutation %+v once reseeded", m)
+	}
+	r := life.NewRun(univ.life, cfg.density, seedMode, 7)
+	setMutation(false)
+	if univ.life.Mutation.Active() {
+		t.Fatalf("mutation %+v once off", univ.life.Mutation)
+	}
+	univ.startRun(r)
+	if !cfg.mutation || univ.life.Mutation != m {
+		t.Errorf("run started with mutation %v: %+v, want %+v", cfg.mutation, univ.life.Mutation, m)
+	}
+}
@@ -0,0 +1,440 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+// Package netplay plays competitive Life on two devices over TCP. Each player seeds cells of their
+// own color on their half of a shared field, then both devices step the same Immigration game in
+// lockstep, comparing their states every SyncEvery generations. The player with more cells once
+// the match is over wins.
+//
+// Devices exchange JSON messages, one per line. The guest starts with a hello telling the largest
+// field it shows, which the host answers with the match to play, on a field fitting both devices.
+// Both then send their seeds, and a sync telling their state at every sync generation. Since
+// stepping is deterministic, the states only differ if a device misbehaves.
+package netplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// Port is the TCP port hosts listen on, unless told otherwise.
+const Port = 7070
+
+// SyncEvery is the number of generations between the syncs of the devices. A device does not step
+// past a sync generation before its peer reached it with the same state.
+const SyncEvery = 16
+
+// protocolVersion is the version of the messages, which both devices must speak.
+const protocolVersion = 1
+
+// handshakeTimeout bounds the exchange of hellos, so that a mute peer does not hang the game.
+const handshakeTimeout = 10 * time.Second
+
+// maxMessage is the size in bytes of the longest message read from the peer, newline included, so
+// that a hostile peer cannot exhaust the memory.
+const maxMessage = 1 << 20
+
+// ErrDesync is returned once the states of both devices differ at a sync generation.
+var ErrDesync = errors.New("netplay: the devices are out of sync")
+
+// A Match holds the settings of a match, chosen by the host.
+type Match struct {
+	Cols, Rows  int    // Size of the field.
+	Rule        string // In B/S notation, see life.ParseRule.
+	Generations int    // Length of the match.
+	Budget      int    // Number of cells each player seeds.
+}
+
+// message is any message between devices. Type tells which fields are set.
+type message struct {
+	Type       string   `json:"type"` // One of hello, seeds and sync.
+	Version    int      `json:"version,omitempty"`
+	Match      *Match   `json:"match,omitempty"`
+	Cells      [][2]int `json:"cells,omitempty"`
+	Generation int      `json:"generation,omitempty"`
+	Hash       uint64   `json:"hash,omitempty"`
+	Pops       [2]int   `json:"pops"`
+}
+
+// A Game is a match between the player of this device and the one of its peer.
+type Game struct {
+	Match Match
+	// Player is the color of the cells of this device: 0 for the host, 1 for the guest.
+	Player int
+	// Life is the shared game, nil until both players seeded.
+	Life *life.Life
+
+	conn net.Conn
+	enc  *json.Encoder
+	r    *bufio.Reader
+	in   chan message
+	errc chan error // Receives why in was closed.
+	// done is closed by Close, which stops reading the messages the game no longer handles.
+	done      chan struct{}
+	closeOnce sync.Once
+	seeds     [2][][2]int
+	ready     [2]bool
+	// syncs holds the syncs of the peer not checked yet, by generation.
+	syncs map[int]message
+	over  bool
+	// lost tells why the connection was lost, which only ends the game if it still needs messages
+	// from the peer: the last sync may come right before the peer closes.
+	lost error
+	err  error
+}
+
+// Host waits on ln for a guest, and returns the game offering it m, shrunk to fit the field of the
+// guest if needed.
+func Host(ln net.Listener, m Match) (*Game, error) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return nil, err
+	}
+	g := newGame(conn, 0)
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	hello, err := g.handshake()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if c, r := hello.Match.Cols, hello.Match.Rows; c < 2 || r < 1 {
+		conn.Close()
+		return nil, fmt.Errorf("netplay: guest field of %dx%d too small for a match", c, r)
+	}
+	if c := hello.Match.Cols; c < m.Cols {
+		m.Cols = c
+	}
+	if r := hello.Match.Rows; r < m.Rows {
+		m.Rows = r
+	}
+	g.Match = m
+	if err := g.enc.Encode(message{Type: "hello", Version: protocolVersion, Match: &m}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return g.start(), nil
+}
+
+// Join connects to the host at addr, and returns the game it offers. The field of the match is at
+// most cols*rows.
+func Join(addr string, cols, rows int) (*Game, error) {
+	conn, err := net.DialTimeout("tcp", addr, handshakeTimeout)
+	if err != nil {
+		return nil, err
+	}
+	g := newGame(conn, 1)
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	err = g.enc.Encode(message{Type: "hello", Version: protocolVersion, Match: &Match{Cols: cols, Rows: rows}})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	hello, err := g.handshake()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	m := *hello.Match
+	if m.Cols < 2 || m.Cols > cols || m.Rows < 1 || m.Rows > rows || m.Generations < 1 || m.Budget < 1 {
+		conn.Close()
+		return nil, fmt.Errorf("netplay: invalid match %+v", m)
+	}
+	if _, err := life.ParseRule(m.Rule); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("netplay: invalid match: %v", err)
+	}
+	g.Match = m
+	return g.start(), nil
+}
+
+func newGame(conn net.Conn, player int) *Game {
+	return &Game{
+		Player: player,
+		conn:   conn,
+		enc:    json.NewEncoder(conn),
+		r:      bufio.NewReader(conn),
+		in:     make(chan message, 64),
+		errc:   make(chan error, 1),
+		done:   make(chan struct{}),
+		syncs:  make(map[int]message),
+	}
+}
+
+// read reads the next message from the peer, a line of at most maxMessage bytes.
+func (g *Game) read() (message, error) {
+	var m message
+	var line []byte
+	for {
+		chunk, err := g.r.ReadSlice('\n')
+		if len(line)+len(chunk) > maxMessage {
+			return m, fmt.Errorf("netplay: message over %d bytes", maxMessage)
+		}
+		line = append(line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && len(line) > 0 {
+			return m, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return m, err
+		}
+		break
+	}
+	err := json.Unmarshal(line, &m)
+	return m, err
+}
+
+// handshake reads the hello of the peer.
+func (g *Game) handshake() (message, error) {
+	m, err := g.read()
+	if err != nil {
+		return m, fmt.Errorf("netplay: reading the hello: %v", err)
+	}
+	if m.Type != "hello" || m.Match == nil {
+		return m, fmt.Errorf("netplay: expected a hello, got %q", m.Type)
+	}
+	if m.Version != protocolVersion {
+		return m, fmt.Errorf("netplay: peer speaks version %d, not %d", m.Version, protocolVersion)
+	}
+	return m, nil
+}
+
+// start lifts the handshake deadline and reads the next messages in the background, until the
+// connection is lost or the game closed.
+func (g *Game) start() *Game {
+	g.conn.SetDeadline(time.Time{})
+	go func() {
+		for {
+			m, err := g.read()
+			if err != nil {
+				g.errc <- err
+				close(g.in)
+				return
+			}
+			select {
+			case g.in <- m:
+			case <-g.done:
+				return
+			}
+		}
+	}()
+	return g
+}
+
+// Close ends the game and closes the connection, which the peer sees as a loss of the connection.
+func (g *Game) Close() error {
+	if g.err == nil {
+		g.err = errors.New("netplay: game closed")
+	}
+	g.closeOnce.Do(func() { close(g.done) })
+	return g.conn.Close()
+}
+
+// Update handles the messages received from the peer so far, without blocking. It returns the error
+// ending the game, if any, for instance once the connection is lost or the peer misbehaves.
+func (g *Game) Update() error {
+	for g.err == nil && g.lost == nil {
+		select {
+		case m, ok := <-g.in:
+			if !ok {
+				g.lost = fmt.Errorf("netplay: connection lost: %v", <-g.errc)
+				break
+			}
+			g.err = g.handle(m)
+		default:
+			return nil
+		}
+	}
+	if _, last := g.syncs[g.Match.Generations]; g.err == nil && !g.over && !last {
+		g.err = g.lost
+	}
+	return g.err
+}
+
+// handle handles m, received from the peer.
+func (g *Game) handle(m message) error {
+	peer := 1 - g.Player
+	switch m.Type {
+	case "seeds":
+		if g.ready[peer] {
+			return fmt.Errorf("netplay: peer seeded twice")
+		}
+		for _, c := range m.Cells {
+			if !g.half(peer, c[0], c[1]) {
+				return fmt.Errorf("netplay: peer seeded (%d, %d) out of its half", c[0], c[1])
+			}
+		}
+		if len(m.Cells) > g.Match.Budget {
+			return fmt.Errorf("netplay: peer seeded %d cells over a budget of %d", len(m.Cells), g.Match.Budget)
+		}
+		g.seeds[peer], g.ready[peer] = m.Cells, true
+		g.begin()
+	case "sync":
+		gen := m.Generation
+		if gen < 0 || gen > g.Match.Generations || !g.isSync(gen) {
+			return fmt.Errorf("netplay: peer synced generation %d, not a sync generation of the match", gen)
+		}
+		if _, ok := g.syncs[gen]; ok || g.Life != nil && gen < g.Life.Generation() {
+			return fmt.Errorf("netplay: peer synced generation %d twice", gen)
+		}
+		g.syncs[gen] = m
+	default:
+		return fmt.Errorf("netplay: unexpected %q message", m.Type)
+	}
+	return nil
+}
+
+// half reports whether (x, y) is a cell of the half of the field of player: the left one for the
+// host, the right one for the guest.
+func (g *Game) half(player, x, y int) bool {
+	mid := g.Match.Cols / 2
+	if y < 0 || y >= g.Match.Rows {
+		return false
+	}
+	if player == 0 {
+		return x >= 0 && x < mid
+	}
+	return x >= mid && x < g.Match.Cols
+}
+
+// Own reports whether (x, y) is a cell of the half of the field this player seeds.
+func (g *Game) Own(x, y int) bool {
+	return g.half(g.Player, x, y)
+}
+
+// Seeds returns the cells seeded by this player so far.
+func (g *Game) Seeds() [][2]int {
+	return g.seeds[g.Player]
+}
+
+// Seed adds the cell (x, y) to the seeds of this player and reports whether it did. Cells already
+// seeded, out of the half of the player or over its budget are refused, as are all once ready.
+func (g *Game) Seed(x, y int) bool {
+	if g.ready[g.Player] || !g.Own(x, y) || len(g.seeds[g.Player]) >= g.Match.Budget {
+		return false
+	}
+	for _, c := range g.seeds[g.Player] {
+		if c == [2]int{x, y} {
+			return false
+		}
+	}
+	g.seeds[g.Player] = append(g.seeds[g.Player], [2]int{x, y})
+	return true
+}
+
+// Ready sends the seeds of this player, which can no longer change. The game starts once the peer
+// is ready too.
+func (g *Game) Ready() error {
+	if g.ready[g.Player] {
+		return nil
+	}
+	if err := g.enc.Encode(message{Type: "seeds", Cells: g.seeds[g.Player]}); err != nil {
+		g.err = err
+		return err
+	}
+	g.ready[g.Player] = true
+	g.begin()
+	return g.err
+}
+
+// IsReady reports whether this player sent their seeds.
+func (g *Game) IsReady() bool {
+	return g.ready[g.Player]
+}
+
+// begin creates the shared game once both players are ready. The field of the game is bounded so
+// that no player can reach the other from behind.
+func (g *Game) begin() {
+	if !g.ready[0] || !g.ready[1] || g.Life != nil {
+		return
+	}
+	r, err := life.ParseRule(g.Match.Rule)
+	if err != nil {
+		g.err = err
+		return
+	}
+	l := life.New(g.Match.Cols, g.Match.Rows)
+	l.Rule = r
+	l.Edges = life.Bounded
+	l.Colors = 2
+	for p, cells := range g.seeds {
+		for _, c := range cells {
+			l.Set(c[0], c[1], true)
+			l.SetColor(c[0], c[1], p)
+		}
+	}
+	g.Life = l
+	g.sync()
+}
+
+// isSync reports whether gen is a sync generation.
+func (g *Game) isSync(gen int) bool {
+	return gen%SyncEvery == 0 || gen == g.Match.Generations
+}
+
+// sync sends the state of the game to the peer, at a sync generation.
+func (g *Game) sync() {
+	l := g.Life
+	m := message{Type: "sync", Generation: l.Generation(), Hash: l.Hash(), Pops: g.pops()}
+	if err := g.enc.Encode(m); err != nil {
+		g.err = err
+	}
+}
+
+// pops returns the number of cells of each player.
+func (g *Game) pops() [2]int {
+	return [2]int{g.Life.ColorPopulation(0), g.Life.ColorPopulation(1)}
+}
+
+// Step steps the game one generation and reports whether it did. It does not step before the game
+// started, once over, or past a sync generation the peer did not reach yet. Once it reached the
+// same state there at the last generation, the game is over.
+func (g *Game) Step() (bool, error) {
+	if g.err != nil || g.Life == nil || g.over {
+		return false, g.err
+	}
+	l := g.Life
+	if gen := l.Generation(); g.isSync(gen) {
+		m, ok := g.syncs[gen]
+		if !ok {
+			return false, nil
+		}
+		delete(g.syncs, gen)
+		if m.Hash != l.Hash() || m.Pops != g.pops() {
+			g.err = ErrDesync
+			return false, g.err
+		}
+		if gen == g.Match.Generations {
+			g.over = true
+			return false, nil
+		}
+	}
+	l.Step()
+	if g.isSync(l.Generation()) {
+		g.sync()
+	}
+	return true, g.err
+}
+
+// Over reports whether the game is over, both devices having reached the last generation in the
+// same state.
+func (g *Game) Over() bool {
+	return g.over
+}
+
+// Score returns the number of cells of this player and of the peer.
+func (g *Game) Score() (own, peer int) {
+	if g.Life == nil {
+		return 0, 0
+	}
+	p := g.pops()
+	return p[g.Player], p[1-g.Player]
+}
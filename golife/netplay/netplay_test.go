@@ -0,0 +1,256 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package netplay
+
+import (
+	"encoding/json"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testMatch = Match{Cols: 40, Rows: 30, Rule: "B3/S23", Generations: 40, Budget: 20}
+
+// listen returns a listener on a free loopback port.
+func listen(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ln
+}
+
+// host hosts m on ln in the background, and returns where the game or error goes.
+func host(ln net.Listener, m Match) (<-chan *Game, <-chan error) {
+	games, errs := make(chan *Game, 1), make(chan error, 1)
+	go func() {
+		g, err := Host(ln, m)
+		if err != nil {
+			errs <- err
+			return
+		}
+		games <- g
+	}()
+	return games, errs
+}
+
+// until calls f until it reports true, failing t after a few seconds.
+func until(t *testing.T, what string, f func() bool) {
+	for deadline := time.Now().Add(5 * time.Second); !f(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	ln := listen(t)
+	defer ln.Close()
+	games, errs := host(ln, testMatch)
+	guest, err := Join(ln.Addr().String(), 32, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer guest.Close()
+	var hst *Game
+	select {
+	case hst = <-games:
+	case err := <-errs:
+		t.Fatal(err)
+	}
+	defer hst.Close()
+
+	// The field fits the guest.
+	for _, g := range []*Game{hst, guest} {
+		if g.Match.Cols != 32 || g.Match.Rows != 30 {
+			t.Errorf("player %d plays on %dx%d, want 32x30", g.Player, g.Match.Cols, g.Match.Rows)
+		}
+	}
+	// Each player seeds a glider on its half, mirrored.
+	for _, c := range [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}} {
+		if !hst.Seed(5+c[0], 5+c[1]) || !guest.Seed(26-c[0], 5+c[1]) {
+			t.Fatalf("seeding %v refused", c)
+		}
+	}
+	if hst.Seed(20, 5) || guest.Seed(5, 5) {
+		t.Errorf("seeded out of the half of the player")
+	}
+	if err := hst.Ready(); err != nil {
+		t.Fatal(err)
+	}
+	if err := guest.Ready(); err != nil {
+		t.Fatal(err)
+	}
+
+	until(t, "the end of the match", func() bool {
+		for _, g := range []*Game{hst, guest} {
+			if err := g.Update(); err != nil {
+				t.Fatalf("player %d: %v", g.Player, err)
+			}
+			if _, err := g.Step(); err != nil {
+				t.Fatalf("player %d: %v", g.Player, err)
+			}
+		}
+		return hst.Over() && guest.Over()
+	})
+	if hst.Life.Generation() != testMatch.Generations || hst.Life.Hash() != guest.Life.Hash() {
+		t.Errorf("games at generations %d and %d with hashes %x and %x at the end",
+			hst.Life.Generation(), guest.Life.Generation(), hst.Life.Hash(), guest.Life.Hash())
+	}
+	// The gliders meet in the middle, and the field is mirrored so they tie.
+	own, peer := hst.Score()
+	if own == 0 || own != peer {
+		t.Errorf("host scored %d to %d, want a tie", own, peer)
+	}
+	if gown, gpeer := guest.Score(); gown != peer || gpeer != own {
+		t.Errorf("guest scored %d to %d, host %d to %d", gown, gpeer, own, peer)
+	}
+}
+
+// rawGuest connects to a host of testMatch, saying hello with the field cols*rows, and returns the
+// connection and the hello of the host.
+func rawGuest(t *testing.T, ln net.Listener, cols, rows int) (net.Conn, *json.Encoder, message) {
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := json.NewEncoder(conn)
+	if err := enc.Encode(message{Type: "hello", Version: protocolVersion, Match: &Match{Cols: cols, Rows: rows}}); err != nil {
+		t.Fatal(err)
+	}
+	var hello message
+	if cols >= 2 && rows >= 1 {
+		if err := json.NewDecoder(conn).Decode(&hello); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return conn, enc, hello
+}
+
+func TestHostInvalidGuest(t *testing.T) {
+	for _, siz := range [][2]int{{1, 30}, {0, 30}, {-5, 30}, {40, 0}, {40, -1}} {
+		ln := listen(t)
+		games, errs := host(ln, testMatch)
+		conn, _, _ := rawGuest(t, ln, siz[0], siz[1])
+		select {
+		case g := <-games:
+			t.Errorf("match offered on a field of %dx%d for a guest of %dx%d", g.Match.Cols, g.Match.Rows, siz[0], siz[1])
+			g.Close()
+		case <-errs:
+		}
+		conn.Close()
+		ln.Close()
+	}
+}
+
+func TestInvalidSync(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		syncs []int
+	}{
+		{"not a sync generation", []int{5}},
+		{"negative", []int{-16}},
+		{"beyond the match", []int{48}},
+		{"twice", []int{16, 16}},
+	} {
+		ln := listen(t)
+		games, errs := host(ln, testMatch)
+		conn, enc, _ := rawGuest(t, ln, 40, 30)
+		var g *Game
+		select {
+		case g = <-games:
+		case err := <-errs:
+			t.Fatal(err)
+		}
+		for _, gen := range test.syncs {
+			if err := enc.Encode(message{Type: "sync", Generation: gen}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var err error
+		until(t, test.name, func() bool { err = g.Update(); return err != nil })
+		if !strings.Contains(err.Error(), "synced") {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		g.Close()
+		conn.Close()
+		ln.Close()
+	}
+}
+
+func TestDesync(t *testing.T) {
+	ln := listen(t)
+	defer ln.Close()
+	games, errs := host(ln, testMatch)
+	conn, enc, _ := rawGuest(t, ln, 40, 30)
+	defer conn.Close()
+	var g *Game
+	select {
+	case g = <-games:
+	case err := <-errs:
+		t.Fatal(err)
+	}
+	defer g.Close()
+	g.Seed(3, 3)
+	if err := g.Ready(); err != nil {
+		t.Fatal(err)
+	}
+	// The guest claims a cell it did not seed.
+	enc.Encode(message{Type: "seeds", Cells: [][2]int{{30, 3}}})
+	enc.Encode(message{Type: "sync", Generation: 0, Pops: [2]int{1, 2}})
+	until(t, "the desync", func() bool {
+		if err := g.Update(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := g.Step()
+		return err == ErrDesync
+	})
+}
+
+func TestOversizedMessage(t *testing.T) {
+	ln := listen(t)
+	defer ln.Close()
+	games, errs := host(ln, testMatch)
+	conn, _, _ := rawGuest(t, ln, 40, 30)
+	defer conn.Close()
+	var g *Game
+	select {
+	case g = <-games:
+	case err := <-errs:
+		t.Fatal(err)
+	}
+	defer g.Close()
+	// A sync padded past the limit, which the host stops reading at.
+	go conn.Write([]byte(`{"type": "sync"` + strings.Repeat(" ", maxMessage) + "}\n"))
+	var err error
+	until(t, "the oversized message", func() bool { err = g.Update(); return err != nil })
+	if !strings.Contains(err.Error(), "bytes") {
+		t.Errorf("oversized message: %v", err)
+	}
+}
+
+func TestCloseStopsReading(t *testing.T) {
+	base := runtime.NumGoroutine()
+	ln := listen(t)
+	defer ln.Close()
+	games, errs := host(ln, testMatch)
+	conn, enc, _ := rawGuest(t, ln, 40, 30)
+	defer conn.Close()
+	var g *Game
+	select {
+	case g = <-games:
+	case err := <-errs:
+		t.Fatal(err)
+	}
+	// More messages than buffered, never handled: closed, the game reads no more.
+	for k := 0; k < 100; k++ {
+		if err := enc.Encode(message{Type: "sync", Generation: 16}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	until(t, "the buffered messages", func() bool { return len(g.in) == cap(g.in) })
+	g.Close()
+	until(t, "the reader to stop", func() bool { return runtime.NumGoroutine() <= base })
+}
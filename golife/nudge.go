@@ -0,0 +1,48 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"time"
+)
+
+// nudgeRepeat is how often an arrow held down nudges again, once held for longPress.
+const nudgeRepeat = 100 * time.Millisecond
+
+// nudgeImages are the arrows of the tool bar nudging every cell of the field by a cell while
+// paused, from left to right, and nudges the way each one moves the cells.
+var (
+	nudgeImages = []string{nudgeLeftImage, nudgeUpImage, nudgeDownImage, nudgeRightImage}
+	nudges      = map[string]image.Point{
+		nudgeLeftImage:  {-1, 0},
+		nudgeUpImage:    {0, -1},
+		nudgeDownImage:  {0, 1},
+		nudgeRightImage: {1, 0},
+	}
+)
+
+// nudge moves every cell of u by d, keeping the generation. The cells moved beyond an edge wrap
+// around or are lost as told by the edge mode, see life.Life.Shift. Unless it goes on with the
+// edit of the previous nudge, as when an arrow is held down, it is an edit that can be undone.
+func (u *universe) nudge(d image.Point, goOn bool) {
+	if goOn {
+		u.life.Shift(d.X, d.Y)
+	} else {
+		u.transact(text("edit.nudge"), func() { u.life.Shift(d.X, d.Y) })
+	}
+	u.edited()
+	u.paint()
+}
+
+// holdNudge nudges again the cells as told by the arrow pressed by p, if held down long enough
+// since the last time. The first nudge of the hold replaces the tap, and starts the edit the
+// next ones go on with.
+func (p *pointer) holdNudge() {
+	d, ok := nudges[p.button]
+	if !ok || p.bar.Button(p.button) == nil || time.Since(p.start) < longPress || time.Since(p.repeated) < nudgeRepeat {
+		return
+	}
+	univ.nudge(d, p.heldLong)
+	p.heldLong, p.repeated = true, time.Now()
+}
@@ -0,0 +1,57 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/event"
+)
+
+func TestNudge(t *testing.T) {
+	gestureScene(t)
+	univ.life.SetCells([][2]int{{0, 3}, {1, 3}, {2, 3}}, true)
+	setPaused(true)
+	updateToolBar()
+	gen := univ.life.Generation()
+
+	// A tap moves every cell a cell, wrapping around in Wrap mode.
+	finger(1, event.TouchStart, buttonLoc(t, nudgeLeftImage))
+	finger(1, event.TouchEnd, buttonLoc(t, nudgeLeftImage))
+	if got := alive(); len(got) != 3 || !univ.life.Alive(univ.cols-1, 3) || !univ.life.Alive(0, 3) || !univ.life.Alive(1, 3) {
+		t.Errorf("nudged left to %v", got)
+	}
+	if univ.life.Generation() != gen {
+		t.Errorf("nudge stepped to generation %d", univ.life.Generation())
+	}
+
+	// Held, an arrow nudges again and again, as a single edit.
+	finger(1, event.TouchStart, buttonLoc(t, nudgeDownImage))
+	p := pointers[1]
+	p.start = time.Now().Add(-longPress)
+	for k := 0; k < 3; k++ {
+		holdPointers()
+		p.repeated = time.Now().Add(-nudgeRepeat)
+	}
+	finger(1, event.TouchEnd, buttonLoc(t, nudgeDownImage))
+	if !univ.life.Alive(0, 6) || len(alive()) != 3 {
+		t.Errorf("held arrow nudged down to %v, want 3 rows", alive())
+	}
+	univ.undo()
+	if !univ.life.Alive(0, 3) || len(alive()) != 3 {
+		t.Errorf("undo of the hold left %v", alive())
+	}
+	univ.undo()
+	if !univ.life.Alive(2, 3) || univ.life.Alive(univ.cols-1, 3) {
+		t.Errorf("undo of the tap left %v", alive())
+	}
+
+	// Bounded, the cells moved beyond the edge are lost.
+	univ.setEdges(life.Bounded)
+	univ.nudge(nudges[nudgeLeftImage], false)
+	if got := alive(); len(got) != 2 {
+		t.Errorf("nudged left of a bounded field to %v", got)
+	}
+}
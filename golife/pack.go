@@ -0,0 +1,345 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// Limits of the pattern packs, so that a forged or corrupted one does not exhaust the storage.
+const (
+	maxPackSize  = 4 << 20 // Bytes of the archive.
+	maxPackFiles = 256     // Entries of the archive.
+	maxPackRLE   = 1 << 20 // Bytes of each of its patterns once decompressed.
+)
+
+// packCategory is the category of the library the patterns of the pack are filed under.
+const packCategory = "downloaded"
+
+// errPackCanceled tells the download of the pattern pack was canceled.
+var errPackCanceled = errors.New("download canceled")
+
+// builtinPatterns is the number of patterns of library shipped with the app, followed by the ones
+// of the pack.
+var builtinPatterns = len(library)
+
+// packDir returns the directory the pattern pack is unpacked to, replaced by the next one. It is in
+// the private storage of the app, which is not cleared as the cache is.
+func packDir() string {
+	return filepath.Join(appDir(), "golife-pack")
+}
+
+// A packDownload downloads the pattern pack of cfg.packURL, a zip of RLE files, and unpacks it to
+// packDir. Canceled, the part downloaded so far is kept for the next download to resume.
+type packDownload struct {
+	cancel chan struct{} // Closed to cancel.
+	mu     sync.Mutex    // Guards the fields below, set by the download as it goes.
+	done   int64
+	total  int64 // Size of the archive, 0 until known.
+	err    error
+	over   bool
+	merged bool // Whether the outcome was merged into library, see updatePack.
+}
+
+// pack is the download of the pattern pack in progress or last over, nil if none.
+var pack *packDownload
+
+// startPack starts downloading the pattern pack, or cancels the download in progress.
+func startPack() {
+	if d := pack; d != nil {
+		d.mu.Lock()
+		over := d.over
+		d.mu.Unlock()
+		if !over {
+			close(d.cancel)
+			return
+		}
+	}
+	d := &packDownload{cancel: make(chan struct{})}
+	pack = d
+	url, dir := cfg.packURL, packDir()
+	log.Printf("downloading the pattern pack %s", url)
+	go func() {
+		archive := dir + ".zip"
+		err := fetchPack(url, archive, d.cancel, func(done, total int64) {
+			d.mu.Lock()
+			d.done, d.total = done, total
+			d.mu.Unlock()
+		})
+		if err == nil {
+			_, err = unpackPack(archive, dir)
+			os.Remove(archive)
+		}
+		d.mu.Lock()
+		d.err, d.over = err, true
+		d.mu.Unlock()
+	}()
+}
+
+// packLabel returns the text of the pack button of the picker: the progress of the download in
+// progress, or whether the last one failed.
+func packLabel() string {
+	d := pack
+	if d == nil {
+		return text("pack.get")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case !d.over && d.total > 0:
+		return strconv.FormatInt(100*d.done/d.total, 10) + "%"
+	case !d.over:
+		return "0%"
+	case d.err != nil:
+		return text("pack.failed")
+	}
+	return text("pack.get")
+}
+
+// updatePack merges the pattern pack into library once downloaded, and shows the progress of the
+// download in the picker, if open. It is called every frame.
+func updatePack() {
+	d := pack
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	over, merged, err := d.over, d.merged, d.err
+	d.merged = d.over
+	d.mu.Unlock()
+	switch {
+	case !over || merged:
+	case err != nil:
+		log.Printf("pattern pack: %v", err)
+	default:
+		setPack(readPack(packDir()))
+		log.Printf("pattern pack of %d patterns", len(library)-builtinPatterns)
+	}
+	if p := views.picker; p != nil && p.packLabel != packLabel() {
+		p.close()
+		openPicker(p.query, p.searching)
+	}
+}
+
+// setPack replaces the patterns of the pack of library by ps.
+func setPack(ps []libraryPattern) {
+	library = append(library[:builtinPatterns:builtinPatterns], ps...)
+	libraryPatterns = nil
+	if nextPattern >= len(library) {
+		nextPattern = 0
+	}
+}
+
+// readPack returns the patterns unpacked to dir by unpackPack, in lexical order of their paths,
+// named after their file.
+func readPack(dir string) []libraryPattern {
+	var ps []libraryPattern
+	filepath.Walk(dir, func(name string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() && strings.HasSuffix(name, ".rle") {
+			base := strings.TrimSuffix(filepath.Base(name), ".rle")
+			ps = append(ps, libraryPattern{name, strings.Replace(base, "_", " ", -1), packCategory})
+		}
+		return nil
+	})
+	return ps
+}
+
+// fetchPack downloads the archive of url to the file part, resuming the download if part holds
+// its beginning, and telling progress the bytes downloaded and the size of the archive, 0 if
+// unknown, as they come. A resumed download whose range does not start at the end of part starts
+// over. Closing cancel cancels it, the file part being kept.
+func fetchPack(url, part string, cancel <-chan struct{}, progress func(done, total int64)) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Cancel = cancel
+	var done int64
+	if fi, err := os.Stat(part); err == nil && fi.Size() > 0 {
+		done = fi.Size()
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", done))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		select {
+		case <-cancel:
+			return errPackCanceled
+		default:
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start, ok := rangeStart(resp.Header.Get("Content-Range")); !ok || start != done {
+			// Appended, the range would corrupt the archive.
+			resp.Body.Close()
+			if err := os.Remove(part); err != nil {
+				return err
+			}
+			log.Printf("%s: range %q resuming %d bytes, starting over", url, resp.Header.Get("Content-Range"), done)
+			return fetchPack(url, part, cancel, progress)
+		}
+	case http.StatusOK:
+		done, flag = 0, flag|os.O_TRUNC
+	default:
+		// A part as long as the archive, or of another one, is started over next time.
+		os.Remove(part)
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	var total int64
+	if resp.ContentLength >= 0 {
+		total = done + resp.ContentLength
+	}
+	if total > maxPackSize {
+		return fmt.Errorf("%s: archive of %d bytes, over %d", url, total, maxPackSize)
+	}
+	f, err := os.OpenFile(part, flag, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	progress(done, total)
+	buf := make([]byte, 32<<10)
+	for {
+		select {
+		case <-cancel:
+			return errPackCanceled
+		default:
+		}
+		n, err := resp.Body.Read(buf)
+		if done += int64(n); done > maxPackSize {
+			f.Close()
+			os.Remove(part)
+			return fmt.Errorf("%s: archive over %d bytes", url, maxPackSize)
+		}
+		if _, err := f.Write(buf[:n]); err != nil {
+			return err
+		}
+		progress(done, total)
+		if err == io.EOF {
+			return f.Close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// rangeStart returns the first byte of the Content-Range header h, of the form "bytes 100-199/200",
+// and whether h is one.
+func rangeStart(h string) (int64, bool) {
+	var start, end int64
+	var total string
+	if n, err := fmt.Sscanf(h, "bytes %d-%d/%s", &start, &end, &total); n != 3 || err != nil || start < 0 || end < start {
+		return 0, false
+	}
+	return start, true
+}
+
+// unpackPack unpacks the RLE files of the archive name to dir, in place of its files, and returns
+// their number. Each must be a valid pattern, of a path inside dir. Other files are ignored. On
+// error dir is left unchanged.
+func unpackPack(name, dir string) (int, error) {
+	z, err := zip.OpenReader(name)
+	if err != nil {
+		return 0, err
+	}
+	defer z.Close()
+	if len(z.File) > maxPackFiles {
+		return 0, fmt.Errorf("archive of %d files, over %d", len(z.File), maxPackFiles)
+	}
+	tmp := dir + ".new"
+	os.RemoveAll(tmp)
+	n := 0
+	for _, f := range z.File {
+		if f.FileInfo().IsDir() || path.Ext(f.Name) != ".rle" {
+			continue
+		}
+		if !safePackPath(f.Name) {
+			os.RemoveAll(tmp)
+			return 0, fmt.Errorf("%s: unsafe path", f.Name)
+		}
+		b, err := readPackFile(f)
+		if err == nil {
+			_, err = life.ParseRLE(bytes.NewReader(b))
+		}
+		if err == nil {
+			out := filepath.Join(tmp, filepath.FromSlash(f.Name))
+			if err = os.MkdirAll(filepath.Dir(out), 0755); err == nil {
+				err = ioutil.WriteFile(out, b, 0644)
+			}
+		}
+		if err != nil {
+			os.RemoveAll(tmp)
+			return 0, fmt.Errorf("%s: %v", f.Name, err)
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, errors.New("no pattern in the archive")
+	}
+	if err := replaceDir(tmp, dir); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// safePackPath reports whether name, a slash-separated path of a pattern of a pack, stays inside the
+// directory of the pack: no absolute path or ".." out of it, no backslash read as a separator
+// elsewhere.
+func safePackPath(name string) bool {
+	p := path.Clean(name)
+	return p == name && !path.IsAbs(p) && p != ".." && !strings.HasPrefix(p, "../") &&
+		!strings.Contains(p, `\`)
+}
+
+// replaceDir replaces the directory dir by tmp, or removes tmp and leaves dir unchanged on error.
+func replaceDir(tmp, dir string) error {
+	old := dir + ".old"
+	os.RemoveAll(old)
+	if err := os.Rename(dir, old); err != nil && !os.IsNotExist(err) {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		os.Rename(old, dir)
+		os.RemoveAll(tmp)
+		return err
+	}
+	os.RemoveAll(old)
+	return nil
+}
+
+// readPackFile returns the content of f, at most maxPackRLE bytes.
+func readPackFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(r, maxPackRLE+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxPackRLE {
+		return nil, fmt.Errorf("over %d bytes", maxPackRLE)
+	}
+	return b, nil
+}
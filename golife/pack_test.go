@@ -0,0 +1,200 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/mobile/app"
+)
+
+// packArchive returns a zip archive of the files of the given names and contents.
+func packArchive(t *testing.T, files ...string) []byte {
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for k := 0; k < len(files); k += 2 {
+		f, err := w.Create(files[k])
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(files[k+1]))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// packServer returns a server of the archive b, honoring ranges.
+func packServer(b []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "pack.zip", time.Time{}, bytes.NewReader(b))
+	}))
+}
+
+const (
+	packBlinker = "x = 3, y = 1\n3o!\n"
+	packBlock   = "x = 2, y = 2\n2o$2o!\n"
+)
+
+func TestUnpackPack(t *testing.T) {
+	inTempDir(t, func(dir string) {
+		name, out := filepath.Join(dir, "pack.zip"), filepath.Join(dir, "pack")
+		unpack := func(b []byte) (int, error) {
+			if err := ioutil.WriteFile(name, b, 0644); err != nil {
+				t.Fatal(err)
+			}
+			return unpackPack(name, out)
+		}
+		n, err := unpack(packArchive(t, "old.rle", packBlock))
+		if err != nil || n != 1 {
+			t.Fatalf("%d patterns unpacked: %v", n, err)
+		}
+		// A new pack replaces the former one, other files ignored.
+		n, err = unpack(packArchive(t, "oscillators/blinker.rle", packBlinker, "still_lifes/block.rle", packBlock, "README", "hi"))
+		if err != nil || n != 2 {
+			t.Fatalf("%d patterns unpacked: %v", n, err)
+		}
+		ps := readPack(out)
+		if len(ps) != 2 || ps[0].name != "blinker" || ps[1].name != "block" || ps[0].category != packCategory {
+			t.Errorf("pack read as %+v", ps)
+		}
+		// The pack is not among the assets, which app.Open only reaches on devices.
+		defer func(open func(string) (app.ReadSeekCloser, error)) { openAsset = open }(openAsset)
+		openAsset = func(name string) (app.ReadSeekCloser, error) { return nil, os.ErrNotExist }
+		p, err := readPattern(ps[0].asset)
+		if err != nil || p.W != 3 {
+			t.Errorf("pattern of the pack read as %+v: %v", p, err)
+		}
+
+		// Unsafe or invalid packs leave the former one.
+		for _, test := range []struct {
+			name string
+			b    []byte
+		}{
+			{"escaping path", packArchive(t, "ok.rle", packBlock, "../evil.rle", packBlock)},
+			{"absolute path", packArchive(t, "/tmp/evil.rle", packBlock)},
+			{"unclean path", packArchive(t, "a/../../evil.rle", packBlock)},
+			{"backslash", packArchive(t, `..\evil.rle`, packBlock)},
+			{"invalid pattern", packArchive(t, "bad.rle", "x = 3, y = 1\n5o!")},
+			{"no pattern", packArchive(t, "README", "hi")},
+			{"not a zip", []byte("PK")},
+		} {
+			if _, err := unpack(test.b); err == nil {
+				t.Errorf("%s: unpacked", test.name)
+			}
+			if ps := readPack(out); len(ps) != 2 {
+				t.Errorf("%s: former pack changed to %+v", test.name, ps)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "evil.rle")); err == nil {
+			t.Errorf("pattern written out of the pack")
+		}
+	})
+}
+
+func TestFetchPack(t *testing.T) {
+	archive := packArchive(t, "blinker.rle", packBlinker, "block.rle", packBlock)
+	s := packServer(archive)
+	defer s.Close()
+	inTempDir(t, func(dir string) {
+		part := filepath.Join(dir, "pack.part")
+		var done, total int64
+		progress := func(d, t int64) { done, total = d, t }
+
+		// A download resumes from the part already downloaded.
+		half := archive[:len(archive)/2]
+		ioutil.WriteFile(part, half, 0644)
+		if err := fetchPack(s.URL, part, nil, progress); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := ioutil.ReadFile(part); !bytes.Equal(b, archive) || done != total || total != int64(len(archive)) {
+			t.Errorf("downloaded %d of %d bytes, told %d of %d", len(b), len(archive), done, total)
+		}
+
+		// Canceled, the part is kept.
+		ioutil.WriteFile(part, half, 0644)
+		cancel := make(chan struct{})
+		close(cancel)
+		if err := fetchPack(s.URL, part, cancel, progress); err != errPackCanceled {
+			t.Errorf("canceled download: %v", err)
+		}
+		if b, _ := ioutil.ReadFile(part); !bytes.Equal(b, half) {
+			t.Errorf("%d bytes kept of a canceled download, want %d", len(b), len(half))
+		}
+
+		// Sent another range than the one resumed, the download starts over.
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") == "" {
+				w.Write(archive)
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(archive)-1, len(archive)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(archive)
+		}))
+		defer other.Close()
+		ioutil.WriteFile(part, half, 0644)
+		if err := fetchPack(other.URL, part, nil, progress); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := ioutil.ReadFile(part); !bytes.Equal(b, archive) {
+			t.Errorf("downloaded %d bytes of %d from another range", len(b), len(archive))
+		}
+	})
+
+	// Archives too large, or failed requests, are errors.
+	large := packServer(make([]byte, maxPackSize+1))
+	defer large.Close()
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	inTempDir(t, func(dir string) {
+		part := filepath.Join(dir, "pack.part")
+		for _, url := range []string{large.URL, missing.URL, "http://%"} {
+			if err := fetchPack(url, part, nil, func(d, t int64) {}); err == nil {
+				t.Errorf("%s: downloaded", url)
+			}
+		}
+	})
+}
+
+func TestPackPicker(t *testing.T) {
+	s := packServer(packArchive(t, "blinker.rle", packBlinker, "block.rle", packBlock))
+	defer s.Close()
+	gestureScene(t)
+	defer useStrings(t, "en")()
+	defer setPack(nil)
+	inTempDir(t, func(dir string) {
+		cfg.packURL = s.URL
+		pack = nil
+		openPicker("", false)
+		if views.picker.packLabel != "get pack" {
+			t.Fatalf("pack button labeled %q", views.picker.packLabel)
+		}
+		pickerTap(t, packButton)
+		for k := 0; k < 200 && views.picker.packLabel != "get pack"; k++ {
+			time.Sleep(10 * time.Millisecond)
+			updatePack()
+		}
+		if len(library) != builtinPatterns+2 {
+			t.Fatalf("library of %d patterns once the pack downloaded, want %d", len(library), builtinPatterns+2)
+		}
+		pickerTap(t, searchImage)
+		for _, key := range []string{"d", "o", "w", "n"} {
+			pickerTap(t, key)
+		}
+		if got := pickerShown(); len(got) != 2 || got[0] != "blinker" {
+			t.Errorf("downloaded patterns shown as %v", got)
+		}
+		views.picker.close()
+	})
+}
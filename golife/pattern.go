@@ -0,0 +1,130 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// A libraryPattern is a pattern of the library: its asset, see readPattern, its name and the
+// category it is filed under.
+type libraryPattern struct {
+	asset, name, category string
+}
+
+// library holds the patterns the pattern button cycles through, also offered by the pattern picker.
+var library = []libraryPattern{
+	{"glider_gun.rle", "Gosper glider gun", "gun"},
+	{"r_pentomino.rle", "R-pentomino", "methuselah"},
+	{"acorn.rle", "acorn", "methuselah"},
+	{"diehard.cells", "diehard", "methuselah"},
+	{"pulsar.rle", "pulsar", "oscillator"},
+	{"pentadecathlon.rle", "pentadecathlon", "oscillator"},
+	{"glider.rle", "glider", "spaceship"},
+	{"lwss.rle", "lightweight spaceship", "spaceship"},
+}
+
+// title returns the name of p shown to the user, the string of "pattern.<asset>" in the table in
+// use, the asset without its extension, or else its name, as the patterns of the pack have none.
+func (p libraryPattern) title() string {
+	if s, ok := texts["pattern."+strings.TrimSuffix(p.asset, path.Ext(p.asset))]; ok {
+		return s
+	}
+	return p.name
+}
+
+// categoryTitle returns the name of p's category shown to the user, the string of
+// "category.<category>" in the table in use, or else the category.
+func (p libraryPattern) categoryTitle() string {
+	if s, ok := texts["category."+p.category]; ok {
+		return s
+	}
+	return p.category
+}
+
+// matches reports whether the title or the category title of p holds query, both folded by
+// foldText. Every pattern matches an empty query.
+func (p libraryPattern) matches(query string) bool {
+	q := foldText(query)
+	return strings.Contains(foldText(p.title()), q) || strings.Contains(foldText(p.categoryTitle()), q)
+}
+
+// nextPattern is the index in library of the pattern placed by the next tap on the pattern button.
+var nextPattern int
+
+// placeNext replaces the universe by the next pattern of library, centered on the grid.
+func (u *universe) placeNext() {
+	u.placeLibrary(nextPattern)
+}
+
+// placeLibrary replaces the universe by the pattern of library of index k, centered on the grid.
+// The pattern button goes on with the next one.
+func (u *universe) placeLibrary(k int) {
+	name := library[k].asset
+	nextPattern = (k + 1) % len(library)
+	p, err := readPattern(name)
+	if err != nil {
+		log.Printf("pattern %s: %v", name, err)
+		return
+	}
+	u.place(p)
+	log.Printf("pattern %s", name)
+}
+
+// place replaces the universe by p alone, centered on the grid and clipped if it does not fit, but
+// for the pinned cells unless cfg.clearPins. The rule switches to the one of p, if it tells.
+func (u *universe) place(p *life.Pattern) {
+	if p.Rule != (life.Rule{}) {
+		u.life.Rule = p.Rule
+	}
+	if cfg.clearPins {
+		u.life.SetPins(nil)
+	}
+	u.life.Seed(0, life.Random)
+	soupSeed, soupRun, runStart = 0, nil, nil
+	u.life.Mutation = mutation()
+	u.life.Stamp(p, (u.cols-p.W)/2, (u.rows-p.H)/2)
+	session, touchLog = nil, nil
+	u.edited()
+	u.edits.clear()
+	spark.clear()
+	u.due = 0
+	u.paint()
+}
+
+// readPattern reads the pattern name, in the format told by its extension: .rle, .cells for the
+// plaintext format or .lif for Life 1.06. It is an asset, or if its path is absolute a file the
+// app wrote, as the patterns of the pack, which app.Open does not reach on devices.
+func readPattern(name string) (*life.Pattern, error) {
+	parse := life.ParseRLE
+	switch path.Ext(name) {
+	case ".rle":
+	case ".cells":
+		parse = life.ParseCells
+	case ".lif", ".life":
+		parse = life.ParseLife106
+	default:
+		return nil, fmt.Errorf("unknown pattern format %q", path.Ext(name))
+	}
+	var a io.ReadCloser
+	var err error
+	if filepath.IsAbs(name) {
+		a, err = os.Open(name)
+	} else {
+		a, err = openAsset(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer a.Close()
+
+	return parse(a)
+}
@@ -0,0 +1,291 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"strconv"
+
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+const (
+	// pickerRows is the number of patterns the picker shows at most, the first ones matching.
+	pickerRows = 8
+	// pickerPx is the height in px of a line of the texture of the picker, pickerScale px a font
+	// pixel.
+	pickerPx    = 32
+	pickerScale = 4
+	// pickerGap is the gap in px between the preview of a pattern and its name.
+	pickerGap = 6
+	// pickerQueryMax is the length of the longest query typed.
+	pickerQueryMax = 16
+	// pickerCols is the number of keys of each row of the keyboard.
+	pickerCols = 7
+)
+
+// packButton is the name of the pack button of the picker.
+const packButton = "pack"
+
+// pickerKeys are the keys of the on-screen keyboard of the picker: the letters, a space and a
+// backspace. The x/mobile events of this tree have no key events, so there is no hardware one.
+var pickerKeys = []string{
+	"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n",
+	"o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z", " ", "<",
+}
+
+// A patternPicker lists the patterns of library matching a query, see libraryPattern.matches, to
+// place one in place of the universe. The search button shows an on-screen keyboard to type the
+// query, shown next to it. If the config tells where, the pack button downloads the pattern pack,
+// see startPack. As the stamp menu, it is drawn over the scene from a scene graph of its
+// own and, while open, receives every touch. It is built anew as the query changes.
+type patternPicker struct {
+	root      *sprite.Node
+	bar       *ui.Bar // The patterns are named by their index in library, the keys by their text.
+	tex       sprite.Texture
+	rect      geom.Rectangle // Uses absolute location.
+	query     string
+	searching bool   // Whether the keyboard shows.
+	packLabel string // Text of the pack button, see packLabel, empty without a pack to download.
+	faces     map[string]sprite.SubTex
+}
+
+// libraryPatterns are the patterns of library once read, nil where unreadable.
+var libraryPatterns []*life.Pattern
+
+// openPicker opens the pattern picker, centered on the screen, listing the patterns matching query,
+// with the keyboard if searching.
+func openPicker(query string, searching bool) {
+	if libraryPatterns == nil {
+		libraryPatterns = make([]*life.Pattern, len(library))
+		for k, e := range library {
+			p, err := readPattern(e.asset)
+			if err != nil {
+				log.Printf("pattern %s: %v", e.asset, err)
+				continue
+			}
+			libraryPatterns[k] = p
+		}
+	}
+	label := ""
+	if cfg.packURL != "" {
+		label = packLabel()
+	}
+	img := pickerImage(query, searching, label)
+	tex, err := eng.LoadTexture(img)
+	if err != nil {
+		log.Printf("pattern picker: %v", err)
+		return
+	}
+	forgetPointers()
+	p := &patternPicker{root: &sprite.Node{}, tex: tex, query: query, searching: searching, packLabel: label,
+		faces: map[string]sprite.SubTex{searchImage: *textures[searchImage]}}
+	eng.Register(p.root)
+	eng.SetTransform(p.root, f32.Affine{{1, 0, 0}, {0, 1, 0}})
+
+	// Lines of buttonSize, showing lines of the texture of pickerPx.
+	line := buttonSize
+	ptOf := func(px int) geom.Pt { return geom.Pt(px) * line / pickerPx }
+	w := geom.Width - 2*buttonSep
+	// The keys, and then the number of patterns shown, shrink to fit the screen.
+	key := slotFit(pickerCols, w)
+	rows := pickerRows
+	var h geom.Pt
+	for ; rows > 0; rows-- {
+		h = line + 2*buttonSep + geom.Pt(rows)*(line+buttonSep)
+		if searching {
+			h += 4 * (key + buttonSep)
+		}
+		if h <= geom.Height || rows == 1 {
+			break
+		}
+	}
+	x0, y0 := geom.Pt(buttonSep), (geom.Height-h)/2
+	p.rect = geom.Rectangle{Min: geom.Point{X: x0, Y: y0}, Max: geom.Point{X: x0 + w, Y: y0 + h}}
+	add := func(sub sprite.SubTex, x, y, w, h geom.Pt) {
+		n := &sprite.Node{}
+		eng.Register(n)
+		p.root.AppendChild(n)
+		eng.SetSubTex(n, sub)
+		eng.SetTransform(n, f32.Affine{{float32(w), 0, float32(x)}, {0, float32(h), float32(y)}})
+	}
+	add(*textures[outOfBoundsImage], x0, y0, w, h)
+	p.bar = ui.NewBar(eng, p.root, geom.Point{}, func(b *ui.Button) sprite.SubTex { return p.faces[b.Name] })
+
+	x, y := x0+buttonSep, y0+buttonSep
+	p.bar.Add(searchImage, mirror(geom.Rectangle{Min: geom.Point{X: x, Y: y}, Max: geom.Point{X: x + line, Y: y + line}}, p.rect))
+	q := query
+	if searching {
+		q += "_"
+	}
+	qy := len(library) * pickerPx
+	qw := ptOf(textWidth(q, pickerScale))
+	add(sprite.SubTex{tex, image.Rect(0, qy, textWidth(q, pickerScale), qy+pickerPx)},
+		mirrorX(x+line+buttonSep, qw, p.rect), y, qw, line)
+	if label != "" {
+		lw, ly := textWidth(label, pickerScale), qy+2*pickerPx
+		p.faces[packButton] = sprite.SubTex{tex, image.Rect(0, ly, lw, ly+pickerPx)}
+		lx := x0 + w - buttonSep - ptOf(lw)
+		p.bar.Add(packButton, mirror(geom.Rectangle{Min: geom.Point{X: lx, Y: y}, Max: geom.Point{X: lx + ptOf(lw), Y: y + line}}, p.rect))
+	}
+
+	y += line + buttonSep
+	shown := 0
+	for k, e := range library {
+		if shown == rows || libraryPatterns[k] == nil || !e.matches(query) {
+			continue
+		}
+		name := strconv.Itoa(k)
+		// Long names are cut at the edge of the picker, the left one if rightToLeft.
+		rw := pickerPx + pickerGap + textWidth(e.title(), pickerScale)
+		left := 0
+		if room := int((x0 + w - buttonSep - x) * pickerPx / line); rw > room {
+			if rightToLeft {
+				left = rw - room
+			}
+			rw = room
+		}
+		p.faces[name] = sprite.SubTex{tex, image.Rect(left, k*pickerPx, left+rw, (k+1)*pickerPx)}
+		p.bar.Add(name, mirror(geom.Rectangle{Min: geom.Point{X: x, Y: y}, Max: geom.Point{X: x + ptOf(rw), Y: y + line}}, p.rect))
+		y += line + buttonSep
+		shown++
+	}
+
+	if searching {
+		ky := (len(library) + 1) * pickerPx
+		corner := geom.Point{
+			X: (geom.Width - pickerCols*key - (pickerCols-1)*buttonSep) / 2,
+			Y: y0 + line + 2*buttonSep + geom.Pt(rows)*(line+buttonSep),
+		}
+		// The keys keep the order of the alphabet they type, even if rightToLeft.
+		for k, r := range ui.Grid(len(pickerKeys), pickerCols, key, buttonSep, corner) {
+			p.faces[pickerKeys[k]] = sprite.SubTex{tex, image.Rect(k*pickerPx, ky, (k+1)*pickerPx, ky+pickerPx)}
+			p.bar.Add(pickerKeys[k], r)
+		}
+	}
+	views.picker = p
+	holdPanel(pickerName)
+}
+
+// pickerImage returns the texture of the picker showing the patterns of library, see
+// openPicker: a line for each pattern, its preview followed by its name, then a line for query,
+// followed by the cursor if searching, one for the keys and one for the label of the pack button.
+func pickerImage(query string, searching bool, label string) image.Image {
+	w := len(pickerKeys) * pickerPx
+	for _, e := range library {
+		if rw := pickerPx + pickerGap + textWidth(e.title(), pickerScale); rw > w {
+			w = rw
+		}
+	}
+	for _, s := range []string{query + "_", label} {
+		if tw := textWidth(s, pickerScale); tw > w {
+			w = tw
+		}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, (len(library)+3)*pickerPx))
+	// Glyphs are centered on their line.
+	dy := (pickerPx - glyphHeight*pickerScale) / 2
+	for k, e := range library {
+		// The name before the preview if rightToLeft.
+		px, tx := 0, pickerPx+pickerGap
+		if rightToLeft {
+			px, tx = textWidth(e.title(), pickerScale)+pickerGap, 0
+		}
+		if p := libraryPatterns[k]; p != nil {
+			drawPreview(img, p, image.Rect(px, k*pickerPx, px+pickerPx, (k+1)*pickerPx))
+		}
+		drawText(img, e.title(), tx, k*pickerPx+dy, pickerScale, color.White)
+	}
+	if searching {
+		query += "_"
+	}
+	drawText(img, query, 0, len(library)*pickerPx+dy, pickerScale, color.White)
+	dx := (pickerPx - glyphWidth*pickerScale) / 2
+	for k, key := range pickerKeys {
+		if key == " " {
+			key = "_"
+		}
+		drawText(img, key, k*pickerPx+dx, (len(library)+1)*pickerPx+dy, pickerScale, color.White)
+	}
+	drawText(img, label, 0, (len(library)+2)*pickerPx+dy, pickerScale, color.White)
+	return img
+}
+
+// drawPreview draws the cells of p on img, scaled to fit r and centered on it, one px inside.
+func drawPreview(img *image.RGBA, p *life.Pattern, r image.Rectangle) {
+	n := p.W
+	if p.H > n {
+		n = p.H
+	}
+	s := float64(r.Dx()-2) / float64(n)
+	x0 := r.Min.X + 1 + int(float64(n-p.W)*s/2)
+	y0 := r.Min.Y + 1 + int(float64(n-p.H)*s/2)
+	for _, c := range p.Cells {
+		cell := image.Rect(x0+int(float64(c[0])*s), y0+int(float64(c[1])*s),
+			x0+int(float64(c[0]+1)*s), y0+int(float64(c[1]+1)*s))
+		if cell.Empty() {
+			cell.Max = cell.Min.Add(image.Pt(1, 1))
+		}
+		for y := cell.Min.Y; y < cell.Max.Y; y++ {
+			for x := cell.Min.X; x < cell.Max.X; x++ {
+				img.Set(x, y, snapshotAliveColor)
+			}
+		}
+	}
+}
+
+// touch handles t while p is open. A touch on a pattern places it and closes p, as does a touch
+// out of p without placing any. The search button shows or hides the keyboard, whose keys type the
+// query.
+func (p *patternPicker) touch(t event.Touch) {
+	if t.Type != event.TouchStart {
+		return
+	}
+	b := p.bar.Find(t.Loc)
+	if b == nil {
+		if !ui.Contains(p.rect, t.Loc) {
+			p.close()
+		}
+		return
+	}
+	sounds.click()
+	query, searching := p.query, p.searching
+	switch k, err := strconv.Atoi(b.Name); {
+	case err == nil:
+		p.close()
+		univ.placeLibrary(k)
+		return
+	case b.Name == searchImage:
+		searching = !searching
+	case b.Name == packButton:
+		startPack()
+	case b.Name == "<":
+		if q := []rune(query); len(q) > 0 {
+			query = string(q[:len(q)-1])
+		}
+	case len(query) < pickerQueryMax:
+		query += b.Name
+	}
+	p.close()
+	openPicker(query, searching)
+}
+
+// close closes p, unregisters its nodes and frees its texture.
+func (p *patternPicker) close() {
+	p.bar.Release()
+	for n := p.root.FirstChild; n != nil; n = p.root.FirstChild {
+		p.root.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	eng.Unregister(p.root)
+	p.tex.Unload()
+	views.picker = nil
+	releasePanel(pickerName)
+}
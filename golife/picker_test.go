@@ -0,0 +1,130 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+// pickerTap taps the button of the picker of the given name.
+func pickerTap(t *testing.T, name string) {
+	b := views.picker.bar.Button(name)
+	if b == nil {
+		t.Fatalf("no %q button in the picker", name)
+	}
+	loc := geom.Point{X: (b.Rect.Min.X + b.Rect.Max.X) / 2, Y: (b.Rect.Min.Y + b.Rect.Max.Y) / 2}
+	finger(1, event.TouchStart, loc)
+	finger(1, event.TouchEnd, loc)
+}
+
+// pickerShown returns the names of the patterns the picker shows.
+func pickerShown() []string {
+	var names []string
+	for _, b := range views.picker.bar.Buttons {
+		if k, err := strconv.Atoi(b.Name); err == nil {
+			names = append(names, library[k].name)
+		}
+	}
+	return names
+}
+
+// useStrings makes the string table of locale the one in use, until the returned func restores
+// the previous one.
+func useStrings(t *testing.T, locale string) func() {
+	old := texts
+	var err error
+	if texts, err = readStrings(locale); err != nil {
+		t.Fatal(err)
+	}
+	return func() { texts = old }
+}
+
+func TestPatternMatches(t *testing.T) {
+	defer useStrings(t, "en")()
+	for _, test := range []struct {
+		locale, query string
+		want          int
+	}{
+		{"en", "", len(library)},
+		{"en", "GLIDER", 2},
+		{"en", "spaceship", 2},
+		{"en", "methuselah", 3},
+		{"en", "r-p", 1},
+		{"en", "wing", 0},
+		// Titles in the table in use, accents folded.
+		{"es", "planeador", 2},
+		{"es", "canon", 1},
+		{"es", "matusalen", 3},
+		{"es", "glider", 0},
+	} {
+		useStrings(t, test.locale)
+		n := 0
+		for _, p := range library {
+			if p.matches(test.query) {
+				n++
+			}
+		}
+		if n != test.want {
+			t.Errorf("%s: %q matches %d patterns, want %d", test.locale, test.query, n, test.want)
+		}
+	}
+}
+
+func TestPicker(t *testing.T) {
+	gestureScene(t)
+	defer useStrings(t, "en")()
+	libraryPatterns = nil
+	// A long press on the pattern button opens the picker, listing the whole library.
+	finger(1, event.TouchStart, buttonLoc(t, patternImage))
+	pointers[1].start = time.Now().Add(-longPress)
+	holdPointers()
+	if views.picker == nil {
+		t.Fatalf("picker not opened by a long press")
+	}
+	finger(1, event.TouchEnd, buttonLoc(t, patternImage))
+	if got := pickerShown(); len(got) != len(library) {
+		t.Errorf("picker showing %v", got)
+	}
+
+	// Typing filters the patterns as it goes, the backspace undoing the last key.
+	pickerTap(t, searchImage)
+	for _, key := range []string{"g", "l", "i"} {
+		pickerTap(t, key)
+	}
+	if got := pickerShown(); views.picker.query != "gli" || len(got) != 2 || got[0] != "Gosper glider gun" || got[1] != "glider" {
+		t.Errorf("%q shows %v", views.picker.query, got)
+	}
+	pickerTap(t, "<")
+	pickerTap(t, "<")
+	pickerTap(t, "<")
+	pickerTap(t, "<")
+	for _, key := range []string{"h", "t", " ", "s", "p"} {
+		pickerTap(t, key)
+	}
+	if got := pickerShown(); views.picker.query != "ht sp" || len(got) != 1 || got[0] != "lightweight spaceship" {
+		t.Errorf("%q shows %v", views.picker.query, got)
+	}
+	// Hiding the keyboard keeps the query.
+	pickerTap(t, searchImage)
+	if views.picker.bar.Button("a") != nil || len(pickerShown()) != 1 {
+		t.Errorf("keyboard still shown, or query dropped")
+	}
+
+	// A pattern placed closes the picker, the pattern button going on with the next one.
+	pickerTap(t, strconv.Itoa(len(library)-1))
+	if views.picker != nil || len(alive()) != 9 || nextPattern != 0 {
+		t.Errorf("picker open %v, %d cells, next pattern %d", views.picker != nil, len(alive()), nextPattern)
+	}
+
+	// A touch out of the picker closes it.
+	openPicker("", false)
+	finger(1, event.TouchStart, geom.Point{X: 1, Y: 1})
+	if views.picker != nil {
+		t.Errorf("picker still open")
+	}
+}
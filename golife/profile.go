@@ -0,0 +1,262 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/geom"
+)
+
+// A profile holds the settings of the settings panel and the patterns of the pattern pack, to copy
+// them to another device: a tap on the profile button exports it to a file of the directory of the
+// snapshots, which a long press on another device imports, see importProfile.
+//
+// It is a JSON object with the keys version, settings, an object with keys of the config manifest
+// as saved by saveSettings, and patterns, an object of the RLE texts of the patterns by their
+// slash-separated path in packDir.
+type profile struct {
+	Version  int                        `json:"version"`
+	Settings map[string]json.RawMessage `json:"settings,omitempty"`
+	Patterns map[string]string          `json:"patterns,omitempty"`
+}
+
+// profileVersion is the version of the profiles written by encodeProfile. It must change whenever
+// their format does, decodeProfile going on reading the former ones.
+const profileVersion = 1
+
+// The sections of a profile, which importing takes or leaves one by one, see cfg.profileSections.
+const (
+	settingsSection = "settings"
+	patternsSection = "patterns"
+)
+
+// profilePrefix starts the names of the profile files, followed by the time they were written.
+const profilePrefix = "golife-profile-"
+
+// A newerProfileError tells a profile is of a version newer than profileVersion, written by a later
+// release of the app.
+type newerProfileError int
+
+func (v newerProfileError) Error() string {
+	return fmt.Sprintf("profile of version %d, newer than %d", int(v), profileVersion)
+}
+
+// encodeProfile returns the profile of the current settings and pattern pack.
+func encodeProfile() ([]byte, error) {
+	settings, err := settingsJSON()
+	if err != nil {
+		return nil, err
+	}
+	p := profile{Version: profileVersion, Settings: settings}
+	dir := packDir()
+	for _, lp := range readPack(dir) {
+		b, err := ioutil.ReadFile(lp.asset)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(dir, lp.asset)
+		if err != nil {
+			return nil, err
+		}
+		if p.Patterns == nil {
+			p.Patterns = make(map[string]string)
+		}
+		p.Patterns[filepath.ToSlash(rel)] = string(b)
+	}
+	return json.MarshalIndent(p, "", "\t")
+}
+
+// decodeProfile returns the profile b, written by a version of the app up to current, which is
+// profileVersion but for tests. It checks its format and version, not its values.
+func decodeProfile(b []byte, current int) (*profile, error) {
+	var p profile
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+	switch {
+	case p.Version < 1:
+		return nil, errors.New("not a profile")
+	case p.Version > current:
+		return nil, newerProfileError(p.Version)
+	}
+	return &p, nil
+}
+
+// merge checks the sections of p and applies them, in place of the current settings and pattern
+// pack: all of them if valid, none otherwise. Only the sections listed by cfg.profileSections are
+// taken, and the settings listed by cfg.keepSettings are left as they are. The settings are those
+// exported, see settingsValues: the keys of the manifest only, such as packURL, are unknown.
+func (p *profile) merge() error {
+	take := make(map[string]bool)
+	for _, s := range cfg.profileSections {
+		take[s] = true
+	}
+	c := cfg
+	if take[settingsSection] {
+		keep := make(map[string]bool)
+		for _, k := range cfg.keepSettings {
+			keep[k] = true
+		}
+		known := settingsValues()
+		// In order, so that the first invalid setting is told.
+		var keys []string
+		for k := range p.Settings {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			_, ok := known[k]
+			switch {
+			case keep[k]:
+			case !ok:
+				return fmt.Errorf("unknown setting %q", k)
+			default:
+				if err := configFields[k](&c, p.Settings[k]); err != nil {
+					return fmt.Errorf("setting %q: %v", k, err)
+				}
+			}
+		}
+	}
+	if take[patternsSection] && p.Patterns != nil {
+		if err := writePatterns(p.Patterns, packDir()); err != nil {
+			return err
+		}
+		setPack(readPack(packDir()))
+	}
+	if take[settingsSection] {
+		old := cfg
+		cfg = c
+		applySettings(old)
+		if err := saveSettings(); err != nil {
+			log.Printf("saving the settings: %v", err)
+		}
+	}
+	return nil
+}
+
+// writePatterns writes the RLE texts of patterns, by slash-separated path, to dir in place of its
+// files. Each must be a valid pattern, of a path inside dir, within the limits of the pattern
+// packs. On error dir is left unchanged.
+func writePatterns(patterns map[string]string, dir string) error {
+	if len(patterns) > maxPackFiles {
+		return fmt.Errorf("%d patterns, over %d", len(patterns), maxPackFiles)
+	}
+	tmp := dir + ".new"
+	os.RemoveAll(tmp)
+	for name, rle := range patterns {
+		var err error
+		switch {
+		case !safePackPath(name) || !strings.HasSuffix(name, ".rle"):
+			err = errors.New("unsafe path")
+		case len(rle) > maxPackRLE:
+			err = fmt.Errorf("%d bytes, over %d", len(rle), maxPackRLE)
+		default:
+			_, err = life.ParseRLE(strings.NewReader(rle))
+		}
+		if err == nil {
+			out := filepath.Join(tmp, filepath.FromSlash(name))
+			if err = os.MkdirAll(filepath.Dir(out), 0755); err == nil {
+				err = ioutil.WriteFile(out, []byte(rle), 0644)
+			}
+		}
+		if err != nil {
+			os.RemoveAll(tmp)
+			return fmt.Errorf("pattern %s: %v", name, err)
+		}
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return err
+	}
+	return replaceDir(tmp, dir)
+}
+
+// applySettings makes the game follow cfg, as it was old before a profile was imported, as the
+// settings panel would.
+func applySettings(old config) {
+	rtl := rightToLeft
+	loadStrings()
+	seedMode, muted = cfg.seedMode, !cfg.sound
+	univ.life.Rule = cfg.rule
+	univ.life.Mutation = mutation()
+	if cfg.edges != univ.life.Edges {
+		univ.setEdges(cfg.edges)
+	}
+	if cfg.theme != currentTheme {
+		setTheme(cfg.theme)
+	}
+	if cfg.accessible != old.accessible {
+		setAccessible(cfg.accessible)
+	}
+	if cfg.cellSize != cellSize {
+		focus := geom.Point{X: univ.w / 2, Y: systemBarHeight + buttonBarHeight + univ.h/2}
+		univ = univ.regrid(cfg.cellSize, focus)
+	}
+	univ.edited()
+	if cfg.large != old.large || cfg.compact != old.compact || rightToLeft != rtl {
+		// Laid out again at the new sizes by the next frame.
+		scaleControls()
+		laidOut = geom.Point{}
+	}
+	buttonBar.Refresh()
+}
+
+// exportProfile writes the profile to a new file of the directory of the snapshots, and tells so
+// with a toast.
+func exportProfile() {
+	b, err := encodeProfile()
+	name := filepath.Join(exportDir(), profilePrefix+time.Now().Format("20060102-150405")+".json")
+	if err == nil {
+		err = ioutil.WriteFile(name, b, 0644)
+	}
+	if err != nil {
+		log.Printf("exporting the profile: %v", err)
+		showToast(text("profile.failed"))
+		return
+	}
+	log.Printf("profile exported to %s", name)
+	showToast(text("profile.exported"))
+}
+
+// importProfile imports the latest profile file of the directory of the snapshots, see merge, and
+// tells how it went with a toast: why not if it failed, leaving everything as it was.
+func importProfile() {
+	names, _ := filepath.Glob(filepath.Join(exportDir(), profilePrefix+"*.json"))
+	if len(names) == 0 {
+		showToast(text("profile.none"))
+		return
+	}
+	// Named after the time they were written.
+	sort.Strings(names)
+	name := names[len(names)-1]
+	b, err := ioutil.ReadFile(name)
+	var p *profile
+	if err == nil {
+		p, err = decodeProfile(b, profileVersion)
+	}
+	if err == nil {
+		err = p.merge()
+	}
+	if v, ok := err.(newerProfileError); ok {
+		log.Printf("importing %s: %v", name, err)
+		showToast(text("profile.newer", int(v)))
+		return
+	}
+	if err != nil {
+		log.Printf("importing %s: %v", name, err)
+		showToast(text("profile.invalid"))
+		return
+	}
+	log.Printf("profile imported from %s", name)
+	showToast(text("profile.imported"))
+}
@@ -0,0 +1,140 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/mobile/app"
+)
+
+// writeProfile writes a profile file of the given content to the directory of the snapshots, named
+// after a time later than the ones written before.
+func writeProfile(t *testing.T, k int, s string) {
+	name := filepath.Join(exportDir(), fmt.Sprintf("%s99990101-%06d.json", profilePrefix, k))
+	if err := ioutil.WriteFile(name, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// packPattern writes the pattern s to the path name of the pattern pack, and merges it into library.
+func packPattern(t *testing.T, name, s string) {
+	out := filepath.Join(packDir(), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(out, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setPack(readPack(packDir()))
+}
+
+func TestProfileRoundTrip(t *testing.T) {
+	gestureScene(t)
+	defer setPack(nil)
+	inTempDir(t, func(dir string) {
+		packPattern(t, "ships/glider.rle", "x = 3, y = 3\nbo$2bo$3o!")
+		cfg.trail, cfg.mutation = 3, true
+		setTheme(1)
+		exportProfile()
+		if views.popup == nil || views.popup.text != text("profile.exported") {
+			t.Fatalf("toast %+v once exported", views.popup)
+		}
+
+		os.RemoveAll(packDir())
+		setPack(readPack(packDir()))
+		cfg.trail, cfg.mutation = 0, false
+		setTheme(0)
+		cfg.keepSettings = []string{"theme"}
+		importProfile()
+		if views.popup.text != text("profile.imported") {
+			t.Fatalf("toast %q once imported", views.popup.text)
+		}
+		if cfg.trail != 3 || !cfg.mutation || !univ.life.Mutation.Active() {
+			t.Errorf("trail %d, mutation %v once imported", cfg.trail, cfg.mutation)
+		}
+		// The theme kept, the patterns taken.
+		if currentTheme != 0 || len(library) != builtinPatterns+1 {
+			t.Errorf("theme %d, %d patterns of the pack once imported", currentTheme,
+				len(library)-builtinPatterns)
+		}
+		// The files the app wrote are read back out of the assets, which app.Open only reaches on
+		// devices.
+		func() {
+			defer func(open func(string) (app.ReadSeekCloser, error)) { openAsset = open }(openAsset)
+			openAsset = func(name string) (app.ReadSeekCloser, error) { return nil, os.ErrNotExist }
+			if p, err := readPattern(library[builtinPatterns].asset); err != nil || p.W != 3 {
+				t.Errorf("pattern imported read as %+v: %v", p, err)
+			}
+			if c := loadConfig(); c.trail != 3 || !c.mutation {
+				t.Errorf("settings imported loaded as trail %d, mutation %v", c.trail, c.mutation)
+			}
+		}()
+
+		// The patterns only.
+		cfg.trail, cfg.profileSections = 0, []string{patternsSection}
+		importProfile()
+		if cfg.trail != 0 || len(library) != builtinPatterns+1 {
+			t.Errorf("trail %d, %d patterns once the patterns imported", cfg.trail,
+				len(library)-builtinPatterns)
+		}
+	})
+}
+
+func TestProfileVersions(t *testing.T) {
+	gestureScene(t)
+	inTempDir(t, func(dir string) {
+		b, err := encodeProfile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// A later release reads the profiles of this one, and this one tells those of a later one.
+		if _, err := decodeProfile(b, profileVersion+1); err != nil {
+			t.Errorf("read by the next version: %v", err)
+		}
+		newer := fmt.Sprintf(`{"version": %d, "settings": {"trail": 4}}`, profileVersion+1)
+		_, err = decodeProfile([]byte(newer), profileVersion)
+		if err != newerProfileError(profileVersion+1) {
+			t.Errorf("profile of the next version read: %v", err)
+		}
+		writeProfile(t, 0, newer)
+		importProfile()
+		if views.popup.text != text("profile.newer", profileVersion+1) || cfg.trail != 0 {
+			t.Errorf("toast %q, trail %d once a newer profile imported", views.popup.text, cfg.trail)
+		}
+	})
+}
+
+func TestProfileInvalid(t *testing.T) {
+	gestureScene(t)
+	defer setPack(nil)
+	inTempDir(t, func(dir string) {
+		importProfile()
+		if views.popup.text != text("profile.none") {
+			t.Errorf("toast %q without a profile", views.popup.text)
+		}
+		packPattern(t, "glider.rle", "x = 3, y = 3\nbo$2bo$3o!")
+		for k, s := range []string{
+			`{"version": 1`,
+			`{"settings": {"trail": 4}}`,
+			`{"version": 1, "settings": {"trail": 4, "zoom": 2}}`,
+			`{"version": 1, "settings": {"trail": 4, "packURL": "http://example.com/pack.zip"}}`,
+			`{"version": 1, "settings": {"trail": 4, "console": true}}`,
+			`{"version": 1, "settings": {"trail": 4, "theme": "plaid"}}`,
+			`{"version": 1, "settings": {"trail": 4}, "patterns": {"../glider.rle": "x = 1, y = 1\no!"}}`,
+			`{"version": 1, "patterns": {"a.rle": "x = 1, y = 1\no!", "b.rle": "o"}}`,
+		} {
+			writeProfile(t, k, s)
+			importProfile()
+			// Nothing changes.
+			if views.popup.text != text("profile.invalid") || cfg.trail != 0 || len(library) != builtinPatterns+1 {
+				t.Errorf("profile %s imported: toast %q, trail %d, %d patterns", s, views.popup.text, cfg.trail,
+					len(library)-builtinPatterns)
+			}
+		}
+	})
+}
@@ -0,0 +1,118 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"golang.org/x/mobile/sprite/clock"
+)
+
+// Sprites of the alive cells, see cfg.cellSprite.
+const (
+	androidSprite = iota // The android image, still.
+	pulseSprite          // The images pulsing, see pulseStrip.
+)
+
+// cellSpriteNames are the names of the cell sprites in the config manifest.
+var cellSpriteNames = []string{androidSprite: "android", pulseSprite: "pulse"}
+
+const (
+	// pulseFrames is the number of frames of the pulsing cells, in the strips of pulseStrip.
+	pulseFrames = 4
+	// pulseTicks is the number of ticks of the clock each frame shows for.
+	pulseTicks = 12
+)
+
+// pulseScales are the sizes of the android image in the frames of the pulse, relative to the cell.
+var pulseScales = [pulseFrames]float64{1, 0.9, 0.8, 0.9}
+
+// pulseFrame is the frame of the pulse all the cells show, from the clock.
+var pulseFrame int
+
+// pulseFrameImage returns the name of the sub-texture of the frame k of the pulse of the image of
+// alive cells img, see pulsedImages.
+func pulseFrameImage(img string, k int) string {
+	return fmt.Sprintf("%s_pulse_%d", img, k)
+}
+
+// pulsedImages returns the images of alive cells that pulse, by name, from the android image img:
+// the image itself and those of the ages and colors, see ageTints and colorTints.
+func pulsedImages(img image.Image) map[string]image.Image {
+	m := map[string]image.Image{androidImage: img}
+	for _, t := range ageTints {
+		m[t.name] = tinted(img, t.c)
+	}
+	for _, t := range colorTints {
+		m[t.name] = tinted(img, t.c)
+	}
+	return m
+}
+
+// pulseStrip returns the frames of the pulse of img side by side, each img shrunk around its center
+// as told by pulseScales.
+func pulseStrip(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	strip := image.NewNRGBA(image.Rect(0, 0, w*pulseFrames, h))
+	for k, s := range pulseScales {
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				// The pixel of img shown at (x, y) once shrunk, if any.
+				sx := int(math.Floor((float64(x)+0.5-float64(w)/2)/s + float64(w)/2))
+				sy := int(math.Floor((float64(y)+0.5-float64(h)/2)/s + float64(h)/2))
+				if sx < 0 || sx >= w || sy < 0 || sy >= h {
+					continue
+				}
+				strip.Set(k*w+x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+			}
+		}
+	}
+	return strip
+}
+
+// pulseRects returns the rectangles of the frames in a strip made by pulseStrip of an image of the
+// given bounds.
+func pulseRects(b image.Rectangle) []image.Rectangle {
+	var rects []image.Rectangle
+	for k := 0; k < pulseFrames; k++ {
+		rects = append(rects, image.Rect(k*b.Dx(), 0, (k+1)*b.Dx(), b.Dy()))
+	}
+	return rects
+}
+
+// updatePulse shows the frame of the pulse for the frame of the given time, if the cells pulse.
+func updatePulse(t clock.Time) {
+	k := int(t/pulseTicks) % pulseFrames
+	if cfg.cellSprite != pulseSprite || k == pulseFrame {
+		return
+	}
+	pulseFrame = k
+	univ.pulse()
+}
+
+// pulse sets the image of the alive cells of u to the current frame of their pulse. The dead cells
+// keep theirs, so that only the cells pulsing are set a sub-texture, once per frame of the pulse.
+// The texture render mode does not pulse, see frame.
+func (u *universe) pulse() {
+	if u.frame != nil {
+		return
+	}
+	for j := 0; j < u.rows; j++ {
+		for i := 0; i < u.cols; i++ {
+			if !u.life.Alive(i, j) {
+				continue
+			}
+			k, tex := j*u.cols+i, *textures[u.cellImage(i, j)]
+			eng.SetSubTex(u.cells[k], tex)
+			// The echoes show the cells as they are while drifting.
+			if u.drifting() {
+				for _, n := range u.echoes[k] {
+					eng.SetSubTex(n, tex)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	imagedraw "image/draw"
+	"testing"
+
+	"golang.org/x/mobile/sprite"
+)
+
+func TestPulseStrip(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	imagedraw.Draw(img, img.Bounds(), image.NewUniform(color.NRGBA{0xff, 0, 0, 0xff}), image.Point{}, imagedraw.Src)
+	strip := pulseStrip(img)
+	rects := pulseRects(img.Bounds())
+	if strip.Bounds() != image.Rect(0, 0, 20*pulseFrames, 20) || len(rects) != pulseFrames {
+		t.Fatalf("strip of %v, %d frames", strip.Bounds(), len(rects))
+	}
+	// The frames show the image whole, then shrunk, the corners left transparent.
+	for k, r := range rects {
+		_, _, _, corner := strip.At(r.Min.X, r.Min.Y).RGBA()
+		_, _, _, center := strip.At(r.Min.X+10, r.Min.Y+10).RGBA()
+		if center == 0 || (corner != 0) != (pulseScales[k] == 1) {
+			t.Errorf("frame %d of scale %v: corner opacity %d, center %d", k, pulseScales[k], corner, center)
+		}
+	}
+}
+
+func TestPulse(t *testing.T) {
+	e := testScene(t, 320, 480)
+	univ.life.SetCells(alive(), false)
+	univ.life.SetCells([][2]int{{2, 2}, {5, 3}}, true)
+	cfg.cellSprite = pulseSprite
+	pulseFrame = 0
+	univ.paint()
+	want := *textures[pulseFrameImage(androidImage, 0)]
+	if e.subTex[univ.cells[2*univ.cols+2]] != want {
+		t.Errorf("cell shows %v, want the first frame of its pulse", e.subTex[univ.cells[2*univ.cols+2]])
+	}
+
+	// The next frame of the pulse sets the alive cells only.
+	e.subTex = make(map[*sprite.Node]sprite.SubTex)
+	updatePulse(pulseTicks)
+	if len(e.subTex) != 2 {
+		t.Errorf("%d nodes set once the pulse went on, want the 2 alive cells", len(e.subTex))
+	}
+	for _, c := range [][2]int{{2, 2}, {5, 3}} {
+		n := univ.cells[c[1]*univ.cols+c[0]]
+		if want := *textures[pulseFrameImage(androidImage, 1)]; e.subTex[n] != want {
+			t.Errorf("cell %v shows %v, want the second frame of its pulse", c, e.subTex[n])
+		}
+	}
+	// And nothing until the frame changes, or if the cells stand still.
+	e.subTex = make(map[*sprite.Node]sprite.SubTex)
+	updatePulse(pulseTicks + 1)
+	cfg.cellSprite = androidSprite
+	updatePulse(2 * pulseTicks)
+	if len(e.subTex) != 0 {
+		t.Errorf("%d nodes set within a frame of the pulse or still", len(e.subTex))
+	}
+}
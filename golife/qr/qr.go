@@ -0,0 +1,473 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+// Package qr encodes data as QR codes, in byte mode at the low error correction level, which holds
+// up to 2953 bytes. The mask is chosen as the standard requires, by the lowest penalty.
+package qr
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// MaxVersion is the largest version of the codes, of MaxVersion*4+17 modules per side.
+const MaxVersion = 40
+
+// Error correction codewords per block and number of blocks, by version, at the low level.
+var (
+	eccPerBlock = [MaxVersion + 1]int{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22,
+		24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30}
+	eccBlocks = [MaxVersion + 1]int{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8,
+		9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25}
+)
+
+// A Code is a QR code, a square of dark and light modules.
+type Code struct {
+	// Size is the number of modules per side, without the quiet zone around them.
+	Size    int
+	dark    []bool
+	reserve []bool // Modules of the function patterns, which data and masks skip.
+}
+
+// Dark reports whether the module at column x and row y is dark.
+func (c *Code) Dark(x, y int) bool {
+	return c.dark[y*c.Size+x]
+}
+
+// Image returns c drawn with scale px per module, black on white, in a quiet zone of 4 modules.
+func (c *Code) Image(scale int) *image.Gray {
+	n := (c.Size + 8) * scale
+	img := image.NewGray(image.Rect(0, 0, n, n))
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			mx, my := x/scale-4, y/scale-4
+			if mx < 0 || mx >= c.Size || my < 0 || my >= c.Size || !c.Dark(mx, my) {
+				img.SetGray(x, y, color.Gray{0xff})
+			}
+		}
+	}
+	return img
+}
+
+// Encode returns the smallest code holding data.
+func Encode(data []byte) (*Code, error) {
+	ver := 1
+	for ; bytesCapacity(ver) < len(data); ver++ {
+		if ver == MaxVersion {
+			return nil, fmt.Errorf("qr: %d bytes do not fit in a code of version %d", len(data), MaxVersion)
+		}
+	}
+	c := &Code{Size: ver*4 + 17}
+	c.dark = make([]bool, c.Size*c.Size)
+	c.reserve = make([]bool, c.Size*c.Size)
+	c.drawFunctionPatterns(ver)
+	c.drawCodewords(addECC(ver, dataCodewords(ver, data)))
+
+	best, lowest := 0, -1
+	for m := 0; m < 8; m++ {
+		c.applyMask(m)
+		c.drawFormatBits(m)
+		if p := c.penalty(); lowest < 0 || p < lowest {
+			best, lowest = m, p
+		}
+		// Masks are their own inverse.
+		c.applyMask(m)
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+	return c, nil
+}
+
+// rawCodewords returns the number of codewords of data and error correction of a code of version
+// ver: the modules left by the function patterns, in whole bytes.
+func rawCodewords(ver int) int {
+	n := (16*ver+128)*ver + 64
+	if ver >= 2 {
+		align := ver/7 + 2
+		n -= (25*align-10)*align - 55
+		if ver >= 7 {
+			n -= 36
+		}
+	}
+	return n / 8
+}
+
+// countBits returns the size in bits of the byte count of a code of version ver.
+func countBits(ver int) int {
+	if ver < 10 {
+		return 8
+	}
+	return 16
+}
+
+// bytesCapacity returns the number of bytes a code of version ver holds.
+func bytesCapacity(ver int) int {
+	n := rawCodewords(ver) - eccPerBlock[ver]*eccBlocks[ver]
+	return (n*8 - 4 - countBits(ver)) / 8
+}
+
+// dataCodewords returns the data codewords of a code of version ver holding data, which fits: the
+// byte mode and count, data, a terminator and pad bytes.
+func dataCodewords(ver int, data []byte) []byte {
+	var bits []bool
+	put := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, v>>uint(i)&1 != 0)
+		}
+	}
+	put(4, 4)
+	put(len(data), countBits(ver))
+	for _, b := range data {
+		put(int(b), 8)
+	}
+	n := (rawCodewords(ver) - eccPerBlock[ver]*eccBlocks[ver]) * 8
+	for k := 0; k < 4 && len(bits) < n; k++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+	for pad := 0xec; len(bits) < n; pad ^= 0xec ^ 0x11 {
+		put(pad, 8)
+	}
+	words := make([]byte, n/8)
+	for i, b := range bits {
+		if b {
+			words[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	return words
+}
+
+// addECC splits data into the blocks of a code of version ver, appends their error correction
+// codewords and interleaves them.
+func addECC(ver int, data []byte) []byte {
+	nblocks, ecc := eccBlocks[ver], eccPerBlock[ver]
+	raw := rawCodewords(ver)
+	short := nblocks - raw%nblocks // Number of blocks one codeword shorter.
+	shortLen := raw / nblocks
+	div := rsDivisor(ecc)
+	var blocks [][]byte
+	for i, k := 0, 0; i < nblocks; i++ {
+		n := shortLen - ecc
+		if i >= short {
+			n++
+		}
+		b := append([]byte(nil), data[k:k+n]...)
+		k += n
+		e := rsRemainder(b, div)
+		if i < short {
+			// A placeholder aligning the codewords of the short blocks with the long ones.
+			b = append(b, 0)
+		}
+		blocks = append(blocks, append(b, e...))
+	}
+	var out []byte
+	for i := range blocks[0] {
+		for j, b := range blocks {
+			if i != shortLen-ecc || j >= short {
+				out = append(out, b[i])
+			}
+		}
+	}
+	return out
+}
+
+// rsDivisor returns the generator polynomial of degree n of the Reed-Solomon code, without its
+// leading term, from the highest to the lowest degree.
+func rsDivisor(n int) []byte {
+	p := make([]byte, n)
+	p[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := range p {
+			p[j] = gfMul(p[j], root)
+			if j+1 < n {
+				p[j] ^= p[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return p
+}
+
+// rsRemainder returns the error correction codewords of data for the generator polynomial div.
+func rsRemainder(data, div []byte) []byte {
+	r := make([]byte, len(div))
+	for _, b := range data {
+		f := b ^ r[0]
+		copy(r, r[1:])
+		r[len(r)-1] = 0
+		for i := range r {
+			r[i] ^= gfMul(div[i], f)
+		}
+	}
+	return r
+}
+
+// gfMul returns the product of x and y in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ z>>7*0x11d
+		z ^= int(y>>uint(i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// set sets the module at (x, y) of a function pattern.
+func (c *Code) set(x, y int, dark bool) {
+	c.dark[y*c.Size+x] = dark
+	c.reserve[y*c.Size+x] = true
+}
+
+// drawFunctionPatterns draws the timing, finder and alignment patterns of a code of version ver,
+// and reserves the modules of the format bits, drawn along with the mask.
+func (c *Code) drawFunctionPatterns(ver int) {
+	n := c.Size
+	for i := 0; i < n; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	for _, p := range [][2]int{{3, 3}, {n - 4, 3}, {3, n - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x >= 0 && x < n && y >= 0 && y < n {
+					d := ring(dx, dy)
+					c.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+	pos := alignmentPositions(ver)
+	for i, x := range pos {
+		for j, y := range pos {
+			// Not over the finders.
+			if i == 0 && j == 0 || i == 0 && j == len(pos)-1 || i == len(pos)-1 && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(x+dx, y+dy, ring(dx, dy) != 1)
+				}
+			}
+		}
+	}
+	c.drawFormatBits(0)
+	if ver >= 7 {
+		rem := ver
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ rem>>11*0x1f25
+		}
+		bits := ver<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := bits>>uint(i)&1 != 0
+			a, b := n-11+i%3, i/3
+			c.set(a, b, dark)
+			c.set(b, a, dark)
+		}
+	}
+}
+
+// alignmentPositions returns the coordinates of the centers of the alignment patterns of a code of
+// version ver, on both axes.
+func alignmentPositions(ver int) []int {
+	if ver == 1 {
+		return nil
+	}
+	n := ver/7 + 2
+	step := (ver*8 + n*3 + 5) / (n*4 - 4) * 2
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, ver*4+17-7; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// levelLow is the indicator of the low error correction level in the format bits.
+const levelLow = 1
+
+// formatBits returns the 15 format bits telling the error correction level of the given indicator
+// and the mask: their 5 bits, the BCH code of them, masked so that they are never all light.
+func formatBits(level, mask int) int {
+	data := level<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ rem>>9*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawFormatBits draws both copies of the format bits telling the low error correction level and
+// mask, and the dark module.
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatBits(levelLow, mask)
+	bit := func(i int) bool { return bits>>uint(i)&1 != 0 }
+	n := c.Size
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		c.set(n-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, n-15+i, bit(i))
+	}
+	c.set(8, n-8, true)
+}
+
+// drawCodewords draws the bits of words in the modules left by the function patterns, in columns
+// two modules wide zigzagging from the bottom-right corner, skipping the vertical timing pattern.
+func (c *Code) drawCodewords(words []byte) {
+	n, i := c.Size, 0
+	for right := n - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for v := 0; v < n; v++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, v
+				if (right+1)&2 == 0 {
+					y = n - 1 - v
+				}
+				if !c.reserve[y*n+x] && i < len(words)*8 {
+					c.dark[y*n+x] = words[i/8]>>uint(7-i%8)&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask inverts the modules of data selected by the mask pattern of the given number.
+func (c *Code) applyMask(mask int) {
+	n := c.Size
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			var inv bool
+			switch mask {
+			case 0:
+				inv = (x+y)%2 == 0
+			case 1:
+				inv = y%2 == 0
+			case 2:
+				inv = x%3 == 0
+			case 3:
+				inv = (x+y)%3 == 0
+			case 4:
+				inv = (x/3+y/2)%2 == 0
+			case 5:
+				inv = x*y%2+x*y%3 == 0
+			case 6:
+				inv = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				inv = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if inv && !c.reserve[y*n+x] {
+				c.dark[y*n+x] = !c.dark[y*n+x]
+			}
+		}
+	}
+}
+
+// penalty returns the penalty score of c, which the mask of a code must minimize: for runs of 5
+// modules or more of the same color, 2x2 blocks of the same color, patterns looking like the
+// finders, and dark modules out of balance with light ones.
+func (c *Code) penalty() int {
+	n, p := c.Size, 0
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			x, y = y, x
+		}
+		return c.dark[y*n+x]
+	}
+	finder := []bool{true, false, true, true, true, false, true}
+	for _, t := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			run := 0
+			for x := 0; x < n; x++ {
+				if x > 0 && at(x, y, t) == at(x-1, y, t) {
+					run++
+				} else {
+					run = 1
+				}
+				if run == 5 {
+					p += 3
+				} else if run > 5 {
+					p++
+				}
+			}
+			// A finder-like pattern with 4 light modules on either side, or the edge.
+			for x := 0; x+7 <= n; x++ {
+				match := true
+				for k, d := range finder {
+					if at(x+k, y, t) != d {
+						match = false
+						break
+					}
+				}
+				if match && (c.light(x-4, x, y, t) || c.light(x+7, x+11, y, t)) {
+					p += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			d := c.dark[y*n+x]
+			if d {
+				dark++
+			}
+			if x+1 < n && y+1 < n && d == c.dark[y*n+x+1] && d == c.dark[(y+1)*n+x] && d == c.dark[(y+1)*n+x+1] {
+				p += 3
+			}
+		}
+	}
+	// 10 points for every 5% of dark modules away from half.
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return p + k*10
+}
+
+// light reports whether the modules from x0 to x1 (excluded) of the row y, or of the column if
+// transpose, are light, the ones beyond the code included.
+func (c *Code) light(x0, x1, y int, transpose bool) bool {
+	for x := x0; x < x1; x++ {
+		if x < 0 || x >= c.Size {
+			continue
+		}
+		i := y*c.Size + x
+		if transpose {
+			i = x*c.Size + y
+		}
+		if c.dark[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ring returns the index of the square ring around a center that the module (dx, dy) away from it
+// is on, 0 for the center.
+func ring(dx, dy int) int {
+	if abs(dx) > abs(dy) {
+		return abs(dx)
+	}
+	return abs(dy)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
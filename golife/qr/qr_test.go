@@ -0,0 +1,228 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// golife is the code of "golife", of version 1 with the mask 1, as drawn by other encoders.
+const golife = `
+#######.#####.#######
+#.....#.##.##.#.....#
+#.###.#..###..#.###.#
+#.###.#..#.##.#.###.#
+#.###.#.#...#.#.###.#
+#.....#.#.#...#.....#
+#######.#.#.#.#######
+........####.........
+###..##.#########..##
+######..##.......##.#
+#.##..##.#....#.....#
+.#.#...#.##.#...##.##
+#.#.#####.....#..#..#
+........####.##..##.#
+#######....###.####.#
+#.....#.##.#.###.#..#
+#.###.#..#.####.##..#
+#.###.#..##.....#.#..
+#.###.#.##....#.#.###
+#.....#.#.#.#...##...
+#######.#.....#.##..#
+`
+
+// draw returns the modules of c, a line per row, # for the dark ones and . for the light ones.
+func draw(c *Code) string {
+	var b bytes.Buffer
+	for y := 0; y < c.Size; y++ {
+		b.WriteByte('\n')
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func TestVersion1(t *testing.T) {
+	c, err := Encode([]byte("golife"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := draw(c); got != golife {
+		t.Errorf("code drawn as%swant%s", got, golife)
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	// The table of the standard, by level indicator then mask.
+	for _, test := range []struct {
+		name  string
+		level int
+		bits  [8]string
+	}{
+		{"low", 1, [8]string{"111011111000100", "111001011110011", "111110110101010", "111100010011101",
+			"110011000101111", "110001100011000", "110110001000001", "110100101110110"}},
+		{"medium", 0, [8]string{"101010000010010", "101000100100101", "101111001111100", "101101101001011",
+			"100010111111001", "100000011001110", "100111110010111", "100101010100000"}},
+		{"quartile", 3, [8]string{"011010101011111", "011000001101000", "011111100110001", "011101000000110",
+			"010010010110100", "010000110000011", "010111011011010", "010101111101101"}},
+		{"high", 2, [8]string{"001011010001001", "001001110111110", "001110011100111", "001100111010000",
+			"000011101100010", "000001001010101", "000110100001100", "000100000111011"}},
+	} {
+		for mask, want := range test.bits {
+			bits := formatBits(test.level, mask)
+			var got string
+			for i := 14; i >= 0; i-- {
+				got += string("01"[bits>>uint(i)&1])
+			}
+			if got != want {
+				t.Errorf("%s level, mask %d: format bits %s, want %s", test.name, mask, got, want)
+			}
+		}
+	}
+}
+
+// decode returns the data of c, read back from its modules as a reader would, the error correction
+// aside.
+func decode(t *testing.T, c *Code) []byte {
+	ver := (c.Size - 17) / 4
+	n := c.Size
+	// The first copy of the format bits tells the mask.
+	var bits int
+	at := func(i, x, y int) {
+		if c.Dark(x, y) {
+			bits |= 1 << uint(i)
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		at(i, 8, i)
+	}
+	at(6, 8, 7)
+	at(7, 8, 8)
+	at(8, 7, 8)
+	for i := 9; i < 15; i++ {
+		at(i, 14-i, 8)
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(levelLow, m) == bits {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("version %d: format bits %015b of no mask", ver, bits)
+	}
+
+	// The modules of data, unmasked, in the order they are drawn in.
+	u := &Code{Size: n, dark: make([]bool, n*n), reserve: make([]bool, n*n)}
+	u.drawFunctionPatterns(ver)
+	copy(u.dark, c.dark)
+	u.applyMask(mask)
+	var words []byte
+	k := 0
+	for right := n - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for v := 0; v < n; v++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, v
+				if (right+1)&2 == 0 {
+					y = n - 1 - v
+				}
+				if u.reserve[y*n+x] {
+					continue
+				}
+				if k%8 == 0 {
+					words = append(words, 0)
+				}
+				if u.dark[y*n+x] {
+					words[k/8] |= 1 << uint(7-k%8)
+				}
+				k++
+			}
+		}
+	}
+
+	// The data codewords of the blocks, interleaved, the short blocks first.
+	nblocks := eccBlocks[ver]
+	raw := rawCodewords(ver)
+	short := nblocks - raw%nblocks
+	shortData := raw/nblocks - eccPerBlock[ver]
+	blocks := make([][]byte, nblocks)
+	i := 0
+	for col := 0; col <= shortData; col++ {
+		for j := range blocks {
+			if col < shortData || j >= short {
+				blocks[j] = append(blocks[j], words[i])
+				i++
+			}
+		}
+	}
+	data := bytes.Join(blocks, nil)
+
+	// The byte mode, the byte count, then the bytes.
+	get := func(pos, size int) int {
+		v := 0
+		for b := pos; b < pos+size; b++ {
+			v = v<<1 | int(data[b/8]>>uint(7-b%8)&1)
+		}
+		return v
+	}
+	if m := get(0, 4); m != 4 {
+		t.Fatalf("version %d: mode %d", ver, m)
+	}
+	count := get(4, countBits(ver))
+	out := make([]byte, count)
+	for b := range out {
+		out[b] = byte(get(4+countBits(ver)+b*8, 8))
+	}
+	return out
+}
+
+func TestCapacity(t *testing.T) {
+	// The bytes a code of each version holds at the low level, by the table of the standard.
+	capacity := [MaxVersion + 1]int{-1, 17, 32, 53, 78, 106, 134, 154, 192, 230, 271, 321, 367, 425, 458,
+		520, 586, 644, 718, 792, 858, 929, 1003, 1091, 1171, 1273, 1367, 1465, 1528, 1628, 1732, 1840,
+		1952, 2068, 2188, 2303, 2431, 2563, 2699, 2809, 2953}
+	full := make([]byte, capacity[MaxVersion]+1)
+	for i := range full {
+		full[i] = byte(i * 7)
+	}
+	for ver := 1; ver <= MaxVersion; ver++ {
+		if n := bytesCapacity(ver); n != capacity[ver] {
+			t.Errorf("version %d holds %d bytes, want %d", ver, n, capacity[ver])
+			continue
+		}
+		// The most bytes the version holds, then one more, which takes the next version.
+		for _, n := range []int{capacity[ver], capacity[ver] + 1} {
+			if ver == MaxVersion && n > capacity[ver] {
+				break
+			}
+			c, err := Encode(full[:n])
+			if err != nil {
+				t.Fatalf("%d bytes: %v", n, err)
+			}
+			want := ver
+			if n > capacity[ver] {
+				want++
+			}
+			if c.Size != want*4+17 {
+				t.Errorf("%d bytes in a code of %d modules, want version %d", n, c.Size, want)
+			}
+			if got := decode(t, c); !bytes.Equal(got, full[:n]) {
+				t.Errorf("%d bytes decoded as %d bytes", n, len(got))
+			}
+		}
+	}
+	if _, err := Encode(full); err == nil || !strings.Contains(err.Error(), "do not fit") {
+		t.Errorf("%d bytes encoded: %v", len(full), err)
+	}
+}
@@ -0,0 +1,140 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"log"
+	"math"
+	"path/filepath"
+	"time"
+)
+
+// maxRecordFrames bounds the number of frames of a recording, which stops once it has them.
+const maxRecordFrames = 240
+
+// maxRecordPixels bounds the number of pixels of all the frames of a recording once scaled up, as
+// they are encoded at once.
+const maxRecordPixels = 16 << 20
+
+// A recorder captures generations of the universe, to save them as an animated GIF. Frames are kept
+// at one pixel per cell and scaled up when encoded.
+type recorder struct {
+	name   string // Path of the GIF file, without its extension.
+	frames []*image.Paletted
+	delays []int // Delay of each frame, in 100ths of a second.
+	count  int   // Generations stepped since the recording started.
+}
+
+// recording is the current recording, nil if none.
+var recording *recorder
+
+// recordPalette are the colors of the recorded frames, see universe.snapshotColor.
+var recordPalette = func() color.Palette {
+	p := color.Palette{snapshotDeadColor, snapshotAliveColor}
+	for _, t := range ageTints {
+		p = append(p, t.c)
+	}
+	for _, t := range colorTints {
+		p = append(p, t.c)
+	}
+	for _, t := range decayTints {
+		p = append(p, t.c)
+	}
+	for _, t := range brickTints {
+		p = append(p, t.c)
+	}
+	return p
+}()
+
+// startRecording starts recording the universe from its current generation.
+func startRecording() {
+	recording = &recorder{
+		name: filepath.Join(exportDir(), time.Now().Format("golife-20060102-150405")),
+	}
+	recording.capture(univ)
+	buttonBar.Refresh()
+	log.Printf("recording to %s.gif", recording.name)
+}
+
+// capture adds the current generation of u to r, if it is one of every cfg.recordEvery. The
+// recording stops once full, or if the field is resized.
+func (r *recorder) capture(u *universe) {
+	if r == nil {
+		return
+	}
+	r.count++
+	if (r.count-1)%cfg.recordEvery != 0 {
+		return
+	}
+	bounds := image.Rect(0, 0, u.cols, u.rows)
+	if len(r.frames) > 0 && r.frames[0].Bounds() != bounds {
+		r.stop()
+		return
+	}
+	img := image.NewPaletted(bounds, recordPalette)
+	for j := 0; j < u.rows; j++ {
+		for i := 0; i < u.cols; i++ {
+			img.Set(i, j, u.snapshotColor(i, j))
+		}
+	}
+	// Frames last as long as the generations they stand for at the current speed.
+	delay := int(math.Floor(100*float64(cfg.recordEvery)/speed + 0.5))
+	if delay < 2 {
+		// Most viewers slow down shorter delays.
+		delay = 2
+	}
+	r.frames = append(r.frames, img)
+	r.delays = append(r.delays, delay)
+	if len(r.frames) == maxRecordFrames {
+		r.stop()
+	}
+}
+
+// stop ends the recording and writes it in the background, not to hold up the frames.
+func (r *recorder) stop() {
+	if r == nil {
+		return
+	}
+	recording = nil
+	buttonBar.Refresh()
+	if len(r.frames) == 0 {
+		return
+	}
+	// The scale of the snapshots, unless the frames would take too much memory.
+	b := r.frames[0].Bounds()
+	scale := int(math.Sqrt(float64(maxRecordPixels) / float64(len(r.frames)*b.Dx()*b.Dy())))
+	if scale > cfg.exportScale {
+		scale = cfg.exportScale
+	} else if scale < 1 {
+		scale = 1
+	}
+	go func() {
+		name := r.name + ".gif"
+		if err := writeFile(name, func(w io.Writer) error { return r.encode(w, scale) }); err != nil {
+			log.Printf("recording: %v", err)
+			return
+		}
+		log.Printf("recorded %d frames to %s", len(r.frames), name)
+	}()
+}
+
+// encode writes the frames of r as a looping animated GIF, each cell as a scale*scale block of
+// pixels.
+func (r *recorder) encode(w io.Writer, scale int) error {
+	g := &gif.GIF{Delay: r.delays}
+	for _, f := range r.frames {
+		b := f.Bounds()
+		img := image.NewPaletted(image.Rect(0, 0, b.Dx()*scale, b.Dy()*scale), f.Palette)
+		for y := 0; y < img.Rect.Dy(); y++ {
+			for x := 0; x < img.Rect.Dx(); x++ {
+				img.SetColorIndex(x, y, f.ColorIndexAt(x/scale, y/scale))
+			}
+		}
+		g.Image = append(g.Image, img)
+	}
+	return gif.EncodeAll(w, g)
+}
@@ -0,0 +1,151 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"log"
+
+	"golang.org/x/mobile/sprite"
+)
+
+// A trackedEngine is an engine counting the nodes it registered and the textures it loaded, so
+// that the nodes and textures the panels and rebuilds fail to release show, see usage.
+type trackedEngine struct {
+	sprite.Engine
+	nodes    int // Registered and not unregistered.
+	textures int // Loaded and not unloaded.
+	texBytes int // Estimated size of the textures loaded, 4 bytes per px.
+}
+
+// track returns e tracking its resources.
+func track(e sprite.Engine) *trackedEngine {
+	return &trackedEngine{Engine: e}
+}
+
+// A trackedTexture is a texture loaded by a trackedEngine, which counts it until unloaded.
+type trackedTexture struct {
+	sprite.Texture
+	e     *trackedEngine
+	bytes int // 0 once unloaded.
+}
+
+// Register implements sprite.Engine.
+func (e *trackedEngine) Register(n *sprite.Node) {
+	e.nodes++
+	e.Engine.Register(n)
+}
+
+// Unregister implements sprite.Engine.
+func (e *trackedEngine) Unregister(n *sprite.Node) {
+	e.nodes--
+	e.Engine.Unregister(n)
+}
+
+// LoadTexture implements sprite.Engine.
+func (e *trackedEngine) LoadTexture(a image.Image) (sprite.Texture, error) {
+	t, err := e.Engine.LoadTexture(a)
+	if err != nil {
+		return nil, err
+	}
+	w, h := t.Bounds()
+	tt := &trackedTexture{Texture: t, e: e, bytes: 4 * w * h}
+	e.textures++
+	e.texBytes += tt.bytes
+	return tt, nil
+}
+
+// SetSubTex implements sprite.Engine. The engine is given its own texture, which it draws from.
+func (e *trackedEngine) SetSubTex(n *sprite.Node, x sprite.SubTex) {
+	if t, ok := x.T.(*trackedTexture); ok {
+		x.T = t.Texture
+	}
+	e.Engine.SetSubTex(n, x)
+}
+
+// Unload implements sprite.Texture.
+func (t *trackedTexture) Unload() {
+	if t.bytes > 0 {
+		t.e.textures--
+		t.e.texBytes -= t.bytes
+		t.bytes = 0
+	}
+	t.Texture.Unload()
+}
+
+// A resourceUsage counts the resources held by the app.
+type resourceUsage struct {
+	// Nodes registered with the engine, and the ones of them attached to the trees rendered, see
+	// renderedRoots. The others are hidden or leaked.
+	nodes, attached int
+	// Textures loaded and their estimated size in bytes.
+	textures, textureBytes int
+	// Size in bytes of the generations to step back and forward to, and of the cells to undo and
+	// redo edits to, see timeline and editStack.
+	historyBytes int
+	lifeBytes    int // Estimated size of the game, see life.Life.Bytes.
+}
+
+// usage returns the resources held by the app, the nodes and textures counted only if eng tracks
+// them.
+func usage() resourceUsage {
+	var r resourceUsage
+	if e, ok := eng.(*trackedEngine); ok {
+		r.nodes, r.textures, r.textureBytes = e.nodes, e.textures, e.texBytes
+	}
+	for _, n := range renderedRoots() {
+		r.attached += countNodes(n)
+	}
+	if u := univ; u != nil {
+		for _, snaps := range [][][]byte{u.timeline.past, u.timeline.future} {
+			for _, s := range snaps {
+				r.historyBytes += len(s)
+			}
+		}
+		for _, es := range [][]*edit{u.edits.undo, u.edits.redo} {
+			for _, e := range es {
+				if e.cells != nil {
+					// Two ints of 8 bytes per cell.
+					r.historyBytes += 2 * 8 * len(e.cells.Cells)
+				}
+			}
+		}
+		r.lifeBytes = u.life.Bytes()
+	}
+	return r
+}
+
+// logUsage logs the resources held by the app, as the debug overlay shows or hides.
+func logUsage() {
+	r := usage()
+	log.Printf("resources: %d nodes, %d attached, %d textures of %d KB, history of %d KB, game of %d KB",
+		r.nodes, r.attached, r.textures, r.textureBytes/1024, r.historyBytes/1024, r.lifeBytes/1024)
+}
+
+// renderedRoots returns the roots of the trees rendered every frame, in order: the scene and the
+// open panels.
+func renderedRoots() []*sprite.Node {
+	var roots []*sprite.Node
+	if scene != nil {
+		roots = append(roots, scene)
+	}
+	if views.panel != nil {
+		roots = append(roots, views.panel.root)
+	}
+	if views.menu != nil {
+		roots = append(roots, views.menu.root)
+	}
+	if views.shared != nil {
+		roots = append(roots, views.shared.root)
+	}
+	if views.gallery != nil {
+		roots = append(roots, views.gallery.root)
+	}
+	if views.picker != nil {
+		roots = append(roots, views.picker.root)
+	}
+	if views.console != nil {
+		roots = append(roots, views.console.root)
+	}
+	return roots
+}
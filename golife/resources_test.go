@@ -0,0 +1,73 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// trackedScene builds the scene as testScene does, on a fake engine tracked by the returned one.
+func trackedScene(t *testing.T) (*trackedEngine, *fakeEngine) {
+	testScene(t, 320, 480)
+	fake := newFakeEngine()
+	e := track(fake)
+	eng = e
+	inTempDir(t, func(dir string) {
+		buildScene(nil)
+	})
+	univ.adopt(life.New(univ.cols, univ.rows))
+	forgetPointers()
+	return e, fake
+}
+
+func TestResourceUsage(t *testing.T) {
+	e, fake := trackedScene(t)
+	r := usage()
+	if r.nodes != fake.nodes || r.textures != fake.textures || r.textureBytes == 0 {
+		t.Errorf("%d nodes and %d textures tracked, %d and %d loaded",
+			r.nodes, r.textures, fake.nodes, fake.textures)
+	}
+	if r.attached == 0 || r.attached > r.nodes || r.lifeBytes != univ.life.Bytes() {
+		t.Errorf("%d nodes attached of %d, game of %d bytes", r.attached, r.nodes, r.lifeBytes)
+	}
+	// The engine draws from its own textures.
+	for n, x := range fake.subTex {
+		if _, ok := x.T.(*trackedTexture); ok {
+			t.Fatalf("node %p given a tracked texture", n)
+		}
+	}
+
+	univ.beginEdit()
+	univ.Step()
+	if h := usage().historyBytes; h <= r.historyBytes {
+		t.Errorf("history of %d bytes once stepped and edited, %d before", h, r.historyBytes)
+	}
+
+	// Unloading twice counts once.
+	tex, _ := e.LoadTexture(image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	tex.Unload()
+	tex.Unload()
+	if e.textures != r.textures || e.texBytes != r.textureBytes {
+		t.Errorf("%d textures of %d bytes once unloaded, want %d of %d",
+			e.textures, e.texBytes, r.textures, r.textureBytes)
+	}
+}
+
+func TestPickerLeaks(t *testing.T) {
+	trackedScene(t)
+	base := usage()
+	for k := 0; k < 50; k++ {
+		openPicker("", k%2 == 1)
+		if r := usage(); r.textures <= base.textures || r.attached <= base.attached {
+			t.Fatalf("picker %d open with %d textures and %d nodes attached", k, r.textures, r.attached)
+		}
+		views.picker.close()
+	}
+	if r := usage(); r.nodes != base.nodes || r.attached != base.attached || r.textures != base.textures ||
+		r.textureBytes != base.textureBytes {
+		t.Errorf("picker opened and closed 50 times leaves %+v, was %+v", r, base)
+	}
+}
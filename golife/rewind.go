@@ -0,0 +1,105 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import "log"
+
+// A timeline holds the encoded past generations of a game, to step back, and once stepped back the
+// generations to step forward to again.
+type timeline struct {
+	past   [][]byte // From the oldest to the latest, at most cfg.history.
+	future [][]byte // From the farthest to the next.
+}
+
+// clear forgets every generation, for instance once the universe is replaced.
+func (tl *timeline) clear() {
+	tl.past, tl.future = nil, nil
+}
+
+// record remembers snap, the encoding of the current generation before a step.
+func (tl *timeline) record(snap []byte) {
+	if cfg.history == 0 {
+		return
+	}
+	if len(tl.past) >= cfg.history {
+		tl.past = append(tl.past[:0], tl.past[1:]...)
+	}
+	tl.past = append(tl.past, snap)
+}
+
+// edited marks the game as edited by the user: the stagnation history is reset and the generations
+// stepped back from are lost, being no longer the future of the game.
+func (u *universe) edited() {
+	u.history.clear()
+	u.timeline.future = nil
+}
+
+// Step advances the universe one generation and reports whether it was computed, rather than
+// replayed from the generations stepped back from.
+func (u *universe) Step() bool {
+	u.edits.stepped()
+	tl := &u.timeline
+	if snap, err := u.life.MarshalBinary(); err == nil {
+		tl.record(snap)
+	}
+	if n := len(tl.future); n > 0 {
+		u.travel(tl.future[n-1])
+		tl.future = tl.future[:n-1]
+		recording.capture(u)
+		sounds.tick(u.life.Population())
+		spark.record(u.life.Population())
+		return false
+	}
+	if u.life.Generation() == 0 {
+		runStart = u.life.Clone()
+	}
+	u.step()
+	recording.capture(u)
+	sounds.tick(u.life.Population())
+	spark.record(u.life.Population())
+	return true
+}
+
+// Back steps the universe back one generation, if its timeline goes back that far. The edits by
+// the user are lost, unless the game was stepped since the latest one.
+func (u *universe) Back() {
+	es := &u.edits
+	n := len(es.undo)
+	ran := n > 0 && es.undo[n-1].steps > 0
+	if !u.back() {
+		return
+	}
+	if ran {
+		es.steppedBack()
+	} else {
+		es.clear()
+	}
+}
+
+// back steps the universe back one generation and reports whether its timeline went back that far.
+func (u *universe) back() bool {
+	tl := &u.timeline
+	n := len(tl.past)
+	if n == 0 {
+		return false
+	}
+	snap, err := u.life.MarshalBinary()
+	if err != nil {
+		log.Printf("stepping back: %v", err)
+		return false
+	}
+	tl.future = append(tl.future, snap)
+	u.travel(tl.past[n-1])
+	tl.past = tl.past[:n-1]
+	spark.back()
+	return true
+}
+
+// travel replaces the game by the one encoded in snap and repaints it.
+func (u *universe) travel(snap []byte) {
+	if err := u.life.UnmarshalBinary(snap); err != nil {
+		log.Printf("restoring a generation: %v", err)
+		return
+	}
+	u.repaint()
+}
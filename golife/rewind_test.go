@@ -0,0 +1,91 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// gameState returns the state of l, the age of its alive cells included, to compare games.
+func gameState(l *life.Life) string {
+	w, h := l.Bounds()
+	s := fmt.Sprintf("%dx%d generation %d population %d hash %x", w, h, l.Generation(), l.Population(), l.Hash())
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if l.Alive(x, y) {
+				s += fmt.Sprintf(" %d,%d:%d", x, y, l.Age(x, y))
+			}
+		}
+	}
+	return s
+}
+
+func TestRewind(t *testing.T) {
+	testScene(t, 320, 480)
+	l := life.New(univ.cols, univ.rows)
+	l.SeedWith(0.3, life.Random, 1)
+	univ.adopt(l)
+	univ.timeline.clear()
+	// Some cells age before the rewind starts.
+	for k := 0; k < 10; k++ {
+		univ.Step()
+	}
+
+	const k = 20
+	var states []string
+	for n := 0; n < k; n++ {
+		states = append(states, gameState(univ.life))
+		if !univ.Step() {
+			t.Fatalf("step %d replayed with no generation stepped back from", n)
+		}
+	}
+	last := gameState(univ.life)
+
+	// k steps back, ages included.
+	for n := k - 1; n >= 0; n-- {
+		univ.Back()
+		if s := gameState(univ.life); s != states[n] {
+			t.Fatalf("%d steps back, %s\nwant %s", k-n, s, states[n])
+		}
+	}
+	// And k steps forward again, replayed.
+	for n := 1; n <= k; n++ {
+		if univ.Step() {
+			t.Fatalf("step %d forward computed rather than replayed", n)
+		}
+		want := last
+		if n < k {
+			want = states[n]
+		}
+		if s := gameState(univ.life); s != want {
+			t.Fatalf("%d steps forward, %s\nwant %s", n, s, want)
+		}
+	}
+	if !univ.Step() {
+		t.Errorf("step past the generations stepped back from replayed")
+	}
+
+	// The timeline keeps at most cfg.history generations.
+	for n := 0; n < cfg.history+10; n++ {
+		univ.Step()
+	}
+	for n := 0; n < cfg.history; n++ {
+		univ.Back()
+	}
+	g := univ.life.Generation()
+	univ.Back()
+	if univ.life.Generation() != g {
+		t.Errorf("stepped back beyond the %d generations of the history", cfg.history)
+	}
+
+	// Edits make the generations stepped back from no longer the future.
+	univ.Back()
+	univ.Back()
+	univ.edited()
+	if !univ.Step() {
+		t.Errorf("step after an edit replayed")
+	}
+}
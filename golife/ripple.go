@@ -0,0 +1,148 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"math"
+
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+	"golang.org/x/mobile/sprite/clock"
+)
+
+const (
+	// rippleTime is how long a ripple spreads, in ticks of the clock, 400ms.
+	rippleTime = 24
+	// rippleSize is the diameter in Pt a ripple spreads to, as shown whatever the zoom.
+	rippleSize = 48
+	// maxRipples is the number of ripples showing at most. Once all show, the oldest starts over.
+	maxRipples = 8
+	// ripplePx is the side in px of the frames of the ring, rippleFrames of them fading out side by
+	// side, see rippleStrip.
+	ripplePx     = 64
+	rippleFrames = 6
+)
+
+// A ripple is a ring spreading and fading out over the grid from a tap or a stamp, drawn by a node
+// of the grid above the cells and below the panels. Its node is kept once it faded out, hidden, for
+// the next ripple.
+type ripple struct {
+	node  *sprite.Node
+	at    geom.Point // Center on the field, see universe.toField.
+	start clock.Time // -1 until the first frame.
+	done  bool
+}
+
+// rippleFrameImage returns the name of the sub-texture of the frame k of the ring of the ripples.
+func rippleFrameImage(k int) string {
+	return fmt.Sprintf("ripple_%d", k)
+}
+
+// rippleStrip returns the frames of the ring of the ripples side by side, in the color of the
+// selection of the current theme, more transparent from one frame to the next.
+func rippleStrip() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, rippleFrames*ripplePx, ripplePx))
+	c := color.NRGBAModel.Convert(themes[currentTheme].wrapBorder).(color.NRGBA)
+	const (
+		r     = ripplePx/2 - 1  // Radius of the middle of the ring.
+		width = ripplePx / 16.0 // Half the thickness of the ring.
+	)
+	for k := 0; k < rippleFrames; k++ {
+		fade := 1 - float64(k)/rippleFrames
+		for y := 0; y < ripplePx; y++ {
+			for x := 0; x < ripplePx; x++ {
+				d := math.Hypot(float64(x)+0.5-ripplePx/2, float64(y)+0.5-ripplePx/2)
+				// Antialiased over a pixel on either side of the ring.
+				a := math.Min(1, math.Max(0, width+1-math.Abs(d-(r-width))))
+				if a > 0 {
+					img.SetNRGBA(k*ripplePx+x, y, color.NRGBA{c.R, c.G, c.B, uint8(float64(c.A)*a*fade + 0.5)})
+				}
+			}
+		}
+	}
+	return img
+}
+
+// loadRipples stores in m the sub-textures of the frames of the ring of the ripples.
+func loadRipples(m map[string]*sprite.SubTex) {
+	tex, err := eng.LoadTexture(rippleStrip())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for k := 0; k < rippleFrames; k++ {
+		m[rippleFrameImage(k)] = &sprite.SubTex{tex, image.Rect(k*ripplePx, 0, (k+1)*ripplePx, ripplePx)}
+	}
+}
+
+// cellCenter returns the location on the field of the center of the cell (i, j) of u, as drawn
+// drifting.
+func (u *universe) cellCenter(i, j int) geom.Point {
+	return geom.Point{
+		X: u.margin + u.slide.X + (geom.Pt(i)+0.5)*cellSize,
+		Y: u.margin + u.slide.Y + (geom.Pt(j)+0.5)*cellSize,
+	}
+}
+
+// startRipple starts a ripple centered on the location at of the field, by the next frame. There
+// are none in accessibility mode, which keeps the screen still.
+func startRipple(at geom.Point) {
+	if cfg.accessible || grid == nil {
+		return
+	}
+	var r *ripple
+	for _, q := range views.ripples {
+		if q.done {
+			r = q
+			break
+		}
+	}
+	switch {
+	case r == nil && len(views.ripples) < maxRipples:
+		r = &ripple{node: &sprite.Node{}}
+		eng.Register(r.node)
+		grid.AppendChild(r.node)
+		views.ripples = append(views.ripples, r)
+	case r == nil:
+		r = views.ripples[0]
+	}
+	// The ripples stay ordered by start.
+	for k, q := range views.ripples {
+		if q == r {
+			views.ripples = append(append(views.ripples[:k:k], views.ripples[k+1:]...), r)
+			break
+		}
+	}
+	r.at, r.start, r.done = at, -1, false
+}
+
+// updateRipples spreads and fades out the ripples for the frame of the given time, hiding those
+// done.
+func updateRipples(t clock.Time) {
+	for _, r := range views.ripples {
+		if r.done {
+			continue
+		}
+		if r.start < 0 {
+			r.start = t
+		}
+		x := clock.EaseOut(r.start, r.start+rippleTime, t)
+		if x >= 1 {
+			r.done = true
+			eng.SetTransform(r.node, f32.Affine{})
+			continue
+		}
+		k := int(x * rippleFrames)
+		eng.SetSubTex(r.node, *textures[rippleFrameImage(k)])
+		// As large on the screen whatever the zoom of the grid.
+		d := float32(rippleSize*(0.25+0.75*x)) / univ.zoom
+		eng.SetTransform(r.node, f32.Affine{
+			{d, 0, float32(r.at.X) - d/2},
+			{0, d, float32(r.at.Y) - d/2},
+		})
+	}
+}
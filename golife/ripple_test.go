@@ -0,0 +1,81 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/f32"
+)
+
+func TestRipple(t *testing.T) {
+	gestureScene(t)
+	e := eng.(*fakeEngine)
+	loc := cellLoc(4, 6)
+	finger(1, event.TouchStart, loc)
+	finger(1, event.TouchEnd, loc)
+	if len(views.ripples) != 1 {
+		t.Fatalf("%d ripples once tapped, want 1", len(views.ripples))
+	}
+	n := views.ripples[0].node
+	updateRipples(10)
+	updateRipples(10 + rippleTime/2)
+	// Centered on the tap, on the grid, fading out.
+	a, at := e.transforms[n], univ.toField(loc)
+	x, y := float64(a[0][2]+a[0][0]/2), float64(a[1][2]+a[1][1]/2)
+	if math.Abs(x-float64(at.X)) > 0.01 || math.Abs(y-float64(at.Y)) > 0.01 {
+		t.Errorf("ripple centered on (%v, %v), want %v", x, y, at)
+	}
+	if e.subTex[n] == *textures[rippleFrameImage(0)] {
+		t.Errorf("ripple not fading out")
+	}
+	updateRipples(10 + rippleTime)
+	if !views.ripples[0].done || e.transforms[n] != (f32.Affine{}) {
+		t.Errorf("ripple showing once spread")
+	}
+
+	// Strokes do not ripple, and the node of a ripple done is reused.
+	finger(1, event.TouchStart, cellLoc(2, 2))
+	finger(1, event.TouchMove, cellLoc(8, 2))
+	finger(1, event.TouchEnd, cellLoc(8, 2))
+	if !views.ripples[0].done {
+		t.Errorf("stroke rippling")
+	}
+	nodes := e.nodes
+	finger(1, event.TouchStart, loc)
+	finger(1, event.TouchEnd, loc)
+	if len(views.ripples) != 1 || views.ripples[0].node != n || e.nodes != nodes {
+		t.Errorf("%d ripples of %d nodes once tapped again, want the node reused", len(views.ripples), e.nodes-nodes+1)
+	}
+}
+
+func TestRipplePool(t *testing.T) {
+	gestureScene(t)
+	e := eng.(*fakeEngine)
+	nodes := e.nodes
+	for k := 0; k < 3*maxRipples; k++ {
+		loc := cellLoc(k%10, 3)
+		finger(1, event.TouchStart, loc)
+		finger(1, event.TouchEnd, loc)
+	}
+	if len(views.ripples) != maxRipples || e.nodes != nodes+maxRipples {
+		t.Errorf("%d ripples, %d nodes added by rapid taps, want %d", len(views.ripples), e.nodes-nodes, maxRipples)
+	}
+	// The latest tap ripples, in place of the oldest one.
+	if last := views.ripples[len(views.ripples)-1]; last.done || last.at != univ.toField(cellLoc((3*maxRipples-1)%10, 3)) {
+		t.Errorf("latest tap not rippling")
+	}
+}
+
+func TestRippleAccessible(t *testing.T) {
+	gestureScene(t)
+	cfg.accessible = true
+	loc := cellLoc(4, 6)
+	finger(1, event.TouchStart, loc)
+	finger(1, event.TouchEnd, loc)
+	if len(views.ripples) != 0 {
+		t.Errorf("%d ripples in accessibility mode", len(views.ripples))
+	}
+}
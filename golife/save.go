@@ -0,0 +1,96 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// saveVersion is the first byte of the save file. It must change whenever its layout does. Version 1
+// stored renderEvery, a number of frames per generation, instead of the speed.
+const saveVersion = 2
+
+// savePath returns the file the game is saved to when the app stops, in the private storage of the
+// app rather than the cache, which the OS may clear. Like every file the app writes, it is read with
+// os.Open, as app.Open only reaches the assets on devices.
+func savePath() string {
+	return filepath.Join(appDir(), "golife.save")
+}
+
+// save writes the speed and the game of u to the save file, as encoded by encodeSave.
+func (u *universe) save() error {
+	b, err := encodeSave(u.life, speed)
+	if err != nil {
+		return err
+	}
+	// Write then rename, so that being killed while saving does not corrupt the previous save.
+	tmp := savePath() + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, savePath())
+}
+
+// encodeSave returns the save file of the game l played at speed s. After the version byte, the save
+// file holds the speed in thousandths of generation per second as a varint, then the binary encoding
+// of the game.
+func encodeSave(l *life.Life, s float64) ([]byte, error) {
+	b := []byte{saveVersion}
+	var buf [binary.MaxVarintLen64]byte
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(s*1000+0.5))]...)
+	g, err := l.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(b, g...), nil
+}
+
+// restore replaces the game of u, and the speed, by the saved ones. A saved field of another size,
+// from another device or orientation, is centered on u, cropped or padded with dead cells. On error
+// u is left unchanged.
+func (u *universe) restore() error {
+	b, err := ioutil.ReadFile(savePath())
+	if err != nil {
+		return err
+	}
+	l, s, err := decodeSave(b)
+	if err != nil {
+		return fmt.Errorf("%s: %v", savePath(), err)
+	}
+	if w, h := l.Bounds(); w != u.cols || h != u.rows {
+		l.Resize(u.cols, u.rows, (u.cols-w)/2, (u.rows-h)/2)
+	}
+	speed = s
+	soupSeed, soupRun, runStart = 0, nil, nil
+	u.adopt(l)
+	return nil
+}
+
+// decodeSave returns the game and the speed of the save file b, as encoded by encodeSave or by an
+// earlier version. Speeds off the levels, for instance saved with levels since changed, snap to the
+// closest one.
+func decodeSave(b []byte) (*life.Life, float64, error) {
+	if len(b) == 0 || b[0] != 1 && b[0] != saveVersion {
+		return nil, 0, errors.New("unknown save version")
+	}
+	v, n := binary.Uvarint(b[1:])
+	s := float64(v) / 1000
+	if b[0] == 1 && v > 0 {
+		s = 60 / float64(v)
+	}
+	if n <= 0 || s <= 0 {
+		return nil, 0, errors.New("invalid speed")
+	}
+	l := life.NewSparse(1, 1)
+	if err := l.UnmarshalBinary(b[1+n:]); err != nil {
+		return nil, 0, err
+	}
+	return l, speedLevels[speedLevel(s)], nil
+}
@@ -0,0 +1,83 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+func TestSaveRoundTrip(t *testing.T) {
+	empty := life.New(30, 20)
+	full := life.New(30, 20)
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 30; x++ {
+			full.Set(x, y, true)
+		}
+	}
+	soup := life.New(30, 20)
+	soup.Rule, _ = life.ParseRule("B36/S23")
+	soup.Edges = life.Reflect
+	soup.SeedWith(0.35, life.Random, 7)
+	for k := 0; k < 10; k++ {
+		soup.Step()
+	}
+	for name, l := range map[string]*life.Life{"empty": empty, "full": full, "soup": soup} {
+		for _, s := range speedLevels {
+			b, err := encodeSave(l, s)
+			if err != nil {
+				t.Fatalf("%s: encodeSave: %v", name, err)
+			}
+			d, ds, err := decodeSave(b)
+			if err != nil {
+				t.Errorf("%s at %v generations per second: decodeSave: %v", name, s, err)
+				continue
+			}
+			if ds != s {
+				t.Errorf("%s: speed %v restored as %v", name, s, ds)
+			}
+			w, h := l.Bounds()
+			if dw, dh := d.Bounds(); dw != w || dh != h {
+				t.Fatalf("%s: %dx%d field restored as %dx%d", name, w, h, dw, dh)
+			}
+			if d.Generation() != l.Generation() || d.Rule != l.Rule || d.Edges != l.Edges ||
+				d.Population() != l.Population() || d.Hash() != l.Hash() {
+				t.Errorf("%s: restored as another game", name)
+			}
+		}
+	}
+}
+
+func TestSaveVersion1(t *testing.T) {
+	g, _ := life.New(4, 4).MarshalBinary()
+	// Version 1 stored 12 frames per generation, at 60 frames per second.
+	_, s, err := decodeSave(append([]byte{1, 12}, g...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := speedLevels[speedLevel(5)]; s != want {
+		t.Errorf("restored speed %v, want %v", s, want)
+	}
+}
+
+func TestSaveCorrupt(t *testing.T) {
+	l := life.New(10, 10)
+	l.SeedWith(0.5, life.Random, 1)
+	b, _ := encodeSave(l, initialSpeed)
+	for n := 0; n < len(b); n++ {
+		if _, _, err := decodeSave(b[:n]); err == nil {
+			t.Errorf("restored the first %d of %d bytes", n, len(b))
+		}
+	}
+	for _, b := range [][]byte{
+		{saveVersion + 1, 1},
+		{saveVersion, 0},
+		// A game whose dying cells go past the end of the field.
+		{saveVersion, 1, 4, 2, 1, 0, 8, 12, 0, 0, 0, 2, 1, 0, 3, 2, 2, 1, 2, 5, 2},
+	} {
+		if _, _, err := decodeSave(b); err == nil {
+			t.Errorf("restored %v", b)
+		}
+	}
+}
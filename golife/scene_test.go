@@ -0,0 +1,269 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"image"
+	imagedraw "image/draw"
+	"reflect"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/app"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+	"golang.org/x/mobile/sprite/clock"
+)
+
+// A fakeEngine is a sprite engine keeping the sub-textures and transforms of the nodes, without
+// drawing them.
+type fakeEngine struct {
+	nodes      int // Number of nodes registered and not unregistered.
+	textures   int // Number of textures loaded.
+	subTex     map[*sprite.Node]sprite.SubTex
+	transforms map[*sprite.Node]f32.Affine
+}
+
+func newFakeEngine() *fakeEngine {
+	return &fakeEngine{
+		subTex:     make(map[*sprite.Node]sprite.SubTex),
+		transforms: make(map[*sprite.Node]f32.Affine),
+	}
+}
+
+func (e *fakeEngine) Register(n *sprite.Node) { e.nodes++ }
+
+func (e *fakeEngine) Unregister(n *sprite.Node) {
+	e.nodes--
+	delete(e.subTex, n)
+	delete(e.transforms, n)
+}
+
+func (e *fakeEngine) LoadTexture(a image.Image) (sprite.Texture, error) {
+	e.textures++
+	return fakeTexture(a.Bounds()), nil
+}
+
+func (e *fakeEngine) SetSubTex(n *sprite.Node, x sprite.SubTex) { e.subTex[n] = x }
+func (e *fakeEngine) SetTransform(n *sprite.Node, m f32.Affine) { e.transforms[n] = m }
+
+// Render arranges the nodes of the scene, as the engines do before drawing them.
+func (e *fakeEngine) Render(scene *sprite.Node, t clock.Time) {
+	var arrange func(n *sprite.Node)
+	arrange = func(n *sprite.Node) {
+		if n.Arranger != nil {
+			n.Arranger.Arrange(e, n, t)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			arrange(c)
+		}
+	}
+	arrange(scene)
+}
+
+// A fakeTexture is a texture of the given bounds, without pixels.
+type fakeTexture image.Rectangle
+
+func (t fakeTexture) Bounds() (w, h int)                              { return image.Rectangle(t).Dx(), image.Rectangle(t).Dy() }
+func (t fakeTexture) Download(r image.Rectangle, dst imagedraw.Image) {}
+func (t fakeTexture) Upload(r image.Rectangle, src image.Image)       {}
+func (t fakeTexture) Unload()                                         {}
+
+// testScene builds the scene of the app on a fake engine for a screen of the given size in Pt, with
+// the default config and a random universe, and returns the engine. The assets are those of the
+// app, and the settings and saved game are looked for in a temporary directory.
+func testScene(t testing.TB, w, h geom.Pt) *fakeEngine {
+	geom.Width, geom.Height, geom.PixelsPerPt = w, h, 2
+	e := newFakeEngine()
+	eng = e
+	cfg = defaultConfig()
+	cellSize = cfg.cellSize
+	inTempDir(t, func(dir string) {
+		buildScene(nil)
+	})
+	return e
+}
+
+func TestFallbackScene(t *testing.T) {
+	defer func(open func(string) (app.ReadSeekCloser, error)) { openAsset = open }(openAsset)
+	openAsset = func(name string) (app.ReadSeekCloser, error) {
+		return nil, errors.New("no assets")
+	}
+	e := testScene(t, 320, 480)
+	// Every image used by the scene has a texture, generated in place of the assets.
+	for _, img := range append(atlasImages, androidImage, emptyImage, buttonsImage) {
+		if textures[img] == nil {
+			t.Errorf("no texture for %q", img)
+		}
+	}
+	if textures[pauseImage].R.Empty() || textures[androidImage].R.Empty() {
+		t.Errorf("empty fallback textures")
+	}
+	if univ == nil || univ.cols == 0 || univ.rows == 0 || buttonBar == nil {
+		t.Fatalf("scene built without a universe or button bar")
+	}
+	// The cells show on the fallback textures.
+	shown := 0
+	for _, n := range univ.cells {
+		if x, ok := e.subTex[n]; ok && x == *textures[androidImage] {
+			shown++
+		}
+	}
+	if shown != univ.life.Population() {
+		t.Errorf("%d cells shown, %d alive", shown, univ.life.Population())
+	}
+	e.Render(scene, 1)
+}
+
+func TestCellBudget(t *testing.T) {
+	e := testScene(t, 320, 480)
+	defer func(c config, siz geom.Pt) { cfg, cellSize = c, siz }(cfg, cellSize)
+
+	// 2pt cells on a 1024*768pt tablet make 196608 cells, which grow to fit the budget.
+	cfg = defaultConfig()
+	cellSize = 2
+	u := newUniverse(768, 1024)
+	if n := u.cols * u.rows; n > cfg.maxCells || n < cfg.maxCells/2 {
+		t.Errorf("%d cells of %v, want at most %d and not much fewer", n, cellSize, cfg.maxCells)
+	}
+	if len(u.cells) != u.cols*u.rows || e.nodes < len(u.cells) {
+		t.Errorf("%d cell nodes registered for %d cells", e.nodes, u.cols*u.rows)
+	}
+	u.release()
+
+	// The budget applies to the cells of the manifest.
+	cfg.maxCells = 1000
+	cellSize = 8
+	if u = newUniverse(768, 1024); u.cols*u.rows > 1000 {
+		t.Errorf("%d cells, over the budget of 1000", u.cols*u.rows)
+	}
+	u.release()
+
+	// The texture render mode has no node per cell, so nor budget.
+	cfg.frameRender = true
+	cellSize = 2
+	nodes := e.nodes
+	if u = newUniverse(768, 1024); u.cols != 512 || u.rows != 384 || cellSize != 2 {
+		t.Errorf("%dx%d cells of %v in the texture render mode, want 512x384 of 2", u.cols, u.rows, cellSize)
+	}
+	if e.nodes-nodes > 100 {
+		t.Errorf("%d nodes registered in the texture render mode", e.nodes-nodes)
+	}
+}
+
+func TestDropViews(t *testing.T) {
+	testScene(t, 320, 480)
+	defer func() { tour = nil }()
+	startTutorial()
+	updateTutorial()
+	showToast("toast")
+	updateToast()
+	openSettings()
+	if views.tour == nil || views.popup == nil || views.panel == nil || len(holds) != 1 {
+		t.Fatalf("tutorial, toast or settings panel not shown")
+	}
+
+	// Once the GL context is lost, the scene is built again on a new engine, showing the same game
+	// and tutorial without the views of the former scene.
+	want := gameState(univ.life)
+	e := newFakeEngine()
+	eng = e
+	inTempDir(t, func(dir string) {
+		buildScene(univ.life)
+	})
+	if !reflect.DeepEqual(views, sceneViews{}) || len(holds) != 0 {
+		t.Errorf("views %+v, %d panels held once the scene was built again", views, len(holds))
+	}
+	if got := gameState(univ.life); got != want || tour == nil || !paused {
+		t.Errorf("game %s, tutorial %v, paused %v once the scene was built again, want %s", got, tour, paused, want)
+	}
+	updateTutorial()
+	if views.tour == nil || len(views.tour.nodes) == 0 || e.subTex[views.tour.nodes[0]] == (sprite.SubTex{}) {
+		t.Errorf("tutorial not shown by the new engine")
+	}
+}
+
+// misshown returns the cells of the universe whose node does not show whether they are alive: an
+// alive cell shows the image of its age, a dead one none or a trail.
+func misshown(e *fakeEngine) [][2]int {
+	dead := map[sprite.SubTex]bool{*textures[emptyImage]: true}
+	for l := 1; l <= trailLevels; l++ {
+		dead[*textures[trailLevelImage(l)]] = true
+	}
+	var cells [][2]int
+	for j := 0; j < univ.rows; j++ {
+		for i := 0; i < univ.cols; i++ {
+			x, ok := e.subTex[univ.cells[j*univ.cols+i]]
+			if alive := univ.life.Alive(i, j); !ok || alive && x != *textures[univ.cellImage(i, j)] || !alive && !dead[x] {
+				cells = append(cells, [2]int{i, j})
+			}
+		}
+	}
+	return cells
+}
+
+func TestFirstPaint(t *testing.T) {
+	// The scene as built shows every cell, not only those changed by a step.
+	e := testScene(t, 320, 480)
+	if univ.life.Population() == 0 {
+		t.Fatalf("scene built with no alive cells")
+	}
+	if cells := misshown(e); len(cells) != 0 {
+		t.Errorf("cells %v misshown once the scene was built", cells)
+	}
+	univ.Step()
+	if cells := misshown(e); len(cells) != 0 {
+		t.Errorf("cells %v misshown after the first generation", cells)
+	}
+
+	// So does the replay, whatever the nodes showed.
+	for _, n := range univ.cells {
+		e.SetSubTex(n, *textures[outOfBoundsImage])
+	}
+	tap(replayImage)
+	if cells := misshown(e); len(cells) != 0 {
+		t.Errorf("%d cells misshown once replayed", len(cells))
+	}
+	univ.Step()
+	if cells := misshown(e); len(cells) != 0 {
+		t.Errorf("%d cells misshown after the first generation replayed", len(cells))
+	}
+}
+
+// benchmarkRepaint steps a random soup on a universe of 100x100 cells, showing after every
+// generation only the cells it changed, as told by the game, or if full every cell, as before.
+func benchmarkRepaint(b *testing.B, full bool) {
+	testScene(b, 320, 480)
+	u := newUniverse(100*cellSize, 100*cellSize)
+	if u.cols != 100 || u.rows != 100 {
+		b.Fatalf("universe of %dx%d cells, want 100x100", u.cols, u.rows)
+	}
+	l := life.New(u.cols, u.rows)
+	u.adopt(l)
+	seed := func() {
+		l.SeedWith(cfg.density, life.Random, 1)
+		u.paint()
+	}
+	seed()
+	if full {
+		l.Listener = nil
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		// The soup is seeded again before it settles, so that it stays typical.
+		if l.Generation() == 200 {
+			b.StopTimer()
+			seed()
+			b.StartTimer()
+		}
+		l.Step()
+		if full {
+			u.paint()
+		}
+	}
+}
+
+func BenchmarkRepaintFull(b *testing.B) { benchmarkRepaint(b, true) }
+func BenchmarkRepaintDiff(b *testing.B) { benchmarkRepaint(b, false) }
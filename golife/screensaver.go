@@ -0,0 +1,48 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"math/rand"
+
+	"golang.org/x/mobile/sprite/clock"
+)
+
+// The screensaver keeps the game going as an ambient display, see cfg.screensaver: once the game
+// stagnates, rather than pausing, it runs on for cfg.screensaverDelay seconds under the banner
+// telling the stagnation, then the soup of the next seed replaces it. The games mutating do not
+// stagnate, see life.Mutation, so they run on as they are.
+
+// reseedAt is the tick of the clock the screensaver reseeds the game at, 0 if none is due.
+var reseedAt clock.Time
+
+// scheduleReseed tells the screensaver the game stagnated at t.
+func scheduleReseed(t clock.Time) {
+	reseedAt = t + clock.Time(cfg.screensaverDelay*60)
+}
+
+// screensave reseeds u once due, unless edited or replaced since it stagnated. It is called by
+// the arranger at t while the game runs.
+func (u *universe) screensave(t clock.Time) {
+	if reseedAt == 0 || t < reseedAt {
+		return
+	}
+	reseedAt = 0
+	// A game edited or replaced since is told again once it stagnates.
+	if !u.history.told {
+		return
+	}
+	s := nextSoupSeed()
+	log.Printf("screensaver: seed %d", s)
+	u.reseed(s)
+}
+
+// nextSoupSeed returns the seed after the one of the random universe, so that consecutive soups of
+// the screensaver differ, or a random seed if the universe is not random.
+func nextSoupSeed() int64 {
+	if soupSeed == 0 {
+		return rand.Int63n(maxSoupSeed) + 1
+	}
+	return soupSeed%maxSoupSeed + 1
+}
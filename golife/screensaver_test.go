@@ -0,0 +1,60 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/sprite/clock"
+)
+
+// runFor renders the scene every 10 ticks from t for the given ticks, stepping the game as the
+// clock goes, and returns the tick reached.
+func runFor(t, ticks clock.Time) clock.Time {
+	for end := t + ticks; t < end; t += 10 {
+		eng.Render(scene, t)
+	}
+	return t
+}
+
+func TestScreensaver(t *testing.T) {
+	gestureScene(t)
+	defer func() { reseedAt, lastArrange = 0, -1 }()
+	cfg.screensaver, cfg.screensaverDelay = true, 2
+	lastArrange = -1
+
+	// A game died out runs on under the banner, and is reseeded once the delay is over.
+	univ.place(&life.Pattern{W: 1, H: 1})
+	now := runFor(0, 30)
+	if paused || views.notice == nil || reseedAt == 0 || soupSeed != 0 {
+		t.Fatalf("game died out: paused %v, banner %v, reseed at %d", paused, views.notice != nil, reseedAt)
+	}
+	now = runFor(now, 2*60)
+	if paused || soupSeed == 0 || univ.life.Population() == 0 || reseedAt != 0 {
+		t.Fatalf("game reseeded with seed %d, %d cells, paused %v", soupSeed, univ.life.Population(), paused)
+	}
+
+	// The stagnation is forgotten once the game is edited.
+	univ.history.told = true
+	scheduleReseed(now)
+	univ.edited()
+	s := soupSeed
+	runFor(now, 3*60)
+	if soupSeed != s {
+		t.Errorf("edited game reseeded")
+	}
+}
+
+func TestNextSoupSeed(t *testing.T) {
+	for _, test := range []struct{ seed, want int64 }{{1, 2}, {41, 42}, {maxSoupSeed, 1}} {
+		soupSeed = test.seed
+		if s := nextSoupSeed(); s != test.want {
+			t.Errorf("seed %d followed by %d, want %d", test.seed, s, test.want)
+		}
+	}
+	soupSeed = 0
+	if s := nextSoupSeed(); s < 1 || s > maxSoupSeed {
+		t.Errorf("seed %d after a universe not random", s)
+	}
+}
@@ -0,0 +1,267 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"log"
+	"strconv"
+
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// Soups in the gallery: the best ones by the criterion of cfg.searchBy, followed by the ones of
+// the highest peak population and the ones whose cells most escaped the field, galleryBest,
+// galleryPeaks and galleryEscapes of each unless there are fewer. The other slots go to the next
+// best.
+const (
+	gallerySize    = 6
+	galleryBest    = 3
+	galleryPeaks   = 2
+	galleryEscapes = 1
+)
+
+// galleryDigits is the number of digits of the lifespans shown by the gallery.
+const galleryDigits = 5
+
+// A soupSearch plays every random universe, numbered by their seed, for a number of generations
+// in the background, without showing them, to find the interesting ones. The random universes are
+// drawn as by reset for the universe when the search started, so that they can be replayed.
+type soupSearch struct {
+	stop chan struct{}
+	// done receives the outcomes of the soups played once the search is over or stopped.
+	done chan []life.Soup
+}
+
+// A soupParams holds what the soups of a search are drawn and played with.
+type soupParams struct {
+	cols, rows  int
+	density     float64
+	mode        life.SeedMode
+	rule        life.Rule
+	edges       life.EdgeMode
+	colors      int
+	generations int
+}
+
+// search is the search in progress, nil if none.
+var search *soupSearch
+
+// startSearch starts searching the soups of the universe, on every processor.
+func startSearch() {
+	p := soupParams{
+		cols:        univ.cols,
+		rows:        univ.rows,
+		density:     cfg.density,
+		mode:        seedMode,
+		rule:        univ.life.Rule,
+		edges:       univ.life.Edges,
+		colors:      univ.life.Colors,
+		generations: cfg.searchGenerations,
+	}
+	s := &soupSearch{stop: make(chan struct{}), done: make(chan []life.Soup, 1)}
+	ls := &life.SoupSearch{
+		Field:       p.field,
+		Density:     p.density,
+		Mode:        p.mode,
+		Generations: p.generations,
+		Stop:        s.stop,
+	}
+	go func() { s.done <- ls.Run(1, maxSoupSeed) }()
+	search = s
+	log.Printf("soup search: %d soups of %d generations, by %s", maxSoupSeed, p.generations, cfg.searchBy)
+	buttonBar.Refresh()
+}
+
+// stopSearch stops the search, whose outcomes so far then show in the gallery.
+func stopSearch() {
+	if search != nil && search.stop != nil {
+		close(search.stop)
+		search.stop = nil
+	}
+}
+
+// updateSearch opens the gallery once the search is over. It is called every frame.
+func updateSearch() {
+	if search == nil {
+		return
+	}
+	select {
+	case found := <-search.done:
+		search = nil
+		buttonBar.Refresh()
+		log.Printf("soup search: played %d soups", len(found))
+		// The gallery does not open over another overlay.
+		covered := views.panel != nil || views.menu != nil || views.shared != nil || views.gallery != nil
+		if g := bestSoups(found, life.SoupOrders[cfg.searchBy]); len(g) > 0 && !covered {
+			openGallery(g)
+		}
+	default:
+	}
+}
+
+// field returns a field of dead cells for the soups of p.
+func (p soupParams) field() *life.Life {
+	l := life.NewSparse(p.cols, p.rows)
+	l.Rule, l.Edges, l.Colors = p.rule, p.edges, p.colors
+	return l
+}
+
+// soup returns the random universe of the given seed.
+func (p soupParams) soup(seed int64) *life.Life {
+	l := p.field()
+	l.SeedWith(p.density, p.mode, seed)
+	return l
+}
+
+// bestSoups returns the soups of found to show in the gallery, ranked by less, see gallerySize.
+func bestSoups(found []life.Soup, less func(a, b life.Soup) bool) []life.Soup {
+	var best []life.Soup
+	taken := make(map[int64]bool)
+	// pick appends the first n soups of found by the order of by, skipping the ones taken and those
+	// no better than a soup of zeros.
+	pick := func(n int, by func(a, b life.Soup) bool) {
+		s := append([]life.Soup(nil), found...)
+		life.SortSoups(s, by)
+		for _, o := range s {
+			if n == 0 || len(best) == gallerySize {
+				return
+			}
+			if !taken[o.Seed] && by(o, life.Soup{}) {
+				best, taken[o.Seed] = append(best, o), true
+				n--
+			}
+		}
+	}
+	pick(galleryBest, less)
+	pick(galleryPeaks, life.SoupOrders["peak"])
+	pick(galleryEscapes, life.SoupOrders["escaped"])
+	pick(gallerySize, less)
+	return best
+}
+
+// A soupGallery offers the soups found by a search, each shown as drawn with its lifespan below, to
+// replay them. As the stamp menu, it is drawn over the scene from a scene graph of its own and,
+// while open, receives every touch.
+type soupGallery struct {
+	root     *sprite.Node
+	bar      *ui.Bar // The buttons are named by the index of their soup.
+	tex      sprite.Texture
+	rect     geom.Rectangle // Uses absolute location.
+	soups    []life.Soup
+	frames   []sprite.SubTex
+	counters []*counter
+}
+
+// openGallery opens the gallery of soups, centered on the screen.
+func openGallery(soups []life.Soup) {
+	img := soupPreviews(soups)
+	tex, err := eng.LoadTexture(img)
+	if err != nil {
+		log.Printf("soup gallery: %v", err)
+		return
+	}
+	g := &soupGallery{root: &sprite.Node{}, tex: tex, soups: soups}
+	for k := range soups {
+		r := image.Rect(k*stampPreviewSize, 0, (k+1)*stampPreviewSize, stampPreviewSize)
+		g.frames = append(g.frames, sprite.SubTex{tex, r})
+	}
+	eng.Register(g.root)
+	eng.SetTransform(g.root, f32.Affine{{1, 0, 0}, {0, 1, 0}})
+
+	n := len(soups)
+	dh := geom.Pt(hudDigitHeight)
+	side := slotFit(n, geom.Width)
+	w := geom.Pt(n)*side + geom.Pt(n+1)*buttonSep
+	h := side + dh + 3*buttonSep
+	x0, y0 := (geom.Width-w)/2, (geom.Height-h)/2
+	g.rect = geom.Rectangle{Min: geom.Point{X: x0, Y: y0}, Max: geom.Point{X: x0 + w, Y: y0 + h}}
+	back := &sprite.Node{}
+	eng.Register(back)
+	g.root.AppendChild(back)
+	eng.SetSubTex(back, *textures[outOfBoundsImage])
+	eng.SetTransform(back, f32.Affine{{float32(w), 0, float32(x0)}, {0, float32(h), float32(y0)}})
+
+	g.bar = ui.NewBar(eng, g.root, geom.Point{}, func(b *ui.Button) sprite.SubTex {
+		k, _ := strconv.Atoi(b.Name)
+		return g.frames[k]
+	})
+	corner := geom.Point{X: x0 + buttonSep, Y: y0 + buttonSep}
+	for k, r := range mirrorAll(ui.Grid(n, n, side, buttonSep, corner), g.rect) {
+		g.bar.Add(strconv.Itoa(k), r)
+		// The lifespan, right aligned under the soup.
+		dx := r.Max.X - geom.Pt(galleryDigits)*dh*hudDigitWidth/hudDigitHeight
+		c := newCounter(g.root, dx, r.Max.Y+buttonSep, dh, galleryDigits)
+		c.set(soups[k].Lifespan)
+		g.counters = append(g.counters, c)
+	}
+	views.gallery = g
+	holdPanel(galleryName)
+}
+
+// soupPreviews returns a strip of the previews of the soups drawn from their seeds, each the field
+// scaled to fit a square of stampPreviewSize px, centered.
+func soupPreviews(soups []life.Soup) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, len(soups)*stampPreviewSize, stampPreviewSize))
+	cols, rows := univ.cols, univ.rows
+	scale := float64(stampPreviewSize) / float64(cols)
+	if s := float64(stampPreviewSize) / float64(rows); s < scale {
+		scale = s
+	}
+	w, h := int(float64(cols)*scale), int(float64(rows)*scale)
+	p := soupParams{cols: cols, rows: rows, density: cfg.density, mode: seedMode, colors: univ.life.Colors}
+	for k, o := range soups {
+		l := p.soup(o.Seed)
+		x0, y0 := k*stampPreviewSize+(stampPreviewSize-w)/2, (stampPreviewSize-h)/2
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				c := snapshotDeadColor
+				if l.Alive(int(float64(x)/scale), int(float64(y)/scale)) {
+					c = snapshotAliveColor
+				}
+				img.Set(x0+x, y0+y, c)
+			}
+		}
+	}
+	return img
+}
+
+// touch handles t while g is open. A touch on a soup replays it and closes g, as does a touch out
+// of g.
+func (g *soupGallery) touch(t event.Touch) {
+	if t.Type != event.TouchStart {
+		return
+	}
+	if b := g.bar.Find(t.Loc); b != nil {
+		k, _ := strconv.Atoi(b.Name)
+		o := g.soups[k]
+		log.Printf("soup %d: lifespan %d, period %d, peak population %d, %d cells escaped", o.Seed, o.Lifespan, o.Period,
+			o.Peak, o.Escaped)
+		univ.reseed(o.Seed)
+		sounds.click()
+		g.close()
+	} else if !ui.Contains(g.rect, t.Loc) {
+		g.close()
+	}
+}
+
+// close closes g, unregisters its nodes and frees its texture.
+func (g *soupGallery) close() {
+	g.bar.Release()
+	for _, c := range g.counters {
+		c.release()
+	}
+	for n := g.root.FirstChild; n != nil; n = g.root.FirstChild {
+		g.root.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	eng.Unregister(g.root)
+	g.tex.Unload()
+	views.gallery = nil
+	releasePanel(galleryName)
+}
@@ -0,0 +1,27 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+func TestBestSoups(t *testing.T) {
+	var found []life.Soup
+	for seed := int64(1); seed <= 10; seed++ {
+		found = append(found, life.Soup{Seed: seed, Lifespan: int(seed), Initial: 1, Peak: 20 - int(seed)})
+	}
+	found[0].Lifespan, found[3].Escaped = 50, 4
+	best := bestSoups(found, life.SoupOrders["methuselah"])
+	var seeds []int64
+	for _, o := range best {
+		seeds = append(seeds, o.Seed)
+	}
+	// The best 3 by the criterion, then the 2 highest peaks of the others and the most escaped.
+	if got, want := fmt.Sprint(seeds), "[1 10 9 2 3 4]"; got != want {
+		t.Errorf("best soups %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,240 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"log"
+
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// toolImages are the images of the buttons of the tool bar acting on the selection, from left to
+// right, and editImages the ones undoing and redoing edits. See also nudgeImages.
+var (
+	toolImages = []string{copyImage, cutImage, pasteImage, rotateImage, flipImage, shareImage}
+	editImages = []string{undoImage, redoImage}
+)
+
+var (
+	// selecting is set by the select button. Strokes on the grid then select a rectangle of cells
+	// instead of painting, and the tool bar acts on it.
+	selecting bool
+	// selection holds the selected cells, empty if none.
+	selection image.Rectangle
+	// clipboard holds the cells last copied or cut, nil if none.
+	clipboard *life.Pattern
+	// toolBar is the bar at the bottom of the screen of the buttons undoing and redoing edits and
+	// picking the brush or the walls, while paused, and acting on the selection, while selecting. It is nil while there are none.
+	toolBar *ui.Bar
+	// selectionFrame holds the lines framing the selection, nil until something is selected.
+	selectionFrame []*sprite.Node
+)
+
+// setSelecting starts or stops selecting. The game is paused while selecting, and the selection is
+// lost once done.
+func setSelecting(on bool) {
+	if on == selecting {
+		return
+	}
+	selecting = on
+	if !on {
+		selectCells(image.Rectangle{})
+		selectionFrame = releaseFrame(selectionFrame)
+		return
+	}
+	setPaused(true)
+}
+
+// updateToolBar rebuilds the tool bar whenever the buttons it should show change: undo and redo
+// while paused out of a match, followed by the brush, the wall and the nudge arrows unless
+// selecting, or the tools while selecting.
+func updateToolBar() {
+	var imgs []string
+	if paused && versus == nil {
+		imgs = append(imgs, editImages...)
+		if !selecting {
+			imgs = append(imgs, brushImage, wallImage)
+			imgs = append(imgs, nudgeImages...)
+		}
+	}
+	if selecting {
+		imgs = append(imgs, toolImages...)
+	}
+	if toolBarShows(imgs) {
+		return
+	}
+	toolBar.Release()
+	toolBar = nil
+	if len(imgs) == 0 {
+		return
+	}
+	// The scene is below the system bar.
+	toolBar = ui.NewBar(eng, scene, geom.Point{Y: systemBarHeight}, buttonFace)
+	for k, r := range mirrorAll(ui.BottomBar(len(imgs), buttonSize, buttonSep, buttonSep), screen()) {
+		toolBar.Add(imgs[k], r)
+	}
+	accessibleBar(toolBar)
+	refreshTools()
+}
+
+// toolBarShows reports whether the tool bar shows the buttons of imgs, from left to right.
+func toolBarShows(imgs []string) bool {
+	if toolBar == nil {
+		return len(imgs) == 0
+	}
+	if len(toolBar.Buttons) != len(imgs) {
+		return false
+	}
+	for k, b := range toolBar.Buttons {
+		if b.Name != imgs[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// forgetSelection stops selecting without releasing the nodes of the selection, for instance once
+// the engine showing them is gone.
+func forgetSelection() {
+	selecting, selection, toolBar, selectionFrame = false, image.Rectangle{}, nil, nil
+}
+
+// selectCells selects the cells of r clipped to the field, and frames them.
+func selectCells(r image.Rectangle) {
+	selection = r.Intersect(image.Rect(0, 0, univ.cols, univ.rows))
+	refreshTools()
+	selectionFrame = frameCells(selectionFrame, selection)
+}
+
+// frameCells frames the cells of r with the lines of frame, and returns them. The lines are created
+// on first use, which an empty r defers; they are hidden while r is empty.
+func frameCells(frame []*sprite.Node, r image.Rectangle) []*sprite.Node {
+	if frame == nil {
+		if r.Empty() {
+			return nil
+		}
+		for k := 0; k < 4; k++ {
+			n := &sprite.Node{}
+			eng.Register(n)
+			grid.AppendChild(n)
+			eng.SetSubTex(n, *textures[wrapBorderImage])
+			frame = append(frame, n)
+		}
+	}
+	var (
+		t  = float32(2 / geom.PixelsPerPt) // Thickness of the lines.
+		m  = float32(univ.margin)
+		s  = float32(cellSize)
+		x0 = m + float32(r.Min.X)*s
+		y0 = m + float32(r.Min.Y)*s
+		w  = float32(r.Dx()) * s
+		h  = float32(r.Dy()) * s
+	)
+	if r.Empty() {
+		t, w, h = 0, 0, 0
+	}
+	// Top, bottom, left and right, outside the cells.
+	for k, a := range []f32.Affine{
+		{{w + 2*t, 0, x0 - t}, {0, t, y0 - t}},
+		{{w + 2*t, 0, x0 - t}, {0, t, y0 + h}},
+		{{t, 0, x0 - t}, {0, h, y0}},
+		{{t, 0, x0 + w}, {0, h, y0}},
+	} {
+		eng.SetTransform(frame[k], a)
+	}
+	return frame
+}
+
+// releaseFrame removes the lines of frame from the scene, unregisters them and returns nil.
+func releaseFrame(frame []*sprite.Node) []*sprite.Node {
+	for _, n := range frame {
+		grid.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	return nil
+}
+
+// refreshTools disables the tools that have nothing to act on.
+func refreshTools() {
+	if toolBar == nil {
+		return
+	}
+	for _, b := range toolBar.Buttons {
+		switch b.Name {
+		case undoImage:
+			b.SetDisabled(len(univ.edits.undo) == 0)
+		case redoImage:
+			b.SetDisabled(len(univ.edits.redo) == 0)
+		case pasteImage:
+			b.SetDisabled(clipboard == nil)
+		case shareImage:
+			// Shares the whole field without a selection.
+			b.SetDisabled(false)
+		case brushImage, wallImage, nudgeLeftImage, nudgeRightImage, nudgeUpImage, nudgeDownImage:
+			b.SetDisabled(false)
+		default:
+			b.SetDisabled(selection.Empty())
+		}
+	}
+}
+
+// copySelection copies the selected cells to the clipboard.
+func copySelection() {
+	r := selection
+	clipboard = univ.life.Region(r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	log.Printf("copied %dx%d cells", r.Dx(), r.Dy())
+	refreshTools()
+}
+
+// cutSelection copies the selected cells to the clipboard and kills them, but the pinned ones.
+func cutSelection() {
+	copySelection()
+	r := selection
+	univ.transact(text("edit.cut"), func() { univ.life.Clear(r.Min.X, r.Min.Y, r.Dx(), r.Dy()) })
+	univ.edited()
+	univ.paint()
+	reportSkipped(univ.skippedPins(r, nil, 0, 0))
+}
+
+// pasteClipboard replaces the cells under the clipboard, its top-left corner at the one of the
+// selection or else centered on the field, by the ones of the clipboard, which are then selected.
+// Pinned cells are left as they are.
+func pasteClipboard() {
+	p := clipboard
+	at := selection.Min
+	if selection.Empty() {
+		at = image.Pt((univ.cols-p.W)/2, (univ.rows-p.H)/2)
+	}
+	r := image.Rect(at.X, at.Y, at.X+p.W, at.Y+p.H)
+	univ.transact(text("edit.paste"), func() { replace(r, p) })
+	reportSkipped(univ.skippedPins(r, p, at.X, at.Y))
+}
+
+// transformSelection replaces the selected cells by them transformed by f, centered on the
+// selection, and selects them, as an edit named label. Pinned cells are left as they are.
+func transformSelection(label string, f func(p *life.Pattern) *life.Pattern) {
+	r := selection
+	p := f(univ.life.Region(r.Min.X, r.Min.Y, r.Dx(), r.Dy()))
+	x, y := r.Min.X+(r.Dx()-p.W)/2, r.Min.Y+(r.Dy()-p.H)/2
+	to := image.Rect(x, y, x+p.W, y+p.H)
+	univ.transact(label, func() {
+		univ.life.Clear(r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+		replace(to, p)
+	})
+	reportSkipped(univ.skippedPins(r.Union(to), p, x, y))
+}
+
+// replace replaces the cells of r by the ones of p, the same size, clipped to the field, and
+// selects them.
+func replace(r image.Rectangle, p *life.Pattern) {
+	univ.life.Clear(r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	univ.life.Stamp(p, r.Min.X, r.Min.Y)
+	univ.edited()
+	univ.paint()
+	selectCells(r)
+}
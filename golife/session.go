@@ -0,0 +1,47 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"log"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// maxSessionChanges bounds the changes journaled by a session, past which it starts over from the
+// current game, so that long sessions do not exhaust memory.
+const maxSessionChanges = 10000
+
+// session journals the game since it was last replaced as a whole, so that it can be exported and
+// replayed, see life.Journal. It starts with the first step, nil until then.
+var session *life.Journal
+
+// stepSession steps the game of u, journaling the step and the changes made since the previous one.
+func stepSession(u *universe) {
+	if session == nil || session.Changes() > maxSessionChanges {
+		if session != nil {
+			log.Printf("session of %d steps journaled, starting over", session.Steps())
+		}
+		session = life.NewJournal(u.life, speed)
+	}
+	session.SetSpeed(speed)
+	session.Step(u.life)
+}
+
+// exportSession writes the session journal, including the changes made to the game of u since the
+// last step, to the file name. Without a session, it journals one of no step from the game of u.
+func exportSession(u *universe, name string) error {
+	if session == nil {
+		session = life.NewJournal(u.life, speed)
+	}
+	session.Note(u.life)
+	b, err := session.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return writeFile(name, func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	})
+}
@@ -0,0 +1,504 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// settingsSlot is the side of the images of the settings panel, in Pt, see scaleControls.
+var settingsSlot = 2 * buttonSize
+
+// slotFit returns the side of n slots in a row of the given length, buttonSep apart and from its
+// ends: settingsSlot, or less if they would not fit, as with the large controls.
+func slotFit(n int, length geom.Pt) geom.Pt {
+	if fit := (length - geom.Pt(n+1)*buttonSep) / geom.Pt(n); fit < settingsSlot {
+		return fit
+	}
+	return settingsSlot
+}
+
+// A setting is a value shown by the settings panel, changed by steps with its minus and plus
+// images.
+type setting struct {
+	icon   func() string // Image telling the setting.
+	digits int           // Number of digits of its value.
+	value  func() int
+	change func(d int) // Changes the value by d steps.
+}
+
+// settings are the rows of the settings panel, from top to bottom.
+var settings = []setting{
+	{
+		icon:   func() string { return ruleImage },
+		digits: 1,
+		value:  func() int { return ruleIndex(univ.life.Rule) + 1 },
+		change: func(d int) {
+			r, err := life.ParseRule(rules[(ruleIndex(univ.life.Rule)+d+len(rules))%len(rules)])
+			if err != nil {
+				log.Fatal(err)
+			}
+			univ.life.Rule, cfg.rule = r, r
+			univ.edited()
+			log.Printf("rule %v", r)
+		},
+	},
+	{
+		icon:   func() string { return face(edgesImage) },
+		digits: 1,
+		value:  func() int { return int(univ.life.Edges) + 1 },
+		change: func(d int) {
+			n := len(edgeModeNames)
+			cfg.edges = (univ.life.Edges + life.EdgeMode(d+n)) % life.EdgeMode(n)
+			univ.setEdges(cfg.edges)
+			buttonBar.Refresh()
+		},
+	},
+	{
+		// The size of the cells, as a cell, one of cellSizes. The game is cropped or padded around
+		// the center of the grid, or resampled to the new grid if cfg.resample.
+		icon:   func() string { return androidImage },
+		digits: 2,
+		value:  func() int { return int(cellSize) },
+		change: func(d int) {
+			siz := nextCellSize(cellSize, d)
+			if siz == cellSize {
+				return
+			}
+			if cfg.resample {
+				univ = univ.resample(siz)
+			} else {
+				focus := geom.Point{X: univ.w / 2, Y: systemBarHeight + buttonBarHeight + univ.h/2}
+				univ = univ.regrid(siz, focus)
+			}
+			cfg.cellSize = siz
+		},
+	},
+	{
+		// The sprite of the alive cells, as a cell showing it, one of cellSpriteNames.
+		icon: func() string {
+			if cfg.cellSprite == pulseSprite {
+				return pulseFrameImage(androidImage, pulseFrame)
+			}
+			return androidImage
+		},
+		digits: 1,
+		value:  func() int { return cfg.cellSprite + 1 },
+		change: func(d int) {
+			n := len(cellSpriteNames)
+			cfg.cellSprite = (cfg.cellSprite + d%n + n) % n
+			log.Printf("cell sprite %s", cellSpriteNames[cfg.cellSprite])
+			univ.paint()
+		},
+	},
+	{
+		// The density of the random universes in percent, used by the next replay.
+		icon:   func() string { return replayImage },
+		digits: 3,
+		value:  func() int { return int(math.Floor(cfg.density*100 + 0.5)) },
+		change: func(d int) {
+			// Multiples of 5%.
+			v := math.Floor(cfg.density*20+0.5) + float64(d)
+			cfg.density = math.Max(0, math.Min(20, v)) / 20
+		},
+	},
+	{
+		// The seed of the random universe, which changing replaces.
+		icon:   func() string { return seedImage },
+		digits: 3,
+		value:  func() int { return int(soupSeed) },
+		change: func(d int) {
+			s := soupSeed + int64(d)
+			if s < 1 {
+				s = maxSoupSeed
+			} else if s > maxSoupSeed {
+				s = 1
+			}
+			univ.reseed(s)
+		},
+	},
+	{
+		// How the random universes lay out their cells, used by the next replay.
+		icon:   func() string { return symmetryImage },
+		digits: 1,
+		value:  func() int { return int(seedMode) + 1 },
+		change: func(d int) {
+			n := life.Rotational + 1
+			seedMode = (seedMode + life.SeedMode(d) + n) % n
+			log.Printf("seed mode %v", seedMode)
+		},
+	},
+	{
+		// The length of the trails of the cells that just died, 0 for none.
+		icon:   func() string { return trailImage },
+		digits: 1,
+		value:  func() int { return cfg.trail },
+		change: func(d int) {
+			cfg.trail = int(math.Max(0, math.Min(maxTrail, float64(cfg.trail+d))))
+			// The trails so far are dropped.
+			univ.paint()
+		},
+	},
+	{
+		// Whether button taps and stamps vibrate the device, 1 if so.
+		icon:   func() string { return hapticsImage },
+		digits: 1,
+		value: func() int {
+			if cfg.haptics {
+				return 1
+			}
+			return 0
+		},
+		change: func(d int) {
+			cfg.haptics = !cfg.haptics
+		},
+	},
+	{
+		// Whether the panels pause the simulation while open, 1 if so.
+		icon:   func() string { return pauseImage },
+		digits: 1,
+		value: func() int {
+			if cfg.pauseMenus {
+				return 1
+			}
+			return 0
+		},
+		change: func(d int) {
+			cfg.pauseMenus = !cfg.pauseMenus
+		},
+	},
+	{
+		// Whether the camera follows the action, 1 if so. Turned on, it engages again.
+		icon:   func() string { return followImage },
+		digits: 1,
+		value: func() int {
+			if cfg.follow {
+				return 1
+			}
+			return 0
+		},
+		change: func(d int) {
+			cfg.follow = !cfg.follow
+			if cfg.follow {
+				follow.start()
+			} else {
+				follow.on = false
+			}
+		},
+	},
+	{
+		// Whether clearing the field unpins its cells too, 1 if so.
+		icon:   func() string { return wallImage },
+		digits: 1,
+		value: func() int {
+			if cfg.clearPins {
+				return 1
+			}
+			return 0
+		},
+		change: func(d int) {
+			cfg.clearPins = !cfg.clearPins
+		},
+	},
+	{
+		// Whether the game mutates, 1 if so.
+		icon:   func() string { return mutationImage },
+		digits: 1,
+		value: func() int {
+			if cfg.mutation {
+				return 1
+			}
+			return 0
+		},
+		change: func(d int) {
+			setMutation(!cfg.mutation)
+		},
+	},
+	{
+		// Whether accessibility mode is on, 1 if so.
+		icon:   func() string { return accessImage },
+		digits: 1,
+		value: func() int {
+			if cfg.accessible {
+				return 1
+			}
+			return 0
+		},
+		change: func(d int) {
+			setAccessible(!cfg.accessible)
+		},
+	},
+	{
+		// Whether the large controls are on, 1 if so.
+		icon:   func() string { return largeImage },
+		digits: 1,
+		value: func() int {
+			if cfg.large {
+				return 1
+			}
+			return 0
+		},
+		change: func(d int) {
+			setLarge(!cfg.large)
+		},
+	},
+	{
+		// Whether the compact controls are on, 1 if so.
+		icon:   func() string { return compactImage },
+		digits: 1,
+		value: func() int {
+			if cfg.compact {
+				return 1
+			}
+			return 0
+		},
+		change: func(d int) {
+			setCompact(!cfg.compact)
+		},
+	},
+	{
+		icon:   func() string { return themeImage },
+		digits: 1,
+		value:  func() int { return currentTheme + 1 },
+		change: func(d int) {
+			cfg.theme = (currentTheme + d + len(themes)) % len(themes)
+			setTheme(cfg.theme)
+		},
+	},
+	{
+		// The language of the strings told, 0 for the one of the system, else 1 + its index in
+		// languages.
+		icon:   func() string { return localeImage },
+		digits: 1,
+		value:  func() int { return localeIndex() },
+		change: func(d int) {
+			n := len(languages) + 1
+			if k := (localeIndex() + d + n) % n; k == 0 {
+				cfg.locale = ""
+			} else {
+				cfg.locale = languages[k-1]
+			}
+			rtl := rightToLeft
+			loadStrings()
+			if rightToLeft != rtl {
+				// Laid out again, mirrored, by the next frame.
+				laidOut = geom.Point{}
+			}
+		},
+	},
+}
+
+// ruleIndex returns the index of r in rules, 0 if it is not listed.
+func ruleIndex(r life.Rule) int {
+	for k, name := range rules {
+		if name == r.String() {
+			return k
+		}
+	}
+	return 0
+}
+
+// localeIndex returns the value of the language setting: 0 if cfg.locale is the one of the system,
+// else 1 + the index in languages of its language.
+func localeIndex() int {
+	lang := strings.SplitN(cfg.locale, "_", 2)[0]
+	for k, l := range languages {
+		if lang == l {
+			return k + 1
+		}
+	}
+	return 0
+}
+
+// A settingsPanel is an overlay changing the settings. It is drawn over the scene from a scene
+// graph of its own and, while open, receives every touch.
+type settingsPanel struct {
+	root *sprite.Node
+	rect geom.Rectangle // Uses absolute location.
+	rows []settingsRow
+}
+
+// A settingsRow shows a setting.
+type settingsRow struct {
+	icon     *sprite.Node
+	value    *counter
+	dec, inc geom.Rectangle // The minus and plus images, using absolute location.
+}
+
+// openSettings opens the settings panel, centered on the screen. The fingers touching the scene
+// are forgotten, as the panel takes over the touches.
+func openSettings() {
+	forgetPointers()
+	// The cell size may change, which the selection would not follow.
+	setSelecting(false)
+	p := &settingsPanel{root: &sprite.Node{}}
+	eng.Register(p.root)
+	eng.SetTransform(p.root, f32.Affine{{1, 0, 0}, {0, 1, 0}})
+
+	// Each row holds the icon, the value two slots wide, and the minus and plus images, the rows
+	// fitting the screen.
+	side := slotFit(len(settings), geom.Height)
+	w := 5*side + 6*buttonSep
+	h := geom.Pt(len(settings))*(side+buttonSep) + buttonSep
+	x0, y0 := (geom.Width-w)/2, (geom.Height-h)/2
+	p.rect = geom.Rectangle{Min: geom.Point{X: x0, Y: y0}, Max: geom.Point{X: x0 + w, Y: y0 + h}}
+	p.add(outOfBoundsImage, p.rect)
+	for k := range settings {
+		s := &settings[k]
+		y := y0 + buttonSep + geom.Pt(k)*(side+buttonSep)
+		slot := func(i, n geom.Pt) geom.Rectangle {
+			x := x0 + buttonSep + i*(side+buttonSep)
+			return mirror(geom.Rectangle{
+				Min: geom.Point{X: x, Y: y},
+				Max: geom.Point{X: x + n*side + (n-1)*buttonSep, Y: y + side},
+			}, p.rect)
+		}
+		row := settingsRow{icon: p.add(s.icon(), slot(0, 1)), dec: slot(3, 1), inc: slot(4, 1)}
+		// Digits three fifths of a slot high, right aligned in theirs.
+		dh := side * 3 / 5
+		v := slot(1, 2)
+		dx := v.Max.X - geom.Pt(s.digits)*dh*hudDigitWidth/hudDigitHeight
+		row.value = newCounter(p.root, dx, v.Min.Y+(side-dh)/2, dh, s.digits)
+		p.add(decSpeedImage, row.dec)
+		p.add(incSpeedImage, row.inc)
+		p.rows = append(p.rows, row)
+	}
+	views.panel = p
+	holdPanel(settingsPanelName)
+	p.refresh()
+}
+
+// add adds to p a node showing the image img over r, which uses absolute location, and returns it.
+func (p *settingsPanel) add(img string, r geom.Rectangle) *sprite.Node {
+	n := &sprite.Node{}
+	eng.Register(n)
+	p.root.AppendChild(n)
+	eng.SetSubTex(n, *textures[img])
+	eng.SetTransform(n, f32.Affine{
+		{float32(r.Max.X - r.Min.X), 0, float32(r.Min.X)},
+		{0, float32(r.Max.Y - r.Min.Y), float32(r.Min.Y)},
+	})
+	return n
+}
+
+// refresh shows the current settings.
+func (p *settingsPanel) refresh() {
+	for k, row := range p.rows {
+		eng.SetSubTex(row.icon, *textures[settings[k].icon()])
+		row.value.set(settings[k].value())
+	}
+}
+
+// touch handles t while p is open. A touch on a minus or plus image changes its setting, which is
+// saved, and one out of the panel closes it.
+func (p *settingsPanel) touch(t event.Touch) {
+	if t.Type != event.TouchStart {
+		return
+	}
+	if !ui.Contains(p.rect, t.Loc) {
+		p.close()
+		return
+	}
+	for k, row := range p.rows {
+		d := 0
+		switch {
+		case ui.Contains(row.dec, t.Loc):
+			d = -1
+		case ui.Contains(row.inc, t.Loc):
+			d = +1
+		default:
+			continue
+		}
+		settings[k].change(d)
+		sounds.click()
+		p.refresh()
+		if err := saveSettings(); err != nil {
+			log.Printf("saving the settings: %v", err)
+		}
+	}
+}
+
+// close closes p and unregisters its nodes.
+func (p *settingsPanel) close() {
+	var release func(n *sprite.Node)
+	release = func(n *sprite.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			release(c)
+		}
+		eng.Unregister(n)
+	}
+	release(p.root)
+	views.panel = nil
+	releasePanel(settingsPanelName)
+}
+
+// settingsPath returns the file the settings chosen in the settings panel are saved to, see
+// savePath. It holds a JSON object with keys of the config manifest, which it overrides.
+func settingsPath() string {
+	return filepath.Join(appDir(), "golife.settings.json")
+}
+
+// settingsValues returns the settings of the settings panel, by key of the config manifest.
+func settingsValues() map[string]interface{} {
+	return map[string]interface{}{
+		"rule":         cfg.rule.String(),
+		"edges":        edgeModeNames[cfg.edges],
+		"cellSize":     cfg.cellSize,
+		"density":      cfg.density,
+		"seedMode":     seedMode.String(),
+		"theme":        themes[cfg.theme].name,
+		"trail":        cfg.trail,
+		"haptics":      cfg.haptics,
+		"pauseMenus":   cfg.pauseMenus,
+		"follow":       cfg.follow,
+		"clearPins":    cfg.clearPins,
+		"cellSprite":   cellSpriteNames[cfg.cellSprite],
+		"mutation":     cfg.mutation,
+		"accessible":   cfg.accessible,
+		"large":        cfg.large,
+		"compact":      cfg.compact,
+		"locale":       cfg.locale,
+		"sound":        !muted,
+		"tutorialDone": cfg.tutorialDone,
+	}
+}
+
+// settingsJSON returns the current settings, encoded by their keys in the config manifest.
+func settingsJSON() (map[string]json.RawMessage, error) {
+	m := make(map[string]json.RawMessage)
+	for k, v := range settingsValues() {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = b
+	}
+	return m, nil
+}
+
+// saveSettings writes the settings of the settings panel to the settings file.
+func saveSettings() error {
+	b, err := json.Marshal(settingsValues())
+	if err != nil {
+		return err
+	}
+	// Write then rename, as for the save file.
+	tmp := settingsPath() + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, settingsPath())
+}
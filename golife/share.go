@@ -0,0 +1,149 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/golife/qr"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// qrModule is the side in px of a module of the QR codes of shared links.
+const qrModule = 4
+
+// A shareView shows the QR code of the link last shared, until the next touch. As the stamp menu,
+// it is drawn over the scene from a scene graph of its own and, while open, receives every touch.
+type shareView struct {
+	root *sprite.Node
+	tex  sprite.Texture
+}
+
+// share shares the selected cells, or else the alive ones of the whole field, as a golife:// link,
+// see life.Pattern.Link. The link is logged and written, and its QR code shown, see shareLink.
+func share() {
+	p := univ.life.Pattern()
+	if r := selection; selecting && !r.Empty() {
+		p = univ.life.Region(r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	}
+	link, err := p.Link()
+	if err != nil {
+		log.Printf("sharing: %v", err)
+		return
+	}
+	log.Printf("shared %dx%d cells as %s", p.W, p.H, link)
+	shareLink(link)
+}
+
+// shareRun shares the run of the game as a golife:// link, see life.Run, so that it evolves the
+// same once opened, starting paused at generation 0: how its random cells were drawn, if it is a
+// random universe, and the cells edited at generation 0. Edits made later are not part of the run.
+// It is shared as share does, by a long press on the share button.
+func shareRun() {
+	l := runStart
+	if univ.life.Generation() == 0 {
+		l = univ.life
+	}
+	if l == nil {
+		log.Printf("sharing the run: generation 0 of the game unknown")
+		return
+	}
+	seeds := soupRun
+	if seeds == nil {
+		seeds = &life.Run{}
+	}
+	r := life.NewRun(l, seeds.Density, seeds.Mode, seeds.Seed)
+	link, err := r.Link()
+	if err != nil {
+		log.Printf("sharing the run: %v", err)
+		return
+	}
+	log.Printf("shared the run of seed %d and %d edits as %s", r.Seed, len(r.Edits), link)
+	shareLink(link)
+}
+
+// shareLink writes link to a text file, and its QR code to a PNG file then shown, both named after
+// the current time in the directory of the snapshots. Links too long for a code are only written.
+func shareLink(link string) {
+	name := filepath.Join(exportDir(), time.Now().Format("golife-20060102-150405"))
+	err := writeFile(name+".txt", func(w io.Writer) error {
+		_, err := io.WriteString(w, link+"\n")
+		return err
+	})
+	if err != nil {
+		log.Printf("sharing: %v", err)
+	}
+	code, err := qr.Encode([]byte(link))
+	if err != nil {
+		log.Printf("sharing: %v", err)
+		return
+	}
+	img := code.Image(qrModule)
+	if err := writeFile(name+"-qr.png", func(w io.Writer) error { return png.Encode(w, img) }); err != nil {
+		log.Printf("sharing: %v", err)
+	}
+	openShareView(img)
+}
+
+// openShareView opens the share view showing img, a QR code, centered on the screen as large as it
+// fits, over the shaded scene.
+func openShareView(img image.Image) {
+	tex, err := eng.LoadTexture(img)
+	if err != nil {
+		log.Printf("share view: %v", err)
+		return
+	}
+	v := &shareView{root: &sprite.Node{}, tex: tex}
+	eng.Register(v.root)
+	eng.SetTransform(v.root, f32.Affine{{1, 0, 0}, {0, 1, 0}})
+
+	back := &sprite.Node{}
+	eng.Register(back)
+	v.root.AppendChild(back)
+	eng.SetSubTex(back, *textures[outOfBoundsImage])
+	eng.SetTransform(back, f32.Affine{{float32(geom.Width), 0, 0}, {0, float32(geom.Height), 0}})
+
+	side := geom.Width
+	if geom.Height < side {
+		side = geom.Height
+	}
+	side -= 2 * buttonSep
+	n := &sprite.Node{}
+	eng.Register(n)
+	v.root.AppendChild(n)
+	eng.SetSubTex(n, sprite.SubTex{tex, img.Bounds()})
+	eng.SetTransform(n, f32.Affine{
+		{float32(side), 0, float32((geom.Width - side) / 2)},
+		{0, float32(side), float32((geom.Height - side) / 2)},
+	})
+	views.shared = v
+	holdPanel(shareViewName)
+}
+
+// touch handles t while v is open: any touch closes v.
+func (v *shareView) touch(t event.Touch) {
+	if t.Type == event.TouchStart {
+		v.close()
+	}
+}
+
+// close closes v, unregisters its nodes and frees its texture.
+func (v *shareView) close() {
+	for n := v.root.FirstChild; n != nil; n = v.root.FirstChild {
+		v.root.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	eng.Unregister(v.root)
+	v.tex.Unload()
+	views.shared = nil
+	releasePanel(shareViewName)
+}
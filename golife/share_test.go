@@ -0,0 +1,80 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// sameCells reports whether the universe has the alive cells of l.
+func sameCells(l *life.Life) bool {
+	for j := 0; j < univ.rows; j++ {
+		for i := 0; i < univ.cols; i++ {
+			if univ.life.Alive(i, j) != l.Alive(i, j) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestShareRun(t *testing.T) {
+	gestureScene(t)
+	inTempDir(t, func(dir string) {
+		univ.reseed(5)
+		univ.life.SetCells([][2]int{{3, 3}, {4, 3}, {5, 3}}, !univ.life.Alive(3, 3))
+		want := univ.life.Clone()
+		for k := 0; k < 3; k++ {
+			univ.Step()
+		}
+		// Edits made once stepped are not part of the run.
+		univ.life.Set(1, 1, !univ.life.Alive(1, 1))
+		shareRun()
+		if views.shared == nil {
+			t.Fatalf("QR code of the run not shown")
+		}
+		views.shared.close()
+		names, _ := filepath.Glob(filepath.Join(dir, "*.txt"))
+		if len(names) != 1 {
+			t.Fatalf("%d links written", len(names))
+		}
+		link, err := ioutil.ReadFile(names[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The app opened with the link, as with the intent filter of the manifest, starts the run
+		// paused at generation 0, to evolve as the game shared.
+		defer func(f func() string) { openedWith = f }(openedWith)
+		openedWith = func() string { return string(link) }
+		setPaused(false)
+		univ.startFrom(startupSource())
+		if cfg.run == nil {
+			t.Fatalf("run link %q not opened", link)
+		}
+		if !paused || univ.life.Generation() != 0 || soupSeed != 5 || !sameCells(want) {
+			t.Errorf("run started: paused %v, generation %d, seed %d, same cells %v", paused, univ.life.Generation(), soupSeed, sameCells(want))
+		}
+		for k := 0; k < 20; k++ {
+			univ.Step()
+			want.Step()
+		}
+		if !sameCells(want) {
+			t.Errorf("run evolving otherwise than the game shared")
+		}
+
+		// Before the first step, the game as it is is shared, without edits if just seeded.
+		univ.reseed(6)
+		shareRun()
+		views.shared.close()
+		names, _ = filepath.Glob(filepath.Join(dir, "*.txt"))
+		link, _ = ioutil.ReadFile(names[len(names)-1])
+		if r, err := life.ParseRunLink(string(link)); err != nil || r.Seed != 6 || len(r.Edits) != 0 {
+			t.Errorf("run of a new random universe shared as %+v: %v", r, err)
+		}
+	})
+}
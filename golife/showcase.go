@@ -0,0 +1,302 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+	"golang.org/x/mobile/sprite/clock"
+)
+
+// The showcase is an attract mode for kiosks, started by the showcase button or on launch, see
+// cfg.showcase. It plays the patterns of the library listed by the playlist asset in a loop: each
+// shows paused under its name, runs for the generations of its entry at the speed and zoom of the
+// entry, and fades out. Any touch stops it, the game running on at the speed it had before.
+const playlistAsset = "showcase.json"
+
+// A showcaseEntry is an entry of the playlist.
+type showcaseEntry struct {
+	pattern     string // Asset of a pattern of library, see readPattern.
+	generations int
+	speed       float64 // In generations per second.
+	zoom        float32
+}
+
+// readPlaylist reads the playlist asset: a JSON array of objects with the keys pattern,
+// generations, speed and zoom. The patterns must be of library.
+func readPlaylist() ([]showcaseEntry, error) {
+	a, err := openAsset(playlistAsset)
+	if err != nil {
+		return nil, err
+	}
+	defer a.Close()
+	var m []struct {
+		Pattern     string
+		Generations int
+		Speed, Zoom float64
+	}
+	if err := json.NewDecoder(a).Decode(&m); err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		return nil, fmt.Errorf("empty playlist")
+	}
+	var pl []showcaseEntry
+	for k, e := range m {
+		found := false
+		for _, p := range library {
+			found = found || p.asset == e.Pattern
+		}
+		switch {
+		case !found:
+			return nil, fmt.Errorf("entry %d: pattern %q not in the library", k, e.Pattern)
+		case e.Generations <= 0 || e.Speed <= 0 || e.Zoom <= 0:
+			return nil, fmt.Errorf("entry %d: %d generations at a speed of %v and a zoom of %v", k,
+				e.Generations, e.Speed, e.Zoom)
+		}
+		pl = append(pl, showcaseEntry{e.Pattern, e.Generations, e.Speed, float32(e.Zoom)})
+	}
+	return pl, nil
+}
+
+// A showcasePhase is a phase of an entry of the playlist in the showcase.
+type showcasePhase int
+
+const (
+	showcaseTitle showcasePhase = iota // The pattern shows paused under its name.
+	showcaseRun                        // It runs for the generations of its entry.
+	showcaseFade                       // It fades out.
+)
+
+// How long the phases last, in ticks of the clock, 2s and 750ms.
+const (
+	showcaseTitleTime = 120
+	showcaseFadeTime  = 45
+)
+
+// A showcase sequences the phases of the entries of a playlist, by the clock and the generation of
+// the game.
+type showcase struct {
+	playlist []showcaseEntry
+	entry    int // Index in playlist of the current entry.
+	phase    showcasePhase
+	since    clock.Time // Start of the phase, -1 until the first frame.
+	from     int        // Generation its run started at, once running.
+}
+
+// next moves s along its playlist for the frame at t, the game being at generation, and reports
+// whether it entered a phase, the title of the first entry on the first frame.
+func (s *showcase) next(t clock.Time, generation int) bool {
+	switch {
+	case s.since < 0:
+	case s.phase == showcaseTitle && t-s.since >= showcaseTitleTime:
+		s.phase, s.from = showcaseRun, generation
+	case s.phase == showcaseRun && generation-s.from >= s.playlist[s.entry].generations:
+		s.phase = showcaseFade
+	case s.phase == showcaseFade && t-s.since >= showcaseFadeTime:
+		s.phase, s.entry = showcaseTitle, (s.entry+1)%len(s.playlist)
+	default:
+		return false
+	}
+	s.since = t
+	return true
+}
+
+// faded returns how far s faded out the pattern at t, from 0 to 1.
+func (s *showcase) faded(t clock.Time) float32 {
+	if s.phase != showcaseFade {
+		return 0
+	}
+	return clock.Linear(s.since, s.since+showcaseFadeTime, t)
+}
+
+// A showcaseView plays a showcase over the scene, shown by views.showcase.
+type showcaseView struct {
+	showcase
+	speed float64 // Speed of the game before the showcase.
+}
+
+// showcaseNodes show the showcase playing over the scene: the title of the entry while its pattern
+// shows, and the cover fading it out.
+type showcaseNodes struct {
+	title   []*sprite.Node // Nil unless showing.
+	cover   *sprite.Node   // Nil unless fading.
+	tex     sprite.Texture // Of the title, nil if none.
+	fadeTex sprite.Texture // Of the cover, nil if none.
+}
+
+// demo is the showcase playing, nil if none.
+var demo *showcaseView
+
+// startShowcase starts playing the showcase from the first entry of the playlist, by the next
+// frame.
+func startShowcase() {
+	pl, err := readPlaylist()
+	if err != nil {
+		log.Printf("%s: %v", playlistAsset, err)
+		return
+	}
+	forgetPointers()
+	views.fit = nil
+	demo = &showcaseView{showcase: showcase{playlist: pl, since: -1}, speed: speed}
+	log.Printf("showcase of %d patterns", len(pl))
+}
+
+// stopShowcase stops the showcase playing, if any, leaving the game running at the speed it had.
+func stopShowcase() {
+	d := demo
+	if d == nil {
+		return
+	}
+	views.showcase.hide()
+	demo = nil
+	speed = d.speed
+	setPaused(false)
+	log.Printf("showcase stopped")
+}
+
+// updateShowcase plays the showcase, if any, for the frame at t. It is called every frame.
+func updateShowcase(t clock.Time) {
+	d := demo
+	if d == nil {
+		return
+	}
+	if d.next(t, univ.life.Generation()) {
+		d.enter()
+	}
+	switch {
+	case d.phase == showcaseTitle && views.showcase.title == nil:
+		views.showcase.showTitle(d.playlist[d.entry].pattern)
+	case d.phase == showcaseFade:
+		views.showcase.fade(d.faded(t))
+	}
+}
+
+// enter starts the phase d is at.
+func (d *showcaseView) enter() {
+	e := d.playlist[d.entry]
+	views.showcase.hide()
+	switch d.phase {
+	case showcaseTitle:
+		p, err := readPattern(e.pattern)
+		if err != nil {
+			log.Printf("pattern %s: %v", e.pattern, err)
+			return
+		}
+		setPaused(true)
+		univ.place(p)
+		u := univ
+		// Centered on the field, as the pattern is.
+		mid := geom.Point{X: u.margin + geom.Pt(u.cols)*cellSize/2, Y: u.margin + geom.Pt(u.rows)*cellSize/2}
+		u.look(e.zoom, mid, u.center())
+		log.Printf("showcase: %s", e.pattern)
+	case showcaseRun:
+		speed = e.speed
+		setPaused(false)
+	case showcaseFade:
+		setPaused(true)
+	}
+}
+
+// add adds to the scene a node of v showing sub over r, which uses absolute location.
+func (v *showcaseNodes) add(sub sprite.SubTex, r geom.Rectangle) *sprite.Node {
+	n := &sprite.Node{}
+	eng.Register(n)
+	// The scene is below the system bar.
+	scene.AppendChild(n)
+	eng.SetSubTex(n, sub)
+	eng.SetTransform(n, f32.Affine{
+		{float32(r.Max.X - r.Min.X), 0, float32(r.Min.X)},
+		{0, float32(r.Max.Y - r.Min.Y), float32(r.Min.Y - systemBarHeight)},
+	})
+	return n
+}
+
+// showTitle shows the title of the pattern of the given asset, centered on the grid in lines of the
+// bitmap font twice as high as the buttons.
+func (v *showcaseNodes) showTitle(asset string) {
+	var s string
+	for _, p := range library {
+		if p.asset == asset {
+			s = p.title()
+		}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, textWidth(s, pickerScale), pickerPx))
+	drawText(img, s, 0, (pickerPx-glyphHeight*pickerScale)/2, pickerScale, color.White)
+	tex, err := eng.LoadTexture(img)
+	if err != nil {
+		log.Printf("showcase: %v", err)
+		return
+	}
+	v.tex = tex
+	line := 2 * buttonSize
+	w := geom.Pt(img.Bounds().Dx()) * line / pickerPx
+	cut := img.Bounds()
+	// Long names are cut at the edge of the screen.
+	if room := geom.Width - 4*buttonSep; w > room {
+		cut.Max.X = int(room * pickerPx / line)
+		w = room
+	}
+	c := univ.center()
+	r := geom.Rectangle{
+		Min: geom.Point{X: c.X - w/2, Y: c.Y - line/2},
+		Max: geom.Point{X: c.X + w/2, Y: c.Y + line/2},
+	}
+	pad := buttonSep
+	back := geom.Rectangle{
+		Min: geom.Point{X: r.Min.X - pad, Y: r.Min.Y - pad},
+		Max: geom.Point{X: r.Max.X + pad, Y: r.Max.Y + pad},
+	}
+	v.title = []*sprite.Node{v.add(*textures[outOfBoundsImage], back), v.add(sprite.SubTex{tex, cut}, r)}
+}
+
+// fade covers the grid in the background color of the theme, opaque as told by alpha, from 0 to 1.
+func (v *showcaseNodes) fade(alpha float32) {
+	bg := themes[currentTheme].background
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	a := uint8(alpha * 0xff)
+	// Premultiplied.
+	img.SetRGBA(0, 0, color.RGBA{uint8(int(bg.R) * int(a) / 0xff), uint8(int(bg.G) * int(a) / 0xff),
+		uint8(int(bg.B) * int(a) / 0xff), a})
+	if v.cover == nil {
+		tex, err := eng.LoadTexture(img)
+		if err != nil {
+			log.Printf("showcase: %v", err)
+			return
+		}
+		v.fadeTex = tex
+		top := systemBarHeight + buttonBarHeight
+		v.cover = v.add(sprite.SubTex{tex, img.Bounds()}, geom.Rectangle{
+			Min: geom.Point{Y: top},
+			Max: geom.Point{X: geom.Width, Y: geom.Height},
+		})
+		return
+	}
+	v.fadeTex.Upload(img.Bounds(), img)
+}
+
+// hide removes the title and the cover of v, if any, from the scene, unregisters their nodes and
+// frees their textures, to be shown again by the next frame.
+func (v *showcaseNodes) hide() {
+	nodes := v.title
+	if v.cover != nil {
+		nodes = append(nodes, v.cover)
+	}
+	for _, n := range nodes {
+		n.Parent.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	for _, t := range []sprite.Texture{v.tex, v.fadeTex} {
+		if t != nil {
+			t.Unload()
+		}
+	}
+	v.title, v.cover, v.tex, v.fadeTex = nil, nil, nil, nil
+}
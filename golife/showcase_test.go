@@ -0,0 +1,125 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/mobile/app"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/sprite/clock"
+)
+
+func TestShowcaseSequence(t *testing.T) {
+	s := showcase{playlist: []showcaseEntry{{"glider.rle", 10, 5, 1}, {"pulsar.rle", 3, 5, 1}}, since: -1}
+	var now clock.Time
+	gen := 0
+	for _, step := range []struct {
+		t, gens int // Ticks and generations since the last step.
+		entered bool
+		entry   int
+		phase   showcasePhase
+	}{
+		{0, 0, true, 0, showcaseTitle},
+		{showcaseTitleTime - 1, 0, false, 0, showcaseTitle},
+		{1, 0, true, 0, showcaseRun},
+		// The run lasts generations, however long they take.
+		{1000, 9, false, 0, showcaseRun},
+		{0, 1, true, 0, showcaseFade},
+		{showcaseFadeTime / 3, 5, false, 0, showcaseFade},
+		{showcaseFadeTime, 0, true, 1, showcaseTitle},
+		{showcaseTitleTime, 0, true, 1, showcaseRun},
+		{1, 3, true, 1, showcaseFade},
+		// Back to the first entry.
+		{showcaseFadeTime, 0, true, 0, showcaseTitle},
+	} {
+		now += clock.Time(step.t)
+		gen += step.gens
+		if entered := s.next(now, gen); entered != step.entered || s.entry != step.entry || s.phase != step.phase {
+			t.Fatalf("at %d, generation %d: entered %v entry %d in phase %d, want %v, %d and %d",
+				now, gen, entered, s.entry, s.phase, step.entered, step.entry, step.phase)
+		}
+	}
+	s.phase, s.since = showcaseFade, 30
+	if f := s.faded(30 + showcaseFadeTime/3); f < 0.3 || f > 0.4 {
+		t.Errorf("faded %v a third into the fade", f)
+	}
+}
+
+func TestReadPlaylist(t *testing.T) {
+	pl, err := readPlaylist()
+	if err != nil || len(pl) == 0 {
+		t.Fatalf("playlist of %d entries: %v", len(pl), err)
+	}
+	for _, e := range pl {
+		if _, err := readPattern(e.pattern); err != nil {
+			t.Errorf("pattern %s: %v", e.pattern, err)
+		}
+	}
+
+	defer func(open func(string) (app.ReadSeekCloser, error)) { openAsset = open }(openAsset)
+	for _, s := range []string{
+		`[]`,
+		`[{"pattern": "glider.rle", "generations": 10, "speed": 5}]`,
+		`[{"pattern": "unknown.rle", "generations": 10, "speed": 5, "zoom": 1}]`,
+		`[{"pattern": "glider.rle", "generations": 0, "speed": 5, "zoom": 1}]`,
+		`{"pattern": "glider.rle"}`,
+	} {
+		openAsset = func(name string) (app.ReadSeekCloser, error) {
+			if name == playlistAsset {
+				return stringAsset{strings.NewReader(s)}, nil
+			}
+			return nil, os.ErrNotExist
+		}
+		if _, err := readPlaylist(); err == nil {
+			t.Errorf("playlist %s read", s)
+		}
+	}
+}
+
+func TestShowcase(t *testing.T) {
+	gestureScene(t)
+	defer func(s float64) { demo, speed = nil, s }(speed)
+	before := speed
+	nodes := countNodes(scene)
+	startShowcase()
+	if demo == nil {
+		t.Fatalf("showcase not started")
+	}
+	e := demo.playlist[0]
+
+	updateShowcase(0)
+	if !paused || views.showcase.title == nil || univ.life.Population() == 0 || univ.zoom != e.zoom {
+		t.Fatalf("title of %s: paused %v, title shown %v, %d cells at a zoom of %v",
+			e.pattern, paused, views.showcase.title != nil, univ.life.Population(), univ.zoom)
+	}
+	updateShowcase(showcaseTitleTime)
+	if paused || speed != e.speed || views.showcase.title != nil {
+		t.Fatalf("run of %s: paused %v at %v generations per second", e.pattern, paused, speed)
+	}
+	for k := 0; k < e.generations; k++ {
+		univ.Step()
+	}
+	updateShowcase(showcaseTitleTime + 1)
+	updateShowcase(showcaseTitleTime + 1 + showcaseFadeTime/2)
+	if !paused || views.showcase.cover == nil {
+		t.Fatalf("fade of %s: paused %v, covered %v", e.pattern, paused, views.showcase.cover != nil)
+	}
+	updateShowcase(showcaseTitleTime + 1 + showcaseFadeTime)
+	if demo.entry != 1 || views.showcase.cover != nil || views.showcase.title == nil {
+		t.Fatalf("showcase at entry %d, covered %v", demo.entry, views.showcase.cover != nil)
+	}
+
+	// A touch stops it, the game running on, the grid taking no touch meanwhile.
+	cells := univ.life.Population()
+	finger(1, event.TouchStart, cellLoc(2, 2))
+	finger(1, event.TouchEnd, cellLoc(2, 2))
+	if demo != nil || paused || speed != before || univ.life.Population() != cells {
+		t.Errorf("showcase stopped %v, paused %v at %v generations per second", demo == nil, paused, speed)
+	}
+	if n := countNodes(scene); n != nodes {
+		t.Errorf("%d nodes in the scene once stopped, %d before", n, nodes)
+	}
+}
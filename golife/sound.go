@@ -0,0 +1,138 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"log"
+	"math"
+	"time"
+
+	"golang.org/x/mobile/audio"
+)
+
+const (
+	// soundRate is the sample rate of the sounds, in Hz.
+	soundRate = 22050
+	// minTickInterval is the shortest time between two ticks, whatever the speed: faster games tick
+	// less than once a generation.
+	minTickInterval = 100 * time.Millisecond
+	// tickPitches is the number of pitches of the ticks, a semitone apart.
+	tickPitches = 13
+)
+
+// A mixer plays the sounds of the app: a click on button taps and, if cfg.ticks, a tick on steps
+// whose pitch follows the change of the population. The sounds are played by a goroutine of their
+// own, and dropped while it is busy, so that fast or large games do not flood the audio device.
+type mixer struct {
+	tap   *audio.Player
+	ticks []*audio.Player // From the lowest pitch to the highest.
+	queue chan *audio.Player
+	// lastTick is when the last tick was played, and population the population then.
+	lastTick   time.Time
+	population int
+}
+
+var (
+	// sounds is the mixer of the app, nil if the audio device could not be opened.
+	sounds *mixer
+	// muted silences every sound, toggled by the sound button.
+	muted bool
+)
+
+// newMixer opens a player for each of the sounds, synthesized rather than loaded from assets, and
+// starts playing the sounds it is asked to.
+func newMixer() (*mixer, error) {
+	m := &mixer{queue: make(chan *audio.Player, 1)}
+	var err error
+	if m.tap, err = newSound(1760, 25*time.Millisecond, 0.25); err != nil {
+		return nil, err
+	}
+	for k := 0; k < tickPitches; k++ {
+		// Around the A below the middle C.
+		f := 220 * math.Pow(2, float64(k-tickPitches/2)/12)
+		p, err := newSound(f, 15*time.Millisecond, 0.15)
+		if err != nil {
+			m.tap.Close()
+			for _, p := range m.ticks {
+				p.Close()
+			}
+			return nil, err
+		}
+		m.ticks = append(m.ticks, p)
+	}
+	go m.loop()
+	return m, nil
+}
+
+// pcm is a sound as 16 bit mono samples.
+type pcm struct {
+	*bytes.Reader
+}
+
+func (pcm) Close() error { return nil }
+
+// newSound returns a player of a sine wave of frequency f in Hz, lasting d and fading out, whose
+// amplitude starts at vol of the loudest.
+func newSound(f float64, d time.Duration, vol float64) (*audio.Player, error) {
+	n := int(d.Seconds() * soundRate)
+	b := make([]byte, 2*n)
+	for k := 0; k < n; k++ {
+		t := float64(k) / soundRate
+		v := int16(vol * math.MaxInt16 * (1 - float64(k)/float64(n)) * math.Sin(2*math.Pi*f*t))
+		b[2*k], b[2*k+1] = byte(v), byte(v>>8)
+	}
+	return audio.NewPlayer(pcm{bytes.NewReader(b)}, audio.Mono16, soundRate)
+}
+
+// loop plays the sounds of the queue from their start, until a player fails.
+func (m *mixer) loop() {
+	for p := range m.queue {
+		err := p.Seek(0)
+		if err == nil {
+			err = p.Play()
+		}
+		if err != nil {
+			log.Printf("playing a sound: %v, sounds disabled", err)
+			return
+		}
+	}
+}
+
+// play plays p, unless a sound is already waiting to be played.
+func (m *mixer) play(p *audio.Player) {
+	if m == nil || muted {
+		return
+	}
+	select {
+	case m.queue <- p:
+	default:
+	}
+}
+
+// click plays the sound of button taps.
+func (m *mixer) click() {
+	if m == nil {
+		return
+	}
+	m.play(m.tap)
+}
+
+// tick plays a tick for a step to a generation of the given population, if cfg.ticks and the last
+// one is old enough. The more the population grew since the last tick the higher the pitch, the
+// more it shrank the lower.
+func (m *mixer) tick(population int) {
+	if m == nil || !cfg.ticks || time.Since(m.lastTick) < minTickInterval {
+		return
+	}
+	// A change of a tenth of the population spans half the pitches.
+	d := float64(population-m.population) / math.Max(1, float64(m.population))
+	k := tickPitches/2 + int(math.Floor(d*10*(tickPitches/2)+0.5))
+	if k < 0 {
+		k = 0
+	} else if k >= tickPitches {
+		k = tickPitches - 1
+	}
+	m.lastTick, m.population = time.Now(), population
+	m.play(m.ticks[k])
+}
@@ -0,0 +1,136 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+const (
+	// sparkSamples is the number of generations the sparkline shows, a column of px each, and
+	// sparkHeight its height in px.
+	sparkSamples = 96
+	sparkHeight  = 32
+	// Size of the sparkline on the screen, in Pt.
+	sparkWidthPt  = 64
+	sparkHeightPt = 20
+)
+
+// A sparkline graphs the population of the latest generations in the bottom-left corner of the
+// screen, scrolling left as generations are stepped, scaled to the largest population shown. A flat
+// line tells a soup has stabilized. As a frame, it is drawn as the pixels of a texture uploaded
+// once per rendered frame if it changed.
+type sparkline struct {
+	pops [sparkSamples]int // Ring buffer of the populations.
+	next int               // Index in pops of the next population recorded.
+	n    int               // Number of populations recorded, up to sparkSamples.
+
+	node  *sprite.Node
+	tex   sprite.Texture // Nil until the first flush.
+	img   *image.RGBA
+	dirty bool // Whether img must be drawn and uploaded again.
+}
+
+// spark is the sparkline, nil if disabled by the sparkline config key.
+var spark *sparkline
+
+// attach adds s to the scene, for instance once the scene is built again with a new engine. The
+// populations recorded so far are kept.
+func (s *sparkline) attach() {
+	s.node, s.tex = &sprite.Node{}, nil
+	s.img = image.NewRGBA(image.Rect(0, 0, sparkSamples, sparkHeight))
+	eng.Register(s.node)
+	scene.AppendChild(s.node)
+	s.place()
+	s.dirty = true
+}
+
+// place moves s to the bottom-left corner of the screen, for instance once its size changed.
+func (s *sparkline) place() {
+	if s == nil {
+		return
+	}
+	// The scene is below the system bar.
+	y := geom.Height - systemBarHeight - buttonSep - sparkHeightPt
+	eng.SetTransform(s.node, f32.Affine{{sparkWidthPt, 0, float32(buttonSep)}, {0, sparkHeightPt, float32(y)}})
+}
+
+// record adds the population of the generation just stepped to.
+func (s *sparkline) record(pop int) {
+	if s == nil {
+		return
+	}
+	s.pops[s.next] = pop
+	s.next = (s.next + 1) % sparkSamples
+	if s.n < sparkSamples {
+		s.n++
+	}
+	s.dirty = true
+}
+
+// back forgets the latest population, once stepped back from its generation.
+func (s *sparkline) back() {
+	if s == nil || s.n == 0 {
+		return
+	}
+	s.next = (s.next + sparkSamples - 1) % sparkSamples
+	s.n--
+	s.dirty = true
+}
+
+// clear forgets every population, for instance once the universe is replaced.
+func (s *sparkline) clear() {
+	if s == nil {
+		return
+	}
+	s.next, s.n = 0, 0
+	s.dirty = true
+}
+
+// flush draws and uploads the image of s if it changed. Textures can only be loaded on the GL
+// thread, hence it is called from draw.
+func (s *sparkline) flush() {
+	if s == nil || !s.dirty {
+		return
+	}
+	s.dirty = false
+	// A translucent background in the color of the screen area outside the field, premultiplied.
+	bg := themes[currentTheme].outOfBounds
+	bg = color.RGBA{bg.R / 4 * 3, bg.G / 4 * 3, bg.B / 4 * 3, 0xc0}
+	for y := 0; y < sparkHeight; y++ {
+		for x := 0; x < sparkSamples; x++ {
+			s.img.SetRGBA(x, y, bg)
+		}
+	}
+	peak := 1
+	for k := 0; k < s.n; k++ {
+		if p := s.pops[k]; p > peak {
+			peak = p
+		}
+	}
+	// The latest population on the right, a column from the bottom.
+	for k := 0; k < s.n; k++ {
+		p := s.pops[(s.next+sparkSamples-s.n+k)%sparkSamples]
+		x := sparkSamples - s.n + k
+		h := 1 + p*(sparkHeight-2)/peak
+		for y := sparkHeight - h; y < sparkHeight; y++ {
+			s.img.SetRGBA(x, y, snapshotAliveColor)
+		}
+	}
+	if s.tex != nil {
+		s.tex.Upload(s.img.Bounds(), s.img)
+		return
+	}
+	tex, err := eng.LoadTexture(s.img)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.tex = tex
+	eng.SetSubTex(s.node, sprite.SubTex{tex, s.img.Bounds()})
+}
@@ -0,0 +1,123 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"time"
+
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// speedLevels are the speeds the speed buttons step through, in generations per second. The
+// initial speed is the fourth one.
+var speedLevels = []float64{minSpeed, 2, 5, initialSpeed, 30, maxSpeed}
+
+// speedDigits is the number of digits of the speed indicator, enough for every level.
+const speedDigits = 2
+
+// speedIndicatorSlot stands for the speed indicator in the layout of the button bar.
+const speedIndicatorSlot = ""
+
+// speedFlashTime is how long the speed indicator grows when a speed button has no effect.
+const speedFlashTime = 150 * time.Millisecond
+
+// A levelIndicator shows the speed, in generations per second, between the speed buttons.
+type levelIndicator struct {
+	// node holds the digits, laid out around its origin so that it grows from the center of its
+	// slot.
+	node     *sprite.Node
+	digits   *counter
+	x        geom.Pt // Left of its slot in the button bar.
+	flashEnd time.Time
+}
+
+// speedIndicator is the speed indicator of the button bar, nil if the speed buttons are not next to
+// each other.
+var speedIndicator *levelIndicator
+
+// speedLevel returns the index in speedLevels of the level closest to the speed s.
+func speedLevel(s float64) int {
+	best := 0
+	for k, v := range speedLevels {
+		if math.Abs(math.Log(v/s)) < math.Abs(math.Log(speedLevels[best]/s)) {
+			best = k
+		}
+	}
+	return best
+}
+
+// changeSpeed moves the speed d levels up or down. At the first or last level, the speed indicator
+// flashes instead.
+func changeSpeed(d int) {
+	k := speedLevel(speed) + d
+	if k < 0 || k >= len(speedLevels) {
+		speedIndicator.flash()
+		return
+	}
+	speed = speedLevels[k]
+	speedIndicator.show()
+}
+
+// newSpeedIndicator returns a speed indicator in the button bar slot whose left is at x.
+func newSpeedIndicator(x geom.Pt) *levelIndicator {
+	n := &sprite.Node{}
+	eng.Register(n)
+	scene.AppendChild(n)
+	// Digits three fifths of a button high.
+	h := geom.Pt(buttonSize) * 3 / 5
+	w := geom.Pt(speedDigits) * h * hudDigitWidth / hudDigitHeight
+	li := &levelIndicator{node: n, digits: newCounter(n, -w/2, -h/2, h, speedDigits), x: x}
+	li.show()
+	return li
+}
+
+// release removes li from the scene and unregisters its node.
+func (li *levelIndicator) release() {
+	if li == nil {
+		return
+	}
+	li.digits.release()
+	scene.RemoveChild(li.node)
+	eng.Unregister(li.node)
+	speedIndicator = nil
+}
+
+// show shows the speed the game runs at, centered in the slot: at its normal size unless flashing,
+// or smaller while limited under the current speed, see limiter.
+func (li *levelIndicator) show() {
+	if li == nil {
+		return
+	}
+	li.digits.set(int(limiter.speed(speed) + 0.5))
+	scale := float32(1)
+	switch {
+	case time.Now().Before(li.flashEnd):
+		scale = 1.4
+	case limiter.limited(speed):
+		scale = 0.75
+	}
+	eng.SetTransform(li.node, f32.Affine{
+		{scale, 0, float32(li.x + buttonSize/2)},
+		{0, scale, float32(buttonSize / 2)},
+	})
+}
+
+// flash briefly grows the indicator, so that a tap without effect still shows it was registered.
+func (li *levelIndicator) flash() {
+	if li == nil {
+		return
+	}
+	li.flashEnd = time.Now().Add(speedFlashTime)
+	li.show()
+}
+
+// update ends the flash of the indicator once due. It is called every frame.
+func (li *levelIndicator) update() {
+	if li != nil && !li.flashEnd.IsZero() && time.Now().After(li.flashEnd) {
+		li.flashEnd = time.Time{}
+		li.show()
+	}
+}
@@ -0,0 +1,163 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package com.example.golife;
+
+import android.app.Activity;
+import android.graphics.Rect;
+import android.os.Build;
+import android.os.Bundle;
+import android.view.View;
+import android.view.ViewParent;
+import android.view.accessibility.AccessibilityEvent;
+import android.view.accessibility.AccessibilityNodeInfo;
+import android.view.accessibility.AccessibilityNodeProvider;
+
+/**
+ * Accessibility lists the buttons the app draws with GL as virtual views of the window of the
+ * NativeActivity, which has no views of its own for the screen reader to read, so that the user
+ * can move through them, hear their labels and activate them. The Go side hands the buttons over
+ * with setControls, see registerControls in bridge_android.go, and is told of the activated ones
+ * by click.
+ */
+public final class Accessibility extends AccessibilityNodeProvider {
+	/** The class used for the virtual views, for the screen reader to tell them as buttons. */
+	private static final String BUTTON = "android.widget.Button";
+
+	/** The provider of the current window, null until the first controls. */
+	private static Accessibility provider;
+
+	private final View host;
+	/** The rectangles of the controls in pixels of the window, as left, top, right and bottom. */
+	private int[] rects = new int[0];
+	private String[] labels = new String[0];
+	/** The control with the focus of the screen reader, View.NO_ID if none. */
+	private int focused = View.NO_ID;
+
+	private Accessibility(View host) {
+		this.host = host;
+	}
+
+	/** click has the Go side tap the control k, see controlClicked in accessibility_android.go. */
+	static native void click(int k);
+
+	/**
+	 * setControls replaces the controls of the window of activity: rects holds their rectangles in
+	 * pixels as left, top, right and bottom, and labels their labels one per line. It can be called
+	 * from any thread. Virtual views need Android 4.1, before which it does nothing.
+	 */
+	public static void setControls(final Activity activity, final int[] rects, final String labels) {
+		if (Build.VERSION.SDK_INT < 16) {
+			return;
+		}
+		activity.runOnUiThread(new Runnable() {
+			@Override
+			public void run() {
+				View host = activity.getWindow().getDecorView();
+				if (provider == null || provider.host != host) {
+					provider = new Accessibility(host);
+					host.setAccessibilityDelegate(new View.AccessibilityDelegate() {
+						@Override
+						public AccessibilityNodeProvider getAccessibilityNodeProvider(View v) {
+							return provider;
+						}
+					});
+				}
+				provider.update(rects, rects.length == 0 ? new String[0] : labels.split("\n", -1));
+			}
+		});
+	}
+
+	/** update replaces the controls, and tells the screen reader the window changed. */
+	private void update(int[] rects, String[] labels) {
+		this.rects = rects;
+		this.labels = labels;
+		if (focused >= labels.length) {
+			focused = View.NO_ID;
+		}
+		send(View.NO_ID, AccessibilityEvent.TYPE_WINDOW_CONTENT_CHANGED);
+	}
+
+	@Override
+	public AccessibilityNodeInfo createAccessibilityNodeInfo(int id) {
+		if (id == View.NO_ID) {
+			AccessibilityNodeInfo info = AccessibilityNodeInfo.obtain(host);
+			host.onInitializeAccessibilityNodeInfo(info);
+			for (int k = 0; k < labels.length; k++) {
+				info.addChild(host, k);
+			}
+			return info;
+		}
+		if (id < 0 || id >= labels.length) {
+			return null;
+		}
+		AccessibilityNodeInfo info = AccessibilityNodeInfo.obtain(host, id);
+		info.setPackageName(host.getContext().getPackageName());
+		info.setClassName(BUTTON);
+		info.setContentDescription(labels[id]);
+		info.setParent(host);
+		Rect r = new Rect(rects[4 * id], rects[4 * id + 1], rects[4 * id + 2], rects[4 * id + 3]);
+		info.setBoundsInParent(r);
+		int[] at = new int[2];
+		host.getLocationOnScreen(at);
+		r.offset(at[0], at[1]);
+		info.setBoundsInScreen(r);
+		info.setEnabled(true);
+		info.setVisibleToUser(true);
+		info.setClickable(true);
+		info.addAction(AccessibilityNodeInfo.ACTION_CLICK);
+		if (id == focused) {
+			info.setAccessibilityFocused(true);
+			info.addAction(AccessibilityNodeInfo.ACTION_CLEAR_ACCESSIBILITY_FOCUS);
+		} else {
+			info.addAction(AccessibilityNodeInfo.ACTION_ACCESSIBILITY_FOCUS);
+		}
+		return info;
+	}
+
+	@Override
+	public boolean performAction(int id, int action, Bundle arguments) {
+		if (id == View.NO_ID) {
+			return host.performAccessibilityAction(action, arguments);
+		}
+		if (id < 0 || id >= labels.length) {
+			return false;
+		}
+		switch (action) {
+		case AccessibilityNodeInfo.ACTION_CLICK:
+			click(id);
+			send(id, AccessibilityEvent.TYPE_VIEW_CLICKED);
+			return true;
+		case AccessibilityNodeInfo.ACTION_ACCESSIBILITY_FOCUS:
+			if (focused == id) {
+				return false;
+			}
+			focused = id;
+			send(id, AccessibilityEvent.TYPE_VIEW_ACCESSIBILITY_FOCUSED);
+			return true;
+		case AccessibilityNodeInfo.ACTION_CLEAR_ACCESSIBILITY_FOCUS:
+			if (focused != id) {
+				return false;
+			}
+			focused = View.NO_ID;
+			send(id, AccessibilityEvent.TYPE_VIEW_ACCESSIBILITY_FOCUS_CLEARED);
+			return true;
+		}
+		return false;
+	}
+
+	/** send sends the screen reader an event of the given type from the control id, or the window. */
+	private void send(int id, int type) {
+		ViewParent parent = host.getParent();
+		if (parent == null) {
+			return;
+		}
+		AccessibilityEvent e = AccessibilityEvent.obtain(type);
+		e.setPackageName(host.getContext().getPackageName());
+		e.setSource(host, id);
+		if (id != View.NO_ID) {
+			e.setClassName(BUTTON);
+			e.setContentDescription(labels[id]);
+		}
+		parent.requestSendAccessibilityEvent(host, e);
+	}
+}
@@ -0,0 +1,39 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// A history tells when a game stagnates: when it dies out, stops changing, enters a cycle of
+// generations or moves on as a whole, as told by life.Life.Period and life.Life.Drift. Each
+// stagnation is told once, so that a game resumed after stagnating runs on.
+type history struct {
+	told bool // Whether the stagnation was told since the last clear.
+}
+
+// clear makes the next stagnation told, for instance once the game was edited.
+func (h *history) clear() {
+	h.told = false
+}
+
+// stagnates reports whether the game of l, which must have just stepped, stagnates and was not yet
+// told so, the period of its cycle, 1 for a still life or once it died out, and how far its cells
+// move each period, zero unless they drift.
+func (h *history) stagnates(l *life.Life) (period int, drift image.Point, ok bool) {
+	period = l.Period()
+	if l.Population() == 0 {
+		period = 1
+	}
+	if period == 0 && !h.told {
+		period, drift.X, drift.Y = l.Drift()
+	}
+	if period == 0 || h.told {
+		return period, drift, false
+	}
+	h.told = true
+	return period, drift, true
+}
@@ -0,0 +1,171 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// stamps are the patterns offered by the stamp menu, from left to right: gliders heading
+// south-east, south-west, north-west and north-east, a lightweight spaceship heading west, a
+// blinker and a block.
+var stamps = []*life.Pattern{
+	{W: 3, H: 3, Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}},
+	{W: 3, H: 3, Cells: [][2]int{{1, 0}, {0, 1}, {0, 2}, {1, 2}, {2, 2}}},
+	{W: 3, H: 3, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}, {0, 1}, {1, 2}}},
+	{W: 3, H: 3, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {1, 2}}},
+	{W: 5, H: 4, Cells: [][2]int{{1, 0}, {4, 0}, {0, 1}, {0, 2}, {4, 2}, {0, 3}, {1, 3}, {2, 3}, {3, 3}}},
+	{W: 3, H: 1, Cells: [][2]int{{0, 0}, {1, 0}, {2, 0}}},
+	{W: 2, H: 2, Cells: [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}}},
+}
+
+// stampPreviewSize is the side in px of the preview of a stamp, which fits stamps of up to
+// stampPreviewSize/previewCell cells wide and high, previewCell px each.
+const (
+	stampPreviewSize = 56
+	previewCell      = 8
+)
+
+// A stampMenu offers the patterns of stamps to stamp on the cell of a long press. As the settings
+// panel, it is drawn over the scene from a scene graph of its own and, while open, receives every
+// touch.
+type stampMenu struct {
+	root   *sprite.Node
+	bar    *ui.Bar // The buttons are named by the index of their stamp.
+	tex    sprite.Texture
+	rect   geom.Rectangle // Uses absolute location.
+	i, j   int            // The cell stamps are centered on.
+	frames []sprite.SubTex
+}
+
+// openStampMenu reverts the painting of s, a long press, and opens the stamp menu for its cell. The
+// menu shows above the cell, or below if there is no room, with the cell highlighted meanwhile.
+func (u *universe) openStampMenu(s *stroke) {
+	if s.edited {
+		u.revertStroke(s)
+		u.dropEdit()
+	}
+	forgetPointers()
+	unflash()
+	views.flash = u.newCell(s.i, s.j)
+	eng.SetSubTex(views.flash, *textures[wrapBorderImage])
+	views.flashEnd = time.Now().Add(flashTime)
+
+	img := stampPreviews()
+	tex, err := eng.LoadTexture(img)
+	if err != nil {
+		log.Printf("stamp menu: %v", err)
+		return
+	}
+	m := &stampMenu{root: &sprite.Node{}, tex: tex, i: s.i, j: s.j}
+	for k := range stamps {
+		r := image.Rect(k*stampPreviewSize, 0, (k+1)*stampPreviewSize, stampPreviewSize)
+		m.frames = append(m.frames, sprite.SubTex{tex, r})
+	}
+	eng.Register(m.root)
+	eng.SetTransform(m.root, f32.Affine{{1, 0, 0}, {0, 1, 0}})
+
+	n := len(stamps)
+	side := slotFit(n, geom.Width)
+	w := geom.Pt(n)*side + geom.Pt(n+1)*buttonSep
+	h := side + 2*buttonSep
+	x0 := s.loc.X - w/2
+	if x0 < 0 {
+		x0 = 0
+	} else if x0 > geom.Width-w {
+		x0 = geom.Width - w
+	}
+	// Clear of the cell under the finger, as large as it shows.
+	siz := geom.Pt(univ.zoom) * cellSize
+	y0 := s.loc.Y - siz - h
+	if y0 < systemBarHeight+buttonBarHeight {
+		y0 = s.loc.Y + siz
+	}
+	m.rect = geom.Rectangle{Min: geom.Point{X: x0, Y: y0}, Max: geom.Point{X: x0 + w, Y: y0 + h}}
+	back := &sprite.Node{}
+	eng.Register(back)
+	m.root.AppendChild(back)
+	eng.SetSubTex(back, *textures[outOfBoundsImage])
+	eng.SetTransform(back, f32.Affine{{float32(w), 0, float32(x0)}, {0, float32(h), float32(y0)}})
+
+	m.bar = ui.NewBar(eng, m.root, geom.Point{}, func(b *ui.Button) sprite.SubTex {
+		k, _ := strconv.Atoi(b.Name)
+		return m.frames[k]
+	})
+	corner := geom.Point{X: x0 + buttonSep, Y: y0 + buttonSep}
+	for k, r := range mirrorAll(ui.Grid(n, n, side, buttonSep, corner), m.rect) {
+		m.bar.Add(strconv.Itoa(k), r)
+	}
+	views.menu = m
+	holdPanel(stampMenuName)
+}
+
+// stampPreviews returns a strip of the previews of stamps, see patternPreviews.
+func stampPreviews() image.Image {
+	return patternPreviews(stamps)
+}
+
+// patternPreviews returns a strip of the previews of ps, each centered in a square of
+// stampPreviewSize px.
+func patternPreviews(ps []*life.Pattern) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, len(ps)*stampPreviewSize, stampPreviewSize))
+	for k, p := range ps {
+		x0 := k*stampPreviewSize + (stampPreviewSize-p.W*previewCell)/2
+		y0 := (stampPreviewSize - p.H*previewCell) / 2
+		for _, c := range p.Cells {
+			// A gap between cells, as the cell image has.
+			for y := 1; y < previewCell; y++ {
+				for x := 1; x < previewCell; x++ {
+					img.Set(x0+c[0]*previewCell+x, y0+c[1]*previewCell+y, snapshotAliveColor)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// touch handles t while m is open. A touch on a stamp stamps it centered on the cell of m, clipped
+// to the field and leaving the pinned cells as they are, and closes m, as does a touch out of m.
+func (m *stampMenu) touch(t event.Touch) {
+	if t.Type != event.TouchStart {
+		return
+	}
+	if b := m.bar.Find(t.Loc); b != nil {
+		k, _ := strconv.Atoi(b.Name)
+		p := stamps[k]
+		x, y := m.i-p.W/2, m.j-p.H/2
+		univ.transact(text("edit.stamp"), func() { univ.life.Stamp(p, x, y) })
+		univ.edited()
+		univ.paint()
+		reportSkipped(univ.skippedPins(image.Rectangle{}, p, x, y))
+		sounds.click()
+		pulse(stampPulse)
+		startRipple(univ.cellCenter(m.i, m.j))
+		m.close()
+	} else if !ui.Contains(m.rect, t.Loc) {
+		m.close()
+	}
+}
+
+// close closes m, unregisters its nodes and frees its texture.
+func (m *stampMenu) close() {
+	m.bar.Release()
+	for n := m.root.FirstChild; n != nil; n = m.root.FirstChild {
+		m.root.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	eng.Unregister(m.root)
+	m.tex.Unload()
+	views.menu = nil
+	releasePanel(stampMenuName)
+}
@@ -0,0 +1,176 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// A seedSource makes the universe the game starts from on launch, as told by cfg.startup.
+type seedSource interface {
+	// seed replaces the game of u by the one of the source, or returns an error, u left as it
+	// was, if the source has none.
+	seed(u *universe) error
+}
+
+// The startup sources of the config manifest, see startupSource.
+const (
+	randomStartup   = "random"   // A random universe, of cfg.seed if not 0.
+	autosaveStartup = "autosave" // The game saved as the app last went to the background.
+	patternStartup  = "pattern"  // The pattern of library named by cfg.startupName.
+	exportStartup   = "export"   // The cells of the export named by cfg.startupName, see export.
+	dailyStartup    = "daily"    // The random universe of the day.
+)
+
+// openedWith returns the link the app was opened with, empty if none. The tests replace it.
+var openedWith = launchLink
+
+// parseLink parses a golife:// link, of a run or else of a pattern, see share.
+func parseLink(s string) (*life.Pattern, *life.Run, error) {
+	if strings.HasPrefix(strings.TrimSpace(s), life.RunLinkPrefix) {
+		r, err := life.ParseRunLink(s)
+		return nil, r, err
+	}
+	p, err := life.ParseLink(s)
+	return p, nil, err
+}
+
+// startupSource returns the source of the universe on launch: the link the app was opened with if
+// any, which replaces the configured one, else the configured link, else the one told by
+// cfg.startup.
+func startupSource() seedSource {
+	if s := openedWith(); s != "" {
+		if p, r, err := parseLink(s); err != nil {
+			log.Printf("link opened with: %v", err)
+		} else {
+			cfg.link, cfg.run = p, r
+		}
+	}
+	if cfg.link != nil || cfg.run != nil {
+		return linkSource{}
+	}
+	switch cfg.startup {
+	case randomStartup:
+		return randomSource{}
+	case patternStartup:
+		return patternSource(cfg.startupName)
+	case exportStartup:
+		return exportSource(cfg.startupName)
+	case dailyStartup:
+		return dailySource(time.Now())
+	}
+	return autosaveSource{}
+}
+
+// startFrom replaces the game of u by the one of s, or else by a random one.
+func (u *universe) startFrom(s seedSource) {
+	err := s.seed(u)
+	if err == nil {
+		return
+	}
+	if !os.IsNotExist(err) {
+		log.Printf("starting from %T: %v", s, err)
+	}
+	randomSource{}.seed(u)
+}
+
+// randomSource makes a random universe, of cfg.seed if not 0. It never fails.
+type randomSource struct{}
+
+func (randomSource) seed(u *universe) error {
+	s := cfg.seed
+	if s == 0 {
+		s = rand.Int63n(maxSoupSeed) + 1
+	}
+	u.reseed(s)
+	return nil
+}
+
+// errNoLink tells no link is configured.
+var errNoLink = errors.New("no link configured")
+
+// linkSource starts the run or places the pattern of the link, see cfg.link and startupSource.
+type linkSource struct{}
+
+func (linkSource) seed(u *universe) error {
+	switch {
+	case cfg.run != nil:
+		u.startRun(cfg.run)
+		log.Printf("run of the link, seed %d, %d edits", cfg.run.Seed, len(cfg.run.Edits))
+	case cfg.link != nil:
+		u.place(cfg.link)
+		log.Printf("pattern of the link, %dx%d cells", cfg.link.W, cfg.link.H)
+	default:
+		return errNoLink
+	}
+	return nil
+}
+
+// autosaveSource restores the saved game, see universe.restore.
+type autosaveSource struct{}
+
+func (autosaveSource) seed(u *universe) error {
+	return u.restore()
+}
+
+// A patternSource places the pattern of library of the asset it names.
+type patternSource string
+
+func (s patternSource) seed(u *universe) error {
+	found := false
+	for _, p := range library {
+		found = found || p.asset == string(s)
+	}
+	if !found {
+		return fmt.Errorf("pattern %q not in the library", string(s))
+	}
+	p, err := readPattern(string(s))
+	if err != nil {
+		return err
+	}
+	u.place(p)
+	return nil
+}
+
+// An exportSource places the alive cells of the export it names, the RLE file written by export
+// without its extension in the directory of the snapshots.
+type exportSource string
+
+func (s exportSource) seed(u *universe) error {
+	f, err := os.Open(filepath.Join(exportDir(), filepath.Base(string(s))+".rle"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p, err := life.ParseRLE(f)
+	if err != nil {
+		return err
+	}
+	u.place(p)
+	return nil
+}
+
+// A dailySource seeds the random universe of its day, the same on every device, see dailySeed.
+type dailySource time.Time
+
+func (s dailySource) seed(u *universe) error {
+	u.reseed(dailySeed(time.Time(s)))
+	return nil
+}
+
+// dailySeed returns the seed of the random universe of the day of t, in its location: the days
+// take the seeds in turn.
+func dailySeed(t time.Time) int64 {
+	y, m, d := t.Date()
+	days := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+	return days%maxSoupSeed + 1
+}
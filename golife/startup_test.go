@@ -0,0 +1,161 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// startsFrom starts univ from s, a source with no seed, and reports whether it did rather than
+// from a random universe.
+func startsFrom(s seedSource) bool {
+	univ.startFrom(s)
+	return soupSeed == 0
+}
+
+// glider is a glider moving down and right.
+var glider = &life.Pattern{W: 3, H: 3, Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}}
+
+// day is the day of the tests of the daily seed, in the evening.
+var day = time.Date(2015, 6, 1, 18, 0, 0, 0, time.UTC)
+
+func TestStartupSources(t *testing.T) {
+	gestureScene(t)
+	inTempDir(t, func(dir string) {
+		for _, test := range []struct {
+			startup, name string
+			want          seedSource
+		}{
+			{randomStartup, "", randomSource{}},
+			{autosaveStartup, "", autosaveSource{}},
+			{patternStartup, "glider.rle", patternSource("glider.rle")},
+			{exportStartup, "golife-1", exportSource("golife-1")},
+		} {
+			cfg.startup, cfg.startupName = test.startup, test.name
+			if s := startupSource(); s != test.want {
+				t.Errorf("startup %s: source %T %v", test.startup, s, s)
+			}
+		}
+		cfg.startup = dailyStartup
+		if _, ok := startupSource().(dailySource); !ok {
+			t.Errorf("daily startup: source %T", startupSource())
+		}
+		// The configured link comes first.
+		cfg.link = glider
+		if s := startupSource(); s != seedSource(linkSource{}) {
+			t.Errorf("startup with a link: source %T", s)
+		}
+	})
+}
+
+func TestOpenedWith(t *testing.T) {
+	gestureScene(t)
+	defer func(f func() string) { openedWith = f }(openedWith)
+	link, err := glider.Link()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The link the app was opened with replaces the one of the manifest.
+	cell, _ := (&life.Pattern{W: 1, H: 1, Cells: [][2]int{{0, 0}}}).Link()
+	manifest := strings.NewReader(`{"link": "` + cell + `"}`)
+	if cfg, err = readConfig(cfg, "manifest", manifest); err != nil || cfg.link == nil {
+		t.Fatalf("link %q not configured: %v", cell, err)
+	}
+	openedWith = func() string { return link }
+	univ.startFrom(startupSource())
+	if got := alive(); len(got) != 5 || cfg.run != nil {
+		t.Errorf("opened with a link to %d cells, run %v", len(got), cfg.run)
+	}
+	// An invalid one is ignored.
+	openedWith = func() string { return life.LinkPrefix + "a*b" }
+	cfg.link, cfg.startup = nil, randomStartup
+	if s := startupSource(); s != seedSource(randomSource{}) {
+		t.Errorf("opened with an invalid link: source %T", s)
+	}
+}
+
+func TestRandomSource(t *testing.T) {
+	gestureScene(t)
+	cfg.seed = 5
+	univ.startFrom(randomSource{})
+	if soupSeed != 5 {
+		t.Errorf("seed %d, want 5", soupSeed)
+	}
+}
+
+func TestAutosaveSource(t *testing.T) {
+	gestureScene(t)
+	inTempDir(t, func(dir string) {
+		if startsFrom(autosaveSource{}) {
+			t.Fatalf("started without a save")
+		}
+		univ.place(glider)
+		if err := univ.save(); err != nil {
+			t.Fatal(err)
+		}
+		want := alive()
+		if !startsFrom(autosaveSource{}) || len(alive()) != len(want) {
+			t.Errorf("%d cells restored, want %d", len(alive()), len(want))
+		}
+	})
+}
+
+func TestPatternSource(t *testing.T) {
+	gestureScene(t)
+	if !startsFrom(patternSource(library[0].asset)) {
+		t.Errorf("%s not placed", library[0].asset)
+	}
+	if startsFrom(patternSource("missing.rle")) {
+		t.Errorf("pattern out of the library placed")
+	}
+}
+
+func TestExportSource(t *testing.T) {
+	gestureScene(t)
+	inTempDir(t, func(dir string) {
+		name := filepath.Join(exportDir(), "golife-1.rle")
+		if err := ioutil.WriteFile(name, []byte("x = 3, y = 3\nbo$2bo$3o!"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if !startsFrom(exportSource("golife-1")) || len(alive()) != 5 {
+			t.Errorf("export started with %d cells", len(alive()))
+		}
+		// Once deleted, the game starts from a random universe.
+		os.Remove(name)
+		if startsFrom(exportSource("golife-1")) {
+			t.Errorf("started from a deleted export")
+		}
+	})
+}
+
+func TestDailySource(t *testing.T) {
+	gestureScene(t)
+	univ.startFrom(dailySource(day))
+	if s := dailySeed(day); soupSeed != s || s < 1 || s > maxSoupSeed {
+		t.Errorf("seed %d of the day, want %d", soupSeed, s)
+	}
+	// The same all day long, another the next day.
+	morning, next := day.Add(-17*time.Hour), day.Add(24*time.Hour)
+	if dailySeed(morning) != dailySeed(day) || dailySeed(next) == dailySeed(day) {
+		t.Errorf("seeds %d in the morning, %d in the evening, %d the next day", dailySeed(morning),
+			dailySeed(day), dailySeed(next))
+	}
+}
+
+func TestLinkSource(t *testing.T) {
+	gestureScene(t)
+	if startsFrom(linkSource{}) {
+		t.Errorf("started from a link without one")
+	}
+	cfg.link = glider
+	if !startsFrom(linkSource{}) || len(alive()) != 5 {
+		t.Errorf("link placed with %d cells", len(alive()))
+	}
+}
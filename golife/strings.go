@@ -0,0 +1,136 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// The strings the app tells the user, such as the labels spoken in accessibility mode, are looked
+// up by key in string tables, one per locale. The table of a locale is the asset strings_<locale>.json,
+// a JSON object mapping each key to its string, for instance strings_es.json or strings_pt_BR.json,
+// whose strings may take fmt verbs. A key missing from it is looked up in the table of its
+// language, then in the English one, which must have every key.
+
+// languages are the languages of the string tables the app ships, each at least with a table for
+// the language alone. The first one is the fallback of the others.
+var languages = []string{"en", "es"}
+
+// texts is the string table in use, the fallbacks merged in. Until loadStrings, the keys are
+// their own strings.
+var texts map[string]string
+
+// stringsAsset returns the name of the string table of locale.
+func stringsAsset(locale string) string {
+	return "strings_" + locale + ".json"
+}
+
+// text returns the string of the given key in the table in use, formatted with args if any, or the
+// key itself if no table has it.
+func text(key string, args ...interface{}) string {
+	s, ok := texts[key]
+	if !ok {
+		s = key
+	}
+	if len(args) > 0 {
+		s = fmt.Sprintf(s, args...)
+	}
+	return s
+}
+
+// loadStrings makes the table of locale, as told by the config or else the system, the one in use,
+// see text, along with its fallbacks, and sets rightToLeft as it is written.
+func loadStrings() {
+	locale := cfg.locale
+	if locale == "" {
+		var err error
+		if locale, err = systemLocale(); err != nil {
+			log.Printf("locale: %v, using %s", err, languages[0])
+		}
+	}
+	locale = normalLocale(locale)
+	chain := []string{languages[0]}
+	if lang := strings.SplitN(locale, "_", 2)[0]; lang != languages[0] && lang != "" {
+		chain = append(chain, lang)
+	}
+	if strings.Contains(locale, "_") {
+		chain = append(chain, locale)
+	}
+	rightToLeft = rtlLanguages[strings.SplitN(locale, "_", 2)[0]]
+	texts = make(map[string]string)
+	for _, l := range chain {
+		t, err := readStrings(l)
+		if err != nil {
+			if !os.IsNotExist(err) || l == languages[0] {
+				log.Printf("%s: %v", stringsAsset(l), err)
+			}
+			continue
+		}
+		for k, s := range t {
+			texts[k] = s
+		}
+	}
+	log.Printf("locale %s", locale)
+}
+
+// readStrings returns the string table of locale.
+func readStrings(locale string) (map[string]string, error) {
+	a, err := openAsset(stringsAsset(locale))
+	if err != nil {
+		return nil, err
+	}
+	defer a.Close()
+	var t map[string]string
+	if err := json.NewDecoder(a).Decode(&t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// normalLocale returns locale, as given by the system for instance "es-MX" or "es_MX.UTF-8", in
+// the form of the names of the string tables, "es_MX", or the fallback language if it names none,
+// as "C" does.
+func normalLocale(locale string) string {
+	if k := strings.IndexAny(locale, ".@"); k >= 0 {
+		locale = locale[:k]
+	}
+	locale = strings.Replace(locale, "-", "_", -1)
+	parts := strings.SplitN(locale, "_", 2)
+	if !isLocale(locale) {
+		if len(parts) > 0 && isLocale(parts[0]) {
+			return strings.ToLower(parts[0])
+		}
+		return languages[0]
+	}
+	if len(parts) == 2 {
+		return strings.ToLower(parts[0]) + "_" + strings.ToUpper(parts[1])
+	}
+	return strings.ToLower(locale)
+}
+
+// isLocale reports whether s is a language of 2 or 3 letters, followed by a region of 2 letters
+// after an underscore if any.
+func isLocale(s string) bool {
+	letters := func(s string, n ...int) bool {
+		for _, c := range s {
+			if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+				return false
+			}
+		}
+		for _, m := range n {
+			if len(s) == m {
+				return true
+			}
+		}
+		return false
+	}
+	parts := strings.SplitN(s, "_", 2)
+	if !letters(parts[0], 2, 3) {
+		return false
+	}
+	return len(parts) == 1 || letters(parts[1], 2)
+}
@@ -0,0 +1,146 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/mobile/app"
+)
+
+// verbs matches the fmt verbs of a string.
+var verbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestStringTables(t *testing.T) {
+	en, err := readStrings(languages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lang := range languages {
+		table, err := readStrings(lang)
+		if err != nil {
+			t.Errorf("%s: %v", lang, err)
+			continue
+		}
+		for k, s := range en {
+			l, ok := table[k]
+			switch {
+			case !ok:
+				t.Errorf("%s: missing %q", stringsAsset(lang), k)
+			case l == "":
+				t.Errorf("%s: empty %q", stringsAsset(lang), k)
+			case strings.Join(verbs.FindAllString(l, -1), " ") != strings.Join(verbs.FindAllString(s, -1), " "):
+				t.Errorf("%s: %q formats %q, the English one %q", stringsAsset(lang), k, l, s)
+			}
+		}
+		for k := range table {
+			if _, ok := en[k]; !ok {
+				t.Errorf("%s: %q is not an English key", stringsAsset(lang), k)
+			}
+		}
+	}
+	// The labels are those of buttons.
+	images := make(map[string]bool)
+	for _, img := range atlasImages {
+		images[img] = true
+	}
+	for k := range en {
+		if strings.HasPrefix(k, "button.") && !images[strings.TrimPrefix(k, "button.")] {
+			t.Errorf("label %q of no button", k)
+		}
+	}
+}
+
+// A stringAsset is an asset read from a string.
+type stringAsset struct{ *strings.Reader }
+
+func (stringAsset) Close() error { return nil }
+
+func TestLoadStrings(t *testing.T) {
+	defer func(c config, open func(string) (app.ReadSeekCloser, error)) { cfg, openAsset = c, open }(cfg, openAsset)
+	assets := map[string]string{
+		stringsAsset("en"):    `{"play": "play", "pause": "pause", "speed": "%v generations per second"}`,
+		stringsAsset("es"):    `{"play": "reanudar", "pause": "pausa"}`,
+		stringsAsset("es_MX"): `{"pause": "pausar"}`,
+	}
+	openAsset = func(name string) (app.ReadSeekCloser, error) {
+		if s, ok := assets[name]; ok {
+			return stringAsset{strings.NewReader(s)}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	for _, test := range []struct {
+		locale             string
+		play, pause, speed string
+	}{
+		{"en", "play", "pause", "2 generations per second"},
+		{"es", "reanudar", "pausa", "2 generations per second"},
+		{"es_MX", "reanudar", "pausar", "2 generations per second"},
+		{"es_AR", "reanudar", "pausa", "2 generations per second"},
+		{"fr_FR", "play", "pause", "2 generations per second"},
+	} {
+		cfg.locale = test.locale
+		loadStrings()
+		if play, pause, speed := text("play"), text("pause"), text("speed", 2); play != test.play ||
+			pause != test.pause || speed != test.speed {
+			t.Errorf("%s: %q, %q and %q, want %q, %q and %q", test.locale, play, pause, speed,
+				test.play, test.pause, test.speed)
+		}
+	}
+	if s := text("missing"); s != "missing" {
+		t.Errorf("missing key is %q", s)
+	}
+
+	// Without a locale in the config, the one of the system is used.
+	cfg.locale = ""
+	defer os.Setenv("LC_ALL", os.Getenv("LC_ALL"))
+	os.Setenv("LC_ALL", "es_MX.UTF-8")
+	loadStrings()
+	if s := text("pause"); s != "pausar" {
+		t.Errorf("pause is %q in the system locale es_MX.UTF-8", s)
+	}
+}
+
+func TestLocaleSetting(t *testing.T) {
+	defer func(c config) { cfg = c; loadStrings() }(cfg)
+	var s setting
+	for _, s = range settings {
+		if s.icon() == localeImage {
+			break
+		}
+	}
+	cfg.locale = ""
+	// From the system locale, the setting cycles through languages and back.
+	for _, want := range []string{"en", "es", ""} {
+		s.change(1)
+		if cfg.locale != want {
+			t.Fatalf("locale %q, want %q", cfg.locale, want)
+		}
+	}
+	s.change(-1)
+	if cfg.locale != "es" || s.value() != 2 || text("pattern.glider") != "planeador" {
+		t.Errorf("locale %q of value %d, glider is %q", cfg.locale, s.value(), text("pattern.glider"))
+	}
+}
+
+func TestNormalLocale(t *testing.T) {
+	for locale, want := range map[string]string{
+		"es":          "es",
+		"es_MX":       "es_MX",
+		"es-mx":       "es_MX",
+		"ES_mx.UTF-8": "es_MX",
+		"pt_BR@euro":  "pt_BR",
+		"fil_PH":      "fil_PH",
+		"es_419":      "es",
+		"C":           "en",
+		"POSIX":       "en",
+		"":            "en",
+	} {
+		if got := normalLocale(locale); got != want {
+			t.Errorf("locale %q normalized to %q, want %q", locale, got, want)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+/*
+Binary term runs the game of golife in a terminal, to try rules, edge modes and patterns on a
+desktop without deploying the app to a device. It steps the universe with the same life package
+as the app.
+
+	term -rule B36/S23 -edges bounded -pattern ../assets/glider_gun.rle
+
+With -n, it instead steps the given number of generations without drawing and prints the
+resulting population and hash, for scripts and CI:
+
+	term -seed 7 -n 1000
+
+Long runs of large fields of the bounded edge mode are stepped by the HashLife algorithm, unless
+traced, see life.NewStepper.
+
+With -replay, it replays a session exported by the app as a .golife file, at the pace it was played
+at, and stops at its end. With -n too, it replays the whole session without drawing:
+
+	term -replay golife-20150412-183005.golife -n 1
+
+With -trace, it also writes the cells born, dead and decayed by every step to a file, or to the
+standard output given -, one per line as the generation stepped to, the event and the coordinates
+of the cell:
+
+	term -pattern glider.rle -n 4 -trace -
+
+With -search, it plays the given number of random fields, numbered by their seed from -seed or 1,
+for -n generations at most each or 1000, without drawing, and prints the best ones by the
+criterion of -by: the longest to stabilize, the highest peak population, the longest period once
+stable, the longest-lived for their initial population, as methuselahs, or the most cells escaped
+from the field in the grow edge mode. The fields are seeded as
+by the app, so that a seed found loads in the app, on a field of the same size and settings. An
+interrupt stops the search and prints the soups played so far:
+
+	term -cols 60 -rows 90 -search 5000 -by methuselah -top 5
+*/
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+var (
+	cols     = flag.Int("cols", 80, "width of the field, in cells")
+	rows     = flag.Int("rows", 48, "height of the field, in cells")
+	rule     = flag.String("rule", "B3/S23", "rule, in B/S notation, or B/S/C for the Generations rules")
+	edges    = flag.String("edges", "wrap", "edge mode: wrap, bounded, reflect or grow")
+	density  = flag.Float64("density", 0.25, "probability of a cell being alive in a random field")
+	seedMode = flag.String("seedMode", "random", "layout of the random cells, as in the seedMode config key")
+	seed     = flag.Int64("seed", 0, "seed of the random field, 0 for a random one")
+	pattern  = flag.String("pattern", "", "file of a pattern to place alone, centered, in the .rle, .cells or .lif format, or a golife:// link")
+	speed    = flag.Float64("speed", 10, "generations per second")
+	gens     = flag.Int("n", 0, "if not 0, number of generations to step without drawing")
+	parallel = flag.Int("parallel", 0, "size of the smallest field stepped concurrently, 0 for the default")
+	colors   = flag.Int("colors", 1, "number of colors of the cells: 2 plays Immigration, 4 QuadLife")
+	replay   = flag.String("replay", "", "file of a session exported by the app, in the .golife format, to replay in place of a universe")
+	trace    = flag.String("trace", "", "file to write the births, deaths and decays of every step to, - for the standard output")
+	search   = flag.Int("search", 0, "if not 0, number of random fields to search for the interesting soups, of the seeds from -seed, each stepped -n generations at most")
+	by       = flag.String("by", "lifespan", "criterion the soups searched are ranked by: lifespan, peak, period, methuselah or escaped")
+	top      = flag.Int("top", 10, "number of the best soups searched to print")
+	workers  = flag.Int("workers", 0, "number of soups searched concurrently, 0 for one per CPU")
+)
+
+// edgeModes are the edge modes by name, as in the edges config key.
+var edgeModes = map[string]life.EdgeMode{
+	"wrap": life.Wrap, "bounded": life.Bounded, "reflect": life.Reflect, "grow": life.Grow,
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("term: ")
+	flag.Parse()
+	runtime.GOMAXPROCS(runtime.NumCPU())
+
+	if *replay != "" {
+		if err := replaySession(*replay); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *search > 0 {
+		if err := searchSoups(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	l, err := newLife()
+	if err != nil {
+		log.Fatal(err)
+	}
+	flush, done, err := startTrace(l)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *gens > 0 {
+		life.NewStepper(l, *gens).Advance(*gens)
+		if err := done(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("generation %d, population %d%s, hash %016x\n", l.Generation(), l.Population(), byColor(l), l.Hash())
+		return
+	}
+	if *speed <= 0 {
+		log.Fatalf("speed %v not positive", *speed)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	// Clear the screen once, then draw every generation over the previous one.
+	fmt.Fprint(w, "\x1b[2J")
+	for tick := time.Tick(time.Duration(float64(time.Second) / *speed)); ; <-tick {
+		fmt.Fprint(w, "\x1b[H")
+		draw(w, l)
+		fmt.Fprintf(w, "generation %d, population %d%s\x1b[K\n", l.Generation(), l.Population(), byColor(l))
+		if err := w.Flush(); err != nil {
+			log.Fatal(err)
+		}
+		l.Step()
+		if err := flush(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// replaySession replays the session journaled in the file name, drawn at its pace unless -n is
+// given.
+func replaySession(name string) error {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	var j life.Journal
+	if err := j.UnmarshalBinary(b); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	r, err := j.Replay()
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	l := r.Life
+	l.ParallelCells = *parallel
+	flush, done, err := startTrace(l)
+	if err != nil {
+		return err
+	}
+	if *gens > 0 {
+		for !r.Done() {
+			r.Step()
+		}
+		if err := done(); err != nil {
+			return err
+		}
+		fmt.Printf("%d steps replayed: generation %d, population %d%s, hash %016x\n", j.Steps(), l.Generation(),
+			l.Population(), byColor(l), l.Hash())
+		return nil
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprint(w, "\x1b[2J")
+	for step := 0; ; step++ {
+		fmt.Fprint(w, "\x1b[H")
+		draw(w, l)
+		fmt.Fprintf(w, "step %d of %d, generation %d, population %d%s\x1b[K\n", step, j.Steps(), l.Generation(),
+			l.Population(), byColor(l))
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if r.Done() {
+			return done()
+		}
+		s := r.Speed
+		if s <= 0 {
+			s = *speed
+		}
+		if s <= 0 {
+			return fmt.Errorf("speed %v not positive", s)
+		}
+		time.Sleep(time.Duration(float64(time.Second) / s))
+		r.Step()
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// startTrace has the events of l written to the file told by -trace, if any, and returns the
+// functions flushing the events written since the last call, and flushing them and closing the file
+// once done.
+func startTrace(l *life.Life) (flush, done func() error, err error) {
+	if *trace == "" {
+		nop := func() error { return nil }
+		return nop, nop, nil
+	}
+	f := os.Stdout
+	if *trace != "-" {
+		if f, err = os.Create(*trace); err != nil {
+			return nil, nil, err
+		}
+	}
+	w := bufio.NewWriter(f)
+	l.Listener = &tracer{w: w, l: l}
+	done = func() error {
+		err := w.Flush()
+		if f == os.Stdout {
+			return err
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+	return w.Flush, done, nil
+}
+
+// A tracer writes the events of the cells of a game, its Listener, to w, one per line: the generation
+// stepped to, told before the step increments the generation count, birth, death or decay, and the
+// coordinates of the cell.
+type tracer struct {
+	w io.Writer
+	l *life.Life
+}
+
+func (t *tracer) OnBirth(x, y int) { fmt.Fprintf(t.w, "%d birth %d %d\n", t.l.Generation()+1, x, y) }
+func (t *tracer) OnDeath(x, y int) { fmt.Fprintf(t.w, "%d death %d %d\n", t.l.Generation()+1, x, y) }
+func (t *tracer) OnDecay(x, y int) { fmt.Fprintf(t.w, "%d decay %d %d\n", t.l.Generation()+1, x, y) }
+
+// newLife returns the universe told by the flags.
+func newLife() (*life.Life, error) {
+	l, err := newField()
+	if err != nil {
+		return nil, err
+	}
+	if *pattern != "" {
+		p, err := readPattern(*pattern)
+		if err != nil {
+			return nil, err
+		}
+		if p.Rule != (life.Rule{}) {
+			l.Rule = p.Rule
+		}
+		l.Stamp(p, (*cols-p.W)/2, (*rows-p.H)/2)
+		return l, nil
+	}
+	m, err := life.ParseSeedMode(*seedMode)
+	if err != nil {
+		return nil, err
+	}
+	s := *seed
+	if s == 0 {
+		s = time.Now().UnixNano()
+	}
+	l.SeedWith(*density, m, s)
+	return l, nil
+}
+
+// newField returns a field of dead cells of the size, rule, colors and edge mode told by the flags.
+func newField() (*life.Life, error) {
+	if *cols < 1 || *rows < 1 {
+		return nil, fmt.Errorf("field of %dx%d cells", *cols, *rows)
+	}
+	l := life.NewSparse(*cols, *rows)
+	r, err := life.ParseRule(*rule)
+	if err != nil {
+		return nil, err
+	}
+	l.Rule = r
+	l.ParallelCells = *parallel
+	if *colors < 1 || *colors > life.MaxColors {
+		return nil, fmt.Errorf("colors %d out of range [1, %d]", *colors, life.MaxColors)
+	}
+	l.Colors = *colors
+	e, ok := edgeModes[*edges]
+	if !ok {
+		return nil, fmt.Errorf("unknown edge mode %q", *edges)
+	}
+	l.Edges = e
+	return l, nil
+}
+
+// byColor returns the population of each color of l, if it has several, as a suffix of the
+// population.
+func byColor(l *life.Life) string {
+	if l.Colors < 2 {
+		return ""
+	}
+	s := " ("
+	for c := 0; c < l.Colors; c++ {
+		if c > 0 {
+			s += " "
+		}
+		s += fmt.Sprint(l.ColorPopulation(c))
+	}
+	return s + ")"
+}
+
+// readPattern reads the pattern file name, in the format told by its extension as for the pattern
+// assets of the app, or parses name if it is a golife:// link.
+func readPattern(name string) (*life.Pattern, error) {
+	if strings.HasPrefix(name, life.LinkPrefix) {
+		return life.ParseLink(name)
+	}
+	parse := life.ParseRLE
+	switch filepath.Ext(name) {
+	case ".rle":
+	case ".cells":
+		parse = life.ParseCells
+	case ".lif", ".life":
+		parse = life.ParseLife106
+	default:
+		return nil, fmt.Errorf("unknown pattern format %q", filepath.Ext(name))
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parse(f)
+}
+
+// halfBlocks are the characters showing two rows of cells, by whether the top and bottom ones are
+// alive.
+var halfBlocks = [2][2]string{{" ", "▄"}, {"▀", "█"}}
+
+// draw writes the cells of l to w, two rows per line of text.
+func draw(w io.Writer, l *life.Life) {
+	cw, ch := l.Bounds()
+	b := make([]byte, 0, 3*cw+1)
+	for y := 0; y < ch; y += 2 {
+		b = b[:0]
+		for x := 0; x < cw; x++ {
+			top, bottom := 0, 0
+			if l.Alive(x, y) {
+				top = 1
+			}
+			if y+1 < ch && l.Alive(x, y+1) {
+				bottom = 1
+			}
+			b = append(b, halfBlocks[top][bottom]...)
+		}
+		b = append(b, '\n')
+		w.Write(b)
+	}
+}
@@ -0,0 +1,47 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+func TestTrace(t *testing.T) {
+	// A blinker turning vertical then horizontal again.
+	l := life.New(5, 5)
+	for x := 1; x <= 3; x++ {
+		l.Set(x, 2, true)
+	}
+	var b bytes.Buffer
+	l.Listener = &tracer{w: &b, l: l}
+	l.Step()
+	l.Step()
+	want := `1 birth 2 1
+1 death 1 2
+1 death 3 2
+1 birth 2 3
+2 death 2 1
+2 birth 1 2
+2 birth 3 2
+2 death 2 3
+`
+	if b.String() != want {
+		t.Errorf("traced\n%swant\n%s", b.String(), want)
+	}
+}
+
+func TestTraceDecay(t *testing.T) {
+	l := life.New(3, 3)
+	l.Rule, _ = life.ParseRule("B/S/C3")
+	l.Set(1, 1, true)
+	var b bytes.Buffer
+	l.Listener = &tracer{w: &b, l: l}
+	l.Step()
+	l.Step()
+	if want := "1 death 1 1\n2 decay 1 1\n"; b.String() != want {
+		t.Errorf("traced\n%swant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,71 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// searchGenerations is the number of generations each soup is stepped for at most, unless -n
+// tells another, as the searchGenerations config key of the app.
+const searchGenerations = 1000
+
+// searchSoups searches the soups told by the flags, until done or interrupted, and prints the best
+// ones by the criterion of -by.
+func searchSoups() error {
+	less, ok := life.SoupOrders[*by]
+	if !ok {
+		return fmt.Errorf("unknown criterion %q", *by)
+	}
+	if _, err := newField(); err != nil {
+		return err
+	}
+	m, err := life.ParseSeedMode(*seedMode)
+	if err != nil {
+		return err
+	}
+	stop := make(chan struct{})
+	s := &life.SoupSearch{
+		Field: func() *life.Life {
+			// Checked above.
+			l, _ := newField()
+			return l
+		},
+		Density:     *density,
+		Mode:        m,
+		Generations: *gens,
+		Workers:     *workers,
+		Stop:        stop,
+	}
+	if s.Generations <= 0 {
+		s.Generations = searchGenerations
+	}
+	first := *seed
+	if first == 0 {
+		first = 1
+	}
+
+	// An interrupt stops the search, which prints the soups played so far.
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+	found := s.Run(first, *search)
+	life.SortSoups(found, less)
+	fmt.Printf("%d soups of %d played, %d generations at most, by %s:\n", len(found), *search, s.Generations, *by)
+	for k, o := range found {
+		if k == *top {
+			break
+		}
+		fmt.Printf("%d. seed %d: lifespan %d, period %d, peak %d, methuselah %.2f\n", k+1, o.Seed, o.Lifespan,
+			o.Period, o.Peak, o.Methuselah())
+	}
+	return nil
+}
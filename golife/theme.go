@@ -0,0 +1,118 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+)
+
+// A theme is a set of colors the screen is drawn with.
+type theme struct {
+	name        string
+	background  color.RGBA // Behind the cells and buttons.
+	outOfBounds color.RGBA // The screen area outside the field.
+	// Colors of the border lines, by edge mode.
+	border, wrapBorder, reflectBorder, growBorder color.RGBA
+	// icons tints the images of the buttons and digits, which are left as is if it is zero.
+	icons color.NRGBA
+}
+
+// themes are the themes the theme button cycles through, the first one being the default.
+var themes = []theme{
+	{
+		name:          "light",
+		background:    color.RGBA{0xff, 0xff, 0xff, 0xff},
+		outOfBounds:   color.RGBA{0xdd, 0xdd, 0xdd, 0xff},
+		border:        color.RGBA{0x66, 0x66, 0x66, 0xff},
+		wrapBorder:    color.RGBA{0x33, 0x99, 0xcc, 0xff},
+		reflectBorder: color.RGBA{0x99, 0x66, 0xcc, 0xff},
+		growBorder:    color.RGBA{0x33, 0xaa, 0x66, 0xff},
+	},
+	{
+		name:          "dark",
+		background:    color.RGBA{0x12, 0x12, 0x12, 0xff},
+		outOfBounds:   color.RGBA{0x2a, 0x2a, 0x2a, 0xff},
+		border:        color.RGBA{0x99, 0x99, 0x99, 0xff},
+		wrapBorder:    color.RGBA{0x55, 0xbb, 0xee, 0xff},
+		reflectBorder: color.RGBA{0xbb, 0x88, 0xee, 0xff},
+		growBorder:    color.RGBA{0x55, 0xcc, 0x88, 0xff},
+		icons:         color.NRGBA{0xcc, 0xcc, 0xcc, 0xff},
+	},
+}
+
+// currentTheme is the index in themes of the theme the screen is drawn with.
+var currentTheme int
+
+// themeSources holds the images of the assets tinted by the theme as decoded, to tint them again
+// when the theme changes.
+var themeSources = make(map[string]image.Image)
+
+// parseTheme returns the index in themes of the theme of the given name.
+func parseTheme(name string) (int, error) {
+	for k, t := range themes {
+		if t.name == name {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown theme %q", name)
+}
+
+// themed returns the image img of the asset of the given name as drawn with the current theme, in
+// high contrast with the large controls.
+func themed(name string, img image.Image) image.Image {
+	if name != buttonsImage && name != digitsImage {
+		return img
+	}
+	themeSources[name] = img
+	if cfg.large {
+		return contrasted(img)
+	}
+	if c := themes[currentTheme].icons; c != (color.NRGBA{}) {
+		return tinted(img, c)
+	}
+	return img
+}
+
+// swatches returns the solid color swatches of the current theme, 4 px wide each. Only their
+// centers are used so that texture filtering does not bleed neighboring colors.
+func swatches() (names []string, img image.Image) {
+	t := themes[currentTheme]
+	colors := []color.Color{t.outOfBounds, t.border, t.wrapBorder, t.reflectBorder, t.growBorder, t.background}
+	names = []string{outOfBoundsImage, borderImage, wrapBorderImage, reflectBorderImage, growBorderImage,
+		backgroundImage}
+	rgba := image.NewRGBA(image.Rect(0, 0, 4*len(colors), 4))
+	for x := 0; x < rgba.Bounds().Dx(); x++ {
+		for y := 0; y < 4; y++ {
+			rgba.Set(x, y, colors[x/4])
+		}
+	}
+	return names, rgba
+}
+
+// setTheme switches to the theme of index k in themes. The textures it affects are uploaded again,
+// which updates the nodes showing them.
+func setTheme(k int) {
+	currentTheme = k
+	log.Printf("theme %s", themes[k].name)
+	if textures == nil {
+		return
+	}
+	_, img := swatches()
+	textures[outOfBoundsImage].T.Upload(img.Bounds(), img)
+	img = rippleStrip()
+	textures[rippleFrameImage(0)].T.Upload(img.Bounds(), img)
+	if spark != nil {
+		// Drawn over the color of the screen area outside the field.
+		spark.dirty = true
+	}
+	for name, src := range themeSources {
+		img := themed(name, src)
+		textures[name].T.Upload(img.Bounds(), img)
+		if name == buttonsImage {
+			textures[disabledImage(name)].T.Upload(img.Bounds(), dimmed(img))
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"time"
+
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// toastTime is how long a toast shows.
+const toastTime = 3 * time.Second
+
+// A toast tells the user a line of text, below the button bar, for toastTime: the outcome of an
+// action with nothing else to show it, as importing a profile.
+type toast struct {
+	text  string
+	end   time.Time
+	nodes []*sprite.Node // Nil until shown by draw.
+	tex   sprite.Texture // Of the text, nil until shown.
+}
+
+// showToast shows a toast of s in place of the one showing, if any, by the next frame.
+func showToast(s string) {
+	views.popup.release()
+	views.popup = &toast{text: s, end: time.Now().Add(toastTime)}
+	log.Printf("toast: %s", s)
+}
+
+// updateToast builds the toast if told since the last frame, or hides it once shown long enough. It
+// is called every frame.
+func updateToast() {
+	p := views.popup
+	switch {
+	case p == nil:
+	case time.Now().After(p.end):
+		p.release()
+		views.popup = nil
+	case p.nodes == nil:
+		p.build()
+	}
+}
+
+// build adds the nodes of p to the scene, its text in the bitmap font as high as the buttons, cut at
+// the edges of the screen, over a dark box.
+func (p *toast) build() {
+	img := image.NewRGBA(image.Rect(0, 0, textWidth(p.text, pickerScale), pickerPx))
+	drawText(img, p.text, 0, (pickerPx-glyphHeight*pickerScale)/2, pickerScale, color.White)
+	tex, err := eng.LoadTexture(img)
+	if err != nil {
+		log.Printf("toast: %v", err)
+		return
+	}
+	p.tex = tex
+	line, pad := buttonSize, buttonSep
+	w := geom.Pt(img.Bounds().Dx()) * line / pickerPx
+	cut := img.Bounds()
+	if room := geom.Width - 4*pad; w > room {
+		cut.Max.X = int(room * pickerPx / line)
+		w = room
+	}
+	x, y := (geom.Width-w)/2, buttonBarHeight+2*pad
+	add := func(sub sprite.SubTex, x, y, w, h geom.Pt) {
+		n := &sprite.Node{}
+		eng.Register(n)
+		scene.AppendChild(n)
+		eng.SetTransform(n, f32.Affine{{float32(w), 0, float32(x)}, {0, float32(h), float32(y)}})
+		eng.SetSubTex(n, sub)
+		p.nodes = append(p.nodes, n)
+	}
+	add(*textures[outOfBoundsImage], x-pad, y-pad, w+2*pad, line+2*pad)
+	add(sprite.SubTex{tex, cut}, x, y, w, line)
+}
+
+// release removes p, if shown, from the scene, unregisters its nodes and frees its texture. It does
+// nothing if p is nil.
+func (p *toast) release() {
+	if p == nil {
+		return
+	}
+	for _, n := range p.nodes {
+		scene.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	if p.tex != nil {
+		p.tex.Unload()
+	}
+	p.nodes, p.tex = nil, nil
+}
@@ -0,0 +1,85 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import "fmt"
+
+// maxTrail is the longest trail, in generations, see trail.
+const maxTrail = 9
+
+// trailLevels is the number of images of the fading cells, each less opaque than androidImage, the
+// faintest first.
+const trailLevels = 4
+
+// A trail fades out the cells that just died over cfg.trail generations rather than showing them
+// dead at once, so that moving patterns such as gliders leave visible trails. It only concerns how
+// the cells are shown, and not under the rules whose dying cells already decay, see
+// life.Rule.States.
+type trail struct {
+	// fade holds the number of generations each cell has left to fade out, 0 once dead or alive.
+	// It is allocated by the first trail.
+	fade  []uint8
+	cells []int // Indexes of the fading cells.
+}
+
+// clear removes every trail, without repainting the cells.
+func (t *trail) clear() {
+	for _, k := range t.cells {
+		t.fade[k] = 0
+	}
+	t.cells = t.cells[:0]
+}
+
+// level returns the index from 1 of the image of the fading cell at index k, see trailLevelImage,
+// or 0 if it is not fading.
+func (t *trail) level(k int) int {
+	if t.fade == nil || t.fade[k] == 0 || cfg.trail == 0 {
+		return 0
+	}
+	l := (int(t.fade[k])*trailLevels + cfg.trail - 1) / cfg.trail
+	if l > trailLevels {
+		l = trailLevels
+	}
+	return l
+}
+
+// startTrail starts fading out the cell (i, j) of u, which just died.
+func (u *universe) startTrail(i, j int) {
+	if cfg.trail == 0 || u.life.Rule.States > 2 {
+		return
+	}
+	t, k := &u.trail, j*u.cols+i
+	if t.fade == nil {
+		t.fade = make([]uint8, u.cols*u.rows)
+	}
+	if t.fade[k] == 0 {
+		t.cells = append(t.cells, k)
+	}
+	t.fade[k] = uint8(cfg.trail)
+}
+
+// fadeTrails moves the trails of u a generation on, and shows the fading cells. It is called before
+// every step, so that the cells dying meanwhile start fading from the next one.
+func (u *universe) fadeTrails() {
+	t := &u.trail
+	cells := t.cells[:0]
+	for _, k := range t.cells {
+		i, j := k%u.cols, k/u.cols
+		if u.life.Alive(i, j) {
+			// Born again.
+			t.fade[k] = 0
+			continue
+		}
+		t.fade[k]--
+		u.show(i, j, false)
+		if t.fade[k] > 0 {
+			cells = append(cells, k)
+		}
+	}
+	t.cells = cells
+}
+
+// trailLevelImage returns the name of the image of the fading cells of the given level, from 1.
+func trailLevelImage(level int) string {
+	return fmt.Sprintf("trail%d", level)
+}
@@ -0,0 +1,257 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"math"
+
+	"github.com/vegacom/mobile/ui"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// The tutorial guides the user through the first steps on the first launch: bringing a cell to
+// life, playing the game and changing its speed. Each step waits for a command, as told by tutor,
+// from the taps on the buttons and the painting of cells. It can be skipped at any point and, once
+// over, is not shown again, see cfg.tutorialDone.
+
+// cellCommand is the command told the tutorial once a stroke painted cells.
+const cellCommand = "cell"
+
+// A tutorialStep is a step of the tutorial.
+type tutorialStep struct {
+	key  string   // Key of its instruction in the string tables.
+	cmds []string // Commands completing it, any of them.
+}
+
+// tutorialSteps are the steps of the tutorial, in order.
+var tutorialSteps = []tutorialStep{
+	{"tutorial.cell", []string{cellCommand}},
+	{"tutorial.play", []string{playImage}},
+	{"tutorial.speed", []string{decSpeedImage, incSpeedImage}},
+}
+
+// A tutorial is the progress through tutorialSteps.
+type tutorial struct {
+	step    int // Index of the current step, len(tutorialSteps) once completed.
+	skipped bool
+}
+
+// saw tells t of the command cmd, which completes the current step if it is one of its commands,
+// and reports whether it did.
+func (t *tutorial) saw(cmd string) bool {
+	if t.over() {
+		return false
+	}
+	for _, c := range tutorialSteps[t.step].cmds {
+		if c == cmd {
+			t.step++
+			return true
+		}
+	}
+	return false
+}
+
+// over reports whether t was completed or skipped.
+func (t *tutorial) over() bool {
+	return t.skipped || t.step >= len(tutorialSteps)
+}
+
+// A tutorialOverlay shows the tutorial in progress over the scene, above the tool bar: the
+// instruction of its step, a dot for each step, dimmed until reached, and the skip button, along
+// with a ring around the control of the step. See views.tour.
+type tutorialOverlay struct {
+	shown   int // Step shown by the nodes, -1 if none.
+	nodes   []*sprite.Node
+	tex     sprite.Texture // Of the instruction, nil if none.
+	buttons *ui.Bar
+}
+
+// tour is the tutorial in progress, nil if none.
+var tour *tutorial
+
+// startTutorial starts the tutorial unless done, pausing the game for cells to be painted.
+func startTutorial() {
+	if cfg.tutorialDone {
+		return
+	}
+	tour = &tutorial{}
+	setPaused(true)
+	log.Printf("tutorial started")
+}
+
+// tutor tells the tutorial in progress, if any, of the command cmd: the face of a button tapped,
+// see face, or cellCommand.
+func tutor(cmd string) {
+	if tour != nil && tour.saw(cmd) {
+		log.Printf("tutorial step %d of %d done", tour.step, len(tutorialSteps))
+	}
+}
+
+// updateTutorial shows the step the tutorial is at and, once over, drops it for good. It is called
+// every frame.
+func updateTutorial() {
+	t := tour
+	switch {
+	case t == nil:
+	case t.over():
+		views.tour.release()
+		tour, views.tour = nil, nil
+		cfg.tutorialDone = true
+		if err := saveSettings(); err != nil {
+			log.Printf("saving the settings: %v", err)
+		}
+		log.Printf("tutorial over, skipped %v", t.skipped)
+	case views.tour == nil || views.tour.shown != t.step:
+		views.tour.release()
+		views.tour = &tutorialOverlay{}
+		views.tour.build(t.step)
+	}
+}
+
+// build adds the nodes of t showing the given step.
+func (t *tutorialOverlay) build(step int) {
+	t.shown = step
+	add := func(parent *sprite.Node, sub sprite.SubTex, a f32.Affine) {
+		n := &sprite.Node{}
+		eng.Register(n)
+		parent.AppendChild(n)
+		eng.SetSubTex(n, sub)
+		eng.SetTransform(n, a)
+		t.nodes = append(t.nodes, n)
+	}
+	// The scene is below the system bar.
+	rect := func(sub sprite.SubTex, r geom.Rectangle) {
+		add(scene, sub, f32.Affine{
+			{float32(r.Max.X - r.Min.X), 0, float32(r.Min.X)},
+			{0, float32(r.Max.Y - r.Min.Y), float32(r.Min.Y - systemBarHeight)},
+		})
+	}
+
+	// The ring goes around the controls of the step or, for the first one, around the cell at the
+	// center of the field, a cell away, following the camera as the cells do.
+	if r, ok := tutorialTarget(step); ok {
+		pad := buttonSep / 2
+		rect(*textures[ringImage], geom.Rectangle{
+			Min: geom.Point{X: r.Min.X - pad, Y: r.Min.Y - pad},
+			Max: geom.Point{X: r.Max.X + pad, Y: r.Max.Y + pad},
+		})
+	} else if step == 0 {
+		siz, m := float32(cellSize), float32(univ.margin)
+		i, j := univ.cols/2, univ.rows/2
+		add(grid, *textures[ringImage], f32.Affine{
+			{3 * siz, 0, m + float32(i-1)*siz},
+			{0, 3 * siz, m + float32(j-1)*siz},
+		})
+	}
+
+	// The instruction, in lines of the bitmap font of pickerPx, as the picker.
+	s := text(tutorialSteps[step].key)
+	img := image.NewRGBA(image.Rect(0, 0, textWidth(s, pickerScale), pickerPx))
+	drawText(img, s, 0, (pickerPx-glyphHeight*pickerScale)/2, pickerScale, color.White)
+	tex, err := eng.LoadTexture(img)
+	if err != nil {
+		log.Printf("tutorial: %v", err)
+		return
+	}
+	t.tex = tex
+	line, pad, dot := buttonSize, buttonSep/2, buttonSize/2
+	tw := geom.Pt(img.Bounds().Dx()) * line / pickerPx
+	dw := geom.Pt(len(tutorialSteps))*(dot+pad) - pad
+	w := tw
+	if dw > w {
+		w = dw
+	}
+	// Long instructions are cut at the edge of the screen.
+	if room := geom.Width - 2*buttonSep - 2*pad - buttonSep - line; w > room {
+		w, tw = room, room
+	}
+	w += 2*pad + buttonSep + line
+	h := 3*pad + line + dot
+	// Above the tool bar, see updateToolBar.
+	x0, y0 := (geom.Width-w)/2, geom.Height-2*buttonSep-buttonSize-h
+	box := geom.Rectangle{Min: geom.Point{X: x0, Y: y0}, Max: geom.Point{X: x0 + w, Y: y0 + h}}
+	rect(*textures[outOfBoundsImage], box)
+	x, y := box.Min.X+pad, box.Min.Y+pad
+	cut := image.Rect(0, 0, int(tw*pickerPx/line), pickerPx)
+	rect(sprite.SubTex{tex, cut}, mirror(geom.Rectangle{
+		Min: geom.Point{X: x, Y: y},
+		Max: geom.Point{X: x + tw, Y: y + line},
+	}, box))
+	for k := range tutorialSteps {
+		img := dotImage
+		if k > step {
+			img = disabledImage(dotImage)
+		}
+		dx := x + geom.Pt(k)*(dot+pad)
+		rect(*textures[img], mirror(geom.Rectangle{
+			Min: geom.Point{X: dx, Y: y + line + pad},
+			Max: geom.Point{X: dx + dot, Y: y + line + pad + dot},
+		}, box))
+	}
+
+	// The scene is below the system bar.
+	t.buttons = ui.NewBar(eng, scene, geom.Point{Y: systemBarHeight}, buttonFace)
+	sx := box.Max.X - pad - line
+	t.buttons.Add(stopImage, mirror(geom.Rectangle{
+		Min: geom.Point{X: sx, Y: y},
+		Max: geom.Point{X: sx + line, Y: y + line},
+	}, box))
+	accessibleBar(t.buttons)
+}
+
+// tutorialTarget returns the rectangle, using absolute location, of the controls of the given step
+// on the button bar, the union of the speed buttons for instance, or false if none shows.
+func tutorialTarget(step int) (geom.Rectangle, bool) {
+	var r geom.Rectangle
+	found := false
+	for _, img := range tutorialSteps[step].cmds {
+		if img == playImage {
+			img = pauseImage
+		}
+		b := buttonBar.Button(img)
+		if b == nil {
+			continue
+		}
+		if !found {
+			r, found = b.Rect, true
+			continue
+		}
+		lesser := func(a, b geom.Pt) geom.Pt { return geom.Pt(math.Min(float64(a), float64(b))) }
+		greater := func(a, b geom.Pt) geom.Pt { return geom.Pt(math.Max(float64(a), float64(b))) }
+		r = geom.Rectangle{
+			Min: geom.Point{X: lesser(r.Min.X, b.Rect.Min.X), Y: lesser(r.Min.Y, b.Rect.Min.Y)},
+			Max: geom.Point{X: greater(r.Max.X, b.Rect.Max.X), Y: greater(r.Max.Y, b.Rect.Max.Y)},
+		}
+	}
+	return r, found
+}
+
+// bar returns the bar of the skip button of t, nil if t is nil or not yet shown.
+func (t *tutorialOverlay) bar() *ui.Bar {
+	if t == nil {
+		return nil
+	}
+	return t.buttons
+}
+
+// release removes the nodes of t, if any, from the scene, unregisters them and frees the texture
+// of the instruction, to be shown again by the next frame.
+func (t *tutorialOverlay) release() {
+	if t == nil {
+		return
+	}
+	for _, n := range t.nodes {
+		n.Parent.RemoveChild(n)
+		eng.Unregister(n)
+	}
+	if t.tex != nil {
+		t.tex.Unload()
+	}
+	t.buttons.Release()
+	t.nodes, t.tex, t.buttons, t.shown = nil, nil, nil, -1
+}
@@ -0,0 +1,91 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+func TestTutorialSteps(t *testing.T) {
+	for _, test := range []struct {
+		cmds []string
+		step int
+	}{
+		{nil, 0},
+		// Out of order commands do not advance it.
+		{[]string{playImage, incSpeedImage}, 0},
+		{[]string{cellCommand, cellCommand, incSpeedImage}, 1},
+		{[]string{cellCommand, playImage}, 2},
+		{[]string{cellCommand, playImage, decSpeedImage}, 3},
+		{[]string{cellCommand, playImage, incSpeedImage, cellCommand}, 3},
+	} {
+		var tut tutorial
+		for _, cmd := range test.cmds {
+			tut.saw(cmd)
+		}
+		if tut.step != test.step || tut.over() != (test.step == len(tutorialSteps)) {
+			t.Errorf("%v: at step %d, over %v, want step %d", test.cmds, tut.step, tut.over(), test.step)
+		}
+	}
+	tut := tutorial{skipped: true}
+	if !tut.over() || tut.saw(cellCommand) {
+		t.Errorf("skipped tutorial not over")
+	}
+}
+
+func TestTutorial(t *testing.T) {
+	gestureScene(t)
+	defer func() { views.tour.release(); tour, views.tour = nil, nil }()
+	inTempDir(t, func(string) {
+		startTutorial()
+		if tour == nil || !paused {
+			t.Fatalf("tutorial not started, paused %v", paused)
+		}
+		tap := func(loc geom.Point) {
+			finger(1, event.TouchStart, loc)
+			finger(1, event.TouchEnd, loc)
+			updateTutorial()
+		}
+		updateTutorial()
+		if views.tour.shown != 0 || views.tour.bar() == nil || len(views.tour.nodes) == 0 {
+			t.Fatalf("first step not shown")
+		}
+		// Playing before painting a cell is not the step.
+		tap(buttonLoc(t, pauseImage))
+		tap(buttonLoc(t, pauseImage))
+		if tour.step != 0 || !paused {
+			t.Fatalf("at step %d, paused %v", tour.step, paused)
+		}
+		tap(cellLoc(3, 4))
+		if tour.step != 1 || views.tour.shown != 1 || len(alive()) != 1 {
+			t.Fatalf("at step %d showing %d with %d cells", tour.step, views.tour.shown, len(alive()))
+		}
+		tap(buttonLoc(t, pauseImage))
+		tap(buttonLoc(t, incSpeedImage))
+		if tour != nil || !cfg.tutorialDone {
+			t.Fatalf("tutorial not over")
+		}
+		if c := loadConfig(); !c.tutorialDone {
+			t.Errorf("tutorial done not saved")
+		}
+
+		// Once done, it does not start again.
+		startTutorial()
+		if tour != nil {
+			t.Errorf("tutorial started again")
+		}
+
+		// The skip button ends it on any step.
+		cfg.tutorialDone = false
+		startTutorial()
+		updateTutorial()
+		b := views.tour.bar().Buttons[0].Rect
+		tap(geom.Point{X: (b.Min.X + b.Max.X) / 2, Y: (b.Min.Y + b.Max.Y) / 2})
+		if tour != nil || !cfg.tutorialDone || len(alive()) != 1 {
+			t.Errorf("skip button did not end the tutorial, %d cells", len(alive()))
+		}
+	})
+}
@@ -0,0 +1,228 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+// maxEdits is the number of latest edits and runs of generations that can be undone.
+const maxEdits = 32
+
+// An editStack is the timeline of the latest edits by the user and of the generations stepped
+// between them, to undo them in turn, and once undone to redo them. A stroke is a single edit,
+// however many cells it paints, and so is the edit of a tool however many cells it changes, see
+// beginEdit.
+type editStack struct {
+	undo []*edit // From the oldest to the latest, at most maxEdits.
+	redo []*edit // From the farthest to the next.
+	// depth is the number of edits begun and not yet committed, nested in the latest one.
+	depth int
+}
+
+// An edit is an edit by the user, or a run of generations if steps is not 0.
+type edit struct {
+	// label names the edit in the toasts telling it undone or redone, empty until committed.
+	label string
+	// cells are the cells of the field before the edit, or after it once undone, and pins its
+	// pinned cells.
+	cells *life.Pattern
+	pins  []life.PinnedCell
+	steps int // Number of generations stepped in a row.
+}
+
+// clear forgets every edit, for instance once the game is replaced or stepped back from: its cells
+// are no longer the ones edited.
+func (es *editStack) clear() {
+	es.depth = 0
+	if es.undo == nil && es.redo == nil {
+		return
+	}
+	es.undo, es.redo = nil, nil
+	refreshTools()
+}
+
+// push adds e as the latest of the edits to undo, forgetting the oldest one beyond maxEdits.
+func (es *editStack) push(e *edit) {
+	if len(es.undo) >= maxEdits {
+		es.undo = append(es.undo[:0], es.undo[1:]...)
+	}
+	es.undo = append(es.undo, e)
+}
+
+// stepped tells es a generation was stepped, which adds it to the run of generations of the latest
+// edit to undo, or starts one. The edits undone so far are lost.
+func (es *editStack) stepped() {
+	if n := len(es.undo); n > 0 && es.undo[n-1].steps > 0 {
+		es.undo[n-1].steps++
+		if es.redo == nil {
+			return
+		}
+	} else {
+		es.push(&edit{steps: 1})
+	}
+	es.redo = nil
+	refreshTools()
+}
+
+// steppedBack tells es the generation stepped last was stepped back from, which moves it from the
+// runs of generations to undo to the ones to redo.
+func (es *editStack) steppedBack() {
+	n := len(es.undo)
+	if e := es.undo[n-1]; e.steps > 1 {
+		e.steps--
+	} else {
+		es.undo = es.undo[:n-1]
+	}
+	if m := len(es.redo); m > 0 && es.redo[m-1].steps > 0 {
+		es.redo[m-1].steps++
+	} else {
+		es.redo = append(es.redo, &edit{steps: 1})
+	}
+	refreshTools()
+}
+
+// field returns the cells of the field of u, as a pattern the size of the field.
+func (u *universe) field() *life.Pattern {
+	return u.life.Region(0, 0, u.cols, u.rows)
+}
+
+// beginEdit remembers the cells of u before an edit by the user, which can then be undone once
+// committed by commitEdit. The edits undone so far are lost. An edit begun before the previous one
+// is committed is part of it, so that a tool made of others is undone at once.
+func (u *universe) beginEdit() {
+	es := &u.edits
+	es.depth++
+	if es.depth > 1 {
+		return
+	}
+	es.push(&edit{cells: u.field(), pins: u.life.PinnedCells()})
+	es.redo = nil
+	refreshTools()
+}
+
+// commitEdit ends the latest edit begun, named label unless part of another one, see beginEdit.
+// The cells changed meanwhile are undone at once.
+func (u *universe) commitEdit(label string) {
+	es := &u.edits
+	if es.depth == 0 {
+		return
+	}
+	if es.depth--; es.depth == 0 {
+		es.undo[len(es.undo)-1].label = label
+	}
+}
+
+// rollBackEdit abandons the edit begun, along with every edit part of it: the cells of u are
+// reverted to the ones before it, and the edit forgotten.
+func (u *universe) rollBackEdit() {
+	es := &u.edits
+	if es.depth == 0 {
+		return
+	}
+	es.depth = 0
+	n := len(es.undo)
+	u.setCells(es.undo[n-1].cells, es.undo[n-1].pins)
+	es.undo = es.undo[:n-1]
+	refreshTools()
+}
+
+// dropEdit forgets the latest edit by the user, which the caller has already reverted, along with
+// the generations stepped since, which went on from it.
+func (u *universe) dropEdit() {
+	es := &u.edits
+	for n := len(es.undo); n > 0; n-- {
+		if es.undo[n-1].cells != nil {
+			es.undo = es.undo[:n-1]
+			break
+		}
+	}
+	refreshTools()
+}
+
+// transact makes the changes of the cells of u by f a single edit, named label. If f panics, the
+// cells are rolled back to the ones before the edit, and the panic goes on.
+func (u *universe) transact(label string, f func()) {
+	u.beginEdit()
+	done := false
+	defer func() {
+		if !done {
+			log.Printf("edit %s abandoned", label)
+			u.rollBackEdit()
+		}
+	}()
+	f()
+	done = true
+	u.commitEdit(label)
+}
+
+// undo reverts the latest edit of u, or steps back the generation stepped last, if any, and tells
+// it in a toast.
+func (u *universe) undo() {
+	es := &u.edits
+	n := len(es.undo)
+	if n == 0 {
+		return
+	}
+	e := es.undo[n-1]
+	if e.steps > 0 {
+		g := u.life.Generation()
+		if !u.back() {
+			// The generation is beyond the timeline, and the edits before it out of reach.
+			es.clear()
+			return
+		}
+		es.steppedBack()
+		log.Printf("undid generation %d", g)
+		showToast(text("undo.undid", text("edit.step", g)))
+		return
+	}
+	es.undo = es.undo[:n-1]
+	es.redo = append(es.redo, &edit{label: e.label, cells: u.field(), pins: u.life.PinnedCells()})
+	u.setCells(e.cells, e.pins)
+	log.Printf("undid %s, %d left", e.label, n-1)
+	showToast(text("undo.undid", e.label))
+	refreshTools()
+}
+
+// redo makes again the latest edit of u undone, or steps again the generation stepped back from
+// last, if any, and tells it in a toast.
+func (u *universe) redo() {
+	es := &u.edits
+	n := len(es.redo)
+	if n == 0 {
+		return
+	}
+	e := es.redo[n-1]
+	if e.steps > 0 {
+		if e.steps--; e.steps == 0 {
+			es.redo = es.redo[:n-1]
+		}
+		// Stepping loses the edits undone, so the other ones are put back.
+		redo := es.redo
+		u.Step()
+		es.redo = redo
+		log.Printf("redid generation %d", u.life.Generation())
+		showToast(text("undo.redid", text("edit.step", u.life.Generation())))
+		refreshTools()
+		return
+	}
+	es.redo = es.redo[:n-1]
+	es.push(&edit{label: e.label, cells: u.field(), pins: u.life.PinnedCells()})
+	u.setCells(e.cells, e.pins)
+	log.Printf("redid %s, %d left", e.label, n-1)
+	showToast(text("undo.redid", e.label))
+	refreshTools()
+}
+
+// setCells replaces every cell of the field of u by the ones of p, pinned as told by pins, and
+// repaints them.
+func (u *universe) setCells(p *life.Pattern, pins []life.PinnedCell) {
+	u.life.SetPins(pins)
+	u.life.Clear(0, 0, u.cols, u.rows)
+	u.life.Stamp(p, 0, 0)
+	u.edited()
+	u.paint()
+}
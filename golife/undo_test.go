@@ -0,0 +1,112 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// setCell makes the cell (i, j) of univ alive as an edit named label.
+func setCell(label string, i, j int) {
+	univ.transact(label, func() { univ.life.SetCells([][2]int{{i, j}}, true) })
+}
+
+func TestNestedEdits(t *testing.T) {
+	gestureScene(t)
+	setPaused(true)
+	// The edits begun within another are part of it, named after it.
+	univ.beginEdit()
+	setCell("inner", 1, 1)
+	setCell("inner", 2, 2)
+	univ.commitEdit("outer")
+	if n := len(univ.edits.undo); n != 1 || univ.edits.undo[0].label != "outer" {
+		t.Fatalf("%d edits, the latest %+v", n, univ.edits.undo[n-1])
+	}
+	univ.undo()
+	if len(alive()) != 0 || views.popup.text != text("undo.undid", "outer") {
+		t.Errorf("undo left %v, toast %q", alive(), views.popup.text)
+	}
+	univ.redo()
+	if len(alive()) != 2 || views.popup.text != text("undo.redid", "outer") {
+		t.Errorf("redo left %v, toast %q", alive(), views.popup.text)
+	}
+}
+
+func TestAbandonedEdit(t *testing.T) {
+	gestureScene(t)
+	setPaused(true)
+	setCell("kept", 1, 1)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("panic of the edit recovered")
+			}
+		}()
+		univ.transact("outer", func() {
+			setCell("inner", 2, 2)
+			panic("abandoned")
+		})
+	}()
+	// The cells are back, the edit forgotten, and the next edits are not part of it.
+	if got := alive(); len(got) != 1 || len(univ.edits.undo) != 1 || univ.edits.depth != 0 {
+		t.Fatalf("abandoned edit left %v, %d edits at depth %d", got, len(univ.edits.undo), univ.edits.depth)
+	}
+	univ.beginEdit()
+	setCell("inner", 3, 3)
+	univ.rollBackEdit()
+	if got := alive(); len(got) != 1 || len(univ.edits.undo) != 1 {
+		t.Errorf("edit rolled back left %v, %d edits", got, len(univ.edits.undo))
+	}
+}
+
+func TestUndoTimeline(t *testing.T) {
+	gestureScene(t)
+	setPaused(true)
+	// An edit, two generations, another edit, undone down to the start then redone.
+	state := func() string { return fmt.Sprint(univ.life.Generation(), alive()) }
+	states := []string{state()}
+	setCell("blinker", 4, 5)
+	univ.life.SetCells([][2]int{{5, 5}, {6, 5}}, true)
+	states = append(states, state())
+	for k := 0; k < 2; k++ {
+		univ.Step()
+		states = append(states, state())
+	}
+	setCell("cell", 10, 10)
+	states = append(states, state())
+	if n := len(univ.edits.undo); n != 3 {
+		t.Fatalf("%d edits, want an edit, a run of generations and another edit", n)
+	}
+	for k := len(states) - 2; k >= 0; k-- {
+		univ.undo()
+		if s := state(); s != states[k] {
+			t.Fatalf("undone to %s, want %s", s, states[k])
+		}
+	}
+	if views.popup.text != text("undo.undid", "blinker") || len(univ.edits.undo) != 0 {
+		t.Errorf("toast %q once undone to the start", views.popup.text)
+	}
+	for k := 1; k < len(states); k++ {
+		univ.redo()
+		if s := state(); s != states[k] {
+			t.Fatalf("redone to %s, want %s", s, states[k])
+		}
+	}
+	if len(univ.edits.redo) != 0 {
+		t.Errorf("%d edits left to redo", len(univ.edits.redo))
+	}
+
+	// Stepped back from by the back button, the generations are undone as well.
+	univ.Step()
+	univ.Back()
+	univ.undo()
+	if s := state(); s != states[len(states)-2] {
+		t.Errorf("undone to %s after the back button, want %s", s, states[len(states)-2])
+	}
+	// A step loses the edits undone.
+	univ.Step()
+	if len(univ.edits.redo) != 0 {
+		t.Errorf("%d edits to redo once stepped", len(univ.edits.redo))
+	}
+}
@@ -0,0 +1,294 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"log"
+	"net"
+
+	"github.com/vegacom/mobile/golife/life"
+	"github.com/vegacom/mobile/golife/netplay"
+)
+
+// A versusMatch is a match against the player of another device, see netplay. The versus button
+// hosts or joins one as configured, then tells the seeds of the player ready, and once over
+// shows whether they won. Meanwhile the universe shows the shared field, centered, its cells
+// painted in the color of their player.
+type versusMatch struct {
+	// game is the match, nil while connecting. connected receives it, or why it failed, from
+	// the goroutine connecting, and ln is the listener waiting for a guest, if hosting.
+	game      *netplay.Game
+	connected chan versusResult
+	ln        net.Listener
+	// due is the number of generations due since the last one was shown, fractions included.
+	due     float64
+	started bool
+	// result is the face of the versus button once the match is over, empty until then.
+	result string
+}
+
+// A versusResult is the outcome of connecting to another device.
+type versusResult struct {
+	game *netplay.Game
+	err  error
+}
+
+// versus is the current match, nil if none.
+var versus *versusMatch
+
+// tapVersus acts on a tap on the versus button, which starts a match, tells the seeds of the player
+// ready, and else ends the match, for instance forfeiting it.
+func tapVersus() {
+	switch v := versus; {
+	case v == nil:
+		startVersus()
+	case v.game != nil && v.game.Life == nil && !v.game.IsReady():
+		if err := v.game.Ready(); err != nil {
+			log.Printf("match: %v", err)
+			endVersus()
+			return
+		}
+		log.Printf("match: seeded %d cells, waiting for the peer", len(v.game.Seeds()))
+	default:
+		endVersus()
+	}
+}
+
+// startVersus hosts a match on the field of the universe, or joins one as configured, in the
+// background.
+func startVersus() {
+	v := &versusMatch{connected: make(chan versusResult, 1)}
+	cols, rows := univ.cols, univ.rows
+	if addr := cfg.versus.join; addr != "" {
+		log.Printf("match: joining %s", addr)
+		go func() {
+			g, err := netplay.Join(addr, cols, rows)
+			v.connected <- versusResult{g, err}
+		}()
+	} else {
+		ln, err := net.Listen("tcp", cfg.versus.host)
+		if err != nil {
+			log.Printf("match: %v", err)
+			return
+		}
+		log.Printf("match: waiting for a guest on %v", ln.Addr())
+		m := netplay.Match{
+			Cols:        cols,
+			Rows:        rows,
+			Rule:        univ.life.Rule.String(),
+			Generations: cfg.versus.generations,
+			Budget:      cfg.versus.budget,
+		}
+		v.ln = ln
+		go func() {
+			g, err := netplay.Host(ln, m)
+			ln.Close()
+			v.connected <- versusResult{g, err}
+		}()
+	}
+	versus = v
+	buttonBar.Refresh()
+}
+
+// endVersus ends the match, if any, and restores the configured colors and edge mode of the
+// universe, which keeps the cells of the match.
+func endVersus() {
+	v := versus
+	if v == nil {
+		return
+	}
+	versus = nil
+	switch {
+	case v.game != nil:
+		v.game.Close()
+	case v.ln != nil:
+		v.ln.Close()
+		fallthrough
+	default:
+		// Close the game connected meanwhile, if any.
+		go func() {
+			if r := <-v.connected; r.game != nil {
+				r.game.Close()
+			}
+		}()
+	}
+	views.matchHalf = releaseFrame(views.matchHalf)
+	univ.life.Colors = cfg.colors
+	univ.setEdges(cfg.edges)
+	univ.edits.clear()
+	buttonBar.Refresh()
+	log.Printf("match: ended")
+}
+
+// updateVersus handles the messages of the peer and the progress of the match. It is called every
+// frame.
+func updateVersus() {
+	v := versus
+	if v == nil || v.result != "" {
+		return
+	}
+	if v.game == nil {
+		select {
+		case r := <-v.connected:
+			if r.err != nil {
+				log.Printf("match: %v", r.err)
+				versus = nil
+				buttonBar.Refresh()
+				return
+			}
+			v.begin(r.game)
+		default:
+			return
+		}
+	}
+	g := v.game
+	if err := g.Update(); err != nil {
+		log.Printf("match: %v", err)
+		endVersus()
+		return
+	}
+	switch {
+	case g.Over():
+		v.finish()
+	case g.Life != nil && !v.started:
+		v.started = true
+		views.matchHalf = releaseFrame(views.matchHalf)
+		v.mirror()
+		log.Printf("match: started, %d generations", g.Match.Generations)
+	case g.Life == nil:
+		// Follows the changes of cell size.
+		views.matchHalf = frameCells(views.matchHalf, v.halfRect())
+	}
+}
+
+// begin prepares the universe for the seeding of g, emptied, paused and bounded by the field of the
+// match.
+func (v *versusMatch) begin(g *netplay.Game) {
+	v.game = g
+	m := g.Match
+	setSelecting(false)
+	setPaused(true)
+	u := univ
+	if r, err := life.ParseRule(m.Rule); err == nil {
+		u.life.Rule = r
+	}
+	u.life.Seed(0, life.Random)
+	u.life.Colors = 2
+	u.setEdges(life.Bounded)
+	u.edits.clear()
+	u.history.clear()
+	u.timeline.clear()
+	spark.clear()
+	side := "left"
+	if g.Player == 1 {
+		side = "right"
+	}
+	log.Printf("match: %dx%d cells, seed up to %d on the %s half then tap versus", m.Cols, m.Rows, m.Budget, side)
+	buttonBar.Refresh()
+}
+
+// origin returns the cell of the universe showing the top-left one of the field of the match.
+func (v *versusMatch) origin() (i, j int) {
+	m := v.game.Match
+	return (univ.cols - m.Cols) / 2, (univ.rows - m.Rows) / 2
+}
+
+// halfRect returns the cells of the universe showing the half of the field the player seeds.
+func (v *versusMatch) halfRect() image.Rectangle {
+	m := v.game.Match
+	i, j := v.origin()
+	x0, x1 := 0, m.Cols/2
+	if v.game.Player == 1 {
+		x0, x1 = m.Cols/2, m.Cols
+	}
+	return image.Rect(i+x0, j, i+x1, j+m.Rows)
+}
+
+// seed seeds the cell (i, j) of the universe, if the player may, and shows it.
+func (v *versusMatch) seed(i, j int) {
+	g := v.game
+	if g == nil || g.Life != nil {
+		return
+	}
+	x, y := v.origin()
+	if g.Seed(i-x, j-y) {
+		univ.life.Set(i, j, true)
+		univ.life.SetColor(i, j, g.Player)
+		univ.show(i, j, true)
+	}
+}
+
+// arrange steps the match at the current speed, unless waiting for the peer, and shows it. It is
+// called by the arranger of the scene instead of stepping the universe.
+func (v *versusMatch) arrange(elapsed float64) {
+	g := v.game
+	if g == nil || g.Life == nil || v.result != "" {
+		return
+	}
+	v.due += elapsed * speed / 60
+	if v.due > maxCatchUp {
+		v.due = maxCatchUp
+	}
+	stepped := false
+	for ; v.due >= 1; v.due-- {
+		ok, err := g.Step()
+		if err != nil || !ok {
+			// Waiting for the peer does not make generations due.
+			v.due = 0
+			break
+		}
+		stepped = true
+	}
+	if stepped {
+		v.mirror()
+	}
+}
+
+// mirror copies the field of the match to the universe, clipped if it does not fit, and paints it.
+func (v *versusMatch) mirror() {
+	u, l := univ, v.game.Life
+	x0, y0 := v.origin()
+	w, h := l.Bounds()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i, j := x0+x, y0+y
+			if i < 0 || i >= u.cols || j < 0 || j >= u.rows {
+				continue
+			}
+			alive := l.Alive(x, y)
+			u.life.Set(i, j, alive)
+			if alive {
+				u.life.SetColor(i, j, l.Color(x, y))
+			}
+		}
+	}
+	u.paint()
+}
+
+// finish tells the outcome of the match once over, and closes the connection.
+func (v *versusMatch) finish() {
+	v.mirror()
+	own, peer := v.game.Score()
+	switch {
+	case own > peer:
+		v.result = wonImage
+		log.Printf("match: won with %d cells against %d", own, peer)
+	case own < peer:
+		v.result = lostImage
+		log.Printf("match: lost with %d cells against %d", own, peer)
+	default:
+		v.result = versusImage
+		log.Printf("match: tie with %d cells each", own)
+	}
+	v.game.Close()
+	buttonBar.Refresh()
+}
+
+// face returns the image of the versus button: the outcome of the match once over.
+func (v *versusMatch) face() string {
+	if v == nil || v.result == "" {
+		return versusImage
+	}
+	return v.result
+}
@@ -0,0 +1,123 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"log"
+
+	"github.com/vegacom/mobile/golife/life"
+)
+
+var (
+	// walling is set by the wall button of the tool bar. Strokes while paused then pin the cells
+	// they go through, with the current brush, or unpin them if they started on a pinned cell, see
+	// life.Life.Pin.
+	walling bool
+	// wallAlive is the polarity of the walls, switched by a long press on the wall button: pinned
+	// alive, sources the cells around always see alive, or pinned dead, walls nothing is born in.
+	wallAlive bool
+	// wallImages are the images of the wall button by polarity of the walls.
+	wallImages = map[bool]string{false: wallImage, true: liveWallImage}
+)
+
+// toggleWalls starts or stops painting walls.
+func toggleWalls() {
+	walling = !walling
+	toolBar.Refresh()
+	log.Printf("walling %v", walling)
+}
+
+// switchWallPolarity switches the polarity of the walls painted next, and tells it in a toast.
+func switchWallPolarity() {
+	wallAlive = !wallAlive
+	toolBar.Refresh()
+	showToast(text("button." + wallImages[wallAlive]))
+	log.Printf("walls alive %v", wallAlive)
+}
+
+// paintWalls pins the unpinned ones of cells, clipped to the field, as painted by s, or unpins the
+// pinned ones if s erases, and repaints them.
+func (u *universe) paintWalls(s *stroke, cells [][2]int) {
+	var changed [][2]int
+	for _, c := range cells {
+		if c[0] >= 0 && c[0] < u.cols && c[1] >= 0 && c[1] < u.rows && u.life.Pinned(c[0], c[1]) == s.erase {
+			changed = append(changed, c)
+			s.was = append(s.was, u.life.Alive(c[0], c[1]))
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	pin := func() {
+		for _, c := range changed {
+			if s.erase {
+				u.life.Unpin(c[0], c[1])
+			} else {
+				u.life.Pin(c[0], c[1], wallAlive)
+			}
+		}
+	}
+	if s.edited {
+		pin()
+	} else {
+		u.transact(s.label(), pin)
+		s.edited = true
+	}
+	u.edited()
+	for _, c := range changed {
+		u.show(c[0], c[1], u.life.Alive(c[0], c[1]))
+	}
+	s.drawn = append(s.drawn, changed...)
+}
+
+// revertWalls unpins the cells pinned by s, or pins again those it unpinned, leaving them in the
+// state they were in before, and repaints them.
+func (u *universe) revertWalls(s *stroke) {
+	for k, c := range s.drawn {
+		if s.erase {
+			u.life.Pin(c[0], c[1], s.was[k])
+		} else {
+			u.life.Unpin(c[0], c[1])
+			u.life.Set(c[0], c[1], s.was[k])
+		}
+		u.show(c[0], c[1], s.was[k])
+	}
+	s.drawn, s.was = nil, nil
+}
+
+// skippedPins returns the number of pinned cells an edit leaves as they are instead of setting them
+// as the cells of p at (x, y), p being nil for none, and clearing the other cells of r. Both are
+// clipped to the field.
+func (u *universe) skippedPins(r image.Rectangle, p *life.Pattern, x, y int) int {
+	field := image.Rect(0, 0, u.cols, u.rows)
+	alive := make(map[image.Point]bool)
+	n := 0
+	if p != nil {
+		for _, c := range p.Cells {
+			pt := image.Pt(x+c[0], y+c[1])
+			alive[pt] = true
+			if pt.In(field) && !pt.In(r) && u.life.Pinned(pt.X, pt.Y) && !u.life.Alive(pt.X, pt.Y) {
+				n++
+			}
+		}
+	}
+	r = r.Intersect(field)
+	for j := r.Min.Y; j < r.Max.Y; j++ {
+		for i := r.Min.X; i < r.Max.X; i++ {
+			if u.life.Pinned(i, j) && u.life.Alive(i, j) != alive[image.Pt(i, j)] {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// reportSkipped tells in a toast how many pinned cells an edit skipped, if any.
+func reportSkipped(n int) {
+	if n == 0 {
+		return
+	}
+	log.Printf("%d pinned cells skipped", n)
+	showToast(text("edit.skipped", n))
+}
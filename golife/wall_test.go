@@ -0,0 +1,137 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/event"
+)
+
+func TestWalls(t *testing.T) {
+	gestureScene(t)
+	defer func() { walling, wallAlive = false, false }()
+	setPaused(true)
+	updateToolBar()
+	univ.life.SetCells(alive(), false)
+
+	// A stroke while walling pins the cells it goes through dead.
+	finger(1, event.TouchStart, buttonLoc(t, wallImage))
+	finger(1, event.TouchEnd, buttonLoc(t, wallImage))
+	if !walling || face(wallImage) != stopImage {
+		t.Fatalf("walling %v, face %s once the wall button tapped", walling, face(wallImage))
+	}
+	finger(2, event.TouchStart, cellLoc(2, 2))
+	finger(2, event.TouchMove, cellLoc(5, 2))
+	finger(2, event.TouchEnd, cellLoc(5, 2))
+	for i := 2; i <= 5; i++ {
+		if !univ.life.Pinned(i, 2) || univ.life.Alive(i, 2) {
+			t.Errorf("cell (%d, 2) pinned %v, alive %v, want a wall", i, univ.life.Pinned(i, 2), univ.life.Alive(i, 2))
+		}
+	}
+	if c := univ.snapshotColor(2, 2); c != brickTints[brickTint(false)].c {
+		t.Errorf("wall of color %v", c)
+	}
+
+	// A long press on the button switches the walls alive.
+	finger(3, event.TouchStart, buttonLoc(t, wallImage))
+	pointers[3].start = time.Now().Add(-longPress)
+	holdPointers()
+	finger(3, event.TouchEnd, buttonLoc(t, wallImage))
+	if !wallAlive || !walling || views.popup == nil || views.popup.text != text("button.live_wall") {
+		t.Fatalf("walls alive %v, walling %v, toast %+v once the wall button held", wallAlive, walling, views.popup)
+	}
+	finger(4, event.TouchStart, cellLoc(2, 4))
+	finger(4, event.TouchEnd, cellLoc(2, 4))
+	if !univ.life.Pinned(2, 4) || !univ.life.Alive(2, 4) {
+		t.Errorf("cell (2, 4) pinned %v, alive %v, want a live wall", univ.life.Pinned(2, 4), univ.life.Alive(2, 4))
+	}
+
+	// A stroke starting on a pinned cell unpins, and undoing it pins again.
+	finger(5, event.TouchStart, cellLoc(2, 2))
+	finger(5, event.TouchMove, cellLoc(3, 2))
+	finger(5, event.TouchEnd, cellLoc(3, 2))
+	if univ.life.Pinned(2, 2) || univ.life.Pinned(3, 2) || !univ.life.Pinned(4, 2) {
+		t.Errorf("cells (2, 2) to (4, 2) pinned %v, %v, %v, want the first two unpinned",
+			univ.life.Pinned(2, 2), univ.life.Pinned(3, 2), univ.life.Pinned(4, 2))
+	}
+	univ.undo()
+	if !univ.life.Pinned(2, 2) || !univ.life.Pinned(3, 2) || univ.life.Alive(2, 2) {
+		t.Errorf("undone, cells (2, 2) and (3, 2) pinned %v, %v", univ.life.Pinned(2, 2), univ.life.Pinned(3, 2))
+	}
+
+	// The brush leaves the pinned cells as they are, alive or dead.
+	finger(6, event.TouchStart, buttonLoc(t, wallImage))
+	finger(6, event.TouchEnd, buttonLoc(t, wallImage))
+	finger(7, event.TouchStart, cellLoc(1, 2))
+	finger(7, event.TouchMove, cellLoc(6, 2))
+	finger(7, event.TouchEnd, cellLoc(6, 2))
+	if univ.life.Alive(4, 2) || !univ.life.Alive(1, 2) || !univ.life.Alive(6, 2) {
+		t.Errorf("painted over a wall to %v", alive())
+	}
+	finger(8, event.TouchStart, cellLoc(2, 4))
+	finger(8, event.TouchEnd, cellLoc(2, 4))
+	if !univ.life.Alive(2, 4) {
+		t.Errorf("erased a live wall")
+	}
+}
+
+func TestSkippedPins(t *testing.T) {
+	gestureScene(t)
+	defer setSelecting(false)
+	univ.life.SetCells(alive(), false)
+	univ.life.Pin(5, 5, false)
+	univ.life.Pin(6, 5, true)
+
+	// Cut, the live wall stays and is told skipped.
+	setSelecting(true)
+	selectCells(image.Rect(4, 4, 8, 8))
+	cutSelection()
+	if !univ.life.Alive(6, 5) || views.popup == nil || views.popup.text != text("edit.skipped", 1) {
+		t.Errorf("cut: wall alive %v, toast %+v", univ.life.Alive(6, 5), views.popup)
+	}
+
+	// Pasted over, the dead wall stays dead, and the live one alive out of the pattern.
+	clipboard = &life.Pattern{W: 2, H: 2, Cells: [][2]int{{0, 0}, {0, 1}}}
+	selectCells(image.Rect(5, 5, 7, 7))
+	pasteClipboard()
+	if univ.life.Alive(5, 5) || !univ.life.Alive(5, 6) || !univ.life.Alive(6, 5) {
+		t.Errorf("pasted to %v", alive())
+	}
+	if views.popup == nil || views.popup.text != text("edit.skipped", 2) {
+		t.Errorf("paste: toast %+v", views.popup)
+	}
+
+	// Stamped, only the dead wall under the pattern is skipped.
+	if n := univ.skippedPins(image.Rectangle{}, stamps[6], 5, 4); n != 1 {
+		t.Errorf("stamp skipping %d pinned cells, want 1", n)
+	}
+}
+
+func TestClearPins(t *testing.T) {
+	gestureScene(t)
+	defer func(c config) { cfg = c }(cfg)
+	univ.life.Pin(3, 3, true)
+	univ.life.Pin(4, 3, false)
+
+	// Randomized or cleared, the pinned cells stay.
+	univ.reseed(7)
+	if !univ.life.Pinned(3, 3) || !univ.life.Alive(3, 3) || !univ.life.Pinned(4, 3) {
+		t.Errorf("reseeded, pinned %v", univ.life.PinnedCells())
+	}
+	glider := &life.Pattern{W: 3, H: 3, Cells: [][2]int{{1, 0}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}}
+	univ.place(glider)
+	if len(univ.life.PinnedCells()) != 2 || len(alive()) != 6 {
+		t.Errorf("cleared, pinned %v, alive %v", univ.life.PinnedCells(), alive())
+	}
+
+	// Unless the setting says otherwise.
+	cfg.clearPins = true
+	univ.place(glider)
+	if len(univ.life.PinnedCells()) != 0 || len(alive()) != 5 {
+		t.Errorf("cleared with the pinned cells, pinned %v, alive %v", univ.life.PinnedCells(), alive())
+	}
+}
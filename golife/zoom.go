@@ -0,0 +1,235 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// Bounds of the cell size when zooming, in Pt.
+const (
+	minCellSize = 4
+	maxCellSize = 24
+)
+
+// cellSizes are the cell sizes picked from the settings panel, in Pt, from the smallest.
+var cellSizes = []geom.Pt{4, 6, 8, 12, 16, 24}
+
+// nextCellSize returns the smallest of cellSizes larger than siz if d is positive, else the largest
+// smaller than siz, or siz if there is none.
+func nextCellSize(siz geom.Pt, d int) geom.Pt {
+	next := siz
+	for _, s := range cellSizes {
+		if d > 0 && s > siz && (next == siz || s < next) || d < 0 && s < siz && (next == siz || s > next) {
+			next = s
+		}
+	}
+	return next
+}
+
+// A pinch is a two finger gesture zooming the view of the field, and panning it as the fingers move
+// together. It moves the camera of the universe only, see universe.look, never the game.
+type pinch struct {
+	ids  [2]event.TouchSequenceID
+	dist geom.Pt // Initial distance between the fingers.
+	zoom float32 // Initial zoom of the universe.
+	// anchor is the location on the field, see universe.toField, initially under the midpoint of the
+	// fingers, which stays under it.
+	anchor geom.Point
+	// start is when the pinch started, from the initial locations of the fingers, and moved is set
+	// once either went further than holdRadius from it. A pinch that did not is a tap, see
+	// twoFingerTap.
+	start time.Time
+	locs  [2]geom.Point
+	moved bool
+}
+
+// newPinch starts a pinch with the fingers of the touch sequences a and b, see pointers.
+func newPinch(a, b event.TouchSequenceID) *pinch {
+	p := &pinch{ids: [2]event.TouchSequenceID{a, b}, zoom: univ.zoom, start: time.Now()}
+	p.locs = [2]geom.Point{pointers[a].loc, pointers[b].loc}
+	var mid geom.Point
+	p.dist, mid = p.measure()
+	p.anchor = univ.toField(mid)
+	return p
+}
+
+// end tells p is over, both its fingers lifted, which makes it a two finger tap if quick and still.
+func (p *pinch) end() {
+	if !p.moved && time.Since(p.start) < twoFingerTap {
+		twoFingerTapped()
+	}
+}
+
+// active reports whether both fingers of p still touch the screen.
+func (p *pinch) active() bool {
+	return pointers[p.ids[0]] != nil && pointers[p.ids[1]] != nil
+}
+
+// measure returns the distance between the fingers of p, which must be active, and their midpoint.
+func (p *pinch) measure() (dist geom.Pt, mid geom.Point) {
+	a, b := pointers[p.ids[0]].loc, pointers[p.ids[1]].loc
+	dx, dy := float64(b.X-a.X), float64(b.Y-a.Y)
+	return geom.Pt(math.Hypot(dx, dy)), geom.Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+}
+
+// update zooms and pans the view of the universe after the fingers of p, which must be active,
+// moved, noting whether they moved enough not to tap.
+func (p *pinch) update() {
+	for k, id := range p.ids {
+		loc := pointers[id].loc
+		if math.Hypot(float64(loc.X-p.locs[k].X), float64(loc.Y-p.locs[k].Y)) > holdRadius {
+			p.moved = true
+		}
+	}
+	if p.moved {
+		follow.disengage()
+	}
+	dist, mid := p.measure()
+	zoom := p.zoom
+	if p.dist != 0 {
+		zoom *= float32(dist / p.dist)
+	}
+	univ.look(zoom, p.anchor, mid)
+}
+
+// look zooms the view of the field of u by zoom, within the bounds of the cell size, and pans it so
+// that the location anchor of the field, see toField, shows under focus, which uses absolute
+// location. The field does not pan further than needed to show its edges.
+func (u *universe) look(zoom float32, anchor, focus geom.Point) {
+	if lo := float32(minCellSize / cellSize); zoom < lo {
+		zoom = lo
+	}
+	if hi := float32(maxCellSize / cellSize); zoom > hi {
+		zoom = hi
+	}
+	u.zoom = zoom
+	z := geom.Pt(zoom)
+	u.pan.X = clampPan(focus.X-0.1-anchor.X*z, u.w, u.fieldWidth()*z)
+	u.pan.Y = clampPan(focus.Y-systemBarHeight-buttonBarHeight-anchor.Y*z, u.h, u.fieldHeight()*z)
+	if grid != nil {
+		eng.SetTransform(grid, f32.Affine{
+			{zoom, 0, float32(u.pan.X)},
+			{0, zoom, float32(buttonBarHeight + u.pan.Y)},
+		})
+	}
+}
+
+// clampPan returns pan, the offset along an axis of a field extent long on a screen area area long,
+// kept between the offsets showing either edge of the field at the edge of the area, or the field
+// whole if it is shorter than the area.
+func clampPan(pan, area, extent geom.Pt) geom.Pt {
+	lo, hi := area-extent, geom.Pt(0)
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	if pan < lo {
+		return lo
+	}
+	if pan > hi {
+		return hi
+	}
+	return pan
+}
+
+// fieldWidth and fieldHeight return the size of the field of u along with its margins, unzoomed.
+func (u *universe) fieldWidth() geom.Pt  { return 2*u.margin + geom.Pt(u.cols)*cellSize }
+func (u *universe) fieldHeight() geom.Pt { return 2*u.margin + geom.Pt(u.rows)*cellSize }
+
+// toField returns the location on the field of u, unzoomed and relative to its top-left corner, of
+// point, which uses absolute location: it undoes the scene transform set in buildScene and the
+// camera set in look.
+func (u *universe) toField(point geom.Point) geom.Point {
+	z := geom.Pt(u.zoom)
+	return geom.Point{
+		X: (point.X - 0.1 - u.pan.X) / z,
+		Y: (point.Y - systemBarHeight - buttonBarHeight - u.pan.Y) / z,
+	}
+}
+
+// regrid returns a universe covering the same screen area as u with cells of size siz and the same
+// game, replacing u. The cell under focus, which uses absolute location, stays under it: growing
+// the cells crops the field around it and shrinking them pads the field with dead cells.
+func (u *universe) regrid(siz geom.Pt, focus geom.Point) *universe {
+	fi, fj := u.cellNear(focus)
+	l := u.life
+	u.release()
+
+	cellSize = siz
+	nu := newUniverse(u.h, u.w)
+	ni, nj := nu.cellNear(focus)
+	l.Resize(nu.cols, nu.rows, ni-fi, nj-fj)
+	nu.adopt(l)
+	return nu
+}
+
+// resample returns a universe covering the same screen area as u with cells of size siz and the
+// game of u scaled to its field, replacing u. Unlike regrid, no cell is lost to cropping, but the
+// patterns are distorted, if not destroyed, by the resampling.
+func (u *universe) resample(siz geom.Pt) *universe {
+	l := u.life
+	u.release()
+
+	cellSize = siz
+	nu := newUniverse(u.h, u.w)
+	l.Resample(nu.cols, nu.rows)
+	nu.adopt(l)
+	return nu
+}
+
+// cellNear returns the column and row of the cell of the field closest to point, which uses
+// absolute location.
+func (u *universe) cellNear(point geom.Point) (i, j int) {
+	p := u.toField(point)
+	i, j = int((p.X-u.margin)/cellSize), int((p.Y-u.margin)/cellSize)
+	if i < 0 {
+		i = 0
+	} else if i >= u.cols {
+		i = u.cols - 1
+	}
+	if j < 0 {
+		j = 0
+	} else if j >= u.rows {
+		j = u.rows - 1
+	}
+	return i, j
+}
+
+// adopt makes u show l, whose field must be the size of u, and repaints it along with the border
+// telling its edge mode.
+func (u *universe) adopt(l *life.Life) {
+	l.Listener = u
+	l.ParallelCells = cfg.parallelCells
+	l.Mutation = mutation()
+	u.life = l
+	u.edits.clear()
+	u.repaint()
+}
+
+// release removes u from the grid and unregisters all its nodes.
+func (u *universe) release() {
+	if u.frame != nil {
+		u.frame.release()
+	}
+	for _, n := range u.cells {
+		eng.Unregister(n)
+	}
+	for _, echoes := range u.echoes {
+		for _, n := range echoes {
+			eng.Unregister(n)
+		}
+	}
+	grid.RemoveChild(u.sheet)
+	eng.Unregister(u.sheet)
+	for _, n := range append(append([]*sprite.Node(nil), u.border...), u.shade...) {
+		grid.RemoveChild(n)
+		eng.Unregister(n)
+	}
+}
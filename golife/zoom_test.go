@@ -0,0 +1,103 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/vegacom/mobile/golife/life"
+	"golang.org/x/mobile/event"
+	"golang.org/x/mobile/geom"
+)
+
+func TestPinch(t *testing.T) {
+	gestureScene(t)
+	setPaused(true)
+	l := life.New(univ.cols, univ.rows)
+	l.SeedWith(0.3, life.Random, 1)
+	univ.adopt(l)
+
+	want := gameState(univ.life)
+	a, b := cellLoc(10, 20), cellLoc(20, 30)
+	finger(1, event.TouchStart, a)
+	finger(2, event.TouchStart, b)
+	if zooming == nil {
+		t.Fatalf("two fingers on the grid not pinching")
+	}
+	// The cell the first finger painted before the pinch started is painted back.
+	if s := gameState(univ.life); s != want || len(univ.edits.undo) != 0 {
+		t.Errorf("pinch started with the game %s and %d edits\nwant %s", s, len(univ.edits.undo), want)
+	}
+	mid := geom.Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+	i, j, _ := univ.cellAt(mid)
+
+	// Spreading the fingers twice as far apart zooms in twice around their midpoint.
+	a2 := geom.Point{X: 2*a.X - mid.X, Y: 2*a.Y - mid.Y}
+	b2 := geom.Point{X: 2*b.X - mid.X, Y: 2*b.Y - mid.Y}
+	finger(1, event.TouchMove, a2)
+	finger(2, event.TouchMove, b2)
+	if univ.zoom < 1.99 || univ.zoom > 2.01 {
+		t.Errorf("zoom %v after spreading the fingers twice as far apart, want 2", univ.zoom)
+	}
+	if i2, j2, ok := univ.cellAt(mid); !ok || i2 != i || j2 != j {
+		t.Errorf("cell %d,%d under the midpoint after zooming, want %d,%d", i2, j2, i, j)
+	}
+	if p := cellLoc(i, j); p.X < mid.X-2*cellSize || p.X > mid.X+2*cellSize {
+		t.Errorf("cell %d,%d shown at %v, away from the midpoint %v", i, j, p, mid)
+	}
+
+	// Moving both fingers pans the view: the cells follow them.
+	d := geom.Point{X: -3 * cellSize, Y: -5 * cellSize}
+	finger(1, event.TouchMove, geom.Point{X: a2.X + d.X, Y: a2.Y + d.Y})
+	finger(2, event.TouchMove, geom.Point{X: b2.X + d.X, Y: b2.Y + d.Y})
+	if i2, j2, _ := univ.cellAt(geom.Point{X: mid.X + d.X, Y: mid.Y + d.Y}); i2 != i || j2 != j {
+		t.Errorf("cell %d,%d under the moved midpoint, want %d,%d", i2, j2, i, j)
+	}
+	finger(1, event.TouchEnd, a2)
+	finger(2, event.TouchEnd, b2)
+
+	// The game is left alone, and a tap on the zoomed grid reaches the cell shown under it.
+	if s := gameState(univ.life); s != want {
+		t.Errorf("pinch changed the game to %s\nwant %s", s, want)
+	}
+	if w, h := univ.life.Bounds(); w != univ.cols || h != univ.rows {
+		t.Errorf("field of %dx%d after the pinch, want %dx%d", w, h, univ.cols, univ.rows)
+	}
+	univ.life.Set(15, 25, false)
+	finger(3, event.TouchStart, cellLoc(15, 25))
+	finger(3, event.TouchEnd, cellLoc(15, 25))
+	if !univ.life.Alive(15, 25) {
+		t.Errorf("tap on the zoomed cell 15,25 did not paint it")
+	}
+}
+
+func TestLook(t *testing.T) {
+	testScene(t, 320, 480)
+	// The zoom stays within the bounds of the cell size.
+	univ.look(100, geom.Point{}, cellLoc(0, 0))
+	if want := float32(maxCellSize / cellSize); univ.zoom != want {
+		t.Errorf("zoom %v, want at most %v", univ.zoom, want)
+	}
+	univ.look(0.01, geom.Point{}, cellLoc(0, 0))
+	if want := float32(minCellSize / cellSize); univ.zoom != want {
+		t.Errorf("zoom %v, want at least %v", univ.zoom, want)
+	}
+
+	// Zoomed in, the view pans up to the edges of the field, and no further.
+	univ.look(2, geom.Point{}, geom.Point{X: geom.Width, Y: geom.Height})
+	if univ.pan.X != 0 || univ.pan.Y != 0 {
+		t.Errorf("panned to %v past the top-left corner of the field", univ.pan)
+	}
+	univ.look(2, geom.Point{X: univ.fieldWidth(), Y: univ.fieldHeight()}, geom.Point{})
+	if x, y := univ.w-2*univ.fieldWidth(), univ.h-2*univ.fieldHeight(); univ.pan.X != x || univ.pan.Y != y {
+		t.Errorf("panned to %v past the bottom-right corner of the field, want %v,%v", univ.pan, x, y)
+	}
+	// The last column and row show at the edges of the screen area, and the cells zoomed out of it
+	// under the button bar are not reached.
+	if i, j, ok := univ.cellAt(geom.Point{X: geom.Width - 1, Y: geom.Height - 1}); !ok || i != univ.cols-1 || j != univ.rows-1 {
+		t.Errorf("cell %d,%d in the bottom-right corner of the screen, want %d,%d", i, j, univ.cols-1, univ.rows-1)
+	}
+	if _, _, ok := univ.cellAt(geom.Point{X: geom.Width / 2, Y: systemBarHeight + buttonBarHeight - 1}); ok {
+		t.Errorf("cell reached under the button bar")
+	}
+}
@@ -0,0 +1,198 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+// Package ui implements buttons for apps drawn with the sprite package of the Go mobile platform:
+// toggle buttons whose image follows the state of the app, pressed and disabled states, image
+// labels, and layout helpers.
+package ui
+
+import (
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+)
+
+// pressedScale is the size of a pressed button relative to its size released, see Bar.SetScale.
+const pressedScale = 0.85
+
+// A Button is an image that can be pressed, shown by a node of its own.
+type Button struct {
+	Name string         // Identifies the button in its bar, for instance by its image.
+	Rect geom.Rectangle // Uses absolute location.
+
+	bar               *Bar
+	node, label       *sprite.Node
+	labelHeight       geom.Pt
+	pressed, disabled bool
+}
+
+// A Bar is a set of buttons, for instance the button bar of an app.
+type Bar struct {
+	// Buttons are the buttons of the bar, in the order they were added.
+	Buttons []*Button
+	// Slop is how far beyond its rectangle a button is still hit, for users who find small targets
+	// hard to hit, see Find.
+	Slop geom.Pt
+
+	eng    sprite.Engine
+	parent *sprite.Node
+	origin geom.Point
+	face   func(b *Button) sprite.SubTex
+	scale  geom.Pt // See SetScale.
+}
+
+// NewBar returns an empty bar whose buttons are drawn by eng as children of parent, whose origin
+// is at the absolute location origin. The function face returns the image shown by a button. For
+// toggle buttons it depends on the state of the app, and it may tell disabled buttons, see
+// Refresh.
+func NewBar(eng sprite.Engine, parent *sprite.Node, origin geom.Point, face func(b *Button) sprite.SubTex) *Bar {
+	return &Bar{eng: eng, parent: parent, origin: origin, face: face, scale: 1}
+}
+
+// Add adds a button of the given name covering r, which uses absolute location, and returns it.
+func (bar *Bar) Add(name string, r geom.Rectangle) *Button {
+	b := &Button{Name: name, Rect: r, bar: bar, node: &sprite.Node{}}
+	bar.eng.Register(b.node)
+	bar.parent.AppendChild(b.node)
+	bar.Buttons = append(bar.Buttons, b)
+	b.place()
+	bar.eng.SetSubTex(b.node, bar.face(b))
+	return b
+}
+
+// Button returns the button of the given name, nil if none.
+func (bar *Bar) Button(name string) *Button {
+	for _, b := range bar.Buttons {
+		if b.Name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// Find returns the enabled button that contains point or else, if any, the closest one less than
+// Slop away from point, nil if none or if bar is nil.
+func (bar *Bar) Find(point geom.Point) *Button {
+	if bar == nil {
+		return nil
+	}
+	var found *Button
+	best := bar.Slop
+	for _, b := range bar.Buttons {
+		if b.disabled {
+			continue
+		}
+		if b.Contains(point) {
+			return b
+		}
+		if d := distance(b.Rect, point); d < best {
+			found, best = b, d
+		}
+	}
+	return found
+}
+
+// SetScale sets the size of the images of the buttons relative to their rectangles, 1 initially, for
+// instance to enlarge the icons, in which case they may overlap their neighbors.
+func (bar *Bar) SetScale(s geom.Pt) {
+	bar.scale = s
+	for _, b := range bar.Buttons {
+		b.place()
+	}
+}
+
+// Refresh shows the current image of every button, as returned by the face function of the bar.
+func (bar *Bar) Refresh() {
+	if bar == nil {
+		return
+	}
+	for _, b := range bar.Buttons {
+		bar.eng.SetSubTex(b.node, bar.face(b))
+	}
+}
+
+// Release removes the buttons from the scene and unregisters their nodes.
+func (bar *Bar) Release() {
+	if bar == nil {
+		return
+	}
+	for _, b := range bar.Buttons {
+		if b.label != nil {
+			b.node.RemoveChild(b.label)
+			bar.eng.Unregister(b.label)
+		}
+		bar.parent.RemoveChild(b.node)
+		bar.eng.Unregister(b.node)
+	}
+	bar.Buttons = nil
+}
+
+// Contains reports whether point is in the rectangle of b, its edges included.
+func (b *Button) Contains(point geom.Point) bool {
+	return Contains(b.Rect, point)
+}
+
+// Pressed reports whether b is shown pressed.
+func (b *Button) Pressed() bool { return b.pressed }
+
+// SetPressed shows b pressed, slightly shrunk around its center, or released.
+func (b *Button) SetPressed(p bool) {
+	b.pressed = p
+	b.place()
+}
+
+// Disabled reports whether b is disabled.
+func (b *Button) Disabled() bool { return b.disabled }
+
+// SetDisabled disables b, which Find then ignores, or enables it. A disabled button is released.
+func (b *Button) SetDisabled(d bool) {
+	b.disabled = d
+	if d && b.pressed {
+		b.SetPressed(false)
+	}
+	b.bar.eng.SetSubTex(b.node, b.bar.face(b))
+}
+
+// SetLabel shows t under b, as wide as b and h high, or removes the label of b if h is 0. The label
+// shrinks along with b when pressed.
+func (b *Button) SetLabel(t sprite.SubTex, h geom.Pt) {
+	eng := b.bar.eng
+	if h == 0 {
+		if b.label != nil {
+			b.node.RemoveChild(b.label)
+			eng.Unregister(b.label)
+			b.label = nil
+		}
+		return
+	}
+	if b.label == nil {
+		b.label = &sprite.Node{}
+		eng.Register(b.label)
+		b.node.AppendChild(b.label)
+	}
+	b.labelHeight = h
+	eng.SetSubTex(b.label, t)
+	b.place()
+}
+
+// place sets the transforms of the nodes of b, relative to the origin of its bar.
+func (b *Button) place() {
+	w, h := b.Rect.Max.X-b.Rect.Min.X, b.Rect.Max.Y-b.Rect.Min.Y
+	s := b.bar.scale
+	if b.pressed {
+		s *= pressedScale
+	}
+	x := b.Rect.Min.X - b.bar.origin.X + w*(1-s)/2
+	y := b.Rect.Min.Y - b.bar.origin.Y + h*(1-s)/2
+	eng := b.bar.eng
+	eng.SetTransform(b.node, f32.Affine{
+		{float32(w * s), 0, float32(x)},
+		{0, float32(h * s), float32(y)},
+	})
+	if b.label != nil {
+		// Relative to the node of b, whose side is 1.
+		eng.SetTransform(b.label, f32.Affine{
+			{1, 0, 0},
+			{0, float32(b.labelHeight / h), 1},
+		})
+	}
+}
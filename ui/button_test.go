@@ -0,0 +1,70 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package ui
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/mobile/f32"
+	"golang.org/x/mobile/geom"
+	"golang.org/x/mobile/sprite"
+	"golang.org/x/mobile/sprite/clock"
+)
+
+// A fakeEngine is a sprite engine counting its nodes, without drawing them.
+type fakeEngine struct {
+	nodes int // Number of nodes registered and not unregistered.
+}
+
+func (e *fakeEngine) Register(n *sprite.Node)                           { e.nodes++ }
+func (e *fakeEngine) Unregister(n *sprite.Node)                         { e.nodes-- }
+func (e *fakeEngine) LoadTexture(a image.Image) (sprite.Texture, error) { return nil, nil }
+func (e *fakeEngine) SetSubTex(n *sprite.Node, x sprite.SubTex)         {}
+func (e *fakeEngine) SetTransform(n *sprite.Node, m f32.Affine)         {}
+func (e *fakeEngine) Render(scene *sprite.Node, t clock.Time)           {}
+
+func TestFind(t *testing.T) {
+	eng := &fakeEngine{}
+	bar := NewBar(eng, &sprite.Node{}, geom.Point{}, func(b *Button) sprite.SubTex { return sprite.SubTex{} })
+	for k, r := range squares(40, 0, 0, 50, 0, 100, 0) {
+		bar.Add([]string{"a", "b", "c"}[k], r)
+	}
+	bar.Button("c").SetDisabled(true)
+	for _, test := range []struct {
+		name string
+		x, y geom.Pt
+		slop geom.Pt
+		want string // The name of the button found, empty if none.
+	}{
+		{"inside", 20, 20, 0, "a"},
+		{"edge", 40, 20, 0, "a"},
+		{"between", 45, 20, 0, ""},
+		{"between, closer to the right", 47, 20, 5, "b"},
+		{"beyond the slop", 20, 50, 5, ""},
+		{"within the slop", 20, 44, 5, "a"},
+		{"disabled", 120, 20, 0, ""},
+		{"disabled, next to another", 95, 20, 10, "b"},
+	} {
+		bar.Slop = test.slop
+		var got string
+		if b := bar.Find(geom.Point{X: test.x, Y: test.y}); b != nil {
+			got = b.Name
+		}
+		if got != test.want {
+			t.Errorf("%s: found %q, want %q", test.name, got, test.want)
+		}
+	}
+	if b := bar.Button("b"); !b.Contains(geom.Point{X: 50, Y: 40}) || b.Contains(geom.Point{X: 49, Y: 0}) {
+		t.Errorf("rectangle of %q %v not hit at its edges only", b.Name, b.Rect)
+	}
+
+	var none *Bar
+	if b := none.Find(geom.Point{}); b != nil {
+		t.Errorf("found %q in no bar", b.Name)
+	}
+	bar.Release()
+	if eng.nodes != 0 || len(bar.Buttons) != 0 {
+		t.Errorf("%d nodes and %d buttons left once released", eng.nodes, len(bar.Buttons))
+	}
+}
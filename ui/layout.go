@@ -0,0 +1,73 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package ui
+
+import (
+	"math"
+
+	"golang.org/x/mobile/geom"
+)
+
+// Contains reports whether point is in r, its edges included.
+func Contains(r geom.Rectangle, point geom.Point) bool {
+	return r.Min.X <= point.X && point.X <= r.Max.X && r.Min.Y <= point.Y && point.Y <= r.Max.Y
+}
+
+// distance returns the distance from point to the closest point of r, 0 if r contains it.
+func distance(r geom.Rectangle, point geom.Point) geom.Pt {
+	dx := math.Max(0, math.Max(float64(r.Min.X-point.X), float64(point.X-r.Max.X)))
+	dy := math.Max(0, math.Max(float64(r.Min.Y-point.Y), float64(point.Y-r.Max.Y)))
+	return geom.Pt(math.Hypot(dx, dy))
+}
+
+// Row returns the rectangles of n squares of the given side laid out left to right, sep apart and
+// centered horizontally on the screen, with their top at y.
+func Row(n int, side, sep, y geom.Pt) []geom.Rectangle {
+	w := geom.Pt(n)*side + geom.Pt(n-1)*sep
+	return Grid(n, n, side, sep, geom.Point{X: (geom.Width - w) / 2, Y: y})
+}
+
+// BottomBar returns the rectangles of a row of n squares whose bottom is inset from the bottom of
+// the screen, see Row.
+func BottomBar(n int, side, sep, inset geom.Pt) []geom.Rectangle {
+	return Row(n, side, sep, geom.Height-inset-side)
+}
+
+// Grid returns the rectangles of n squares of the given side laid out in rows of cols squares, left
+// to right then top to bottom, sep apart, starting with the top-left one at origin.
+func Grid(n, cols int, side, sep geom.Pt, origin geom.Point) []geom.Rectangle {
+	rs := make([]geom.Rectangle, n)
+	for k := range rs {
+		x := origin.X + geom.Pt(k%cols)*(side+sep)
+		y := origin.Y + geom.Pt(k/cols)*(side+sep)
+		rs[k] = geom.Rectangle{Min: geom.Point{X: x, Y: y}, Max: geom.Point{X: x + side, Y: y + side}}
+	}
+	return rs
+}
+
+// Mirror returns r mirrored across the vertical axis of bounds, as for a right-to-left layout.
+func Mirror(r, bounds geom.Rectangle) geom.Rectangle {
+	x := bounds.Min.X + bounds.Max.X - r.Max.X
+	return geom.Rectangle{Min: geom.Point{X: x, Y: r.Min.Y}, Max: geom.Point{X: x + r.Max.X - r.Min.X, Y: r.Max.Y}}
+}
+
+// Wrap returns the rectangles of n squares of the given side laid out in rows of at most cols
+// squares, as even as they can be, each centered horizontally on the screen, see Row. The top of
+// the first row is at y.
+func Wrap(n, cols int, side, sep, y geom.Pt) []geom.Rectangle {
+	rows := (n + cols - 1) / cols
+	if rows == 0 {
+		return nil
+	}
+	per := (n + rows - 1) / rows
+	var rs []geom.Rectangle
+	for k := 0; k < n; k += per {
+		m := per
+		if k+m > n {
+			m = n - k
+		}
+		rs = append(rs, Row(m, side, sep, y)...)
+		y += side + sep
+	}
+	return rs
+}
@@ -0,0 +1,89 @@
+// All rights reserved. Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/mobile/geom"
+)
+
+// rect returns the rectangle from (x0, y0) to (x1, y1).
+func rect(x0, y0, x1, y1 geom.Pt) geom.Rectangle {
+	return geom.Rectangle{Min: geom.Point{X: x0, Y: y0}, Max: geom.Point{X: x1, Y: y1}}
+}
+
+// squares returns the squares of the given side whose top-left corners are at the pairs of xy.
+func squares(side geom.Pt, xy ...geom.Pt) []geom.Rectangle {
+	var rs []geom.Rectangle
+	for k := 0; k < len(xy); k += 2 {
+		rs = append(rs, rect(xy[k], xy[k+1], xy[k]+side, xy[k+1]+side))
+	}
+	return rs
+}
+
+func TestLayouts(t *testing.T) {
+	defer func(w, h geom.Pt) { geom.Width, geom.Height = w, h }(geom.Width, geom.Height)
+	geom.Width, geom.Height = 320, 480
+	for _, test := range []struct {
+		name string
+		got  []geom.Rectangle
+		want []geom.Rectangle
+	}{
+		{"row", Row(3, 40, 10, 100), squares(40, 90, 100, 140, 100, 190, 100)},
+		{"row of one", Row(1, 40, 10, 0), squares(40, 140, 0)},
+		{"bottom bar", BottomBar(2, 40, 10, 20), squares(40, 115, 420, 165, 420)},
+		{"grid", Grid(5, 2, 10, 5, geom.Point{X: 1, Y: 2}), squares(10, 1, 2, 16, 2, 1, 17, 16, 17, 1, 32)},
+		{"grid of one row", Grid(2, 4, 10, 0, geom.Point{}), squares(10, 0, 0, 10, 0)},
+		// The rows as even as they can be, each centered.
+		{"wrap", Wrap(5, 3, 40, 10, 0), squares(40, 90, 0, 140, 0, 190, 0, 115, 50, 165, 50)},
+		{"wrap evenly", Wrap(4, 3, 40, 10, 0), squares(40, 115, 0, 165, 0, 115, 50, 165, 50)},
+		{"wrap in one row", Wrap(2, 3, 40, 10, 5), squares(40, 115, 5, 165, 5)},
+		{"wrap nothing", Wrap(0, 3, 40, 10, 0), nil},
+	} {
+		if fmt.Sprint(test.got) != fmt.Sprint(test.want) {
+			t.Errorf("%s: %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestMirror(t *testing.T) {
+	for _, test := range []struct {
+		r, bounds, want geom.Rectangle
+	}{
+		{rect(10, 0, 30, 5), rect(0, 0, 100, 50), rect(70, 0, 90, 5)},
+		{rect(10, 0, 30, 5), rect(20, 0, 120, 50), rect(110, 0, 130, 5)},
+		// Centered, a rectangle is its own mirror.
+		{rect(40, 7, 60, 9), rect(0, 0, 100, 50), rect(40, 7, 60, 9)},
+	} {
+		got := Mirror(test.r, test.bounds)
+		if got != test.want {
+			t.Errorf("%v mirrored in %v: %v, want %v", test.r, test.bounds, got, test.want)
+		}
+		if back := Mirror(got, test.bounds); back != test.r {
+			t.Errorf("%v mirrored twice in %v: %v", test.r, test.bounds, back)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	r := rect(10, 20, 30, 40)
+	for _, test := range []struct {
+		x, y geom.Pt
+		want bool
+	}{
+		{20, 30, true},
+		{10, 20, true}, // The edges are included.
+		{30, 40, true},
+		{10, 40, true},
+		{9.5, 30, false},
+		{30.5, 30, false},
+		{20, 19.5, false},
+		{20, 40.5, false},
+	} {
+		if got := Contains(r, geom.Point{X: test.x, Y: test.y}); got != test.want {
+			t.Errorf("(%v, %v) in %v: %v, want %v", test.x, test.y, r, got, test.want)
+		}
+	}
+}